@@ -0,0 +1,36 @@
+#!/bin/bash
+
+# Generate client SDKs from docs/api/openapi.yaml with openapi-generator
+# Usage: clientgen/generate.sh <go|typescript>
+# Output is written to clientgen/out/<language> (ignored by git)
+
+set -e
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+PROJECT_ROOT="$(cd "$SCRIPT_DIR/.." && pwd)"
+
+# Pin the generator so regenerated SDKs are reproducible
+GENERATOR_IMAGE="${OPENAPI_GENERATOR_IMAGE:-openapitools/openapi-generator-cli:v7.8.0}"
+
+LANGUAGE="$1"
+case "$LANGUAGE" in
+    go|typescript)
+        ;;
+    *)
+        echo "Usage: $0 <go|typescript>" >&2
+        exit 1
+        ;;
+esac
+
+OUT_DIR="clientgen/out/$LANGUAGE"
+rm -rf "$PROJECT_ROOT/$OUT_DIR"
+
+docker run --rm \
+    -u "$(id -u):$(id -g)" \
+    -v "$PROJECT_ROOT:/local" \
+    "$GENERATOR_IMAGE" generate \
+    -i /local/docs/api/openapi.yaml \
+    -c "/local/clientgen/$LANGUAGE.yaml" \
+    -o "/local/$OUT_DIR"
+
+echo "Generated $LANGUAGE SDK in $OUT_DIR"
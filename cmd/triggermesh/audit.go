@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+	"triggermesh/pkg/triggermesh"
+)
+
+// auditUsage lists the `triggermesh audit` subcommands
+const auditUsage = `usage:
+  triggermesh audit prune -before date [-dry-run] [-vacuum] [-db path | -config path]
+  triggermesh audit export [-since date] [-until date] [-out path] [-db path | -config path]
+  triggermesh audit stats [-json] [-db path | -config path]
+
+Dates are YYYY-MM-DD (UTC midnight) or RFC 3339. The commands open the database
+directly and do not need the server to be running.`
+
+// auditTopJobs is the number of jobs listed by `triggermesh audit stats`
+const auditTopJobs = 10
+
+// runAudit implements the `triggermesh audit` subcommands and returns the exit code
+func runAudit(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, auditUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("audit "+args[0], flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to the configuration file, used for database.path")
+	dbPath := fs.String("db", "", "Database file (overrides -config)")
+	before := fs.String("before", "", "Prune entries older than this date")
+	dryRun := fs.Bool("dry-run", false, "Report how many entries would be pruned without deleting them")
+	vacuum := fs.Bool("vacuum", false, "Reclaim disk space after pruning")
+	since := fs.String("since", "", "Export entries at or after this date")
+	until := fs.String("until", "", "Export entries before this date")
+	out := fs.String("out", "", "Export file (default: stdout)")
+	asJSON := fs.Bool("json", false, "Print statistics as JSON")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	var code int
+	switch args[0] {
+	case "prune":
+		if *before == "" {
+			fmt.Fprintln(os.Stderr, auditUsage)
+			return 2
+		}
+		cutoff, err := parseAuditDate(*before)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -before: %v\n", err)
+			return 2
+		}
+		code = withAuditDatabase(*dbPath, *configPath, func() error {
+			return pruneAudit(cutoff, *dryRun, *vacuum)
+		})
+	case "export":
+		start, end := time.Time{}, time.Now().Add(time.Second)
+		var err error
+		if *since != "" {
+			if start, err = parseAuditDate(*since); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid -since: %v\n", err)
+				return 2
+			}
+		}
+		if *until != "" {
+			if end, err = parseAuditDate(*until); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid -until: %v\n", err)
+				return 2
+			}
+		}
+		code = withAuditDatabase(*dbPath, *configPath, func() error {
+			return exportAudit(start, end, *out)
+		})
+	case "stats":
+		code = withAuditDatabase(*dbPath, *configPath, func() error {
+			return printAuditStats(os.Stdout, *asJSON)
+		})
+	default:
+		fmt.Fprintln(os.Stderr, auditUsage)
+		return 2
+	}
+	return code
+}
+
+// withAuditDatabase opens the database given by -db, or database.path of the configuration,
+// runs fn, and returns the exit code
+func withAuditDatabase(dbPath, configPath string, fn func() error) int {
+	if dbPath == "" {
+		cfg, err := triggermesh.LoadConfig(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			return 1
+		}
+		dbPath = cfg.Database.Path
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Database not found: %v\n", err)
+		return 1
+	}
+
+	if err := storage.Init(dbPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open database: %v\n", err)
+		return 1
+	}
+	defer storage.Close()
+
+	if err := fn(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// parseAuditDate parses a YYYY-MM-DD date (UTC midnight) or an RFC 3339 time
+func parseAuditDate(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("expected YYYY-MM-DD or an RFC 3339 time")
+	}
+	return t, nil
+}
+
+// pruneAudit deletes audit entries older than cutoff
+func pruneAudit(cutoff time.Time, dryRun, vacuum bool) error {
+	if dryRun {
+		count, err := storage.CountAuditLogsInRange(time.Time{}, cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to count audit logs: %w", err)
+		}
+		fmt.Printf("Would prune %d audit entries before %s\n", count, cutoff.Format(time.RFC3339))
+		return nil
+	}
+
+	deleted, err := storage.DeleteAuditLogsInRange(time.Time{}, cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to prune audit logs: %w", err)
+	}
+	fmt.Printf("Pruned %d audit entries before %s\n", deleted, cutoff.Format(time.RFC3339))
+
+	if vacuum {
+		if err := storage.Vacuum(); err != nil {
+			return fmt.Errorf("failed to vacuum database: %w", err)
+		}
+		fmt.Println("Reclaimed free space")
+	}
+	return nil
+}
+
+// exportAudit writes the audit entries with start <= timestamp < end as NDJSON,
+// the format of the audit archive
+func exportAudit(start, end time.Time, path string) error {
+	var w io.Writer = os.Stdout
+	if path != "" {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+	count := 0
+	if err := storage.EachAuditLog(start, end, func(log models.AuditLog) error {
+		count++
+		return encoder.Encode(log)
+	}); err != nil {
+		return fmt.Errorf("failed to export audit logs: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "Exported %d audit entries to %s\n", count, path)
+	}
+	return nil
+}
+
+// printAuditStats prints a summary of the audit table
+func printAuditStats(w io.Writer, asJSON bool) error {
+	stats, err := storage.GetAuditStats(auditTopJobs)
+	if err != nil {
+		return fmt.Errorf("failed to read audit statistics: %w", err)
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	fmt.Fprintf(w, "Entries: %d\n", stats.RowCount)
+	if stats.Oldest != nil && stats.Newest != nil {
+		fmt.Fprintf(w, "Range:   %s to %s\n", stats.Oldest.Format(time.RFC3339), stats.Newest.Format(time.RFC3339))
+	}
+	printAuditCounts(w, "By result", stats.ByResult)
+	printAuditCounts(w, "By source", stats.BySource)
+	if len(stats.TopJobs) > 0 {
+		fmt.Fprintln(w, "Top jobs:")
+		for _, job := range stats.TopJobs {
+			fmt.Fprintf(w, "  %-40s %d\n", job.JobName, job.Count)
+		}
+	}
+	return nil
+}
+
+// printAuditCounts prints counts sorted by key; an empty key is shown as (none)
+func printAuditCounts(w io.Writer, title string, counts map[string]int64) {
+	if len(counts) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(w, "%s:\n", title)
+	for _, key := range keys {
+		label := key
+		if label == "" {
+			label = "(none)"
+		}
+		fmt.Fprintf(w, "  %-40s %d\n", label, counts[key])
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"triggermesh/pkg/triggermesh"
+)
+
+// runConfig implements the `triggermesh config` subcommands and returns the exit code
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "print-effective" {
+		fmt.Fprintln(os.Stderr, "usage: triggermesh config print-effective [-config path]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config print-effective", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to the configuration file")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, err := triggermesh.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	fmt.Println("# Effective configuration (file + environment + defaults), secrets masked")
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg.Masked()); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode configuration: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"triggermesh/internal/storage"
+	"triggermesh/pkg/triggermesh"
+)
+
+// dbUsage lists the `triggermesh db` subcommands
+const dbUsage = `usage:
+  triggermesh db backup -out path [-config path]
+  triggermesh db restore -from path [-config path]
+  triggermesh db verify -file path`
+
+// runDB implements the `triggermesh db` subcommands and returns the exit code
+func runDB(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, dbUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("db "+args[0], flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "Path to the configuration file")
+	out := fs.String("out", "", "Backup file to write (must not exist)")
+	from := fs.String("from", "", "Backup file to restore")
+	file := fs.String("file", "", "Database file to verify")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	switch args[0] {
+	case "backup":
+		if *out == "" {
+			fmt.Fprintln(os.Stderr, dbUsage)
+			return 2
+		}
+		cfg, err := triggermesh.LoadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			return 1
+		}
+		if err := triggermesh.Backup(cfg, *out); err != nil {
+			fmt.Fprintf(os.Stderr, "Backup failed: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Backed up %s to %s\n", cfg.Database.Path, *out)
+	case "restore":
+		if *from == "" {
+			fmt.Fprintln(os.Stderr, dbUsage)
+			return 2
+		}
+		cfg, err := triggermesh.LoadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			return 1
+		}
+		if err := triggermesh.Restore(cfg, *from); err != nil {
+			fmt.Fprintf(os.Stderr, "Restore failed: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Restored %s from %s\n", cfg.Database.Path, *from)
+	case "verify":
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, dbUsage)
+			return 2
+		}
+		if err := storage.VerifyDatabase(*file); err != nil {
+			fmt.Fprintf(os.Stderr, "Verification failed: %v\n", err)
+			return 1
+		}
+		fmt.Printf("%s is a valid database\n", *file)
+	default:
+		fmt.Fprintln(os.Stderr, dbUsage)
+		return 2
+	}
+	return 0
+}
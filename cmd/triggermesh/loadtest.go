@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"triggermesh/internal/loadtest"
+	"triggermesh/internal/logger"
+)
+
+// runLoadTest implements the `triggermesh loadtest` subcommand and returns the exit code
+func runLoadTest(args []string) int {
+	fs := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	targetURL := fs.String("url", "", "TriggerMesh base URL (default: in-process server with a mock Jenkins)")
+	apiKey := fs.String("api-key", "", "API key used when -url is set")
+	job := fs.String("job", "loadtest-job", "Job name to trigger")
+	requests := fs.Int("requests", 1000, "Total number of trigger requests")
+	concurrency := fs.Int("concurrency", 10, "Number of concurrent workers")
+	jenkinsLatency := fs.Duration("jenkins-latency", 0, "Artificial mock Jenkins latency (in-process mode only)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *targetURL != "" && *apiKey == "" {
+		fmt.Fprintln(os.Stderr, "-api-key is required when -url is set")
+		return 2
+	}
+
+	// Keep per-request logs of the in-process server out of the report
+	logger.Init("error")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	start := time.Now()
+	report, err := loadtest.Run(ctx, loadtest.Options{
+		TargetURL:      *targetURL,
+		APIKey:         *apiKey,
+		Job:            *job,
+		Requests:       *requests,
+		Concurrency:    *concurrency,
+		JenkinsLatency: *jenkinsLatency,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Load test failed after %s: %v\n", time.Since(start).Round(time.Millisecond), err)
+		return 1
+	}
+
+	fmt.Println(report.String())
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -4,27 +4,51 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
-	"time"
 
-	"triggermesh/internal/api"
 	"triggermesh/internal/config"
-	"triggermesh/internal/engine/jenkins"
 	"triggermesh/internal/logger"
-	"triggermesh/internal/storage"
+	"triggermesh/pkg/triggermesh"
 )
 
 func main() {
+	// Dispatch subcommands; without one, run the server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "loadtest":
+			os.Exit(runLoadTest(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		case "db":
+			os.Exit(runDB(os.Args[2:]))
+		case "audit":
+			os.Exit(runAudit(os.Args[2:]))
+		}
+	}
+
+	// The Windows Service Control Manager stops the service instead of sending signals
+	if runWindowsService(runServer) {
+		return
+	}
+
+	// Serve until an interrupt signal triggers a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	runServer(ctx)
+}
+
+// runServer starts the HTTP server and blocks until ctx is cancelled and the server has shut down
+func runServer(ctx context.Context) {
 	// Parse command line flags
 	configPath := flag.String("config", "config.yaml", "Path to the configuration file")
+	migrateOnly := flag.Bool("migrate-only", false, "Apply database migrations and exit (e.g. as an init container)")
+	restoreFrom := flag.String("restore-from", "", "Replace the database with this verified backup before starting")
 	flag.Parse()
 
 	// Load configuration
-	cfg, err := config.Load(*configPath)
+	cfg, err := triggermesh.LoadConfig(*configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
 		os.Exit(1)
@@ -35,68 +59,32 @@ func main() {
 	logger.Init(loggerLevel)
 	logger.Info("Starting TriggerMesh service", "log_level", loggerLevel)
 
-	// Initialize database
-	if err := storage.Init(cfg.Database.Path); err != nil {
-		logger.Error("Failed to initialize database", "error", err)
-		os.Exit(1)
-	}
-	defer storage.Close()
-
-	// Initialize Jenkins client and engine
-	jenkinsClient := jenkins.NewClient(cfg.Jenkins)
-	jenkinsEngine := jenkins.NewTrigger(jenkinsClient)
-
-	// Initialize router
-	router := api.NewRouter(*cfg, jenkinsEngine)
-
-	// Read PORT from environment variable if set
-	port := cfg.Server.Port
-	if envPort := os.Getenv("PORT"); envPort != "" {
-		if p, err := strconv.Atoi(envPort); err == nil && p > 0 {
-			port = p
+	if *restoreFrom != "" {
+		if err := triggermesh.Restore(cfg, *restoreFrom); err != nil {
+			logger.Error("Restore failed", "error", err, "backup", *restoreFrom)
+			os.Exit(1)
 		}
+		logger.Info("Database restored from backup", "path", cfg.Database.Path, "backup", *restoreFrom)
 	}
 
-	// Create HTTP server
-	server := &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, port),
-		Handler: router,
-	}
-
-	// Start the server in a goroutine
-	go func() {
-		logger.Info("Server listening", "addr", server.Addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Failed to start server", "error", err)
+	if *migrateOnly {
+		if err := triggermesh.Migrate(cfg); err != nil {
+			logger.Error("Migration failed", "error", err)
 			os.Exit(1)
 		}
-	}()
-
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Shutting down server...")
-
-	// Create a context with timeout for graceful shutdown
-	// Use 30 seconds for production to allow long-running requests to complete
-	shutdownTimeout := 30 * time.Second
-	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
-	defer cancel()
-
-	logger.Info("Initiating graceful shutdown", "timeout", shutdownTimeout.String())
+		logger.Info("Database migrations applied", "path", cfg.Database.Path)
+		return
+	}
 
-	// Shutdown the server gracefully
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Error("Server forced to shutdown", "error", err, "timeout", shutdownTimeout.String())
-	} else {
-		logger.Info("Server shutdown gracefully")
+	server, err := triggermesh.NewServer(cfg)
+	if err != nil {
+		logger.Error("Failed to initialize server", "error", err)
+		os.Exit(1)
 	}
 
-	// Close the database connection
-	if err := storage.Close(); err != nil {
-		logger.Error("Failed to close database connection", "error", err)
+	if err := server.Run(ctx); err != nil {
+		logger.Error("Server stopped with error", "error", err)
+		os.Exit(1)
 	}
 
 	logger.Info("Server stopped")
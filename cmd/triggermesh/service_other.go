@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import "context"
+
+// runWindowsService reports false: only Windows has a Service Control Manager
+func runWindowsService(func(ctx context.Context)) bool {
+	return false
+}
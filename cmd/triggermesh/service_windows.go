@@ -0,0 +1,73 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// serviceName is the name TriggerMesh reports to the Service Control Manager
+const serviceName = "TriggerMesh"
+
+// runWindowsService runs the server under the Service Control Manager when started as a
+// Windows service and reports true; it reports false for interactive runs
+// Register the service with, for example:
+//
+//	sc.exe create TriggerMesh binPath= "C:\TriggerMesh\triggermesh.exe -config C:\TriggerMesh\config.yaml" start= auto
+func runWindowsService(run func(ctx context.Context)) bool {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to detect the Windows service environment: %v\n", err)
+		os.Exit(1)
+	}
+	if !isService {
+		return false
+	}
+
+	if err := svc.Run(serviceName, &windowsService{run: run}); err != nil {
+		fmt.Fprintf(os.Stderr, "Windows service failed: %v\n", err)
+		os.Exit(1)
+	}
+	return true
+}
+
+// windowsService adapts the server to the Service Control Manager
+type windowsService struct {
+	run func(ctx context.Context)
+}
+
+// Execute runs the server until the Service Control Manager asks it to stop, then shuts it down gracefully
+func (s *windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.run(ctx)
+	}()
+
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case <-done:
+			// The server stopped on its own, e.g. the listener failed
+			cancel()
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+				<-done
+				return false, 0
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/workpool"
+)
+
+// defaultNotifyConcurrency is the number of notifiers an alert is delivered to at once unless
+// SetConcurrency is called
+const defaultNotifyConcurrency = 4
+
+// Scopes an alert can cover
+const (
+	ScopeJob    = "job"
+	ScopeEngine = "engine"
+)
+
+// Alert describes a failure-rate threshold breach
+type Alert struct {
+	Scope         string    `json:"scope"` // job or engine
+	Engine        string    `json:"engine"`
+	Job           string    `json:"job,omitempty"` // Empty for engine-wide alerts
+	Failures      int       `json:"failures"`
+	Triggers      int       `json:"triggers"`
+	FailureRate   float64   `json:"failure_rate"`
+	Threshold     float64   `json:"threshold"`
+	WindowSeconds int       `json:"window_seconds"`
+	FiredAt       time.Time `json:"fired_at"`
+}
+
+// Summary returns a one-line human readable description of the alert
+func (a Alert) Summary() string {
+	target := fmt.Sprintf("engine %s", a.Engine)
+	if a.Scope == ScopeJob {
+		target = fmt.Sprintf("job %s on %s", a.Job, a.Engine)
+	}
+	return fmt.Sprintf("TriggerMesh: trigger failure rate for %s is %.0f%% (%d of %d in the last %ds, threshold %.0f%%)",
+		target, a.FailureRate*100, a.Failures, a.Triggers, a.WindowSeconds, a.Threshold*100)
+}
+
+// outcome is a single trigger result in a rolling window
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+// series is the rolling trigger history of a job or engine
+type series struct {
+	outcomes  []outcome
+	lastFired time.Time
+}
+
+// Evaluator watches rolling trigger failure rates per job and per engine
+// and notifies when a rate crosses the threshold, at most once per cooldown
+type Evaluator struct {
+	mu          sync.Mutex
+	series      map[string]*series
+	window      time.Duration
+	minTriggers int
+	threshold   float64
+	cooldown    time.Duration
+	notifiers   []Notifier
+	now         func() time.Time
+
+	deliveries    *workpool.Pool // Alerts being delivered, so Close can wait for them
+	notifyLimit   int            // Notifiers an alert is delivered to at once
+	notifyTimeout time.Duration  // Longest a single notification may take
+}
+
+// NewEvaluator creates an evaluator from the alerts configuration
+func NewEvaluator(cfg config.AlertsConfig) (*Evaluator, error) {
+	notifiers := make([]Notifier, 0, len(cfg.Notifiers))
+	for _, notifierCfg := range cfg.Notifiers {
+		notifier, err := NewNotifier(notifierCfg)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, notifier)
+	}
+
+	return &Evaluator{
+		series:      make(map[string]*series),
+		window:      time.Duration(cfg.Window) * time.Second,
+		minTriggers: cfg.MinTriggers,
+		threshold:   cfg.FailureRate,
+		cooldown:    time.Duration(cfg.Cooldown) * time.Second,
+		notifiers:   notifiers,
+		now:         time.Now,
+
+		deliveries:    workpool.New(context.Background(), 0, 0),
+		notifyLimit:   defaultNotifyConcurrency,
+		notifyTimeout: notifyTimeout,
+	}, nil
+}
+
+// SetConcurrency sets the number of notifiers an alert is delivered to at once and how long each
+// notification may take; call it before recording outcomes
+func (e *Evaluator) SetConcurrency(limit int, timeout time.Duration) {
+	e.notifyLimit = limit
+	e.notifyTimeout = timeout
+}
+
+// Close waits for the alerts being delivered
+func (e *Evaluator) Close() {
+	_ = e.deliveries.Wait()
+}
+
+// Record adds a trigger outcome for the job and its engine and fires any alerts it causes
+func (e *Evaluator) Record(engineName, job string, failed bool) {
+	e.mu.Lock()
+	now := e.now()
+	var alerts []Alert
+	if alert, ok := e.record(now, ScopeJob, engineName, job, failed); ok {
+		alerts = append(alerts, alert)
+	}
+	if alert, ok := e.record(now, ScopeEngine, engineName, "", failed); ok {
+		alerts = append(alerts, alert)
+	}
+	e.mu.Unlock()
+
+	for _, alert := range alerts {
+		logger.Warn("Trigger failure rate alert", "scope", alert.Scope, "engine", alert.Engine, "job", alert.Job,
+			"failure_rate", alert.FailureRate, "failures", alert.Failures, "triggers", alert.Triggers)
+		alert := alert
+		e.deliveries.Go(func(context.Context) error {
+			e.notify(alert)
+			return nil
+		})
+	}
+}
+
+// record updates one series and returns an alert if it breached; the caller holds e.mu
+func (e *Evaluator) record(now time.Time, scope, engineName, job string, failed bool) (Alert, bool) {
+	key := scope + "\x00" + engineName + "\x00" + job
+	s, ok := e.series[key]
+	if !ok {
+		s = &series{}
+		e.series[key] = s
+	}
+
+	// Drop outcomes that left the window
+	cutoff := now.Add(-e.window)
+	kept := s.outcomes[:0]
+	for _, o := range s.outcomes {
+		if o.at.After(cutoff) {
+			kept = append(kept, o)
+		}
+	}
+	s.outcomes = append(kept, outcome{at: now, failed: failed})
+
+	failures := 0
+	for _, o := range s.outcomes {
+		if o.failed {
+			failures++
+		}
+	}
+	triggers := len(s.outcomes)
+	rate := float64(failures) / float64(triggers)
+
+	if triggers < e.minTriggers || rate < e.threshold {
+		return Alert{}, false
+	}
+	if !s.lastFired.IsZero() && now.Sub(s.lastFired) < e.cooldown {
+		return Alert{}, false
+	}
+	s.lastFired = now
+
+	return Alert{
+		Scope:         scope,
+		Engine:        engineName,
+		Job:           job,
+		Failures:      failures,
+		Triggers:      triggers,
+		FailureRate:   rate,
+		Threshold:     e.threshold,
+		WindowSeconds: int(e.window / time.Second),
+		FiredAt:       now,
+	}, true
+}
+
+// notify delivers an alert to every notifier, logging failures
+// A slow notifier only holds up its own delivery, each bounded by the notification timeout
+func (e *Evaluator) notify(alert Alert) {
+	pool := workpool.New(context.Background(), e.notifyLimit, e.notifyTimeout)
+	for _, notifier := range e.notifiers {
+		notifier := notifier
+		pool.Go(func(ctx context.Context) error {
+			if err := notifier.Notify(ctx, alert); err != nil {
+				logger.Error("Failed to deliver alert", "error", err, "scope", alert.Scope, "engine", alert.Engine, "job", alert.Job)
+			}
+			return nil
+		})
+	}
+	_ = pool.Wait()
+}
@@ -0,0 +1,94 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/signature"
+	"triggermesh/internal/version"
+)
+
+// notifyTimeout bounds a single notification delivery
+const notifyTimeout = 10 * time.Second
+
+// Notification is a message delivered by notifiers, such as an Alert
+// Slack receives its summary; webhooks receive the notification as JSON
+type Notification interface {
+	Summary() string
+}
+
+// Notifier delivers notifications to an external system
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// NewNotifier creates the notifier described by the configuration
+func NewNotifier(cfg config.AlertNotifierConfig) (Notifier, error) {
+	client := &http.Client{Timeout: notifyTimeout}
+	switch cfg.Type {
+	case "slack":
+		return &SlackNotifier{url: cfg.URL, secret: cfg.Secret, client: client}, nil
+	case "webhook":
+		return &WebhookNotifier{url: cfg.URL, secret: cfg.Secret, client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type: %s", cfg.Type)
+	}
+}
+
+// SlackNotifier posts notifications to a Slack incoming webhook
+type SlackNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// Notify implements Notifier
+func (n *SlackNotifier) Notify(ctx context.Context, notification Notification) error {
+	return postJSON(ctx, n.client, n.url, n.secret, map[string]string{"text": notification.Summary()})
+}
+
+// WebhookNotifier posts notifications as JSON to a generic webhook
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// Notify implements Notifier
+func (n *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	return postJSON(ctx, n.client, n.url, n.secret, notification)
+}
+
+// postJSON posts the payload as JSON, signed when a secret is set, and fails on non-2xx responses
+func postJSON(ctx context.Context, client *http.Client, url, secret string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create alert request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent())
+	signature.SetHeader(req, secret, body)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send alert: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("alert endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -2,20 +2,32 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 
 	"triggermesh/internal/api/middleware"
 	"triggermesh/internal/logger"
 	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
 )
 
 // AuditHandler handles audit log-related API requests
-type AuditHandler struct{}
+type AuditHandler struct {
+	logReads bool // Record audit log reads in the configuration audit
+	tails    *auditTails
+}
 
 // NewAuditHandler creates a new AuditHandler instance
 func NewAuditHandler() *AuditHandler {
-	return &AuditHandler{}
+	return &AuditHandler{tails: &auditTails{done: make(chan struct{})}}
+}
+
+// SetLogReads enables recording who read the audit log, with the filters used and the rows returned
+func (h *AuditHandler) SetLogReads(enabled bool) {
+	h.logReads = enabled
 }
 
 // GetAuditLogs handles the GET /api/v1/audit request
@@ -47,22 +59,233 @@ func (h *AuditHandler) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
 	// Get request ID for logging
 	requestID := middleware.GetRequestID(r)
 
+	// Parse label filters (label=key:value, repeatable, all must match)
+	labels, message := parseLabelFilters(r.URL.Query()["label"])
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	location, message := requestLocation(r)
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+	fields, message := parseFields(r, models.AuditLog{})
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	// Cursors continue after the last entry of the previous page; new entries do not shift pages
+	cursor, message := parseAfterCursor(r, 1)
+	if message == "" && cursor != nil && cursor[0] == 0 {
+		message = "Invalid cursor"
+	}
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	filter := models.AuditFilter{Labels: labels, ChangeRef: r.URL.Query().Get("change_ref"), Commit: strings.ToLower(r.URL.Query().Get("commit"))}
+	if message := validateChangeRef(filter.ChangeRef); message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+	if message := validateCommit(filter.Commit); message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+	if cursor != nil {
+		filter.BeforeID = cursor[0]
+	}
+
 	// Get audit logs from database
-	logs, err := storage.GetAuditLogs(limit, offset)
+	var logs []models.AuditLog
+	var err error
+	if !filter.IsEmpty() {
+		logs, err = storage.QueryAuditLogs(filter, limit, offset)
+	} else {
+		logs, err = storage.GetAuditLogs(limit, offset)
+	}
 	if err != nil {
 		logger.Error("Failed to get audit logs", "error", err, "request_id", requestID)
 		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get audit logs")
 		return
 	}
+	// Keys restricted to jobs only see the triggers of those jobs; pages may then hold fewer entries
+	// API keys are never returned, so an audit reader cannot act as the keys it sees
+	principal := middleware.GetPrincipal(r)
+	visible := make([]models.AuditLog, 0, len(logs))
+	for _, log := range logs {
+		if !principal.CanAccessJob(log.JobName) {
+			continue
+		}
+		log.APIKey = ""
+		log.Timestamp = log.Timestamp.In(location)
+		visible = append(visible, log)
+	}
+	if h.logReads {
+		recordAdminAction(r, models.ConfigActionAuditRead, auditReadDetails(filter, limit, offset, len(visible)))
+	}
+	var body interface{} = visible
+	if fields != nil {
+		if body, err = selectFields(visible, fields); err != nil {
+			logger.Error("Failed to select audit log fields", "error", err, "request_id", requestID)
+			writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to encode response")
+			return
+		}
+	}
 
-	// Return the logs as JSON
+	// Return the logs as JSON, with the cursor of the next page when this one is full
+	if len(logs) > 0 && len(logs) == limit {
+		w.Header().Set(nextCursorHeader, encodeCursor(logs[len(logs)-1].ID))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
 	// Encode response
-	if err := json.NewEncoder(w).Encode(logs); err != nil {
+	if err := json.NewEncoder(w).Encode(body); err != nil {
 		logger.Error("Failed to encode audit logs response", "error", err, "request_id", requestID)
 		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
 }
+
+// auditReadDetails describes an audit log read for the configuration audit: the page, the rows
+// returned, and the filters used, labels sorted by key
+func auditReadDetails(filter models.AuditFilter, limit, offset, rows int) string {
+	details := fmt.Sprintf("limit=%d offset=%d rows=%d", limit, offset, rows)
+	keys := make([]string, 0, len(filter.Labels))
+	for key := range filter.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		details += fmt.Sprintf(" label=%s:%s", key, filter.Labels[key])
+	}
+	if filter.ChangeRef != "" {
+		details += " change_ref=" + filter.ChangeRef
+	}
+	if filter.Commit != "" {
+		details += " commit=" + filter.Commit
+	}
+	if filter.BeforeID > 0 {
+		details += fmt.Sprintf(" before_id=%d", filter.BeforeID)
+	}
+	return details
+}
+
+// parseLabelFilters parses label query values of the form key:value into a label set
+// It returns the client-facing error message, or "" when all filters are valid
+func parseLabelFilters(values []string) (map[string]string, string) {
+	if len(values) == 0 {
+		return nil, ""
+	}
+	labels := make(map[string]string, len(values))
+	for _, value := range values {
+		key, labelValue, ok := strings.Cut(value, ":")
+		if !ok {
+			return nil, fmt.Sprintf("Invalid label filter '%s': expected key:value", value)
+		}
+		if message := validateLabel(key, labelValue); message != "" {
+			return nil, message
+		}
+		labels[key] = labelValue
+	}
+	if len(labels) > maxLabels {
+		return nil, fmt.Sprintf("Maximum %d label filters allowed", maxLabels)
+	}
+	return labels, ""
+}
+
+// auditParamsPathSuffix follows the audit entry ID in the full parameters route
+const auditParamsPathSuffix = "/params"
+
+// GetAuditParams handles the GET /api/v1/audit/{id}/params request
+// It returns the full parameters of an audit entry, including those truncated in the audit log
+func (h *AuditHandler) GetAuditParams(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	idPart := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, auditReplayPathPrefix), auditParamsPathSuffix)
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || id <= 0 {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, "Not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	entry, err := storage.GetAuditLog(id)
+	if err != nil {
+		logger.Error("Failed to get audit log", "error", err, "audit_id", id, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get audit log entry")
+		return
+	}
+	// Entries of jobs the key may not access are reported as missing
+	if entry == nil || !middleware.GetPrincipal(r).CanAccessJob(entry.JobName) {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Audit log entry %d not found", id))
+		return
+	}
+
+	params := entry.Params
+	if entry.ParamsTruncated {
+		full, ok, err := storage.GetAuditParams(id)
+		if err != nil {
+			logger.Error("Failed to get full audit parameters", "error", err, "audit_id", id, "request_id", requestID)
+			writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get audit log entry")
+			return
+		}
+		if !ok {
+			writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Full parameters of audit log entry %d were not kept", id))
+			return
+		}
+		params = full
+	}
+	if params == "" {
+		params = "{}"
+	}
+	if h.logReads {
+		recordAdminAction(r, models.ConfigActionAuditRead, fmt.Sprintf("audit_id=%d params", id))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":     id,
+		"params": json.RawMessage(params),
+	}); err != nil {
+		logger.Error("Failed to encode audit parameters response", "error", err, "request_id", requestID)
+	}
+}
+
+// GetAuditArchives handles the GET /api/v1/audit/archives request
+// It reports which time ranges are archived to object storage and which are live
+func (h *AuditHandler) GetAuditArchives(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	archives, err := storage.GetAuditArchives()
+	if err != nil {
+		logger.Error("Failed to get audit archives", "error", err, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get audit archives")
+		return
+	}
+
+	liveRange, err := storage.GetAuditLiveRange()
+	if err != nil {
+		logger.Error("Failed to get live audit range", "error", err, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get audit archives")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"archives": archives,
+		"live":     liveRange,
+	}); err != nil {
+		logger.Error("Failed to encode audit archives response", "error", err, "request_id", requestID)
+	}
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// Audit tail settings
+const (
+	tailPollInterval      = time.Second
+	tailKeepAliveInterval = 15 * time.Second
+	tailBatchSize         = 100
+	maxAuditTails         = 100 // Concurrent streams, each polling the database once per interval
+)
+
+// auditTails tracks the open audit tail streams, so they can be ended on shutdown
+type auditTails struct {
+	mu     sync.Mutex
+	open   int
+	closed bool
+	done   chan struct{}
+}
+
+// acquire reserves a stream, reporting false at the limit or after shutdown
+func (t *auditTails) acquire() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed || t.open >= maxAuditTails {
+		return false
+	}
+	t.open++
+	return true
+}
+
+// release frees a stream reserved by acquire
+func (t *auditTails) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.open--
+}
+
+// close ends the open streams and refuses new ones
+func (t *auditTails) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.closed {
+		t.closed = true
+		close(t.done)
+	}
+}
+
+// CloseStreams ends the open audit tail streams and refuses new ones, so a graceful shutdown does
+// not wait for them
+func (h *AuditHandler) CloseStreams() {
+	h.tails.close()
+}
+
+// TailAuditLogs handles the GET /api/v1/audit/tail request, streaming new audit entries as
+// Server-Sent Events. The optional q takes the terms of GET /api/v1/search, except since and until
+// A reconnecting client sends the Last-Event-ID header to resume after the last entry it received
+func (h *AuditHandler) TailAuditLogs(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeErrorWithRequestID(w, r, http.StatusNotImplemented, "Streaming is not supported")
+		return
+	}
+
+	location, message := requestLocation(r)
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	filter, message := parseSearchQuery(query, location, time.Now())
+	if message == "" && (!filter.Since.IsZero() || !filter.Until.IsZero()) {
+		message = "since and until are not supported when tailing"
+	}
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	// Start after the newest entry, or after the last one a reconnecting client received
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			writeErrorWithRequestID(w, r, http.StatusBadRequest, "Invalid Last-Event-ID")
+			return
+		}
+		filter.AfterID = id
+	} else {
+		latest, err := storage.GetAuditLogs(1, 0)
+		if err != nil {
+			logger.Error("Failed to get latest audit log", "error", err, "request_id", requestID)
+			writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to tail audit logs")
+			return
+		}
+		if len(latest) > 0 {
+			filter.AfterID = latest[0].ID
+		}
+	}
+	// Fail before the stream starts if the audit store cannot be queried
+	logs, err := storage.QueryAuditLogs(filter, tailBatchSize, 0)
+	if err != nil {
+		logger.Error("Failed to tail audit logs", "error", err, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to tail audit logs")
+		return
+	}
+
+	if !h.tails.acquire() {
+		w.Header().Set("Retry-After", strconv.Itoa(int(tailKeepAliveInterval.Seconds())))
+		writeErrorWithRequestID(w, r, http.StatusServiceUnavailable, "Too many audit tail streams")
+		return
+	}
+	defer h.tails.release()
+	if h.logReads {
+		recordAdminAction(r, models.ConfigActionAuditRead, fmt.Sprintf("tail q=%q after=%d", query, filter.AfterID))
+	}
+	logger.Info("Audit tail started", "query", query, "after", filter.AfterID, "request_id", requestID)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Keep nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", tailPollInterval.Milliseconds())
+	flusher.Flush()
+
+	principal := middleware.GetPrincipal(r)
+	poll := time.NewTicker(tailPollInterval)
+	defer poll.Stop()
+	lastWrite := time.Now()
+	for {
+		for _, log := range logs {
+			filter.AfterID = log.ID
+			if !principal.CanAccessJob(log.JobName) {
+				continue
+			}
+			log.APIKey = ""
+			log.Timestamp = log.Timestamp.In(location)
+			data, err := json.Marshal(log)
+			if err != nil {
+				logger.Error("Failed to encode audit log", "error", err, "id", log.ID, "request_id", requestID)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: audit\ndata: %s\n\n", log.ID, data); err != nil {
+				return
+			}
+			lastWrite = time.Now()
+		}
+		if time.Since(lastWrite) >= tailKeepAliveInterval {
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			lastWrite = time.Now()
+		}
+		flusher.Flush()
+
+		// A full batch may have more entries waiting
+		if len(logs) < tailBatchSize {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-h.tails.done:
+				return
+			case <-poll.C:
+			}
+		}
+		if logs, err = storage.QueryAuditLogs(filter, tailBatchSize, 0); err != nil {
+			// End the stream; the client reconnects with Last-Event-ID and misses nothing
+			logger.Error("Failed to tail audit logs", "error", err, "request_id", requestID)
+			return
+		}
+	}
+}
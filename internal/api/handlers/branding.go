@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"triggermesh/internal/config"
+)
+
+// defaultServiceName names the service in the root response unless branding.service_name is set
+const defaultServiceName = "TriggerMesh"
+
+// Branding is the presentation of the instance in the root response and GET /api/v1/me
+type Branding struct {
+	ServiceName string `json:"service_name,omitempty"`
+	Contact     string `json:"contact,omitempty"`
+	DocsURL     string `json:"docs_url,omitempty"`
+	Message     string `json:"message,omitempty"` // Notice to clients, e.g. a maintenance announcement
+}
+
+// NewBranding returns the branding of a tenant, or nil when nothing is configured for it
+func NewBranding(cfg config.BrandingConfig, tenant string) *Branding {
+	brand := cfg.ForTenant(tenant)
+	if brand.IsZero() {
+		return nil
+	}
+	return &Branding{
+		ServiceName: brand.ServiceName,
+		Contact:     brand.Contact,
+		DocsURL:     brand.DocsURL,
+		Message:     brand.Message,
+	}
+}
+
+// ServiceName returns the name of the service as branded for a tenant
+func ServiceName(cfg config.BrandingConfig, tenant string) string {
+	if name := cfg.ForTenant(tenant).ServiceName; name != "" {
+		return name
+	}
+	return defaultServiceName
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+)
+
+// buildURLMode returns how the engine URLs of a response to the request are presented: keys with
+// the internal_urls scope always see them
+func buildURLMode(mode string, r *http.Request) string {
+	if mode == "" || mode == config.BuildURLsKeep || middleware.GetPrincipal(r).HasScope(config.ScopeInternalURLs) {
+		return config.BuildURLsKeep
+	}
+	return mode
+}
+
+// presentURL returns the engine URL as presented to the request: unchanged, omitted, or replaced by
+// the TriggerMesh path below the base path; an empty path omits the URL in rewrite mode too
+func presentURL(mode string, r *http.Request, engineURL, path string) string {
+	switch buildURLMode(mode, r) {
+	case config.BuildURLsKeep:
+		return engineURL
+	case config.BuildURLsRewrite:
+		if engineURL == "" || path == "" {
+			return ""
+		}
+		return middleware.GetBasePath(r) + path
+	default:
+		return ""
+	}
+}
+
+// presentBuildResult returns the result with its build URL presented to the request, copying it
+// when the URL changes; statusPath is the TriggerMesh status path of the build
+func presentBuildResult(mode string, r *http.Request, result *engine.BuildResult, statusPath string) *engine.BuildResult {
+	if result == nil {
+		return nil
+	}
+	buildURL := presentURL(mode, r, result.BuildURL, statusPath)
+	if buildURL == result.BuildURL {
+		return result
+	}
+	presented := *result
+	presented.BuildURL = buildURL
+	return &presented
+}
+
+// buildStatusPath returns the TriggerMesh status path of a build: by its global build ID when known,
+// otherwise on its engine
+func buildStatusPath(engineName, buildID, globalBuildID string) string {
+	switch {
+	case globalBuildID != "":
+		return globalBuildPathPrefix + globalBuildID
+	case buildID == "":
+		return ""
+	case engineName == jenkinsEngineName:
+		return buildStatusPathPrefix + buildID
+	default:
+		return "/api/v1/engines/" + url.PathEscape(engineName) + "/builds/" + url.PathEscape(buildID)
+	}
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// maxChangeRefLength limits the length of a change ticket reference
+const maxChangeRefLength = 128
+
+// changeRefRegex validates change ticket references such as CHG0012345, OPS-1234, or INC:42
+var changeRefRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:#/-]*$`)
+
+// validateChangeRef checks the format of a change_ref, returning the client-facing error message or ""
+func validateChangeRef(ref string) string {
+	if ref == "" {
+		return ""
+	}
+	if len(ref) > maxChangeRefLength {
+		return fmt.Sprintf("change_ref exceeds maximum length of %d characters", maxChangeRefLength)
+	}
+	if !changeRefRegex.MatchString(ref) {
+		return "Invalid change_ref format: only alphanumeric characters, underscores, dots, colons, hashes, slashes, and hyphens are allowed"
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Git object names: abbreviated (at least 7 digits) or full SHA-1, and full SHA-256
+const (
+	minCommitLength = 7
+	maxCommitLength = 64
+)
+
+// maxRefLength limits the length of a Git ref
+const maxRefLength = 255
+
+// commitRegex validates Git commit SHAs in hexadecimal
+var commitRegex = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// validateCommit checks the format of a commit, returning the client-facing error message or ""
+func validateCommit(commit string) string {
+	if commit == "" {
+		return ""
+	}
+	if len(commit) < minCommitLength || len(commit) > maxCommitLength || !commitRegex.MatchString(commit) {
+		return fmt.Sprintf("Invalid commit: expected a hexadecimal SHA of %d to %d characters", minCommitLength, maxCommitLength)
+	}
+	return ""
+}
+
+// validateRef checks a Git branch, tag, or full ref (refs/heads/main) against the rules of
+// git check-ref-format, returning the client-facing error message or ""
+func validateRef(ref string) string {
+	if ref == "" {
+		return ""
+	}
+	if len(ref) > maxRefLength {
+		return fmt.Sprintf("ref exceeds maximum length of %d characters", maxRefLength)
+	}
+	if ref == "@" || strings.HasPrefix(ref, "/") || strings.HasSuffix(ref, "/") || strings.HasSuffix(ref, ".") ||
+		strings.Contains(ref, "//") || strings.Contains(ref, "..") || strings.Contains(ref, "@{") {
+		return fmt.Sprintf("Invalid ref '%s': not a valid Git ref name", ref)
+	}
+	for _, c := range ref {
+		if c < 0x20 || c == 0x7f || strings.ContainsRune(" ~^:?*[\\", c) {
+			return fmt.Sprintf("Invalid ref '%s': Git ref names cannot contain spaces, control characters, or any of ~^:?*[\\", ref)
+		}
+	}
+	for _, component := range strings.Split(ref, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return fmt.Sprintf("Invalid ref '%s': ref components cannot start with a dot or end with .lock", ref)
+		}
+	}
+	return ""
+}
+
+// commitParameterConflict returns the client-facing error message when a request sets the parameter
+// its commit or ref is passed as to a different value, or ""
+func (h *JenkinsHandler) commitParameterConflict(req TriggerJenkinsBuildRequest) string {
+	for _, mapping := range []struct{ field, param, value string }{
+		{"commit", h.commitParameter, req.Commit},
+		{"ref", h.refParameter, req.Ref},
+	} {
+		if mapping.param == "" || mapping.value == "" {
+			continue
+		}
+		if value, ok := req.Parameters[mapping.param]; ok && !strings.EqualFold(value, mapping.value) {
+			return fmt.Sprintf("Parameter '%s' conflicts with %s '%s'", mapping.param, mapping.field, mapping.value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// cursorVersion prefixes encoded cursors so their layout can change without misreading old ones
+const cursorVersion = "v1"
+
+// nextCursorHeader carries the cursor of the next page of list responses that are plain arrays
+const nextCursorHeader = "X-Next-Cursor"
+
+// encodeCursor encodes the position after the last item of a page as an opaque cursor
+func encodeCursor(values ...int64) string {
+	parts := make([]string, 0, len(values)+1)
+	parts = append(parts, cursorVersion)
+	for _, value := range values {
+		parts = append(parts, strconv.FormatInt(value, 10))
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(strings.Join(parts, ":")))
+}
+
+// decodeCursor decodes a cursor made by encodeCursor with count values
+// It reports false for cursors that are malformed or hold negative values
+func decodeCursor(cursor string, count int) ([]int64, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, false
+	}
+	parts := strings.Split(string(data), ":")
+	if len(parts) != count+1 || parts[0] != cursorVersion {
+		return nil, false
+	}
+	values := make([]int64, count)
+	for i, part := range parts[1:] {
+		value, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || value < 0 {
+			return nil, false
+		}
+		values[i] = value
+	}
+	return values, true
+}
+
+// parseAfterCursor parses the after query parameter holding the cursor of the page to continue from
+// It returns nil when there is no cursor, and the client-facing error message, or "" when the
+// cursor is valid; offset cannot be combined with a cursor
+func parseAfterCursor(r *http.Request, count int) ([]int64, string) {
+	cursor := r.URL.Query().Get("after")
+	if cursor == "" {
+		return nil, ""
+	}
+	if r.URL.Query().Get("offset") != "" {
+		return nil, "offset cannot be combined with after"
+	}
+	values, ok := decodeCursor(cursor, count)
+	if !ok {
+		return nil, "Invalid cursor"
+	}
+	return values, ""
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/logger"
+)
+
+const (
+	// maxJSONBodySize caps request bodies read by decodeJSON, in addition to server.max_body_size
+	maxJSONBodySize = 10 * 1024 * 1024
+	// maxJSONDepth is the deepest nesting of objects and arrays accepted in request bodies
+	maxJSONDepth = 32
+)
+
+// Error codes of rejected request bodies
+const (
+	codeInvalidBody  = "INVALID_REQUEST_BODY"
+	codeBodyTooLarge = "REQUEST_BODY_TOO_LARGE"
+)
+
+// bodyError describes why a request body was rejected and where
+type bodyError struct {
+	status int
+	detail string
+	offset int64 // Byte offset of the error; -1 when the error has no position
+	line   int
+	column int
+}
+
+// Error implements the error interface
+func (e *bodyError) Error() string {
+	if e.offset < 0 {
+		return e.detail
+	}
+	return fmt.Sprintf("%s at line %d, column %d", e.detail, e.line, e.column)
+}
+
+// decodeJSON strictly decodes a JSON request body into v: unknown fields, trailing data, and nesting
+// deeper than maxJSONDepth are rejected. An empty body is accepted when optional is set
+// It returns a *bodyError; write it with writeBodyError
+func decodeJSON(r *http.Request, v interface{}, optional bool) error {
+	return decodeBody(r, v, optional, true)
+}
+
+// decodeExternalJSON decodes a JSON request body sent by a third-party system, such as a Jenkins
+// plugin, whose payloads gain fields across versions: unknown fields are ignored, everything else is
+// checked as by decodeJSON
+func decodeExternalJSON(r *http.Request, v interface{}) error {
+	return decodeBody(r, v, false, false)
+}
+
+// decodeBody decodes a JSON request body into v, rejecting unknown fields when strict is set
+func decodeBody(r *http.Request, v interface{}, optional, strict bool) error {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxJSONBodySize+1))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || len(data) > maxJSONBodySize {
+		return &bodyError{status: http.StatusRequestEntityTooLarge, detail: "request body too large", offset: -1}
+	}
+	if err != nil {
+		return &bodyError{status: http.StatusBadRequest, detail: "failed to read request body", offset: -1}
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		if optional {
+			return nil
+		}
+		return &bodyError{status: http.StatusBadRequest, detail: "request body is empty", offset: -1}
+	}
+
+	if offset := exceedsDepth(data, maxJSONDepth); offset >= 0 {
+		return newBodyError(data, fmt.Sprintf("nesting exceeds %d levels", maxJSONDepth), offset)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			// Offsets count the bytes read, so the offending byte is the last one
+			return newBodyError(data, strings.TrimPrefix(syntaxErr.Error(), "json: "), syntaxErr.Offset-1)
+		case errors.As(err, &typeErr):
+			detail := fmt.Sprintf("cannot use %s as %s", typeErr.Value, typeErr.Type)
+			if typeErr.Field != "" {
+				detail = fmt.Sprintf("field %q: %s", typeErr.Field, detail)
+			}
+			return newBodyError(data, detail, typeErr.Offset-1)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return newBodyError(data, "unexpected end of JSON input", int64(len(data)))
+		default:
+			// Unknown fields and errors from UnmarshalJSON methods (e.g. invalid times); the decoder
+			// has consumed the whole value, so point at the unknown field's key when there is one
+			message := strings.TrimPrefix(err.Error(), "json: ")
+			offset := decoder.InputOffset()
+			if name, ok := strings.CutPrefix(message, "unknown field "); ok {
+				if pos := unknownFieldOffset(data, name); pos >= 0 {
+					offset = pos
+				}
+			}
+			return newBodyError(data, message, offset)
+		}
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return newBodyError(data, "unexpected data after the JSON value", decoder.InputOffset())
+	}
+	return nil
+}
+
+// unknownFieldOffset returns the offset of the first object key matching the quoted field name, or -1
+func unknownFieldOffset(data []byte, quoted string) int64 {
+	key := []byte(quoted)
+	for start := 0; ; {
+		i := bytes.Index(data[start:], key)
+		if i < 0 {
+			return -1
+		}
+		pos := start + i
+		if rest := bytes.TrimLeft(data[pos+len(key):], " \t\r\n"); len(rest) > 0 && rest[0] == ':' {
+			return int64(pos)
+		}
+		start = pos + len(key)
+	}
+}
+
+// newBodyError creates a 400 body error positioned at the byte offset in data
+func newBodyError(data []byte, detail string, offset int64) *bodyError {
+	offset = max(0, min(offset, int64(len(data))))
+	line, column := 1, 1
+	for _, c := range data[:offset] {
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return &bodyError{status: http.StatusBadRequest, detail: detail, offset: offset, line: line, column: column}
+}
+
+// exceedsDepth returns the offset where objects and arrays in data nest deeper than maxDepth, or -1
+// Brackets inside strings are skipped; syntax errors are left to the decoder
+func exceedsDepth(data []byte, maxDepth int) int64 {
+	depth := 0
+	inString, escaped := false, false
+	for i, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return int64(i)
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return -1
+}
+
+// writeBodyError writes the response for a request body rejected by decodeJSON
+// The message stays "Invalid request body" (or "Request body too large"); the reason and position
+// are added as separate fields, since they come from the decoder and are not translated
+func writeBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	logger.Error("Failed to parse request body", "error", err, "request_id", middleware.GetRequestID(r))
+
+	var bodyErr *bodyError
+	if !errors.As(err, &bodyErr) {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if bodyErr.status == http.StatusRequestEntityTooLarge {
+		writeErrorResponse(w, r, bodyErr.status, map[string]interface{}{
+			"error": "Request body too large",
+			"code":  codeBodyTooLarge,
+		})
+		return
+	}
+
+	response := map[string]interface{}{
+		"error":  "Invalid request body",
+		"code":   codeInvalidBody,
+		"reason": bodyErr.detail,
+	}
+	if bodyErr.offset >= 0 {
+		response["offset"] = bodyErr.offset
+		response["line"] = bodyErr.line
+		response["column"] = bodyErr.column
+	}
+	writeErrorResponse(w, r, bodyErr.status, response)
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/resilience"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/ulid"
+)
+
+// engineTriggerPathPrefix is the route prefix followed by the engine name of a trigger
+const engineTriggerPathPrefix = "/api/v1/trigger/"
+
+// enginePathPrefix is the route prefix of engine resources: /api/v1/engines/{engine}/builds/{build_id}
+const enginePathPrefix = "/api/v1/engines/"
+
+// globalBuildPathPrefix is the route prefix followed by a TriggerMesh-wide build ID
+const globalBuildPathPrefix = "/api/v1/builds/"
+
+// maxBuildIDLength limits the length of engine build IDs in paths
+const maxBuildIDLength = 255
+
+// Operations reported by GET /api/v1/engines
+const (
+	OperationTrigger  = "trigger"  // Trigger builds
+	OperationStatus   = "status"   // Get build status
+	OperationSchedule = "schedule" // Hold triggers until not_before
+	OperationReplay   = "replay"   // Replay recorded triggers
+	OperationJobs     = "jobs"     // List jobs
+	OperationBuilds   = "builds"   // List recent builds of a job
+)
+
+// EngineInfo describes an engine instance in the GET /api/v1/engines response
+type EngineInfo struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`       // Configured engine type, or "custom" for engines registered in code
+	Operations  []string `json:"operations"` // Operations the API supports for the engine
+	TriggerPath string   `json:"trigger_path"`
+	StatusPath  string   `json:"status_path"`
+	OpenAPIPath string   `json:"openapi_path"`     // OpenAPI document of the trigger request, with the engine's fields
+	Fields      []string `json:"fields,omitempty"` // Engine-specific fields accepted in trigger requests
+}
+
+// EnginesResponse is the response of GET /api/v1/engines
+type EnginesResponse struct {
+	Engines []EngineInfo `json:"engines"`
+}
+
+// registeredEngine is an engine besides Jenkins with the handler serving it
+type registeredEngine struct {
+	engineType string
+	handler    *JenkinsHandler
+}
+
+// EngineHandler routes trigger and build status requests to the engines besides Jenkins,
+// and lists all engines with their capabilities
+type EngineHandler struct {
+	jenkins      engine.CIEngine
+	jenkinsGuard *resilience.Guard // Retries and circuit breaker of Jenkins calls; nil calls Jenkins directly
+	buildURLs    string            // How engine URLs are presented in responses (server.build_urls)
+
+	mu      sync.RWMutex
+	engines map[string]registeredEngine
+	names   []string // Registration order
+}
+
+// NewEngineHandler creates an EngineHandler that lists the Jenkins engine first
+func NewEngineHandler(jenkinsEngine engine.CIEngine) *EngineHandler {
+	return &EngineHandler{jenkins: jenkinsEngine, engines: make(map[string]registeredEngine)}
+}
+
+// SetBuildURLs sets how build status responses present the engine's build URLs, as
+// JenkinsHandler.SetBuildURLs does for triggers
+func (h *EngineHandler) SetBuildURLs(mode string) {
+	h.buildURLs = mode
+}
+
+// SetJenkinsGuard applies the Jenkins retry and circuit breaker settings to the build status
+// requests of Jenkins builds, as JenkinsHandler.SetGuard does for the Jenkins handler
+func (h *EngineHandler) SetJenkinsGuard(guard *resilience.Guard) {
+	h.jenkinsGuard = guard
+}
+
+// Add routes requests for the named engine of the given type to the handler
+func (h *EngineHandler) Add(name, engineType string, handler *JenkinsHandler) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, exists := h.engines[name]; !exists {
+		h.names = append(h.names, name)
+	}
+	h.engines[name] = registeredEngine{engineType: engineType, handler: handler}
+}
+
+// ListEngines handles the GET /api/v1/engines request
+// Operations an engine does not list (e.g. cancel or logs) are not available through the API
+func (h *EngineHandler) ListEngines(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	basePath := middleware.GetBasePath(r)
+	response := EnginesResponse{Engines: []EngineInfo{}}
+	if h.jenkins != nil {
+		operations := []string{OperationTrigger, OperationStatus, OperationSchedule, OperationReplay}
+		if _, ok := h.jenkins.(engine.JobLister); ok {
+			operations = append(operations, OperationJobs)
+		}
+		if _, ok := h.jenkins.(engine.BuildLister); ok {
+			operations = append(operations, OperationBuilds)
+		}
+		response.Engines = append(response.Engines, EngineInfo{
+			Name:        jenkinsEngineName,
+			Type:        jenkinsEngineName,
+			Operations:  operations,
+			TriggerPath: basePath + "/api/v1/trigger/jenkins",
+			StatusPath:  basePath + "/api/v1/jenkins/builds/{build_id}",
+			OpenAPIPath: basePath + enginePathPrefix + jenkinsEngineName + "/openapi",
+		})
+	}
+
+	h.mu.RLock()
+	for _, name := range h.names {
+		info := EngineInfo{
+			Name:        name,
+			Type:        h.engines[name].engineType,
+			Operations:  []string{OperationTrigger, OperationStatus},
+			TriggerPath: basePath + engineTriggerPathPrefix + name,
+			StatusPath:  basePath + enginePathPrefix + name + "/builds/{build_id}",
+			OpenAPIPath: basePath + enginePathPrefix + name + "/openapi",
+		}
+		if fields := h.engines[name].handler.fields; fields != nil {
+			for _, field := range fields.fields {
+				info.Fields = append(info.Fields, field.Name)
+			}
+		}
+		response.Engines = append(response.Engines, info)
+	}
+	h.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Failed to encode engines response", "error", err, "request_id", requestID)
+	}
+}
+
+// handler returns the handler of the named engine
+func (h *EngineHandler) handler(name string) (*JenkinsHandler, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	registered, ok := h.engines[name]
+	return registered.handler, ok
+}
+
+// Trigger handles the POST /api/v1/trigger/{engine} request, which takes the same body as Jenkins triggers
+func (h *EngineHandler) Trigger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, engineTriggerPathPrefix)
+	handler, ok := h.handler(name)
+	if !ok {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Engine '%s' not found", name))
+		return
+	}
+	handler.TriggerJenkinsBuild(w, r)
+}
+
+// GetBuildStatus handles the GET /api/v1/engines/{engine}/builds/{build_id} request
+// The build ID is the rest of the path and may contain slashes
+func (h *EngineHandler) GetBuildStatus(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	name, buildID, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, enginePathPrefix), "/builds/")
+	if !ok {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, "Not found")
+		return
+	}
+	handler, ok := h.handler(name)
+	if !ok {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Engine '%s' not found", name))
+		return
+	}
+	if !validBuildID(buildID) {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, "Invalid build ID")
+		return
+	}
+
+	// Build IDs of other engines do not contain the job name, so look up which job started the build
+	principal := middleware.GetPrincipal(r)
+	job, err := storage.GetBuildJob(name, buildID)
+	if err != nil {
+		logger.Warn("Failed to look up build job", "error", err, "engine", name, "build_id", buildID, "request_id", requestID)
+	}
+	if principal != nil && len(principal.Jobs) > 0 && (job == "" || !principal.CanAccessJob(job)) {
+		writeErrorWithRequestID(w, r, http.StatusForbidden, fmt.Sprintf("API key is not allowed to access build '%s'", buildID))
+		return
+	}
+
+	result, err := handler.buildStatus(buildID)
+	if err != nil {
+		logger.Error("Failed to get build status", "error", err, "engine", name, "build_id", buildID, "request_id", requestID)
+		writeEngineError(w, r, "Failed to get build status", err)
+		return
+	}
+
+	// Labels are informational; a failed lookup does not fail the status request
+	labels, err := storage.GetBuildLabels(buildID)
+	if err != nil {
+		logger.Warn("Failed to get build labels", "error", err, "build_id", buildID, "request_id", requestID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(BuildStatusResponse{
+		BuildResult: presentBuildResult(h.buildURLs, r, result, buildStatusPath(name, buildID, "")),
+		Labels:      labels,
+	}); err != nil {
+		logger.Error("Failed to encode build status response", "error", err, "request_id", requestID)
+	}
+}
+
+// GetGlobalBuildStatus handles the GET /api/v1/builds/{global_build_id} request
+// The global build ID returned by any trigger resolves to the engine and engine build ID it maps to
+func (h *EngineHandler) GetGlobalBuildStatus(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	globalBuildID := strings.TrimPrefix(r.URL.Path, globalBuildPathPrefix)
+	if !ulid.Valid(globalBuildID) {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, "Invalid build ID")
+		return
+	}
+	globalBuildID = strings.ToUpper(globalBuildID)
+
+	entry, err := storage.GetAuditLogByGlobalBuildID(globalBuildID)
+	if err != nil {
+		logger.Error("Failed to look up global build ID", "error", err, "global_build_id", globalBuildID, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get build status")
+		return
+	}
+	if entry == nil || entry.BuildID == "" {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Build '%s' not found", globalBuildID))
+		return
+	}
+
+	principal := middleware.GetPrincipal(r)
+	if principal != nil && len(principal.Jobs) > 0 && !principal.CanAccessJob(entry.JobName) {
+		writeErrorWithRequestID(w, r, http.StatusForbidden, fmt.Sprintf("API key is not allowed to access build '%s'", globalBuildID))
+		return
+	}
+
+	// The engine may have been removed from the configuration since the trigger
+	ciEngine, guard := h.jenkins, h.jenkinsGuard
+	if entry.Engine != jenkinsEngineName {
+		handler, ok := h.handler(entry.Engine)
+		if !ok {
+			writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Engine '%s' not found", entry.Engine))
+			return
+		}
+		ciEngine, guard = handler.jenkinsEngine, handler.guard
+	}
+	if ciEngine == nil {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Engine '%s' not found", entry.Engine))
+		return
+	}
+
+	result, err := guard.Status(func() (*engine.BuildResult, error) {
+		return ciEngine.GetBuildStatus(entry.BuildID)
+	})
+	if err != nil {
+		logger.Error("Failed to get build status", "error", err, "engine", entry.Engine, "build_id", entry.BuildID, "request_id", requestID)
+		writeEngineError(w, r, "Failed to get build status", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(BuildStatusResponse{
+		BuildResult:   presentBuildResult(h.buildURLs, r, result, globalBuildPathPrefix+globalBuildID),
+		Labels:        entry.Labels,
+		GlobalBuildID: globalBuildID,
+		Engine:        entry.Engine,
+		Job:           entry.JobName,
+	}); err != nil {
+		logger.Error("Failed to encode build status response", "error", err, "request_id", requestID)
+	}
+}
+
+// validBuildID reports whether an engine build ID is non-empty, bounded, and printable
+func validBuildID(buildID string) bool {
+	if buildID == "" || len(buildID) > maxBuildIDLength || strings.Contains(buildID, "..") {
+		return false
+	}
+	for _, c := range buildID {
+		if !unicode.IsPrint(c) || unicode.IsSpace(c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// parseFields parses the fields query parameter, a comma-separated list of the JSON field names of
+// item to return, e.g. fields=id,job_name,result
+// It returns nil when all fields are requested, and the client-facing error message, or "" when
+// every name is a field of item
+func parseFields(r *http.Request, item interface{}) ([]string, string) {
+	value := r.URL.Query().Get("fields")
+	if value == "" {
+		return nil, ""
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(item))
+	var fields []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if !known[name] {
+			return nil, fmt.Sprintf("Unknown field '%s'", name)
+		}
+		seen[name] = true
+		fields = append(fields, name)
+	}
+	return fields, ""
+}
+
+// jsonFieldNames returns the JSON names of the fields encoded for a struct type,
+// including the fields of embedded structs
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for embedded := range jsonFieldNames(field.Type) {
+				names[embedded] = true
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+// selectFields returns the items of a slice reduced to the given JSON fields
+// Fields an item omits, such as empty omitempty fields, stay omitted
+func selectFields(items interface{}, fields []string) ([]map[string]json.RawMessage, error) {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var all []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	selected := make([]map[string]json.RawMessage, len(all))
+	for i, item := range all {
+		selected[i] = make(map[string]json.RawMessage, len(fields))
+		for _, name := range fields {
+			if value, ok := item[name]; ok {
+				selected[i][name] = value
+			}
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"triggermesh/internal/logger"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/version"
+)
+
+// HeartbeatHandler answers GET /healthz for external uptime monitors that cannot send headers
+// They authenticate with the monitoring token in the query string, which grants nothing else
+type HeartbeatHandler struct {
+	token     string
+	readiness *ReadinessHandler
+	startedAt time.Time
+}
+
+// NewHeartbeatHandler creates a heartbeat handler accepting the monitoring token; an empty token
+// disables the endpoint
+func NewHeartbeatHandler(token string, readiness *ReadinessHandler) *HeartbeatHandler {
+	return &HeartbeatHandler{
+		token:     token,
+		readiness: readiness,
+		startedAt: time.Now(),
+	}
+}
+
+// Healthz handles the GET /healthz?token=... request
+func (h *HeartbeatHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	if h.token == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	// Responses must not be cached along with the URL carrying the token
+	w.Header().Set("Cache-Control", "no-store")
+
+	token := r.URL.Query().Get("token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		logger.Warn("Heartbeat with an invalid monitoring token", "remote_addr", r.RemoteAddr)
+		writeErrorWithRequestID(w, r, http.StatusUnauthorized, "Invalid monitoring token")
+		return
+	}
+
+	status := http.StatusOK
+	body := map[string]interface{}{
+		"status":         "ok",
+		"version":        version.Version,
+		"uptime_seconds": int64(time.Since(h.startedAt).Seconds()),
+	}
+	switch {
+	case !h.readiness.Ready():
+		status = http.StatusServiceUnavailable
+		body["status"] = "starting"
+	case storage.Ping() != nil:
+		status = http.StatusServiceUnavailable
+		body["status"] = "unhealthy"
+		body["error"] = "database connection failed"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Error("Failed to encode heartbeat response", "error", err)
+	}
+}
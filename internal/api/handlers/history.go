@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+)
+
+// jobPathPrefix is the route prefix followed by the job name and a sub-resource
+const jobPathPrefix = "/api/v1/jenkins/jobs/"
+
+// buildHistorySuffix ends build history paths: /api/v1/jenkins/jobs/{job}/builds
+const buildHistorySuffix = "/builds"
+
+// Build history limits
+const (
+	defaultBuildHistoryLimit = 10
+	maxBuildHistoryLimit     = 100
+)
+
+// buildHistoryCacheTTL is how long a job's build history is served from cache
+const buildHistoryCacheTTL = 15 * time.Second
+
+// maxBuildHistoryCacheEntries bounds the build history cache; it is cleared when full
+const maxBuildHistoryCacheEntries = 1000
+
+// BuildHistoryResponse is the response body of a build history request
+type BuildHistoryResponse struct {
+	Job        string             `json:"job"`
+	Builds     []engine.BuildInfo `json:"builds"`
+	NextCursor string             `json:"next_cursor,omitempty"` // Cursor of the next page of older builds when this one is full
+}
+
+// selectedBuildHistoryResponse is the response body of a build history request with a fields selection
+type selectedBuildHistoryResponse struct {
+	Job        string                       `json:"job"`
+	Builds     []map[string]json.RawMessage `json:"builds"`
+	NextCursor string                       `json:"next_cursor,omitempty"`
+}
+
+// buildHistoryCache remembers recent build history per job and limit
+type buildHistoryCache struct {
+	mu      sync.Mutex
+	entries map[string]buildHistoryEntry
+}
+
+// buildHistoryEntry is a cached build history valid until expires
+type buildHistoryEntry struct {
+	builds  []engine.BuildInfo
+	expires time.Time
+}
+
+// newBuildHistoryCache creates an empty build history cache
+func newBuildHistoryCache() *buildHistoryCache {
+	return &buildHistoryCache{entries: make(map[string]buildHistoryEntry)}
+}
+
+// get returns the cached history for the job and limit, if not expired
+func (c *buildHistoryCache) get(job string, limit int) ([]engine.BuildInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[buildHistoryKey(job, limit)]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.builds, true
+}
+
+// put caches the history for the job and limit
+func (c *buildHistoryCache) put(job string, limit int, builds []engine.BuildInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxBuildHistoryCacheEntries {
+		c.entries = make(map[string]buildHistoryEntry)
+	}
+	c.entries[buildHistoryKey(job, limit)] = buildHistoryEntry{builds: builds, expires: time.Now().Add(buildHistoryCacheTTL)}
+}
+
+// buildHistoryKey identifies a cached history; job names cannot contain newlines
+func buildHistoryKey(job string, limit int) string {
+	return job + "\n" + strconv.Itoa(limit)
+}
+
+// ListJenkinsJobBuilds handles the GET /api/v1/jenkins/jobs/{job}/builds request
+// It returns the job's recent builds, cached briefly to spare Jenkins
+func (h *JenkinsHandler) ListJenkinsJobBuilds(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, jobPathPrefix)
+	if !strings.HasSuffix(rest, buildHistorySuffix) {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, "Not found")
+		return
+	}
+	jobName := strings.TrimSuffix(rest, buildHistorySuffix)
+	if !jobNameRegex.MatchString(jobName) || strings.Contains(jobName, "//") || strings.HasPrefix(jobName, "/") {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, "Invalid job name format")
+		return
+	}
+
+	limit := defaultBuildHistoryLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > maxBuildHistoryLimit {
+			writeErrorWithRequestID(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid limit: must be between 1 and %d", maxBuildHistoryLimit))
+			return
+		}
+		limit = parsed
+	}
+	fields, message := parseFields(r, engine.BuildInfo{})
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+	// Cursors hold the number of the last build of the previous page
+	cursor, message := parseAfterCursor(r, 1)
+	if message == "" && cursor != nil && cursor[0] == 0 {
+		message = "Invalid cursor"
+	}
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	if !middleware.GetPrincipal(r).CanAccessJob(jobName) {
+		writeErrorWithRequestID(w, r, http.StatusForbidden, fmt.Sprintf("API key is not allowed to access job '%s'", jobName))
+		return
+	}
+
+	lister, ok := h.jenkinsEngine.(engine.BuildLister)
+	if !ok {
+		writeErrorWithRequestID(w, r, http.StatusNotImplemented, "Build history is not supported by this engine")
+		return
+	}
+
+	var builds []engine.BuildInfo
+	if cursor != nil {
+		// Older pages are not cached; they are requested far less often than the first
+		pager, ok := h.jenkinsEngine.(engine.BuildPager)
+		if !ok {
+			writeErrorWithRequestID(w, r, http.StatusNotImplemented, "Cursor pagination of builds is not supported by this engine")
+			return
+		}
+		var err error
+		builds, err = pager.ListBuildsBefore(jobName, cursor[0], limit)
+		if err != nil {
+			logger.Error("Failed to list Jenkins builds", "error", err, "job", jobName, "request_id", requestID)
+			writeEngineError(w, r, "Failed to list builds", err)
+			return
+		}
+	} else {
+		var cached bool
+		builds, cached = h.history.get(jobName, limit)
+		if !cached {
+			var err error
+			builds, err = lister.ListBuilds(jobName, limit)
+			if err != nil {
+				logger.Error("Failed to list Jenkins builds", "error", err, "job", jobName, "request_id", requestID)
+				writeEngineError(w, r, "Failed to list builds", err)
+				return
+			}
+			h.history.put(jobName, limit, builds)
+		}
+	}
+
+	var nextCursor string
+	if _, ok := h.jenkinsEngine.(engine.BuildPager); ok && len(builds) > 0 && len(builds) == limit {
+		nextCursor = encodeCursor(builds[len(builds)-1].Number)
+	}
+	if buildURLMode(h.buildURLs, r) != config.BuildURLsKeep {
+		// Cached builds are shared between requests, so present a copy
+		presented := make([]engine.BuildInfo, len(builds))
+		for i, build := range builds {
+			build.URL = presentURL(h.buildURLs, r, build.URL, buildStatusPath(h.engineName, build.BuildID, ""))
+			presented[i] = build
+		}
+		builds = presented
+	}
+	var body interface{} = BuildHistoryResponse{Job: jobName, Builds: builds, NextCursor: nextCursor}
+	if fields != nil {
+		selected, err := selectFields(builds, fields)
+		if err != nil {
+			logger.Error("Failed to select build fields", "error", err, "request_id", requestID)
+			writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to encode response")
+			return
+		}
+		body = selectedBuildHistoryResponse{Job: jobName, Builds: selected, NextCursor: nextCursor}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Error("Failed to encode build history response", "error", err, "request_id", requestID)
+	}
+}
@@ -1,42 +1,260 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
+	"triggermesh/internal/alert"
 	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/authz"
+	"triggermesh/internal/blackout"
+	"triggermesh/internal/change"
+	"triggermesh/internal/config"
 	"triggermesh/internal/engine"
+	"triggermesh/internal/grafana"
+	"triggermesh/internal/incident"
 	"triggermesh/internal/logger"
+	"triggermesh/internal/metrics"
+	"triggermesh/internal/quota"
+	"triggermesh/internal/resilience"
+	"triggermesh/internal/scheduler"
 	"triggermesh/internal/storage"
 	"triggermesh/internal/storage/models"
+	"triggermesh/internal/throttle"
+	"triggermesh/internal/transform"
+	"triggermesh/internal/ulid"
 )
 
 // JenkinsHandler handles Jenkins-related API requests
 type JenkinsHandler struct {
 	jenkinsEngine engine.CIEngine
+	engineName    string // Engine recorded in audit logs; jenkins unless created by ForEngine
+	trackBuilds   bool
+	alerts        *alert.Evaluator
+	annotator     *grafana.Annotator
+	incidents     *incident.Manager
+	labelPrefix   string // Prefix of the parameters labels are injected as; empty disables injection
+	changes       *change.Checker
+	authorizer    *authz.Authorizer
+	blackouts     *blackout.Calendar
+	throttle      *throttle.Controller
+	quotas        *quota.Limiter
+	transforms    *transform.Pipeline
+	history       *buildHistoryCache
+	overview      *overviewCache
+	guard         *resilience.Guard // Retries and circuit breaker of engine calls; nil calls the engine directly
+
+	maxScheduleDelay time.Duration            // Furthest not_before accepted
+	schedules        []*scheduler.Recurring   // Jobs triggered on cron schedules
+	maxAuditParams   int                      // Bytes of parameters recorded in the audit log; 0 records them in full
+	keepFullParams   bool                     // Store the full parameters of truncated audit entries in the side table
+	maxWait          time.Duration            // Longest a trigger with wait: true is held open
+	waitPollInterval time.Duration            // Time between build status checks while waiting
+	reuseRules       []config.ReuseRuleConfig // Jobs whose recent successful builds answer identical triggers
+	reuseLookups     *metrics.Counter         // Outcomes of result reuse lookups; nil without metrics
+	buildURLs        string                   // How engine URLs are presented in responses (server.build_urls)
+	maxBulkReplay    int                      // Most parallel triggers a bulk replay may ask for
+	replayTimeout    time.Duration            // Longest each bulk replay trigger may take
+	commitParameter  string                   // Parameter the commit of a trigger is passed as; empty passes none
+	refParameter     string                   // Parameter the ref of a trigger is passed as; empty passes none
+	fields           *requestFields           // Engine-specific fields of trigger requests; nil accepts none
 }
 
 // NewJenkinsHandler creates a new JenkinsHandler instance
 func NewJenkinsHandler(jenkinsEngine engine.CIEngine) *JenkinsHandler {
 	return &JenkinsHandler{
 		jenkinsEngine: jenkinsEngine,
+		engineName:    jenkinsEngineName,
+		history:       newBuildHistoryCache(),
+		overview:      &overviewCache{},
+
+		maxScheduleDelay: defaultMaxScheduleDelay,
+		maxWait:          defaultMaxWait,
+		waitPollInterval: defaultWaitPollInterval,
+		maxBulkReplay:    defaultMaxBulkReplayConcurrency,
+		replayTimeout:    defaultBulkReplayTaskTimeout,
+	}
+}
+
+// ForEngine returns a handler that triggers builds on another engine with the same policies
+// (build tracking, alerts, blackout windows, authorization, change policy, quotas, and transforms)
+// Label and commit parameter injection and queue throttling are Jenkins settings and are not carried over
+func (h *JenkinsHandler) ForEngine(name string, e engine.CIEngine) *JenkinsHandler {
+	clone := *h
+	clone.jenkinsEngine = e
+	clone.engineName = name
+	clone.labelPrefix = ""
+	clone.commitParameter = ""
+	clone.refParameter = ""
+	clone.throttle = nil
+	clone.fields = nil
+	clone.schedules = nil
+	clone.guard = nil
+	clone.history = newBuildHistoryCache()
+	clone.overview = &overviewCache{}
+	return &clone
+}
+
+// SetBuildURLs sets how responses present the engine's build and job URLs to keys without the
+// internal_urls scope: config.BuildURLsKeep, config.BuildURLsOmit, or config.BuildURLsRewrite
+func (h *JenkinsHandler) SetBuildURLs(mode string) {
+	h.buildURLs = mode
+}
+
+// SetGuard retries failed calls to the engine and stops calling it while it keeps failing,
+// according to the engine's retry and circuit breaker settings
+func (h *JenkinsHandler) SetGuard(guard *resilience.Guard) {
+	h.guard = guard
+}
+
+// EnableBuildTracking records triggered builds so the status poller can collect their outcomes
+func (h *JenkinsHandler) EnableBuildTracking() {
+	h.trackBuilds = true
+}
+
+// SetAlertEvaluator feeds trigger outcomes to the failure-rate alert evaluator
+func (h *JenkinsHandler) SetAlertEvaluator(evaluator *alert.Evaluator) {
+	h.alerts = evaluator
+}
+
+// SetGrafanaAnnotator posts a Grafana annotation for every successful trigger of an annotated job
+func (h *JenkinsHandler) SetGrafanaAnnotator(annotator *grafana.Annotator) {
+	h.annotator = annotator
+}
+
+// SetIncidentManager reports trigger outcomes of critical jobs to the incident manager
+func (h *JenkinsHandler) SetIncidentManager(manager *incident.Manager) {
+	h.incidents = manager
+}
+
+// SetAuditParamsLimit truncates the parameters recorded in the audit log to maxSize bytes
+// With keepFull, the full parameters of truncated entries are stored in a side table
+func (h *JenkinsHandler) SetAuditParamsLimit(maxSize int, keepFull bool) {
+	h.maxAuditParams = maxSize
+	h.keepFullParams = keepFull
+}
+
+// InjectLabelParameters passes trigger labels to Jenkins as parameters named prefix+key
+// Parameters given explicitly in the request take precedence over injected labels
+func (h *JenkinsHandler) InjectLabelParameters(prefix string) {
+	h.labelPrefix = prefix
+}
+
+// SetCommitParameters passes the commit and ref of triggers as the named Jenkins parameters;
+// an empty name or config.NoParameter leaves that field out of the parameters
+func (h *JenkinsHandler) SetCommitParameters(commit, ref string) {
+	if commit == config.NoParameter {
+		commit = ""
+	}
+	if ref == config.NoParameter {
+		ref = ""
 	}
+	h.commitParameter = commit
+	h.refParameter = ref
+}
+
+// SetChangeChecker enforces the change ticket policy on triggers
+func (h *JenkinsHandler) SetChangeChecker(checker *change.Checker) {
+	h.changes = checker
+}
+
+// SetBlackoutCalendar refuses triggers during the calendar's blackout windows
+func (h *JenkinsHandler) SetBlackoutCalendar(calendar *blackout.Calendar) {
+	h.blackouts = calendar
+}
+
+// SetThrottle throttles triggers while the controller reports the build queue as too long
+func (h *JenkinsHandler) SetThrottle(controller *throttle.Controller) {
+	h.throttle = controller
+}
+
+// SetQuotas limits the running builds and trigger rate of job categories
+func (h *JenkinsHandler) SetQuotas(limiter *quota.Limiter) {
+	h.quotas = limiter
+}
+
+// SetTransformPipeline rewrites and enriches trigger parameters before dispatch
+func (h *JenkinsHandler) SetTransformPipeline(pipeline *transform.Pipeline) {
+	h.transforms = pipeline
+}
+
+// SetAuthorizer consults the external authorization hook before every trigger
+func (h *JenkinsHandler) SetAuthorizer(authorizer *authz.Authorizer) {
+	h.authorizer = authorizer
 }
 
 // TriggerJenkinsBuildRequest represents the request body for triggering a Jenkins build
 type TriggerJenkinsBuildRequest struct {
 	Job        string            `json:"job"`
 	Parameters map[string]string `json:"parameters"`
+	Labels     map[string]string `json:"labels,omitempty"`     // Metadata recorded with the trigger, e.g. team
+	ChangeRef  string            `json:"change_ref,omitempty"` // Change ticket (Jira, ServiceNow) authorizing the trigger
+	Commit     string            `json:"commit,omitempty"`     // Git commit SHA the build is for (7 to 64 hex characters)
+	Ref        string            `json:"ref,omitempty"`        // Git branch, tag, or full ref the build is for
+	NotBefore  *time.Time        `json:"not_before,omitempty"` // Hold the trigger until this time (RFC 3339)
+	Deadline   *time.Time        `json:"deadline,omitempty"`   // Drop the trigger if it has not run by this time (RFC 3339)
+	Wait       bool              `json:"wait,omitempty"`       // Respond once the build has finished, up to max_wait
+	MaxWait    int               `json:"max_wait,omitempty"`   // Seconds to wait for the build (default and cap: wait.max_wait)
+	NoReuse    bool              `json:"no_reuse,omitempty"`   // Start a new build even if a recent identical one succeeded
+
+	Fields map[string]json.RawMessage `json:"fields,omitempty"` // Engine-specific fields, passed to the engine as parameters
+}
+
+// jenkinsEngineName is the engine recorded in audit logs for Jenkins triggers
+const jenkinsEngineName = "jenkins"
+
+// buildStatusPathPrefix is the route prefix followed by the build ID (jobName/buildNumber)
+const buildStatusPathPrefix = "/api/v1/jenkins/builds/"
+
+// TriggerJenkinsBuildResponse is the response body of a successful trigger
+type TriggerJenkinsBuildResponse struct {
+	*engine.BuildResult
+	TriggerID        string            `json:"trigger_id"`
+	GlobalBuildID    string            `json:"global_build_id"`       // TriggerMesh-wide build ID for GET /api/v1/builds/{global_build_id}
+	DurationMS       int64             `json:"duration_ms"`           // End-to-end handler duration
+	EngineDurationMS int64             `json:"engine_duration_ms"`    // Jenkins round-trip duration
+	ReplayOf         int64             `json:"replay_of,omitempty"`   // Audit entry ID when the trigger is a replay
+	ReusedFrom       int64             `json:"reused_from,omitempty"` // Audit entry ID of the identical trigger whose build was reused
+	Labels           map[string]string `json:"labels,omitempty"`      // Labels recorded with the trigger
+	ChangeRef        string            `json:"change_ref,omitempty"`  // Change ticket recorded with the trigger
+	Commit           string            `json:"commit,omitempty"`      // Git commit recorded with the trigger
+	Ref              string            `json:"ref,omitempty"`         // Git ref recorded with the trigger
+	WaitStatus       string            `json:"wait_status,omitempty"` // COMPLETED or IN_PROGRESS when the request waited for the build
+}
+
+// BuildStatusResponse is the response body of a build status lookup
+type BuildStatusResponse struct {
+	*engine.BuildResult
+	Labels        map[string]string `json:"labels,omitempty"`          // Labels of the trigger that started the build
+	GlobalBuildID string            `json:"global_build_id,omitempty"` // Set when the build is looked up by its TriggerMesh-wide ID
+	Engine        string            `json:"engine,omitempty"`          // Engine that ran the build, set with global_build_id
+	Job           string            `json:"job,omitempty"`             // Job that was triggered, set with global_build_id
 }
 
+const (
+	// maxLabels limits the number of labels on a trigger request
+	maxLabels = 20
+	// maxLabelKeyLength limits the length of a label key
+	maxLabelKeyLength = 63
+	// maxLabelValueLength limits the length of a label value
+	maxLabelValueLength = 256
+)
+
 var (
 	// jobNameRegex validates Jenkins job names (supports folder structure: folder/subfolder/job)
 	// Jenkins job names can contain: alphanumeric, underscore, hyphen, slash, and spaces
 	jobNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_/\- ]+$`)
+	// viewNameRegex validates Jenkins view names used to filter job discovery
+	viewNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_\- ]+$`)
+	// buildNumberRegex validates Jenkins build numbers
+	buildNumberRegex = regexp.MustCompile(`^[0-9]+$`)
 	// parameterKeyRegex validates parameter keys (alphanumeric, underscore, hyphen, dot)
 	// No leading/trailing dots, no consecutive dots
 	parameterKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`)
@@ -44,42 +262,455 @@ var (
 
 // TriggerJenkinsBuild handles the POST /api/v1/trigger/jenkins request
 func (h *JenkinsHandler) TriggerJenkinsBuild(w http.ResponseWriter, r *http.Request) {
-	// Get API key from context
-	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(string)
-	if !ok {
-		apiKey = "unknown"
-	}
+	started := time.Now()
 
 	// Get request ID for logging
 	requestID := middleware.GetRequestID(r)
 
 	// Parse request body
 	var req TriggerJenkinsBuildRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		logger.Error("Failed to parse request body", "error", err, "request_id", requestID)
-		writeErrorWithRequestID(w, r, http.StatusBadRequest, "Invalid request body")
+	if err := decodeJSON(r, &req, false); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	if message := validateTriggerRequest(req, requestID); message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+	if message := h.applyRequestFields(&req); message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+	req.Commit = strings.ToLower(req.Commit)
+	if message := h.commitParameterConflict(req); message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+	maxWait, message := h.validateWait(req)
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	now := time.Now()
+	if req.Deadline != nil && !req.Deadline.After(now) {
+		h.rejectExpired(w, r, req, started)
+		return
+	}
+	if req.NotBefore != nil && req.NotBefore.After(now.Add(scheduleTolerance)) {
+		if h.engineName != jenkinsEngineName {
+			writeErrorWithRequestID(w, r, http.StatusBadRequest, "not_before is only supported for Jenkins triggers")
+			return
+		}
+		h.scheduleTrigger(w, r, req)
+		return
+	}
+
+	if !req.NoReuse && h.reuseResult(w, r, req, started) {
 		return
 	}
+	h.executeTrigger(w, r, req, started, triggerOrigin{source: models.SourceHTTP, maxWait: maxWait})
+}
+
+// triggerOrigin describes what started a trigger, for audit attribution
+type triggerOrigin struct {
+	source    string        // models.Source* value
+	replayOf  int64         // Audit entry ID when replaying a previous trigger
+	triggerID string        // ID assigned when the trigger was scheduled; empty generates a new one
+	maxWait   time.Duration // How long to wait for the build to finish before responding; 0 responds at once
+	// dispatch is called right before the engine; an error fails the trigger without calling it
+	dispatch func() error
+}
+
+// errJobNotAllowed is the runTrigger error for jobs the API key may not trigger
+var errJobNotAllowed = errors.New("job not allowed for API key")
+
+// triggerOutcome is the result of runTrigger
+type triggerOutcome struct {
+	result         *engine.BuildResult // Engine result, set on success
+	err            error               // errJobNotAllowed or the engine error
+	triggerID      string
+	globalBuildID  string // TriggerMesh-wide build ID, set on success
+	engineDuration time.Duration
+}
+
+// executeTrigger runs a validated trigger request and writes the response
+func (h *JenkinsHandler) executeTrigger(w http.ResponseWriter, r *http.Request, req TriggerJenkinsBuildRequest, started time.Time, origin triggerOrigin) {
+	outcome := h.runTrigger(r, req, started, origin)
+	if writeTriggerRefusal(w, r, req.Job, outcome.err) {
+		return
+	}
+	if outcome.err != nil {
+		setServerTiming(w, time.Since(started), outcome.engineDuration)
+		writeEngineError(w, r, "Failed to trigger build", outcome.err)
+		return
+	}
+
+	// Follow the build to completion when asked; a build still running at the end is
+	// reported with 202 so the client knows to keep polling
+	result := outcome.result
+	status := http.StatusOK
+	var waitStatus string
+	if origin.maxWait > 0 {
+		var completed bool
+		result, completed = h.waitForBuild(r, outcome.result, origin.maxWait)
+		waitStatus = WaitCompleted
+		if !completed {
+			waitStatus = WaitInProgress
+			status = http.StatusAccepted
+		}
+	}
+
+	// Return the result
+	duration := time.Since(started)
+	setServerTiming(w, duration, outcome.engineDuration)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(TriggerJenkinsBuildResponse{
+		BuildResult:      presentBuildResult(h.buildURLs, r, result, buildStatusPath(h.engineName, result.BuildID, outcome.globalBuildID)),
+		TriggerID:        outcome.triggerID,
+		GlobalBuildID:    outcome.globalBuildID,
+		DurationMS:       duration.Milliseconds(),
+		EngineDurationMS: outcome.engineDuration.Milliseconds(),
+		ReplayOf:         origin.replayOf,
+		Labels:           req.Labels,
+		ChangeRef:        req.ChangeRef,
+		Commit:           req.Commit,
+		Ref:              req.Ref,
+		WaitStatus:       waitStatus,
+	}); err != nil {
+		logger.Error("Failed to encode response", "error", err)
+	}
+}
+
+// writeTriggerRefusal writes the error response of a trigger refused before reaching the engine,
+// reporting false for other errors
+func writeTriggerRefusal(w http.ResponseWriter, r *http.Request, job string, err error) bool {
+	if errors.Is(err, errJobNotAllowed) {
+		writeErrorWithRequestID(w, r, http.StatusForbidden, fmt.Sprintf("API key is not allowed to trigger job '%s'", job))
+		return true
+	}
+	var disabledErr *jobDisabledError
+	if errors.As(err, &disabledErr) {
+		writeJobDisabledError(w, r, job, disabledErr)
+		return true
+	}
+	var blackoutErr *blackout.ActiveError
+	if errors.As(err, &blackoutErr) {
+		writeBlackoutError(w, r, job, blackoutErr)
+		return true
+	}
+	var throttledErr *throttle.ThrottledError
+	if errors.As(err, &throttledErr) {
+		writeThrottledError(w, r, job, throttledErr)
+		return true
+	}
+	var quotaErr *quota.ExceededError
+	if errors.As(err, &quotaErr) {
+		writeQuotaError(w, r, job, quotaErr)
+		return true
+	}
+	if status, code, message, ok := policyError(job, err); ok {
+		writePolicyError(w, r, status, code, message)
+		return true
+	}
+	return false
+}
+
+// checkTriggerPolicies returns why a trigger may not run, checking in order job access, kill
+// switches, blackout windows, the authorization hook, and the change policy; nil allows it
+// Refusals are logged; the caller records them in the audit log
+func (h *JenkinsHandler) checkTriggerPolicies(r *http.Request, apiKey string, req TriggerJenkinsBuildRequest, origin triggerOrigin) error {
+	requestID := middleware.GetRequestID(r)
+
+	// Enforce per-key job visibility rules
+	if !middleware.GetPrincipal(r).CanAccessJob(req.Job) {
+		logger.Warn("API key is not allowed to trigger job", "job", req.Job, "request_id", requestID)
+		return errJobNotAllowed
+	}
+
+	// Refuse triggers of jobs disabled by a kill switch
+	if err := checkKillSwitches(req.Job, time.Now(), requestID); err != nil {
+		logger.Warn("Trigger refused by kill switch", "error", err, "job", req.Job, "request_id", requestID)
+		return err
+	}
+
+	// Refuse triggers inside a blackout window unless the key may override it
+	if h.blackouts != nil {
+		now := time.Now()
+		canOverride := middleware.GetPrincipal(r).HasScope(config.ScopeBlackoutOverride)
+		if err := h.blackouts.Check(req.Job, canOverride, now); err != nil {
+			logger.Warn("Trigger refused by blackout window", "error", err, "job", req.Job, "request_id", requestID)
+			return err
+		}
+		if canOverride {
+			for _, window := range h.blackouts.Active(req.Job, now) {
+				logger.Warn("Trigger overrides blackout window", "window", window.Name, "job", req.Job, "request_id", requestID)
+			}
+		}
+	}
+
+	// Ask the external policy service, then enforce the change ticket policy
+	if h.authorizer != nil {
+		if err := h.authorizer.Authorize(r.Context(), authzInput(r, apiKey, req, origin)); err != nil {
+			logger.Warn("Trigger refused by authorization hook", "error", err, "job", req.Job, "request_id", requestID)
+			return err
+		}
+	}
+	if h.changes != nil {
+		if err := h.changes.Check(r.Context(), req.Job, req.ChangeRef); err != nil {
+			logger.Warn("Trigger refused by change policy", "error", err, "job", req.Job, "change_ref", req.ChangeRef, "request_id", requestID)
+			return err
+		}
+	}
+	return nil
+}
+
+// deniedStatus returns the status of a trigger refused by checkTriggerPolicies, as recorded in the audit log
+func deniedStatus(job string, err error) int {
+	if errors.Is(err, errJobNotAllowed) {
+		return http.StatusForbidden
+	}
+	status, _, _, _ := policyError(job, err)
+	return status
+}
+
+// runTrigger checks job access, triggers a validated request on Jenkins, and records the
+// audit log, alert outcome, and build tracking; the caller reports the outcome
+func (h *JenkinsHandler) runTrigger(r *http.Request, req TriggerJenkinsBuildRequest, started time.Time, origin triggerOrigin) triggerOutcome {
+	outcome := triggerOutcome{triggerID: origin.triggerID}
+	if outcome.triggerID == "" {
+		outcome.triggerID = newTriggerID()
+	}
+	requestID := middleware.GetRequestID(r)
+	// Fingerprint the parameters as requested, before transforms may add varying values
+	paramsHash := parametersHash(req.Parameters)
+
+	// Get API key from context
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(string)
+	if !ok {
+		apiKey = "unknown"
+	}
+
+	// Refuse triggers the key, kill switches, blackout windows, or policy services do not allow
+	if err := h.checkTriggerPolicies(r, apiKey, req, origin); err != nil {
+		recordDenied(h.newTriggerAuditLog(r, apiKey, outcome.triggerID, req, started, origin), deniedStatus(req.Job, err), err)
+		outcome.err = err
+		return outcome
+	}
+
+	// Hold back or refuse the trigger while the Jenkins build queue is too long
+	if h.throttle != nil {
+		if err := h.throttle.Admit(r.Context()); err != nil {
+			logger.Warn("Trigger throttled on the build queue length", "error", err, "job", req.Job, "request_id", requestID)
+			status, _, _, _ := policyError(req.Job, err)
+			recordDenied(h.newTriggerAuditLog(r, apiKey, outcome.triggerID, req, started, origin), status, err)
+			outcome.err = err
+			return outcome
+		}
+	}
+
+	// Refuse the trigger once its job category reached a limit; the admitted trigger counts as
+	// running until its build is tracked
+	if h.quotas != nil {
+		release, err := h.quotas.Admit(req.Job)
+		if err != nil {
+			logger.Warn("Trigger refused by job category quota", "error", err, "job", req.Job, "request_id", requestID)
+			status, _, _, _ := policyError(req.Job, err)
+			recordDenied(h.newTriggerAuditLog(r, apiKey, outcome.triggerID, req, started, origin), status, err)
+			outcome.err = err
+			return outcome
+		}
+		defer release()
+	}
+
+	// Rewrite and enrich the parameters once the trigger is allowed; the audit log
+	// records the parameters actually dispatched
+	if h.transforms != nil {
+		params, err := h.transforms.Apply(r.Context(), req.Job, req.Parameters)
+		if err != nil {
+			logger.Error("Failed to transform trigger parameters", "error", err, "job", req.Job, "request_id", requestID)
+			status, _, _, _ := policyError(req.Job, err)
+			auditLog := h.newTriggerAuditLog(r, apiKey, outcome.triggerID, req, started, origin)
+			auditLog.Status = status
+			auditLog.Result = "failed"
+			auditLog.Error = truncateMessage(err.Error(), maxErrorMessageLength)
+			if err := storage.InsertAuditLog(auditLog); err != nil {
+				logger.Error("Failed to insert audit log", "error", err)
+			}
+			outcome.err = err
+			return outcome
+		}
+		req.Parameters = params
+	}
+
+	if origin.dispatch != nil {
+		if err := origin.dispatch(); err != nil {
+			logger.Error("Failed to record the trigger dispatch", "error", err, "job", req.Job, "request_id", requestID)
+			auditLog := h.newTriggerAuditLog(r, apiKey, outcome.triggerID, req, started, origin)
+			auditLog.Status = http.StatusInternalServerError
+			auditLog.Result = "failed"
+			auditLog.Error = truncateMessage(err.Error(), maxErrorMessageLength)
+			if err := storage.InsertAuditLog(auditLog); err != nil {
+				logger.Error("Failed to insert audit log", "error", err)
+			}
+			outcome.err = err
+			return outcome
+		}
+	}
+
+	// Trigger the build, timing the engine round-trip separately from the handler
+	engineStarted := time.Now()
+	result, err := h.triggerEngine(r, req)
+	outcome.engineDuration = time.Since(engineStarted)
+	if err != nil {
+		logger.Error("Failed to trigger build", "error", err, "engine", h.engineName, "job", req.Job, "request_id", requestID)
+
+		// Log the failure to audit logs with the status returned to the client
+		auditLog := h.newTriggerAuditLog(r, apiKey, outcome.triggerID, req, started, origin)
+		auditLog.Status = engineErrorStatus(engine.Classify(err))
+		auditLog.Result = "failed"
+		auditLog.Error = truncateMessage(err.Error(), maxErrorMessageLength)
+		auditLog.EngineDurationMS = outcome.engineDuration.Milliseconds()
+		if err := storage.InsertAuditLog(auditLog); err != nil {
+			logger.Error("Failed to insert audit log", "error", err)
+		}
+
+		if h.alerts != nil {
+			h.alerts.Record(h.engineName, req.Job, true)
+		}
+		if h.incidents != nil {
+			h.incidents.Record(h.engineName, req.Job, err)
+		}
+
+		outcome.err = err
+		return outcome
+	}
+
+	// Log the success to audit logs
+	auditLog := h.newTriggerAuditLog(r, apiKey, outcome.triggerID, req, started, origin)
+	auditLog.Status = http.StatusOK
+	auditLog.Result = "success"
+	auditLog.EngineDurationMS = outcome.engineDuration.Milliseconds()
+	auditLog.BuildID = result.BuildID
+	auditLog.GlobalBuildID = ulid.New()
+	auditLog.ParamsHash = paramsHash
 
+	// The audit entry and the tracked build are written together so statistics never
+	// count a build that has no audit record
+	if err := storage.WithTx(func(tx *storage.Tx) error {
+		if err := tx.InsertAuditLog(auditLog); err != nil {
+			return fmt.Errorf("failed to insert audit log: %w", err)
+		}
+		if h.trackBuilds && result.BuildID != "" {
+			if err := tx.TrackBuild(models.TrackedBuild{
+				BuildID:     result.BuildID,
+				JobName:     req.Job,
+				Engine:      h.engineName,
+				TriggeredAt: time.Now(),
+			}); err != nil {
+				return fmt.Errorf("failed to track build: %w", err)
+			}
+		}
+		return nil
+	}); err != nil {
+		logger.Error("Failed to record trigger", "error", err, "build_id", result.BuildID, "request_id", requestID)
+	}
+
+	if h.alerts != nil {
+		h.alerts.Record(h.engineName, req.Job, false)
+	}
+	if h.incidents != nil {
+		h.incidents.Record(h.engineName, req.Job, nil)
+	}
+	if h.annotator != nil {
+		h.annotator.Triggered(h.engineName, req.Job, result.BuildID, time.Now())
+	}
+
+	outcome.result = result
+	outcome.globalBuildID = auditLog.GlobalBuildID
+	return outcome
+}
+
+// recordDenied audits a trigger refused before reaching the engine
+func recordDenied(auditLog models.AuditLog, status int, err error) {
+	auditLog.Status = status
+	auditLog.Result = "denied"
+	auditLog.Error = truncateMessage(err.Error(), maxErrorMessageLength)
+	if err := storage.InsertAuditLog(auditLog); err != nil {
+		logger.Error("Failed to insert audit log", "error", err)
+	}
+}
+
+// triggerEngine triggers the build, passing the caller and request ID as the build cause
+// to engines that can record it
+func (h *JenkinsHandler) triggerEngine(r *http.Request, req TriggerJenkinsBuildRequest) (*engine.BuildResult, error) {
+	params := h.engineParameters(req)
+	return h.guard.Trigger(func() (*engine.BuildResult, error) {
+		if causeTriggerer, ok := h.jenkinsEngine.(engine.CauseTriggerer); ok {
+			return causeTriggerer.TriggerBuildWithCause(req.Job, params, engine.Cause{
+				Actor:     requestActor(r),
+				RequestID: middleware.GetRequestID(r),
+			})
+		}
+		return h.jenkinsEngine.TriggerBuild(req.Job, params)
+	})
+}
+
+// buildStatus gets the status of a build from the engine
+func (h *JenkinsHandler) buildStatus(buildID string) (*engine.BuildResult, error) {
+	return h.guard.Status(func() (*engine.BuildResult, error) {
+		return h.jenkinsEngine.GetBuildStatus(buildID)
+	})
+}
+
+// engineParameters returns the parameters sent to the engine: the request parameters
+// plus labels injected under the configured prefix, without overriding explicit parameters
+func (h *JenkinsHandler) engineParameters(req TriggerJenkinsBuildRequest) map[string]string {
+	injectLabels := h.labelPrefix != "" && len(req.Labels) > 0
+	injectCommit := h.commitParameter != "" && req.Commit != ""
+	injectRef := h.refParameter != "" && req.Ref != ""
+	if !injectLabels && !injectCommit && !injectRef {
+		return req.Parameters
+	}
+	params := make(map[string]string, len(req.Parameters)+len(req.Labels)+2)
+	if injectLabels {
+		for key, value := range req.Labels {
+			params[h.labelPrefix+key] = value
+		}
+	}
+	if injectCommit {
+		params[h.commitParameter] = req.Commit
+	}
+	if injectRef {
+		params[h.refParameter] = req.Ref
+	}
+	for key, value := range req.Parameters {
+		params[key] = value
+	}
+	return params
+}
+
+// validateTriggerRequest checks the job name and parameters of a trigger request
+// It logs and returns the client-facing error message, or "" when the request is valid
+func validateTriggerRequest(req TriggerJenkinsBuildRequest, requestID string) string {
 	// Validate required fields
 	if req.Job == "" {
 		logger.Error("Job name is required", "request_id", requestID)
-		writeErrorWithRequestID(w, r, http.StatusBadRequest, "Job name is required")
-		return
+		return "Job name is required"
 	}
 
 	// Validate job name length (Jenkins job names are typically limited)
 	if len(req.Job) > 255 {
 		logger.Error("Job name too long", "length", len(req.Job), "request_id", requestID)
-		writeErrorWithRequestID(w, r, http.StatusBadRequest, "Job name exceeds maximum length of 255 characters")
-		return
+		return "Job name exceeds maximum length of 255 characters"
 	}
 
 	// Validate job name format (supports folder structure: folder/subfolder/job)
 	if !jobNameRegex.MatchString(req.Job) {
 		logger.Error("Invalid job name format", "job", req.Job, "request_id", requestID)
-		writeErrorWithRequestID(w, r, http.StatusBadRequest, "Invalid job name format: only alphanumeric characters, underscores, hyphens, slashes, and spaces are allowed")
-		return
+		return "Invalid job name format: only alphanumeric characters, underscores, hyphens, slashes, and spaces are allowed"
 	}
 
 	// Validate parameters
@@ -87,8 +718,7 @@ func (h *JenkinsHandler) TriggerJenkinsBuild(w http.ResponseWriter, r *http.Requ
 		// Limit number of parameters
 		if len(req.Parameters) > 100 {
 			logger.Error("Too many parameters", "count", len(req.Parameters), "request_id", requestID)
-			writeErrorWithRequestID(w, r, http.StatusBadRequest, "Maximum 100 parameters allowed")
-			return
+			return "Maximum 100 parameters allowed"
 		}
 
 		// Validate parameter keys and values
@@ -96,88 +726,257 @@ func (h *JenkinsHandler) TriggerJenkinsBuild(w http.ResponseWriter, r *http.Requ
 			// Validate parameter key is not empty
 			if key == "" {
 				logger.Error("Parameter key cannot be empty", "request_id", requestID)
-				writeErrorWithRequestID(w, r, http.StatusBadRequest, "Parameter key cannot be empty")
-				return
+				return "Parameter key cannot be empty"
 			}
 
 			// Validate parameter key length
 			if len(key) > 255 {
 				logger.Error("Parameter key too long", "key", key, "length", len(key), "request_id", requestID)
-				writeErrorWithRequestID(w, r, http.StatusBadRequest, fmt.Sprintf("Parameter key '%s' exceeds maximum length of 255 characters", key))
-				return
+				return fmt.Sprintf("Parameter key '%s' exceeds maximum length of 255 characters", key)
 			}
 
 			// Validate parameter key format (no leading/trailing dots, no consecutive dots)
 			if !parameterKeyRegex.MatchString(key) {
 				logger.Error("Invalid parameter key format", "key", key, "request_id", requestID)
-				writeErrorWithRequestID(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid parameter key format '%s': only alphanumeric characters, underscores, hyphens, and dots (not leading/trailing/consecutive) are allowed", key))
-				return
+				return fmt.Sprintf("Invalid parameter key format '%s': only alphanumeric characters, underscores, hyphens, and dots (not leading/trailing/consecutive) are allowed", key)
 			}
 
 			// Additional validation: check for leading/trailing dots and consecutive dots
 			if strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") || strings.Contains(key, "..") {
 				logger.Error("Invalid parameter key format", "key", key, "request_id", requestID, "reason", "leading/trailing/consecutive dots not allowed")
-				writeErrorWithRequestID(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid parameter key format '%s': dots cannot be leading, trailing, or consecutive", key))
-				return
+				return fmt.Sprintf("Invalid parameter key format '%s': dots cannot be leading, trailing, or consecutive", key)
 			}
 
 			// Validate parameter value length (limit to 10KB per parameter)
 			if len(value) > 10240 {
 				logger.Error("Parameter value too long", "key", key, "length", len(value), "request_id", requestID)
-				writeErrorWithRequestID(w, r, http.StatusBadRequest, fmt.Sprintf("Parameter value for '%s' exceeds maximum length of 10KB", key))
-				return
+				return fmt.Sprintf("Parameter value for '%s' exceeds maximum length of 10KB", key)
+			}
+
+			// Validate credential references (@cred:<id>), forwarded to the engine as credential IDs
+			if engine.IsCredentialRef(value) {
+				if _, ok := engine.ParseCredentialRef(value); !ok {
+					logger.Error("Invalid credential reference", "key", key, "request_id", requestID)
+					return fmt.Sprintf("Invalid credential reference for '%s': expected %s<id> with an ID of alphanumeric characters, underscores, dots, and hyphens", key, engine.CredentialRefPrefix)
+				}
 			}
 		}
 	}
 
-	// Trigger the build
-	result, err := h.jenkinsEngine.TriggerBuild(req.Job, req.Parameters)
+	if message := validateChangeRef(req.ChangeRef); message != "" {
+		logger.Error("Invalid change_ref", "reason", message, "request_id", requestID)
+		return message
+	}
+
+	if message := validateCommit(req.Commit); message != "" {
+		logger.Error("Invalid commit", "reason", message, "request_id", requestID)
+		return message
+	}
+	if message := validateRef(req.Ref); message != "" {
+		logger.Error("Invalid ref", "reason", message, "request_id", requestID)
+		return message
+	}
+
+	if message := validateLabels(req.Labels); message != "" {
+		logger.Error("Invalid trigger labels", "reason", message, "request_id", requestID)
+		return message
+	}
+
+	if req.NotBefore != nil && req.Deadline != nil && !req.Deadline.After(*req.NotBefore) {
+		logger.Error("Trigger deadline is not after not_before", "request_id", requestID)
+		return "deadline must be after not_before"
+	}
+
+	return ""
+}
+
+// validateLabels checks label keys and values, returning the client-facing error message or ""
+// Keys follow the parameter key format so they can be injected as Jenkins parameters
+func validateLabels(labels map[string]string) string {
+	if len(labels) > maxLabels {
+		return fmt.Sprintf("Maximum %d labels allowed", maxLabels)
+	}
+	for key, value := range labels {
+		if message := validateLabel(key, value); message != "" {
+			return message
+		}
+	}
+	return ""
+}
+
+// validateLabel checks a single label key and value
+func validateLabel(key, value string) string {
+	if key == "" {
+		return "Label key cannot be empty"
+	}
+	if len(key) > maxLabelKeyLength {
+		return fmt.Sprintf("Label key '%s' exceeds maximum length of %d characters", key, maxLabelKeyLength)
+	}
+	if !parameterKeyRegex.MatchString(key) {
+		return fmt.Sprintf("Invalid label key format '%s': only alphanumeric characters, underscores, hyphens, and dots (not leading/trailing/consecutive) are allowed", key)
+	}
+	if len(value) > maxLabelValueLength {
+		return fmt.Sprintf("Label value for '%s' exceeds maximum length of %d characters", key, maxLabelValueLength)
+	}
+	return ""
+}
+
+// ListJenkinsJobs handles the GET /api/v1/jenkins/jobs request
+// Supports optional view and folder filters; only jobs visible to the API key are returned
+func (h *JenkinsHandler) ListJenkinsJobs(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	lister, ok := h.jenkinsEngine.(engine.JobLister)
+	if !ok {
+		writeErrorWithRequestID(w, r, http.StatusNotImplemented, "Job discovery is not supported by this engine")
+		return
+	}
+
+	filter := engine.JobFilter{
+		View:   r.URL.Query().Get("view"),
+		Folder: strings.Trim(r.URL.Query().Get("folder"), "/"),
+	}
+	if filter.View != "" && !viewNameRegex.MatchString(filter.View) {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, "Invalid view name format")
+		return
+	}
+	if filter.Folder != "" && (!jobNameRegex.MatchString(filter.Folder) || strings.Contains(filter.Folder, "//")) {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, "Invalid folder format")
+		return
+	}
+
+	jobs, err := lister.ListJobs(filter)
 	if err != nil {
-		logger.Error("Failed to trigger Jenkins build", "error", err, "job", req.Job, "request_id", requestID)
-
-		// Log the failure to audit logs
-		auditLog := models.AuditLog{
-			Timestamp: time.Now(),
-			APIKey:    apiKey,
-			Method:    r.Method,
-			Path:      r.URL.Path,
-			Status:    http.StatusInternalServerError,
-			JobName:   req.Job,
-			Params:    marshalParams(req.Parameters),
-			Result:    "failed",
-			Error:     err.Error(),
+		logger.Error("Failed to list Jenkins jobs", "error", err, "view", filter.View, "folder", filter.Folder, "request_id", requestID)
+		writeEngineError(w, r, "Failed to list jobs", err)
+		return
+	}
+
+	// Drop jobs the API key is not allowed to see
+	principal := middleware.GetPrincipal(r)
+	visible := make([]engine.JobInfo, 0, len(jobs))
+	for _, job := range jobs {
+		allowed := principal.CanAccessJob(job.Name)
+		if job.Folder {
+			allowed = principal.CanSeeFolder(job.Name)
 		}
-		if err := storage.InsertAuditLog(auditLog); err != nil {
-			logger.Error("Failed to insert audit log", "error", err)
+		if allowed {
+			// Folders have no TriggerMesh page, so their URL is omitted in rewrite mode
+			var jobPath string
+			if !job.Folder {
+				jobPath = jobPathPrefix + job.Name + buildHistorySuffix
+			}
+			job.URL = presentURL(h.buildURLs, r, job.URL, jobPath)
+			visible = append(visible, job)
 		}
+	}
 
-		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(result); err != nil {
-			logger.Error("Failed to encode response", "error", err)
-		}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"jobs": visible,
+	}); err != nil {
+		logger.Error("Failed to encode jobs response", "error", err, "request_id", requestID)
+	}
+}
+
+// GetJenkinsBuildStatus handles the GET /api/v1/jenkins/builds/{job}/{number} request
+func (h *JenkinsHandler) GetJenkinsBuildStatus(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	// Log the success to audit logs
-	auditLog := models.AuditLog{
-		Timestamp: time.Now(),
-		APIKey:    apiKey,
-		Method:    r.Method,
-		Path:      r.URL.Path,
-		Status:    http.StatusOK,
-		JobName:   req.Job,
-		Params:    marshalParams(req.Parameters),
-		Result:    "success",
+	// The build ID is the remainder of the path: jobName/buildNumber
+	buildID := strings.Trim(strings.TrimPrefix(r.URL.Path, buildStatusPathPrefix), "/")
+	jobName, buildNumber, ok := strings.Cut(buildID, "/")
+	if !ok || !jobNameRegex.MatchString(jobName) || !buildNumberRegex.MatchString(buildNumber) {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, "Invalid build ID format: expected jobName/buildNumber")
+		return
 	}
-	if err := storage.InsertAuditLog(auditLog); err != nil {
-		logger.Error("Failed to insert audit log", "error", err)
+
+	if !middleware.GetPrincipal(r).CanAccessJob(jobName) {
+		writeErrorWithRequestID(w, r, http.StatusForbidden, fmt.Sprintf("API key is not allowed to access job '%s'", jobName))
+		return
 	}
 
-	// Return the result
+	result, err := h.buildStatus(buildID)
+	if err != nil {
+		logger.Error("Failed to get Jenkins build status", "error", err, "build_id", buildID, "request_id", requestID)
+		writeEngineError(w, r, "Failed to get build status", err)
+		return
+	}
+
+	// Labels are informational; a failed lookup does not fail the status request
+	labels, err := storage.GetBuildLabels(buildID)
+	if err != nil {
+		logger.Warn("Failed to get build labels", "error", err, "build_id", buildID, "request_id", requestID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(result); err != nil {
-		logger.Error("Failed to encode response", "error", err)
+	if err := json.NewEncoder(w).Encode(BuildStatusResponse{
+		BuildResult: presentBuildResult(h.buildURLs, r, result, buildStatusPath(jenkinsEngineName, buildID, "")),
+		Labels:      labels,
+	}); err != nil {
+		logger.Error("Failed to encode build status response", "error", err, "request_id", requestID)
+	}
+}
+
+// newTriggerAuditLog builds the audit record for a trigger request received over HTTP
+// The duration covers the handler from the start of the request until now
+func (h *JenkinsHandler) newTriggerAuditLog(r *http.Request, apiKey, triggerID string, req TriggerJenkinsBuildRequest, started time.Time, origin triggerOrigin) models.AuditLog {
+	auditLog := models.AuditLog{
+		Timestamp:  time.Now(),
+		APIKey:     apiKey,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		JobName:    req.Job,
+		Params:     marshalParams(req.Parameters),
+		Source:     origin.source,
+		Engine:     h.engineName,
+		TriggerID:  triggerID,
+		DurationMS: time.Since(started).Milliseconds(),
+		ReplayOf:   origin.replayOf,
+		Labels:     req.Labels,
+		ChangeRef:  req.ChangeRef,
+		Commit:     req.Commit,
+		Ref:        req.Ref,
+	}
+	if principal := middleware.GetPrincipal(r); principal != nil {
+		auditLog.Tenant = principal.Tenant
+	}
+	if h.maxAuditParams > 0 && len(auditLog.Params) > h.maxAuditParams {
+		if h.keepFullParams {
+			auditLog.FullParams = auditLog.Params
+		}
+		auditLog.Params = truncateUTF8(auditLog.Params, h.maxAuditParams)
+		auditLog.ParamsTruncated = true
+	}
+	return auditLog
+}
+
+// setServerTiming reports the handler and engine durations in a Server-Timing header
+func setServerTiming(w http.ResponseWriter, total, engineDuration time.Duration) {
+	w.Header().Set("Server-Timing", fmt.Sprintf("engine;dur=%.1f, total;dur=%.1f",
+		float64(engineDuration.Microseconds())/1000, float64(total.Microseconds())/1000))
+}
+
+// newTriggerID generates a unique ID for a trigger attempt
+func newTriggerID() string {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		// Fall back to a timestamp; uniqueness is best effort when crypto/rand is unavailable
+		return fmt.Sprintf("trg-%d", time.Now().UnixNano())
 	}
+	return hex.EncodeToString(bytes)
 }
 
 // marshalParams marshals parameters to a JSON string
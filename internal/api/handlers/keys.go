@@ -0,0 +1,452 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/authz"
+	"triggermesh/internal/config"
+	"triggermesh/internal/email"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// keyRequestsPath is the route of API key requests: /api/v1/keys/requests/{id}[/{action}]
+const keyRequestsPath = "/api/v1/keys/requests"
+
+// issuedKeyPrefix marks keys issued through key requests, so they are recognizable in secret scanners
+const issuedKeyPrefix = "tmk_"
+
+// API key request limits
+const (
+	maxKeyNameLength       = 64
+	maxKeyReasonLength     = 1024
+	maxKeyJobPatterns      = 50
+	defaultKeyRequestLimit = 100
+	maxKeyRequestLimit     = 1000
+)
+
+// Key request actions in POST /api/v1/keys/requests/{id}/{action}
+const (
+	keyActionApprove = "approve"
+	keyActionDeny    = "deny"
+	keyActionClaim   = "claim"
+)
+
+// CreateKeyRequest is the body of POST /api/v1/keys/requests
+type CreateKeyRequest struct {
+	Name      string     `json:"name"`       // Client name of the key, recorded in audit logs
+	Tenant    string     `json:"tenant"`     // Optional
+	Jobs      []string   `json:"jobs"`       // Job name patterns ("*" wildcard); empty requests all jobs
+	Scopes    []string   `json:"scopes"`     // admin or blackout_override
+	Reason    string     `json:"reason"`     // Shown to the approving admin
+	ExpiresAt *time.Time `json:"expires_at"` // Optional; the key is refused from this time
+}
+
+// KeyDecisionRequest is the optional body of the approve and deny actions
+type KeyDecisionRequest struct {
+	Note string `json:"note"`
+}
+
+// KeyClaimResponse is the response of the claim action; the key is never shown again
+type KeyClaimResponse struct {
+	*models.KeyRequest
+	Key string `json:"key"`
+}
+
+// KeyRequestHandler handles the self-service API key request workflow:
+// any API client requests a key, an admin approves or denies it, and the requester claims it once
+// Requests belong to the API key that made them, identified by its fingerprint, since client names
+// are chosen by requesters
+type KeyRequestHandler struct {
+	mailer     *email.Mailer
+	clientName func(name string) bool // Reports the names of configured clients; nil reports none
+}
+
+// NewKeyRequestHandler creates a new KeyRequestHandler instance
+func NewKeyRequestHandler() *KeyRequestHandler {
+	return &KeyRequestHandler{}
+}
+
+// SetMailer emails new key requests to the approvers
+func (h *KeyRequestHandler) SetMailer(mailer *email.Mailer) {
+	h.mailer = mailer
+}
+
+// SetClientNames refuses key requests for a name that isClient reports as a configured client
+func (h *KeyRequestHandler) SetClientNames(isClient func(name string) bool) {
+	h.clientName = isClient
+}
+
+// Requests handles POST (create) and GET (list) on /api/v1/keys/requests
+// Admins list every request; other clients only see their own
+func (h *KeyRequestHandler) Requests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.create(w, r)
+	case http.MethodGet:
+		h.list(w, r)
+	default:
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// create records a pending key request
+func (h *KeyRequestHandler) create(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	var body CreateKeyRequest
+	if err := decodeJSON(r, &body, false); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if message := validateKeyRequest(body); message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+	// Client names identify keys in audit logs, so a requested key cannot take the name of another
+	nameTaken := fmt.Sprintf("Name '%s' is already used by another API key", body.Name)
+	if h.clientName != nil && h.clientName(body.Name) {
+		writeErrorWithRequestID(w, r, http.StatusConflict, nameTaken)
+		return
+	}
+
+	request := models.KeyRequest{
+		Name:        body.Name,
+		Tenant:      body.Tenant,
+		Jobs:        body.Jobs,
+		Scopes:      body.Scopes,
+		Reason:      body.Reason,
+		ExpiresAt:   body.ExpiresAt,
+		RequestedBy: requestOwner(r),
+		Status:      models.KeyRequestPending,
+		CreatedAt:   time.Now().UTC(),
+	}
+	id, err := storage.InsertKeyRequest(request)
+	if errors.Is(err, storage.ErrKeyNameTaken) {
+		writeErrorWithRequestID(w, r, http.StatusConflict, nameTaken)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to store key request", "error", err, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to store key request")
+		return
+	}
+	request.ID = id
+
+	logger.Info("API key requested", "key_request_id", id, "name", request.Name, "requested_by", request.RequestedBy, "request_id", requestID)
+	recordAdminAction(r, models.ConfigActionKeyRequest, keyRequestDetails(request))
+	if h.mailer != nil {
+		h.mailer.KeyRequested(request)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(request); err != nil {
+		logger.Error("Failed to encode key request response", "error", err, "request_id", requestID)
+	}
+}
+
+// list returns key requests, newest first
+func (h *KeyRequestHandler) list(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	limit := defaultKeyRequestLimit
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsed > 0 {
+		limit = min(parsed, maxKeyRequestLimit)
+	}
+	offset := 0
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && parsed >= 0 {
+		offset = parsed
+	}
+	requestedBy := ""
+	if !middleware.GetPrincipal(r).HasScope(config.ScopeAdmin) {
+		requestedBy = requestOwner(r)
+	}
+
+	requests, err := storage.GetKeyRequests(r.URL.Query().Get("status"), requestedBy, limit, offset)
+	if err != nil {
+		logger.Error("Failed to get key requests", "error", err, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get key requests")
+		return
+	}
+	if requests == nil {
+		requests = []models.KeyRequest{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(requests); err != nil {
+		logger.Error("Failed to encode key requests response", "error", err, "request_id", requestID)
+	}
+}
+
+// Request handles /api/v1/keys/requests/{id}: GET returns the request, and POST to
+// /approve or /deny (admin scope) decides it, and POST to /claim issues the key to the requester
+func (h *KeyRequestHandler) Request(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	idPart, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, keyRequestsPath+"/"), "/")
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || id <= 0 {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, "Not found")
+		return
+	}
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+	case (action == keyActionApprove || action == keyActionDeny || action == keyActionClaim) && r.Method == http.MethodPost:
+	case action == "" || action == keyActionApprove || action == keyActionDeny || action == keyActionClaim:
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	default:
+		writeErrorWithRequestID(w, r, http.StatusNotFound, "Not found")
+		return
+	}
+
+	isAdmin := middleware.GetPrincipal(r).HasScope(config.ScopeAdmin)
+	if (action == keyActionApprove || action == keyActionDeny) && !isAdmin {
+		writeErrorWithRequestID(w, r, http.StatusForbidden, "Deciding key requests requires the admin scope")
+		return
+	}
+
+	request, err := storage.GetKeyRequest(id)
+	if err != nil {
+		logger.Error("Failed to get key request", "error", err, "key_request_id", id, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get key request")
+		return
+	}
+	// Requests of other keys are reported as missing so their IDs are not disclosed
+	owner := requestOwner(r)
+	if request == nil || (!isAdmin && request.RequestedBy != owner) || (action == keyActionClaim && request.RequestedBy != owner) {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Key request %d not found", id))
+		return
+	}
+
+	switch action {
+	case keyActionApprove, keyActionDeny:
+		h.decide(w, r, request, action)
+	case keyActionClaim:
+		h.claim(w, r, request)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(request); err != nil {
+			logger.Error("Failed to encode key request response", "error", err, "request_id", requestID)
+		}
+	}
+}
+
+// decide approves or denies a pending key request
+func (h *KeyRequestHandler) decide(w http.ResponseWriter, r *http.Request, request *models.KeyRequest, action string) {
+	requestID := middleware.GetRequestID(r)
+
+	// The body is optional
+	var body KeyDecisionRequest
+	if err := decodeJSON(r, &body, true); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if len(body.Note) > maxKeyReasonLength {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, fmt.Sprintf("Note cannot exceed %d characters", maxKeyReasonLength))
+		return
+	}
+
+	// A key request needs a second person, so an admin cannot grant themselves a new key
+	actor := requestActor(r)
+	if action == keyActionApprove && request.RequestedBy == requestOwner(r) {
+		writeErrorWithRequestID(w, r, http.StatusForbidden, "Key requests must be approved by another admin")
+		return
+	}
+
+	status, configAction := models.KeyRequestApproved, models.ConfigActionKeyApprove
+	if action == keyActionDeny {
+		status, configAction = models.KeyRequestDenied, models.ConfigActionKeyDeny
+	}
+	decidedAt := time.Now().UTC()
+	decided, err := storage.DecideKeyRequest(request.ID, status, actor, body.Note, decidedAt)
+	if err != nil {
+		logger.Error("Failed to decide key request", "error", err, "key_request_id", request.ID, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to decide key request")
+		return
+	}
+	if !decided {
+		writeErrorWithRequestID(w, r, http.StatusConflict, fmt.Sprintf("Key request %d was already decided", request.ID))
+		return
+	}
+	request.Status = status
+	request.DecidedBy = actor
+	request.DecisionNote = body.Note
+	request.DecidedAt = &decidedAt
+
+	logger.Info("API key request decided", "key_request_id", request.ID, "status", status, "decided_by", actor, "request_id", requestID)
+	recordAdminAction(r, configAction, keyRequestDetails(*request))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(request); err != nil {
+		logger.Error("Failed to encode key request response", "error", err, "request_id", requestID)
+	}
+}
+
+// claim issues the key of an approved request; only its hash is stored, so the key is shown once
+func (h *KeyRequestHandler) claim(w http.ResponseWriter, r *http.Request, request *models.KeyRequest) {
+	requestID := middleware.GetRequestID(r)
+
+	switch request.Status {
+	case models.KeyRequestPending:
+		writeErrorWithRequestID(w, r, http.StatusConflict, fmt.Sprintf("Key request %d has not been approved yet", request.ID))
+		return
+	case models.KeyRequestDenied:
+		writeErrorWithRequestID(w, r, http.StatusConflict, fmt.Sprintf("Key request %d was denied", request.ID))
+		return
+	case models.KeyRequestIssued:
+		writeErrorWithRequestID(w, r, http.StatusGone, fmt.Sprintf("The key of request %d was already issued", request.ID))
+		return
+	}
+
+	key, err := newIssuedKey()
+	if err != nil {
+		logger.Error("Failed to generate API key", "error", err, "key_request_id", request.ID, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to issue key")
+		return
+	}
+	issuedAt := time.Now().UTC()
+	keyID := authz.KeyID(key)
+	issued, err := storage.IssueKeyRequest(request.ID, hashAPIKey(key), keyID, issuedAt)
+	if err != nil {
+		logger.Error("Failed to issue API key", "error", err, "key_request_id", request.ID, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to issue key")
+		return
+	}
+	if !issued {
+		// A concurrent claim won
+		writeErrorWithRequestID(w, r, http.StatusGone, fmt.Sprintf("The key of request %d was already issued", request.ID))
+		return
+	}
+	request.Status = models.KeyRequestIssued
+	request.KeyID = keyID
+	request.IssuedAt = &issuedAt
+
+	logger.Info("API key issued", "key_request_id", request.ID, "name", request.Name, "key_id", keyID, "request_id", requestID)
+	recordAdminAction(r, models.ConfigActionKeyIssue, keyRequestDetails(*request)+" key_id="+keyID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(KeyClaimResponse{KeyRequest: request, Key: key}); err != nil {
+		logger.Error("Failed to encode key claim response", "error", err, "request_id", requestID)
+	}
+}
+
+// requestOwner identifies the API key of a request by its fingerprint, as key:<key ID>, which unlike
+// the client name cannot be chosen by another key
+func requestOwner(r *http.Request) string {
+	apiKey, _ := r.Context().Value(middleware.APIKeyContextKey).(string)
+	return "key:" + authz.KeyID(apiKey)
+}
+
+// LookupIssuedKey returns the principal of a key issued through a key request, or nil if the key
+// was not issued; it is the AuthMiddleware lookup for keys that are not in the configuration
+func LookupIssuedKey(apiKey string) *middleware.Principal {
+	if !strings.HasPrefix(apiKey, issuedKeyPrefix) {
+		return nil
+	}
+	request, err := storage.GetIssuedKey(hashAPIKey(apiKey))
+	if err != nil {
+		logger.Warn("Failed to look up issued API key", "error", err)
+		return nil
+	}
+	if request == nil {
+		return nil
+	}
+	principal := &middleware.Principal{
+		Name:   request.Name,
+		Tenant: request.Tenant,
+		Jobs:   request.Jobs,
+		Scopes: request.Scopes,
+		Owner:  request.RequestedBy,
+	}
+	if request.ExpiresAt != nil {
+		principal.ExpiresAt = *request.ExpiresAt
+	}
+	return principal
+}
+
+// validateKeyRequest returns the error message for an invalid key request, or "" if it is valid
+func validateKeyRequest(body CreateKeyRequest) string {
+	if body.Name == "" {
+		return "Name is required"
+	}
+	if len(body.Name) > maxKeyNameLength || strings.IndexFunc(body.Name, func(c rune) bool { return !unicode.IsPrint(c) }) >= 0 {
+		return fmt.Sprintf("Name must be at most %d printable characters", maxKeyNameLength)
+	}
+	// Actors such as key:<fingerprint> and schedule:<name> are told apart from client names by the colon
+	if strings.Contains(body.Name, ":") {
+		return "Name cannot contain ':'"
+	}
+	if len(body.Tenant) > maxKeyNameLength {
+		return fmt.Sprintf("Tenant cannot exceed %d characters", maxKeyNameLength)
+	}
+	if len(body.Reason) > maxKeyReasonLength {
+		return fmt.Sprintf("Reason cannot exceed %d characters", maxKeyReasonLength)
+	}
+	if len(body.Jobs) > maxKeyJobPatterns {
+		return fmt.Sprintf("Too many job patterns (max %d)", maxKeyJobPatterns)
+	}
+	for _, pattern := range body.Jobs {
+		if pattern == "" {
+			return "Job patterns cannot be empty"
+		}
+	}
+	for _, scope := range body.Scopes {
+		if scope != config.ScopeAdmin && scope != config.ScopeBlackoutOverride && scope != config.ScopeInternalURLs {
+			return fmt.Sprintf("Invalid scope '%s' (must be admin, blackout_override, or internal_urls)", scope)
+		}
+	}
+	if body.ExpiresAt != nil && !body.ExpiresAt.After(time.Now()) {
+		return "expires_at must be in the future"
+	}
+	return ""
+}
+
+// keyRequestDetails describes a key request in the configuration audit
+func keyRequestDetails(request models.KeyRequest) string {
+	details := fmt.Sprintf("key_request_id=%d name=%s requested_by=%s", request.ID, request.Name, request.RequestedBy)
+	if len(request.Jobs) > 0 {
+		details += " jobs=" + strings.Join(request.Jobs, ",")
+	}
+	if len(request.Scopes) > 0 {
+		details += " scopes=" + strings.Join(request.Scopes, ",")
+	}
+	if request.ExpiresAt != nil {
+		details += " expires_at=" + request.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return details
+}
+
+// newIssuedKey generates a random API key
+func newIssuedKey() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return issuedKeyPrefix + base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// hashAPIKey returns the stored form of an issued API key
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/config"
+	"triggermesh/internal/jobmatch"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// killSwitchesPath is the route of kill switches: /api/v1/admin/kill-switches[/{id}]
+const killSwitchesPath = "/api/v1/admin/kill-switches"
+
+// Kill switch limits
+const (
+	maxKillSwitchPatternLength = 255
+	maxKillSwitchReasonLength  = 1024
+	maxKillSwitchDuration      = 30 * 24 * time.Hour
+)
+
+// killSwitchPatternRegex validates kill switch patterns: job names with "*" wildcards
+var killSwitchPatternRegex = regexp.MustCompile(`^[a-zA-Z0-9_/\- *]+$`)
+
+// CreateKillSwitchRequest is the body of POST /api/v1/admin/kill-switches
+type CreateKillSwitchRequest struct {
+	Pattern   string    `json:"pattern"`    // Job name pattern ("*" wildcard)
+	Reason    string    `json:"reason"`     // Returned to callers whose triggers are refused
+	ExpiresAt time.Time `json:"expires_at"` // Triggers are allowed again from this time (at most 30 days ahead)
+}
+
+// jobDisabledError is the runTrigger error for jobs disabled by a kill switch
+type jobDisabledError struct {
+	killSwitch models.KillSwitch
+}
+
+// Error implements error
+func (e *jobDisabledError) Error() string {
+	return fmt.Sprintf("job disabled by kill switch %d until %s: %s", e.killSwitch.ID, e.killSwitch.ExpiresAt.UTC().Format(time.RFC3339), e.killSwitch.Reason)
+}
+
+// checkKillSwitches returns a jobDisabledError if an active kill switch covers the job, naming the
+// one that expires last; a failed lookup lets the trigger through
+func checkKillSwitches(job string, now time.Time, requestID string) error {
+	killSwitches, err := storage.GetActiveKillSwitches(now)
+	if err != nil {
+		logger.Error("Failed to get kill switches, trigger not checked", "error", err, "job", job, "request_id", requestID)
+		return nil
+	}
+	var disabled *jobDisabledError
+	for _, killSwitch := range killSwitches {
+		if jobmatch.Match(killSwitch.Pattern, job) {
+			disabled = &jobDisabledError{killSwitch: killSwitch}
+		}
+	}
+	if disabled == nil {
+		return nil
+	}
+	return disabled
+}
+
+// writeJobDisabledError writes the error response for a trigger refused by a kill switch,
+// including the kill switch so clients see the reason and when to retry
+func writeJobDisabledError(w http.ResponseWriter, r *http.Request, job string, err *jobDisabledError) {
+	status, code, message, _ := policyError(job, err)
+	if wait := time.Until(err.killSwitch.ExpiresAt); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds()+1)))
+	}
+	writeErrorResponse(w, r, status, map[string]interface{}{
+		"success":     false,
+		"error":       message,
+		"code":        code,
+		"kill_switch": err.killSwitch,
+	})
+}
+
+// KillSwitchHandler lets admins disable triggering of jobs in an emergency, with a reason and
+// an expiry; triggers of covered jobs are refused with 423 Locked until then
+type KillSwitchHandler struct{}
+
+// NewKillSwitchHandler creates a new KillSwitchHandler instance
+func NewKillSwitchHandler() *KillSwitchHandler {
+	return &KillSwitchHandler{}
+}
+
+// KillSwitches handles POST (create) and GET (list active) on /api/v1/admin/kill-switches
+// and DELETE (lift) on /api/v1/admin/kill-switches/{id}; all require the admin scope
+func (h *KillSwitchHandler) KillSwitches(w http.ResponseWriter, r *http.Request) {
+	idPart := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, killSwitchesPath), "/")
+	switch {
+	case idPart == "" && (r.Method == http.MethodPost || r.Method == http.MethodGet):
+	case idPart != "" && r.Method == http.MethodDelete:
+	default:
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !middleware.GetPrincipal(r).HasScope(config.ScopeAdmin) {
+		writeErrorWithRequestID(w, r, http.StatusForbidden, "Managing kill switches requires the admin scope")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.create(w, r)
+	case http.MethodGet:
+		h.list(w, r)
+	default:
+		id, err := strconv.ParseInt(idPart, 10, 64)
+		if err != nil || id <= 0 {
+			writeErrorWithRequestID(w, r, http.StatusNotFound, "Not found")
+			return
+		}
+		h.lift(w, r, id)
+	}
+}
+
+// create stores a kill switch
+func (h *KillSwitchHandler) create(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	var body CreateKillSwitchRequest
+	if err := decodeJSON(r, &body, false); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	now := time.Now().UTC()
+	if message := validateKillSwitch(body, now); message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	killSwitch := models.KillSwitch{
+		Pattern:   body.Pattern,
+		Reason:    body.Reason,
+		CreatedBy: requestActor(r),
+		CreatedAt: now,
+		ExpiresAt: body.ExpiresAt.UTC(),
+	}
+	id, err := storage.InsertKillSwitch(killSwitch)
+	if err != nil {
+		logger.Error("Failed to store kill switch", "error", err, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to store kill switch")
+		return
+	}
+	killSwitch.ID = id
+
+	logger.Warn("Kill switch engaged", "kill_switch_id", id, "pattern", killSwitch.Pattern, "expires_at", killSwitch.ExpiresAt, "created_by", killSwitch.CreatedBy, "request_id", requestID)
+	recordAdminAction(r, models.ConfigActionKillSwitch, fmt.Sprintf("kill_switch_id=%d pattern=%s expires_at=%s reason=%s",
+		id, killSwitch.Pattern, killSwitch.ExpiresAt.Format(time.RFC3339), killSwitch.Reason))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(killSwitch); err != nil {
+		logger.Error("Failed to encode kill switch response", "error", err, "request_id", requestID)
+	}
+}
+
+// list returns the active kill switches, soonest expiry first
+func (h *KillSwitchHandler) list(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	killSwitches, err := storage.GetActiveKillSwitches(time.Now())
+	if err != nil {
+		logger.Error("Failed to get kill switches", "error", err, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get kill switches")
+		return
+	}
+	if killSwitches == nil {
+		killSwitches = []models.KillSwitch{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(killSwitches); err != nil {
+		logger.Error("Failed to encode kill switches response", "error", err, "request_id", requestID)
+	}
+}
+
+// lift ends an active kill switch before it expires
+func (h *KillSwitchHandler) lift(w http.ResponseWriter, r *http.Request, id int64) {
+	requestID := middleware.GetRequestID(r)
+
+	actor := requestActor(r)
+	lifted, err := storage.LiftKillSwitch(id, actor, time.Now().UTC())
+	if err != nil {
+		logger.Error("Failed to lift kill switch", "error", err, "kill_switch_id", id, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to lift kill switch")
+		return
+	}
+	if !lifted {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Active kill switch %d not found", id))
+		return
+	}
+	killSwitch, err := storage.GetKillSwitch(id)
+	if err != nil || killSwitch == nil {
+		logger.Error("Failed to get lifted kill switch", "error", err, "kill_switch_id", id, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get kill switch")
+		return
+	}
+
+	logger.Info("Kill switch lifted", "kill_switch_id", id, "pattern", killSwitch.Pattern, "lifted_by", actor, "request_id", requestID)
+	recordAdminAction(r, models.ConfigActionKillLift, fmt.Sprintf("kill_switch_id=%d pattern=%s", id, killSwitch.Pattern))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(killSwitch); err != nil {
+		logger.Error("Failed to encode kill switch response", "error", err, "request_id", requestID)
+	}
+}
+
+// validateKillSwitch returns the client-facing error message of an invalid kill switch, or ""
+func validateKillSwitch(body CreateKillSwitchRequest, now time.Time) string {
+	if body.Pattern == "" {
+		return "Pattern is required"
+	}
+	if len(body.Pattern) > maxKillSwitchPatternLength || !killSwitchPatternRegex.MatchString(body.Pattern) {
+		return fmt.Sprintf("Invalid pattern '%s': job name characters and '*', at most %d characters", body.Pattern, maxKillSwitchPatternLength)
+	}
+	if strings.TrimSpace(body.Reason) == "" {
+		return "Reason is required"
+	}
+	if len(body.Reason) > maxKillSwitchReasonLength {
+		return fmt.Sprintf("Reason cannot exceed %d characters", maxKillSwitchReasonLength)
+	}
+	if body.ExpiresAt.IsZero() {
+		return "expires_at is required"
+	}
+	if !body.ExpiresAt.After(now) {
+		return "expires_at must be in the future"
+	}
+	if body.ExpiresAt.Sub(now) > maxKillSwitchDuration {
+		return "expires_at cannot be more than 30 days ahead"
+	}
+	return ""
+}
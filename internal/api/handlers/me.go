@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/config"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/quota"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// recentTriggerLimit is the number of the caller's latest triggers in GET /api/v1/me
+const recentTriggerLimit = 10
+
+// usageWindow is the period of the usage counts in GET /api/v1/me
+const usageWindow = 24 * time.Hour
+
+// MeResponse is the response body of GET /api/v1/me
+type MeResponse struct {
+	Name           string            `json:"name"`
+	Tenant         string            `json:"tenant,omitempty"`
+	Owner          string            `json:"owner,omitempty"`
+	Jobs           []string          `json:"jobs"`   // Job name patterns the key may access; empty means all jobs
+	Scopes         []string          `json:"scopes"` // Extra permissions such as admin
+	ExpiresAt      *time.Time        `json:"expires_at,omitempty"`
+	Timezone       string            `json:"timezone,omitempty"`
+	Usage          MeUsage           `json:"usage"`
+	RecentTriggers []models.AuditLog `json:"recent_triggers"`    // Newest first, without the API key
+	Branding       *Branding         `json:"branding,omitempty"` // Presentation of the instance for the key's tenant
+}
+
+// MeUsage counts the caller's audited requests over the usage window
+type MeUsage struct {
+	WindowSeconds    int64     `json:"window_seconds"`
+	Triggers         int64     `json:"triggers"`
+	Failed           int64     `json:"failed"`            // Triggers that ended with status 400 or above
+	QuotasConfigured bool      `json:"quotas_configured"` // False when no job categories are configured
+	Quotas           []MeQuota `json:"quotas"`            // Categories covering the caller's jobs
+}
+
+// MeQuota is the usage of a job category the caller can trigger, shared by every key triggering its jobs
+type MeQuota struct {
+	Category      string   `json:"category"`
+	Jobs          []string `json:"jobs"`
+	MaxConcurrent int      `json:"max_concurrent"` // 0 is unlimited
+	Running       *int     `json:"running"`        // Null when running builds cannot be counted
+	MaxTriggers   int      `json:"max_triggers"`   // 0 is unlimited
+	WindowSeconds int64    `json:"window_seconds"`
+	Triggers      int      `json:"triggers"` // Triggers admitted within the window on this instance
+}
+
+// MeHandler handles GET /api/v1/me, which lets a client inspect its own API key
+type MeHandler struct {
+	branding config.BrandingConfig
+	quotas   *quota.Limiter
+}
+
+// NewMeHandler creates a new MeHandler instance reporting the branding of the caller's tenant
+func NewMeHandler(branding config.BrandingConfig) *MeHandler {
+	return &MeHandler{branding: branding}
+}
+
+// SetQuotas reports the usage of the job categories limited by the limiter
+func (h *MeHandler) SetQuotas(limiter *quota.Limiter) {
+	h.quotas = limiter
+}
+
+// GetMe handles the GET /api/v1/me request
+// It needs no scope: every key may see its own identity and triggers, and nothing else
+func (h *MeHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	requestID := middleware.GetRequestID(r)
+	principal := middleware.GetPrincipal(r)
+	apiKey, _ := r.Context().Value(middleware.APIKeyContextKey).(string)
+	if principal == nil || apiKey == "" {
+		writeErrorWithRequestID(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	location, message := requestLocation(r)
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	logs, err := storage.QueryAuditLogs(models.AuditFilter{APIKey: apiKey}, recentTriggerLimit, 0)
+	if err != nil {
+		logger.Error("Failed to get recent triggers", "error", err, "client", principal.Name, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get recent triggers")
+		return
+	}
+	total, failed, err := storage.CountAPIKeyAuditLogsSince(apiKey, time.Now().Add(-usageWindow))
+	if err != nil {
+		logger.Error("Failed to count triggers", "error", err, "client", principal.Name, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get usage")
+		return
+	}
+	for i := range logs {
+		logs[i].APIKey = ""
+		logs[i].Timestamp = logs[i].Timestamp.In(location)
+	}
+
+	response := MeResponse{
+		Name:     principal.Name,
+		Tenant:   principal.Tenant,
+		Owner:    principal.Owner,
+		Jobs:     principal.Jobs,
+		Scopes:   principal.Scopes,
+		Timezone: principal.Timezone,
+		Usage: MeUsage{
+			WindowSeconds: int64(usageWindow.Seconds()),
+			Triggers:      total,
+			Failed:        failed,
+			Quotas:        []MeQuota{},
+		},
+		RecentTriggers: logs,
+		Branding:       NewBranding(h.branding, principal.Tenant),
+	}
+	if response.Jobs == nil {
+		response.Jobs = []string{}
+	}
+	if response.Scopes == nil {
+		response.Scopes = []string{}
+	}
+	if response.RecentTriggers == nil {
+		response.RecentTriggers = []models.AuditLog{}
+	}
+	if h.quotas != nil {
+		response.Usage.QuotasConfigured = true
+		for _, u := range h.quotas.Usage(principal.Jobs) {
+			q := MeQuota{
+				Category:      u.Name,
+				Jobs:          u.Jobs,
+				MaxConcurrent: u.MaxConcurrent,
+				MaxTriggers:   u.MaxTriggers,
+				WindowSeconds: int64(u.Window.Seconds()),
+				Triggers:      u.Triggers,
+			}
+			if u.Running >= 0 {
+				running := u.Running
+				q.Running = &running
+			}
+			response.Usage.Quotas = append(response.Usage.Quotas, q)
+		}
+	}
+	if !principal.ExpiresAt.IsZero() {
+		expiresAt := principal.ExpiresAt.In(location)
+		response.ExpiresAt = &expiresAt
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Failed to encode me response", "error", err, "request_id", requestID)
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+)
+
+// Jenkins Notification plugin phases that carry the final build status
+const (
+	notificationPhaseCompleted = "COMPLETED"
+	notificationPhaseFinalized = "FINALIZED"
+)
+
+// Outcomes of a build notification in the response status field
+const (
+	notificationRecorded  = "recorded"  // The outcome of a tracked build was recorded
+	notificationUnmatched = "unmatched" // The build is not tracked, or its outcome was already recorded
+	notificationIgnored   = "ignored"   // The event does not report a finished build
+)
+
+// BuildReporter records the outcome of a finished build pushed by its engine and reports whether
+// the build was tracked, as stats.Poller.Report does
+type BuildReporter func(engineName, buildID string, result *engine.BuildResult) (bool, error)
+
+// JenkinsNotification is a build event of the Jenkins Notification plugin; generic webhooks may send
+// the same shape with only name, build.number, build.phase, and build.status
+type JenkinsNotification struct {
+	Name  string `json:"name"` // Job name, without folders
+	Build struct {
+		Number   int64  `json:"number"`
+		Phase    string `json:"phase"`    // QUEUED, STARTED, COMPLETED, or FINALIZED
+		Status   string `json:"status"`   // SUCCESS, FAILURE, UNSTABLE, ABORTED, or NOT_BUILT once completed
+		Duration int64  `json:"duration"` // Milliseconds, sent by recent plugin versions
+		FullURL  string `json:"full_url"`
+	} `json:"build"`
+}
+
+// JenkinsNotificationHandler accepts build events pushed by Jenkins at POST
+// /api/v1/notifications/jenkins, as an alternative to waiting for the build status poller
+// Jenkins authenticates with the notification token in the query string, which grants nothing else
+type JenkinsNotificationHandler struct {
+	token string
+
+	mu       sync.RWMutex
+	reporter BuildReporter // nil until build tracking runs
+}
+
+// NewJenkinsNotificationHandler creates a notification handler accepting the token; an empty token
+// disables the endpoint
+func NewJenkinsNotificationHandler(token string) *JenkinsNotificationHandler {
+	return &JenkinsNotificationHandler{token: token}
+}
+
+// SetReporter sets where finished builds are recorded; it may be called while serving
+func (h *JenkinsNotificationHandler) SetReporter(reporter BuildReporter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.reporter = reporter
+}
+
+// Notify handles the POST /api/v1/notifications/jenkins?token=... request
+func (h *JenkinsNotificationHandler) Notify(w http.ResponseWriter, r *http.Request) {
+	if h.token == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	requestID := middleware.GetRequestID(r)
+
+	token := r.URL.Query().Get("token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		logger.Warn("Jenkins notification with an invalid token", "remote_addr", r.RemoteAddr, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusUnauthorized, "Invalid notification token")
+		return
+	}
+
+	var notification JenkinsNotification
+	if err := decodeExternalJSON(r, &notification); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if notification.Name == "" || strings.Contains(notification.Name, "/") || notification.Build.Number <= 0 {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, "Notification requires the job name and build number")
+		return
+	}
+	buildID := notification.Name + "/" + strconv.FormatInt(notification.Build.Number, 10)
+
+	phase := strings.ToUpper(notification.Build.Phase)
+	if (phase != notificationPhaseCompleted && phase != notificationPhaseFinalized) || notification.Build.Status == "" {
+		writeNotificationResult(w, http.StatusAccepted, notificationIgnored, buildID)
+		return
+	}
+
+	h.mu.RLock()
+	reporter := h.reporter
+	h.mu.RUnlock()
+	if reporter == nil {
+		writeErrorWithRequestID(w, r, http.StatusServiceUnavailable, "Build tracking is not running")
+		return
+	}
+
+	recorded, err := reporter(jenkinsEngineName, buildID, &engine.BuildResult{
+		Success:         true,
+		BuildID:         buildID,
+		BuildURL:        notification.Build.FullURL,
+		Result:          strings.ToUpper(notification.Build.Status),
+		BuildDurationMS: notification.Build.Duration,
+	})
+	if err != nil {
+		logger.Error("Failed to record notified build", "error", err, "build_id", buildID, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to record build")
+		return
+	}
+	if !recorded {
+		writeNotificationResult(w, http.StatusAccepted, notificationUnmatched, buildID)
+		return
+	}
+	logger.Info("Recorded notified build", "build_id", buildID, "result", notification.Build.Status, "request_id", requestID)
+	writeNotificationResult(w, http.StatusOK, notificationRecorded, buildID)
+}
+
+// writeNotificationResult writes the outcome of a build notification
+func writeNotificationResult(w http.ResponseWriter, status int, outcome, buildID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{"status": outcome, "build_id": buildID}); err != nil {
+		logger.Error("Failed to encode notification response", "error", err)
+	}
+}
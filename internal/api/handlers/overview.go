@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+)
+
+// overviewCacheTTL is how long the queue and executor overview is served from cache
+const overviewCacheTTL = 10 * time.Second
+
+// OverviewResponse is the response body of an engine overview request
+type OverviewResponse struct {
+	engine.Overview
+	FetchedAt time.Time `json:"fetched_at"` // When the overview was read from the engine; up to 10s old
+}
+
+// overviewCache remembers the last overview of the engine
+// Concurrent misses share one engine request instead of each querying the engine
+type overviewCache struct {
+	mu       sync.Mutex
+	overview *OverviewResponse
+	expires  time.Time
+}
+
+// get returns the cached overview, fetching it with the reporter once expired
+func (c *overviewCache) get(reporter engine.OverviewReporter) (*OverviewResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.overview != nil && time.Now().Before(c.expires) {
+		return c.overview, nil
+	}
+	overview, err := reporter.Overview()
+	if err != nil {
+		return nil, err
+	}
+	if overview.Nodes == nil {
+		overview.Nodes = []engine.NodeInfo{}
+	}
+	c.overview = &OverviewResponse{Overview: *overview, FetchedAt: time.Now().UTC()}
+	c.expires = time.Now().Add(overviewCacheTTL)
+	return c.overview, nil
+}
+
+// GetJenkinsOverview handles the GET /api/v1/jenkins/overview request
+// It reports the queue length and executor status so callers can decide to trigger now or defer
+func (h *JenkinsHandler) GetJenkinsOverview(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	reporter, ok := h.jenkinsEngine.(engine.OverviewReporter)
+	if !ok {
+		writeErrorWithRequestID(w, r, http.StatusNotImplemented, "Queue and executor status is not supported by this engine")
+		return
+	}
+
+	overview, err := h.overview.get(reporter)
+	if err != nil {
+		logger.Error("Failed to get Jenkins overview", "error", err, "request_id", requestID)
+		writeEngineError(w, r, "Failed to get queue and executor status", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(overview); err != nil {
+		logger.Error("Failed to encode overview response", "error", err, "request_id", requestID)
+	}
+}
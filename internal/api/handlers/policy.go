@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/authz"
+	"triggermesh/internal/blackout"
+	"triggermesh/internal/change"
+	"triggermesh/internal/quota"
+	"triggermesh/internal/throttle"
+	"triggermesh/internal/transform"
+)
+
+// policyError maps a trigger policy or parameter transform error to the response
+// status, stable code, and client message; ok is false for errors that did not come from a policy
+func policyError(job string, err error) (status int, code, message string, ok bool) {
+	var denied *authz.DeniedError
+	var unavailable *authz.UnavailableError
+	var rejected *change.RejectedError
+	var lookupErr *change.LookupError
+	var blackoutErr *blackout.ActiveError
+	var transformErr *transform.Error
+	var throttledErr *throttle.ThrottledError
+	var quotaErr *quota.ExceededError
+	var disabledErr *jobDisabledError
+	switch {
+	case errors.As(err, &denied):
+		message = fmt.Sprintf("Trigger of job '%s' denied by authorization policy", job)
+		if denied.Reason != "" {
+			message += ": " + denied.Reason
+		}
+		return http.StatusForbidden, "POLICY_DENIED", truncateMessage(message, maxErrorMessageLength), true
+	case errors.As(err, &unavailable):
+		return http.StatusBadGateway, "POLICY_UNAVAILABLE", "Failed to obtain an authorization decision", true
+	case errors.Is(err, change.ErrRequired):
+		return http.StatusUnprocessableEntity, "CHANGE_REF_REQUIRED", fmt.Sprintf("Job '%s' requires a change_ref", job), true
+	case errors.As(err, &rejected):
+		return http.StatusUnprocessableEntity, "CHANGE_REF_REJECTED", truncateMessage(fmt.Sprintf("Change '%s' rejected: %s", rejected.Ref, rejected.Reason), maxErrorMessageLength), true
+	case errors.As(err, &lookupErr):
+		return http.StatusBadGateway, "CHANGE_LOOKUP_FAILED", "Failed to verify change_ref with the change lookup service", true
+	case errors.As(err, &disabledErr):
+		message = fmt.Sprintf("Triggering of job '%s' is disabled until %s: %s", job, disabledErr.killSwitch.ExpiresAt.UTC().Format(time.RFC3339), disabledErr.killSwitch.Reason)
+		return http.StatusLocked, "JOB_DISABLED", truncateMessage(message, maxErrorMessageLength), true
+	case errors.As(err, &blackoutErr):
+		message = fmt.Sprintf("Job '%s' is in blackout window '%s' until %s", job, blackoutErr.Window.Name, blackoutErr.Window.End.UTC().Format(time.RFC3339))
+		return http.StatusLocked, "BLACKOUT_ACTIVE", truncateMessage(message, maxErrorMessageLength), true
+	case errors.As(err, &throttledErr):
+		message = fmt.Sprintf("Trigger of job '%s' throttled: the Jenkins build queue has %d builds, above the limit of %d", job, throttledErr.QueueLength, throttledErr.Threshold)
+		return http.StatusTooManyRequests, "THROTTLED", message, true
+	case errors.As(err, &quotaErr):
+		message = fmt.Sprintf("Trigger of job '%s' refused by quota: %s", job, quotaErr.Error())
+		return http.StatusTooManyRequests, "QUOTA_EXCEEDED", truncateMessage(message, maxErrorMessageLength), true
+	case errors.As(err, &transformErr):
+		return http.StatusBadGateway, "PARAMETER_TRANSFORM_FAILED", fmt.Sprintf("Failed to prepare the parameters of job '%s'", job), true
+	case errors.Is(err, errDeadlineExceeded):
+		return http.StatusUnprocessableEntity, "DEADLINE_EXCEEDED", fmt.Sprintf("Deadline for triggering job '%s' has passed", job), true
+	default:
+		return 0, "", "", false
+	}
+}
+
+// writePolicyError writes the error response for a trigger refused by a policy
+func writePolicyError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	writeErrorResponse(w, r, status, map[string]interface{}{
+		"success": false,
+		"error":   message,
+		"code":    code,
+	})
+}
+
+// writeBlackoutError writes the error response for a trigger refused by a blackout window,
+// including the active window so clients know when to retry
+func writeBlackoutError(w http.ResponseWriter, r *http.Request, job string, err *blackout.ActiveError) {
+	status, code, message, _ := policyError(job, err)
+	if wait := time.Until(err.Window.End); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds()+1)))
+	}
+	writeErrorResponse(w, r, status, map[string]interface{}{
+		"success":  false,
+		"error":    message,
+		"code":     code,
+		"blackout": err.Window,
+	})
+}
+
+// writeThrottledError writes the error response for a trigger refused while the build queue is too long,
+// with Retry-After set to when the queue is sampled again
+func writeThrottledError(w http.ResponseWriter, r *http.Request, job string, err *throttle.ThrottledError) {
+	status, code, message, _ := policyError(job, err)
+	w.Header().Set("Retry-After", strconv.Itoa(int(err.RetryAfter.Seconds())))
+	writeErrorResponse(w, r, status, map[string]interface{}{
+		"success":      false,
+		"error":        message,
+		"code":         code,
+		"queue_length": err.QueueLength,
+	})
+}
+
+// writeQuotaError writes the error response for a trigger refused by a job category quota,
+// with Retry-After when the rate window tells when the next trigger is let through
+func writeQuotaError(w http.ResponseWriter, r *http.Request, job string, err *quota.ExceededError) {
+	status, code, message, _ := policyError(job, err)
+	if err.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(err.RetryAfter.Seconds())))
+	}
+	writeErrorResponse(w, r, status, map[string]interface{}{
+		"success":  false,
+		"error":    message,
+		"code":     code,
+		"category": err.Category,
+		"limit":    err.Limit,
+	})
+}
+
+// authzInput describes a trigger request for the authorization hook
+func authzInput(r *http.Request, apiKey string, req TriggerJenkinsBuildRequest, origin triggerOrigin) authz.Input {
+	input := authz.Input{
+		KeyID:      authz.KeyID(apiKey),
+		Job:        req.Job,
+		Parameters: req.Parameters,
+		Labels:     req.Labels,
+		ChangeRef:  req.ChangeRef,
+		Commit:     req.Commit,
+		Ref:        req.Ref,
+		Source:     origin.source,
+		SourceIP:   r.RemoteAddr,
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		input.SourceIP = host
+	}
+	if principal := middleware.GetPrincipal(r); principal != nil {
+		input.Client = principal.Name
+		input.Tenant = principal.Tenant
+		input.Scopes = principal.Scopes
+	}
+	return input
+}
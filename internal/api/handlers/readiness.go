@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"triggermesh/internal/logger"
+	"triggermesh/internal/storage"
+)
+
+// Startup check states reported by /readyz
+const (
+	CheckPending = "pending"
+	CheckOK      = "ok"
+	CheckFailed  = "failed"
+)
+
+// EngineUnchecked is the connectivity state reported by /readyz for an engine not called yet,
+// besides CheckOK and CheckFailed
+const EngineUnchecked = "unchecked"
+
+// ReadinessHandler reports whether startup (storage migrations, engine connectivity
+// checks) has completed and holds back API requests until it has
+// It also reports the connectivity of each engine, from the startup check or the latest call
+type ReadinessHandler struct {
+	mu      sync.RWMutex
+	ready   bool
+	checks  map[string]string
+	engines map[string]string
+}
+
+// NewReadinessHandler creates a ReadinessHandler in the given initial state
+func NewReadinessHandler(ready bool) *ReadinessHandler {
+	return &ReadinessHandler{
+		ready:   ready,
+		checks:  make(map[string]string),
+		engines: make(map[string]string),
+	}
+}
+
+// SetCheck records the state of a named startup check
+func (h *ReadinessHandler) SetCheck(name, state string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = state
+}
+
+// SetEngine records the connectivity state of a named engine
+func (h *ReadinessHandler) SetEngine(name, state string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.engines[name] = state
+}
+
+// RecordEngine records the outcome of a call to a named engine; it matches the observer of
+// resilience.Guard, so engines checked lazily report their state on first use
+func (h *ReadinessHandler) RecordEngine(name string, healthy bool) {
+	if healthy {
+		h.SetEngine(name, CheckOK)
+	} else {
+		h.SetEngine(name, CheckFailed)
+	}
+}
+
+// SetReady marks startup as complete (or not)
+func (h *ReadinessHandler) SetReady(ready bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ready = ready
+}
+
+// Ready reports whether startup has completed
+func (h *ReadinessHandler) Ready() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.ready
+}
+
+// Readyz handles the GET /readyz request
+func (h *ReadinessHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	ready := h.ready
+	checks := make(map[string]string, len(h.checks))
+	for name, state := range h.checks {
+		checks[name] = state
+	}
+	engines := make(map[string]string, len(h.engines))
+	for name, state := range h.engines {
+		engines[name] = state
+	}
+	h.mu.RUnlock()
+
+	status := http.StatusOK
+	body := map[string]interface{}{
+		"status": "ready",
+	}
+	if ready {
+		if err := storage.Ping(); err != nil {
+			logger.Error("Readiness check failed", "error", err)
+			status = http.StatusServiceUnavailable
+			body["status"] = "not_ready"
+			checks["storage"] = CheckFailed
+		}
+	} else {
+		status = http.StatusServiceUnavailable
+		body["status"] = "not_ready"
+	}
+	if len(checks) > 0 {
+		body["checks"] = checks
+	}
+	// Engine states do not affect the status: an unreachable engine fails its own requests only
+	if len(engines) > 0 {
+		body["engines"] = engines
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Error("Failed to encode readiness response", "error", err)
+	}
+}
+
+// Middleware rejects API requests with 503 until startup has completed
+func (h *ReadinessHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.Ready() && strings.HasPrefix(r.URL.Path, "/api/") {
+			w.Header().Set("Retry-After", "5")
+			writeErrorWithRequestID(w, r, http.StatusServiceUnavailable, "Service is starting")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
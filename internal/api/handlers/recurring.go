@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/scheduler"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+const (
+	// scheduleKeyPrefix precedes the schedule name in the API key and client name recorded for
+	// triggers of recurring schedules
+	scheduleKeyPrefix = "schedule:"
+	// maxScheduleFireAttempts is how many times the trigger of a recurring schedule fire time is
+	// attempted before the fire time is given up
+	maxScheduleFireAttempts = 5
+	// scheduleFireLease is how long an attempt holds a fire time; the fire time of an attempt
+	// interrupted by a crash before it called the engine is retried once it ran out
+	scheduleFireLease = 5 * time.Minute
+)
+
+// SetSchedules sets the jobs triggered on cron schedules; schedules with an invalid job name or
+// parameters are logged and left out
+func (h *JenkinsHandler) SetSchedules(schedules []*scheduler.Recurring) {
+	h.schedules = nil
+	for _, schedule := range schedules {
+		req := TriggerJenkinsBuildRequest{Job: schedule.Job, Parameters: schedule.Parameters}
+		if message := validateTriggerRequest(req, ""); message != "" {
+			logger.Error("Invalid recurring schedule, schedule disabled", "schedule", schedule.Name, "error", message)
+			continue
+		}
+		h.schedules = append(h.schedules, schedule)
+	}
+}
+
+// FireRecurringSchedules triggers the jobs of recurring schedules whose fire time has come and
+// returns how many triggers were fired
+// Each fire time is claimed in the database before its trigger runs, so it is claimed once across
+// restarts and instances, and stays pending until its trigger succeeds: a trigger that failed is
+// retried on the next tick and one interrupted by a crash once its lease ran out, up to
+// maxScheduleFireAttempts attempts. An attempt is marked as dispatched before it calls the
+// engine, and one interrupted after that is given up rather than repeated, since the engine may
+// have started its build: a fire time triggers at most one build. Fire times missed while the
+// service was down follow the misfire policy of the schedule. A schedule seen for the first time
+// fires from the next fire time on
+func (h *JenkinsHandler) FireRecurringSchedules(ctx context.Context) (int, error) {
+	fired := 0
+	for _, schedule := range h.schedules {
+		if ctx.Err() != nil {
+			break
+		}
+		now := time.Now()
+		last, ok, err := storage.GetScheduleLastFire(schedule.Name)
+		if err != nil {
+			return fired, err
+		}
+		if !ok {
+			if err := storage.RegisterSchedule(schedule.Name, now); err != nil {
+				return fired, err
+			}
+			logger.Info("Recurring schedule registered", "schedule", schedule.Name, "job", schedule.Job)
+			continue
+		}
+
+		// Retry the fire times whose trigger failed before firing new ones
+		retries, err := storage.ClaimScheduleRetries(schedule.Name, now, now.Add(scheduleFireLease))
+		if err != nil {
+			return fired, err
+		}
+		for _, fire := range retries {
+			if ctx.Err() != nil {
+				break
+			}
+			if h.retryScheduleFire(ctx, schedule, fire) {
+				fired++
+			}
+		}
+
+		fires, latest, missed := schedule.Due(last, now)
+		if latest.IsZero() {
+			continue
+		}
+		if missed {
+			logger.Warn("Recurring schedule missed fire times", "schedule", schedule.Name, "job", schedule.Job, "misfire", schedule.Misfire(), "last_fire", last, "latest_fire", latest, "fires", len(fires))
+		}
+		if len(fires) == 0 {
+			// Skipped fire times are claimed too, so they are not reconsidered
+			if _, err := storage.SkipScheduleFires(schedule.Name, last, latest); err != nil {
+				return fired, err
+			}
+			continue
+		}
+
+		for _, fireTime := range fires {
+			if ctx.Err() != nil {
+				break
+			}
+			fire := models.PendingScheduleFire{
+				Name:      schedule.Name,
+				FireAt:    fireTime,
+				TriggerID: newTriggerID(),
+				Attempts:  1,
+				RetryAt:   time.Now().Add(scheduleFireLease),
+			}
+			claimed, err := storage.ClaimScheduleFire(schedule.Name, last, fire)
+			if err != nil {
+				return fired, err
+			}
+			if !claimed {
+				// Another instance fires this schedule
+				break
+			}
+			last = fireTime
+			if h.fireSchedule(ctx, schedule, fire) {
+				fired++
+			}
+		}
+	}
+	return fired, nil
+}
+
+// retryScheduleFire runs another attempt of a pending fire time and reports whether it fired
+// An attempt whose trigger succeeded, or was dispatched to the engine, before the service stopped
+// is not repeated
+func (h *JenkinsHandler) retryScheduleFire(ctx context.Context, schedule *scheduler.Recurring, fire models.PendingScheduleFire) bool {
+	previous, err := storage.GetAuditLogByTriggerID(fire.TriggerID)
+	if err != nil {
+		logger.Error("Failed to look up the previous attempt of a recurring schedule fire", "error", err, "schedule", schedule.Name, "fire_time", fire.FireAt, "trigger_id", fire.TriggerID)
+		return false
+	}
+	if previous != nil && previous.Result == "success" {
+		logger.Info("Recurring schedule fire already triggered", "schedule", schedule.Name, "job", schedule.Job, "fire_time", fire.FireAt, "trigger_id", fire.TriggerID, "build_id", previous.BuildID)
+		h.finishScheduleFire(fire)
+		return false
+	}
+	if !fire.DispatchedAt.IsZero() {
+		logger.Error("Recurring schedule fire interrupted after reaching the engine, not repeated", "schedule", schedule.Name, "job", schedule.Job, "fire_time", fire.FireAt, "trigger_id", fire.TriggerID, "dispatched_at", fire.DispatchedAt)
+		h.finishScheduleFire(fire)
+		return false
+	}
+	if fire.Attempts > maxScheduleFireAttempts {
+		logger.Error("Recurring schedule fire abandoned", "schedule", schedule.Name, "job", schedule.Job, "fire_time", fire.FireAt, "trigger_id", fire.TriggerID, "attempts", fire.Attempts-1, "error", fire.Error)
+		h.finishScheduleFire(fire)
+		return false
+	}
+	return h.fireSchedule(ctx, schedule, fire)
+}
+
+// fireSchedule triggers the job of a recurring schedule for a claimed fire time and reports
+// whether it fired; a failed trigger is left pending for another attempt unless it was refused
+// by a policy or ran out of attempts
+func (h *JenkinsHandler) fireSchedule(ctx context.Context, schedule *scheduler.Recurring, fire models.PendingScheduleFire) bool {
+	r := recurringRequest(ctx, schedule.Name, fire.TriggerID)
+	req := TriggerJenkinsBuildRequest{Job: schedule.Job, Parameters: schedule.Parameters}
+	outcome := h.runTrigger(r, req, time.Now(), triggerOrigin{
+		source:    models.SourceSchedule,
+		triggerID: fire.TriggerID,
+		dispatch: func() error {
+			return storage.DispatchScheduleFire(fire.Name, fire.FireAt)
+		},
+	})
+	if outcome.err == nil {
+		logger.Info("Recurring schedule fired", "schedule", schedule.Name, "job", schedule.Job, "fire_time", fire.FireAt, "trigger_id", fire.TriggerID, "build_id", outcome.result.BuildID, "attempt", fire.Attempts)
+		h.finishScheduleFire(fire)
+		return true
+	}
+
+	if retryableFire(schedule.Job, outcome.err) && fire.Attempts < maxScheduleFireAttempts {
+		logger.Warn("Recurring schedule trigger failed, retrying on the next tick", "error", outcome.err, "schedule", schedule.Name, "job", schedule.Job, "fire_time", fire.FireAt, "trigger_id", fire.TriggerID, "attempt", fire.Attempts)
+		errMsg := truncateMessage(outcome.err.Error(), maxErrorMessageLength)
+		if err := storage.FailScheduleFire(fire.Name, fire.FireAt, errMsg, time.Now()); err != nil {
+			logger.Error("Failed to record a failed recurring schedule fire", "error", err, "schedule", schedule.Name, "fire_time", fire.FireAt)
+		}
+		return false
+	}
+
+	logger.Error("Recurring schedule trigger failed", "error", outcome.err, "schedule", schedule.Name, "job", schedule.Job, "fire_time", fire.FireAt, "trigger_id", fire.TriggerID, "attempts", fire.Attempts)
+	h.finishScheduleFire(fire)
+	return false
+}
+
+// finishScheduleFire removes a fire time that is no longer pending
+func (h *JenkinsHandler) finishScheduleFire(fire models.PendingScheduleFire) {
+	if err := storage.FinishScheduleFire(fire.Name, fire.FireAt); err != nil {
+		logger.Error("Failed to finish a recurring schedule fire", "error", err, "schedule", fire.Name, "fire_time", fire.FireAt)
+	}
+}
+
+// retryableFire reports whether a failed recurring schedule trigger is attempted again: engine
+// failures, throttling, and unavailable policy services are, while triggers refused by the key,
+// a kill switch, a blackout window, or a policy are not
+func retryableFire(job string, err error) bool {
+	if errors.Is(err, errJobNotAllowed) {
+		return false
+	}
+	status, _, _, ok := policyError(job, err)
+	if !ok {
+		status = engineErrorStatus(engine.Classify(err))
+	}
+	return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+}
+
+// recurringRequest builds the request context of a recurring schedule trigger, identified by
+// the schedule name in place of an API key
+func recurringRequest(ctx context.Context, name, triggerID string) *http.Request {
+	principal := &middleware.Principal{Name: scheduleKeyPrefix + name}
+	ctx = context.WithValue(ctx, middleware.APIKeyContextKey, scheduleKeyPrefix+name)
+	ctx = context.WithValue(ctx, middleware.PrincipalContextKey, principal)
+	ctx = context.WithValue(ctx, middleware.RequestIDContextKey, triggerID)
+
+	r, _ := http.NewRequestWithContext(ctx, http.MethodPost, triggerPath, nil)
+	return r
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/jobmatch"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+	"triggermesh/internal/workpool"
+)
+
+// auditReplayPathPrefix and auditReplayPathSuffix surround the audit entry ID in the replay route
+const (
+	auditReplayPathPrefix = "/api/v1/audit/"
+	auditReplayPathSuffix = "/replay"
+)
+
+// ReplayAuditRequest is the optional request body of an audit replay
+type ReplayAuditRequest struct {
+	Parameters map[string]string `json:"parameters"` // Merged over the original parameters
+}
+
+// ReplayAuditEntry handles the POST /api/v1/audit/{id}/replay request
+// It re-executes a recorded trigger with its original job and parameters, requires the admin
+// scope, and links the new audit entry to the original through replay_of
+func (h *JenkinsHandler) ReplayAuditEntry(w http.ResponseWriter, r *http.Request) {
+	started := time.Now()
+	requestID := middleware.GetRequestID(r)
+
+	idPart := strings.TrimPrefix(r.URL.Path, auditReplayPathPrefix)
+	if !strings.HasSuffix(idPart, auditReplayPathSuffix) {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, "Not found")
+		return
+	}
+	id, err := strconv.ParseInt(strings.TrimSuffix(idPart, auditReplayPathSuffix), 10, 64)
+	if err != nil || id <= 0 {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, "Not found")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if !middleware.GetPrincipal(r).HasScope(config.ScopeAdmin) {
+		logger.Warn("Audit replay requires the admin scope", "audit_id", id, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusForbidden, "Replaying triggers requires the admin scope")
+		return
+	}
+
+	// The body is optional; an empty body replays the original parameters unchanged
+	var body ReplayAuditRequest
+	if err := decodeJSON(r, &body, true); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	entry, err := storage.GetAuditLog(id)
+	if err != nil {
+		logger.Error("Failed to get audit log", "error", err, "audit_id", id, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get audit log entry")
+		return
+	}
+	if entry == nil {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Audit log entry %d not found", id))
+		return
+	}
+
+	req, err := replayRequest(entry, body.Parameters)
+	if err != nil {
+		logger.Warn("Audit log entry cannot be replayed", "error", err, "audit_id", id, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusUnprocessableEntity, fmt.Sprintf("Audit log entry %d cannot be replayed: %v", id, err))
+		return
+	}
+	if message := validateTriggerRequest(req, requestID); message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	logger.Info("Replaying trigger from audit log", "audit_id", id, "job", req.Job, "request_id", requestID)
+	recordAdminAction(r, models.ConfigActionReplay, fmt.Sprintf("audit_id=%d job=%s", id, req.Job))
+	h.executeTrigger(w, r, req, started, triggerOrigin{source: models.SourceReplay, replayOf: id})
+}
+
+// replayRequest rebuilds the trigger request recorded in an audit entry, applying parameter overrides
+func replayRequest(entry *models.AuditLog, overrides map[string]string) (TriggerJenkinsBuildRequest, error) {
+	if entry.JobName == "" || (entry.Engine != "" && entry.Engine != jenkinsEngineName) {
+		return TriggerJenkinsBuildRequest{}, errors.New("not a Jenkins trigger")
+	}
+
+	recorded := entry.Params
+	if entry.ParamsTruncated {
+		full, ok, err := storage.GetAuditParams(entry.ID)
+		if err != nil || !ok {
+			return TriggerJenkinsBuildRequest{}, errors.New("recorded parameters were truncated")
+		}
+		recorded = full
+	}
+
+	var params map[string]string
+	if recorded != "" {
+		if err := json.Unmarshal([]byte(recorded), &params); err != nil {
+			return TriggerJenkinsBuildRequest{}, errors.New("recorded parameters are not valid JSON")
+		}
+	}
+	if len(overrides) > 0 && params == nil {
+		params = make(map[string]string, len(overrides))
+	}
+	for key, value := range overrides {
+		params[key] = value
+	}
+
+	return TriggerJenkinsBuildRequest{Job: entry.JobName, Parameters: params, Labels: entry.Labels, ChangeRef: entry.ChangeRef, Commit: entry.Commit, Ref: entry.Ref}, nil
+}
+
+// Bulk replay limits; the concurrency maximum and task timeout apply unless SetConcurrency is called
+const (
+	defaultBulkReplayConcurrency    = 4
+	defaultMaxBulkReplayConcurrency = 16
+	defaultBulkReplayTaskTimeout    = 30 * time.Second
+	defaultBulkReplayLimit          = 100
+	maxBulkReplayLimit              = 1000
+)
+
+// Bulk replay result states
+const (
+	BulkReplayPending   = "pending" // Dry run: would be re-triggered
+	BulkReplayTriggered = "triggered"
+	BulkReplayFailed    = "failed"
+	BulkReplaySkipped   = "skipped" // Recorded trigger cannot be replayed
+)
+
+// BulkReplayRequest selects failed triggers to re-trigger
+type BulkReplayRequest struct {
+	Job         string    `json:"job"`         // Job name pattern ("*" wildcard); empty matches all jobs
+	Since       time.Time `json:"since"`       // Required, RFC 3339
+	Until       time.Time `json:"until"`       // Default: now
+	DryRun      bool      `json:"dry_run"`     // Only list the triggers that would be replayed
+	Concurrency int       `json:"concurrency"` // Parallel triggers (default: 4, max: concurrency.bulk_replay)
+	Limit       int       `json:"limit"`       // Maximum triggers replayed (default: 100, max: 1000)
+}
+
+// BulkReplayResult is the outcome for one selected audit entry
+type BulkReplayResult struct {
+	AuditID       int64             `json:"audit_id"`
+	Job           string            `json:"job"`
+	Parameters    map[string]string `json:"parameters,omitempty"`
+	Status        string            `json:"status"` // pending, triggered, failed, or skipped
+	BuildID       string            `json:"build_id,omitempty"`
+	GlobalBuildID string            `json:"global_build_id,omitempty"`
+	TriggerID     string            `json:"trigger_id,omitempty"`
+	Error         string            `json:"error,omitempty"`
+}
+
+// BulkReplayResponse is the response body of a bulk replay
+type BulkReplayResponse struct {
+	DryRun    bool               `json:"dry_run"`
+	Matched   int                `json:"matched"`             // Failed triggers matching the selection
+	Truncated bool               `json:"truncated,omitempty"` // More triggers matched than the limit
+	Results   []BulkReplayResult `json:"results"`
+}
+
+// BulkReplay handles the POST /api/v1/audit/replay request
+// It re-triggers failed triggers of matching jobs in a time window with bounded concurrency,
+// e.g. to recover from a Jenkins outage; each replay is linked to its original audit entry
+func (h *JenkinsHandler) BulkReplay(w http.ResponseWriter, r *http.Request) {
+	started := time.Now()
+	requestID := middleware.GetRequestID(r)
+
+	if r.Method != http.MethodPost {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	principal := middleware.GetPrincipal(r)
+	if !principal.HasScope(config.ScopeAdmin) {
+		logger.Warn("Bulk replay requires the admin scope", "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusForbidden, "Replaying triggers requires the admin scope")
+		return
+	}
+
+	var req BulkReplayRequest
+	if err := decodeJSON(r, &req, false); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if message := normalizeBulkReplayRequest(&req, h.maxBulkReplay); message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	entries, err := storage.GetFailedTriggers(req.Since, req.Until)
+	if err != nil {
+		logger.Error("Failed to get failed triggers", "error", err, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get failed triggers")
+		return
+	}
+
+	resp := BulkReplayResponse{DryRun: req.DryRun, Results: []BulkReplayResult{}}
+	var selected []*models.AuditLog
+	for i := range entries {
+		entry := &entries[i]
+		if req.Job != "" && !jobmatch.Match(req.Job, entry.JobName) {
+			continue
+		}
+		if !principal.CanAccessJob(entry.JobName) {
+			continue
+		}
+		resp.Matched++
+		if len(selected) < req.Limit {
+			selected = append(selected, entry)
+		}
+	}
+	resp.Truncated = resp.Matched > len(selected)
+
+	results := make([]BulkReplayResult, len(selected))
+	pool := workpool.New(r.Context(), req.Concurrency, h.replayTimeout)
+	for i, entry := range selected {
+		results[i] = BulkReplayResult{AuditID: entry.ID, Job: entry.JobName}
+
+		replay, err := replayRequest(entry, nil)
+		if err == nil {
+			if message := validateTriggerRequest(replay, requestID); message != "" {
+				err = errors.New(message)
+			}
+		}
+		if err != nil {
+			results[i].Status = BulkReplaySkipped
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Parameters = replay.Parameters
+
+		if req.DryRun {
+			results[i].Status = BulkReplayPending
+			continue
+		}
+
+		result, replay, auditID := &results[i], replay, entry.ID
+		pool.Go(func(ctx context.Context) error {
+			outcome := h.runTrigger(r.WithContext(ctx), replay, time.Now(), triggerOrigin{source: models.SourceReplay, replayOf: auditID})
+			result.TriggerID = outcome.triggerID
+			if outcome.err != nil {
+				result.Status = BulkReplayFailed
+				if _, _, message, ok := policyError(replay.Job, outcome.err); ok {
+					result.Error = message
+				} else {
+					result.Error = engineErrorMessage("Failed to trigger build", outcome.err, engine.Classify(outcome.err))
+				}
+				return nil
+			}
+			result.Status = BulkReplayTriggered
+			result.BuildID = outcome.result.BuildID
+			result.GlobalBuildID = outcome.globalBuildID
+			return nil
+		})
+	}
+	_ = pool.Wait() // Failures are reported per result
+	resp.Results = append(resp.Results, results...)
+	if !req.DryRun {
+		recordAdminAction(r, models.ConfigActionBulkReplay, fmt.Sprintf("job=%s since=%s until=%s matched=%d replayed=%d",
+			req.Job, req.Since.Format(time.RFC3339), req.Until.Format(time.RFC3339), resp.Matched, len(results)))
+	}
+
+	logger.Info("Bulk replay completed", "dry_run", req.DryRun, "matched", resp.Matched, "replayed", len(results),
+		"duration_ms", time.Since(started).Milliseconds(), "request_id", requestID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error("Failed to encode bulk replay response", "error", err, "request_id", requestID)
+	}
+}
+
+// SetConcurrency bounds bulk replays: the parallel triggers a request may ask for, and how long each may take
+func (h *JenkinsHandler) SetConcurrency(maxBulkReplay int, taskTimeout time.Duration) {
+	h.maxBulkReplay = maxBulkReplay
+	h.replayTimeout = taskTimeout
+}
+
+// normalizeBulkReplayRequest applies defaults and returns a client error message, or "" when valid
+func normalizeBulkReplayRequest(req *BulkReplayRequest, maxConcurrency int) string {
+	if req.Since.IsZero() {
+		return "since is required"
+	}
+	if req.Until.IsZero() {
+		req.Until = time.Now()
+	}
+	if !req.Since.Before(req.Until) {
+		return "since must be before until"
+	}
+
+	if req.Concurrency == 0 {
+		req.Concurrency = min(defaultBulkReplayConcurrency, maxConcurrency)
+	}
+	if req.Concurrency < 0 || req.Concurrency > maxConcurrency {
+		return fmt.Sprintf("concurrency must be between 1 and %d", maxConcurrency)
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultBulkReplayLimit
+	}
+	if req.Limit < 0 || req.Limit > maxBulkReplayLimit {
+		return fmt.Sprintf("limit must be between 1 and %d", maxBulkReplayLimit)
+	}
+	return ""
+}
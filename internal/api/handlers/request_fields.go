@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/version"
+)
+
+// maxFieldValueLength limits string field values like parameter values
+const maxFieldValueLength = 10240
+
+// requestFields are the engine-specific fields accepted in the fields object of trigger requests
+type requestFields struct {
+	fields   []engine.RequestField
+	patterns map[string]*regexp.Regexp // Anchored patterns of string fields, by field name
+}
+
+// newRequestFields compiles the field patterns; patterns must be valid regular expressions
+// Fields without a type are strings
+func newRequestFields(fields []engine.RequestField) *requestFields {
+	if len(fields) == 0 {
+		return nil
+	}
+	f := &requestFields{fields: slices.Clone(fields), patterns: make(map[string]*regexp.Regexp)}
+	for i, field := range f.fields {
+		if field.Type == "" {
+			f.fields[i].Type = engine.FieldString
+		}
+		if field.Pattern != "" {
+			f.patterns[field.Name] = regexp.MustCompile(`^(?:` + field.Pattern + `)$`)
+		}
+	}
+	return f
+}
+
+// fieldParameter returns the parameter a field is passed as
+func fieldParameter(field engine.RequestField) string {
+	if field.Parameter != "" {
+		return field.Parameter
+	}
+	return field.Name
+}
+
+// SetRequestFields accepts the fields in the fields object of trigger requests, validates them, and
+// passes them to the engine as parameters
+func (h *JenkinsHandler) SetRequestFields(fields []engine.RequestField) {
+	h.fields = newRequestFields(fields)
+}
+
+// applyRequestFields validates the fields of a request and adds them to its parameters
+// It returns the client-facing error message, or "" when the fields are valid
+func (h *JenkinsHandler) applyRequestFields(req *TriggerJenkinsBuildRequest) string {
+	if h.fields == nil {
+		if len(req.Fields) > 0 {
+			return fmt.Sprintf("Engine '%s' accepts no fields", h.engineName)
+		}
+		return ""
+	}
+
+	names := make([]string, 0, len(req.Fields))
+	for name := range req.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !slices.ContainsFunc(h.fields.fields, func(field engine.RequestField) bool { return field.Name == name }) {
+			return fmt.Sprintf("Unknown field '%s' for engine '%s'", name, h.engineName)
+		}
+	}
+
+	for _, field := range h.fields.fields {
+		raw, ok := req.Fields[field.Name]
+		if !ok || string(raw) == "null" {
+			if field.Required {
+				return fmt.Sprintf("Field '%s' is required for engine '%s'", field.Name, h.engineName)
+			}
+			continue
+		}
+		value, message := h.fields.value(field, raw)
+		if message != "" {
+			return message
+		}
+		param := fieldParameter(field)
+		if existing, ok := req.Parameters[param]; ok && existing != value {
+			return fmt.Sprintf("Parameter '%s' conflicts with field '%s'", param, field.Name)
+		}
+		if req.Parameters == nil {
+			req.Parameters = make(map[string]string, len(h.fields.fields))
+		}
+		req.Parameters[param] = value
+	}
+	return ""
+}
+
+// value checks a field value against the field's type and constraints and returns it as a parameter value
+func (f *requestFields) value(field engine.RequestField, raw json.RawMessage) (string, string) {
+	switch field.Type {
+	case engine.FieldInteger:
+		var n int64
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return "", fmt.Sprintf("Field '%s' must be an integer", field.Name)
+		}
+		return strconv.FormatInt(n, 10), ""
+	case engine.FieldBoolean:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return "", fmt.Sprintf("Field '%s' must be a boolean", field.Name)
+		}
+		return strconv.FormatBool(b), ""
+	default:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", fmt.Sprintf("Field '%s' must be a string", field.Name)
+		}
+		if len(s) > maxFieldValueLength {
+			return "", fmt.Sprintf("Field '%s' exceeds maximum length of 10KB", field.Name)
+		}
+		if len(field.Enum) > 0 && !slices.Contains(field.Enum, s) {
+			return "", fmt.Sprintf("Invalid field '%s': must be one of %s", field.Name, strings.Join(field.Enum, ", "))
+		}
+		if pattern, ok := f.patterns[field.Name]; ok && !pattern.MatchString(s) {
+			return "", fmt.Sprintf("Invalid field '%s': must match %s", field.Name, field.Pattern)
+		}
+		return s, ""
+	}
+}
+
+// schema returns the OpenAPI schema of the fields object, or nil without fields
+func (f *requestFields) schema() map[string]interface{} {
+	if f == nil {
+		return nil
+	}
+	properties := make(map[string]interface{}, len(f.fields))
+	var required []string
+	for _, field := range f.fields {
+		property := map[string]interface{}{"type": field.Type}
+		description := field.Description
+		if description != "" {
+			description += ". "
+		}
+		property["description"] = description + "Passed to the engine as the " + fieldParameter(field) + " parameter"
+		if len(field.Enum) > 0 {
+			property["enum"] = field.Enum
+		}
+		if field.Pattern != "" {
+			property["pattern"] = "^(?:" + field.Pattern + ")$"
+		}
+		if field.Type == engine.FieldString {
+			property["maxLength"] = maxFieldValueLength
+		}
+		properties[field.Name] = property
+		if field.Required {
+			required = append(required, field.Name)
+		}
+	}
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"description":          "Fields of this engine, validated and passed to the engine as parameters",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// triggerRequestSchema returns the OpenAPI schema of the trigger request body, with not_before for
+// engines that hold triggers, and the fields object of the engine when it accepts fields
+func triggerRequestSchema(fields *requestFields, scheduling bool) map[string]interface{} {
+	stringMap := func(description string) map[string]interface{} {
+		return map[string]interface{}{"type": "object", "description": description, "additionalProperties": map[string]interface{}{"type": "string"}}
+	}
+	properties := map[string]interface{}{
+		"job":        map[string]interface{}{"type": "string", "minLength": 1, "maxLength": 255},
+		"parameters": stringMap("Build parameters"),
+		"labels":     stringMap("Metadata recorded with the trigger"),
+		"change_ref": map[string]interface{}{"type": "string", "description": "Change ticket authorizing the trigger"},
+		"commit":     map[string]interface{}{"type": "string", "pattern": "^[0-9a-fA-F]{7,64}$"},
+		"ref":        map[string]interface{}{"type": "string", "maxLength": maxRefLength},
+		"deadline":   map[string]interface{}{"type": "string", "format": "date-time"},
+		"wait":       map[string]interface{}{"type": "boolean"},
+		"max_wait":   map[string]interface{}{"type": "integer", "minimum": 1},
+		"no_reuse":   map[string]interface{}{"type": "boolean"},
+	}
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"required":             []string{"job"},
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if scheduling {
+		properties["not_before"] = map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	if fieldsSchema := fields.schema(); fieldsSchema != nil {
+		properties["fields"] = fieldsSchema
+		if _, ok := fieldsSchema["required"]; ok {
+			schema["required"] = []string{"job", "fields"}
+		}
+	}
+	return schema
+}
+
+// GetOpenAPI handles the GET /api/v1/engines/{engine}/openapi request
+// It returns an OpenAPI document of the engine's trigger endpoint, with the fields the engine accepts
+func (h *EngineHandler) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, enginePathPrefix), "/openapi")
+	var fields *requestFields
+	triggerPath := engineTriggerPathPrefix + name
+	if name == jenkinsEngineName && h.jenkins != nil {
+		triggerPath = "/api/v1/trigger/jenkins"
+	} else {
+		handler, ok := h.handler(name)
+		if !ok {
+			writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Engine '%s' not found", name))
+			return
+		}
+		fields = handler.fields
+	}
+
+	document := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   fmt.Sprintf("TriggerMesh %s trigger", name),
+			"version": version.Version,
+		},
+		"paths": map[string]interface{}{
+			middleware.GetBasePath(r) + triggerPath: map[string]interface{}{
+				"post": map[string]interface{}{
+					"operationId": "trigger_" + strings.ReplaceAll(name, "-", "_"),
+					"summary":     fmt.Sprintf("Trigger a build on %s", name),
+					"security":    []interface{}{map[string]interface{}{"BearerAuth": []string{}}},
+					"requestBody": map[string]interface{}{
+						"required": true,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{"$ref": "#/components/schemas/TriggerRequest"},
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{"description": "Build triggered"},
+						"400": map[string]interface{}{"description": "Invalid request, including invalid fields"},
+					},
+				},
+			},
+		},
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"BearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+			"schemas": map[string]interface{}{
+				"TriggerRequest": triggerRequestSchema(fields, name == jenkinsEngineName),
+			},
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(document); err != nil {
+		logger.Error("Failed to encode OpenAPI document", "error", err, "request_id", middleware.GetRequestID(r))
+	}
+}
@@ -2,21 +2,67 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
+	"strings"
+	"unicode/utf8"
 
 	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/i18n"
 	"triggermesh/internal/logger"
 )
 
+// maxErrorMessageLength caps engine error messages written to responses and audit logs
+const maxErrorMessageLength = 512
+
+// problemContentType is the RFC 9457 media type clients send in Accept to get Problem Details errors
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix prefixes stable error codes to build Problem Details type URIs
+const problemTypePrefix = "urn:triggermesh:error:"
+
 // writeErrorWithRequestID writes a standardized error response with optional request ID
 func writeErrorWithRequestID(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeErrorResponse(w, r, status, map[string]interface{}{
+		"error": message,
+	})
+}
+
+// writeEngineError writes a structured error response for a failed engine call
+// The response never echoes the engine result or request payload, only a sanitized classification
+func writeEngineError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	kind := engine.Classify(err)
+	writeErrorResponse(w, r, engineErrorStatus(kind), map[string]interface{}{
+		"success": false,
+		"error":   engineErrorMessage(action, err, kind),
+		"kind":    string(kind),
+		"code":    kind.Code(),
+	})
+}
+
+// writeErrorResponse adds the status text and request ID to the given fields and writes them as JSON
+// The message is translated to the client's Accept-Language; codes stay stable across languages
+// Clients that accept application/problem+json get an RFC 9457 Problem Details document instead
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, status int, response map[string]interface{}) {
+	if r != nil {
+		language := i18n.Negotiate(r.Header.Get("Accept-Language"))
+		if message, ok := response["error"].(string); ok {
+			response["error"] = i18n.Translate(language, message)
+		}
+		w.Header().Set("Content-Language", language)
+	}
+
+	if r != nil && wantsProblemDetails(r) {
+		writeProblemDetails(w, r, status, response)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
-	response := map[string]interface{}{
-		"error":  message,
-		"status": http.StatusText(status),
-	}
+	response["status"] = http.StatusText(status)
 
 	// Add request ID if available (from context, not header)
 	if r != nil {
@@ -27,6 +73,107 @@ func writeErrorWithRequestID(w http.ResponseWriter, r *http.Request, status int,
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		// Log but don't try to write again (headers already sent)
-		logger.Error("Failed to encode error response", "error", err, "status", status, "message", message)
+		logger.Error("Failed to encode error response", "error", err, "status", status, "message", response["error"])
+	}
+}
+
+// writeProblemDetails writes the error fields as an RFC 9457 Problem Details document
+// The message becomes detail, the request ID becomes instance, and the remaining
+// fields (such as code and kind) are kept as extension members
+func writeProblemDetails(w http.ResponseWriter, r *http.Request, status int, response map[string]interface{}) {
+	problem := map[string]interface{}{
+		"type":   "about:blank",
+		"title":  http.StatusText(status),
+		"status": status,
+	}
+	for key, value := range response {
+		switch key {
+		case "error":
+			problem["detail"] = value
+		case "success", "status":
+			// Redundant with the HTTP status, not part of the problem document
+		default:
+			problem[key] = value
+		}
+	}
+	if code, ok := response["code"].(string); ok && code != "" {
+		problem["type"] = problemTypePrefix + strings.ToLower(code)
+	}
+	if requestID := middleware.GetRequestID(r); requestID != "" {
+		problem["instance"] = requestID
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		logger.Error("Failed to encode problem details response", "error", err, "status", status, "message", response["error"])
+	}
+}
+
+// wantsProblemDetails reports whether the request's Accept header lists application/problem+json
+func wantsProblemDetails(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || mediaType != problemContentType {
+				continue
+			}
+			// An explicit q=0 means the client refuses the type
+			if q, ok := params["q"]; ok && strings.Trim(q, "0.") == "" {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// engineErrorStatus maps an engine error kind to the HTTP status returned to clients
+func engineErrorStatus(kind engine.ErrorKind) int {
+	switch kind {
+	case engine.ErrorKindNotFound:
+		return http.StatusNotFound
+	case engine.ErrorKindAuth, engine.ErrorKindServer:
+		return http.StatusBadGateway
+	case engine.ErrorKindTimeout:
+		return http.StatusGatewayTimeout
+	case engine.ErrorKindCircuitOpen:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// engineErrorMessage returns a client-safe message for an engine error, prefixed with the failed action
+// Only messages produced by engines as *engine.Error are passed through, since
+// transport errors may contain internal URLs
+func engineErrorMessage(action string, err error, kind engine.ErrorKind) string {
+	var engineErr *engine.Error
+	if errors.As(err, &engineErr) {
+		return truncateMessage(action+": "+engineErr.Message, maxErrorMessageLength)
+	}
+	if kind == engine.ErrorKindTimeout {
+		return action + ": engine request timed out"
+	}
+	return action
+}
+
+// truncateMessage shortens a message to at most maxLen bytes, marking it as truncated
+func truncateMessage(message string, maxLen int) string {
+	if len(message) <= maxLen {
+		return message
+	}
+	return truncateUTF8(message, maxLen) + "...(truncated)"
+}
+
+// truncateUTF8 cuts s to at most n bytes, stepping back to a rune boundary so the result stays valid UTF-8
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
 	}
+	return s[:n]
 }
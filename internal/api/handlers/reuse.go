@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/jobmatch"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/metrics"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// maxReuseCandidates bounds the recent identical triggers whose builds are checked for reuse
+const maxReuseCandidates = 3
+
+// reusedResult is the audit result of a trigger answered with a recent successful build
+const reusedResult = "reused"
+
+// reuseEntriesPath is the route of result reuse entries: /api/v1/admin/reuse[/{audit_id}]
+const reuseEntriesPath = "/api/v1/admin/reuse"
+
+// reuseEntriesBatch is the number of triggers read at a time while listing reuse entries
+const reuseEntriesBatch = 500
+
+// Outcomes of result reuse lookups, as reported in the result label of triggermesh_reuse_lookups_total
+const (
+	reuseLookupHit   = "hit"   // A recent identical trigger's successful build answered the trigger
+	reuseLookupMiss  = "miss"  // No build could be reused, so a new build was triggered
+	reuseLookupError = "error" // Recent triggers could not be read, so a new build was triggered
+)
+
+// ReuseEntry is a recent successful trigger whose build answers identical triggers until it expires
+type ReuseEntry struct {
+	AuditID       int64     `json:"audit_id"`                  // Audit entry of the trigger; reuses report it as reused_from
+	Key           string    `json:"key"`                       // Fingerprint (SHA-256) of the requested parameters, matched with commit and ref
+	Engine        string    `json:"engine"`                    // Engine identical triggers must target
+	Job           string    `json:"job"`                       // Job name
+	BuildID       string    `json:"build_id"`                  // Build returned to identical triggers once it has succeeded
+	GlobalBuildID string    `json:"global_build_id,omitempty"` // TriggerMesh-wide ID of the build
+	Commit        string    `json:"commit,omitempty"`          // Git commit identical triggers must name
+	Ref           string    `json:"ref,omitempty"`             // Git ref identical triggers must name
+	Result        string    `json:"result"`                    // Audit result of the trigger (success); the build's own result is checked on reuse
+	CreatedAt     time.Time `json:"created_at"`                // When the trigger was made
+	ExpiresAt     time.Time `json:"expires_at"`                // When the reuse window of the job ends
+}
+
+// SetMetrics registers the trigger metrics, currently the outcomes of result reuse lookups
+func (h *JenkinsHandler) SetMetrics(registry *metrics.Registry) {
+	h.reuseLookups = registry.NewCounter("triggermesh_reuse_lookups_total",
+		"Result reuse lookups of triggers of jobs with a reuse rule, by engine and outcome (hit, miss, or error)", "engine", "result")
+}
+
+// countReuseLookup records the outcome of a result reuse lookup
+func (h *JenkinsHandler) countReuseLookup(result string) {
+	if h.reuseLookups != nil {
+		h.reuseLookups.Inc(h.engineName, result)
+	}
+}
+
+// SetResultReuse lets triggers of jobs matching a rule return the successful build of an
+// identical trigger made within the rule's window instead of starting a new build
+func (h *JenkinsHandler) SetResultReuse(rules []config.ReuseRuleConfig) {
+	h.reuseRules = rules
+}
+
+// reuseWindow returns how far back builds of a job may be reused, or 0 when reuse is off
+func (h *JenkinsHandler) reuseWindow(job string) time.Duration {
+	for _, rule := range h.reuseRules {
+		if len(rule.Jobs) == 0 || jobmatch.MatchAny(rule.Jobs, job) {
+			return time.Duration(rule.Window) * time.Second
+		}
+	}
+	return 0
+}
+
+// parametersHash fingerprints the parameters of a trigger request
+// encoding/json sorts map keys, so identical parameter sets hash equally
+func parametersHash(params map[string]string) string {
+	if len(params) == 0 {
+		params = map[string]string{}
+	}
+	data, _ := json.Marshal(params)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// findReusableBuild returns the most recent identical trigger within the job's reuse window whose
+// build finished successfully, with the build's status, or nil when there is none
+func (h *JenkinsHandler) findReusableBuild(r *http.Request, req TriggerJenkinsBuildRequest) (*models.AuditLog, *engine.BuildResult) {
+	window := h.reuseWindow(req.Job)
+	if window == 0 {
+		return nil, nil
+	}
+	requestID := middleware.GetRequestID(r)
+
+	candidates, err := storage.GetReusableTriggers(h.engineName, req.Job, parametersHash(req.Parameters), time.Now().Add(-window), maxReuseCandidates)
+	if err != nil {
+		logger.Error("Failed to look up reusable builds", "error", err, "job", req.Job, "request_id", requestID)
+		h.countReuseLookup(reuseLookupError)
+		return nil, nil
+	}
+	for i := range candidates {
+		// Builds of another commit or ref are not identical, even with the same parameters
+		if candidates[i].Commit != req.Commit || candidates[i].Ref != req.Ref {
+			continue
+		}
+		status, err := h.buildStatus(candidates[i].BuildID)
+		if err != nil {
+			logger.Warn("Failed to get status of reusable build", "error", err, "build_id", candidates[i].BuildID, "request_id", requestID)
+			continue
+		}
+		if !status.Building && status.Result == engine.ResultSuccess {
+			h.countReuseLookup(reuseLookupHit)
+			return &candidates[i], status
+		}
+	}
+	h.countReuseLookup(reuseLookupMiss)
+	return nil, nil
+}
+
+// reuseResult answers a trigger with a recent successful build of an identical trigger
+// It reports false, leaving the response unwritten, when there is no build to reuse
+func (h *JenkinsHandler) reuseResult(w http.ResponseWriter, r *http.Request, req TriggerJenkinsBuildRequest, started time.Time) bool {
+	// Keys that may not trigger the job get the usual denial from executeTrigger
+	if !middleware.GetPrincipal(r).CanAccessJob(req.Job) {
+		return false
+	}
+	entry, status := h.findReusableBuild(r, req)
+	if entry == nil {
+		return false
+	}
+
+	// A reused build answers only triggers that could run: the policies of runTrigger apply,
+	// except the queue throttle and category quotas, as no build is started
+	apiKey, _ := r.Context().Value(middleware.APIKeyContextKey).(string)
+	triggerID := newTriggerID()
+	origin := triggerOrigin{source: models.SourceHTTP}
+	if err := h.checkTriggerPolicies(r, apiKey, req, origin); err != nil {
+		recordDenied(h.newTriggerAuditLog(r, apiKey, triggerID, req, started, origin), deniedStatus(req.Job, err), err)
+		if !writeTriggerRefusal(w, r, req.Job, err) {
+			writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to trigger build")
+		}
+		return true
+	}
+
+	auditLog := h.newTriggerAuditLog(r, apiKey, triggerID, req, started, origin)
+	auditLog.Status = http.StatusOK
+	auditLog.Result = reusedResult
+	auditLog.BuildID = entry.BuildID
+	if err := storage.InsertAuditLog(auditLog); err != nil {
+		logger.Error("Failed to insert audit log", "error", err)
+	}
+	logger.Info("Reused successful build of an identical trigger", "job", req.Job, "build_id", entry.BuildID, "reused_from", entry.ID, "request_id", middleware.GetRequestID(r))
+
+	result := *status
+	result.Success = true
+	result.BuildID = entry.BuildID
+	result.Message = fmt.Sprintf("Reused successful build %s of an identical trigger", entry.BuildID)
+	var waitStatus string
+	if req.Wait {
+		waitStatus = WaitCompleted
+	}
+
+	duration := time.Since(started)
+	setServerTiming(w, duration, 0)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(TriggerJenkinsBuildResponse{
+		BuildResult:   presentBuildResult(h.buildURLs, r, &result, buildStatusPath(h.engineName, entry.BuildID, entry.GlobalBuildID)),
+		TriggerID:     triggerID,
+		GlobalBuildID: entry.GlobalBuildID,
+		DurationMS:    duration.Milliseconds(),
+		ReusedFrom:    entry.ID,
+		Labels:        req.Labels,
+		ChangeRef:     req.ChangeRef,
+		Commit:        req.Commit,
+		Ref:           req.Ref,
+		WaitStatus:    waitStatus,
+	}); err != nil {
+		logger.Error("Failed to encode response", "error", err)
+	}
+	return true
+}
+
+// ReuseEntries handles GET (list) and DELETE (purge by job pattern) on /api/v1/admin/reuse and
+// DELETE (purge one entry) on /api/v1/admin/reuse/{audit_id}; all require the admin scope
+// Purged triggers stay in the audit log, but their builds no longer answer identical triggers
+func (h *JenkinsHandler) ReuseEntries(w http.ResponseWriter, r *http.Request) {
+	idPart := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, reuseEntriesPath), "/")
+	switch {
+	case idPart == "" && (r.Method == http.MethodGet || r.Method == http.MethodDelete):
+	case idPart != "" && r.Method == http.MethodDelete:
+	default:
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !middleware.GetPrincipal(r).HasScope(config.ScopeAdmin) {
+		writeErrorWithRequestID(w, r, http.StatusForbidden, "Managing result reuse requires the admin scope")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet:
+		h.listReuseEntries(w, r)
+	case idPart == "":
+		h.purgeReuseEntries(w, r)
+	default:
+		id, err := strconv.ParseInt(idPart, 10, 64)
+		if err != nil || id <= 0 {
+			writeErrorWithRequestID(w, r, http.StatusNotFound, "Not found")
+			return
+		}
+		h.purgeReuseEntry(w, r, id)
+	}
+}
+
+// listReuseEntries returns the current reuse entries, newest first, optionally filtered by job pattern
+func (h *JenkinsHandler) listReuseEntries(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	limit := 100
+	if parsedLimit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsedLimit > 0 {
+		limit = parsedLimit
+	}
+	entries, err := h.reuseEntries(r.URL.Query().Get("job"), time.Now(), limit)
+	if err != nil {
+		logger.Error("Failed to get reuse entries", "error", err, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get reuse entries")
+		return
+	}
+	if entries == nil {
+		entries = []ReuseEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		logger.Error("Failed to encode reuse entries response", "error", err, "request_id", requestID)
+	}
+}
+
+// purgeReuseEntries purges the current reuse entries of the jobs matching the job query parameter,
+// which is required so a purge of every entry is explicit (job=*)
+func (h *JenkinsHandler) purgeReuseEntries(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	jobPattern := r.URL.Query().Get("job")
+	if jobPattern == "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, "The job query parameter is required; use job=* to purge every entry")
+		return
+	}
+	entries, err := h.reuseEntries(jobPattern, time.Now(), 0)
+	if err != nil {
+		logger.Error("Failed to get reuse entries", "error", err, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get reuse entries")
+		return
+	}
+	ids := make([]int64, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.AuditID
+	}
+	purged, err := purgeReuse(r, ids, "job="+jobPattern)
+	if err != nil {
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to purge reuse entries")
+		return
+	}
+	writePurged(w, r, purged)
+}
+
+// purgeReuseEntry purges one current reuse entry
+func (h *JenkinsHandler) purgeReuseEntry(w http.ResponseWriter, r *http.Request, id int64) {
+	requestID := middleware.GetRequestID(r)
+
+	entry, err := storage.GetAuditLog(id)
+	if err != nil {
+		logger.Error("Failed to get audit log", "error", err, "audit_id", id, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get reuse entry")
+		return
+	}
+	if entry == nil || entry.Result != "success" || entry.BuildID == "" || !h.reusable(*entry, time.Now()) {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Reuse entry %d not found", id))
+		return
+	}
+	purged, err := purgeReuse(r, []int64{id}, fmt.Sprintf("audit_id=%d job=%s", id, entry.JobName))
+	if err != nil {
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to purge reuse entry")
+		return
+	}
+	// Purging twice finds no entry, as listing does
+	if purged == 0 {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Reuse entry %d not found", id))
+		return
+	}
+	writePurged(w, r, purged)
+}
+
+// purgeReuse stops the builds of the triggers from being reused and records the purge in the
+// configuration audit, returning the number of entries newly purged
+func purgeReuse(r *http.Request, ids []int64, details string) (int64, error) {
+	requestID := middleware.GetRequestID(r)
+
+	actor := requestActor(r)
+	purged, err := storage.PurgeReuseEntries(ids, actor, time.Now().UTC())
+	if err != nil {
+		logger.Error("Failed to purge reuse entries", "error", err, "request_id", requestID)
+		return 0, err
+	}
+	if purged > 0 {
+		logger.Info("Purged reuse entries", "purged", purged, "filter", details, "purged_by", actor, "request_id", requestID)
+		recordAdminAction(r, models.ConfigActionReusePurge, fmt.Sprintf("%s purged=%d", details, purged))
+	}
+	return purged, nil
+}
+
+// writePurged writes the number of reuse entries purged
+func writePurged(w http.ResponseWriter, r *http.Request, purged int64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"purged": purged}); err != nil {
+		logger.Error("Failed to encode purge response", "error", err, "request_id", middleware.GetRequestID(r))
+	}
+}
+
+// reuseEntries returns up to limit (0 for all) current reuse entries of jobs matching the pattern
+// (empty for all), newest first: successful triggers within the reuse window of their job
+func (h *JenkinsHandler) reuseEntries(jobPattern string, now time.Time, limit int) ([]ReuseEntry, error) {
+	var maxWindow time.Duration
+	for _, rule := range h.reuseRules {
+		if window := time.Duration(rule.Window) * time.Second; window > maxWindow {
+			maxWindow = window
+		}
+	}
+	if maxWindow == 0 {
+		return nil, nil
+	}
+
+	var entries []ReuseEntry
+	var beforeID int64
+	for {
+		logs, err := storage.GetReuseEntries(jobPattern, now.Add(-maxWindow), beforeID, reuseEntriesBatch)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range logs {
+			if !h.reusable(log, now) {
+				continue
+			}
+			entries = append(entries, ReuseEntry{
+				AuditID:       log.ID,
+				Key:           log.ParamsHash,
+				Engine:        log.Engine,
+				Job:           log.JobName,
+				BuildID:       log.BuildID,
+				GlobalBuildID: log.GlobalBuildID,
+				Commit:        log.Commit,
+				Ref:           log.Ref,
+				Result:        log.Result,
+				CreatedAt:     log.Timestamp,
+				ExpiresAt:     log.Timestamp.Add(h.reuseWindow(log.JobName)),
+			})
+			if limit > 0 && len(entries) == limit {
+				return entries, nil
+			}
+		}
+		if len(logs) < reuseEntriesBatch {
+			return entries, nil
+		}
+		beforeID = logs[len(logs)-1].ID
+	}
+}
+
+// reusable reports whether a trigger is still within the reuse window of its job
+func (h *JenkinsHandler) reusable(log models.AuditLog, now time.Time) bool {
+	window := h.reuseWindow(log.JobName)
+	return window > 0 && !log.Timestamp.Before(now.Add(-window))
+}
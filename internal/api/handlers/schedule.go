@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// scheduleTolerance is how far in the future not_before may be for the trigger to still run immediately
+const scheduleTolerance = time.Second
+
+// defaultMaxScheduleDelay is the furthest not_before accepted unless SetMaxScheduleDelay is called
+const defaultMaxScheduleDelay = 7 * 24 * time.Hour
+
+// scheduledBatchSize caps the number of due triggers fired per scheduler run
+const scheduledBatchSize = 100
+
+// triggerPath is the trigger endpoint, recorded as the audit path of scheduled triggers
+const triggerPath = "/api/v1/trigger/jenkins"
+
+// scheduledTriggerPathPrefix is the route prefix followed by the trigger ID of a scheduled trigger
+const scheduledTriggerPathPrefix = "/api/v1/trigger/scheduled/"
+
+// errDeadlineExceeded is returned for triggers whose deadline passed before they could run
+var errDeadlineExceeded = errors.New("trigger deadline has passed")
+
+// ScheduledTriggerResponse is the response body of a trigger held until its not_before time
+type ScheduledTriggerResponse struct {
+	TriggerID string     `json:"trigger_id"`
+	Status    string     `json:"status"`
+	Job       string     `json:"job"`
+	NotBefore time.Time  `json:"not_before"`
+	Deadline  *time.Time `json:"deadline,omitempty"`
+	StatusURL string     `json:"status_url"` // Where to follow the trigger
+}
+
+// SetMaxScheduleDelay limits how far in the future not_before may be
+func (h *JenkinsHandler) SetMaxScheduleDelay(delay time.Duration) {
+	h.maxScheduleDelay = delay
+}
+
+// rejectExpired refuses a trigger whose deadline has already passed
+func (h *JenkinsHandler) rejectExpired(w http.ResponseWriter, r *http.Request, req TriggerJenkinsBuildRequest, started time.Time) {
+	apiKey, _ := r.Context().Value(middleware.APIKeyContextKey).(string)
+	auditLog := h.newTriggerAuditLog(r, apiKey, newTriggerID(), req, started, triggerOrigin{source: models.SourceHTTP})
+	recordExpired(auditLog)
+
+	status, code, message, _ := policyError(req.Job, errDeadlineExceeded)
+	writePolicyError(w, r, status, code, message)
+}
+
+// recordExpired audits a trigger dropped because its deadline passed
+func recordExpired(auditLog models.AuditLog) {
+	auditLog.Status = http.StatusUnprocessableEntity
+	auditLog.Result = "expired"
+	auditLog.Error = errDeadlineExceeded.Error()
+	if err := storage.InsertAuditLog(auditLog); err != nil {
+		logger.Error("Failed to insert audit log", "error", err)
+	}
+}
+
+// scheduleTrigger stores a trigger whose not_before is in the future and answers 202 Accepted
+// Job access is checked now; the authorization hook and change policy run when the trigger fires
+func (h *JenkinsHandler) scheduleTrigger(w http.ResponseWriter, r *http.Request, req TriggerJenkinsBuildRequest) {
+	requestID := middleware.GetRequestID(r)
+
+	principal := middleware.GetPrincipal(r)
+	if !principal.CanAccessJob(req.Job) {
+		writeErrorWithRequestID(w, r, http.StatusForbidden, fmt.Sprintf("API key is not allowed to trigger job '%s'", req.Job))
+		return
+	}
+	if req.NotBefore.After(time.Now().Add(h.maxScheduleDelay)) {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, fmt.Sprintf("not_before must be within %s", h.maxScheduleDelay))
+		return
+	}
+
+	apiKey, _ := r.Context().Value(middleware.APIKeyContextKey).(string)
+	trigger := models.ScheduledTrigger{
+		TriggerID:  newTriggerID(),
+		JobName:    req.Job,
+		Parameters: req.Parameters,
+		Labels:     req.Labels,
+		ChangeRef:  req.ChangeRef,
+		Commit:     req.Commit,
+		Ref:        req.Ref,
+		APIKey:     apiKey,
+		NotBefore:  *req.NotBefore,
+		Deadline:   req.Deadline,
+		Status:     models.ScheduledPending,
+		CreatedAt:  time.Now(),
+	}
+	if principal != nil {
+		trigger.Principal = models.TriggerPrincipal{Name: principal.Name, Tenant: principal.Tenant, Jobs: principal.Jobs, Scopes: principal.Scopes}
+	}
+	if err := storage.InsertScheduledTrigger(trigger); err != nil {
+		logger.Error("Failed to schedule trigger", "error", err, "job", req.Job, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to schedule trigger")
+		return
+	}
+
+	logger.Info("Trigger scheduled", "trigger_id", trigger.TriggerID, "job", req.Job, "not_before", trigger.NotBefore, "request_id", requestID)
+
+	statusURL := middleware.GetBasePath(r) + scheduledTriggerPathPrefix + trigger.TriggerID
+	w.Header().Set("Location", statusURL)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(ScheduledTriggerResponse{
+		TriggerID: trigger.TriggerID,
+		Status:    trigger.Status,
+		Job:       trigger.JobName,
+		NotBefore: trigger.NotBefore,
+		Deadline:  trigger.Deadline,
+		StatusURL: statusURL,
+	}); err != nil {
+		logger.Error("Failed to encode response", "error", err)
+	}
+}
+
+// GetScheduledTrigger handles the GET /api/v1/trigger/scheduled/{trigger_id} request
+// Triggers of jobs the API key may not access are reported as not found
+func (h *JenkinsHandler) GetScheduledTrigger(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	triggerID := strings.TrimPrefix(r.URL.Path, scheduledTriggerPathPrefix)
+	if triggerID == "" || strings.Contains(triggerID, "/") {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, "Not found")
+		return
+	}
+
+	trigger, err := storage.GetScheduledTrigger(triggerID)
+	if err != nil {
+		logger.Error("Failed to get scheduled trigger", "error", err, "trigger_id", triggerID, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get scheduled trigger")
+		return
+	}
+	if trigger == nil || !middleware.GetPrincipal(r).CanAccessJob(trigger.JobName) {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("Scheduled trigger '%s' not found", triggerID))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(trigger); err != nil {
+		logger.Error("Failed to encode scheduled trigger response", "error", err, "request_id", requestID)
+	}
+}
+
+// FireDueTriggers runs the scheduled triggers whose not_before has been reached and returns how many
+// were fired; triggers past their deadline are expired instead
+// Each trigger goes through the regular trigger path (job access, authorization hook, change policy)
+// with the identity of the API key that scheduled it
+func (h *JenkinsHandler) FireDueTriggers(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := storage.ClaimDueScheduledTriggers(now, scheduledBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	fired := 0
+	for _, trigger := range due {
+		if ctx.Err() != nil {
+			// Claimed triggers are not fired twice; record why these never ran
+			if err := storage.FinishScheduledTrigger(trigger.ID, models.ScheduledFailed, "", "scheduler stopped before the trigger ran"); err != nil {
+				logger.Error("Failed to update scheduled trigger", "error", err, "trigger_id", trigger.TriggerID)
+			}
+			continue
+		}
+
+		r := scheduledRequest(ctx, trigger)
+		req := TriggerJenkinsBuildRequest{Job: trigger.JobName, Parameters: trigger.Parameters, Labels: trigger.Labels, ChangeRef: trigger.ChangeRef, Commit: trigger.Commit, Ref: trigger.Ref}
+		origin := triggerOrigin{source: models.SourceSchedule, triggerID: trigger.TriggerID}
+
+		if trigger.Deadline != nil && now.After(*trigger.Deadline) {
+			logger.Warn("Scheduled trigger expired", "trigger_id", trigger.TriggerID, "job", trigger.JobName, "deadline", *trigger.Deadline)
+			recordExpired(h.newTriggerAuditLog(r, trigger.APIKey, trigger.TriggerID, req, now, origin))
+			if err := storage.FinishScheduledTrigger(trigger.ID, models.ScheduledExpired, "", errDeadlineExceeded.Error()); err != nil {
+				logger.Error("Failed to update scheduled trigger", "error", err, "trigger_id", trigger.TriggerID)
+			}
+			continue
+		}
+
+		outcome := h.runTrigger(r, req, time.Now(), origin)
+		status, buildID, errMsg := models.ScheduledTriggered, "", ""
+		if outcome.err != nil {
+			status, errMsg = models.ScheduledFailed, truncateMessage(outcome.err.Error(), maxErrorMessageLength)
+		} else {
+			buildID = outcome.result.BuildID
+		}
+		if err := storage.FinishScheduledTrigger(trigger.ID, status, buildID, errMsg); err != nil {
+			logger.Error("Failed to update scheduled trigger", "error", err, "trigger_id", trigger.TriggerID)
+		}
+		logger.Info("Scheduled trigger fired", "trigger_id", trigger.TriggerID, "job", trigger.JobName, "status", status, "build_id", buildID)
+		fired++
+	}
+	return fired, nil
+}
+
+// scheduledRequest rebuilds the authenticated request context of a scheduled trigger
+func scheduledRequest(ctx context.Context, trigger models.ScheduledTrigger) *http.Request {
+	principal := &middleware.Principal{
+		Name:   trigger.Principal.Name,
+		Tenant: trigger.Principal.Tenant,
+		Jobs:   trigger.Principal.Jobs,
+		Scopes: trigger.Principal.Scopes,
+	}
+	ctx = context.WithValue(ctx, middleware.APIKeyContextKey, trigger.APIKey)
+	ctx = context.WithValue(ctx, middleware.PrincipalContextKey, principal)
+	ctx = context.WithValue(ctx, middleware.RequestIDContextKey, trigger.TriggerID)
+
+	r, _ := http.NewRequestWithContext(ctx, http.MethodPost, triggerPath, nil)
+	return r
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// Search limits
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxSearchLength    = 1024
+	maxSearchTerms     = 20
+)
+
+// SearchResponse is the response body of a trigger search
+type SearchResponse struct {
+	Query      string            `json:"query"`
+	Results    []models.AuditLog `json:"results"`               // Matching triggers, newest first, without API keys
+	NextCursor string            `json:"next_cursor,omitempty"` // Cursor of the next page of older results when this one is full
+}
+
+// Search handles the GET /api/v1/search?q=... request
+// The query is a list of terms that must all match: commit:<sha>, ref:<ref>, ticket:<change_ref>,
+// label:<key>:<value>, job:<pattern>, since:<time>, until:<time>, or a bare value matching the job,
+// change ticket, ref, or a label value (a hexadecimal value of 7 to 64 characters matches the commit)
+func (h *AuditHandler) Search(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > maxSearchLimit {
+			writeErrorWithRequestID(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid limit: must be between 1 and %d", maxSearchLimit))
+			return
+		}
+		limit = parsed
+	}
+	location, message := requestLocation(r)
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	filter, message := parseSearchQuery(query, location, time.Now())
+	if message == "" && filter.IsEmpty() {
+		message = "Query parameter q is required"
+	}
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+	cursor, message := parseAfterCursor(r, 1)
+	if message == "" && cursor != nil && cursor[0] == 0 {
+		message = "Invalid cursor"
+	}
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+	if cursor != nil {
+		filter.BeforeID = cursor[0]
+	}
+
+	logs, err := storage.QueryAuditLogs(filter, limit, 0)
+	if err != nil {
+		logger.Error("Failed to search audit logs", "error", err, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to search triggers")
+		return
+	}
+	if h.logReads {
+		recordAdminAction(r, models.ConfigActionAuditRead, fmt.Sprintf("search q=%q rows=%d", query, len(logs)))
+	}
+
+	response := SearchResponse{Query: query, Results: make([]models.AuditLog, 0, len(logs))}
+	if len(logs) == limit {
+		response.NextCursor = encodeCursor(logs[len(logs)-1].ID)
+	}
+	// Keys restricted to jobs only find the triggers of those jobs; pages may then hold fewer results
+	principal := middleware.GetPrincipal(r)
+	for _, log := range logs {
+		if !principal.CanAccessJob(log.JobName) {
+			continue
+		}
+		log.APIKey = ""
+		log.Timestamp = log.Timestamp.In(location)
+		response.Results = append(response.Results, log)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Failed to encode search response", "error", err, "request_id", requestID)
+	}
+}
+
+// parseSearchQuery parses a search query into an audit filter, returning the client-facing error
+// message or ""; times without a zone are read in the location, and durations count back from now
+func parseSearchQuery(query string, location *time.Location, now time.Time) (models.AuditFilter, string) {
+	var filter models.AuditFilter
+	if len(query) > maxSearchLength {
+		return filter, fmt.Sprintf("Query exceeds maximum length of %d characters", maxSearchLength)
+	}
+	terms := strings.Fields(query)
+	if len(terms) > maxSearchTerms {
+		return filter, fmt.Sprintf("Maximum %d search terms allowed", maxSearchTerms)
+	}
+
+	for _, term := range terms {
+		field, value, ok := strings.Cut(term, ":")
+		if !ok || value == "" {
+			field, value = "", term
+		}
+		var message string
+		switch field {
+		case "commit":
+			filter.Commit, message = strings.ToLower(value), validateCommit(value)
+		case "ref":
+			filter.Ref, message = value, validateRef(value)
+		case "ticket":
+			filter.ChangeRef, message = value, validateChangeRef(value)
+		case "job":
+			filter.Job = value
+			if !jobNameRegex.MatchString(strings.ReplaceAll(value, "*", "")) && value != "*" {
+				message = fmt.Sprintf("Invalid job pattern '%s'", value)
+			}
+		case "label":
+			key, labelValue, ok := strings.Cut(value, ":")
+			if !ok {
+				return filter, fmt.Sprintf("Invalid label term '%s': expected label:key:value", term)
+			}
+			if message = validateLabel(key, labelValue); message == "" {
+				if filter.Labels == nil {
+					filter.Labels = make(map[string]string)
+				}
+				filter.Labels[key] = labelValue
+			}
+		case "since":
+			filter.Since, message = parseSearchTime(value, location, now)
+		case "until":
+			filter.Until, message = parseSearchTime(value, location, now)
+		case "":
+			if validateCommit(value) == "" {
+				filter.Commit = strings.ToLower(value)
+			} else {
+				filter.Terms = append(filter.Terms, value)
+			}
+		default:
+			// Values such as refs/tags/v1:2 contain colons; unknown fields are searched as bare values
+			filter.Terms = append(filter.Terms, term)
+		}
+		if message != "" {
+			return filter, message
+		}
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Until.After(filter.Since) {
+		return filter, "until must be after since"
+	}
+	return filter, ""
+}
+
+// parseSearchTime parses an RFC 3339 time, a date (2006-01-02) in the location, or a duration back
+// from now such as 90m, 24h, or 7d
+func parseSearchTime(value string, location *time.Location, now time.Time) (time.Time, string) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, ""
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, location); err == nil {
+		return t, ""
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, -n), ""
+		}
+	} else if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(-d), ""
+	}
+	return time.Time{}, fmt.Sprintf("Invalid time '%s': expected RFC 3339, a date (2006-01-02), or a duration such as 24h or 7d", value)
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// jobStatsPathPrefix and jobStatsPathSuffix surround the job name in the job stats route
+const (
+	jobStatsPathPrefix = "/api/v1/jobs/"
+	jobStatsPathSuffix = "/stats"
+	jobDailyPathSuffix = "/daily"
+)
+
+// Days of daily statistics returned by default and at most
+const (
+	defaultDailyStatsDays = 30
+	maxDailyStatsDays     = 366
+)
+
+// JobDailyStatsResponse is the response of the daily job statistics endpoint
+type JobDailyStatsResponse struct {
+	JobName  string                   `json:"job_name"`
+	Timezone string                   `json:"timezone"` // Time zone whose days are reported
+	Days     []models.DailyJobSummary `json:"days"`     // Days with triggers, oldest first
+}
+
+// StatsHandler handles per-job build statistics requests
+type StatsHandler struct{}
+
+// NewStatsHandler creates a new StatsHandler instance
+func NewStatsHandler() *StatsHandler {
+	return &StatsHandler{}
+}
+
+// GetJobStats handles the GET /api/v1/jobs/{job}/stats and GET /api/v1/jobs/{job}/daily requests
+// The job name may contain folder separators (folder/job)
+func (h *StatsHandler) GetJobStats(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r)
+
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, jobStatsPathPrefix)
+	if strings.HasSuffix(path, jobDailyPathSuffix) {
+		h.getJobDailyStats(w, r, strings.TrimSuffix(path, jobDailyPathSuffix))
+		return
+	}
+	if !strings.HasSuffix(path, jobStatsPathSuffix) {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, "Not found")
+		return
+	}
+	jobName := strings.TrimSuffix(path, jobStatsPathSuffix)
+	if !checkStatsJob(w, r, jobName) {
+		return
+	}
+	location, message := requestLocation(r)
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	stats, err := storage.GetJobStats(jobName)
+	if err != nil {
+		logger.Error("Failed to get job stats", "error", err, "job", jobName, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get job stats")
+		return
+	}
+	if stats == nil {
+		writeErrorWithRequestID(w, r, http.StatusNotFound, fmt.Sprintf("No build statistics recorded for job '%s'", jobName))
+		return
+	}
+	if stats.LastFailureAt != nil {
+		lastFailureAt := stats.LastFailureAt.In(location)
+		stats.LastFailureAt = &lastFailureAt
+	}
+	stats.UpdatedAt = stats.UpdatedAt.In(location)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		logger.Error("Failed to encode job stats response", "error", err, "request_id", requestID)
+	}
+}
+
+// getJobDailyStats handles the GET /api/v1/jobs/{job}/daily request
+// It reads the summary refreshed in the background, so recent triggers may not be counted yet
+// Days follow the tz query parameter or the API client's time zone
+func (h *StatsHandler) getJobDailyStats(w http.ResponseWriter, r *http.Request, jobName string) {
+	requestID := middleware.GetRequestID(r)
+	if !checkStatsJob(w, r, jobName) {
+		return
+	}
+	location, message := requestLocation(r)
+	if message != "" {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	days := defaultDailyStatsDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxDailyStatsDays {
+			writeErrorWithRequestID(w, r, http.StatusBadRequest, fmt.Sprintf("Invalid days: must be between 1 and %d", maxDailyStatsDays))
+			return
+		}
+		days = parsed
+	}
+
+	now := time.Now()
+	summary, err := storage.GetAuditSummary(jobName, now.AddDate(0, 0, 1-days), now, location)
+	if err != nil {
+		logger.Error("Failed to get daily job stats", "error", err, "job", jobName, "request_id", requestID)
+		writeErrorWithRequestID(w, r, http.StatusInternalServerError, "Failed to get job stats")
+		return
+	}
+	if summary == nil {
+		summary = []models.DailyJobSummary{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(JobDailyStatsResponse{JobName: jobName, Timezone: location.String(), Days: summary}); err != nil {
+		logger.Error("Failed to encode daily job stats response", "error", err, "request_id", requestID)
+	}
+}
+
+// checkStatsJob validates the job name of a stats route and the caller's access to the job,
+// writing the error response and returning false if either check fails
+func checkStatsJob(w http.ResponseWriter, r *http.Request, jobName string) bool {
+	if jobName == "" || len(jobName) > 255 || !jobNameRegex.MatchString(jobName) {
+		writeErrorWithRequestID(w, r, http.StatusBadRequest, "Invalid job name format")
+		return false
+	}
+	if !middleware.GetPrincipal(r).CanAccessJob(jobName) {
+		writeErrorWithRequestID(w, r, http.StatusForbidden, fmt.Sprintf("API key is not allowed to access job '%s'", jobName))
+		return false
+	}
+	return true
+}
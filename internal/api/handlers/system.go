@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"triggermesh/internal/logger"
+	"triggermesh/internal/timesync"
+	"triggermesh/internal/version"
+)
+
+// ClockStatus returns the latest clock check, or nil before the first check, as
+// timesync.Checker.Status does
+type ClockStatus func() *timesync.Status
+
+// SystemInfo is the response of GET /api/v1/system/info
+type SystemInfo struct {
+	Version       string           `json:"version"`
+	GoVersion     string           `json:"go_version"`
+	StartedAt     time.Time        `json:"started_at"`
+	UptimeSeconds int64            `json:"uptime_seconds"`
+	Clock         *timesync.Status `json:"clock"` // null until the engine clock is checked, or without a clock check
+}
+
+// SystemHandler reports the version, uptime, and clock skew of the running server
+type SystemHandler struct {
+	startedAt time.Time
+
+	mu    sync.RWMutex
+	clock ClockStatus // nil without a clock check
+}
+
+// NewSystemHandler creates a system handler
+func NewSystemHandler() *SystemHandler {
+	return &SystemHandler{startedAt: time.Now()}
+}
+
+// SetClockStatus sets where the clock skew is read from; it may be called while serving
+func (h *SystemHandler) SetClockStatus(clock ClockStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clock = clock
+}
+
+// GetSystemInfo handles the GET /api/v1/system/info request
+func (h *SystemHandler) GetSystemInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorWithRequestID(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	info := SystemInfo{
+		Version:       version.Version,
+		GoVersion:     runtime.Version(),
+		StartedAt:     h.startedAt,
+		UptimeSeconds: int64(time.Since(h.startedAt).Seconds()),
+	}
+	h.mu.RLock()
+	clock := h.clock
+	h.mu.RUnlock()
+	if clock != nil {
+		info.Clock = clock()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(info); err != nil {
+		logger.Error("Failed to encode system info response", "error", err)
+	}
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+)
+
+// requestLocation returns the time zone of audit and stats responses: the tz query parameter,
+// else the API client's configured timezone, else UTC
+// It returns the client-facing error message, or "" when the time zone is valid
+func requestLocation(r *http.Request) (*time.Location, string) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		if principal := middleware.GetPrincipal(r); principal != nil {
+			name = principal.Timezone
+		}
+	}
+	if name == "" {
+		return time.UTC, ""
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Sprintf("Invalid tz '%s': unknown time zone", name)
+	}
+	return location, ""
+}
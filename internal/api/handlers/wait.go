@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+)
+
+// Trigger-and-wait limits used unless SetWaitLimits is called
+const (
+	defaultMaxWait          = 5 * time.Minute
+	defaultWaitPollInterval = 5 * time.Second
+)
+
+// Wait statuses reported in TriggerJenkinsBuildResponse.WaitStatus
+const (
+	WaitCompleted  = "COMPLETED"   // The build finished; result holds its outcome
+	WaitInProgress = "IN_PROGRESS" // max_wait or the request ended while the build was queued or running
+)
+
+// SetWaitLimits bounds how long trigger requests with wait: true are held open and how often
+// the build status is checked meanwhile
+func (h *JenkinsHandler) SetWaitLimits(maxWait, pollInterval time.Duration) {
+	h.maxWait = maxWait
+	h.waitPollInterval = pollInterval
+}
+
+// validateWait checks the wait options of a trigger request
+// It returns the time to wait, and the client-facing error message, or "" when the options are valid
+func (h *JenkinsHandler) validateWait(req TriggerJenkinsBuildRequest) (time.Duration, string) {
+	if !req.Wait {
+		if req.MaxWait != 0 {
+			return 0, "max_wait requires wait"
+		}
+		return 0, ""
+	}
+	if req.NotBefore != nil && req.NotBefore.After(time.Now().Add(scheduleTolerance)) {
+		return 0, "wait cannot be combined with a future not_before"
+	}
+	maxSeconds := int(h.maxWait / time.Second)
+	if req.MaxWait < 0 || req.MaxWait > maxSeconds {
+		return 0, fmt.Sprintf("max_wait must be between 1 and %d seconds", maxSeconds)
+	}
+	if req.MaxWait == 0 {
+		return h.maxWait, ""
+	}
+	return time.Duration(req.MaxWait) * time.Second, ""
+}
+
+// waitForBuild follows a triggered build from the queue to completion
+// It returns the latest known state of the build and whether it finished within maxWait and
+// before the request was cancelled
+func (h *JenkinsHandler) waitForBuild(r *http.Request, result *engine.BuildResult, maxWait time.Duration) (*engine.BuildResult, bool) {
+	requestID := middleware.GetRequestID(r)
+	ctx, cancel := context.WithTimeout(r.Context(), maxWait)
+	defer cancel()
+	ticker := time.NewTicker(h.waitPollInterval)
+	defer ticker.Stop()
+
+	current := *result
+	for {
+		if current.BuildID == "" {
+			resolver, ok := h.jenkinsEngine.(engine.QueueResolver)
+			if !ok || current.QueueID == "" {
+				// Nothing to follow; the client can only poll the engine itself
+				return &current, false
+			}
+			buildID, err := resolver.ResolveQueuedBuild(current.QueueID)
+			if err != nil {
+				logger.Warn("Failed to resolve queued build", "error", err, "engine", h.engineName, "queue_id", current.QueueID, "request_id", requestID)
+			} else if buildID != "" {
+				current.BuildID = buildID
+			}
+		}
+		if current.BuildID != "" {
+			status, err := h.buildStatus(current.BuildID)
+			if err != nil {
+				logger.Warn("Failed to get build status while waiting", "error", err, "engine", h.engineName, "build_id", current.BuildID, "request_id", requestID)
+			} else {
+				if status.BuildURL != "" {
+					current.BuildURL = status.BuildURL
+				}
+				current.Building = status.Building
+				current.Result = status.Result
+				current.BuildDurationMS = status.BuildDurationMS
+				if !status.Building && status.Result != "" {
+					return &current, true
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return &current, false
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,10 +2,15 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"triggermesh/internal/config"
+	"triggermesh/internal/i18n"
+	"triggermesh/internal/jobmatch"
 	"triggermesh/internal/logger"
 )
 
@@ -15,33 +20,200 @@ type ContextKey string
 // APIKeyContextKey is the context key for the API key
 const APIKeyContextKey ContextKey = "api_key"
 
+// PrincipalContextKey is the context key for the authenticated principal
+const PrincipalContextKey ContextKey = "principal"
+
+// Principal is the identity behind an authenticated API key
+type Principal struct {
+	Name      string
+	Tenant    string
+	Jobs      []string  // Job name patterns the key may access; empty means all jobs
+	Scopes    []string  // Extra permissions such as config.ScopeAdmin
+	ExpiresAt time.Time // Zero if the key never expires
+	Owner     string    // Contact reminded before the key expires
+	Timezone  string    // Default IANA time zone of audit and stats responses; empty means UTC
+}
+
+// KeyExpiredCode is the error code of requests made with an expired API key
+const KeyExpiredCode = "KEY_EXPIRED"
+
+// Expired reports whether the principal's key has expired at the given time
+func (p *Principal) Expired(now time.Time) bool {
+	return !p.ExpiresAt.IsZero() && !now.Before(p.ExpiresAt)
+}
+
+// HasScope reports whether the principal was granted the given scope
+func (p *Principal) HasScope(scope string) bool {
+	if p == nil {
+		return false
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessJob reports whether the principal may see and trigger the given job
+func (p *Principal) CanAccessJob(job string) bool {
+	if p == nil || len(p.Jobs) == 0 {
+		return true
+	}
+	return jobmatch.MatchAny(p.Jobs, job)
+}
+
+// CanSeeFolder reports whether the principal may see a folder, i.e. may access
+// the folder itself or some job inside it
+func (p *Principal) CanSeeFolder(folder string) bool {
+	if p.CanAccessJob(folder) {
+		return true
+	}
+	for _, pattern := range p.Jobs {
+		if strings.HasPrefix(pattern, folder+"/") || jobmatch.Match(pattern, folder+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPrincipal extracts the authenticated principal from the request context
+// Returns nil when the request was not authenticated by AuthMiddleware
+func GetPrincipal(r *http.Request) *Principal {
+	if principal, ok := r.Context().Value(PrincipalContextKey).(*Principal); ok {
+		return principal
+	}
+	return nil
+}
+
+// IssuedKeyLookup returns the principal of an API key issued at runtime, or nil if the key is unknown
+type IssuedKeyLookup func(apiKey string) *Principal
+
 // AuthMiddleware is an HTTP middleware that validates API keys
 type AuthMiddleware struct {
-	apiKeys map[string]bool
+	mu       sync.RWMutex
+	apiKeys  map[string]*Principal
+	internal map[string]*Principal // Keys of in-process clients such as the canary; kept across reloads
+	issued   IssuedKeyLookup       // Consulted for keys that are not configured
 }
 
 // NewAuthMiddleware creates a new AuthMiddleware instance
 func NewAuthMiddleware(cfg config.APIConfig) *AuthMiddleware {
-	// Convert API keys slice to map for O(1) lookups
-	apiKeys := make(map[string]bool)
-	for _, key := range cfg.Keys {
-		apiKeys[key] = true
+	return &AuthMiddleware{
+		apiKeys: buildPrincipals(cfg),
 	}
+}
 
-	return &AuthMiddleware{
-		apiKeys: apiKeys,
+// Update replaces the accepted API keys, e.g. after a configuration reload
+func (am *AuthMiddleware) Update(cfg config.APIConfig) {
+	apiKeys := buildPrincipals(cfg)
+
+	am.mu.Lock()
+	am.apiKeys = apiKeys
+	am.mu.Unlock()
+}
+
+// SetIssuedKeyLookup accepts API keys issued at runtime, besides the configured ones
+func (am *AuthMiddleware) SetIssuedKeyLookup(lookup IssuedKeyLookup) {
+	am.mu.Lock()
+	am.issued = lookup
+	am.mu.Unlock()
+}
+
+// AddInternalKey accepts an API key generated for an in-process client such as the canary
+// Internal keys are not configured, so configuration reloads keep them
+func (am *AuthMiddleware) AddInternalKey(apiKey string, principal *Principal) {
+	am.mu.Lock()
+	if am.internal == nil {
+		am.internal = make(map[string]*Principal)
+	}
+	am.internal[apiKey] = principal
+	am.mu.Unlock()
+}
+
+// buildPrincipals converts API keys to a map for O(1) lookups
+func buildPrincipals(cfg config.APIConfig) map[string]*Principal {
+	apiKeys := make(map[string]*Principal)
+	for _, key := range cfg.Keys {
+		apiKeys[key] = &Principal{}
+	}
+	for _, client := range cfg.Clients {
+		apiKeys[client.Key] = &Principal{
+			Name:      client.Name,
+			Tenant:    client.Tenant,
+			Jobs:      client.Jobs,
+			Scopes:    client.Scopes,
+			ExpiresAt: client.ExpiresAt,
+			Owner:     client.Owner,
+			Timezone:  client.Timezone,
+		}
 	}
+	return apiKeys
 }
 
-// ValidateAPIKey returns true if the API key is valid
+// ValidateAPIKey returns true if the API key is valid and has not expired
 func (am *AuthMiddleware) ValidateAPIKey(apiKey string) bool {
+	principal := am.lookup(apiKey)
+	return principal != nil && !principal.Expired(time.Now())
+}
+
+// ExpiringKeys returns the principals of the configured API keys that have an expiry date
+func (am *AuthMiddleware) ExpiringKeys() []*Principal {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	var principals []*Principal
+	for _, principal := range am.apiKeys {
+		if !principal.ExpiresAt.IsZero() {
+			principals = append(principals, principal)
+		}
+	}
+	return principals
+}
+
+// HasClient reports whether a configured or internal API key has the client name, e.g. so that keys
+// issued at runtime cannot take it
+func (am *AuthMiddleware) HasClient(name string) bool {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	for _, principals := range []map[string]*Principal{am.apiKeys, am.internal} {
+		for _, principal := range principals {
+			if principal.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookup returns the principal for an API key, or nil if the key is unknown
+func (am *AuthMiddleware) lookup(apiKey string) *Principal {
 	// Remove Bearer prefix if present
 	apiKey = strings.TrimPrefix(apiKey, "Bearer ")
 	apiKey = strings.TrimSpace(apiKey)
 
 	// Check if the API key is in the map
-	_, ok := am.apiKeys[apiKey]
-	return ok
+	am.mu.RLock()
+	principal, issued := am.apiKeys[apiKey], am.issued
+	if principal == nil {
+		principal = am.internal[apiKey]
+	}
+	am.mu.RUnlock()
+	if principal == nil && issued != nil && apiKey != "" {
+		principal = issued(apiKey)
+	}
+	return principal
+}
+
+// Identify returns the principal of the request's API key without requiring one, e.g. for public
+// routes that adapt to the caller; it is nil for missing, unknown, and expired keys
+func (am *AuthMiddleware) Identify(r *http.Request) *Principal {
+	principal := am.lookup(GetAPIKey(r))
+	if principal == nil || principal.Expired(time.Now()) {
+		return nil
+	}
+	return principal
 }
 
 // GetAPIKey extracts the API key from the request
@@ -63,18 +235,45 @@ func (am *AuthMiddleware) Middleware(next http.Handler) http.Handler {
 		apiKey := GetAPIKey(r)
 
 		// Validate the API key
-		if !am.ValidateAPIKey(apiKey) {
+		principal := am.lookup(apiKey)
+		if principal == nil {
 			logger.Warn("Invalid API key", "ip", r.RemoteAddr, "path", r.URL.Path)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			language := i18n.Negotiate(r.Header.Get("Accept-Language"))
+			w.Header().Set("Content-Language", language)
+			http.Error(w, i18n.Translate(language, "Unauthorized"), http.StatusUnauthorized)
+			return
+		}
+		if principal.Expired(time.Now()) {
+			logger.Warn("Expired API key", "client", principal.Name, "expired_at", principal.ExpiresAt, "ip", r.RemoteAddr, "path", r.URL.Path)
+			writeKeyExpired(w, r, principal.ExpiresAt)
 			return
 		}
 
-		// Add the API key to the request context for later use
+		// Add the API key and principal to the request context for later use
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, APIKeyContextKey, apiKey)
+		ctx = context.WithValue(ctx, PrincipalContextKey, principal)
 		r = r.WithContext(ctx)
 
 		// Call the next handler
 		next.ServeHTTP(w, r)
 	})
 }
+
+// writeKeyExpired rejects a request made with an expired API key with a JSON body carrying
+// KeyExpiredCode, so clients can tell an expired key from a wrong one
+func writeKeyExpired(w http.ResponseWriter, r *http.Request, expiredAt time.Time) {
+	language := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", language)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="The API key has expired"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    false,
+		"error":      i18n.Translate(language, "API key expired"),
+		"code":       KeyExpiredCode,
+		"expired_at": expiredAt.UTC(),
+		"status":     http.StatusText(http.StatusUnauthorized),
+		"request_id": GetRequestID(r),
+	})
+}
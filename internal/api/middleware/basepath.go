@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// BasePathContextKey is the context key for the path prefix the API is served under
+const BasePathContextKey ContextKey = "base_path"
+
+// GetBasePath returns the prefix the API is served under (e.g. /triggermesh), or "" at the root
+// Handlers prepend it to the links they return
+func GetBasePath(r *http.Request) string {
+	if basePath, ok := r.Context().Value(BasePathContextKey).(string); ok {
+		return basePath
+	}
+	return ""
+}
+
+// StripBasePath serves the API under basePath: the prefix is removed from request paths before
+// routing and requests outside it are not found. An empty basePath serves the API at the root
+func StripBasePath(basePath string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if basePath == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := r.URL.Path
+			if path != basePath && !strings.HasPrefix(path, basePath+"/") {
+				http.NotFound(w, r)
+				return
+			}
+
+			r2 := r.WithContext(context.WithValue(r.Context(), BasePathContextKey, basePath))
+			u := *r.URL
+			u.Path = strings.TrimPrefix(path, basePath)
+			if u.Path == "" {
+				u.Path = "/"
+			}
+			u.RawPath = strings.TrimPrefix(r.URL.RawPath, basePath)
+			r2.URL = &u
+			next.ServeHTTP(w, r2)
+		})
+	}
+}
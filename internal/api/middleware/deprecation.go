@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/logger"
+)
+
+// deprecationWarningField is the field added to JSON object responses of deprecated routes
+const deprecationWarningField = "warning"
+
+// Deprecate is a middleware that marks responses of deprecated routes with the Deprecation
+// (RFC 9745), Sunset (RFC 8594), and Link headers, and adds a warning field to JSON objects
+// The most specific route of the deprecations applies
+func Deprecate(deprecations []config.DeprecationConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(deprecations) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deprecation, ok := matchDeprecation(r.URL.Path, deprecations)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			logger.Info("Deprecated route called", "route", deprecation.Route, "path", r.URL.Path, "ip", r.RemoteAddr, "request_id", GetRequestID(r))
+
+			header := w.Header()
+			if deprecation.Since.IsZero() {
+				header.Set("Deprecation", "true")
+			} else {
+				header.Set("Deprecation", "@"+strconv.FormatInt(deprecation.Since.Unix(), 10))
+			}
+			if !deprecation.Sunset.IsZero() {
+				header.Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if deprecation.Link != "" {
+				header.Add("Link", fmt.Sprintf("<%s>; rel=\"deprecation\"", deprecation.Link))
+			}
+
+			warning := DeprecationWarning(deprecation)
+			jw := newJSONRewriter(w, func(_ int, body []byte) []byte {
+				return addWarningField(body, warning)
+			})
+			defer jw.finish()
+			next.ServeHTTP(jw, r)
+		})
+	}
+}
+
+// DeprecationWarning returns the warning of a deprecated route's JSON responses
+func DeprecationWarning(deprecation config.DeprecationConfig) string {
+	if deprecation.Message != "" {
+		return deprecation.Message
+	}
+	warning := fmt.Sprintf("%s is deprecated", deprecation.Route)
+	if !deprecation.Sunset.IsZero() {
+		warning += " and may be removed after " + deprecation.Sunset.UTC().Format("2006-01-02")
+	}
+	if deprecation.Link != "" {
+		warning += "; see " + deprecation.Link
+	}
+	return warning
+}
+
+// matchDeprecation returns the deprecation with the longest route matching the path
+func matchDeprecation(path string, deprecations []config.DeprecationConfig) (config.DeprecationConfig, bool) {
+	var match config.DeprecationConfig
+	matched := -1
+	for _, deprecation := range deprecations {
+		prefix := strings.TrimSuffix(deprecation.Route, "/")
+		if (prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")) && len(prefix) > matched {
+			match, matched = deprecation, len(prefix)
+		}
+	}
+	return match, matched >= 0
+}
+
+// addWarningField adds the warning as the last field of a JSON object; other bodies, and objects
+// that already have a warning, are returned unchanged
+func addWarningField(body []byte, warning string) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+	if _, exists := fields[deprecationWarningField]; exists {
+		return body
+	}
+	value, err := json.Marshal(warning)
+	if err != nil {
+		return body
+	}
+
+	// Insert before the closing brace, keeping the field order and trailing newline of the encoder
+	end := bytes.LastIndexByte(body, '}')
+	field := append([]byte(`"`+deprecationWarningField+`":`), value...)
+	if len(fields) > 0 {
+		field = append([]byte(","), field...)
+	}
+	amended := make([]byte, 0, len(body)+len(field))
+	amended = append(amended, bytes.TrimRight(body[:end], " \t\r\n")...)
+	amended = append(amended, field...)
+	return append(amended, body[end:]...)
+}
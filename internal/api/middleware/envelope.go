@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// EnvelopeHeader marks responses wrapped in the response envelope, so clients know to unwrap data
+const EnvelopeHeader = "X-Response-Envelope"
+
+// nextCursorHeader carries the cursor of the next page of paginated responses, repeated in the envelope
+const nextCursorHeader = "X-Next-Cursor"
+
+// ResponseEnvelope is the standard shape of successful responses with server.response_envelope
+type ResponseEnvelope struct {
+	Data      json.RawMessage `json:"data"`       // The response body without the envelope
+	RequestID string          `json:"request_id"` // As in the X-Request-ID header
+	Meta      EnvelopeMeta    `json:"meta"`
+}
+
+// EnvelopeMeta describes an enveloped response
+type EnvelopeMeta struct {
+	Status     int    `json:"status"`                // HTTP status code
+	NextCursor string `json:"next_cursor,omitempty"` // Cursor of the next page when a paginated response is full
+}
+
+// Envelope is a middleware that wraps successful JSON responses in a ResponseEnvelope, for
+// organizations whose API guidelines mandate one. Error responses and non-JSON bodies keep their shape
+func Envelope(enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			jw := newJSONRewriter(w, func(status int, body []byte) []byte {
+				if status < 200 || status > 299 || !json.Valid(body) {
+					return body
+				}
+				envelope := ResponseEnvelope{
+					Data:      bytes.TrimSpace(body),
+					RequestID: GetRequestID(r),
+					Meta:      EnvelopeMeta{Status: status, NextCursor: w.Header().Get(nextCursorHeader)},
+				}
+				var buf bytes.Buffer
+				if err := json.NewEncoder(&buf).Encode(envelope); err != nil {
+					return body
+				}
+				w.Header().Set(EnvelopeHeader, "true")
+				return buf.Bytes()
+			})
+			defer jw.finish()
+			next.ServeHTTP(jw, r)
+		})
+	}
+}
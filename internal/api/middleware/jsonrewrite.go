@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// jsonRewriter holds back JSON responses so a middleware can rewrite them before they are sent;
+// other responses, and JSON responses once flushed, pass through unchanged
+type jsonRewriter struct {
+	http.ResponseWriter
+	rewrite     func(status int, body []byte) []byte
+	status      int
+	wroteHeader bool
+	buffering   bool
+	body        bytes.Buffer
+}
+
+// newJSONRewriter wraps w; the caller must call finish once the handler returns
+func newJSONRewriter(w http.ResponseWriter, rewrite func(status int, body []byte) []byte) *jsonRewriter {
+	return &jsonRewriter{ResponseWriter: w, rewrite: rewrite}
+}
+
+// WriteHeader records the status; JSON responses are written by finish
+func (jw *jsonRewriter) WriteHeader(status int) {
+	if jw.wroteHeader {
+		return
+	}
+	jw.wroteHeader = true
+	jw.status = status
+	jw.buffering = isJSONContentType(jw.Header().Get("Content-Type"))
+	if !jw.buffering {
+		jw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+// Write buffers JSON responses and passes others through
+func (jw *jsonRewriter) Write(p []byte) (int, error) {
+	if !jw.wroteHeader {
+		jw.WriteHeader(http.StatusOK)
+	}
+	if jw.buffering {
+		return jw.body.Write(p)
+	}
+	return jw.ResponseWriter.Write(p)
+}
+
+// Flush sends a buffered JSON response as is, since a streamed response cannot be rewritten
+func (jw *jsonRewriter) Flush() {
+	if jw.buffering {
+		jw.buffering = false
+		jw.ResponseWriter.WriteHeader(jw.status)
+		jw.ResponseWriter.Write(jw.body.Bytes())
+		jw.body.Reset()
+	}
+	if flusher, ok := jw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (jw *jsonRewriter) Unwrap() http.ResponseWriter {
+	return jw.ResponseWriter
+}
+
+// finish writes a buffered JSON response, rewritten
+func (jw *jsonRewriter) finish() {
+	if !jw.buffering {
+		return
+	}
+	jw.buffering = false
+	body := jw.rewrite(jw.status, jw.body.Bytes())
+	jw.Header().Del("Content-Length")
+	jw.ResponseWriter.WriteHeader(jw.status)
+	jw.ResponseWriter.Write(body)
+}
+
+// isJSONContentType reports whether the content type is application/json or a +json type such as
+// application/problem+json
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -1,23 +1,73 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"net/http/pprof"
 	"strings"
+	"time"
 
+	"triggermesh/internal/alert"
 	"triggermesh/internal/api/handlers"
 	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/archive"
+	"triggermesh/internal/authz"
+	"triggermesh/internal/blackout"
+	"triggermesh/internal/canary"
+	"triggermesh/internal/change"
 	"triggermesh/internal/config"
+	"triggermesh/internal/email"
 	"triggermesh/internal/engine"
+	"triggermesh/internal/grafana"
+	"triggermesh/internal/incident"
+	"triggermesh/internal/keyexpiry"
 	"triggermesh/internal/logger"
+	"triggermesh/internal/metrics"
+	"triggermesh/internal/outbound"
+	"triggermesh/internal/quota"
+	"triggermesh/internal/resilience"
+	"triggermesh/internal/scheduler"
 	"triggermesh/internal/storage"
+	"triggermesh/internal/throttle"
+	"triggermesh/internal/transform"
+	"triggermesh/internal/version"
 )
 
+// managementRoutes are the path prefixes served only by the management listener once it is enabled:
+// the admin-scoped APIs, the profiler, and the metrics
+var managementRoutes = []string{
+	"/api/v1/admin",
+	"/api/v1/audit/config",
+	"/api/v1/audit/{id}/replay",
+	"/api/v1/audit/replay",
+	"/api/v1/keys/requests/{id}/approve",
+	"/api/v1/keys/requests/{id}/deny",
+	"/debug/pprof",
+	"/metrics",
+}
+
 // Router represents the API router
 type Router struct {
 	mux            *http.ServeMux
+	basePath       string
 	allowedOrigins []string
 	maxBodySize    int64
+	routes         []string // Path prefixes served; empty serves all routes
+	hiddenRoutes   []string // Path prefixes answered with 404, e.g. management routes on public listeners
+	deprecations   []config.DeprecationConfig
+	envelope       bool // Wrap successful JSON responses in the response envelope
+	authMiddleware *middleware.AuthMiddleware
+	readiness      *handlers.ReadinessHandler
+	jenkins        *handlers.JenkinsHandler
+	engines        *handlers.EngineHandler
+	alerts         *alert.Evaluator  // nil with alerts disabled
+	incidents      *incident.Manager // nil without an incident provider
+	notifications  *handlers.JenkinsNotificationHandler
+	audit          *handlers.AuditHandler
+	system         *handlers.SystemHandler
+	metrics        *metrics.Registry
+	guards         map[string]*resilience.Guard // Retries and circuit breakers of the configured engines by name
 }
 
 // NewRouter creates a new Router instance
@@ -28,26 +78,220 @@ func NewRouter(
 	// Create a new ServeMux
 	mux := http.NewServeMux()
 
+	// Metrics served at /metrics
+	registry := metrics.NewRegistry()
+
 	// Create handlers
 	jenkinsHandler := handlers.NewJenkinsHandler(jenkinsEngine)
+	engineHandler := handlers.NewEngineHandler(jenkinsEngine)
 	auditHandler := handlers.NewAuditHandler()
+	auditHandler.SetLogReads(cfg.Audit.LogReads)
+	statsHandler := handlers.NewStatsHandler()
+	var backupUploader archive.Uploader
+	if cfg.Database.BackupS3.Bucket != "" {
+		backupUploader = archive.NewS3Uploader(cfg.Database.BackupS3)
+	}
+	keyRequestHandler := handlers.NewKeyRequestHandler()
+	systemHandler := handlers.NewSystemHandler()
+	backupHandler := handlers.NewBackupHandler(cfg.Database.BackupDir, backupUploader, cfg.Database.BackupS3.Prefix)
+	killSwitchHandler := handlers.NewKillSwitchHandler()
+	// With readiness gating, the server reports ready only after startup checks complete
+	readinessHandler := handlers.NewReadinessHandler(!cfg.Server.ReadinessGating)
+	if cfg.Stats.Enabled {
+		jenkinsHandler.EnableBuildTracking()
+	}
+	jenkinsHandler.SetAuditParamsLimit(cfg.Audit.MaxParamsSize, cfg.Audit.KeepFullParams)
+	var alerts *alert.Evaluator
+	if cfg.Alerts.Enabled {
+		evaluator, err := alert.NewEvaluator(cfg.Alerts)
+		if err != nil {
+			logger.Error("Failed to create alert evaluator, alerts disabled", "error", err)
+		} else {
+			if cfg.Concurrency.Notifications > 0 && cfg.Concurrency.TaskTimeout > 0 {
+				evaluator.SetConcurrency(cfg.Concurrency.Notifications, time.Duration(cfg.Concurrency.TaskTimeout)*time.Second)
+			}
+			jenkinsHandler.SetAlertEvaluator(evaluator)
+			alerts = evaluator
+		}
+	}
+	if cfg.Grafana.URL != "" {
+		policy, err := outbound.NewPolicy(cfg.Outbound)
+		if err != nil {
+			logger.Error("Failed to create Grafana annotator, annotations disabled", "error", err)
+		} else {
+			jenkinsHandler.SetGrafanaAnnotator(grafana.NewAnnotator(cfg.Grafana, policy))
+		}
+	}
+	if cfg.Notifications.Email.Host != "" {
+		policy, err := outbound.NewPolicy(cfg.Outbound)
+		var mailer *email.Mailer
+		if err == nil {
+			mailer, err = email.NewMailer(cfg.Notifications.Email, policy)
+		}
+		if err != nil {
+			logger.Error("Failed to create mailer, email notifications disabled", "error", err)
+		} else {
+			keyRequestHandler.SetMailer(mailer)
+		}
+	}
+	var incidents *incident.Manager
+	if cfg.Incidents.Provider != "" {
+		policy, err := outbound.NewPolicy(cfg.Outbound)
+		var provider incident.Provider
+		if err == nil {
+			provider, err = incident.NewProvider(cfg.Incidents, policy)
+		}
+		if err != nil {
+			logger.Error("Failed to create incident provider, incidents disabled", "error", err)
+		} else {
+			incidents = incident.NewManager(cfg.Incidents, provider)
+			jenkinsHandler.SetIncidentManager(incidents)
+		}
+	}
+	if cfg.Authz.Enabled {
+		jenkinsHandler.SetAuthorizer(authz.NewAuthorizer(cfg.Authz))
+	}
+	if len(cfg.Blackout.Windows) > 0 {
+		calendar, err := blackout.NewCalendar(cfg.Blackout)
+		if err != nil {
+			logger.Error("Failed to create blackout calendar, blackout windows disabled", "error", err)
+		} else {
+			jenkinsHandler.SetBlackoutCalendar(calendar)
+		}
+	}
+	if cfg.Throttle.Enabled {
+		if reporter, ok := jenkinsEngine.(engine.OverviewReporter); ok {
+			jenkinsHandler.SetThrottle(throttle.NewController(cfg.Throttle, reporter))
+		} else {
+			logger.Warn("Jenkins engine cannot report its build queue, adaptive throttling disabled")
+		}
+	}
+	var quotas *quota.Limiter
+	if len(cfg.Quotas.Categories) > 0 {
+		quotas = quota.NewLimiter(cfg.Quotas, storage.CountTrackedBuildsByJob)
+		jenkinsHandler.SetQuotas(quotas)
+	}
+	if cfg.Change.Enabled() {
+		checker, err := change.NewChecker(cfg.Change)
+		if err != nil {
+			logger.Error("Failed to create change checker, change policy disabled", "error", err)
+		} else {
+			jenkinsHandler.SetChangeChecker(checker)
+		}
+	}
+	if len(cfg.Transform.Rules) > 0 {
+		policy, err := outbound.NewPolicy(cfg.Outbound)
+		var pipeline *transform.Pipeline
+		if err == nil {
+			pipeline, err = transform.NewPipeline(cfg.Transform, policy)
+		}
+		if err != nil {
+			logger.Error("Failed to create parameter transform pipeline, transforms disabled", "error", err)
+		} else {
+			jenkinsHandler.SetTransformPipeline(pipeline)
+		}
+	}
+	jenkinsHandler.SetMaxScheduleDelay(time.Duration(cfg.Scheduler.MaxDelay) * time.Second)
+	if len(cfg.Scheduler.Schedules) > 0 {
+		var schedules []*scheduler.Recurring
+		for _, sc := range cfg.Scheduler.Schedules {
+			schedule, err := scheduler.NewRecurring(sc)
+			if err != nil {
+				logger.Error("Failed to create recurring schedule, schedule disabled", "error", err)
+				continue
+			}
+			schedules = append(schedules, schedule)
+		}
+		jenkinsHandler.SetSchedules(schedules)
+	}
+	if cfg.Wait.MaxWait > 0 && cfg.Wait.PollInterval > 0 {
+		jenkinsHandler.SetWaitLimits(time.Duration(cfg.Wait.MaxWait)*time.Second, time.Duration(cfg.Wait.PollInterval)*time.Second)
+	}
+	jenkinsHandler.SetResultReuse(cfg.Reuse.Rules)
+	jenkinsHandler.SetMetrics(registry)
+	jenkinsHandler.SetBuildURLs(cfg.Server.BuildURLs)
+	engineHandler.SetBuildURLs(cfg.Server.BuildURLs)
+	guardMetrics := resilience.NewMetrics(registry)
+	jenkinsGuard := resilience.NewGuard("jenkins", cfg.Jenkins.Retry, cfg.Jenkins.Breaker)
+	jenkinsGuard.SetMetrics(guardMetrics)
+	jenkinsGuard.SetObserver(readinessHandler.RecordEngine)
+	jenkinsHandler.SetGuard(jenkinsGuard)
+	engineHandler.SetJenkinsGuard(jenkinsGuard)
+	guards := make(map[string]*resilience.Guard, len(cfg.Engines))
+	for _, ec := range cfg.Engines {
+		guard := resilience.NewGuard(ec.Name, ec.Retry, ec.Breaker)
+		guard.SetMetrics(guardMetrics)
+		guard.SetObserver(readinessHandler.RecordEngine)
+		guards[ec.Name] = guard
+	}
+	if cfg.Concurrency.BulkReplay > 0 && cfg.Concurrency.TaskTimeout > 0 {
+		jenkinsHandler.SetConcurrency(cfg.Concurrency.BulkReplay, time.Duration(cfg.Concurrency.TaskTimeout)*time.Second)
+	}
+	if cfg.Jenkins.LabelParameterPrefix != "" {
+		jenkinsHandler.InjectLabelParameters(cfg.Jenkins.LabelParameterPrefix)
+	}
+	jenkinsHandler.SetCommitParameters(cfg.Jenkins.CommitParameter, cfg.Jenkins.RefParameter)
 
 	// Create middleware
 	authMiddleware := middleware.NewAuthMiddleware(cfg.API)
+	authMiddleware.SetIssuedKeyLookup(handlers.LookupIssuedKey)
+	keyRequestHandler.SetClientNames(authMiddleware.HasClient)
 
 	// Public routes
 	// Root path handler
+	// A valid API key is optional and selects the branding of its tenant
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(map[string]interface{}{
-			"message": "TriggerMesh API",
-			"version": "1.0.0",
-			"endpoints": []string{
+		var tenant string
+		if principal := authMiddleware.Identify(r); principal != nil {
+			tenant = principal.Tenant
+		}
+		response := map[string]interface{}{
+			"message": handlers.ServiceName(cfg.Branding, tenant) + " API",
+			"version": version.Version,
+			"endpoints": withBasePath(middleware.GetBasePath(r), []string{
 				"/health - Health check",
+				"/readyz - Readiness check",
+				"/healthz?token={monitoring_token} - Heartbeat for external uptime monitors",
 				"/api/v1/trigger/jenkins - Trigger Jenkins build",
+				"/api/v1/trigger/scheduled/{trigger_id} - Get a trigger held until its not_before time",
+				"/api/v1/engines - List engines and their supported operations",
+				"/api/v1/trigger/{engine} - Trigger a build on a configured engine",
+				"/api/v1/engines/{engine}/builds/{build_id} - Get build status on a configured engine",
+				"/api/v1/engines/{engine}/openapi - Get the OpenAPI document of an engine's trigger request, with its fields",
+				"/api/v1/builds/{global_build_id} - Get build status by the ID returned from any trigger",
+				"/api/v1/jenkins/jobs - List Jenkins jobs visible to the API key",
+				"/api/v1/jenkins/jobs/{job}/builds - List recent builds of a job",
+				"/api/v1/jenkins/builds/{job}/{number} - Get Jenkins build status",
+				"/api/v1/jenkins/overview - Get Jenkins queue length and executor status",
+				"/api/v1/notifications/jenkins?token={notification_token} - Report finished Jenkins builds (Notification plugin)",
+				"/api/v1/jobs/{job}/daily - Get daily trigger counts and failure rates of a job",
+				"/api/v1/jobs/{job}/stats - Get per-job build statistics",
 				"/api/v1/audit - Get audit logs",
-			},
-		}); err != nil {
+				"/api/v1/audit/tail - Stream new audit entries as Server-Sent Events",
+				"/api/v1/audit/archives - Get archived and live audit ranges",
+				"/api/v1/audit/config - Get configuration changes and admin actions (admin scope)",
+				"/api/v1/audit/{id}/params - Get the full parameters of an audit entry",
+				"/api/v1/audit/{id}/replay - Replay a recorded trigger (admin scope)",
+				"/api/v1/audit/replay - Re-trigger failed triggers in a time range (admin scope)",
+				"/api/v1/search?q={query} - Search triggers by commit, ref, label, change ticket, job, and time range",
+				"/api/v1/me - Get the calling API key's identity, usage, and recent triggers",
+				"/api/v1/keys/requests - Request an API key, or list key requests",
+				"/api/v1/keys/requests/{id}/approve - Approve or deny (/deny) a key request (admin scope)",
+				"/api/v1/keys/requests/{id}/claim - Claim the key of an approved request, shown once",
+				"/api/v1/system/info - Get the server version, uptime, and clock skew against Jenkins",
+				"/api/v1/admin/backup - Back up the database (admin scope)",
+				"/api/v1/admin/kill-switches - Disable triggering of jobs until an expiry, or list active kill switches (admin scope)",
+				"/api/v1/admin/kill-switches/{id} - Lift a kill switch with DELETE (admin scope)",
+				"/api/v1/admin/reuse - List result reuse entries, or purge them by job with DELETE (admin scope)",
+				"/api/v1/admin/reuse/{audit_id} - Purge a result reuse entry with DELETE (admin scope)",
+				"/metrics - Get metrics in the Prometheus text format",
+			}),
+		}
+		if branding := handlers.NewBranding(cfg.Branding, tenant); branding != nil {
+			response["branding"] = branding
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
 			logger.Error("Failed to encode response", "error", err)
 		}
 	})
@@ -56,6 +300,17 @@ func NewRouter(
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
+		// Storage is opened in the background during gated startup; the process is still alive
+		if !readinessHandler.Ready() {
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(map[string]interface{}{
+				"status": "starting",
+			}); err != nil {
+				logger.Error("Failed to encode health check response", "error", err)
+			}
+			return
+		}
+
 		// Check database connection
 		if err := storage.Ping(); err != nil {
 			w.WriteHeader(http.StatusServiceUnavailable)
@@ -76,32 +331,327 @@ func NewRouter(
 		}
 	})
 
+	// Readiness check
+	mux.HandleFunc("/readyz", readinessHandler.Readyz)
+
+	// Heartbeat for uptime monitors, authenticated by the monitoring token instead of an API key
+	mux.HandleFunc("/healthz", handlers.NewHeartbeatHandler(cfg.Monitoring.Token, readinessHandler).Healthz)
+
+	// Build notifications authenticate with the notification token, since Jenkins cannot send API keys
+	notificationHandler := handlers.NewJenkinsNotificationHandler(cfg.Jenkins.NotificationToken)
+	mux.HandleFunc("/api/v1/notifications/jenkins", notificationHandler.Notify)
+
 	// Protected routes
 	// Jenkins routes
 	mux.Handle("/api/v1/trigger/jenkins", authMiddleware.Middleware(http.HandlerFunc(jenkinsHandler.TriggerJenkinsBuild)))
+	mux.Handle("/api/v1/trigger/scheduled/", authMiddleware.Middleware(http.HandlerFunc(jenkinsHandler.GetScheduledTrigger)))
+	mux.Handle("/api/v1/jenkins/jobs", authMiddleware.Middleware(http.HandlerFunc(jenkinsHandler.ListJenkinsJobs)))
+	mux.Handle("/api/v1/jenkins/jobs/", authMiddleware.Middleware(http.HandlerFunc(jenkinsHandler.ListJenkinsJobBuilds)))
+	mux.Handle("/api/v1/jenkins/builds/", authMiddleware.Middleware(http.HandlerFunc(jenkinsHandler.GetJenkinsBuildStatus)))
+	mux.Handle("/api/v1/jenkins/overview", authMiddleware.Middleware(http.HandlerFunc(jenkinsHandler.GetJenkinsOverview)))
+
+	// Routes of the other engines
+	mux.Handle("/api/v1/trigger/", authMiddleware.Middleware(http.HandlerFunc(engineHandler.Trigger)))
+	mux.Handle("/api/v1/engines", authMiddleware.Middleware(http.HandlerFunc(engineHandler.ListEngines)))
+	mux.Handle("/api/v1/engines/", authMiddleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/openapi") && !strings.Contains(r.URL.Path, "/builds/") {
+			engineHandler.GetOpenAPI(w, r)
+			return
+		}
+		engineHandler.GetBuildStatus(w, r)
+	})))
+	mux.Handle("/api/v1/builds/", authMiddleware.Middleware(http.HandlerFunc(engineHandler.GetGlobalBuildStatus)))
+
+	// Job statistics routes
+	mux.Handle("/api/v1/jobs/", authMiddleware.Middleware(http.HandlerFunc(statsHandler.GetJobStats)))
 
 	// Audit routes
 	mux.Handle("/api/v1/audit", authMiddleware.Middleware(http.HandlerFunc(auditHandler.GetAuditLogs)))
+	mux.Handle("/api/v1/audit/tail", authMiddleware.Middleware(http.HandlerFunc(auditHandler.TailAuditLogs)))
+	mux.Handle("/api/v1/audit/archives", authMiddleware.Middleware(http.HandlerFunc(auditHandler.GetAuditArchives)))
+	mux.Handle("/api/v1/audit/config", authMiddleware.Middleware(http.HandlerFunc(auditHandler.GetConfigAudit)))
+	mux.Handle("/api/v1/audit/", authMiddleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/params") {
+			auditHandler.GetAuditParams(w, r)
+			return
+		}
+		jenkinsHandler.ReplayAuditEntry(w, r)
+	})))
+	mux.Handle("/api/v1/audit/replay", authMiddleware.Middleware(http.HandlerFunc(jenkinsHandler.BulkReplay)))
+	mux.Handle("/api/v1/search", authMiddleware.Middleware(http.HandlerFunc(auditHandler.Search)))
+
+	// API key routes
+	meHandler := handlers.NewMeHandler(cfg.Branding)
+	if quotas != nil {
+		meHandler.SetQuotas(quotas)
+	}
+	mux.Handle("/api/v1/me", authMiddleware.Middleware(http.HandlerFunc(meHandler.GetMe)))
+	mux.Handle("/api/v1/keys/requests", authMiddleware.Middleware(http.HandlerFunc(keyRequestHandler.Requests)))
+	mux.Handle("/api/v1/keys/requests/", authMiddleware.Middleware(http.HandlerFunc(keyRequestHandler.Request)))
+
+	// System routes
+	mux.Handle("/api/v1/system/info", authMiddleware.Middleware(http.HandlerFunc(systemHandler.GetSystemInfo)))
+
+	// Admin routes
+	mux.Handle("/api/v1/admin/backup", authMiddleware.Middleware(http.HandlerFunc(backupHandler.CreateBackup)))
+	mux.Handle("/api/v1/admin/kill-switches", authMiddleware.Middleware(http.HandlerFunc(killSwitchHandler.KillSwitches)))
+	mux.Handle("/api/v1/admin/kill-switches/", authMiddleware.Middleware(http.HandlerFunc(killSwitchHandler.KillSwitches)))
+	mux.Handle("/api/v1/admin/reuse", authMiddleware.Middleware(http.HandlerFunc(jenkinsHandler.ReuseEntries)))
+	mux.Handle("/api/v1/admin/reuse/", authMiddleware.Middleware(http.HandlerFunc(jenkinsHandler.ReuseEntries)))
+
+	// Metrics, on the management listener once it is enabled
+	mux.Handle("/metrics", authMiddleware.Middleware(registry))
+
+	// Profiler, only reachable through the management listener
+	if cfg.Server.Management.Pprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	var hiddenRoutes []string
+	if cfg.Server.Management.Enabled() {
+		hiddenRoutes = managementRoutes
+	}
 
 	return &Router{
 		mux:            mux,
+		basePath:       cfg.Server.BasePath,
 		allowedOrigins: cfg.Server.AllowedOrigins,
 		maxBodySize:    cfg.Server.MaxBodySize,
+		hiddenRoutes:   hiddenRoutes,
+		deprecations:   cfg.Server.Deprecations,
+		envelope:       cfg.Server.ResponseEnvelope,
+		authMiddleware: authMiddleware,
+		readiness:      readinessHandler,
+		jenkins:        jenkinsHandler,
+		engines:        engineHandler,
+		alerts:         alerts,
+		incidents:      incidents,
+		notifications:  notificationHandler,
+		audit:          auditHandler,
+		system:         systemHandler,
+		metrics:        registry,
+		guards:         guards,
 	}
 }
 
+// Close waits for alert deliveries and stops the background delivery of incident events, after
+// delivering the queued ones
+func (r *Router) Close() {
+	if r.alerts != nil {
+		r.alerts.Close()
+	}
+	if r.incidents != nil {
+		r.incidents.Close()
+	}
+}
+
+// CloseStreams ends the open audit tail streams, which a graceful HTTP server shutdown would
+// otherwise wait for until its timeout
+func (r *Router) CloseStreams() {
+	r.audit.CloseStreams()
+}
+
+// AddEngine serves triggers and build status for an engine besides Jenkins at
+// /api/v1/trigger/{name} and /api/v1/engines/{name}/builds/{build_id}, with the Jenkins trigger policies
+// and the retry and circuit breaker settings of the engine's configuration, if any; the outcomes of
+// its calls are reported as its connectivity at /readyz
+// engineType is reported by GET /api/v1/engines. Trigger requests accept the fields of engines
+// implementing engine.FieldProvider followed by the given fields, e.g. from the engine configuration
+func (r *Router) AddEngine(name, engineType string, e engine.CIEngine, fields ...engine.RequestField) {
+	handler := r.jenkins.ForEngine(name, e)
+	if provider, ok := e.(engine.FieldProvider); ok {
+		fields = append(provider.RequestFields(), fields...)
+	}
+	handler.SetRequestFields(fields)
+	// Engines registered in code are called once per request, with their outcomes still reported at /readyz
+	guard, ok := r.guards[name]
+	if !ok {
+		guard = resilience.NewGuard(name, config.RetryConfig{}, config.BreakerConfig{})
+		guard.SetObserver(r.readiness.RecordEngine)
+	}
+	handler.SetGuard(guard)
+	r.engines.Add(name, engineType, handler)
+}
+
+// SetBuildReporter records the builds reported at /api/v1/notifications/jenkins with the reporter,
+// typically the Report method of the stats poller
+func (r *Router) SetBuildReporter(reporter handlers.BuildReporter) {
+	r.notifications.SetReporter(reporter)
+}
+
+// SetClockStatus reports the clock skew read from the function at /api/v1/system/info, typically the
+// Status method of the clock checker
+func (r *Router) SetClockStatus(clock handlers.ClockStatus) {
+	r.system.SetClockStatus(clock)
+}
+
+// Metrics returns the registry of the metrics served at /metrics
+func (r *Router) Metrics() *metrics.Registry {
+	return r.metrics
+}
+
+// EnableCanary serves the canary engine at /api/v1/trigger/canary and accepts a generated API key
+// limited to the canary job, returning the prober that sends the canary probes through this router
+func (r *Router) EnableCanary(cfg config.CanaryConfig, e engine.CIEngine) (*canary.Prober, error) {
+	apiKey, err := canary.NewKey()
+	if err != nil {
+		return nil, err
+	}
+	r.authMiddleware.AddInternalKey(apiKey, &middleware.Principal{Name: canary.Client, Jobs: []string{canary.Job}})
+	r.AddEngine(config.CanaryEngine, "canary", e)
+	return canary.NewProber(cfg, r, r.basePath, apiKey, r.metrics), nil
+}
+
+// Readiness returns the readiness state reported by /readyz
+func (r *Router) Readiness() *handlers.ReadinessHandler {
+	return r.readiness
+}
+
+// FireDueTriggers fires the scheduled triggers whose not_before has been reached and the
+// recurring schedules whose fire time has come
+func (r *Router) FireDueTriggers(ctx context.Context) (int, error) {
+	fired, err := r.jenkins.FireDueTriggers(ctx)
+	if err != nil {
+		return fired, err
+	}
+	recurring, err := r.jenkins.FireRecurringSchedules(ctx)
+	return fired + recurring, err
+}
+
+// UpdateAPIConfig replaces the accepted API keys and clients, e.g. after a configuration reload
+func (r *Router) UpdateAPIConfig(cfg config.APIConfig) {
+	r.authMiddleware.Update(cfg)
+}
+
+// ExpiringKeys returns the configured and issued API keys that expire before the given time
+func (r *Router) ExpiringKeys(before time.Time) ([]keyexpiry.Key, error) {
+	var keys []keyexpiry.Key
+	for _, principal := range r.authMiddleware.ExpiringKeys() {
+		if principal.ExpiresAt.Before(before) {
+			keys = append(keys, keyexpiry.Key{Client: principal.Name, Owner: principal.Owner, ExpiresAt: principal.ExpiresAt})
+		}
+	}
+
+	issued, err := storage.GetExpiringIssuedKeys(before)
+	if err != nil {
+		return nil, err
+	}
+	for _, request := range issued {
+		keys = append(keys, keyexpiry.Key{Client: request.Name, Owner: request.RequestedBy, ExpiresAt: *request.ExpiresAt})
+	}
+	return keys, nil
+}
+
+// ForListener returns a handler for an additional listener, serving the listener's routes with its
+// CORS, body size, and base path settings. Unset settings fall back to those of the server
+func (r *Router) ForListener(listener config.ListenerConfig) http.Handler {
+	listenerRouter := *r
+	listenerRouter.routes = listener.Routes
+	if listener.AllowedOrigins != nil {
+		listenerRouter.allowedOrigins = listener.AllowedOrigins
+	}
+	if listener.MaxBodySize != 0 {
+		listenerRouter.maxBodySize = listener.MaxBodySize
+	}
+	if listener.BasePath != "" {
+		listenerRouter.basePath = listener.BasePath
+	}
+	return &listenerRouter
+}
+
+// ForManagement returns a handler for the management listener, serving only the management routes
+// and the health checks at the root with the server's CORS and body size settings
+func (r *Router) ForManagement() http.Handler {
+	managementRouter := *r
+	managementRouter.basePath = ""
+	managementRouter.routes = append([]string{"/health", "/healthz", "/readyz"}, managementRoutes...)
+	managementRouter.hiddenRoutes = nil
+	return &managementRouter
+}
+
 // ServeHTTP implements the http.Handler interface
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	// Chain middleware: RequestID -> BodySizeLimit -> CORS -> Mux
+	// Chain middleware: RequestID -> BasePath -> Routes -> Deprecation -> Envelope -> BodySizeLimit -> CORS -> Readiness -> Mux
 	handler := chainMiddleware(
 		http.HandlerFunc(r.mux.ServeHTTP),
 		middleware.RequestIDMiddleware,
+		middleware.StripBasePath(r.basePath),
+		r.routesMiddleware,
+		middleware.Deprecate(r.deprecations),
+		middleware.Envelope(r.envelope),
 		middleware.LimitBodySize(r.maxBodySize),
 		r.corsMiddleware,
+		r.readiness.Middleware,
 	)
 	handler.ServeHTTP(w, req)
 }
 
+// routesMiddleware answers 404 for paths outside the routes of a listener or inside its hidden routes
+func (r *Router) routesMiddleware(next http.Handler) http.Handler {
+	if len(r.routes) == 0 && len(r.hiddenRoutes) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if matchesRoute(req.URL.Path, r.hiddenRoutes) || (len(r.routes) > 0 && !matchesRoute(req.URL.Path, r.routes)) {
+			http.NotFound(w, req)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// matchesRoute reports whether path is one of the route prefixes or below one; a {name} segment
+// of a route matches any path segment
+func matchesRoute(path string, routes []string) bool {
+	for _, route := range routes {
+		prefix := strings.TrimSuffix(route, "/")
+		if prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+		if strings.Contains(prefix, "{") && matchesPattern(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPattern reports whether path is the route pattern or below it
+func matchesPattern(path, pattern string) bool {
+	pathSegments := strings.Split(path, "/")
+	patternSegments := strings.Split(pattern, "/")
+	if len(pathSegments) < len(patternSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// withBasePath prefixes the endpoint descriptions of the root response with the base path
+func withBasePath(basePath string, endpoints []string) []string {
+	if basePath == "" {
+		return endpoints
+	}
+	prefixed := make([]string, len(endpoints))
+	for i, endpoint := range endpoints {
+		prefixed[i] = basePath + endpoint
+	}
+	return prefixed
+}
+
 // chainMiddleware chains multiple middleware functions together
 func chainMiddleware(handler http.Handler, middlewares ...func(http.Handler) http.Handler) http.Handler {
 	for i := len(middlewares) - 1; i >= 0; i-- {
@@ -137,7 +687,7 @@ func (r *Router) corsMiddleware(next http.Handler) http.Handler {
 		// If origin is empty, it's a same-origin request (browsers don't send Origin header for same-origin)
 		// Allow it to proceed without CORS headers
 
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		// Handle OPTIONS requests for CORS preflight
@@ -0,0 +1,174 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// partitionSize is the time span of one archive partition (one UTC day)
+const partitionSize = 24 * time.Hour
+
+// Archiver periodically exports completed daily audit partitions as gzipped NDJSON
+type Archiver struct {
+	uploader       Uploader
+	prefix         string
+	interval       time.Duration
+	deleteArchived bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewArchiver creates a new Archiver instance
+func NewArchiver(cfg config.ArchiveConfig, uploader Uploader) *Archiver {
+	return &Archiver{
+		uploader:       uploader,
+		prefix:         cfg.S3.Prefix,
+		interval:       time.Duration(cfg.Interval) * time.Second,
+		deleteArchived: cfg.DeleteArchived,
+	}
+}
+
+// Start runs the archiver in the background until Stop is called
+func (a *Archiver) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.done = make(chan struct{})
+
+	go func() {
+		defer close(a.done)
+
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := a.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("Audit archive run failed", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the background archiver and waits for an in-flight run to finish
+func (a *Archiver) Stop() {
+	if a.cancel == nil {
+		return
+	}
+	a.cancel()
+	<-a.done
+}
+
+// RunOnce archives every completed partition that has not been archived yet
+// and returns the manifest entries created
+func (a *Archiver) RunOnce(ctx context.Context) ([]models.AuditArchive, error) {
+	// Only whole days that have already ended are archived
+	today := time.Now().UTC().Truncate(partitionSize)
+
+	from, archived, err := storage.GetLatestArchivedPartitionEnd()
+	if err != nil {
+		return nil, err
+	}
+	if !archived {
+		from = time.Time{}
+	}
+
+	var created []models.AuditArchive
+	for {
+		if err := ctx.Err(); err != nil {
+			return created, err
+		}
+
+		oldest, ok, err := storage.GetOldestAuditTimestamp(from)
+		if err != nil {
+			return created, err
+		}
+		if !ok {
+			return created, nil
+		}
+
+		start := oldest.UTC().Truncate(partitionSize)
+		if !start.Before(today) {
+			return created, nil
+		}
+
+		archive, err := a.archivePartition(ctx, start, start.Add(partitionSize))
+		if err != nil {
+			return created, err
+		}
+		created = append(created, archive)
+		from = archive.PartitionEnd
+	}
+}
+
+// archivePartition exports one partition, records its manifest, and optionally removes the live rows
+func (a *Archiver) archivePartition(ctx context.Context, start, end time.Time) (models.AuditArchive, error) {
+	logs, err := storage.GetAuditLogsInRange(start, end)
+	if err != nil {
+		return models.AuditArchive{}, err
+	}
+
+	body, err := encodeNDJSONGzip(logs)
+	if err != nil {
+		return models.AuditArchive{}, err
+	}
+
+	key := fmt.Sprintf("%s%s/audit-%s.ndjson.gz", a.prefix, start.Format("2006/01/02"), start.Format("2006-01-02"))
+	if err := a.uploader.Put(ctx, key, body, "application/x-ndjson"); err != nil {
+		return models.AuditArchive{}, err
+	}
+
+	archive := models.AuditArchive{
+		PartitionStart: start,
+		PartitionEnd:   end,
+		ObjectKey:      key,
+		RowCount:       int64(len(logs)),
+		SizeBytes:      int64(len(body)),
+		LiveDeleted:    a.deleteArchived,
+		CreatedAt:      time.Now(),
+	}
+
+	// Record the manifest before deleting so archived rows are never untracked
+	if err := storage.InsertAuditArchive(archive); err != nil {
+		return models.AuditArchive{}, err
+	}
+
+	if a.deleteArchived {
+		if _, err := storage.DeleteAuditLogsInRange(start, end); err != nil {
+			return models.AuditArchive{}, err
+		}
+	}
+
+	logger.Info("Archived audit partition", "partition_start", start, "object_key", key, "rows", len(logs), "bytes", len(body))
+	return archive, nil
+}
+
+// encodeNDJSONGzip encodes audit logs as gzip-compressed newline-delimited JSON
+func encodeNDJSONGzip(logs []models.AuditLog) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(gz)
+	for _, log := range logs {
+		if err := encoder.Encode(log); err != nil {
+			return nil, err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,69 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"triggermesh/internal/aws"
+	"triggermesh/internal/config"
+)
+
+// Uploader stores archive objects
+type Uploader interface {
+	// Put uploads an object under the given key
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+// S3Uploader uploads objects to an S3-compatible bucket using path-style addressing
+// Path-style URLs (endpoint/bucket/key) work with both AWS S3 and MinIO
+type S3Uploader struct {
+	endpoint string
+	region   string
+	bucket   string
+	creds    aws.Credentials
+	client   *http.Client
+}
+
+// NewS3Uploader creates a new S3Uploader instance
+func NewS3Uploader(cfg config.S3Config) *S3Uploader {
+	return &S3Uploader{
+		endpoint: strings.TrimSuffix(cfg.Endpoint, "/"),
+		region:   cfg.Region,
+		bucket:   cfg.Bucket,
+		creds: aws.Credentials{
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+		},
+		client: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// Put uploads an object to the bucket
+func (u *S3Uploader) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	objectURL := fmt.Sprintf("%s/%s/%s", u.endpoint, aws.URIEncode(u.bucket, true), aws.URIEncode(key, false))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	aws.SignRequest(req, body, u.creds, u.region, "s3", time.Now())
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 put %s failed: %s: %s", key, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	return nil
+}
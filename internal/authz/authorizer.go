@@ -0,0 +1,249 @@
+package authz
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/signature"
+	"triggermesh/internal/version"
+)
+
+// maxResponseSize bounds the policy response read into memory
+const maxResponseSize = 64 * 1024
+
+// maxCacheEntries bounds the decision cache; it is cleared when full
+const maxCacheEntries = 10000
+
+// Input describes a trigger request sent to the policy service
+type Input struct {
+	Client     string            `json:"client,omitempty"` // Name of the API client (api.clients[].name)
+	KeyID      string            `json:"key_id"`           // Fingerprint of the API key; the key itself is never sent
+	Tenant     string            `json:"tenant,omitempty"`
+	Scopes     []string          `json:"scopes,omitempty"`
+	Job        string            `json:"job"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	ChangeRef  string            `json:"change_ref,omitempty"`
+	Commit     string            `json:"commit,omitempty"`
+	Ref        string            `json:"ref,omitempty"`
+	Source     string            `json:"source"`    // What started the trigger (http, replay, ...)
+	SourceIP   string            `json:"source_ip"` // Client IP address
+}
+
+// Decision is the policy verdict for a trigger request
+type Decision struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// DeniedError is returned when the policy denies a trigger
+type DeniedError struct {
+	Reason string
+}
+
+// Error implements error
+func (e *DeniedError) Error() string {
+	if e.Reason == "" {
+		return "denied by authorization policy"
+	}
+	return "denied by authorization policy: " + e.Reason
+}
+
+// UnavailableError is returned when no decision could be obtained and the hook fails closed
+type UnavailableError struct {
+	Err error
+}
+
+// Error implements error
+func (e *UnavailableError) Error() string {
+	return "authorization policy unavailable: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// KeyID returns a stable, non-reversible identifier for an API key
+func KeyID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:6])
+}
+
+// cachedDecision is a decision remembered until expires
+type cachedDecision struct {
+	decision Decision
+	expires  time.Time
+}
+
+// Authorizer asks an OPA sidecar or policy webhook whether a trigger is allowed
+type Authorizer struct {
+	policyType string
+	url        string
+	secret     string
+	cacheTTL   time.Duration
+	failOpen   bool
+	client     *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedDecision
+}
+
+// NewAuthorizer creates an authorizer from the authz configuration
+func NewAuthorizer(cfg config.AuthzConfig) *Authorizer {
+	return &Authorizer{
+		policyType: cfg.Type,
+		url:        cfg.URL,
+		secret:     cfg.Secret,
+		cacheTTL:   time.Duration(cfg.CacheTTL) * time.Second,
+		failOpen:   cfg.FailOpen,
+		client:     &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+		cache:      make(map[string]cachedDecision),
+	}
+}
+
+// Authorize returns nil when the policy allows the trigger, a *DeniedError when it denies it,
+// or an *UnavailableError when the policy service fails and the hook is not configured to fail open
+func (a *Authorizer) Authorize(ctx context.Context, input Input) error {
+	key, err := cacheKey(input)
+	if err != nil {
+		return &UnavailableError{Err: err}
+	}
+
+	decision, ok := a.cached(key)
+	if !ok {
+		decision, err = a.query(ctx, input)
+		if err != nil {
+			if a.failOpen {
+				logger.Warn("Authorization policy unavailable, allowing trigger (fail_open)", "error", err, "job", input.Job)
+				return nil
+			}
+			return &UnavailableError{Err: err}
+		}
+		a.store(key, decision)
+	}
+
+	if !decision.Allow {
+		return &DeniedError{Reason: decision.Reason}
+	}
+	return nil
+}
+
+// cacheKey hashes the input so identical requests share a decision
+func cacheKey(input Input) (string, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal policy input: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// cached returns an unexpired cached decision
+func (a *Authorizer) cached(key string) (Decision, bool) {
+	if a.cacheTTL <= 0 {
+		return Decision{}, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry, ok := a.cache[key]
+	if !ok || time.Now().After(entry.expires) {
+		return Decision{}, false
+	}
+	return entry.decision, true
+}
+
+// store caches a decision for the cache TTL
+func (a *Authorizer) store(key string, decision Decision) {
+	if a.cacheTTL <= 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.cache) >= maxCacheEntries {
+		a.cache = make(map[string]cachedDecision)
+	}
+	a.cache[key] = cachedDecision{decision: decision, expires: time.Now().Add(a.cacheTTL)}
+}
+
+// query posts the input to the policy service and parses its decision
+// OPA receives {"input": ...} and answers {"result": {"allow": ..., "reason": ...}} or {"result": true|false};
+// webhooks receive the input itself and answer with the decision
+func (a *Authorizer) query(ctx context.Context, input Input) (Decision, error) {
+	var payload interface{} = input
+	if a.policyType == config.AuthzTypeOPA {
+		payload = map[string]interface{}{"input": input}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to marshal policy request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to create policy request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent())
+	signature.SetHeader(req, a.secret, body)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to send policy request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to read policy response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Decision{}, fmt.Errorf("policy service returned status %d", resp.StatusCode)
+	}
+
+	if a.policyType == config.AuthzTypeOPA {
+		return parseOPADecision(respBody)
+	}
+	var decision Decision
+	if err := json.Unmarshal(respBody, &decision); err != nil {
+		return Decision{}, fmt.Errorf("failed to decode policy response: %w", err)
+	}
+	return decision, nil
+}
+
+// parseOPADecision reads the result of an OPA data API query, which is either
+// a boolean rule or an object with allow and reason
+func parseOPADecision(body []byte) (Decision, error) {
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Decision{}, fmt.Errorf("failed to decode OPA response: %w", err)
+	}
+	if len(resp.Result) == 0 {
+		// OPA omits result when the queried rule is undefined
+		return Decision{}, errors.New("OPA policy returned no result (undefined rule)")
+	}
+
+	var allow bool
+	if err := json.Unmarshal(resp.Result, &allow); err == nil {
+		return Decision{Allow: allow}, nil
+	}
+	var decision Decision
+	if err := json.Unmarshal(resp.Result, &decision); err != nil {
+		return Decision{}, fmt.Errorf("failed to decode OPA result: %w", err)
+	}
+	return decision, nil
+}
@@ -0,0 +1,438 @@
+package aws
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// refreshWindow is how long before expiry temporary credentials are fetched again
+	refreshWindow = 5 * time.Minute
+	// maxCredentialsResponseSize bounds credential endpoint responses read into memory
+	maxCredentialsResponseSize = 64 * 1024
+	// containerCredentialsHost serves ECS task credentials for AWS_CONTAINER_CREDENTIALS_RELATIVE_URI
+	containerCredentialsHost = "http://169.254.170.2"
+	// defaultIMDSEndpoint is the EC2 instance metadata service
+	defaultIMDSEndpoint = "http://169.254.169.254"
+)
+
+// errNotConfigured is returned by providers whose credential source is not set up, so the chain moves on
+var errNotConfigured = errors.New("not configured")
+
+// Provider retrieves AWS credentials
+type Provider interface {
+	Retrieve(ctx context.Context) (Credentials, error)
+}
+
+// StaticProvider returns fixed credentials
+type StaticProvider Credentials
+
+// Retrieve implements Provider
+func (p StaticProvider) Retrieve(ctx context.Context) (Credentials, error) {
+	return Credentials(p), nil
+}
+
+// EnvProvider reads AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN
+type EnvProvider struct{}
+
+// Retrieve implements Provider
+func (EnvProvider) Retrieve(ctx context.Context) (Credentials, error) {
+	accessKeyID := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretAccessKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKeyID == "" || secretAccessKey == "" {
+		return Credentials{}, errNotConfigured
+	}
+	return Credentials{
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}, nil
+}
+
+// SharedFileProvider reads static keys of a profile from the shared credentials and config files
+// (~/.aws/credentials and ~/.aws/config, or AWS_SHARED_CREDENTIALS_FILE and AWS_CONFIG_FILE)
+type SharedFileProvider struct {
+	Profile string // default: AWS_PROFILE or "default"
+}
+
+// Retrieve implements Provider
+func (p SharedFileProvider) Retrieve(ctx context.Context) (Credentials, error) {
+	profile := p.Profile
+	if profile == "" {
+		profile = defaultProfile()
+	}
+	// The credentials file takes precedence over the config file
+	for _, section := range []map[string]string{
+		loadSharedFile(sharedCredentialsFile(), profile, false),
+		loadSharedFile(sharedConfigFile(), profile, true),
+	} {
+		if section["aws_access_key_id"] != "" && section["aws_secret_access_key"] != "" {
+			return Credentials{
+				AccessKeyID:     section["aws_access_key_id"],
+				SecretAccessKey: section["aws_secret_access_key"],
+				SessionToken:    section["aws_session_token"],
+			}, nil
+		}
+	}
+	return Credentials{}, errNotConfigured
+}
+
+// WebIdentityProvider exchanges the token in AWS_WEB_IDENTITY_TOKEN_FILE for credentials of
+// AWS_ROLE_ARN with STS, as set up by EKS service account roles
+type WebIdentityProvider struct {
+	Region string
+	Client *http.Client
+}
+
+// Retrieve implements Provider
+func (p WebIdentityProvider) Retrieve(ctx context.Context) (Credentials, error) {
+	tokenFile := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	roleARN := os.Getenv("AWS_ROLE_ARN")
+	if tokenFile == "" || roleARN == "" {
+		return Credentials{}, errNotConfigured
+	}
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to read web identity token: %w", err)
+	}
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = fmt.Sprintf("triggermesh-%d", time.Now().Unix())
+	}
+
+	endpoint := "https://sts.amazonaws.com/"
+	if p.Region != "" {
+		endpoint = "https://sts." + p.Region + "." + dnsSuffix(p.Region) + "/"
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Credentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, err := fetch(p.Client, req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to assume role with web identity: %w", err)
+	}
+	var resp struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(body, &resp); err != nil {
+		return Credentials{}, fmt.Errorf("failed to decode STS response: %w", err)
+	}
+	return Credentials{
+		AccessKeyID:     resp.Credentials.AccessKeyID,
+		SecretAccessKey: resp.Credentials.SecretAccessKey,
+		SessionToken:    resp.Credentials.SessionToken,
+		Expires:         resp.Credentials.Expiration,
+	}, nil
+}
+
+// ContainerProvider reads ECS task role credentials from AWS_CONTAINER_CREDENTIALS_RELATIVE_URI,
+// or from AWS_CONTAINER_CREDENTIALS_FULL_URI with AWS_CONTAINER_AUTHORIZATION_TOKEN(_FILE)
+type ContainerProvider struct {
+	Client *http.Client
+}
+
+// Retrieve implements Provider
+func (p ContainerProvider) Retrieve(ctx context.Context) (Credentials, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		endpoint = containerCredentialsHost + relative
+	}
+	if endpoint == "" {
+		return Credentials{}, errNotConfigured
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if tokenFile := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("failed to read container authorization token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	body, err := fetch(p.Client, req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to get container credentials: %w", err)
+	}
+	return decodeRoleCredentials(body)
+}
+
+// IMDSProvider reads the instance role credentials from the EC2 instance metadata service (IMDSv2)
+// AWS_EC2_METADATA_DISABLED=true turns it off
+type IMDSProvider struct {
+	Endpoint string // default: AWS_EC2_METADATA_SERVICE_ENDPOINT or http://169.254.169.254
+	Client   *http.Client
+}
+
+// Retrieve implements Provider
+func (p IMDSProvider) Retrieve(ctx context.Context) (Credentials, error) {
+	if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return Credentials{}, errNotConfigured
+	}
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT")
+	}
+	if endpoint == "" {
+		endpoint = defaultIMDSEndpoint
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	token, err := fetch(p.Client, req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to get instance metadata token: %w", err)
+	}
+
+	get := func(path string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", string(token))
+		return fetch(p.Client, req)
+	}
+	role, err := get("/latest/meta-data/iam/security-credentials/")
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to get instance role: %w", err)
+	}
+	roleName := strings.TrimSpace(strings.SplitN(string(role), "\n", 2)[0])
+	if roleName == "" {
+		return Credentials{}, errors.New("instance has no IAM role")
+	}
+	body, err := get("/latest/meta-data/iam/security-credentials/" + url.PathEscape(roleName))
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to get instance role credentials: %w", err)
+	}
+	return decodeRoleCredentials(body)
+}
+
+// decodeRoleCredentials decodes the credentials document served by ECS and EC2 metadata endpoints
+func decodeRoleCredentials(body []byte) (Credentials, error) {
+	var doc struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return Credentials{}, fmt.Errorf("failed to decode credentials: %w", err)
+	}
+	if doc.AccessKeyID == "" || doc.SecretAccessKey == "" {
+		return Credentials{}, errors.New("credentials response has no access key")
+	}
+	return Credentials{
+		AccessKeyID:     doc.AccessKeyID,
+		SecretAccessKey: doc.SecretAccessKey,
+		SessionToken:    doc.Token,
+		Expires:         doc.Expiration,
+	}, nil
+}
+
+// fetch sends a credentials request and returns the body of a successful response
+func fetch(client *http.Client, req *http.Request) ([]byte, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCredentialsResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return body, nil
+}
+
+// Chain tries providers in order and returns the first credentials found
+type Chain []Provider
+
+// Retrieve implements Provider
+func (c Chain) Retrieve(ctx context.Context) (Credentials, error) {
+	var errs []string
+	for _, p := range c {
+		creds, err := p.Retrieve(ctx)
+		if err == nil {
+			return creds, nil
+		}
+		if !errors.Is(err, errNotConfigured) {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) == 0 {
+		return Credentials{}, errors.New("no AWS credentials found")
+	}
+	return Credentials{}, fmt.Errorf("no AWS credentials found: %s", strings.Join(errs, "; "))
+}
+
+// CachedProvider reuses credentials until shortly before they expire
+type CachedProvider struct {
+	Provider Provider
+
+	mu    sync.Mutex
+	creds Credentials
+	valid bool
+}
+
+// Retrieve implements Provider
+func (c *CachedProvider) Retrieve(ctx context.Context) (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.valid && (c.creds.Expires.IsZero() || time.Until(c.creds.Expires) > refreshWindow) {
+		return c.creds, nil
+	}
+	creds, err := c.Provider.Retrieve(ctx)
+	if err != nil {
+		return Credentials{}, err
+	}
+	c.creds, c.valid = creds, true
+	return creds, nil
+}
+
+// NewDefaultProvider returns the standard AWS credential chain: environment variables, the
+// shared credentials and config files, web identity, container credentials, and EC2 instance
+// metadata; temporary credentials are cached until shortly before they expire
+// Profiles that assume roles or use SSO are not supported
+func NewDefaultProvider(profile, region string) *CachedProvider {
+	return &CachedProvider{Provider: Chain{
+		EnvProvider{},
+		SharedFileProvider{Profile: profile},
+		WebIdentityProvider{Region: region, Client: &http.Client{Timeout: 10 * time.Second}},
+		ContainerProvider{Client: &http.Client{Timeout: 5 * time.Second}},
+		IMDSProvider{Client: &http.Client{Timeout: 2 * time.Second}},
+	}}
+}
+
+// ResolveRegion returns the configured region, else AWS_REGION, AWS_DEFAULT_REGION, or the
+// region of the profile in the shared config file
+func ResolveRegion(region, profile string) string {
+	if region != "" {
+		return region
+	}
+	if region = os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	if region = os.Getenv("AWS_DEFAULT_REGION"); region != "" {
+		return region
+	}
+	if profile == "" {
+		profile = defaultProfile()
+	}
+	return loadSharedFile(sharedConfigFile(), profile, true)["region"]
+}
+
+// dnsSuffix returns the domain of AWS endpoints in the region's partition
+func dnsSuffix(region string) string {
+	if strings.HasPrefix(region, "cn-") {
+		return "amazonaws.com.cn"
+	}
+	return "amazonaws.com"
+}
+
+// Endpoint returns the regional endpoint of an AWS service, e.g. https://codebuild.us-east-1.amazonaws.com
+func Endpoint(service, region string) string {
+	return "https://" + service + "." + region + "." + dnsSuffix(region)
+}
+
+// defaultProfile returns AWS_PROFILE, or "default"
+func defaultProfile() string {
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		return profile
+	}
+	return "default"
+}
+
+// sharedCredentialsFile returns the path of the shared credentials file
+func sharedCredentialsFile() string {
+	if path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", "credentials")
+}
+
+// sharedConfigFile returns the path of the shared config file
+func sharedConfigFile() string {
+	if path := os.Getenv("AWS_CONFIG_FILE"); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".aws", "config")
+}
+
+// loadSharedFile returns the keys of a profile in an INI-style shared file; sections of the
+// config file are named "profile NAME" except for the default profile
+// A missing or unreadable file yields no keys
+func loadSharedFile(path, profile string, configFile bool) map[string]string {
+	section := profile
+	if configFile && profile != "default" {
+		section = "profile " + profile
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	keys := make(map[string]string)
+	inSection := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inSection = strings.Join(strings.Fields(line[1:len(line)-1]), " ") == section
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && inSection {
+			keys[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+		}
+	}
+	return keys
+}
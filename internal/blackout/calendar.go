@@ -0,0 +1,126 @@
+package blackout
+
+import (
+	"fmt"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/cron"
+	"triggermesh/internal/jobmatch"
+)
+
+// Window is an occurrence of a blackout window
+type Window struct {
+	Name          string    `json:"name"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	AllowOverride bool      `json:"allow_override"`
+}
+
+// ActiveError is returned when a trigger falls inside a blackout window
+type ActiveError struct {
+	Window Window
+}
+
+// Error implements error
+func (e *ActiveError) Error() string {
+	return fmt.Sprintf("blackout window %q is active until %s", e.Window.Name, e.Window.End.UTC().Format(time.RFC3339))
+}
+
+// window is a configured blackout window
+type window struct {
+	name          string
+	jobs          []string
+	allowOverride bool
+
+	// One-off windows
+	start, end time.Time
+
+	// Recurring windows
+	schedule *cron.Schedule
+	duration time.Duration
+	location *time.Location
+}
+
+// Calendar holds the blackout windows checked before each trigger
+type Calendar struct {
+	windows []window
+}
+
+// NewCalendar creates a calendar from the blackout configuration
+func NewCalendar(cfg config.BlackoutConfig) (*Calendar, error) {
+	c := &Calendar{}
+	for _, wc := range cfg.Windows {
+		w := window{name: wc.Name, jobs: wc.Jobs, allowOverride: wc.AllowOverride}
+		if wc.Schedule == "" {
+			var err error
+			if w.start, err = time.Parse(time.RFC3339, wc.Start); err != nil {
+				return nil, fmt.Errorf("invalid start of blackout window %q: %w", wc.Name, err)
+			}
+			if w.end, err = time.Parse(time.RFC3339, wc.End); err != nil {
+				return nil, fmt.Errorf("invalid end of blackout window %q: %w", wc.Name, err)
+			}
+		} else {
+			schedule, err := cron.Parse(wc.Schedule)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schedule of blackout window %q: %w", wc.Name, err)
+			}
+			location, err := time.LoadLocation(wc.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timezone of blackout window %q: %w", wc.Name, err)
+			}
+			w.schedule = schedule
+			w.duration = time.Duration(wc.Duration) * time.Second
+			w.location = location
+		}
+		c.windows = append(c.windows, w)
+	}
+	return c, nil
+}
+
+// Active returns the blackout windows covering the job at now
+func (c *Calendar) Active(job string, now time.Time) []Window {
+	var active []Window
+	for _, w := range c.windows {
+		if len(w.jobs) > 0 && !jobmatch.MatchAny(w.jobs, job) {
+			continue
+		}
+		if occurrence, ok := w.occurrence(now); ok {
+			active = append(active, occurrence)
+		}
+	}
+	return active
+}
+
+// Check returns an *ActiveError when the job is inside a blackout window at now
+// Callers that may override are let through windows configured with allow_override
+func (c *Calendar) Check(job string, canOverride bool, now time.Time) error {
+	for _, w := range c.Active(job, now) {
+		if !canOverride || !w.AllowOverride {
+			return &ActiveError{Window: w}
+		}
+	}
+	return nil
+}
+
+// occurrence returns the occurrence of the window that contains now, if any
+func (w window) occurrence(now time.Time) (Window, bool) {
+	if w.schedule == nil {
+		if now.Before(w.start) || !now.Before(w.end) {
+			return Window{}, false
+		}
+		return Window{Name: w.name, Start: w.start, End: w.end, AllowOverride: w.allowOverride}, true
+	}
+
+	// The latest opening within the window duration is the one still open
+	local := now.In(w.location)
+	start, ok := w.schedule.Prev(local, local.Add(-w.duration))
+	if !ok {
+		return Window{}, false
+	}
+	end := start.Add(w.duration)
+	if !now.Before(end) {
+		return Window{}, false
+	}
+	return Window{Name: w.name, Start: start, End: end, AllowOverride: w.allowOverride}, true
+}
@@ -0,0 +1,242 @@
+// Package canary probes the API from inside the service: an authenticated no-op request and a
+// trigger of the built-in canary engine, sent periodically through the full middleware chain with
+// the outcomes exported as metrics, so availability SLOs can be measured without external monitors
+package canary
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/metrics"
+	"triggermesh/internal/ulid"
+)
+
+// Job is the job triggered on the canary engine; the canary key may access no other job
+const Job = "canary"
+
+// Client is the API client name of the canary, as recorded in the audit log
+const Client = "canary"
+
+// Probes, as reported in the probe label
+const (
+	ProbeAPI     = "api"     // GET /api/v1/me
+	ProbeTrigger = "trigger" // POST /api/v1/trigger/canary
+)
+
+// Engine is the CI engine triggered by the canary; every build succeeds at once, so a failed
+// trigger probe is always caused by TriggerMesh itself
+type Engine struct{}
+
+// NewEngine creates the canary engine
+func NewEngine() *Engine {
+	return &Engine{}
+}
+
+// TriggerBuild implements engine.CIEngine without starting anything
+func (e *Engine) TriggerBuild(jobName string, params map[string]string) (*engine.BuildResult, error) {
+	return &engine.BuildResult{
+		Success: true,
+		BuildID: Job + "-" + ulid.New(),
+		Message: "Canary build triggered",
+	}, nil
+}
+
+// GetBuildStatus implements engine.CIEngine; every canary build has succeeded
+func (e *Engine) GetBuildStatus(buildID string) (*engine.BuildResult, error) {
+	return &engine.BuildResult{
+		Success: true,
+		BuildID: buildID,
+		Message: "Canary build finished",
+		Result:  engine.ResultSuccess,
+	}, nil
+}
+
+// NewKey generates the API key the canary authenticates with; it is only known in-process
+func NewKey() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "tmc_" + base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// probe is a request sent by the prober
+type probe struct {
+	name   string
+	method string
+	path   string
+	body   string
+}
+
+// Prober sends the canary probes to a handler and records their outcomes
+type Prober struct {
+	handler  http.Handler
+	apiKey   string
+	probes   []probe
+	interval time.Duration
+	timeout  time.Duration
+
+	total       *metrics.Counter
+	up          *metrics.Gauge
+	duration    *metrics.Gauge
+	lastSuccess *metrics.Gauge
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewProber creates a prober sending requests authenticated with apiKey to the handler, below the
+// base path, and registers its metrics
+// The handler must route POST {basePath}/api/v1/trigger/canary to the canary engine
+func NewProber(cfg config.CanaryConfig, handler http.Handler, basePath, apiKey string, registry *metrics.Registry) *Prober {
+	basePath = strings.TrimSuffix(basePath, "/")
+	return &Prober{
+		handler: handler,
+		apiKey:  apiKey,
+		probes: []probe{
+			{name: ProbeAPI, method: http.MethodGet, path: basePath + "/api/v1/me"},
+			{name: ProbeTrigger, method: http.MethodPost, path: basePath + "/api/v1/trigger/" + config.CanaryEngine, body: `{"job":"` + Job + `"}`},
+		},
+		interval: time.Duration(cfg.Interval) * time.Second,
+		timeout:  time.Duration(cfg.Timeout) * time.Second,
+		total: registry.NewCounter("triggermesh_canary_probes_total",
+			"Canary probes by outcome (success or failure)", "probe", "result"),
+		up: registry.NewGauge("triggermesh_canary_up",
+			"Whether the latest canary probe succeeded (1) or failed (0)", "probe"),
+		duration: registry.NewGauge("triggermesh_canary_probe_duration_seconds",
+			"Duration of the latest canary probe", "probe"),
+		lastSuccess: registry.NewGauge("triggermesh_canary_last_success_timestamp_seconds",
+			"Unix time of the latest successful canary probe", "probe"),
+	}
+}
+
+// Probe sends each probe once and records the outcomes; it returns whether all succeeded
+func (p *Prober) Probe() bool {
+	ok := true
+	for _, pr := range p.probes {
+		started := time.Now()
+		status, err := p.send(pr)
+		elapsed := time.Since(started)
+
+		p.duration.Set(elapsed.Seconds(), pr.name)
+		if err != nil || status < 200 || status > 299 {
+			ok = false
+			p.total.Inc(pr.name, "failure")
+			p.up.Set(0, pr.name)
+			logger.Warn("Canary probe failed", "probe", pr.name, "status", status, "error", err, "duration_ms", elapsed.Milliseconds())
+			continue
+		}
+		p.total.Inc(pr.name, "success")
+		p.up.Set(1, pr.name)
+		p.lastSuccess.Set(float64(started.Add(elapsed).Unix()), pr.name)
+		logger.Debug("Canary probe succeeded", "probe", pr.name, "status", status, "duration_ms", elapsed.Milliseconds())
+	}
+	return ok
+}
+
+// send serves one probe request and returns the response status
+// A probe still running after the timeout counts as failed; it finishes in the background
+func (p *Prober) send(pr probe) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, pr.method, pr.path, strings.NewReader(pr.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("User-Agent", "triggermesh-canary")
+	if pr.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rw := &statusRecorder{header: make(http.Header)}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.handler.ServeHTTP(rw, req)
+	}()
+	select {
+	case <-done:
+		return rw.Status(), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// Start probes right away and then every interval, in the background until Stop is called
+func (p *Prober) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.Probe()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the background probes and waits for the current round to finish
+func (p *Prober) Stop() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+}
+
+// statusRecorder is the response writer of probe requests; it keeps the status and discards the body
+type statusRecorder struct {
+	mu     sync.Mutex
+	header http.Header
+	status int
+}
+
+// Header implements http.ResponseWriter
+func (w *statusRecorder) Header() http.Header {
+	return w.header
+}
+
+// Write implements http.ResponseWriter
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return len(b), nil
+}
+
+// WriteHeader implements http.ResponseWriter; the first status written wins
+func (w *statusRecorder) WriteHeader(status int) {
+	w.mu.Lock()
+	if w.status == 0 {
+		w.status = status
+	}
+	w.mu.Unlock()
+}
+
+// Status returns the status written, 200 if the handler wrote nothing
+func (w *statusRecorder) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
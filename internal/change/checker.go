@@ -0,0 +1,163 @@
+package change
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/jobmatch"
+	"triggermesh/internal/signature"
+	"triggermesh/internal/version"
+)
+
+// maxLookupResponseSize bounds the lookup webhook response read into memory
+const maxLookupResponseSize = 64 * 1024
+
+// ErrRequired is returned when a job that requires a change ticket is triggered without one
+var ErrRequired = errors.New("change_ref is required for this job")
+
+// RejectedError is returned when a change_ref fails the pattern or is refused by the lookup webhook
+type RejectedError struct {
+	Ref    string
+	Reason string
+}
+
+// Error implements error
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("change_ref %q rejected: %s", e.Ref, e.Reason)
+}
+
+// LookupError is returned when the lookup webhook cannot be reached or answers with an error
+// Triggers are refused in that case (fail closed)
+type LookupError struct {
+	Err error
+}
+
+// Error implements error
+func (e *LookupError) Error() string {
+	return "change lookup failed: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *LookupError) Unwrap() error {
+	return e.Err
+}
+
+// LookupRequest is the body posted to the lookup webhook
+type LookupRequest struct {
+	ChangeRef string `json:"change_ref"`
+	Job       string `json:"job"`
+}
+
+// LookupResponse is the body expected from the lookup webhook
+type LookupResponse struct {
+	Valid  bool   `json:"valid"`
+	Reason string `json:"reason,omitempty"` // Why the change was refused, e.g. "change window closed"
+}
+
+// Checker enforces the change ticket policy on trigger requests
+type Checker struct {
+	pattern      *regexp.Regexp
+	requiredJobs []string
+	lookupURL    string
+	lookupSecret string
+	client       *http.Client
+}
+
+// NewChecker creates a checker from the change configuration
+func NewChecker(cfg config.ChangeConfig) (*Checker, error) {
+	c := &Checker{
+		requiredJobs: cfg.RequiredJobs,
+		lookupURL:    cfg.LookupURL,
+		lookupSecret: cfg.LookupSecret,
+		client:       &http.Client{Timeout: time.Duration(cfg.LookupTimeout) * time.Second},
+	}
+	if cfg.Pattern != "" {
+		pattern, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid change pattern: %w", err)
+		}
+		c.pattern = pattern
+	}
+	return c, nil
+}
+
+// Required reports whether triggering the job requires a change_ref
+func (c *Checker) Required(job string) bool {
+	return jobmatch.MatchAny(c.requiredJobs, job)
+}
+
+// Check validates the change_ref of a trigger for the job
+// Empty references are accepted for jobs that do not require one
+func (c *Checker) Check(ctx context.Context, job, ref string) error {
+	if ref == "" {
+		if c.Required(job) {
+			return ErrRequired
+		}
+		return nil
+	}
+
+	if c.pattern != nil && !c.pattern.MatchString(ref) {
+		return &RejectedError{Ref: ref, Reason: "does not match the configured pattern"}
+	}
+
+	if c.lookupURL == "" {
+		return nil
+	}
+	resp, err := c.lookup(ctx, LookupRequest{ChangeRef: ref, Job: job})
+	if err != nil {
+		return &LookupError{Err: err}
+	}
+	if !resp.Valid {
+		reason := resp.Reason
+		if reason == "" {
+			reason = "refused by the change lookup"
+		}
+		return &RejectedError{Ref: ref, Reason: reason}
+	}
+	return nil
+}
+
+// lookup asks the webhook whether the change is valid for the job
+func (c *Checker) lookup(ctx context.Context, payload LookupRequest) (*LookupResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lookup request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.lookupURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lookup request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent())
+	signature.SetHeader(req, c.lookupSecret, body)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send lookup request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxLookupResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lookup response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("lookup returned status %d", resp.StatusCode)
+	}
+
+	var result LookupResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode lookup response: %w", err)
+	}
+	return &result, nil
+}
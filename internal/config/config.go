@@ -1,20 +1,71 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/netip"
 	"net/url"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"triggermesh/internal/cron"
+	"triggermesh/internal/jsonpath"
 
 	yaml "gopkg.in/yaml.v3"
 )
 
+// headerNameRegex validates HTTP header names (RFC 7230 token characters)
+var headerNameRegex = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// labelParameterPrefixRegex validates jenkins.label_parameter_prefix so prefixed label keys remain valid parameter keys
+var labelParameterPrefixRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*[._-]?$`)
+
+// parameterKeyRegex validates the parameters written by transformers and the commit and ref parameters,
+// as trigger parameter keys are validated
+var parameterKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`)
+
 // Config represents the application configuration
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Jenkins  JenkinsConfig  `yaml:"jenkins"`
-	API      APIConfig      `yaml:"api"`
+	Server        ServerConfig        `yaml:"server"`
+	Database      DatabaseConfig      `yaml:"database"`
+	Jenkins       JenkinsConfig       `yaml:"jenkins"`
+	API           APIConfig           `yaml:"api"`
+	Archive       ArchiveConfig       `yaml:"archive"`
+	Audit         AuditConfig         `yaml:"audit"`
+	Stats         StatsConfig         `yaml:"stats"`
+	Alerts        AlertsConfig        `yaml:"alerts"`
+	Grafana       GrafanaConfig       `yaml:"grafana"`
+	Incidents     IncidentsConfig     `yaml:"incidents"`
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Reload        ReloadConfig        `yaml:"config"`
+	Change        ChangeConfig        `yaml:"change"`
+	Authz         AuthzConfig         `yaml:"authz"`
+	Scheduler     SchedulerConfig     `yaml:"scheduler"`
+	Wait          WaitConfig          `yaml:"wait"`
+	Reuse         ReuseConfig         `yaml:"reuse"`
+	Blackout      BlackoutConfig      `yaml:"blackout"`
+	Throttle      ThrottleConfig      `yaml:"throttle"`
+	Quotas        QuotasConfig        `yaml:"quotas"`
+	Transform     TransformConfig     `yaml:"transform"`
+	Outbound      OutboundConfig      `yaml:"outbound"`
+	Outbox        OutboxConfig        `yaml:"outbox"`
+	Clock         ClockConfig         `yaml:"clock"`
+	Concurrency   ConcurrencyConfig   `yaml:"concurrency"`
+	Monitoring    MonitoringConfig    `yaml:"monitoring"`
+	Branding      BrandingConfig      `yaml:"branding"`
+	Engines       []EngineConfig      `yaml:"engines"` // CI engines besides Jenkins
+
+	// Path is the file the configuration was loaded from (set by Load)
+	Path string `yaml:"-"`
 }
 
 // ServerConfig represents the server configuration
@@ -23,11 +74,93 @@ type ServerConfig struct {
 	Host           string   `yaml:"host"`
 	AllowedOrigins []string `yaml:"allowed_origins"` // Empty slice means allow all origins (default, for backward compatibility)
 	MaxBodySize    int64    `yaml:"max_body_size"`   // Maximum request body size in bytes (default: 1MB)
+	// ReadinessGating binds the listener before storage migrations and engine connectivity
+	// checks run, reporting not ready on /readyz until they complete
+	ReadinessGating bool `yaml:"readiness_gating"`
+	// EngineCheck is when engine connectivity is checked: lazy (default) on first use, or
+	// fail_fast at startup, failing startup when an engine cannot be reached
+	EngineCheck string `yaml:"engine_check"`
+	// BasePath serves all routes under a path prefix (e.g. /triggermesh) behind shared ingress
+	// controllers; returned links include it. Empty serves the API at the root
+	BasePath string `yaml:"base_path"`
+	// Listen lists where the API is served: "tcp" (host:port), "tcp:<address>", "unix:<path>", "systemd"
+	// (all sockets passed by systemd socket activation), or "systemd:<name>" (FileDescriptorName=). Default: tcp
+	Listen []string `yaml:"listen"`
+	// SocketMode is the file mode of Unix sockets, in octal (default: 0660)
+	SocketMode string `yaml:"socket_mode"`
+	// Listeners are additional listeners with their own routes and middleware settings, e.g. a
+	// localhost port serving only health checks next to the public API
+	Listeners []ListenerConfig `yaml:"listeners"`
+	// Management moves the management endpoints to their own listener
+	Management ManagementConfig `yaml:"management"`
+	// ResponseEnvelope wraps successful JSON responses in {data, request_id, meta}
+	ResponseEnvelope bool `yaml:"response_envelope"`
+	// Deprecations mark routes deprecated ahead of their removal, e.g. once v2 routes replace v1
+	Deprecations []DeprecationConfig `yaml:"deprecations"`
+	// BuildURLs is how responses present the engine's build and job URLs to keys without the
+	// internal_urls scope: keep (default), omit, or rewrite to the TriggerMesh status URL
+	BuildURLs string `yaml:"build_urls"`
+}
+
+// Modes of server.build_urls
+const (
+	BuildURLsKeep    = "keep"
+	BuildURLsOmit    = "omit"
+	BuildURLsRewrite = "rewrite"
+)
+
+// Modes of server.engine_check
+const (
+	EngineCheckLazy     = "lazy"
+	EngineCheckFailFast = "fail_fast"
+)
+
+// DeprecationConfig marks a route deprecated: its responses carry the Deprecation, Sunset, and Link
+// headers and, for JSON objects, a warning field. The route keeps working after the sunset date
+type DeprecationConfig struct {
+	Route   string    `yaml:"route"`            // Path prefix, e.g. /api/v1/jenkins (required)
+	Since   time.Time `yaml:"since,omitempty"`  // When the route was deprecated (RFC 3339); zero sends Deprecation: true
+	Sunset  time.Time `yaml:"sunset,omitempty"` // When the route may be removed (RFC 3339); zero sends no Sunset header
+	Link    string    `yaml:"link"`             // Migration guide, sent as Link with rel="deprecation"
+	Message string    `yaml:"message"`          // Warning of JSON responses (default: derived from the route and sunset)
+}
+
+// ManagementConfig is the listener of the management endpoints (/api/v1/admin, /debug/pprof)
+// Once listen is set, the public listeners no longer serve them
+type ManagementConfig struct {
+	Listen []string `yaml:"listen"` // Addresses as in server.listen, e.g. tcp:127.0.0.1:9091; empty keeps them on the public listeners
+	Pprof  bool     `yaml:"pprof"`  // Serve the Go profiler at /debug/pprof (requires listen)
+}
+
+// Enabled reports whether the management endpoints have their own listener
+func (m ManagementConfig) Enabled() bool {
+	return len(m.Listen) > 0
+}
+
+// ListenerConfig is an additional listener; unset settings fall back to those of the server
+type ListenerConfig struct {
+	Name           string   `yaml:"name"`            // Shown in logs and errors
+	Listen         []string `yaml:"listen"`          // Addresses as in server.listen (required)
+	Routes         []string `yaml:"routes"`          // Path prefixes served, e.g. [/health, /readyz]; empty serves all routes
+	AllowedOrigins []string `yaml:"allowed_origins"` // CORS origins (default: server.allowed_origins)
+	MaxBodySize    int64    `yaml:"max_body_size"`   // Maximum request body size in bytes (default: server.max_body_size)
+	BasePath       string   `yaml:"base_path"`       // Path prefix of all routes (default: server.base_path)
 }
 
+// Listen address schemes of server.listen
+const (
+	ListenTCP     = "tcp"
+	ListenUnix    = "unix"
+	ListenSystemd = "systemd"
+)
+
 // DatabaseConfig represents the database configuration
 type DatabaseConfig struct {
 	Path string `yaml:"path"`
+	// BackupDir is where POST /api/v1/admin/backup writes backups (empty disables the endpoint)
+	BackupDir string `yaml:"backup_dir"`
+	// BackupS3 additionally uploads each backup to an S3-compatible bucket when bucket is set
+	BackupS3 S3Config `yaml:"backup_s3"`
 }
 
 // JenkinsConfig represents the Jenkins configuration
@@ -36,28 +169,654 @@ type JenkinsConfig struct {
 	Username string `yaml:"username"` // Jenkins username (optional, defaults to token if not provided)
 	Token    string `yaml:"token"`
 	Timeout  int    `yaml:"timeout"` // Request timeout in seconds (default: 30)
+	// PublicURL replaces url in the build and job links returned to users, for a Jenkins reached
+	// internally at another address than from outside the cluster (optional)
+	PublicURL string `yaml:"public_url"`
+	// Headers are extra static headers sent on every Jenkins request (e.g. for reverse proxies)
+	Headers map[string]string `yaml:"headers"`
+	// LabelParameterPrefix passes trigger labels to Jenkins as parameters named prefix+key (empty disables)
+	LabelParameterPrefix string `yaml:"label_parameter_prefix"`
+	// BuildToken is the "Trigger builds remotely" token of the triggered jobs; with it, Jenkins shows
+	// the build cause sent by TriggerMesh (optional)
+	BuildToken string `yaml:"build_token"`
+	// NotificationToken enables POST /api/v1/notifications/jenkins?token=..., where the Notification
+	// plugin reports finished builds instead of waiting for the next status poll (requires stats.enabled)
+	NotificationToken string `yaml:"notification_token"`
+	// CommitParameter and RefParameter name the parameters the commit and ref of a trigger are
+	// passed to Jenkins as, e.g. GIT_COMMIT and GIT_REF; empty or NoParameter only records them,
+	// since passing parameters makes Jenkins refuse triggers of jobs without parameters
+	CommitParameter string `yaml:"commit_parameter"`
+	RefParameter    string `yaml:"ref_parameter"`
+	// Fake replaces Jenkins with the built-in fake engine, so the API can be exercised in staging
+	// and load tests without a Jenkins server; url and token are then optional
+	Fake *FakeEngineConfig `yaml:"fake"`
+	// Retry and Breaker protect triggers and build status requests from Jenkins failures
+	Retry   RetryConfig   `yaml:"retry"`
+	Breaker BreakerConfig `yaml:"breaker"`
+}
+
+// RetryConfig represents retries of failed engine calls
+// Build status requests are retried after server errors and timeouts; triggers only after server
+// errors, since a trigger that timed out may have started a build
+type RetryConfig struct {
+	Attempts int `yaml:"attempts"` // Calls made per request, the first included (default: 1, no retries)
+	Backoff  int `yaml:"backoff"`  // Milliseconds before the first retry, doubled for each further retry (default: 500)
+}
+
+// BreakerConfig represents the circuit breaker of an engine: after failure_threshold consecutive
+// failed calls, calls fail at once for open_duration seconds, then a trial call decides whether
+// the engine is called again
+type BreakerConfig struct {
+	FailureThreshold int `yaml:"failure_threshold"` // Consecutive failed calls that open the breaker (0 disables it)
+	OpenDuration     int `yaml:"open_duration"`     // Seconds the breaker stays open before a trial call (default: 30)
+}
+
+// EngineConfig represents an additional CI engine, triggered at /api/v1/trigger/{name}
+type EngineConfig struct {
+	Name      string                `yaml:"name"`      // Name used in API paths and audit logs
+	Type      string                `yaml:"type"`      // http, codebuild, codepipeline, spinnaker, awx, or fake
+	Timeout   int                   `yaml:"timeout"`   // Request timeout in seconds of every type, unless the type's own timeout is set (default: 30)
+	Retry     RetryConfig           `yaml:"retry"`     // Retries of failed triggers and build status requests
+	Breaker   BreakerConfig         `yaml:"breaker"`   // Circuit breaker of the engine
+	HTTP      HTTPEngineConfig      `yaml:"http"`      // Settings of http engines
+	AWS       AWSEngineConfig       `yaml:"aws"`       // Settings of codebuild and codepipeline engines
+	Spinnaker SpinnakerEngineConfig `yaml:"spinnaker"` // Settings of spinnaker engines
+	AWX       AWXEngineConfig       `yaml:"awx"`       // Settings of awx engines
+	Fake      FakeEngineConfig      `yaml:"fake"`      // Settings of fake engines
+	Fields    []EngineFieldConfig   `yaml:"fields"`    // Fields accepted in trigger requests besides the Jenkins-shaped body
+}
+
+// EngineFieldConfig declares a field of trigger requests for an engine, accepted in the fields object
+// of the request body, validated, and passed to the engine as a parameter
+type EngineFieldConfig struct {
+	Name        string   `yaml:"name"`        // Key in the fields object (lowercase letters, digits, and underscores)
+	Type        string   `yaml:"type"`        // string, integer, or boolean (default: string)
+	Description string   `yaml:"description"` // Shown in the generated OpenAPI schema
+	Required    bool     `yaml:"required"`    // Reject triggers without the field
+	Enum        []string `yaml:"enum"`        // Values allowed for string fields
+	Pattern     string   `yaml:"pattern"`     // Regular expression string values must match
+	Parameter   string   `yaml:"parameter"`   // Parameter the value is passed as (default: the name)
+}
+
+// Engine types
+const (
+	EngineTypeHTTP         = "http"
+	EngineTypeCodeBuild    = "codebuild"    // Jobs are CodeBuild projects
+	EngineTypeCodePipeline = "codepipeline" // Jobs are CodePipeline pipelines
+	EngineTypeSpinnaker    = "spinnaker"    // Jobs are application/pipeline names
+	EngineTypeAWX          = "awx"          // Jobs are AWX / Ansible Tower job template names or IDs
+	EngineTypeFake         = "fake"         // Simulated builds of any job name, for staging and load tests
+)
+
+// awsRegionRegex validates AWS region names, e.g. us-east-1 or us-gov-west-1
+var awsRegionRegex = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// basePathRegex validates server.base_path: one or more path segments without a trailing slash
+var basePathRegex = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// engineNameRegex validates engine names, which appear in API paths
+var engineNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// engineFieldNameRegex validates the names of engine request fields
+var engineFieldNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// reservedEngineNames cannot be used by configured engines because they are taken by routes
+var reservedEngineNames = map[string]bool{"jenkins": true, "scheduled": true}
+
+// CanaryEngine is the name of the built-in engine triggered by the canary, when it is enabled
+const CanaryEngine = "canary"
+
+// HTTPEngineConfig describes a REST CI system declaratively
+// URLs and bodies are Go templates over .Job and .Params (and .BuildID for status requests);
+// the json function renders a value as JSON and urlquery escapes it for URLs
+type HTTPEngineConfig struct {
+	Timeout    int               `yaml:"timeout"`     // Request timeout in seconds (default: the engine timeout)
+	AuthHeader string            `yaml:"auth_header"` // Header carrying token (default: Authorization)
+	Token      string            `yaml:"token"`       // Credential sent in auth_header, e.g. "Bearer abc123" (optional)
+	Headers    map[string]string `yaml:"headers"`     // Extra static headers
+	Trigger    HTTPTriggerConfig `yaml:"trigger"`
+	Status     HTTPStatusConfig  `yaml:"status"`
+}
+
+// AWSEngineConfig configures the AWS CodeBuild and CodePipeline engines
+// Without access_key_id, credentials come from the standard AWS chain: environment variables,
+// the shared credentials and config files, web identity tokens, ECS task roles, and EC2 instance roles
+type AWSEngineConfig struct {
+	Region          string `yaml:"region"`            // default: AWS_REGION, AWS_DEFAULT_REGION, or the profile's region
+	Profile         string `yaml:"profile"`           // Shared config profile (default: AWS_PROFILE or default)
+	AccessKeyID     string `yaml:"access_key_id"`     // Static credentials (optional)
+	SecretAccessKey string `yaml:"secret_access_key"` // Required with access_key_id
+	SessionToken    string `yaml:"session_token"`     // For temporary static credentials (optional)
+	Endpoint        string `yaml:"endpoint"`          // API endpoint override, e.g. a VPC endpoint (optional)
+	Timeout         int    `yaml:"timeout"`           // Request timeout in seconds (default: the engine timeout)
+}
+
+// SpinnakerEngineConfig configures a Spinnaker engine, which starts pipeline executions through Gate
+type SpinnakerEngineConfig struct {
+	URL      string            `yaml:"url"`      // Gate API URL, e.g. https://gate.spinnaker.example.com
+	UIURL    string            `yaml:"ui_url"`   // Deck URL for execution links (optional)
+	Token    string            `yaml:"token"`    // Bearer token (optional)
+	Username string            `yaml:"username"` // Basic auth user, used when token is empty (optional)
+	Password string            `yaml:"password"`
+	Headers  map[string]string `yaml:"headers"` // Extra static headers, e.g. for an authenticating proxy
+	Timeout  int               `yaml:"timeout"` // Request timeout in seconds (default: the engine timeout)
+}
+
+// AWXEngineConfig configures an AWX or Ansible Tower engine, which launches job templates
+type AWXEngineConfig struct {
+	URL      string            `yaml:"url"`      // AWX URL, e.g. https://awx.example.com
+	Token    string            `yaml:"token"`    // OAuth2 or personal access token (optional)
+	Username string            `yaml:"username"` // Basic auth user, used when token is empty (optional)
+	Password string            `yaml:"password"`
+	Headers  map[string]string `yaml:"headers"` // Extra static headers
+	Timeout  int               `yaml:"timeout"` // Request timeout in seconds (default: the engine timeout)
+}
+
+// FakeEngineConfig configures the fake engine, which simulates builds without a backend
+type FakeEngineConfig struct {
+	Latency          int     `yaml:"latency"`            // Milliseconds each engine call takes
+	Jitter           int     `yaml:"jitter"`             // Up to this many milliseconds added to the latency at random
+	FailureRate      float64 `yaml:"failure_rate"`       // Fraction [0-1] of triggers failing with a server error
+	BuildDuration    int     `yaml:"build_duration"`     // Seconds a build runs before it reports a result
+	BuildFailureRate float64 `yaml:"build_failure_rate"` // Fraction [0-1] of builds finishing with FAILURE
+	Seed             int64   `yaml:"seed"`               // Seed of the random source; equal seeds give equal runs
+}
+
+// HTTPTriggerConfig describes the request that starts a build
+type HTTPTriggerConfig struct {
+	Method       string `yaml:"method"`         // default: POST
+	URL          string `yaml:"url"`            // URL template
+	Body         string `yaml:"body"`           // JSON body template (optional)
+	BuildIDPath  string `yaml:"build_id_path"`  // JSONPath of the build ID in the response, e.g. $.id
+	BuildURLPath string `yaml:"build_url_path"` // JSONPath of the build's web URL (optional)
+}
+
+// HTTPStatusConfig describes the request that reports a build's status (optional)
+type HTTPStatusConfig struct {
+	Method    string            `yaml:"method"`     // default: GET
+	URL       string            `yaml:"url"`        // URL template; empty disables build status
+	StatePath string            `yaml:"state_path"` // JSONPath of the build state, e.g. $.state
+	URLPath   string            `yaml:"url_path"`   // JSONPath of the build's web URL (optional)
+	Results   map[string]string `yaml:"results"`    // Final states mapped to SUCCESS, FAILURE, UNSTABLE, or ABORTED; other states mean running
+}
+
+// ArchiveConfig represents the audit archive configuration
+type ArchiveConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	Interval       int      `yaml:"interval"`        // Seconds between archive runs (default: 3600)
+	DeleteArchived bool     `yaml:"delete_archived"` // Delete audit rows from the live table once archived
+	S3             S3Config `yaml:"s3"`
+}
+
+// SchedulerConfig represents the scheduler that fires triggers held until their not_before time
+// and the recurring schedules
+type SchedulerConfig struct {
+	PollInterval int              `yaml:"poll_interval"` // Seconds between checks for due triggers (default: 5)
+	MaxDelay     int              `yaml:"max_delay"`     // Furthest not_before accepted, in seconds from now (default: 604800, one week)
+	Schedules    []ScheduleConfig `yaml:"schedules"`     // Jenkins jobs triggered on cron schedules
+}
+
+// Misfire policies of recurring schedules, for fire times missed while the service was down
+const (
+	MisfireSkip    = "skip"     // Drop missed fire times; only a fire time within misfire_grace still runs
+	MisfireRunOnce = "run_once" // Run once for all missed fire times
+	MisfireCatchUp = "catch_up" // Run once per missed fire time, oldest first, at most max_catch_up times
+)
+
+// ScheduleConfig represents a Jenkins job triggered on a cron schedule
+// The last fire time is stored under the name, so each fire time runs once across restarts
+// and instances sharing the database
+type ScheduleConfig struct {
+	Name         string            `yaml:"name"`          // Unique name, recorded as the API key of its triggers
+	Job          string            `yaml:"job"`           // Jenkins job to trigger
+	Parameters   map[string]string `yaml:"parameters"`    // Build parameters
+	Schedule     string            `yaml:"schedule"`      // Cron expression (minute hour day month weekday)
+	Timezone     string            `yaml:"timezone"`      // IANA time zone of the schedule (default: UTC)
+	Misfire      string            `yaml:"misfire"`       // skip, run_once, or catch_up (default: run_once)
+	MisfireGrace int               `yaml:"misfire_grace"` // Seconds after a fire time it still runs under skip (default: 60)
+	MaxCatchUp   int               `yaml:"max_catch_up"`  // Most missed fire times run under catch_up (default: 10)
+}
+
+// WaitConfig represents trigger requests that wait for the build to finish (wait: true)
+type WaitConfig struct {
+	MaxWait      int `yaml:"max_wait"`      // Longest a trigger request may wait for its build, in seconds (default: 300)
+	PollInterval int `yaml:"poll_interval"` // Seconds between build status checks while waiting (default: 5)
+}
+
+// ReuseConfig represents result reuse: a trigger identical to a recent one whose build succeeded
+// returns that build instead of starting a new one
+type ReuseConfig struct {
+	Rules []ReuseRuleConfig `yaml:"rules"`
+}
+
+// ReuseRuleConfig enables result reuse for matching jobs; the first matching rule applies
+type ReuseRuleConfig struct {
+	Jobs   []string `yaml:"jobs"`   // Job name patterns covered ("*" wildcard); empty means all jobs
+	Window int      `yaml:"window"` // Seconds after a trigger during which its successful build is reused
+}
+
+// StatsConfig represents the per-job build statistics configuration
+type StatsConfig struct {
+	Enabled         bool `yaml:"enabled"`
+	PollInterval    int  `yaml:"poll_interval"`    // Seconds between build status polls (default: 30)
+	MaxTrackAge     int  `yaml:"max_track_age"`    // Seconds after which an unfinished build is no longer polled (default: 86400)
+	SummaryInterval int  `yaml:"summary_interval"` // Seconds between refreshes of the daily per-job trigger summary (default: 300)
+}
+
+// ConcurrencyConfig bounds the parallel work of batch triggers, notification fan-out, and pollers
+type ConcurrencyConfig struct {
+	BulkReplay    int `yaml:"bulk_replay"`   // Maximum parallel triggers a bulk replay may ask for (default: 16)
+	Notifications int `yaml:"notifications"` // Alert notifications delivered at once (default: 4)
+	StatusPolls   int `yaml:"status_polls"`  // Build status checks run at once by the stats poller (default: 4)
+	TaskTimeout   int `yaml:"task_timeout"`  // Seconds each bulk replay trigger or alert notification may take (default: 30)
+}
+
+// MonitoringConfig represents access for external uptime monitors
+type MonitoringConfig struct {
+	// Token authenticates GET /healthz?token=... for monitors that cannot send headers; it is not an
+	// API key and grants nothing else. Empty disables /healthz
+	Token string `yaml:"token"`
+	// Canary periodically probes the API from inside the service and exports the outcomes at /metrics
+	Canary CanaryConfig `yaml:"canary"`
+}
+
+// CanaryConfig represents the synthetic canary: an authenticated no-op request and a trigger of the
+// built-in canary engine, made through the full middleware chain so availability SLOs can be measured
+type CanaryConfig struct {
+	Enabled  bool `yaml:"enabled"`  // Run the canary (default: false)
+	Interval int  `yaml:"interval"` // Seconds between probe rounds (default: 60)
+	Timeout  int  `yaml:"timeout"`  // Seconds a probe may take before it counts as failed (default: 10)
+}
+
+// BrandingConfig represents how the instance presents itself in the root response and GET /api/v1/me,
+// so platform teams can white-label it; tenants of API keys may override each setting
+type BrandingConfig struct {
+	Brand   `yaml:",inline"`
+	Tenants map[string]Brand `yaml:"tenants"` // Overrides by API key tenant; empty settings keep the defaults
+}
+
+// Brand is the presentation of the instance
+type Brand struct {
+	ServiceName string `yaml:"service_name"` // Name shown instead of TriggerMesh
+	Contact     string `yaml:"contact"`      // Who to ask for help, e.g. an email address or chat channel
+	DocsURL     string `yaml:"docs_url"`     // Documentation of the instance
+	Message     string `yaml:"message"`      // Notice shown to clients, e.g. a maintenance announcement
+}
+
+// IsZero reports whether no setting of the brand is set
+func (b Brand) IsZero() bool {
+	return b == Brand{}
+}
+
+// ForTenant returns the brand of a tenant: the tenant's settings over the defaults
+func (c BrandingConfig) ForTenant(tenant string) Brand {
+	brand := c.Brand
+	override, ok := c.Tenants[tenant]
+	if tenant == "" || !ok {
+		return brand
+	}
+	if override.ServiceName != "" {
+		brand.ServiceName = override.ServiceName
+	}
+	if override.Contact != "" {
+		brand.Contact = override.Contact
+	}
+	if override.DocsURL != "" {
+		brand.DocsURL = override.DocsURL
+	}
+	if override.Message != "" {
+		brand.Message = override.Message
+	}
+	return brand
+}
+
+// AlertsConfig represents the trigger failure-rate alerting configuration
+type AlertsConfig struct {
+	Enabled     bool                  `yaml:"enabled"`
+	Window      int                   `yaml:"window"`       // Seconds of trigger history considered (default: 300)
+	MinTriggers int                   `yaml:"min_triggers"` // Minimum triggers in the window before alerting (default: 5)
+	FailureRate float64               `yaml:"failure_rate"` // Failure rate (0-1] that fires an alert (default: 0.5)
+	Cooldown    int                   `yaml:"cooldown"`     // Seconds before the same job or engine can alert again (default: 900)
+	Notifiers   []AlertNotifierConfig `yaml:"notifiers"`
+}
+
+// AlertNotifierConfig represents a destination for alert notifications
+type AlertNotifierConfig struct {
+	Type   string `yaml:"type"`   // slack or webhook
+	URL    string `yaml:"url"`    // Slack incoming webhook URL or generic webhook URL
+	Secret string `yaml:"secret"` // HMAC key signing each delivery in X-TriggerMesh-Signature (optional)
+}
+
+// Incident providers
+const (
+	IncidentProviderPagerDuty = "pagerduty"
+	IncidentProviderOpsgenie  = "opsgenie"
+)
+
+// Incident severities, from most to least severe
+const (
+	SeverityCritical = "critical"
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// IncidentsConfig represents incidents opened in PagerDuty or Opsgenie when critical jobs fail repeatedly
+// Incidents are resolved by the next successful trigger; an empty provider disables them
+type IncidentsConfig struct {
+	Provider         string              `yaml:"provider"`          // pagerduty or opsgenie
+	RoutingKey       string              `yaml:"routing_key"`       // PagerDuty Events API v2 integration key
+	APIKey           string              `yaml:"api_key"`           // Opsgenie API integration key
+	URL              string              `yaml:"url"`               // API base URL (default: the provider's public API, e.g. https://api.eu.opsgenie.com for EU accounts)
+	FailureThreshold int                 `yaml:"failure_threshold"` // Consecutive failed triggers that open an incident (default: 3)
+	Jobs             []IncidentJobConfig `yaml:"jobs"`              // Critical jobs; the first matching entry applies
+}
+
+// IncidentJobConfig maps critical jobs to an incident severity
+type IncidentJobConfig struct {
+	Pattern  string `yaml:"pattern"`  // Job name pattern ("*" wildcard)
+	Severity string `yaml:"severity"` // critical, error, warning, or info (default: critical)
+}
+
+// Email notification events
+const (
+	EmailEventBuildCompleted = "build_completed"
+	EmailEventKeyRequested   = "key_requested"
+)
+
+// Email transport security modes
+const (
+	EmailTLSStartTLS = "starttls"
+	EmailTLSImplicit = "tls"
+	EmailTLSNone     = "none"
+)
+
+// NotificationsConfig represents notification sinks for build and workflow events
+type NotificationsConfig struct {
+	Email EmailConfig `yaml:"email"`
+}
+
+// EmailConfig represents email notifications sent over SMTP
+// Email is enabled when host is set
+type EmailConfig struct {
+	Host         string   `yaml:"host"`          // SMTP server
+	Port         int      `yaml:"port"`          // SMTP port (default: 587, or 465 with tls: tls)
+	Username     string   `yaml:"username"`      // SMTP AUTH PLAIN user (optional)
+	Password     string   `yaml:"password"`      // SMTP AUTH PLAIN password
+	TLS          string   `yaml:"tls"`           // starttls, tls (implicit TLS), or none (default: starttls)
+	From         string   `yaml:"from"`          // Sender address, e.g. "TriggerMesh <triggermesh@example.com>"
+	To           []string `yaml:"to"`            // Recipient addresses
+	Events       []string `yaml:"events"`        // build_completed and/or key_requested (default: both)
+	Jobs         []string `yaml:"jobs"`          // Job name patterns ("*" wildcard) of build_completed emails; empty means all jobs
+	TemplatesDir string   `yaml:"templates_dir"` // Directory of templates replacing the built-in ones (optional)
+	Timeout      int      `yaml:"timeout"`       // Seconds to wait for the SMTP server (default: 10)
+}
+
+// GrafanaConfig represents deploy markers posted as Grafana annotations when jobs are triggered or complete
+// The integration is active when url is set
+type GrafanaConfig struct {
+	URL          string   `yaml:"url"`           // Grafana base URL, e.g. https://grafana.example.com
+	Token        string   `yaml:"token"`         // Service account token with the annotations:write permission
+	Jobs         []string `yaml:"jobs"`          // Job name patterns ("*" wildcard) annotated; empty annotates all jobs
+	DashboardUID string   `yaml:"dashboard_uid"` // Dashboard the annotations belong to (optional; default: organization-wide)
+	Tags         []string `yaml:"tags"`          // Extra tags added to every annotation
+	Timeout      int      `yaml:"timeout"`       // Seconds to wait for Grafana (default: 5)
+}
+
+// ChangeConfig represents correlation of triggers with change tickets (Jira, ServiceNow)
+// The policy is active when any field is set
+type ChangeConfig struct {
+	Pattern       string   `yaml:"pattern"`        // Regular expression every change_ref must match (optional)
+	RequiredJobs  []string `yaml:"required_jobs"`  // Production-impacting job patterns that cannot be triggered without a change_ref
+	LookupURL     string   `yaml:"lookup_url"`     // Webhook that confirms a change_ref is valid for the job (optional)
+	LookupTimeout int      `yaml:"lookup_timeout"` // Seconds to wait for the lookup webhook (default: 5)
+	LookupSecret  string   `yaml:"lookup_secret"`  // HMAC key signing lookup requests in X-TriggerMesh-Signature (optional)
+}
+
+// Enabled reports whether a change policy is configured
+func (c ChangeConfig) Enabled() bool {
+	return c.Pattern != "" || len(c.RequiredJobs) > 0 || c.LookupURL != ""
+}
+
+// BlackoutConfig represents change freeze windows during which triggers are refused
+type BlackoutConfig struct {
+	Windows []BlackoutWindowConfig `yaml:"windows"`
+}
+
+// BlackoutWindowConfig represents a one-off window (start and end) or a recurring window
+// (a cron schedule opening it for duration seconds)
+type BlackoutWindowConfig struct {
+	Name          string   `yaml:"name"`
+	Jobs          []string `yaml:"jobs"`           // Job name patterns covered ("*" wildcard); empty means all jobs
+	Start         string   `yaml:"start"`          // RFC 3339 start of a one-off window
+	End           string   `yaml:"end"`            // RFC 3339 end of a one-off window
+	Schedule      string   `yaml:"schedule"`       // Cron expression opening a recurring window (minute hour day month weekday)
+	Duration      int      `yaml:"duration"`       // Seconds a recurring window stays open
+	Timezone      string   `yaml:"timezone"`       // IANA time zone of the schedule (default: UTC)
+	AllowOverride bool     `yaml:"allow_override"` // API clients with the blackout_override scope may trigger during the window
+}
+
+// NoParameter as jenkins.commit_parameter or jenkins.ref_parameter keeps the field out of the Jenkins parameters
+const NoParameter = "-"
+
+// Throttle policies applied to triggers while the Jenkins build queue is too long
+const (
+	ThrottlePolicyReject = "reject" // Refuse triggers with 429 and Retry-After
+	ThrottlePolicyDelay  = "delay"  // Hold triggers until the queue drains, refusing them after max_delay
+)
+
+// ThrottleConfig represents adaptive throttling of Jenkins triggers on the Jenkins build queue length,
+// protecting Jenkins from overload during incident storms
+type ThrottleConfig struct {
+	Enabled         bool   `yaml:"enabled"`
+	QueueThreshold  int    `yaml:"queue_threshold"`  // Queue length above which triggers are throttled (default: 100)
+	ResumeThreshold int    `yaml:"resume_threshold"` // Queue length at or below which throttling stops (default: 80% of queue_threshold)
+	Policy          string `yaml:"policy"`           // reject or delay (default: reject)
+	MaxDelay        int    `yaml:"max_delay"`        // Seconds a delayed trigger waits for the queue to drain (default: 30)
+	SampleInterval  int    `yaml:"sample_interval"`  // Seconds a queue length sample is reused before Jenkins is asked again (default: 10)
+}
+
+// QuotasConfig groups jobs into categories (e.g. build, test, deploy) with their own concurrency and
+// trigger rate limits, so runaway triggers of one category cannot starve the capacity of another
+type QuotasConfig struct {
+	Categories []QuotaCategoryConfig `yaml:"categories"`
+}
+
+// QuotaCategoryConfig represents a category of jobs and its limits; a job belongs to the first
+// category matching it, and jobs of no category are not limited
+type QuotaCategoryConfig struct {
+	Name          string   `yaml:"name"`
+	Jobs          []string `yaml:"jobs"`           // Job name patterns of the category ("*" wildcard)
+	MaxConcurrent int      `yaml:"max_concurrent"` // Builds of the category running at once; 0 is unlimited (needs stats.enabled)
+	MaxTriggers   int      `yaml:"max_triggers"`   // Triggers of the category per window; 0 is unlimited
+	Window        int      `yaml:"window"`         // Seconds of the max_triggers window (default: 60)
+}
+
+// OutboxConfig represents the forwarding of audit entries to webhooks such as a SIEM through an
+// outbox: events are stored in the same transaction as their audit entry and delivered at least once,
+// across restarts. Forwarding is active when any destination is configured
+type OutboxConfig struct {
+	Destinations  []OutboxDestinationConfig `yaml:"destinations"`
+	PollInterval  int                       `yaml:"poll_interval"`  // Seconds between delivery runs (default: 5)
+	MaxAttempts   int                       `yaml:"max_attempts"`   // Deliveries tried before an event is given up as dead (default: 20)
+	RetentionDays int                       `yaml:"retention_days"` // Days delivered and dead events are kept (default: 7)
+}
+
+// Enabled reports whether audit entries are forwarded
+func (c OutboxConfig) Enabled() bool {
+	return len(c.Destinations) > 0
+}
+
+// OutboxDestinationConfig represents a webhook receiving audit entries from the outbox
+type OutboxDestinationConfig struct {
+	Name    string   `yaml:"name"`    // Identifies the destination of stored events; renaming it abandons its pending events (required)
+	URL     string   `yaml:"url"`     // Receives each event as a JSON POST (required)
+	Secret  string   `yaml:"secret"`  // HMAC key signing each delivery in X-TriggerMesh-Signature (optional)
+	Jobs    []string `yaml:"jobs"`    // Job name patterns ("*" wildcard) forwarded; empty forwards every entry
+	Timeout int      `yaml:"timeout"` // Seconds to wait for the destination (default: 10)
+}
+
+// ClockConfig represents the comparison of the local clock with the Jenkins clock, made at startup
+// and periodically, since crumb validity and the ordering of audit entries depend on synchronized clocks
+type ClockConfig struct {
+	MaxSkew       int `yaml:"max_skew"`       // Seconds the clocks may differ before a warning is logged and recorded (default: 5)
+	CheckInterval int `yaml:"check_interval"` // Seconds between checks after the one at startup (default: 600)
+}
+
+// OutboundConfig represents the policy for outbound requests whose URLs are rendered from trigger
+// requests: generic HTTP engine requests and http_lookup transformers
+type OutboundConfig struct {
+	AllowedSchemes []string `yaml:"allowed_schemes"` // URL schemes that may be requested (default: http, https)
+	DeniedCIDRs    []string `yaml:"denied_cidrs"`    // Address ranges that may not be connected to (default: link-local and cloud metadata addresses)
+	AllowedHosts   []string `yaml:"allowed_hosts"`   // Host name patterns ("*" wildcard) that may be requested; empty allows any host
+}
+
+// DefaultDeniedCIDRs are the address ranges denied when outbound.denied_cidrs is not set:
+// unspecified addresses, IPv4 and IPv6 link-local ranges (which include the AWS, GCP, and Azure
+// metadata service at 169.254.169.254), the AWS IPv6 metadata address, and the Alibaba Cloud metadata address
+var DefaultDeniedCIDRs = []string{
+	"0.0.0.0/8",
+	"169.254.0.0/16",
+	"100.100.100.200/32",
+	"::/128",
+	"fe80::/10",
+	"fd00:ec2::254/128",
+}
+
+// TransformConfig represents the pipelines that rewrite or enrich trigger parameters before dispatch
+type TransformConfig struct {
+	Rules []TransformRuleConfig `yaml:"rules"`
+}
+
+// TransformRuleConfig applies its steps, in order, to triggers of matching jobs
+// Every matching rule applies, in configuration order
+type TransformRuleConfig struct {
+	Jobs  []string              `yaml:"jobs"` // Job name patterns covered ("*" wildcard); empty means all jobs
+	Steps []TransformStepConfig `yaml:"steps"`
+}
+
+// TransformStepConfig represents one parameter transformer; the fields used depend on the type
+type TransformStepConfig struct {
+	Type        string `yaml:"type"`        // set, replace, timestamp, or http_lookup
+	Param       string `yaml:"param"`       // Parameter the step writes
+	Value       string `yaml:"value"`       // set: value, with {job} and {param:NAME} placeholders
+	Pattern     string `yaml:"pattern"`     // replace: regular expression matched against the parameter
+	Replacement string `yaml:"replacement"` // replace: replacement text, may reference groups ($1)
+	Format      string `yaml:"format"`      // timestamp: Go time layout (default: RFC 3339)
+	Timezone    string `yaml:"timezone"`    // timestamp: IANA time zone (default: UTC)
+	URL         string `yaml:"url"`         // http_lookup: endpoint fetched with GET, with URL-escaped placeholders
+	Field       string `yaml:"field"`       // http_lookup: dot-separated path of the value in the JSON response (empty: the whole body as text)
+	Timeout     int    `yaml:"timeout"`     // http_lookup: seconds to wait for the endpoint (default: 5)
+	Overwrite   bool   `yaml:"overwrite"`   // set, timestamp, http_lookup: replace a value given in the request (default: keep it)
+}
+
+// Parameter transformer types
+const (
+	TransformTypeSet        = "set"
+	TransformTypeReplace    = "replace"
+	TransformTypeTimestamp  = "timestamp"
+	TransformTypeHTTPLookup = "http_lookup"
+)
+
+// AuthzConfig represents the external authorization hook consulted for every trigger
+type AuthzConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Type     string `yaml:"type"`      // opa (OPA data API) or webhook (default: webhook)
+	URL      string `yaml:"url"`       // e.g. http://localhost:8181/v1/data/triggermesh/authz for OPA
+	Timeout  int    `yaml:"timeout"`   // Seconds to wait for a decision (default: 2)
+	CacheTTL int    `yaml:"cache_ttl"` // Seconds identical requests reuse a decision (default: 10; negative disables caching)
+	FailOpen bool   `yaml:"fail_open"` // Allow triggers when the policy service is unavailable (default: deny)
+	Secret   string `yaml:"secret"`    // HMAC key signing policy requests in X-TriggerMesh-Signature (optional)
+}
+
+// Authorization hook types
+const (
+	AuthzTypeOPA     = "opa"
+	AuthzTypeWebhook = "webhook"
+)
+
+// AuditConfig represents auditing of access to the audit log itself
+type AuditConfig struct {
+	// LogReads records every GET /api/v1/audit in the configuration audit as audit_read,
+	// with the reader, the filters used, and the number of rows returned
+	LogReads bool `yaml:"log_reads"`
+	// MaxParamsSize truncates the parameters recorded with a trigger to this many bytes and flags
+	// the entry with params_truncated (0 records them in full)
+	MaxParamsSize int `yaml:"max_params_size"`
+	// KeepFullParams stores the untruncated parameters of truncated entries in a side table, read
+	// by GET /api/v1/audit/{id}/params and replays; requires the SQLite database
+	KeepFullParams bool `yaml:"keep_full_params"`
+}
+
+// ReloadConfig represents hot-reloading of the configuration file
+type ReloadConfig struct {
+	// Watch polls the config file and its includes (e.g. a mounted Kubernetes ConfigMap/Secret)
+	// and applies changed API keys and Jenkins credentials without a restart
+	Watch         bool `yaml:"watch"`
+	WatchInterval int  `yaml:"watch_interval"` // Seconds between checks (default: 5)
+}
+
+// S3Config represents an S3-compatible object storage target (AWS S3, MinIO, ...)
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint"` // e.g. https://s3.us-east-1.amazonaws.com or http://minio:9000
+	Region          string `yaml:"region"`   // default: us-east-1
+	Bucket          string `yaml:"bucket"`
+	Prefix          string `yaml:"prefix"` // Object key prefix (default: audit/)
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
 }
 
 // APIConfig represents the API configuration
 type APIConfig struct {
-	Keys []string `yaml:"keys"`
+	Keys           []string                `yaml:"keys"`            // Unrestricted API keys
+	Clients        []APIClientConfig       `yaml:"clients"`         // Named API keys with per-key rules
+	ExpiryReminder KeyExpiryReminderConfig `yaml:"expiry_reminder"` // Notifications before keys expire
+}
+
+// APIClientConfig represents a named API key and the rules attached to it
+type APIClientConfig struct {
+	Name      string    `yaml:"name"`
+	Key       string    `yaml:"key"`
+	Tenant    string    `yaml:"tenant"`               // Tenant recorded in audit logs for this key (optional)
+	Jobs      []string  `yaml:"jobs"`                 // Job name patterns the key may see and trigger ("*" wildcard); empty means all jobs
+	Scopes    []string  `yaml:"scopes"`               // Extra permissions (admin); keys in api.keys have none
+	ExpiresAt time.Time `yaml:"expires_at,omitempty"` // Time after which the key is refused (RFC 3339); zero never expires
+	Owner     string    `yaml:"owner"`                // Contact included in expiry reminders, e.g. an e-mail address or team
+	Timezone  string    `yaml:"timezone"`             // IANA time zone of audit and stats responses when the request has no tz (default: UTC)
+}
+
+// KeyExpiryReminderConfig represents notifications sent before API keys expire
+type KeyExpiryReminderConfig struct {
+	Days      int                   `yaml:"days"`      // Remind this many days before a key expires; 0 disables reminders
+	Interval  int                   `yaml:"interval"`  // Seconds between expiry checks (default: 3600)
+	Notifiers []AlertNotifierConfig `yaml:"notifiers"` // Same types as alerts.notifiers
 }
 
+// ScopeAdmin grants access to administrative endpoints such as audit replay
+const ScopeAdmin = "admin"
+
+// ScopeBlackoutOverride allows triggers during blackout windows configured with allow_override
+const ScopeBlackoutOverride = "blackout_override"
+
+// ScopeInternalURLs shows the engine's own build and job URLs in responses despite server.build_urls
+const ScopeInternalURLs = "internal_urls"
+
 // Load loads the configuration from the given file path
 func Load(filePath string) (*Config, error) {
-	// Read the YAML file
-	data, err := os.ReadFile(filePath) //nolint:gosec // Trusted file path input
+	// Read the YAML file, resolving includes and the TRIGGERMESH_ENV overlay
+	data, err := loadDocument(filePath, os.Getenv("TRIGGERMESH_ENV"))
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse the YAML into the Config struct
+	// Parse the YAML into the Config struct, rejecting unknown keys
 	config := &Config{}
-	err = yaml.Unmarshal(data, config)
-	if err != nil {
+	if err := decodeStrict(data, config); err != nil {
 		return nil, err
 	}
 
+	config.Path = filePath
+
 	// Apply environment variables
 	applyEnvVars(config)
 
@@ -72,6 +831,17 @@ func Load(filePath string) (*Config, error) {
 	return config, nil
 }
 
+// decodeStrict parses YAML into out and fails on keys that do not map to a field,
+// so typos such as "alowed_origins" are reported instead of silently ignored
+func decodeStrict(data []byte, out interface{}) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	return nil
+}
+
 // applyEnvVars applies environment variables to the configuration
 func applyEnvVars(config *Config) {
 	// Server configuration
@@ -80,9 +850,18 @@ func applyEnvVars(config *Config) {
 			config.Server.Port = p
 		}
 	}
+	// PORT (set by many container platforms) takes precedence over TRIGGERMESH_SERVER_PORT
+	if port := os.Getenv("PORT"); port != "" {
+		if p, err := strconv.Atoi(port); err == nil && p > 0 {
+			config.Server.Port = p
+		}
+	}
 	if host := os.Getenv("TRIGGERMESH_SERVER_HOST"); host != "" {
 		config.Server.Host = host
 	}
+	if basePath := os.Getenv("TRIGGERMESH_SERVER_BASE_PATH"); basePath != "" {
+		config.Server.BasePath = basePath
+	}
 
 	// Database configuration
 	if path := os.Getenv("TRIGGERMESH_DATABASE_PATH"); path != "" {
@@ -99,11 +878,59 @@ func applyEnvVars(config *Config) {
 	if token := os.Getenv("TRIGGERMESH_JENKINS_TOKEN"); token != "" {
 		config.Jenkins.Token = token
 	}
+	if publicURL := os.Getenv("TRIGGERMESH_JENKINS_PUBLIC_URL"); publicURL != "" {
+		config.Jenkins.PublicURL = publicURL
+	}
 	if timeout := os.Getenv("TRIGGERMESH_JENKINS_TIMEOUT"); timeout != "" {
 		if t, err := strconv.Atoi(timeout); err == nil && t > 0 {
 			config.Jenkins.Timeout = t
 		}
 	}
+	if buildToken := os.Getenv("TRIGGERMESH_JENKINS_BUILD_TOKEN"); buildToken != "" {
+		config.Jenkins.BuildToken = buildToken
+	}
+	if token := os.Getenv("TRIGGERMESH_JENKINS_NOTIFICATION_TOKEN"); token != "" {
+		config.Jenkins.NotificationToken = token
+	}
+
+	// Grafana configuration
+	if token := os.Getenv("TRIGGERMESH_GRAFANA_TOKEN"); token != "" {
+		config.Grafana.Token = token
+	}
+
+	// Monitoring configuration
+	if token := os.Getenv("TRIGGERMESH_MONITORING_TOKEN"); token != "" {
+		config.Monitoring.Token = token
+	}
+
+	// Incident configuration
+	if routingKey := os.Getenv("TRIGGERMESH_PAGERDUTY_ROUTING_KEY"); routingKey != "" {
+		config.Incidents.RoutingKey = routingKey
+	}
+	if apiKey := os.Getenv("TRIGGERMESH_OPSGENIE_API_KEY"); apiKey != "" {
+		config.Incidents.APIKey = apiKey
+	}
+
+	// Email configuration
+	if password := os.Getenv("TRIGGERMESH_SMTP_PASSWORD"); password != "" {
+		config.Notifications.Email.Password = password
+	}
+
+	// Archive configuration
+	if accessKeyID := os.Getenv("TRIGGERMESH_ARCHIVE_S3_ACCESS_KEY_ID"); accessKeyID != "" {
+		config.Archive.S3.AccessKeyID = accessKeyID
+	}
+	if secretAccessKey := os.Getenv("TRIGGERMESH_ARCHIVE_S3_SECRET_ACCESS_KEY"); secretAccessKey != "" {
+		config.Archive.S3.SecretAccessKey = secretAccessKey
+	}
+
+	// Backup upload configuration
+	if accessKeyID := os.Getenv("TRIGGERMESH_BACKUP_S3_ACCESS_KEY_ID"); accessKeyID != "" {
+		config.Database.BackupS3.AccessKeyID = accessKeyID
+	}
+	if secretAccessKey := os.Getenv("TRIGGERMESH_BACKUP_S3_SECRET_ACCESS_KEY"); secretAccessKey != "" {
+		config.Database.BackupS3.SecretAccessKey = secretAccessKey
+	}
 }
 
 // setDefaults sets default values for the configuration
@@ -118,12 +945,37 @@ func setDefaults(config *Config) {
 	if config.Server.MaxBodySize == 0 {
 		config.Server.MaxBodySize = 1 << 20 // 1MB default
 	}
+	if config.Server.BuildURLs == "" {
+		config.Server.BuildURLs = BuildURLsKeep
+	}
+	if config.Server.EngineCheck == "" {
+		config.Server.EngineCheck = EngineCheckLazy
+	}
+	if len(config.Server.Listen) == 0 {
+		config.Server.Listen = []string{ListenTCP}
+	}
+	if config.Server.SocketMode == "" {
+		config.Server.SocketMode = "0660"
+	}
+	// "/triggermesh/" and "/triggermesh" are the same prefix; "/" is the root
+	config.Server.BasePath = strings.TrimRight(config.Server.BasePath, "/")
+	for i := range config.Server.Listeners {
+		listener := &config.Server.Listeners[i]
+		listener.BasePath = strings.TrimRight(listener.BasePath, "/")
+	}
 
 	// Database defaults
 	if config.Database.Path == "" {
 		config.Database.Path = "./triggermesh.db"
 	}
 
+	if config.Database.BackupS3.Region == "" {
+		config.Database.BackupS3.Region = "us-east-1"
+	}
+	if config.Database.BackupS3.Prefix == "" {
+		config.Database.BackupS3.Prefix = "backups/"
+	}
+
 	// Jenkins defaults
 	if config.Jenkins.Timeout == 0 {
 		config.Jenkins.Timeout = 30 // 30 seconds default timeout
@@ -132,65 +984,1341 @@ func setDefaults(config *Config) {
 		// If username is not provided, use token as username (Jenkins API token authentication)
 		config.Jenkins.Username = config.Jenkins.Token
 	}
-}
 
-// GetLogLevel returns the log level from the environment
-func GetLogLevel() string {
-	levelStr := os.Getenv("TRIGGERMESH_LOG_LEVEL")
-	if levelStr == "" {
-		return "info"
+	// Archive defaults
+	if config.Archive.Interval == 0 {
+		config.Archive.Interval = 3600 // Hourly
 	}
-
-	// Validate log level
-	validLevels := map[string]bool{
-		"debug": true,
-		"info":  true,
-		"warn":  true,
-		"error": true,
+	if config.Archive.S3.Region == "" {
+		config.Archive.S3.Region = "us-east-1"
+	}
+	if config.Archive.S3.Prefix == "" {
+		config.Archive.S3.Prefix = "audit/"
 	}
 
-	if _, ok := validLevels[levelStr]; ok {
-		return levelStr
+	// Concurrency defaults
+	if config.Concurrency.BulkReplay == 0 {
+		config.Concurrency.BulkReplay = 16
+	}
+	if config.Concurrency.Notifications == 0 {
+		config.Concurrency.Notifications = 4
+	}
+	if config.Concurrency.StatusPolls == 0 {
+		config.Concurrency.StatusPolls = 4
+	}
+	if config.Concurrency.TaskTimeout == 0 {
+		config.Concurrency.TaskTimeout = 30
 	}
 
-	return "info"
-}
+	// Stats defaults
+	if config.Stats.PollInterval == 0 {
+		config.Stats.PollInterval = 30
+	}
+	if config.Stats.MaxTrackAge == 0 {
+		config.Stats.MaxTrackAge = 86400 // One day
+	}
+	if config.Stats.SummaryInterval == 0 {
+		config.Stats.SummaryInterval = 300
+	}
 
-// validateConfig validates the configuration
-func validateConfig(cfg *Config) error {
-	// Validate server port
-	if cfg.Server.Port < 1 || cfg.Server.Port > 65535 {
-		return fmt.Errorf("invalid server.port: %d (must be between 1 and 65535)", cfg.Server.Port)
+	// Scheduler defaults
+	if config.Scheduler.PollInterval == 0 {
+		config.Scheduler.PollInterval = 5
+	}
+	if config.Scheduler.MaxDelay == 0 {
+		config.Scheduler.MaxDelay = 604800 // One week
+	}
+	for i := range config.Scheduler.Schedules {
+		schedule := &config.Scheduler.Schedules[i]
+		if schedule.Misfire == "" {
+			schedule.Misfire = MisfireRunOnce
+		}
+		if schedule.MisfireGrace == 0 {
+			schedule.MisfireGrace = 60
+		}
+		if schedule.MaxCatchUp == 0 {
+			schedule.MaxCatchUp = 10
+		}
 	}
 
-	// Validate max body size
-	if cfg.Server.MaxBodySize < 0 {
-		return fmt.Errorf("invalid server.max_body_size: %d (must be non-negative)", cfg.Server.MaxBodySize)
+	// Wait defaults
+	if config.Wait.MaxWait == 0 {
+		config.Wait.MaxWait = 300
 	}
-	if cfg.Server.MaxBodySize > 100<<20 { // 100MB max
-		return fmt.Errorf("invalid server.max_body_size: %d (must be less than 100MB)", cfg.Server.MaxBodySize)
+	if config.Wait.PollInterval == 0 {
+		config.Wait.PollInterval = 5
+	}
+
+	// Quota defaults
+	for i := range config.Quotas.Categories {
+		if config.Quotas.Categories[i].Window == 0 {
+			config.Quotas.Categories[i].Window = 60
+		}
 	}
 
-	// Validate Jenkins configuration
-	if cfg.Jenkins.URL == "" {
-		return fmt.Errorf("jenkins.url is required")
+	// Throttle defaults
+	if config.Throttle.QueueThreshold == 0 {
+		config.Throttle.QueueThreshold = 100
 	}
-	if _, err := url.Parse(cfg.Jenkins.URL); err != nil {
-		return fmt.Errorf("invalid jenkins.url: %v", err)
+	if config.Throttle.ResumeThreshold == 0 {
+		config.Throttle.ResumeThreshold = config.Throttle.QueueThreshold * 4 / 5
 	}
-	if cfg.Jenkins.Token == "" {
-		return fmt.Errorf("jenkins.token is required")
+	if config.Throttle.Policy == "" {
+		config.Throttle.Policy = ThrottlePolicyReject
+	}
+	if config.Throttle.MaxDelay == 0 {
+		config.Throttle.MaxDelay = 30
+	}
+	if config.Throttle.SampleInterval == 0 {
+		config.Throttle.SampleInterval = 10
 	}
 
-	// Validate API keys
-	if len(cfg.API.Keys) == 0 {
-		return fmt.Errorf("at least one api.key is required")
+	// Outbox defaults
+	if config.Outbox.PollInterval == 0 {
+		config.Outbox.PollInterval = 5
 	}
-	for i, key := range cfg.API.Keys {
-		if key == "" {
-			return fmt.Errorf("api.keys[%d] cannot be empty", i)
+	if config.Outbox.MaxAttempts == 0 {
+		config.Outbox.MaxAttempts = 20
+	}
+	if config.Outbox.RetentionDays == 0 {
+		config.Outbox.RetentionDays = 7
+	}
+	for i := range config.Outbox.Destinations {
+		if config.Outbox.Destinations[i].Timeout == 0 {
+			config.Outbox.Destinations[i].Timeout = 10
 		}
 	}
 
+	// Clock defaults
+	if config.Clock.MaxSkew == 0 {
+		config.Clock.MaxSkew = 5
+	}
+	if config.Clock.CheckInterval == 0 {
+		config.Clock.CheckInterval = 600
+	}
+
+	// Canary defaults
+	if config.Monitoring.Canary.Interval == 0 {
+		config.Monitoring.Canary.Interval = 60
+	}
+	if config.Monitoring.Canary.Timeout == 0 {
+		config.Monitoring.Canary.Timeout = 10
+	}
+
+	// Reload defaults
+	if config.Reload.WatchInterval == 0 {
+		config.Reload.WatchInterval = 5
+	}
+
+	// Alerts defaults
+	if config.API.ExpiryReminder.Interval == 0 {
+		config.API.ExpiryReminder.Interval = 3600
+	}
+	if config.Alerts.Window == 0 {
+		config.Alerts.Window = 300
+	}
+	if config.Alerts.MinTriggers == 0 {
+		config.Alerts.MinTriggers = 5
+	}
+	if config.Alerts.FailureRate == 0 {
+		config.Alerts.FailureRate = 0.5
+	}
+	if config.Alerts.Cooldown == 0 {
+		config.Alerts.Cooldown = 900
+	}
+
+	// Change policy defaults
+	if config.Change.LookupTimeout == 0 {
+		config.Change.LookupTimeout = 5
+	}
+
+	// Engine defaults
+	setResilienceDefaults(&config.Jenkins.Retry, &config.Jenkins.Breaker)
+	for i := range config.Engines {
+		engine := &config.Engines[i]
+		if engine.Timeout == 0 {
+			engine.Timeout = 30
+		}
+		setResilienceDefaults(&engine.Retry, &engine.Breaker)
+		if engine.Type == EngineTypeHTTP {
+			if engine.HTTP.Timeout == 0 {
+				engine.HTTP.Timeout = engine.Timeout
+			}
+			if engine.HTTP.AuthHeader == "" {
+				engine.HTTP.AuthHeader = "Authorization"
+			}
+			if engine.HTTP.Trigger.Method == "" {
+				engine.HTTP.Trigger.Method = http.MethodPost
+			}
+			if engine.HTTP.Status.Method == "" {
+				engine.HTTP.Status.Method = http.MethodGet
+			}
+		}
+		if (engine.Type == EngineTypeCodeBuild || engine.Type == EngineTypeCodePipeline) && engine.AWS.Timeout == 0 {
+			engine.AWS.Timeout = engine.Timeout
+		}
+		if engine.Type == EngineTypeSpinnaker && engine.Spinnaker.Timeout == 0 {
+			engine.Spinnaker.Timeout = engine.Timeout
+		}
+		if engine.Type == EngineTypeAWX && engine.AWX.Timeout == 0 {
+			engine.AWX.Timeout = engine.Timeout
+		}
+		for j := range engine.Fields {
+			if engine.Fields[j].Type == "" {
+				engine.Fields[j].Type = "string"
+			}
+		}
+	}
+
+	// Parameter transformer defaults
+	for i := range config.Transform.Rules {
+		for j := range config.Transform.Rules[i].Steps {
+			step := &config.Transform.Rules[i].Steps[j]
+			if step.Type == TransformTypeHTTPLookup && step.Timeout == 0 {
+				step.Timeout = 5
+			}
+		}
+	}
+
+	// Grafana defaults
+	if config.Grafana.Timeout == 0 {
+		config.Grafana.Timeout = 5
+	}
+
+	// Incident defaults
+	if config.Incidents.FailureThreshold == 0 {
+		config.Incidents.FailureThreshold = 3
+	}
+	for i := range config.Incidents.Jobs {
+		if config.Incidents.Jobs[i].Severity == "" {
+			config.Incidents.Jobs[i].Severity = SeverityCritical
+		}
+	}
+
+	// Email defaults
+	if email := &config.Notifications.Email; email.Host != "" {
+		if email.TLS == "" {
+			email.TLS = EmailTLSStartTLS
+		}
+		if email.Port == 0 {
+			email.Port = 587
+			if email.TLS == EmailTLSImplicit {
+				email.Port = 465
+			}
+		}
+		if len(email.Events) == 0 {
+			email.Events = []string{EmailEventBuildCompleted, EmailEventKeyRequested}
+		}
+		if email.Timeout == 0 {
+			email.Timeout = 10
+		}
+	}
+
+	// Outbound policy defaults; an explicitly empty denied_cidrs list denies no addresses
+	if config.Outbound.AllowedSchemes == nil {
+		config.Outbound.AllowedSchemes = []string{"http", "https"}
+	}
+	if config.Outbound.DeniedCIDRs == nil {
+		config.Outbound.DeniedCIDRs = append([]string(nil), DefaultDeniedCIDRs...)
+	}
+
+	// Authorization hook defaults
+	if config.Authz.Type == "" {
+		config.Authz.Type = AuthzTypeWebhook
+	}
+	if config.Authz.Timeout == 0 {
+		config.Authz.Timeout = 2
+	}
+	if config.Authz.CacheTTL == 0 {
+		config.Authz.CacheTTL = 10
+	}
+}
+
+// GetLogLevel returns the log level from the environment
+func GetLogLevel() string {
+	levelStr := os.Getenv("TRIGGERMESH_LOG_LEVEL")
+	if levelStr == "" {
+		return "info"
+	}
+
+	// Validate log level
+	validLevels := map[string]bool{
+		"debug": true,
+		"info":  true,
+		"warn":  true,
+		"error": true,
+	}
+
+	if _, ok := validLevels[levelStr]; ok {
+		return levelStr
+	}
+
+	return "info"
+}
+
+// validateConfig validates the configuration
+func validateConfig(cfg *Config) error {
+	// Validate server port
+	if cfg.Server.Port < 1 || cfg.Server.Port > 65535 {
+		return fmt.Errorf("invalid server.port: %d (must be between 1 and 65535)", cfg.Server.Port)
+	}
+
+	// Validate max body size
+	if cfg.Server.MaxBodySize < 0 {
+		return fmt.Errorf("invalid server.max_body_size: %d (must be non-negative)", cfg.Server.MaxBodySize)
+	}
+	if cfg.Server.MaxBodySize > 100<<20 { // 100MB max
+		return fmt.Errorf("invalid server.max_body_size: %d (must be less than 100MB)", cfg.Server.MaxBodySize)
+	}
+	for i, address := range cfg.Server.Listen {
+		if err := validateListenAddress(address); err != nil {
+			return fmt.Errorf("invalid server.listen[%d]: %w", i, err)
+		}
+	}
+	if mode, err := strconv.ParseUint(cfg.Server.SocketMode, 8, 32); err != nil || mode > 0o777 {
+		return fmt.Errorf("invalid server.socket_mode: %q (must be an octal file mode like 0660)", cfg.Server.SocketMode)
+	}
+	if cfg.Server.BasePath != "" && !basePathRegex.MatchString(cfg.Server.BasePath) {
+		return fmt.Errorf("invalid server.base_path: %q (must be a path like /triggermesh)", cfg.Server.BasePath)
+	}
+	listenerNames := make(map[string]bool, len(cfg.Server.Listeners))
+	for i, listener := range cfg.Server.Listeners {
+		if err := validateListener(listener); err != nil {
+			return fmt.Errorf("invalid server.listeners[%d]: %w", i, err)
+		}
+		if listenerNames[listener.Name] {
+			return fmt.Errorf("invalid server.listeners[%d]: duplicate name %q", i, listener.Name)
+		}
+		listenerNames[listener.Name] = true
+	}
+	for i, address := range cfg.Server.Management.Listen {
+		if err := validateListenAddress(address); err != nil {
+			return fmt.Errorf("invalid server.management.listen[%d]: %w", i, err)
+		}
+	}
+	if cfg.Server.Management.Pprof && !cfg.Server.Management.Enabled() {
+		return errors.New("invalid server.management.pprof: requires server.management.listen, so the profiler is never public")
+	}
+	switch cfg.Server.BuildURLs {
+	case "", BuildURLsKeep, BuildURLsOmit, BuildURLsRewrite:
+	default:
+		return fmt.Errorf("invalid server.build_urls: %q (must be keep, omit, or rewrite)", cfg.Server.BuildURLs)
+	}
+	switch cfg.Server.EngineCheck {
+	case "", EngineCheckLazy, EngineCheckFailFast:
+	default:
+		return fmt.Errorf("invalid server.engine_check: %q (must be lazy or fail_fast)", cfg.Server.EngineCheck)
+	}
+	deprecatedRoutes := make(map[string]bool, len(cfg.Server.Deprecations))
+	for i, deprecation := range cfg.Server.Deprecations {
+		if err := validateDeprecation(deprecation); err != nil {
+			return fmt.Errorf("invalid server.deprecations[%d]: %w", i, err)
+		}
+		if deprecatedRoutes[deprecation.Route] {
+			return fmt.Errorf("invalid server.deprecations[%d]: duplicate route %q", i, deprecation.Route)
+		}
+		deprecatedRoutes[deprecation.Route] = true
+	}
+
+	// Validate Jenkins configuration; the fake engine needs no Jenkins server
+	if cfg.Jenkins.Fake != nil {
+		if err := validateFakeEngine(*cfg.Jenkins.Fake); err != nil {
+			return fmt.Errorf("invalid jenkins.fake: %w", err)
+		}
+	} else {
+		if cfg.Jenkins.URL == "" {
+			return fmt.Errorf("jenkins.url is required")
+		}
+		if cfg.Jenkins.Token == "" {
+			return fmt.Errorf("jenkins.token is required")
+		}
+	}
+	if _, err := url.Parse(cfg.Jenkins.URL); err != nil {
+		return fmt.Errorf("invalid jenkins.url: %v", err)
+	}
+	if err := validateResilience("jenkins.", cfg.Jenkins.Retry, cfg.Jenkins.Breaker); err != nil {
+		return err
+	}
+	if cfg.Jenkins.PublicURL != "" {
+		if u, err := url.Parse(cfg.Jenkins.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid jenkins.public_url: %q (must be an http or https URL)", cfg.Jenkins.PublicURL)
+		}
+	}
+	for name := range cfg.Jenkins.Headers {
+		if !headerNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid jenkins.headers name: %q", name)
+		}
+		if strings.EqualFold(name, "Authorization") {
+			return fmt.Errorf("jenkins.headers cannot override Authorization")
+		}
+	}
+	if cfg.Jenkins.LabelParameterPrefix != "" && !labelParameterPrefixRegex.MatchString(cfg.Jenkins.LabelParameterPrefix) {
+		return fmt.Errorf("invalid jenkins.label_parameter_prefix: %q", cfg.Jenkins.LabelParameterPrefix)
+	}
+
+	// Validate API keys
+	if len(cfg.API.Keys) == 0 && len(cfg.API.Clients) == 0 {
+		return fmt.Errorf("at least one api.key is required")
+	}
+	seenKeys := make(map[string]bool)
+	for i, key := range cfg.API.Keys {
+		if key == "" {
+			return fmt.Errorf("api.keys[%d] cannot be empty", i)
+		}
+		seenKeys[key] = true
+	}
+	for i, client := range cfg.API.Clients {
+		if client.Name == "" {
+			return fmt.Errorf("api.clients[%d].name is required", i)
+		}
+		if client.Key == "" {
+			return fmt.Errorf("api.clients[%d].key cannot be empty", i)
+		}
+		if seenKeys[client.Key] {
+			return fmt.Errorf("api.clients[%d].key is already configured", i)
+		}
+		seenKeys[client.Key] = true
+		for j, pattern := range client.Jobs {
+			if pattern == "" {
+				return fmt.Errorf("api.clients[%d].jobs[%d] cannot be empty", i, j)
+			}
+		}
+		for j, scope := range client.Scopes {
+			if scope != ScopeAdmin && scope != ScopeBlackoutOverride && scope != ScopeInternalURLs {
+				return fmt.Errorf("invalid api.clients[%d].scopes[%d]: %q (must be admin, blackout_override, or internal_urls)", i, j, scope)
+			}
+		}
+		if _, err := time.LoadLocation(client.Timezone); err != nil {
+			return fmt.Errorf("invalid api.clients[%d].timezone: unknown timezone %q", i, client.Timezone)
+		}
+	}
+	if cfg.Monitoring.Token != "" {
+		if len(cfg.Monitoring.Token) < 16 {
+			return fmt.Errorf("invalid monitoring.token: must be at least 16 characters")
+		}
+		// The token travels in URLs, so it must never double as an API key
+		if seenKeys[cfg.Monitoring.Token] {
+			return fmt.Errorf("invalid monitoring.token: must differ from every API key")
+		}
+	}
+	if err := validateBranding(cfg.Branding); err != nil {
+		return err
+	}
+	if cfg.Jenkins.NotificationToken != "" {
+		if len(cfg.Jenkins.NotificationToken) < 16 {
+			return fmt.Errorf("invalid jenkins.notification_token: must be at least 16 characters")
+		}
+		if seenKeys[cfg.Jenkins.NotificationToken] {
+			return fmt.Errorf("invalid jenkins.notification_token: must differ from every API key")
+		}
+		if !cfg.Stats.Enabled {
+			return fmt.Errorf("invalid jenkins.notification_token: requires stats.enabled, which tracks the builds notifications complete")
+		}
+	}
+	for field, param := range map[string]string{"commit_parameter": cfg.Jenkins.CommitParameter, "ref_parameter": cfg.Jenkins.RefParameter} {
+		if param != "" && param != NoParameter && !parameterKeyRegex.MatchString(param) {
+			return fmt.Errorf("invalid jenkins.%s: %q", field, param)
+		}
+	}
+	if cfg.Jenkins.CommitParameter != "" && cfg.Jenkins.CommitParameter != NoParameter && cfg.Jenkins.CommitParameter == cfg.Jenkins.RefParameter {
+		return fmt.Errorf("invalid jenkins.ref_parameter: must differ from jenkins.commit_parameter")
+	}
+	if cfg.API.ExpiryReminder.Days < 0 {
+		return fmt.Errorf("invalid api.expiry_reminder.days: %d (must not be negative)", cfg.API.ExpiryReminder.Days)
+	}
+	if cfg.API.ExpiryReminder.Interval <= 0 {
+		return fmt.Errorf("invalid api.expiry_reminder.interval: %d (must be positive)", cfg.API.ExpiryReminder.Interval)
+	}
+	if cfg.API.ExpiryReminder.Days > 0 {
+		if len(cfg.API.ExpiryReminder.Notifiers) == 0 {
+			return fmt.Errorf("api.expiry_reminder.notifiers is required when api.expiry_reminder.days is set")
+		}
+		if err := validateNotifiers("api.expiry_reminder.notifiers", cfg.API.ExpiryReminder.Notifiers); err != nil {
+			return err
+		}
+	}
+
+	// Validate backup upload configuration
+	if cfg.Database.BackupS3.Bucket != "" {
+		if cfg.Database.BackupDir == "" {
+			return fmt.Errorf("database.backup_dir is required when database.backup_s3 is set")
+		}
+		if u, err := url.Parse(cfg.Database.BackupS3.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid database.backup_s3.endpoint: %s", cfg.Database.BackupS3.Endpoint)
+		}
+		if cfg.Database.BackupS3.AccessKeyID == "" || cfg.Database.BackupS3.SecretAccessKey == "" {
+			return fmt.Errorf("database.backup_s3.access_key_id and database.backup_s3.secret_access_key are required when database.backup_s3 is set")
+		}
+	}
+
+	// Validate archive configuration
+	if cfg.Archive.Enabled {
+		if cfg.Archive.Interval < 0 {
+			return fmt.Errorf("invalid archive.interval: %d (must be positive)", cfg.Archive.Interval)
+		}
+		if cfg.Archive.S3.Endpoint == "" {
+			return fmt.Errorf("archive.s3.endpoint is required when archive is enabled")
+		}
+		if u, err := url.Parse(cfg.Archive.S3.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid archive.s3.endpoint: %s", cfg.Archive.S3.Endpoint)
+		}
+		if cfg.Archive.S3.Bucket == "" {
+			return fmt.Errorf("archive.s3.bucket is required when archive is enabled")
+		}
+		if cfg.Archive.S3.AccessKeyID == "" || cfg.Archive.S3.SecretAccessKey == "" {
+			return fmt.Errorf("archive.s3.access_key_id and archive.s3.secret_access_key are required when archive is enabled")
+		}
+	}
+
+	// Validate stats configuration
+	if cfg.Stats.PollInterval < 0 {
+		return fmt.Errorf("invalid stats.poll_interval: %d (must be positive)", cfg.Stats.PollInterval)
+	}
+	if cfg.Stats.MaxTrackAge < 0 {
+		return fmt.Errorf("invalid stats.max_track_age: %d (must be positive)", cfg.Stats.MaxTrackAge)
+	}
+	if cfg.Stats.SummaryInterval < 0 {
+		return fmt.Errorf("invalid stats.summary_interval: %d (must be positive)", cfg.Stats.SummaryInterval)
+	}
+
+	// Validate concurrency limits
+	if cfg.Concurrency.BulkReplay < 0 || cfg.Concurrency.BulkReplay > 256 {
+		return fmt.Errorf("invalid concurrency.bulk_replay: %d (must be between 1 and 256)", cfg.Concurrency.BulkReplay)
+	}
+	if cfg.Concurrency.Notifications < 0 || cfg.Concurrency.Notifications > 256 {
+		return fmt.Errorf("invalid concurrency.notifications: %d (must be between 1 and 256)", cfg.Concurrency.Notifications)
+	}
+	if cfg.Concurrency.StatusPolls < 0 || cfg.Concurrency.StatusPolls > 256 {
+		return fmt.Errorf("invalid concurrency.status_polls: %d (must be between 1 and 256)", cfg.Concurrency.StatusPolls)
+	}
+	if cfg.Concurrency.TaskTimeout < 0 {
+		return fmt.Errorf("invalid concurrency.task_timeout: %d (must be positive)", cfg.Concurrency.TaskTimeout)
+	}
+
+	// Validate scheduler configuration
+	if cfg.Scheduler.PollInterval < 0 {
+		return fmt.Errorf("invalid scheduler.poll_interval: %d (must be positive)", cfg.Scheduler.PollInterval)
+	}
+	if cfg.Scheduler.MaxDelay < 0 {
+		return fmt.Errorf("invalid scheduler.max_delay: %d (must be positive)", cfg.Scheduler.MaxDelay)
+	}
+	scheduleNames := make(map[string]bool)
+	for i, schedule := range cfg.Scheduler.Schedules {
+		if err := validateSchedule(schedule); err != nil {
+			return fmt.Errorf("invalid scheduler.schedules[%d]: %w", i, err)
+		}
+		if scheduleNames[schedule.Name] {
+			return fmt.Errorf("invalid scheduler.schedules[%d]: duplicate name %q", i, schedule.Name)
+		}
+		scheduleNames[schedule.Name] = true
+	}
+	if cfg.Wait.MaxWait < 0 {
+		return fmt.Errorf("invalid wait.max_wait: %d (must be positive)", cfg.Wait.MaxWait)
+	}
+	if cfg.Wait.PollInterval < 0 {
+		return fmt.Errorf("invalid wait.poll_interval: %d (must be positive)", cfg.Wait.PollInterval)
+	}
+	for i, rule := range cfg.Reuse.Rules {
+		if rule.Window <= 0 {
+			return fmt.Errorf("invalid reuse.rules[%d].window: %d (must be positive)", i, rule.Window)
+		}
+	}
+
+	if cfg.Reload.WatchInterval < 0 {
+		return fmt.Errorf("invalid config.watch_interval: %d (must be positive)", cfg.Reload.WatchInterval)
+	}
+
+	// Validate alerts configuration
+	if cfg.Alerts.Enabled {
+		if cfg.Alerts.Window < 0 || cfg.Alerts.MinTriggers < 0 || cfg.Alerts.Cooldown < 0 {
+			return fmt.Errorf("alerts.window, alerts.min_triggers and alerts.cooldown must be positive")
+		}
+		if cfg.Alerts.FailureRate <= 0 || cfg.Alerts.FailureRate > 1 {
+			return fmt.Errorf("invalid alerts.failure_rate: %v (must be in (0, 1])", cfg.Alerts.FailureRate)
+		}
+		if len(cfg.Alerts.Notifiers) == 0 {
+			return fmt.Errorf("alerts.notifiers is required when alerts are enabled")
+		}
+		if err := validateNotifiers("alerts.notifiers", cfg.Alerts.Notifiers); err != nil {
+			return err
+		}
+	}
+
+	// Validate change policy
+	if cfg.Change.Pattern != "" {
+		if _, err := regexp.Compile(cfg.Change.Pattern); err != nil {
+			return fmt.Errorf("invalid change.pattern: %w", err)
+		}
+	}
+	if cfg.Change.LookupURL != "" {
+		if u, err := url.Parse(cfg.Change.LookupURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid change.lookup_url: %q", cfg.Change.LookupURL)
+		}
+	}
+	if cfg.Change.LookupTimeout < 0 {
+		return fmt.Errorf("change.lookup_timeout must be positive")
+	}
+
+	// Validate blackout windows
+	for i, window := range cfg.Blackout.Windows {
+		if err := validateBlackoutWindow(window); err != nil {
+			return fmt.Errorf("invalid blackout.windows[%d]: %w", i, err)
+		}
+	}
+
+	// Validate adaptive throttling
+	if cfg.Throttle.Enabled {
+		if err := validateThrottle(cfg.Throttle); err != nil {
+			return err
+		}
+	}
+
+	// Validate job category quotas
+	if err := validateQuotas(cfg.Quotas, cfg.Stats.Enabled); err != nil {
+		return err
+	}
+
+	// Validate the clock check
+	if cfg.Clock.MaxSkew < 0 {
+		return fmt.Errorf("invalid clock.max_skew: %d (must be positive)", cfg.Clock.MaxSkew)
+	}
+	if cfg.Clock.CheckInterval < 0 {
+		return fmt.Errorf("invalid clock.check_interval: %d (must be positive)", cfg.Clock.CheckInterval)
+	}
+
+	// Validate the canary
+	if cfg.Monitoring.Canary.Interval < 0 {
+		return fmt.Errorf("invalid monitoring.canary.interval: %d (must be positive)", cfg.Monitoring.Canary.Interval)
+	}
+	if cfg.Monitoring.Canary.Timeout < 0 {
+		return fmt.Errorf("invalid monitoring.canary.timeout: %d (must be positive)", cfg.Monitoring.Canary.Timeout)
+	}
+
+	// Validate audit forwarding
+	if cfg.Outbox.Enabled() {
+		if err := validateOutbox(cfg.Outbox); err != nil {
+			return err
+		}
+	}
+
+	// Validate engines
+	engineNames := make(map[string]bool, len(cfg.Engines))
+	for i, engine := range cfg.Engines {
+		if !engineNameRegex.MatchString(engine.Name) || reservedEngineNames[engine.Name] {
+			return fmt.Errorf("invalid engines[%d].name: %q", i, engine.Name)
+		}
+		if cfg.Monitoring.Canary.Enabled && engine.Name == CanaryEngine {
+			return fmt.Errorf("invalid engines[%d].name: %q is taken by the canary (monitoring.canary.enabled)", i, engine.Name)
+		}
+		if engineNames[engine.Name] {
+			return fmt.Errorf("duplicate engine name: %q", engine.Name)
+		}
+		engineNames[engine.Name] = true
+		switch engine.Type {
+		case EngineTypeHTTP:
+			if err := validateHTTPEngine(engine.HTTP); err != nil {
+				return fmt.Errorf("invalid engines[%d] (%s): %w", i, engine.Name, err)
+			}
+		case EngineTypeCodeBuild, EngineTypeCodePipeline:
+			if err := validateAWSEngine(engine.AWS); err != nil {
+				return fmt.Errorf("invalid engines[%d] (%s): %w", i, engine.Name, err)
+			}
+		case EngineTypeSpinnaker:
+			if err := validateSpinnakerEngine(engine.Spinnaker); err != nil {
+				return fmt.Errorf("invalid engines[%d] (%s): %w", i, engine.Name, err)
+			}
+		case EngineTypeAWX:
+			if err := validateAWXEngine(engine.AWX); err != nil {
+				return fmt.Errorf("invalid engines[%d] (%s): %w", i, engine.Name, err)
+			}
+		case EngineTypeFake:
+			if err := validateFakeEngine(engine.Fake); err != nil {
+				return fmt.Errorf("invalid engines[%d] (%s): %w", i, engine.Name, err)
+			}
+		default:
+			return fmt.Errorf("invalid engines[%d].type: %q (must be http, codebuild, codepipeline, spinnaker, awx, or fake)", i, engine.Type)
+		}
+		if err := validateEngineFields(engine.Fields); err != nil {
+			return fmt.Errorf("invalid engines[%d] (%s): %w", i, engine.Name, err)
+		}
+		if engine.Timeout < 0 {
+			return fmt.Errorf("invalid engines[%d].timeout: %d (must be positive)", i, engine.Timeout)
+		}
+		if err := validateResilience(fmt.Sprintf("engines[%d].", i), engine.Retry, engine.Breaker); err != nil {
+			return err
+		}
+	}
+
+	// Validate parameter transformers
+	for i, rule := range cfg.Transform.Rules {
+		for j, pattern := range rule.Jobs {
+			if pattern == "" {
+				return fmt.Errorf("invalid transform.rules[%d]: jobs[%d] cannot be empty", i, j)
+			}
+		}
+		if len(rule.Steps) == 0 {
+			return fmt.Errorf("invalid transform.rules[%d]: steps cannot be empty", i)
+		}
+		for j, step := range rule.Steps {
+			if err := validateTransformStep(step); err != nil {
+				return fmt.Errorf("invalid transform.rules[%d].steps[%d]: %w", i, j, err)
+			}
+		}
+	}
+
+	// Validate Grafana annotations
+	if cfg.Grafana.URL != "" {
+		if u, err := url.Parse(cfg.Grafana.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid grafana.url: %q", cfg.Grafana.URL)
+		}
+		if cfg.Grafana.Timeout < 0 {
+			return fmt.Errorf("invalid grafana.timeout: %d (must be positive)", cfg.Grafana.Timeout)
+		}
+		for i, pattern := range cfg.Grafana.Jobs {
+			if pattern == "" {
+				return fmt.Errorf("invalid grafana.jobs[%d]: cannot be empty", i)
+			}
+		}
+	}
+
+	// Validate incidents
+	if err := validateIncidents(cfg.Incidents); err != nil {
+		return err
+	}
+
+	// Validate audit parameter truncation
+	if cfg.Audit.MaxParamsSize < 0 {
+		return fmt.Errorf("invalid audit.max_params_size: %d (must not be negative)", cfg.Audit.MaxParamsSize)
+	}
+	if cfg.Audit.KeepFullParams && cfg.Audit.MaxParamsSize == 0 {
+		return fmt.Errorf("audit.keep_full_params requires audit.max_params_size")
+	}
+
+	// Validate email notifications
+	if err := validateEmail(cfg.Notifications.Email); err != nil {
+		return err
+	}
+
+	// Validate outbound policy
+	for i, scheme := range cfg.Outbound.AllowedSchemes {
+		if scheme != "http" && scheme != "https" {
+			return fmt.Errorf("invalid outbound.allowed_schemes[%d]: %q (must be http or https)", i, scheme)
+		}
+	}
+	for i, cidr := range cfg.Outbound.DeniedCIDRs {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			return fmt.Errorf("invalid outbound.denied_cidrs[%d]: %q", i, cidr)
+		}
+	}
+	for i, host := range cfg.Outbound.AllowedHosts {
+		if host == "" {
+			return fmt.Errorf("invalid outbound.allowed_hosts[%d]: cannot be empty", i)
+		}
+	}
+
+	// Validate authorization hook
+	if cfg.Authz.Enabled {
+		if cfg.Authz.Type != AuthzTypeOPA && cfg.Authz.Type != AuthzTypeWebhook {
+			return fmt.Errorf("invalid authz.type: %q (must be opa or webhook)", cfg.Authz.Type)
+		}
+		if u, err := url.Parse(cfg.Authz.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid authz.url: %q", cfg.Authz.URL)
+		}
+		if cfg.Authz.Timeout < 0 {
+			return fmt.Errorf("authz.timeout must be positive")
+		}
+	}
+
+	return nil
+}
+
+// validateHTTPEngine checks the templates and JSONPaths of a generic HTTP engine
+func validateHTTPEngine(cfg HTTPEngineConfig) error {
+	if cfg.Timeout < 0 {
+		return errors.New("timeout must be positive")
+	}
+	if !headerNameRegex.MatchString(cfg.AuthHeader) {
+		return fmt.Errorf("invalid auth_header: %q", cfg.AuthHeader)
+	}
+	for name := range cfg.Headers {
+		if !headerNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid header name: %q", name)
+		}
+	}
+
+	if cfg.Trigger.URL == "" {
+		return errors.New("trigger.url cannot be empty")
+	}
+	// Only the names of the template functions matter for parsing
+	funcs := template.FuncMap{"json": func(interface{}) (string, error) { return "", nil }}
+	for field, tmpl := range map[string]string{"trigger.url": cfg.Trigger.URL, "trigger.body": cfg.Trigger.Body, "status.url": cfg.Status.URL} {
+		if _, err := template.New(field).Funcs(funcs).Parse(tmpl); err != nil {
+			return fmt.Errorf("invalid %s template: %w", field, err)
+		}
+	}
+	if cfg.Trigger.BuildIDPath == "" {
+		return errors.New("trigger.build_id_path cannot be empty")
+	}
+	paths := map[string]string{"trigger.build_id_path": cfg.Trigger.BuildIDPath, "trigger.build_url_path": cfg.Trigger.BuildURLPath}
+
+	if cfg.Status.URL != "" {
+		if cfg.Status.StatePath == "" {
+			return errors.New("status.state_path cannot be empty")
+		}
+		paths["status.state_path"] = cfg.Status.StatePath
+		paths["status.url_path"] = cfg.Status.URLPath
+		for state, result := range cfg.Status.Results {
+			switch result {
+			case "SUCCESS", "FAILURE", "UNSTABLE", "ABORTED":
+			default:
+				return fmt.Errorf("invalid status.results[%s]: %q (must be SUCCESS, FAILURE, UNSTABLE, or ABORTED)", state, result)
+			}
+		}
+	}
+	for field, path := range paths {
+		if path == "" {
+			continue
+		}
+		if _, err := jsonpath.Parse(path); err != nil {
+			return fmt.Errorf("invalid %s: %w", field, err)
+		}
+	}
+	return nil
+}
+
+// validateAWSEngine checks the region, endpoint, and static credentials of an AWS engine
+// The region may be left to the environment, so it is only checked when set
+func validateAWSEngine(cfg AWSEngineConfig) error {
+	if cfg.Timeout < 0 {
+		return errors.New("timeout must be positive")
+	}
+	if cfg.Region != "" && !awsRegionRegex.MatchString(cfg.Region) {
+		return fmt.Errorf("invalid aws.region: %q", cfg.Region)
+	}
+	if cfg.Endpoint != "" {
+		if u, err := url.Parse(cfg.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid aws.endpoint: %s", cfg.Endpoint)
+		}
+	}
+	if (cfg.AccessKeyID == "") != (cfg.SecretAccessKey == "") {
+		return errors.New("aws.access_key_id and aws.secret_access_key must be set together")
+	}
+	if cfg.SessionToken != "" && cfg.AccessKeyID == "" {
+		return errors.New("aws.session_token requires aws.access_key_id")
+	}
+	return nil
+}
+
+// validateSpinnakerEngine checks the URLs and headers of a Spinnaker engine
+func validateSpinnakerEngine(cfg SpinnakerEngineConfig) error {
+	if cfg.Timeout < 0 {
+		return errors.New("timeout must be positive")
+	}
+	for field, value := range map[string]string{"spinnaker.url": cfg.URL, "spinnaker.ui_url": cfg.UIURL} {
+		if value == "" && field == "spinnaker.ui_url" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid %s: %q", field, value)
+		}
+	}
+	for name := range cfg.Headers {
+		if !headerNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid header name: %q", name)
+		}
+	}
+	return nil
+}
+
+// validateAWXEngine checks the URL and headers of an AWX engine
+func validateAWXEngine(cfg AWXEngineConfig) error {
+	if cfg.Timeout < 0 {
+		return errors.New("timeout must be positive")
+	}
+	if u, err := url.Parse(cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid awx.url: %q", cfg.URL)
+	}
+	for name := range cfg.Headers {
+		if !headerNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid header name: %q", name)
+		}
+	}
+	return nil
+}
+
+// validateListenAddress checks a listen address: tcp, tcp:<address>, unix:<path>, systemd, or systemd:<name>
+func validateListenAddress(address string) error {
+	scheme, value, hasValue := strings.Cut(address, ":")
+	switch {
+	case scheme == ListenTCP && !hasValue:
+		return nil
+	case scheme == ListenTCP:
+		if _, port, err := net.SplitHostPort(value); err != nil || port == "" {
+			return fmt.Errorf("%q (tcp:<address> must be host:port, e.g. tcp:127.0.0.1:9090 or tcp:[::1]:9090)", address)
+		}
+		return nil
+	case scheme == ListenUnix && value != "":
+		return nil
+	case scheme == ListenSystemd && (!hasValue || value != ""):
+		return nil
+	default:
+		return fmt.Errorf("%q (must be tcp, tcp:<address>, unix:<path>, systemd, or systemd:<name>)", address)
+	}
+}
+
+// validateListener checks the name, addresses, and middleware settings of an additional listener
+func validateListener(listener ListenerConfig) error {
+	if listener.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(listener.Listen) == 0 {
+		return fmt.Errorf("%s: listen is required", listener.Name)
+	}
+	for _, address := range listener.Listen {
+		if err := validateListenAddress(address); err != nil {
+			return fmt.Errorf("%s: invalid listen address %w", listener.Name, err)
+		}
+	}
+	for _, route := range listener.Routes {
+		if !strings.HasPrefix(route, "/") {
+			return fmt.Errorf("%s: invalid route %q (must start with /)", listener.Name, route)
+		}
+	}
+	if listener.MaxBodySize < 0 || listener.MaxBodySize > 100<<20 {
+		return fmt.Errorf("%s: invalid max_body_size: %d (must be between 0 and 100MB)", listener.Name, listener.MaxBodySize)
+	}
+	if listener.BasePath != "" && !basePathRegex.MatchString(listener.BasePath) {
+		return fmt.Errorf("%s: invalid base_path: %q (must be a path like /triggermesh)", listener.Name, listener.BasePath)
+	}
+	return nil
+}
+
+// validateDeprecation checks the route, dates, and link of a deprecated route
+func validateDeprecation(deprecation DeprecationConfig) error {
+	if !strings.HasPrefix(deprecation.Route, "/") {
+		return fmt.Errorf("invalid route %q (must start with /)", deprecation.Route)
+	}
+	if !deprecation.Since.IsZero() && !deprecation.Sunset.IsZero() && !deprecation.Sunset.After(deprecation.Since) {
+		return fmt.Errorf("%s: sunset must be after since", deprecation.Route)
+	}
+	if deprecation.Link != "" {
+		if u, err := url.Parse(deprecation.Link); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s: invalid link %q (must be an http or https URL)", deprecation.Route, deprecation.Link)
+		}
+	}
+	return nil
+}
+
+// validateFakeEngine checks the latencies and rates of a fake engine
+func validateFakeEngine(cfg FakeEngineConfig) error {
+	if cfg.Latency < 0 || cfg.Jitter < 0 {
+		return errors.New("latency and jitter must be positive")
+	}
+	if cfg.BuildDuration < 0 {
+		return errors.New("build_duration must be positive")
+	}
+	if cfg.FailureRate < 0 || cfg.FailureRate > 1 {
+		return fmt.Errorf("invalid failure_rate: %v (must be between 0 and 1)", cfg.FailureRate)
+	}
+	if cfg.BuildFailureRate < 0 || cfg.BuildFailureRate > 1 {
+		return fmt.Errorf("invalid build_failure_rate: %v (must be between 0 and 1)", cfg.BuildFailureRate)
+	}
+	return nil
+}
+
+// validateBranding checks the documentation URLs and lengths of the default and tenant brands
+func validateBranding(cfg BrandingConfig) error {
+	check := func(prefix string, brand Brand) error {
+		for _, setting := range []struct{ name, value string }{
+			{"service_name", brand.ServiceName}, {"contact", brand.Contact}, {"docs_url", brand.DocsURL},
+		} {
+			if len(setting.value) > 256 {
+				return fmt.Errorf("invalid %s%s: exceeds 256 characters", prefix, setting.name)
+			}
+		}
+		if len(brand.Message) > 2048 {
+			return fmt.Errorf("invalid %smessage: exceeds 2048 characters", prefix)
+		}
+		if brand.DocsURL != "" {
+			if u, err := url.Parse(brand.DocsURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid %sdocs_url: %q (must be an http or https URL)", prefix, brand.DocsURL)
+			}
+		}
+		return nil
+	}
+	if err := check("branding.", cfg.Brand); err != nil {
+		return err
+	}
+	for tenant, brand := range cfg.Tenants {
+		if tenant == "" {
+			return fmt.Errorf("invalid branding.tenants: tenant name cannot be empty")
+		}
+		if err := check(fmt.Sprintf("branding.tenants[%s].", tenant), brand); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateEngineFields checks that engine request fields have unique names and parameters, a known
+// type, and valid constraints
+func validateEngineFields(fields []EngineFieldConfig) error {
+	names := make(map[string]bool, len(fields))
+	params := make(map[string]bool, len(fields))
+	for i, field := range fields {
+		if !engineFieldNameRegex.MatchString(field.Name) {
+			return fmt.Errorf("invalid fields[%d].name: %q", i, field.Name)
+		}
+		if names[field.Name] {
+			return fmt.Errorf("invalid fields[%d].name: duplicate name %q", i, field.Name)
+		}
+		names[field.Name] = true
+		param := field.Parameter
+		if param == "" {
+			param = field.Name
+		}
+		if !parameterKeyRegex.MatchString(param) {
+			return fmt.Errorf("invalid fields[%d].parameter: %q", i, param)
+		}
+		if params[param] {
+			return fmt.Errorf("invalid fields[%d].parameter: %q is used by another field", i, param)
+		}
+		params[param] = true
+		switch field.Type {
+		case "string":
+			if field.Pattern != "" {
+				if _, err := regexp.Compile(field.Pattern); err != nil {
+					return fmt.Errorf("invalid fields[%d].pattern: %w", i, err)
+				}
+			}
+		case "integer", "boolean":
+			if len(field.Enum) > 0 || field.Pattern != "" {
+				return fmt.Errorf("invalid fields[%d] (%s): enum and pattern only apply to string fields", i, field.Name)
+			}
+		default:
+			return fmt.Errorf("invalid fields[%d].type: %q (must be string, integer, or boolean)", i, field.Type)
+		}
+	}
+	return nil
+}
+
+// validateTransformStep checks that a transformer step has the fields its type needs
+func validateTransformStep(step TransformStepConfig) error {
+	if !parameterKeyRegex.MatchString(step.Param) {
+		return fmt.Errorf("invalid param: %q", step.Param)
+	}
+	switch step.Type {
+	case TransformTypeSet:
+	case TransformTypeReplace:
+		if step.Pattern == "" {
+			return errors.New("pattern cannot be empty")
+		}
+		if _, err := regexp.Compile(step.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	case TransformTypeTimestamp:
+		if _, err := time.LoadLocation(step.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone: %q", step.Timezone)
+		}
+	case TransformTypeHTTPLookup:
+		if u, err := url.Parse(step.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid url: %q", step.URL)
+		}
+		if step.Timeout < 0 {
+			return errors.New("timeout must be positive")
+		}
+	default:
+		return fmt.Errorf("invalid type: %q (must be set, replace, timestamp, or http_lookup)", step.Type)
+	}
+	return nil
+}
+
+// validateThrottle checks the thresholds, policy, and intervals of adaptive throttling
+func validateThrottle(cfg ThrottleConfig) error {
+	if cfg.QueueThreshold < 1 {
+		return fmt.Errorf("invalid throttle.queue_threshold: %d (must be positive)", cfg.QueueThreshold)
+	}
+	if cfg.ResumeThreshold < 0 || cfg.ResumeThreshold > cfg.QueueThreshold {
+		return fmt.Errorf("invalid throttle.resume_threshold: %d (must be between 0 and queue_threshold)", cfg.ResumeThreshold)
+	}
+	if cfg.Policy != ThrottlePolicyReject && cfg.Policy != ThrottlePolicyDelay {
+		return fmt.Errorf("invalid throttle.policy: %q (must be reject or delay)", cfg.Policy)
+	}
+	if cfg.MaxDelay < 0 {
+		return fmt.Errorf("invalid throttle.max_delay: %d (must be positive)", cfg.MaxDelay)
+	}
+	if cfg.SampleInterval < 1 {
+		return fmt.Errorf("invalid throttle.sample_interval: %d (must be positive)", cfg.SampleInterval)
+	}
+	return nil
+}
+
+// validateQuotas checks that categories are named uniquely, cover jobs, and set non-negative limits;
+// concurrency limits count tracked builds, so they need build statistics
+func validateQuotas(cfg QuotasConfig, statsEnabled bool) error {
+	names := make(map[string]bool, len(cfg.Categories))
+	for i, category := range cfg.Categories {
+		if category.Name == "" {
+			return fmt.Errorf("invalid quotas.categories[%d].name: cannot be empty", i)
+		}
+		if names[category.Name] {
+			return fmt.Errorf("invalid quotas.categories[%d].name: duplicate name %q", i, category.Name)
+		}
+		names[category.Name] = true
+		if len(category.Jobs) == 0 {
+			return fmt.Errorf("invalid quotas.categories[%d].jobs: cannot be empty", i)
+		}
+		for j, pattern := range category.Jobs {
+			if pattern == "" {
+				return fmt.Errorf("invalid quotas.categories[%d].jobs[%d]: cannot be empty", i, j)
+			}
+		}
+		if category.MaxConcurrent < 0 {
+			return fmt.Errorf("invalid quotas.categories[%d].max_concurrent: %d (must be positive)", i, category.MaxConcurrent)
+		}
+		if category.MaxConcurrent > 0 && !statsEnabled {
+			return fmt.Errorf("invalid quotas.categories[%d].max_concurrent: running builds are only known with stats.enabled", i)
+		}
+		if category.MaxTriggers < 0 {
+			return fmt.Errorf("invalid quotas.categories[%d].max_triggers: %d (must be positive)", i, category.MaxTriggers)
+		}
+		if category.Window < 1 {
+			return fmt.Errorf("invalid quotas.categories[%d].window: %d (must be positive)", i, category.Window)
+		}
+	}
+	return nil
+}
+
+// validateOutbox checks the destinations and intervals of audit forwarding
+func validateOutbox(cfg OutboxConfig) error {
+	if cfg.PollInterval < 1 {
+		return fmt.Errorf("invalid outbox.poll_interval: %d (must be positive)", cfg.PollInterval)
+	}
+	if cfg.MaxAttempts < 1 {
+		return fmt.Errorf("invalid outbox.max_attempts: %d (must be positive)", cfg.MaxAttempts)
+	}
+	if cfg.RetentionDays < 1 {
+		return fmt.Errorf("invalid outbox.retention_days: %d (must be positive)", cfg.RetentionDays)
+	}
+	names := make(map[string]bool, len(cfg.Destinations))
+	for i, destination := range cfg.Destinations {
+		if destination.Name == "" {
+			return fmt.Errorf("invalid outbox.destinations[%d].name: cannot be empty", i)
+		}
+		if names[destination.Name] {
+			return fmt.Errorf("invalid outbox.destinations[%d].name: duplicate name %q", i, destination.Name)
+		}
+		names[destination.Name] = true
+		if u, err := url.Parse(destination.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid outbox.destinations[%d].url: %q", i, destination.URL)
+		}
+		if destination.Timeout < 0 {
+			return fmt.Errorf("invalid outbox.destinations[%d].timeout: %d (must be positive)", i, destination.Timeout)
+		}
+		for j, pattern := range destination.Jobs {
+			if pattern == "" {
+				return fmt.Errorf("invalid outbox.destinations[%d].jobs[%d]: cannot be empty", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// validateBlackoutWindow checks that a blackout window is either a one-off range or a recurring schedule
+func validateBlackoutWindow(window BlackoutWindowConfig) error {
+	if window.Name == "" {
+		return errors.New("name cannot be empty")
+	}
+	for j, pattern := range window.Jobs {
+		if pattern == "" {
+			return fmt.Errorf("jobs[%d] cannot be empty", j)
+		}
+	}
+
+	oneOff := window.Start != "" || window.End != ""
+	recurring := window.Schedule != ""
+	switch {
+	case oneOff && recurring:
+		return errors.New("set either start and end, or schedule and duration")
+	case oneOff:
+		start, err := time.Parse(time.RFC3339, window.Start)
+		if err != nil {
+			return fmt.Errorf("start must be an RFC 3339 time: %q", window.Start)
+		}
+		end, err := time.Parse(time.RFC3339, window.End)
+		if err != nil {
+			return fmt.Errorf("end must be an RFC 3339 time: %q", window.End)
+		}
+		if !end.After(start) {
+			return errors.New("end must be after start")
+		}
+	case recurring:
+		if _, err := cron.Parse(window.Schedule); err != nil {
+			return err
+		}
+		if window.Duration <= 0 {
+			return errors.New("duration must be positive")
+		}
+		if _, err := time.LoadLocation(window.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone: %q", window.Timezone)
+		}
+	default:
+		return errors.New("set either start and end, or schedule and duration")
+	}
+	return nil
+}
+
+// setResilienceDefaults fills in the defaults of an engine's retry and breaker settings
+func setResilienceDefaults(retry *RetryConfig, breaker *BreakerConfig) {
+	if retry.Attempts == 0 {
+		retry.Attempts = 1
+	}
+	if retry.Backoff == 0 {
+		retry.Backoff = 500
+	}
+	if breaker.OpenDuration == 0 {
+		breaker.OpenDuration = 30
+	}
+}
+
+// validateResilience checks an engine's retry and breaker settings, below the configuration path prefix
+func validateResilience(prefix string, retry RetryConfig, breaker BreakerConfig) error {
+	if retry.Attempts < 0 || retry.Attempts > 10 {
+		return fmt.Errorf("invalid %sretry.attempts: %d (must be 1 to 10)", prefix, retry.Attempts)
+	}
+	if retry.Backoff < 0 {
+		return fmt.Errorf("invalid %sretry.backoff: %d (must be positive)", prefix, retry.Backoff)
+	}
+	if breaker.FailureThreshold < 0 {
+		return fmt.Errorf("invalid %sbreaker.failure_threshold: %d (must be positive)", prefix, breaker.FailureThreshold)
+	}
+	if breaker.OpenDuration < 0 {
+		return fmt.Errorf("invalid %sbreaker.open_duration: %d (must be positive)", prefix, breaker.OpenDuration)
+	}
+	return nil
+}
+
+// validateSchedule checks a recurring schedule
+func validateSchedule(schedule ScheduleConfig) error {
+	if !engineNameRegex.MatchString(schedule.Name) {
+		return fmt.Errorf("invalid name: %q (lowercase letters, digits, _ and -)", schedule.Name)
+	}
+	if schedule.Job == "" {
+		return errors.New("job cannot be empty")
+	}
+	for key := range schedule.Parameters {
+		if !parameterKeyRegex.MatchString(key) {
+			return fmt.Errorf("invalid parameter name: %q", key)
+		}
+	}
+	if _, err := cron.Parse(schedule.Schedule); err != nil {
+		return err
+	}
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone: %q", schedule.Timezone)
+	}
+	switch schedule.Misfire {
+	case "", MisfireSkip, MisfireRunOnce, MisfireCatchUp:
+	default:
+		return fmt.Errorf("invalid misfire: %q (must be skip, run_once, or catch_up)", schedule.Misfire)
+	}
+	if schedule.MisfireGrace < 0 {
+		return fmt.Errorf("invalid misfire_grace: %d (must be positive)", schedule.MisfireGrace)
+	}
+	if schedule.MaxCatchUp < 0 {
+		return fmt.Errorf("invalid max_catch_up: %d (must be positive)", schedule.MaxCatchUp)
+	}
+	return nil
+}
+
+// validateNotifiers checks the notifier list at the given configuration path
+func validateNotifiers(field string, notifiers []AlertNotifierConfig) error {
+	for i, notifier := range notifiers {
+		if notifier.Type != "slack" && notifier.Type != "webhook" {
+			return fmt.Errorf("invalid %s[%d].type: %q (must be slack or webhook)", field, i, notifier.Type)
+		}
+		if u, err := url.Parse(notifier.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid %s[%d].url: %q", field, i, notifier.URL)
+		}
+	}
+	return nil
+}
+
+// validateIncidents checks the incident provider and job severities
+func validateIncidents(cfg IncidentsConfig) error {
+	switch cfg.Provider {
+	case "":
+		return nil
+	case IncidentProviderPagerDuty:
+		if cfg.RoutingKey == "" {
+			return fmt.Errorf("incidents.routing_key is required for provider pagerduty")
+		}
+	case IncidentProviderOpsgenie:
+		if cfg.APIKey == "" {
+			return fmt.Errorf("incidents.api_key is required for provider opsgenie")
+		}
+	default:
+		return fmt.Errorf("invalid incidents.provider: %q (must be pagerduty or opsgenie)", cfg.Provider)
+	}
+
+	if cfg.URL != "" {
+		if u, err := url.Parse(cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid incidents.url: %q", cfg.URL)
+		}
+	}
+	if cfg.FailureThreshold < 1 {
+		return fmt.Errorf("invalid incidents.failure_threshold: %d (must be positive)", cfg.FailureThreshold)
+	}
+	if len(cfg.Jobs) == 0 {
+		return fmt.Errorf("incidents.jobs is required when incidents.provider is set")
+	}
+	for i, job := range cfg.Jobs {
+		if job.Pattern == "" {
+			return fmt.Errorf("invalid incidents.jobs[%d].pattern: cannot be empty", i)
+		}
+		switch job.Severity {
+		case SeverityCritical, SeverityError, SeverityWarning, SeverityInfo:
+		default:
+			return fmt.Errorf("invalid incidents.jobs[%d].severity: %q (must be critical, error, warning, or info)", i, job.Severity)
+		}
+	}
+	return nil
+}
+
+// validateEmail checks the SMTP settings, addresses, and events of email notifications
+func validateEmail(cfg EmailConfig) error {
+	if cfg.Host == "" {
+		return nil
+	}
+	if cfg.Port < 1 || cfg.Port > 65535 {
+		return fmt.Errorf("invalid notifications.email.port: %d", cfg.Port)
+	}
+	switch cfg.TLS {
+	case EmailTLSStartTLS, EmailTLSImplicit, EmailTLSNone:
+	default:
+		return fmt.Errorf("invalid notifications.email.tls: %q (must be starttls, tls, or none)", cfg.TLS)
+	}
+	if cfg.Username != "" && cfg.TLS == EmailTLSNone {
+		return fmt.Errorf("notifications.email.username requires tls or starttls")
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return fmt.Errorf("invalid notifications.email.from: %q", cfg.From)
+	}
+	if len(cfg.To) == 0 {
+		return fmt.Errorf("notifications.email.to is required when notifications.email.host is set")
+	}
+	for i, to := range cfg.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("invalid notifications.email.to[%d]: %q", i, to)
+		}
+	}
+	for i, event := range cfg.Events {
+		if event != EmailEventBuildCompleted && event != EmailEventKeyRequested {
+			return fmt.Errorf("invalid notifications.email.events[%d]: %q (must be build_completed or key_requested)", i, event)
+		}
+	}
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("invalid notifications.email.timeout: %d (must be positive)", cfg.Timeout)
+	}
 	return nil
 }
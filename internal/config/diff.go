@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldChange is a configuration setting that differs between two configurations
+// Field is the dotted YAML path (e.g. jenkins.url, api.keys[0]); Before and After are
+// empty when the setting was added or removed, and secrets are masked
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Diff returns the settings that differ between two configurations, sorted by field
+// Changes are detected on the real values, so a rotated secret is reported, but only masked values are returned
+func Diff(before, after *Config) ([]FieldChange, error) {
+	beforeValues, err := flatten(before)
+	if err != nil {
+		return nil, err
+	}
+	afterValues, err := flatten(after)
+	if err != nil {
+		return nil, err
+	}
+	beforeMasked, err := flatten(before.Masked())
+	if err != nil {
+		return nil, err
+	}
+	afterMasked, err := flatten(after.Masked())
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]bool, len(beforeValues)+len(afterValues))
+	for field := range beforeValues {
+		fields[field] = true
+	}
+	for field := range afterValues {
+		fields[field] = true
+	}
+
+	var changes []FieldChange
+	for field := range fields {
+		if beforeValues[field] == afterValues[field] {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field, Before: beforeMasked[field], After: afterMasked[field]})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// flatten maps the dotted YAML path of every scalar setting to its value
+func flatten(cfg *Config) (map[string]string, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	var tree interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	values := make(map[string]string)
+	flattenValue("", tree, values)
+	return values, nil
+}
+
+// flattenValue adds the scalars below value to values, prefixing their paths with path
+func flattenValue(path string, value interface{}, values map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if path != "" {
+				key = path + "." + key
+			}
+			flattenValue(key, child, values)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenValue(path+"["+strconv.Itoa(i)+"]", child, values)
+		}
+	case nil:
+		values[path] = ""
+	default:
+		values[path] = fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,118 @@
+package config
+
+// maskedValue replaces secrets in printed configuration
+const maskedValue = "********"
+
+// Masked returns a copy of the configuration with secrets (tokens, API keys,
+// credentials, Jenkins and engine header values, notifier, lookup, policy, and outbox URLs and signing secrets) replaced, safe to print or log
+func (c *Config) Masked() *Config {
+	masked := *c
+
+	masked.Jenkins.Token = mask(c.Jenkins.Token)
+	masked.Jenkins.Headers = maskHeaders(c.Jenkins.Headers)
+	masked.Jenkins.BuildToken = mask(c.Jenkins.BuildToken)
+	masked.Jenkins.NotificationToken = mask(c.Jenkins.NotificationToken)
+	// The username defaults to the token, so mask it when they match
+	if c.Jenkins.Username == c.Jenkins.Token {
+		masked.Jenkins.Username = mask(c.Jenkins.Username)
+	}
+
+	if c.Engines != nil {
+		masked.Engines = make([]EngineConfig, len(c.Engines))
+		for i, engine := range c.Engines {
+			engine.HTTP.Token = mask(engine.HTTP.Token)
+			engine.AWS.AccessKeyID = mask(engine.AWS.AccessKeyID)
+			engine.AWS.SecretAccessKey = mask(engine.AWS.SecretAccessKey)
+			engine.AWS.SessionToken = mask(engine.AWS.SessionToken)
+			engine.Spinnaker.Token = mask(engine.Spinnaker.Token)
+			engine.Spinnaker.Password = mask(engine.Spinnaker.Password)
+			engine.HTTP.Headers = maskHeaders(engine.HTTP.Headers)
+			engine.Spinnaker.Headers = maskHeaders(engine.Spinnaker.Headers)
+			engine.AWX.Token = mask(engine.AWX.Token)
+			engine.AWX.Password = mask(engine.AWX.Password)
+			engine.AWX.Headers = maskHeaders(engine.AWX.Headers)
+			masked.Engines[i] = engine
+		}
+	}
+
+	if c.API.Keys != nil {
+		masked.API.Keys = make([]string, len(c.API.Keys))
+		for i, key := range c.API.Keys {
+			masked.API.Keys[i] = mask(key)
+		}
+	}
+	if c.API.Clients != nil {
+		masked.API.Clients = make([]APIClientConfig, len(c.API.Clients))
+		for i, client := range c.API.Clients {
+			client.Key = mask(client.Key)
+			masked.API.Clients[i] = client
+		}
+	}
+
+	masked.Grafana.Token = mask(c.Grafana.Token)
+	masked.Monitoring.Token = mask(c.Monitoring.Token)
+	masked.Incidents.RoutingKey = mask(c.Incidents.RoutingKey)
+	masked.Incidents.APIKey = mask(c.Incidents.APIKey)
+	masked.Notifications.Email.Password = mask(c.Notifications.Email.Password)
+	masked.Archive.S3.AccessKeyID = mask(c.Archive.S3.AccessKeyID)
+	masked.Archive.S3.SecretAccessKey = mask(c.Archive.S3.SecretAccessKey)
+	masked.Database.BackupS3.AccessKeyID = mask(c.Database.BackupS3.AccessKeyID)
+	masked.Database.BackupS3.SecretAccessKey = mask(c.Database.BackupS3.SecretAccessKey)
+
+	if c.API.ExpiryReminder.Notifiers != nil {
+		masked.API.ExpiryReminder.Notifiers = make([]AlertNotifierConfig, len(c.API.ExpiryReminder.Notifiers))
+		for i, notifier := range c.API.ExpiryReminder.Notifiers {
+			notifier.URL = mask(notifier.URL)
+			notifier.Secret = mask(notifier.Secret)
+			masked.API.ExpiryReminder.Notifiers[i] = notifier
+		}
+	}
+
+	if c.Alerts.Notifiers != nil {
+		masked.Alerts.Notifiers = make([]AlertNotifierConfig, len(c.Alerts.Notifiers))
+		for i, notifier := range c.Alerts.Notifiers {
+			// Slack incoming webhook URLs embed their credential in the path
+			notifier.URL = mask(notifier.URL)
+			notifier.Secret = mask(notifier.Secret)
+			masked.Alerts.Notifiers[i] = notifier
+		}
+	}
+
+	if c.Outbox.Destinations != nil {
+		masked.Outbox.Destinations = make([]OutboxDestinationConfig, len(c.Outbox.Destinations))
+		for i, destination := range c.Outbox.Destinations {
+			// SIEM collectors commonly carry a token in the URL
+			destination.URL = mask(destination.URL)
+			destination.Secret = mask(destination.Secret)
+			masked.Outbox.Destinations[i] = destination
+		}
+	}
+
+	// Lookup webhooks commonly carry a token in the URL
+	masked.Change.LookupURL = mask(c.Change.LookupURL)
+	masked.Authz.URL = mask(c.Authz.URL)
+	masked.Change.LookupSecret = mask(c.Change.LookupSecret)
+	masked.Authz.Secret = mask(c.Authz.Secret)
+
+	return &masked
+}
+
+// maskHeaders returns a copy of extra headers with their values masked
+func maskHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	masked := make(map[string]string, len(headers))
+	for name, value := range headers {
+		masked[name] = mask(value)
+	}
+	return masked
+}
+
+// mask hides a non-empty secret; empty values stay empty so unset secrets remain visible
+func mask(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return maskedValue
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Top-level keys that compose the configuration instead of mapping to a field
+const (
+	includeKey   = "include"
+	overridesKey = "overrides"
+)
+
+// loadDocument reads a config file and resolves its includes and the overlay
+// for environment, returning the merged document as YAML
+//
+// Included files are merged first in the order listed (paths are relative to the
+// including file), then the including file on top, then overrides.<environment>.
+// Maps are merged key by key; scalars and lists from later sources replace earlier ones.
+func loadDocument(filePath, environment string) ([]byte, error) {
+	data, err := os.ReadFile(filePath) //nolint:gosec // Trusted file path input
+	if err != nil {
+		return nil, err
+	}
+
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// Plain files are decoded as-is so errors keep their original line numbers
+	_, hasInclude := root[includeKey]
+	_, hasOverrides := root[overridesKey]
+	if !hasInclude && !hasOverrides {
+		return data, nil
+	}
+
+	doc, err := resolveIncludes(filePath, root, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+
+	if overrides, ok := doc[overridesKey]; ok {
+		overlays, ok := overrides.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid configuration: %s must be a map of environment names", overridesKey)
+		}
+		if overlay, ok := overlays[environment]; ok && environment != "" {
+			overlayMap, ok := overlay.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid configuration: %s.%s must be a map", overridesKey, environment)
+			}
+			doc = mergeMaps(doc, overlayMap)
+		}
+		delete(doc, overridesKey)
+	}
+
+	return yaml.Marshal(doc)
+}
+
+// fingerprint returns a hash of the configuration file merged with its includes
+// and the TRIGGERMESH_ENV overlay, which changes whenever any of them changes
+func fingerprint(filePath string) (string, error) {
+	data, err := loadDocument(filePath, os.Getenv("TRIGGERMESH_ENV"))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// resolveIncludes merges the files included by root underneath it, recursively
+func resolveIncludes(filePath string, root map[string]interface{}, visiting map[string]bool) (map[string]interface{}, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if visiting[absPath] {
+		return nil, fmt.Errorf("invalid configuration: include cycle at %s", filePath)
+	}
+	visiting[absPath] = true
+	defer delete(visiting, absPath)
+
+	includes, err := includePaths(root[includeKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration in %s: %w", filePath, err)
+	}
+	delete(root, includeKey)
+
+	merged := map[string]interface{}{}
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(filePath), include)
+		}
+
+		data, err := os.ReadFile(include) //nolint:gosec // Paths come from the trusted config file
+		if err != nil {
+			return nil, fmt.Errorf("failed to read included config: %w", err)
+		}
+		var included map[string]interface{}
+		if err := yaml.Unmarshal(data, &included); err != nil {
+			return nil, fmt.Errorf("invalid configuration in %s: %w", include, err)
+		}
+		if included == nil {
+			continue
+		}
+
+		resolved, err := resolveIncludes(include, included, visiting)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeMaps(merged, resolved)
+	}
+
+	return mergeMaps(merged, root), nil
+}
+
+// includePaths validates the include value as a list of file paths
+func includePaths(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be a list of file paths", includeKey)
+	}
+	paths := make([]string, 0, len(items))
+	for i, item := range items {
+		path, ok := item.(string)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("%s[%d] must be a file path", includeKey, i)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// mergeMaps merges src into dst, recursing into nested maps; src wins on conflicts
+func mergeMaps(dst, src map[string]interface{}) map[string]interface{} {
+	for key, srcValue := range src {
+		srcMap, srcIsMap := srcValue.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			dst[key] = mergeMaps(dstMap, srcMap)
+			continue
+		}
+		dst[key] = srcValue
+	}
+	return dst
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"time"
+
+	"triggermesh/internal/logger"
+)
+
+// Watcher polls a configuration file and its includes and calls onChange with the
+// reloaded configuration whenever their merged content changes
+//
+// Polling content rather than watching inotify events handles Kubernetes ConfigMap/Secret
+// volumes, which update through an atomic swap of the ..data symlink
+type Watcher struct {
+	path     string
+	interval time.Duration
+	onChange func(*Config)
+	last     string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewWatcher creates a watcher for the configuration file at path, using its current content as the baseline
+func NewWatcher(path string, interval time.Duration, onChange func(*Config)) (*Watcher, error) {
+	last, err := fingerprint(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Watcher{
+		path:     path,
+		interval: interval,
+		onChange: onChange,
+		last:     last,
+	}, nil
+}
+
+// Start runs the watcher in the background until Stop is called
+func (w *Watcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if _, err := w.RunOnce(); err != nil {
+				logger.Error("Failed to reload configuration, keeping the current one", "error", err, "path", w.path)
+			}
+		}
+	}()
+}
+
+// Stop stops the background watcher
+func (w *Watcher) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+}
+
+// RunOnce checks the configuration for changes and reports whether a new configuration was applied
+// An invalid configuration is reported once and not retried until the files change again
+func (w *Watcher) RunOnce() (bool, error) {
+	current, err := fingerprint(w.path)
+	if err != nil {
+		return false, err
+	}
+	if current == w.last {
+		return false, nil
+	}
+	w.last = current
+
+	cfg, err := Load(w.path)
+	if err != nil {
+		return false, err
+	}
+
+	w.onChange(cfg)
+	return true, nil
+}
@@ -0,0 +1,158 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed five-field cron expression: minute hour day-of-month month day-of-week
+// Fields accept "*", numbers, ranges (1-5), lists (1,15) and steps (*/15, 0-30/10);
+// day-of-week runs from 0 (Sunday) to 6, with 7 also meaning Sunday
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // Bit i is set when value i matches
+
+	// As in classic cron, when both day fields are restricted a day matching either one matches
+	domAny, dowAny bool
+}
+
+// field describes the allowed range of a cron field
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = [5]field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Parse parses a five-field cron expression
+func Parse(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day month weekday)", expr)
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		bits[i] = b
+	}
+
+	// Sunday may be written as 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: parts[2] == "*",
+		dowAny: parts[4] == "*",
+	}, nil
+}
+
+// parseField parses one comma-separated cron field into a bit set
+func parseField(expr string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(expr, ",") {
+		rangeExpr, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %s field: %q", f.name, item)
+			}
+			rangeExpr, step = item[:i], n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangeExpr == "*":
+		case strings.Contains(rangeExpr, "-"):
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil || lo > hi {
+				return 0, fmt.Errorf("invalid range in %s field: %q", f.name, item)
+			}
+		default:
+			n, err := strconv.Atoi(rangeExpr)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value in %s field: %q", f.name, item)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				// "5/15" means from 5 to the end of the range in steps of 15
+				hi = f.max
+			}
+		}
+		if lo < f.min || hi > f.max {
+			return 0, fmt.Errorf("%s field value out of range %d-%d: %q", f.name, f.min, f.max, item)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Matches reports whether the minute of t matches the schedule, in t's location
+func (s *Schedule) Matches(t time.Time) bool {
+	return s.dayMatches(t) && s.hour&(1<<uint(t.Hour())) != 0 && s.minute&(1<<uint(t.Minute())) != 0
+}
+
+// dayMatches reports whether the date of t matches the month and day fields
+func (s *Schedule) dayMatches(t time.Time) bool {
+	if s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowMatch
+	case s.dowAny:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+// Prev returns the latest minute at or before t that matches the schedule, searching back
+// no further than since; ok is false when there is none in that range
+// Times are evaluated in t's location
+func (s *Schedule) Prev(t, since time.Time) (time.Time, bool) {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+
+	for !t.Before(since) {
+		switch {
+		case !s.dayMatches(t):
+			// Jump to the last minute of the previous day
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).Add(-time.Minute)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			// Jump to the last minute of the previous hour
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc).Add(-time.Minute)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(-time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,271 @@
+// Package email sends templated notification emails over SMTP
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/jobmatch"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/outbound"
+	"triggermesh/internal/storage/models"
+)
+
+// funcs are the functions available in email templates
+var funcs = map[string]interface{}{
+	"join": strings.Join,
+}
+
+// BuildCompleted is the data of build_completed templates
+type BuildCompleted struct {
+	Job         string
+	Engine      string
+	BuildID     string
+	Result      string // Engine result, e.g. SUCCESS or FAILURE
+	Succeeded   bool
+	Duration    time.Duration
+	TriggeredAt time.Time
+	FinishedAt  time.Time
+}
+
+// Message is a rendered email
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer renders notification emails and sends them over SMTP
+// Emails are sent in the background; failures are logged and never affect the API
+type Mailer struct {
+	cfg       config.EmailConfig
+	from      *mail.Address
+	to        []string
+	events    map[string]bool
+	templates map[string]templateSet
+	timeout   time.Duration
+	policy    *outbound.Policy
+}
+
+// NewMailer creates a mailer from the email configuration, parsing its templates
+// Connections to the SMTP server are checked against the outbound URL policy, if any
+func NewMailer(cfg config.EmailConfig, policy *outbound.Policy) (*Mailer, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address: %w", err)
+	}
+	to := make([]string, 0, len(cfg.To))
+	for _, recipient := range cfg.To {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient address: %w", err)
+		}
+		to = append(to, address.Address)
+	}
+	templates, err := loadTemplates(cfg.TemplatesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(map[string]bool, len(cfg.Events))
+	for _, event := range cfg.Events {
+		events[event] = true
+	}
+	return &Mailer{
+		cfg:       cfg,
+		from:      from,
+		to:        to,
+		events:    events,
+		templates: templates,
+		timeout:   time.Duration(cfg.Timeout) * time.Second,
+		policy:    policy,
+	}, nil
+}
+
+// BuildCompleted emails the outcome of a finished build if the job is selected
+func (m *Mailer) BuildCompleted(build models.TrackedBuild, outcome models.BuildOutcome) {
+	if !m.events[config.EmailEventBuildCompleted] || (len(m.cfg.Jobs) > 0 && !jobmatch.MatchAny(m.cfg.Jobs, build.JobName)) {
+		return
+	}
+	m.sendAsync(config.EmailEventBuildCompleted, BuildCompleted{
+		Job:         build.JobName,
+		Engine:      build.Engine,
+		BuildID:     build.BuildID,
+		Result:      outcome.Result,
+		Succeeded:   outcome.Succeeded,
+		Duration:    time.Duration(outcome.DurationMS) * time.Millisecond,
+		TriggeredAt: build.TriggeredAt,
+		FinishedAt:  outcome.FinishedAt,
+	})
+}
+
+// KeyRequested emails a new API key request awaiting approval
+func (m *Mailer) KeyRequested(request models.KeyRequest) {
+	if !m.events[config.EmailEventKeyRequested] {
+		return
+	}
+	m.sendAsync(config.EmailEventKeyRequested, request)
+}
+
+// sendAsync renders and sends the email of an event in the background
+func (m *Mailer) sendAsync(event string, data interface{}) {
+	message, err := m.Render(event, data)
+	if err != nil {
+		logger.Error("Failed to render notification email", "error", err, "event", event)
+		return
+	}
+	go func() {
+		if err := m.Send(message); err != nil {
+			logger.Error("Failed to send notification email", "error", err, "event", event, "subject", message.Subject)
+		}
+	}()
+}
+
+// Render renders the templates of an event with the data
+func (m *Mailer) Render(event string, data interface{}) (Message, error) {
+	set, ok := m.templates[event]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email event: %s", event)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := set.subject.Execute(&subject, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render subject: %w", err)
+	}
+	if err := set.text.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render text body: %w", err)
+	}
+	if err := set.html.Execute(&html, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render HTML body: %w", err)
+	}
+	// Header values cannot span lines
+	return Message{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// Send delivers the message to every recipient in one SMTP transaction
+func (m *Mailer) Send(message Message) error {
+	data, err := m.compose(message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	address := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	dialer := &net.Dialer{Timeout: m.timeout}
+	if m.policy != nil {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			return m.policy.CheckAddr(address)
+		}
+	}
+	tlsConfig := &tls.Config{ServerName: m.cfg.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	if m.cfg.TLS == config.EmailTLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	// net/smtp has no context support; the deadline bounds the whole transaction
+	if err := conn.SetDeadline(time.Now().Add(m.timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if m.cfg.TLS == config.EmailTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("SMTP server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if m.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("SMTP server refused the sender: %w", err)
+	}
+	for _, recipient := range m.to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("SMTP server refused recipient %s: %w", recipient, err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP server refused the message: %w", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("SMTP server refused the message: %w", err)
+	}
+	return client.Quit()
+}
+
+// compose builds a multipart/alternative MIME message with quoted-printable text and HTML parts
+func (m *Mailer) compose(message Message, date time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", message.Text},
+		{"text/html; charset=UTF-8", message.HTML},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var data bytes.Buffer
+	fmt.Fprintf(&data, "From: %s\r\n", m.from.String())
+	fmt.Fprintf(&data, "To: %s\r\n", strings.Join(m.cfg.To, ", "))
+	fmt.Fprintf(&data, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", message.Subject))
+	fmt.Fprintf(&data, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	data.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&data, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	data.Write(body.Bytes())
+	return data.Bytes(), nil
+}
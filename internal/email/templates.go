@@ -0,0 +1,118 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	texttemplate "text/template"
+
+	"triggermesh/internal/config"
+)
+
+// templateSet renders the subject, plain text, and HTML bodies of one event
+type templateSet struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// Built-in templates; a file <event>.subject.tmpl, <event>.txt.tmpl, or <event>.html.tmpl in
+// notifications.email.templates_dir replaces the matching one
+var builtinTemplates = map[string][3]string{
+	config.EmailEventBuildCompleted: {
+		`[TriggerMesh] {{.Job}} {{if .Succeeded}}succeeded{{else}}finished: {{.Result}}{{end}}`,
+		`Build {{.BuildID}} of {{.Job}} on {{.Engine}} finished with result {{.Result}}.
+
+Triggered: {{.TriggeredAt.UTC.Format "2006-01-02 15:04:05 MST"}}
+Finished:  {{.FinishedAt.UTC.Format "2006-01-02 15:04:05 MST"}}
+Duration:  {{.Duration}}
+`,
+		`<p>Build <b>{{.BuildID}}</b> of <b>{{.Job}}</b> on {{.Engine}} finished with result <b>{{.Result}}</b>.</p>
+<table>
+<tr><td>Triggered</td><td>{{.TriggeredAt.UTC.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><td>Finished</td><td>{{.FinishedAt.UTC.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><td>Duration</td><td>{{.Duration}}</td></tr>
+</table>
+`,
+	},
+	config.EmailEventKeyRequested: {
+		`[TriggerMesh] API key request #{{.ID}} from {{.RequestedBy}} awaits approval`,
+		`{{.RequestedBy}} requested an API key named {{.Name}}.
+
+Jobs:   {{if .Jobs}}{{join .Jobs ", "}}{{else}}all jobs{{end}}
+Scopes: {{if .Scopes}}{{join .Scopes ", "}}{{else}}none{{end}}
+{{- if .Tenant}}
+Tenant: {{.Tenant}}{{end}}
+{{- if .ExpiresAt}}
+Expires: {{.ExpiresAt.UTC.Format "2006-01-02 15:04:05 MST"}}{{end}}
+{{- if .Reason}}
+Reason: {{.Reason}}{{end}}
+
+Approve with POST /api/v1/keys/requests/{{.ID}}/approve or deny with POST /api/v1/keys/requests/{{.ID}}/deny.
+`,
+		`<p><b>{{.RequestedBy}}</b> requested an API key named <b>{{.Name}}</b>.</p>
+<table>
+<tr><td>Jobs</td><td>{{if .Jobs}}{{join .Jobs ", "}}{{else}}all jobs{{end}}</td></tr>
+<tr><td>Scopes</td><td>{{if .Scopes}}{{join .Scopes ", "}}{{else}}none{{end}}</td></tr>
+{{- if .Tenant}}
+<tr><td>Tenant</td><td>{{.Tenant}}</td></tr>{{end}}
+{{- if .ExpiresAt}}
+<tr><td>Expires</td><td>{{.ExpiresAt.UTC.Format "2006-01-02 15:04:05 MST"}}</td></tr>{{end}}
+{{- if .Reason}}
+<tr><td>Reason</td><td>{{.Reason}}</td></tr>{{end}}
+</table>
+<p>Approve with <code>POST /api/v1/keys/requests/{{.ID}}/approve</code> or deny with <code>POST /api/v1/keys/requests/{{.ID}}/deny</code>.</p>
+`,
+	},
+}
+
+// loadTemplates parses the templates of every event, reading overrides from dir if it is set
+func loadTemplates(dir string) (map[string]templateSet, error) {
+	sets := make(map[string]templateSet, len(builtinTemplates))
+	for event, builtin := range builtinTemplates {
+		var sources [3]string
+		for i, suffix := range []string{".subject.tmpl", ".txt.tmpl", ".html.tmpl"} {
+			source, err := readOverride(dir, event+suffix)
+			if err != nil {
+				return nil, err
+			}
+			if source == "" {
+				source = builtin[i]
+			}
+			sources[i] = source
+		}
+
+		subject, err := texttemplate.New(event + ".subject").Funcs(funcs).Parse(sources[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s subject template: %w", event, err)
+		}
+		text, err := texttemplate.New(event + ".txt").Funcs(funcs).Parse(sources[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s text template: %w", event, err)
+		}
+		html, err := htmltemplate.New(event + ".html").Funcs(funcs).Parse(sources[2])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s HTML template: %w", event, err)
+		}
+		sets[event] = templateSet{subject: subject, text: text, html: html}
+	}
+	return sets, nil
+}
+
+// readOverride returns the content of the template file in dir, or "" if dir is empty or has no such file
+func readOverride(dir, name string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read email template: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,178 @@
+// Package awsengine implements CI engines for AWS CodeBuild projects and CodePipeline pipelines
+// through their JSON APIs, signed with Signature Version 4
+package awsengine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"triggermesh/internal/aws"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/version"
+)
+
+// maxResponseSize bounds the API response read into memory
+const maxResponseSize = 1024 * 1024
+
+// client calls the operations of an AWS JSON 1.1 API
+type client struct {
+	service      string // Signing name and endpoint prefix, e.g. codebuild
+	targetPrefix string // X-Amz-Target prefix, e.g. CodeBuild_20161006
+	region       string
+	endpoint     string
+	creds        aws.Provider
+	http         *http.Client
+}
+
+// newClient creates a client for the service from the engine configuration
+func newClient(service, targetPrefix string, cfg config.AWSEngineConfig) (*client, error) {
+	region := aws.ResolveRegion(cfg.Region, cfg.Profile)
+	if region == "" {
+		return nil, fmt.Errorf("no AWS region configured: set aws.region or AWS_REGION")
+	}
+
+	var creds aws.Provider = aws.NewDefaultProvider(cfg.Profile, region)
+	if cfg.AccessKeyID != "" {
+		creds = aws.StaticProvider{
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+			SessionToken:    cfg.SessionToken,
+		}
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = aws.Endpoint(service, region)
+	}
+
+	return &client{
+		service:      service,
+		targetPrefix: targetPrefix,
+		region:       region,
+		endpoint:     strings.TrimSuffix(endpoint, "/") + "/",
+		creds:        creds,
+		http:         &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+	}, nil
+}
+
+// call invokes an operation with the input, decoding the response into output
+func (c *client) call(ctx context.Context, operation string, input, output interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", operation, err)
+	}
+	creds, err := c.creds.Retrieve(ctx)
+	if err != nil {
+		logger.Error("Failed to get AWS credentials", "service", c.service, "error", err)
+		return engine.NewError(engine.ErrorKindAuth, "authentication failed: no AWS credentials available")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", c.targetPrefix+"."+operation)
+	req.Header.Set("User-Agent", version.UserAgent())
+	aws.SignRequest(req, body, creds, c.region, c.service, time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		code := errorCode(resp, respBody)
+		logger.Error("AWS API request failed", "service", c.service, "operation", operation, "status", resp.Status, "code", code, "body", string(respBody))
+		return formatError(c.service, resp.StatusCode, code)
+	}
+
+	if err := json.Unmarshal(respBody, output); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", operation, err)
+	}
+	return nil
+}
+
+// errorCode returns the AWS error code of a failed response, e.g. ResourceNotFoundException
+// The JSON protocol reports it in __type (possibly prefixed with a namespace) or X-Amzn-ErrorType
+func errorCode(resp *http.Response, body []byte) string {
+	code := resp.Header.Get("X-Amzn-ErrorType")
+	if code == "" {
+		var doc struct {
+			Type string `json:"__type"`
+		}
+		_ = json.Unmarshal(body, &doc)
+		code = doc.Type
+	}
+	if i := strings.LastIndex(code, "#"); i >= 0 {
+		code = code[i+1:]
+	}
+	code, _, _ = strings.Cut(code, ":")
+	return code
+}
+
+// formatError turns an AWS error into a sanitized engine error
+func formatError(service string, statusCode int, code string) error {
+	switch code {
+	case "ResourceNotFoundException", "PipelineNotFoundException", "PipelineExecutionNotFoundException":
+		return engine.NewError(engine.ErrorKindNotFound, "resource not found")
+	case "AccessDeniedException", "UnrecognizedClientException", "InvalidSignatureException",
+		"IncompleteSignature", "ExpiredTokenException", "InvalidClientTokenId", "MissingAuthenticationToken":
+		return engine.NewError(engine.ErrorKindAuth, "access denied: check the AWS credentials and IAM permissions")
+	case "ThrottlingException", "ServiceUnavailableException", "AccountLimitExceededException":
+		return engine.NewError(engine.ErrorKindServer, service+" is throttling requests: please try again later")
+	}
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return engine.NewError(engine.ErrorKindAuth, "access denied: check the AWS credentials and IAM permissions")
+	case statusCode == http.StatusGatewayTimeout:
+		return engine.NewError(engine.ErrorKindTimeout, service+" timed out: please try again later")
+	case statusCode >= 500:
+		return engine.NewError(engine.ErrorKindServer, service+" server error: please try again later")
+	case statusCode == http.StatusBadRequest:
+		return engine.NewError(engine.ErrorKindUnknown, "invalid request")
+	default:
+		return engine.NewError(engine.ErrorKindUnknown, service+" api request failed")
+	}
+}
+
+// sortedKeys returns the parameter names in order, so requests are deterministic
+func sortedKeys(params map[string]string) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// consoleURL returns a link into the AWS console for the region
+func consoleURL(region, path string) string {
+	host := "console.aws.amazon.com"
+	if strings.HasPrefix(region, "cn-") {
+		host = "console.amazonaws.cn"
+	}
+	return fmt.Sprintf("https://%s/%s?region=%s", host, path, region)
+}
+
+// durationMS returns the milliseconds between two epoch-second timestamps, or 0 if either is unset
+func durationMS(start, end float64) int64 {
+	if start == 0 || end == 0 {
+		return 0
+	}
+	return int64((end - start) * 1000)
+}
@@ -0,0 +1,149 @@
+package awsengine
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+)
+
+// codeBuildTargetPrefix versions the CodeBuild API operations
+const codeBuildTargetPrefix = "CodeBuild_20161006"
+
+// CodeBuild starts builds of CodeBuild projects; the job name is the project name and parameters
+// become environment variable overrides
+type CodeBuild struct {
+	client *client
+}
+
+// codeBuildEnvVar is an environment variable override of a build
+type codeBuildEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// codeBuildBuild is the part of a CodeBuild build that TriggerMesh reads
+type codeBuildBuild struct {
+	ID          string  `json:"id"`
+	ProjectName string  `json:"projectName"`
+	BuildStatus string  `json:"buildStatus"` // IN_PROGRESS, SUCCEEDED, FAILED, FAULT, TIMED_OUT, STOPPED
+	StartTime   float64 `json:"startTime"`
+	EndTime     float64 `json:"endTime"`
+}
+
+// NewCodeBuild creates a CodeBuild engine from its configuration
+func NewCodeBuild(cfg config.AWSEngineConfig) (*CodeBuild, error) {
+	c, err := newClient("codebuild", codeBuildTargetPrefix, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &CodeBuild{client: c}, nil
+}
+
+// TriggerBuild starts a build of the project
+// Credential references ("@cred:<id>") become SECRETS_MANAGER variables resolved by CodeBuild
+func (e *CodeBuild) TriggerBuild(jobName string, params map[string]string) (*engine.BuildResult, error) {
+	input := struct {
+		ProjectName                  string            `json:"projectName"`
+		EnvironmentVariablesOverride []codeBuildEnvVar `json:"environmentVariablesOverride,omitempty"`
+	}{ProjectName: jobName}
+	for _, name := range sortedKeys(params) {
+		value := params[name]
+		envVar := codeBuildEnvVar{Name: name, Value: value, Type: "PLAINTEXT"}
+		if id, ok := engine.ParseCredentialRef(value); ok {
+			envVar.Value, envVar.Type = id, "SECRETS_MANAGER"
+		}
+		input.EnvironmentVariablesOverride = append(input.EnvironmentVariablesOverride, envVar)
+	}
+
+	var output struct {
+		Build codeBuildBuild `json:"build"`
+	}
+	if err := e.client.call(context.Background(), "StartBuild", input, &output); err != nil {
+		if engine.Classify(err) == engine.ErrorKindNotFound {
+			err = engine.NewError(engine.ErrorKindNotFound, "job not found")
+		}
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to start CodeBuild build: %v", err),
+		}, err
+	}
+
+	return &engine.BuildResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Successfully started CodeBuild build for project %s", jobName),
+		BuildID:  output.Build.ID,
+		BuildURL: e.buildURL(output.Build),
+	}, nil
+}
+
+// GetBuildStatus returns the status of a build by its CodeBuild ID (project:uuid)
+func (e *CodeBuild) GetBuildStatus(buildID string) (*engine.BuildResult, error) {
+	if buildID == "" {
+		return &engine.BuildResult{
+			Success: false,
+			Message: "Build ID cannot be empty",
+		}, fmt.Errorf("build ID cannot be empty")
+	}
+
+	input := struct {
+		IDs []string `json:"ids"`
+	}{IDs: []string{buildID}}
+	var output struct {
+		Builds []codeBuildBuild `json:"builds"`
+	}
+	if err := e.client.call(context.Background(), "BatchGetBuilds", input, &output); err != nil {
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get CodeBuild build status: %v", err),
+		}, err
+	}
+	// Unknown IDs are reported in buildsNotFound rather than as an error
+	if len(output.Builds) == 0 {
+		err := engine.NewError(engine.ErrorKindNotFound, "build not found")
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get CodeBuild build status: %v", err),
+		}, err
+	}
+
+	build := output.Builds[0]
+	result := &engine.BuildResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Retrieved build status for %s", buildID),
+		BuildID:  build.ID,
+		BuildURL: e.buildURL(build),
+	}
+	switch build.BuildStatus {
+	case "SUCCEEDED":
+		result.Result = engine.ResultSuccess
+	case "FAILED", "FAULT", "TIMED_OUT":
+		result.Result = engine.ResultFailure
+	case "STOPPED":
+		result.Result = engine.ResultAborted
+	default:
+		result.Building = true
+	}
+	if !result.Building {
+		result.BuildDurationMS = durationMS(build.StartTime, build.EndTime)
+	}
+	return result, nil
+}
+
+// Ping checks that CodeBuild accepts the credentials
+func (e *CodeBuild) Ping() error {
+	var output struct{}
+	return e.client.call(context.Background(), "ListProjects", struct{}{}, &output)
+}
+
+// buildURL returns the console page of a build
+func (e *CodeBuild) buildURL(build codeBuildBuild) string {
+	if build.ID == "" || build.ProjectName == "" {
+		return ""
+	}
+	return consoleURL(e.client.region, fmt.Sprintf("codesuite/codebuild/projects/%s/build/%s/log",
+		url.PathEscape(build.ProjectName), url.PathEscape(build.ID)))
+}
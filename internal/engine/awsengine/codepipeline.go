@@ -0,0 +1,132 @@
+package awsengine
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+)
+
+// codePipelineTargetPrefix versions the CodePipeline API operations
+const codePipelineTargetPrefix = "CodePipeline_20150709"
+
+// CodePipeline starts executions of CodePipeline pipelines; the job name is the pipeline name and
+// parameters become pipeline variables, which the pipeline must declare
+// Build IDs are pipeline:executionId, since execution status lookups need both
+type CodePipeline struct {
+	client *client
+}
+
+// codePipelineVariable is a pipeline-level variable of an execution
+type codePipelineVariable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// NewCodePipeline creates a CodePipeline engine from its configuration
+func NewCodePipeline(cfg config.AWSEngineConfig) (*CodePipeline, error) {
+	c, err := newClient("codepipeline", codePipelineTargetPrefix, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &CodePipeline{client: c}, nil
+}
+
+// TriggerBuild starts an execution of the pipeline
+// Credential references ("@cred:<id>") pass the bare ID for the pipeline to resolve
+func (e *CodePipeline) TriggerBuild(jobName string, params map[string]string) (*engine.BuildResult, error) {
+	input := struct {
+		Name      string                 `json:"name"`
+		Variables []codePipelineVariable `json:"variables,omitempty"`
+	}{Name: jobName}
+	for _, name := range sortedKeys(params) {
+		value := params[name]
+		if id, ok := engine.ParseCredentialRef(value); ok {
+			value = id
+		}
+		input.Variables = append(input.Variables, codePipelineVariable{Name: name, Value: value})
+	}
+
+	var output struct {
+		PipelineExecutionID string `json:"pipelineExecutionId"`
+	}
+	if err := e.client.call(context.Background(), "StartPipelineExecution", input, &output); err != nil {
+		if engine.Classify(err) == engine.ErrorKindNotFound {
+			err = engine.NewError(engine.ErrorKindNotFound, "job not found")
+		}
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to start CodePipeline execution: %v", err),
+		}, err
+	}
+
+	return &engine.BuildResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Successfully started CodePipeline execution for pipeline %s", jobName),
+		BuildID:  jobName + ":" + output.PipelineExecutionID,
+		BuildURL: e.executionURL(jobName, output.PipelineExecutionID),
+	}, nil
+}
+
+// GetBuildStatus returns the status of an execution by its build ID (pipeline:executionId)
+func (e *CodePipeline) GetBuildStatus(buildID string) (*engine.BuildResult, error) {
+	pipeline, executionID, ok := strings.Cut(buildID, ":")
+	if !ok || pipeline == "" || executionID == "" {
+		return &engine.BuildResult{
+			Success: false,
+			Message: "Invalid build ID format, expected pipeline:executionId",
+		}, fmt.Errorf("invalid build ID format: %s", buildID)
+	}
+
+	input := struct {
+		PipelineName        string `json:"pipelineName"`
+		PipelineExecutionID string `json:"pipelineExecutionId"`
+	}{PipelineName: pipeline, PipelineExecutionID: executionID}
+	var output struct {
+		PipelineExecution struct {
+			Status string `json:"status"` // InProgress, Stopping, Stopped, Succeeded, Superseded, Failed, Cancelled
+		} `json:"pipelineExecution"`
+	}
+	if err := e.client.call(context.Background(), "GetPipelineExecution", input, &output); err != nil {
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get CodePipeline execution status: %v", err),
+		}, err
+	}
+
+	result := &engine.BuildResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Retrieved build status for %s", buildID),
+		BuildID:  buildID,
+		BuildURL: e.executionURL(pipeline, executionID),
+	}
+	switch output.PipelineExecution.Status {
+	case "Succeeded":
+		result.Result = engine.ResultSuccess
+	case "Failed":
+		result.Result = engine.ResultFailure
+	case "Stopped", "Superseded", "Cancelled":
+		result.Result = engine.ResultAborted
+	default:
+		result.Building = true
+	}
+	return result, nil
+}
+
+// Ping checks that CodePipeline accepts the credentials
+func (e *CodePipeline) Ping() error {
+	var output struct{}
+	return e.client.call(context.Background(), "ListPipelines", struct{}{}, &output)
+}
+
+// executionURL returns the console page of an execution
+func (e *CodePipeline) executionURL(pipeline, executionID string) string {
+	if executionID == "" {
+		return ""
+	}
+	return consoleURL(e.client.region, fmt.Sprintf("codesuite/codepipeline/pipelines/%s/executions/%s/timeline",
+		url.PathEscape(pipeline), url.PathEscape(executionID)))
+}
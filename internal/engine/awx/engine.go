@@ -0,0 +1,240 @@
+// Package awx implements a CI engine that launches AWX / Ansible Tower job templates
+package awx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/version"
+)
+
+// maxResponseSize bounds the AWX response read into memory
+const maxResponseSize = 1024 * 1024
+
+// Engine launches job templates; job names are template names or numeric IDs and parameters
+// become extra_vars. Build IDs are AWX job IDs
+// Templates must enable "Prompt on launch" for variables, otherwise AWX ignores extra_vars
+type Engine struct {
+	url      string
+	token    string
+	username string
+	password string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// New creates an AWX engine from its configuration
+func New(cfg config.AWXEngineConfig) *Engine {
+	return &Engine{
+		url:      strings.TrimSuffix(cfg.URL, "/"),
+		token:    cfg.Token,
+		username: cfg.Username,
+		password: cfg.Password,
+		headers:  cfg.Headers,
+		client:   &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+	}
+}
+
+// TriggerBuild launches the job template with the parameters as extra_vars
+// Credential references ("@cred:<id>") pass the bare ID for the playbook to resolve
+func (e *Engine) TriggerBuild(jobName string, params map[string]string) (*engine.BuildResult, error) {
+	ctx := context.Background()
+	templateID, err := e.templateID(ctx, jobName)
+	if err != nil {
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to launch AWX job template: %v", err),
+		}, err
+	}
+
+	extraVars := make(map[string]string, len(params))
+	for name, value := range params {
+		if id, ok := engine.ParseCredentialRef(value); ok {
+			value = id
+		}
+		extraVars[name] = value
+	}
+	input := map[string]interface{}{"extra_vars": extraVars}
+
+	var output struct {
+		Job           int64                  `json:"job"`
+		IgnoredFields map[string]interface{} `json:"ignored_fields"`
+	}
+	path := fmt.Sprintf("/api/v2/job_templates/%d/launch/", templateID)
+	if err := e.do(ctx, http.MethodPost, path, input, &output, true); err != nil {
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to launch AWX job template: %v", err),
+		}, err
+	}
+	if _, ignored := output.IgnoredFields["extra_vars"]; ignored && len(extraVars) > 0 {
+		logger.Warn("AWX ignored extra_vars; enable prompt on launch for variables", "job", jobName, "template_id", templateID)
+	}
+
+	buildID := strconv.FormatInt(output.Job, 10)
+	return &engine.BuildResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Successfully launched AWX job template %s", jobName),
+		BuildID:  buildID,
+		BuildURL: e.jobURL(buildID),
+	}, nil
+}
+
+// GetBuildStatus returns the status of an AWX job
+func (e *Engine) GetBuildStatus(buildID string) (*engine.BuildResult, error) {
+	if _, err := strconv.ParseInt(buildID, 10, 64); err != nil {
+		return &engine.BuildResult{
+			Success: false,
+			Message: "Invalid build ID format, expected an AWX job ID",
+		}, fmt.Errorf("invalid build ID format: %s", buildID)
+	}
+
+	var job struct {
+		Status  string  `json:"status"`  // new, pending, waiting, running, successful, failed, error, canceled
+		Elapsed float64 `json:"elapsed"` // Seconds
+	}
+	if err := e.do(context.Background(), http.MethodGet, "/api/v2/jobs/"+buildID+"/", nil, &job, false); err != nil {
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get AWX job status: %v", err),
+		}, err
+	}
+
+	result := &engine.BuildResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Retrieved build status for %s", buildID),
+		BuildID:  buildID,
+		BuildURL: e.jobURL(buildID),
+	}
+	switch job.Status {
+	case "successful":
+		result.Result = engine.ResultSuccess
+	case "failed", "error":
+		result.Result = engine.ResultFailure
+	case "canceled":
+		result.Result = engine.ResultAborted
+	default:
+		result.Building = true
+	}
+	if !result.Building {
+		result.BuildDurationMS = int64(job.Elapsed * 1000)
+	}
+	return result, nil
+}
+
+// Ping checks that AWX is reachable and accepts the credentials
+func (e *Engine) Ping() error {
+	var me map[string]interface{}
+	return e.do(context.Background(), http.MethodGet, "/api/v2/me/", nil, &me, false)
+}
+
+// templateID returns the ID of a job template given by ID or by name
+func (e *Engine) templateID(ctx context.Context, jobName string) (int64, error) {
+	if id, err := strconv.ParseInt(jobName, 10, 64); err == nil && id > 0 {
+		return id, nil
+	}
+
+	var output struct {
+		Results []struct {
+			ID int64 `json:"id"`
+		} `json:"results"`
+	}
+	if err := e.do(ctx, http.MethodGet, "/api/v2/job_templates/?name="+url.QueryEscape(jobName), nil, &output, false); err != nil {
+		return 0, err
+	}
+	if len(output.Results) == 0 {
+		return 0, engine.NewError(engine.ErrorKindNotFound, "job not found")
+	}
+	return output.Results[0].ID, nil
+}
+
+// jobURL returns the AWX UI page of a job's output
+func (e *Engine) jobURL(buildID string) string {
+	return e.url + "/#/jobs/playbook/" + buildID + "/output"
+}
+
+// do sends a request to the AWX API and decodes the JSON response into output
+// On launch requests a 404 means the job template does not exist
+func (e *Engine) do(ctx context.Context, method, path string, input, output interface{}, launch bool) error {
+	var body io.Reader
+	if input != nil {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, e.url+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range e.headers {
+		req.Header.Set(name, value)
+	}
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	} else if e.username != "" {
+		req.SetBasicAuth(e.username, e.password)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logger.Error("AWX API request failed", "path", path, "status", resp.Status, "body", string(respBody))
+		if launch && resp.StatusCode == http.StatusNotFound {
+			return engine.NewError(engine.ErrorKindNotFound, "job not found")
+		}
+		return formatError(resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(respBody, output); err != nil {
+		return fmt.Errorf("failed to decode AWX response: %w", err)
+	}
+	return nil
+}
+
+// formatError turns an AWX error status into a sanitized engine error
+func formatError(statusCode int) error {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return engine.NewError(engine.ErrorKindAuth, "authentication failed: invalid credentials")
+	case http.StatusForbidden:
+		return engine.NewError(engine.ErrorKindAuth, "access denied: insufficient permissions")
+	case http.StatusNotFound:
+		return engine.NewError(engine.ErrorKindNotFound, "resource not found")
+	case http.StatusBadRequest:
+		// AWX rejects launches missing required survey answers or credentials with 400
+		return engine.NewError(engine.ErrorKindUnknown, "invalid request: the job template rejected the launch")
+	case http.StatusGatewayTimeout:
+		return engine.NewError(engine.ErrorKindTimeout, "awx server timed out: please try again later")
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return engine.NewError(engine.ErrorKindServer, "awx server error: please try again later")
+	default:
+		return engine.NewError(engine.ErrorKindUnknown, "awx api request failed")
+	}
+}
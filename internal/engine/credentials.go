@@ -0,0 +1,30 @@
+package engine
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CredentialRefPrefix marks a parameter value that references a credential stored in the CI engine,
+// e.g. "@cred:deploy-key"; the secret itself never transits TriggerMesh
+const CredentialRefPrefix = "@cred:"
+
+// credentialIDRegex validates referenced credential IDs (Jenkins IDs are names or UUIDs)
+var credentialIDRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,127}$`)
+
+// IsCredentialRef reports whether a parameter value is a credential reference, valid or not
+func IsCredentialRef(value string) bool {
+	return strings.HasPrefix(value, CredentialRefPrefix)
+}
+
+// ParseCredentialRef returns the credential ID of a well-formed credential reference
+func ParseCredentialRef(value string) (string, bool) {
+	if !IsCredentialRef(value) {
+		return "", false
+	}
+	id := strings.TrimPrefix(value, CredentialRefPrefix)
+	if !credentialIDRegex.MatchString(id) {
+		return "", false
+	}
+	return id, true
+}
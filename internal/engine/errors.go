@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// ErrorKind classifies why a CI engine call failed
+type ErrorKind string
+
+const (
+	// ErrorKindUnknown is used for failures that could not be classified
+	ErrorKindUnknown ErrorKind = "unknown"
+	// ErrorKindAuth means the engine rejected TriggerMesh's credentials
+	ErrorKindAuth ErrorKind = "auth"
+	// ErrorKindNotFound means the job or build does not exist on the engine
+	ErrorKindNotFound ErrorKind = "not_found"
+	// ErrorKindTimeout means the engine did not answer in time
+	ErrorKindTimeout ErrorKind = "timeout"
+	// ErrorKindServer means the engine answered with a server-side error
+	ErrorKindServer ErrorKind = "server"
+	// ErrorKindCircuitOpen means the engine was not called because its circuit breaker is open
+	ErrorKindCircuitOpen ErrorKind = "circuit_open"
+)
+
+// Sentinel errors matched by errors.Is against an *Error of the corresponding kind
+var (
+	// ErrJobNotFound is returned when the job or build does not exist on the engine
+	ErrJobNotFound = errors.New("job not found")
+	// ErrAuth is returned when the engine rejects TriggerMesh's credentials
+	ErrAuth = errors.New("engine authentication failed")
+	// ErrTimeout is returned when the engine does not answer in time
+	ErrTimeout = errors.New("engine request timed out")
+	// ErrServer is returned when the engine answers with a server-side error
+	ErrServer = errors.New("engine server error")
+	// ErrCircuitOpen is returned when the engine is not called because its circuit breaker is open
+	ErrCircuitOpen = errors.New("engine circuit breaker open")
+)
+
+// Error is a sanitized engine failure whose message is safe to return to API clients
+type Error struct {
+	Kind    ErrorKind
+	Message string
+}
+
+// NewError creates a new engine error of the given kind
+func NewError(kind ErrorKind, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Message: message,
+	}
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the sentinel error for the error's kind so errors.Is works on engine errors
+func (e *Error) Unwrap() error {
+	switch e.Kind {
+	case ErrorKindNotFound:
+		return ErrJobNotFound
+	case ErrorKindAuth:
+		return ErrAuth
+	case ErrorKindTimeout:
+		return ErrTimeout
+	case ErrorKindServer:
+		return ErrServer
+	case ErrorKindCircuitOpen:
+		return ErrCircuitOpen
+	default:
+		return nil
+	}
+}
+
+// Code returns a stable, machine-readable error code for the kind
+func (k ErrorKind) Code() string {
+	switch k {
+	case ErrorKindNotFound:
+		return "JOB_NOT_FOUND"
+	case ErrorKindAuth:
+		return "ENGINE_AUTH_FAILED"
+	case ErrorKindTimeout:
+		return "ENGINE_TIMEOUT"
+	case ErrorKindServer:
+		return "ENGINE_UNAVAILABLE"
+	case ErrorKindCircuitOpen:
+		return "ENGINE_CIRCUIT_OPEN"
+	default:
+		return "ENGINE_ERROR"
+	}
+}
+
+// Classify returns the kind of the given engine error
+// Timeouts are detected from context deadlines and network errors, everything else
+// relies on the engine returning an *Error
+func Classify(err error) ErrorKind {
+	if err == nil {
+		return ErrorKindUnknown
+	}
+
+	var engineErr *Error
+	if errors.As(err, &engineErr) {
+		return engineErr.Kind
+	}
+
+	// Engines may also wrap the sentinel errors directly
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return ErrorKindNotFound
+	case errors.Is(err, ErrAuth):
+		return ErrorKindAuth
+	case errors.Is(err, ErrTimeout):
+		return ErrorKindTimeout
+	case errors.Is(err, ErrServer):
+		return ErrorKindServer
+	case errors.Is(err, ErrCircuitOpen):
+		return ErrorKindCircuitOpen
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorKindTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorKindTimeout
+	}
+
+	return ErrorKindUnknown
+}
@@ -0,0 +1,138 @@
+// Package fake implements a CI engine that simulates builds without a backend, for staging
+// environments and load tests
+package fake
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+)
+
+// Engine simulates builds; every job exists and build IDs are jobName/number, numbered per job
+// from 1. Latencies, trigger failures, and build outcomes are drawn from a seeded random source,
+// so a run with the same seed and request order behaves the same
+type Engine struct {
+	latency          time.Duration
+	jitter           time.Duration
+	failureRate      float64
+	buildDuration    time.Duration
+	buildFailureRate float64
+
+	mu      sync.Mutex
+	rand    *rand.Rand
+	numbers map[string]int64  // Last build number per job
+	builds  map[string]*build // Keyed by build ID
+}
+
+// build is a simulated build
+type build struct {
+	started time.Time
+	result  string // Outcome reported once the build duration has passed
+}
+
+// New creates a fake engine from its configuration
+func New(cfg config.FakeEngineConfig) *Engine {
+	return &Engine{
+		latency:          time.Duration(cfg.Latency) * time.Millisecond,
+		jitter:           time.Duration(cfg.Jitter) * time.Millisecond,
+		failureRate:      cfg.FailureRate,
+		buildDuration:    time.Duration(cfg.BuildDuration) * time.Second,
+		buildFailureRate: cfg.BuildFailureRate,
+		rand:             rand.New(rand.NewSource(cfg.Seed)),
+		numbers:          make(map[string]int64),
+		builds:           make(map[string]*build),
+	}
+}
+
+// TriggerBuild starts a simulated build of the job, or fails with a server error at the failure rate
+func (e *Engine) TriggerBuild(jobName string, params map[string]string) (*engine.BuildResult, error) {
+	e.mu.Lock()
+	delay := e.delay()
+	failed := e.rand.Float64() < e.failureRate
+	var buildID string
+	if !failed {
+		e.numbers[jobName]++
+		buildID = jobName + "/" + strconv.FormatInt(e.numbers[jobName], 10)
+		result := engine.ResultSuccess
+		if e.rand.Float64() < e.buildFailureRate {
+			result = engine.ResultFailure
+		}
+		e.builds[buildID] = &build{started: time.Now().Add(delay), result: result}
+	}
+	e.mu.Unlock()
+
+	time.Sleep(delay)
+	if failed {
+		err := engine.NewError(engine.ErrorKindServer, "fake engine error: simulated trigger failure")
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to trigger build: %v", err),
+		}, err
+	}
+	return &engine.BuildResult{
+		Success: true,
+		Message: fmt.Sprintf("Successfully triggered fake build of %s", jobName),
+		BuildID: buildID,
+	}, nil
+}
+
+// GetBuildStatus reports a simulated build as running until the build duration has passed
+func (e *Engine) GetBuildStatus(buildID string) (*engine.BuildResult, error) {
+	if i := strings.LastIndex(buildID, "/"); i <= 0 || i == len(buildID)-1 {
+		return &engine.BuildResult{
+			Success: false,
+			Message: "Invalid build ID format, expected jobName/buildNumber",
+		}, fmt.Errorf("invalid build ID format: %s", buildID)
+	}
+
+	e.mu.Lock()
+	delay := e.delay()
+	b, ok := e.builds[buildID]
+	e.mu.Unlock()
+
+	time.Sleep(delay)
+	if !ok {
+		err := engine.NewError(engine.ErrorKindNotFound, "build not found")
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get build status: %v", err),
+		}, err
+	}
+
+	result := &engine.BuildResult{
+		Success: true,
+		Message: fmt.Sprintf("Retrieved build status for %s", buildID),
+		BuildID: buildID,
+	}
+	if elapsed := time.Since(b.started); elapsed < e.buildDuration {
+		result.Building = true
+	} else {
+		result.Result = b.result
+		result.BuildDurationMS = e.buildDuration.Milliseconds()
+	}
+	return result, nil
+}
+
+// Ping always succeeds after the configured latency
+func (e *Engine) Ping() error {
+	e.mu.Lock()
+	delay := e.delay()
+	e.mu.Unlock()
+
+	time.Sleep(delay)
+	return nil
+}
+
+// delay draws the latency of a call; e.mu must be held
+func (e *Engine) delay() time.Duration {
+	if e.jitter <= 0 {
+		return e.latency
+	}
+	return e.latency + time.Duration(e.rand.Int63n(int64(e.jitter)+1))
+}
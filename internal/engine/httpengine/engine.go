@@ -0,0 +1,298 @@
+// Package httpengine implements a CI engine for REST CI systems described declaratively in
+// the configuration: request templates for triggering a build and reading its status, and
+// JSONPaths that pick the build ID, URL, and state out of the responses
+package httpengine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/jsonpath"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/outbound"
+	"triggermesh/internal/version"
+)
+
+// maxResponseSize bounds the engine response read into memory
+const maxResponseSize = 1024 * 1024
+
+// templateFuncs are available to URL and body templates in addition to the text/template built-ins
+var templateFuncs = template.FuncMap{
+	// json renders a value as JSON, e.g. {"ref": {{json .Params.BRANCH}}}
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// templateData is the data URL and body templates are executed with
+type templateData struct {
+	Job     string
+	Params  map[string]string
+	BuildID string
+}
+
+// Engine triggers builds on a REST CI system described by an HTTPEngineConfig
+type Engine struct {
+	name       string
+	client     *http.Client
+	authHeader string
+	token      string
+	headers    map[string]string
+
+	triggerMethod string
+	triggerURL    *template.Template
+	triggerBody   *template.Template // nil sends no body
+	buildIDPath   *jsonpath.Path
+	buildURLPath  *jsonpath.Path // nil when not configured
+
+	statusMethod  string
+	statusURL     *template.Template // nil when build status is not configured
+	statePath     *jsonpath.Path
+	statusURLPath *jsonpath.Path
+	results       map[string]string
+}
+
+// New creates an engine from its configuration; name identifies it in logs and messages
+// Requests are subject to the outbound policy, since their URLs are rendered from job names and parameters
+func New(name string, cfg config.HTTPEngineConfig, policy *outbound.Policy) (*Engine, error) {
+	e := &Engine{
+		name:          name,
+		client:        policy.Client(time.Duration(cfg.Timeout) * time.Second),
+		authHeader:    cfg.AuthHeader,
+		token:         cfg.Token,
+		headers:       cfg.Headers,
+		triggerMethod: cfg.Trigger.Method,
+		statusMethod:  cfg.Status.Method,
+		results:       cfg.Status.Results,
+	}
+
+	var err error
+	if e.triggerURL, err = parseTemplate("trigger.url", cfg.Trigger.URL); err != nil {
+		return nil, err
+	}
+	if cfg.Trigger.Body != "" {
+		if e.triggerBody, err = parseTemplate("trigger.body", cfg.Trigger.Body); err != nil {
+			return nil, err
+		}
+	}
+	if e.buildIDPath, err = jsonpath.Parse(cfg.Trigger.BuildIDPath); err != nil {
+		return nil, fmt.Errorf("invalid trigger.build_id_path: %w", err)
+	}
+	if e.buildURLPath, err = parseOptionalPath(cfg.Trigger.BuildURLPath); err != nil {
+		return nil, fmt.Errorf("invalid trigger.build_url_path: %w", err)
+	}
+
+	if cfg.Status.URL != "" {
+		if e.statusURL, err = parseTemplate("status.url", cfg.Status.URL); err != nil {
+			return nil, err
+		}
+		if e.statePath, err = jsonpath.Parse(cfg.Status.StatePath); err != nil {
+			return nil, fmt.Errorf("invalid status.state_path: %w", err)
+		}
+		if e.statusURLPath, err = parseOptionalPath(cfg.Status.URLPath); err != nil {
+			return nil, fmt.Errorf("invalid status.url_path: %w", err)
+		}
+	}
+	return e, nil
+}
+
+// parseTemplate parses a URL or body template; parameters missing from the request render empty
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// parseOptionalPath parses a JSONPath that may be left empty
+func parseOptionalPath(expr string) (*jsonpath.Path, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return jsonpath.Parse(expr)
+}
+
+// TriggerBuild starts a build by sending the configured trigger request
+func (e *Engine) TriggerBuild(jobName string, params map[string]string) (*engine.BuildResult, error) {
+	if params == nil {
+		params = map[string]string{}
+	}
+	data := templateData{Job: jobName, Params: resolveCredentialRefs(params)}
+
+	doc, err := e.do(context.Background(), e.triggerMethod, e.triggerURL, e.triggerBody, data, true)
+	if err != nil {
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to trigger %s build: %v", e.name, err),
+		}, err
+	}
+
+	buildID, err := e.buildIDPath.LookupString(doc)
+	if err != nil {
+		logger.Error("Engine response has no build ID", "engine", e.name, "error", err)
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to trigger %s build: no build ID in the response", e.name),
+		}, engine.NewError(engine.ErrorKindUnknown, fmt.Sprintf("%s returned no build ID", e.name))
+	}
+
+	result := &engine.BuildResult{
+		Success: true,
+		Message: fmt.Sprintf("Successfully triggered %s build for job %s", e.name, jobName),
+		BuildID: buildID,
+	}
+	if e.buildURLPath != nil {
+		result.BuildURL, _ = e.buildURLPath.LookupString(doc)
+	}
+	return result, nil
+}
+
+// GetBuildStatus reads a build's state with the configured status request
+// States listed in the results mapping are final; any other state means the build is running
+func (e *Engine) GetBuildStatus(buildID string) (*engine.BuildResult, error) {
+	if buildID == "" {
+		return &engine.BuildResult{
+			Success: false,
+			Message: "Build ID cannot be empty",
+		}, fmt.Errorf("build ID cannot be empty")
+	}
+	if e.statusURL == nil {
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Build status is not configured for %s", e.name),
+		}, engine.NewError(engine.ErrorKindUnknown, fmt.Sprintf("build status is not configured for %s", e.name))
+	}
+
+	doc, err := e.do(context.Background(), e.statusMethod, e.statusURL, nil, templateData{BuildID: buildID, Params: map[string]string{}}, false)
+	if err != nil {
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get %s build status: %v", e.name, err),
+		}, err
+	}
+
+	state, err := e.statePath.LookupString(doc)
+	if err != nil {
+		logger.Error("Engine response has no build state", "engine", e.name, "error", err)
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get %s build status: no state in the response", e.name),
+		}, engine.NewError(engine.ErrorKindUnknown, fmt.Sprintf("%s returned no build state", e.name))
+	}
+
+	result := &engine.BuildResult{
+		Success: true,
+		Message: fmt.Sprintf("Retrieved build status for %s", buildID),
+		BuildID: buildID,
+	}
+	if mapped, ok := e.results[state]; ok {
+		result.Result = mapped
+	} else {
+		result.Building = true
+	}
+	if e.statusURLPath != nil {
+		result.BuildURL, _ = e.statusURLPath.LookupString(doc)
+	}
+	return result, nil
+}
+
+// do renders and sends a request, returning the decoded JSON response
+// On trigger requests a 404 means the job does not exist
+func (e *Engine) do(ctx context.Context, method string, urlTemplate, bodyTemplate *template.Template, data templateData, trigger bool) (interface{}, error) {
+	var target strings.Builder
+	if err := urlTemplate.Execute(&target, data); err != nil {
+		return nil, fmt.Errorf("failed to render request URL: %w", err)
+	}
+	var body io.Reader
+	if bodyTemplate != nil {
+		var buf bytes.Buffer
+		if err := bodyTemplate.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render request body: %w", err)
+		}
+		body = &buf
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range e.headers {
+		req.Header.Set(name, value)
+	}
+	if e.token != "" {
+		req.Header.Set(e.authHeader, e.token)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		if outbound.IsDenied(err) {
+			logger.Warn("Engine request blocked by outbound policy", "engine", e.name, "error", err)
+			return nil, engine.NewError(engine.ErrorKindUnknown, e.name+" request blocked by the outbound URL policy")
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logger.Error("Engine API request failed", "engine", e.name, "status", resp.Status, "body", string(respBody))
+		if trigger && resp.StatusCode == http.StatusNotFound {
+			return nil, engine.NewError(engine.ErrorKindNotFound, "job not found")
+		}
+		return nil, formatError(e.name, resp.StatusCode)
+	}
+
+	return jsonpath.Decode(respBody)
+}
+
+// formatError turns an HTTP error status into a sanitized engine error
+func formatError(name string, statusCode int) error {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return engine.NewError(engine.ErrorKindAuth, "authentication failed: invalid credentials")
+	case http.StatusForbidden:
+		return engine.NewError(engine.ErrorKindAuth, "access denied: insufficient permissions")
+	case http.StatusNotFound:
+		return engine.NewError(engine.ErrorKindNotFound, "resource not found")
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return engine.NewError(engine.ErrorKindUnknown, "invalid request")
+	case http.StatusGatewayTimeout:
+		return engine.NewError(engine.ErrorKindTimeout, name+" server timed out: please try again later")
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return engine.NewError(engine.ErrorKindServer, name+" server error: please try again later")
+	default:
+		return engine.NewError(engine.ErrorKindUnknown, name+" api request failed")
+	}
+}
+
+// resolveCredentialRefs replaces "@cred:<id>" values with the bare credential ID, which the
+// CI system resolves itself
+func resolveCredentialRefs(params map[string]string) map[string]string {
+	resolved := make(map[string]string, len(params))
+	for key, value := range params {
+		if id, ok := engine.ParseCredentialRef(value); ok {
+			value = id
+		}
+		resolved[key] = value
+	}
+	return resolved
+}
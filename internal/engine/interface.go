@@ -1,13 +1,29 @@
 package engine
 
+import "time"
+
 // BuildResult represents the result of a CI build trigger
 type BuildResult struct {
 	Success  bool   `json:"success"`
 	BuildID  string `json:"build_id,omitempty"`
 	BuildURL string `json:"build_url,omitempty"`
 	Message  string `json:"message"`
+	QueueID  string `json:"queue_id,omitempty"` // Queue item of a trigger that has not started a build yet
+
+	// Set by GetBuildStatus when the engine reports them
+	Building        bool   `json:"building,omitempty"`          // Whether the build is still running
+	Result          string `json:"result,omitempty"`            // Final outcome (SUCCESS, FAILURE, UNSTABLE, ABORTED) once finished
+	BuildDurationMS int64  `json:"build_duration_ms,omitempty"` // Build duration once finished
 }
 
+// Build results reported in BuildResult.Result
+const (
+	ResultSuccess  = "SUCCESS"
+	ResultFailure  = "FAILURE"
+	ResultUnstable = "UNSTABLE"
+	ResultAborted  = "ABORTED"
+)
+
 // CIEngine is an interface for CI engines
 type CIEngine interface {
 	// TriggerBuild triggers a build for the given job with the provided parameters
@@ -16,3 +32,142 @@ type CIEngine interface {
 	// GetBuildStatus returns the status of a build by its ID
 	GetBuildStatus(buildID string) (*BuildResult, error)
 }
+
+// Cause describes on whose behalf a build is triggered
+type Cause struct {
+	Actor     string // API client name, or key fingerprint (key:...) for unnamed keys
+	RequestID string // Request ID of the trigger; empty if unknown
+}
+
+// String returns the cause as engines display it, e.g. "Started by TriggerMesh on behalf of ci (request 42)"
+func (c Cause) String() string {
+	text := "Started by TriggerMesh on behalf of " + c.Actor
+	if c.RequestID != "" {
+		text += " (request " + c.RequestID + ")"
+	}
+	return text
+}
+
+// CauseTriggerer is implemented by engines that can record on whose behalf a build was triggered
+type CauseTriggerer interface {
+	// TriggerBuildWithCause triggers a build like TriggerBuild and attaches the cause to it
+	TriggerBuildWithCause(jobName string, params map[string]string, cause Cause) (*BuildResult, error)
+}
+
+// QueueResolver is implemented by engines that queue triggers before starting a build
+type QueueResolver interface {
+	// ResolveQueuedBuild returns the ID of the build started for a queue item, or "" while it waits
+	ResolveQueuedBuild(queueID string) (string, error)
+}
+
+// JobInfo describes a job discovered on a CI engine
+type JobInfo struct {
+	Name   string `json:"name"` // Full job name including folders (folder/job)
+	URL    string `json:"url,omitempty"`
+	Folder bool   `json:"folder,omitempty"` // True when the entry is a folder containing other jobs
+	Status string `json:"status,omitempty"` // Engine-specific last build status (e.g. Jenkins color)
+}
+
+// JobFilter narrows job discovery
+type JobFilter struct {
+	View   string // Only jobs in this view
+	Folder string // Only jobs directly inside this folder (folder/subfolder)
+}
+
+// JobLister is implemented by engines that support job discovery
+type JobLister interface {
+	// ListJobs returns the jobs matching the filter
+	ListJobs(filter JobFilter) ([]JobInfo, error)
+}
+
+// BuildInfo summarizes a recent build of a job
+type BuildInfo struct {
+	Number     int64      `json:"number"`
+	BuildID    string     `json:"build_id"` // jobName/buildNumber, usable with the build status endpoint
+	URL        string     `json:"url,omitempty"`
+	Building   bool       `json:"building"`
+	Result     string     `json:"result,omitempty"` // Final outcome once finished (SUCCESS, FAILURE, ...)
+	DurationMS int64      `json:"duration_ms"`      // Build duration once finished
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// BuildLister is implemented by engines that can list the recent builds of a job
+type BuildLister interface {
+	// ListBuilds returns up to limit builds of the job, newest first
+	ListBuilds(jobName string, limit int) ([]BuildInfo, error)
+}
+
+// BuildPager is implemented by engines that can page through older builds of a job
+type BuildPager interface {
+	// ListBuildsBefore returns up to limit builds of the job numbered below before, newest first
+	ListBuildsBefore(jobName string, before int64, limit int) ([]BuildInfo, error)
+}
+
+// Pinger is implemented by engines that can check connectivity to their backend
+type Pinger interface {
+	// Ping verifies the engine is reachable and accepts the configured credentials
+	Ping() error
+}
+
+// NodeInfo describes a build agent of a CI engine
+type NodeInfo struct {
+	Name          string `json:"name"`
+	Online        bool   `json:"online"`
+	Executors     int    `json:"executors"`
+	BusyExecutors int    `json:"busy_executors"`
+}
+
+// Overview summarizes the build queue and executor load of a CI engine
+type Overview struct {
+	QueueLength    int        `json:"queue_length"`    // Builds waiting for an executor
+	QueueStuck     int        `json:"queue_stuck"`     // Queued builds the engine reports as stuck
+	BusyExecutors  int        `json:"busy_executors"`  // Executors running a build on online nodes
+	IdleExecutors  int        `json:"idle_executors"`  // Executors free on online nodes
+	TotalExecutors int        `json:"total_executors"` // Executors of online nodes
+	Nodes          []NodeInfo `json:"nodes"`
+}
+
+// OverviewReporter is implemented by engines that can report their queue and executor load
+type OverviewReporter interface {
+	// Overview returns the current queue length and executor status
+	Overview() (*Overview, error)
+}
+
+// ClockSample compares the clock of a CI engine's server with the local clock
+type ClockSample struct {
+	Remote    time.Time     // Time reported by the server, e.g. in its Date header
+	Local     time.Time     // Local time halfway through the request
+	RoundTrip time.Duration // Duration of the request, bounding the precision of the comparison
+}
+
+// ClockReporter is implemented by engines that can report the clock of their server
+type ClockReporter interface {
+	// Clock reads the server's clock
+	Clock() (*ClockSample, error)
+}
+
+// Types of request fields
+const (
+	FieldString  = "string"
+	FieldInteger = "integer"
+	FieldBoolean = "boolean"
+)
+
+// RequestField describes an engine-specific field of trigger requests, e.g. a Tekton namespace,
+// accepted in the fields object of the request body and passed to the engine as a parameter
+type RequestField struct {
+	Name        string   // Key in the fields object
+	Type        string   // FieldString, FieldInteger, or FieldBoolean
+	Description string   // Shown in the generated OpenAPI schema
+	Required    bool     // Reject triggers without the field
+	Enum        []string // Values allowed for string fields; empty allows any
+	Pattern     string   // Regular expression string values must match; empty allows any
+	Parameter   string   // Parameter the value is passed as; empty uses Name
+}
+
+// FieldProvider is implemented by engines that accept fields besides the Jenkins-shaped request body
+type FieldProvider interface {
+	// RequestFields returns the fields accepted in trigger requests
+	RequestFields() []RequestField
+}
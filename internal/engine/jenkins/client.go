@@ -13,34 +13,84 @@ import (
 	"time"
 
 	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
 	"triggermesh/internal/logger"
+	"triggermesh/internal/version"
 )
 
 // Client represents a Jenkins API client
 type Client struct {
 	url      string
+	linkURL  string // Base of the build and job links returned to users (jenkins.public_url, or url)
 	username string
 	token    string
+	headers  map[string]string
 	client   *http.Client
+
+	buildToken string // Job build token sent with build requests, so Jenkins shows the cause
 }
 
 // NewClient creates a new Jenkins client instance
 func NewClient(cfg config.JenkinsConfig) *Client {
-	// Create HTTP client with timeout
+	// Create HTTP client with timeout, sharing the pooled transport across clients
 	timeout := time.Duration(cfg.Timeout) * time.Second
 	client := &http.Client{
-		Timeout: timeout,
+		Timeout:   timeout,
+		Transport: sharedTransport,
 	}
 
 	// Normalize URL: remove trailing slash to avoid double slashes in paths
 	url := strings.TrimSuffix(cfg.URL, "/")
+	linkURL := url
+	if cfg.PublicURL != "" {
+		linkURL = strings.TrimSuffix(cfg.PublicURL, "/")
+	}
 
 	return &Client{
 		url:      url,
+		linkURL:  linkURL,
 		username: cfg.Username,
 		token:    cfg.Token,
+		headers:  cfg.Headers,
 		client:   client,
+
+		buildToken: cfg.BuildToken,
+	}
+}
+
+// SetTransport replaces the HTTP transport of the client, e.g. to record or replay Jenkins
+// interactions in tests; the timeout is kept
+func (c *Client) SetTransport(transport http.RoundTripper) {
+	c.client = &http.Client{
+		Timeout:   c.client.Timeout,
+		Transport: transport,
+	}
+}
+
+// publicLink returns a build or job link as users should see it: links below the internal URL move
+// below jenkins.public_url, while requests keep using the internal URL
+// Links Jenkins reports under another address (its own root URL setting) are returned unchanged
+func (c *Client) publicLink(link string) string {
+	if c.linkURL == c.url {
+		return link
 	}
+	if rest, ok := strings.CutPrefix(link, c.url); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+		return c.linkURL + rest
+	}
+	return link
+}
+
+// setCommonHeaders sets the User-Agent, configured extra headers, and authentication on a request
+func (c *Client) setCommonHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", version.UserAgent())
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+
+	// Jenkins API uses Basic Authentication
+	// Format: username:token
+	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", c.username, c.token)))
+	req.Header.Set("Authorization", "Basic "+auth)
 }
 
 // doRequest sends an HTTP request to the Jenkins API
@@ -65,13 +115,9 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 	}
 
 	// Set headers
+	c.setCommonHeaders(req)
 	req.Header.Set("Content-Type", "application/json")
 
-	// Jenkins API uses Basic Authentication
-	// Format: username:token
-	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", c.username, c.token)))
-	req.Header.Set("Authorization", "Basic "+auth)
-
 	// Send the request
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -95,10 +141,8 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body interf
 }
 
 // doBuildRequest sends a POST request to trigger a Jenkins build without parameters
-// Returns build ID and build URL extracted from the Location header
-func (c *Client) doBuildRequest(ctx context.Context, buildPath string) (string, string, error) {
-	fullURL := c.url + buildPath
-
+// Returns build ID, build URL, and queue item ID extracted from the Location header
+func (c *Client) doBuildRequest(ctx context.Context, buildPath string) (string, string, string, error) {
 	// Get CSRF crumb first - some Jenkins versions require it in the form data
 	crumbField, crumbValue, err := c.getCrumb(ctx)
 	if err != nil {
@@ -116,108 +160,120 @@ func (c *Client) doBuildRequest(ctx context.Context, buildPath string) (string,
 		formData.Set(crumbField, crumbValue)
 	}
 
-	reqBody := strings.NewReader(formData.Encode())
-
-	// Create the request with context
-	req, err := http.NewRequestWithContext(ctx, "POST", fullURL, reqBody)
-	if err != nil {
-		return "", "", err
-	}
-
-	// Set Content-Type for form-encoded data
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	// Set authentication
-	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", c.username, c.token)))
-	req.Header.Set("Authorization", "Basic "+auth)
-
-	// Also set crumb in header if available (some Jenkins versions require both)
-	if crumbField != "" && crumbValue != "" {
-		req.Header.Set(crumbField, crumbValue)
-	}
-
-	// Send the request
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return "", "", err
-	}
-	defer resp.Body.Close()
-
-	// Read response body for error messages
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to read response body: %v", err)
-	}
-
-	// Check if the response status is successful
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		logger.Error("Jenkins build request failed", "status", resp.Status, "body", string(respBody), "url", fullURL)
-		return "", "", formatJenkinsError(resp.StatusCode, string(respBody))
-	}
-
-	// Extract build ID and URL from Location header
-	location := resp.Header.Get("Location")
-	buildID, buildURL := c.extractBuildInfo(location, buildPath)
-
-	return buildID, buildURL, nil
+	return c.sendBuildRequest(ctx, buildPath, formData, crumbField, crumbValue)
 }
 
 // doParameterizedRequest sends a POST request to trigger a Jenkins build with parameters
 // Jenkins buildWithParameters expects form-encoded data
-func (c *Client) doParameterizedRequest(ctx context.Context, buildPath string, params map[string]string) (string, string, error) {
-	fullURL := c.url + buildPath
-
+// Returns build ID, build URL, and queue item ID extracted from the Location header
+func (c *Client) doParameterizedRequest(ctx context.Context, buildPath string, params map[string]string) (string, string, string, error) {
 	// Create form data
 	formData := url.Values{}
 	for k, v := range params {
 		formData.Set(k, v)
 	}
 
-	// Create the request with form-encoded body and context
-	req, err := http.NewRequestWithContext(ctx, "POST", fullURL, strings.NewReader(formData.Encode()))
-	if err != nil {
-		return "", "", err
-	}
-
-	// Set headers for form-encoded data
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	// Set authentication
-	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", c.username, c.token)))
-	req.Header.Set("Authorization", "Basic "+auth)
-
 	// Jenkins expects a CSRF token for POST requests
 	crumbField, crumbValue, err := c.getCrumb(ctx)
 	if err != nil {
 		logger.Warn("Failed to get CSRF crumb, proceeding without it", "error", err)
-	} else if crumbField != "" && crumbValue != "" {
-		req.Header.Set(crumbField, crumbValue)
 	}
 
-	// Send the request
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return "", "", err
+	return c.sendBuildRequest(ctx, buildPath, formData, crumbField, crumbValue)
+}
+
+// sendBuildRequest posts the form to a build trigger endpoint and extracts the build info
+// Redirects are not followed blindly, since http.Client would turn the POST into a GET:
+// a redirect to a queue item or build is the trigger's result, a redirect of the endpoint
+// itself (e.g. http to https, or a proxy adding a prefix) is retried as a POST, and any
+// other target (such as the job page of older Jenkins versions) means the build was accepted
+func (c *Client) sendBuildRequest(ctx context.Context, buildPath string, formData url.Values, crumbField, crumbValue string) (string, string, string, error) {
+	fullURL := c.url + buildPath
+	body := formData.Encode()
+
+	client := *c.client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
 	}
-	defer resp.Body.Close()
 
-	// Read response body for error messages
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to read response body: %v", err)
+	for redirects := 0; ; redirects++ {
+		// Create the request with form-encoded body and context
+		req, err := http.NewRequestWithContext(ctx, "POST", fullURL, strings.NewReader(body))
+		if err != nil {
+			return "", "", "", err
+		}
+
+		// Set headers for form-encoded data, with the crumb also in a header (some Jenkins versions require both)
+		c.setCommonHeaders(req)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if crumbField != "" && crumbValue != "" {
+			req.Header.Set(crumbField, crumbValue)
+		}
+
+		// Send the request
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", "", "", err
+		}
+
+		// Read response body for error messages
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return "", "", "", fmt.Errorf("failed to read response body: %v", err)
+		}
+
+		location := resp.Header.Get("Location")
+		if isRedirect(resp.StatusCode) && location != "" {
+			target, err := req.URL.Parse(location)
+			if err != nil {
+				return "", "", "", engine.NewError(engine.ErrorKindUnknown, "jenkins returned an invalid redirect")
+			}
+			switch {
+			case isLoginPath(target.Path):
+				logger.Error("Jenkins build request redirected to login", "status", resp.Status, "url", fullURL)
+				return "", "", "", engine.NewError(engine.ErrorKindAuth, "authentication failed: jenkins redirected to login")
+			case isEndpointRedirect(req.URL, target):
+				if redirects >= maxBuildRedirects {
+					return "", "", "", engine.NewError(engine.ErrorKindUnknown, "jenkins redirected too many times")
+				}
+				logger.Warn("Jenkins build request redirected, retrying at the new location", "status", resp.Status, "url", fullURL, "location", target.String())
+				fullURL = target.String()
+				continue
+			}
+		} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			// Check if the response status is successful
+			logger.Error("Jenkins build request failed", "status", resp.Status, "body", string(respBody), "url", fullURL)
+			return "", "", "", formatJenkinsBuildError(resp.StatusCode, string(respBody))
+		}
+
+		// Extract build ID and URL from Location header
+		buildID, buildURL, queueID := c.extractBuildInfo(location, buildPath)
+		return buildID, buildURL, queueID, nil
 	}
+}
 
-	// Check if the response status is successful
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		logger.Error("Jenkins parameterized build request failed", "status", resp.Status, "body", string(respBody), "url", fullURL)
-		return "", "", formatJenkinsError(resp.StatusCode, string(respBody))
+// maxBuildRedirects bounds how often a build request follows redirects of the build endpoint
+const maxBuildRedirects = 3
+
+// isRedirect reports whether the status code is a redirect with a Location
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
 	}
+	return false
+}
 
-	// Extract build ID and URL from Location header
-	location := resp.Header.Get("Location")
-	buildID, buildURL := c.extractBuildInfo(location, buildPath)
+// isEndpointRedirect reports whether target is the requested endpoint itself, e.g. moved to https
+// or below a reverse-proxy prefix; redirects to the same URL count too and end at the redirect limit
+func isEndpointRedirect(from, target *url.URL) bool {
+	return strings.HasSuffix(target.Path, from.Path)
+}
 
-	return buildID, buildURL, nil
+// isLoginPath reports whether a redirect target is a Jenkins or SSO login page
+func isLoginPath(path string) bool {
+	return strings.HasSuffix(path, "/login") || strings.Contains(path, "/securityRealm/") || strings.HasSuffix(path, "/j_spring_security_check")
 }
 
 // getCrumb retrieves the CSRF crumb from Jenkins for POST requests
@@ -231,14 +287,17 @@ func (c *Client) getCrumb(ctx context.Context) (string, string, error) {
 	}
 
 	// Set authentication
-	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", c.username, c.token)))
-	req.Header.Set("Authorization", "Basic "+auth)
+	c.setCommonHeaders(req)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return "", "", err
 	}
-	defer resp.Body.Close()
+	defer func() {
+		// Drain the body so the connection can be reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
 
 	if resp.StatusCode != http.StatusOK {
 		return "", "", fmt.Errorf("failed to get crumb: %s", resp.Status)
@@ -262,47 +321,55 @@ func (c *Client) getCrumb(ctx context.Context) (string, string, error) {
 	return crumbField, crumbData.Crumb, nil
 }
 
-// extractBuildInfo extracts build ID and URL from Jenkins Location header
-// Location format: /job/jobName/buildNumber/ or http://jenkins/job/jobName/buildNumber/
-func (c *Client) extractBuildInfo(location, buildPath string) (string, string) {
-	if location == "" {
-		// If no location header, try to extract from buildPath
-		// buildPath format: /job/jobName/build or /job/jobName/buildWithParameters
-		parts := strings.Split(strings.TrimPrefix(buildPath, "/job/"), "/")
-		if len(parts) > 0 {
-			jobName := parts[0]
-			return "", fmt.Sprintf("%s/job/%s/", c.url, jobName)
+// extractBuildInfo extracts build ID and URL, or the queue item ID, from Jenkins Location header
+// Location format: /job/jobName/buildNumber/ or http://jenkins/job/jobName/buildNumber/,
+// or /queue/item/queueID/ while the build waits in the queue; both may sit below a Jenkins
+// context path or reverse-proxy prefix and carry trailing segments (e.g. .../5/console)
+// Without a recognizable Location the build URL falls back to the job page
+func (c *Client) extractBuildInfo(location, buildPath string) (string, string, string) {
+	var parts []string
+	if u, err := url.Parse(location); err == nil && location != "" {
+		parts = strings.Split(strings.Trim(u.Path, "/"), "/")
+	}
+
+	// Format: .../job/jobName/buildNumber/..., matched from the end so folder jobs
+	// (/job/folder/job/jobName/buildNumber/) yield the job itself
+	for i := len(parts) - 3; i >= 0; i-- {
+		if parts[i] == "job" && parts[i+1] != "" && isNumeric(parts[i+2]) {
+			jobName := parts[i+1]
+			buildNumber := parts[i+2]
+			buildID := jobName + "/" + buildNumber
+			buildURL := fmt.Sprintf("%s/job/%s/%s/", c.url, url.PathEscape(jobName), buildNumber)
+			return buildID, buildURL, ""
 		}
-		return "", ""
 	}
 
-	// Parse location to extract job name and build number
-	// Location can be relative or absolute
-	var pathPart string
-	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
-		// Absolute URL
-		u, err := url.Parse(location)
-		if err != nil {
-			return "", ""
+	// Format: .../queue/item/queueID/...
+	for i := len(parts) - 3; i >= 0; i-- {
+		if parts[i] == "queue" && parts[i+1] == "item" && isNumeric(parts[i+2]) {
+			return "", "", parts[i+2]
 		}
-		pathPart = u.Path
-	} else {
-		// Relative URL
-		pathPart = location
 	}
 
-	// Extract job name and build number from path
-	// Format: /job/jobName/buildNumber/
-	parts := strings.Split(strings.Trim(pathPart, "/"), "/")
-	if len(parts) >= 3 && parts[0] == "job" {
-		jobName := parts[1]
-		buildNumber := parts[2]
-		buildID := jobName + "/" + buildNumber
-		buildURL := fmt.Sprintf("%s/job/%s/%s/", c.url, jobName, buildNumber)
-		return buildID, buildURL
+	// Fall back to the job page derived from buildPath (/job/jobName/build or /job/jobName/buildWithParameters)
+	if !strings.HasPrefix(buildPath, "/job/") {
+		return "", "", ""
 	}
+	jobName, _, _ := strings.Cut(strings.TrimPrefix(buildPath, "/job/"), "/")
+	return "", fmt.Sprintf("%s/job/%s/", c.url, jobName), ""
+}
 
-	return "", ""
+// isNumeric reports whether s is a non-empty string of ASCII digits
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
 }
 
 // formatJenkinsError formats Jenkins API errors into user-friendly messages
@@ -310,17 +377,28 @@ func (c *Client) extractBuildInfo(location, buildPath string) (string, string) {
 func formatJenkinsError(statusCode int, responseBody string) error {
 	switch statusCode {
 	case http.StatusUnauthorized:
-		return fmt.Errorf("authentication failed: invalid credentials")
+		return engine.NewError(engine.ErrorKindAuth, "authentication failed: invalid credentials")
 	case http.StatusForbidden:
-		return fmt.Errorf("access denied: insufficient permissions")
+		return engine.NewError(engine.ErrorKindAuth, "access denied: insufficient permissions")
 	case http.StatusNotFound:
-		return fmt.Errorf("resource not found")
+		return engine.NewError(engine.ErrorKindNotFound, "resource not found")
 	case http.StatusBadRequest:
-		return fmt.Errorf("invalid request")
+		return engine.NewError(engine.ErrorKindUnknown, "invalid request")
+	case http.StatusGatewayTimeout:
+		return engine.NewError(engine.ErrorKindTimeout, "jenkins server timed out: please try again later")
 	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
-		return fmt.Errorf("jenkins server error: please try again later")
+		return engine.NewError(engine.ErrorKindServer, "jenkins server error: please try again later")
 	default:
 		// For other errors, return a generic message
-		return fmt.Errorf("jenkins api request failed")
+		return engine.NewError(engine.ErrorKindUnknown, "jenkins api request failed")
+	}
+}
+
+// formatJenkinsBuildError formats errors from build trigger endpoints
+// A 404 there means the job itself does not exist
+func formatJenkinsBuildError(statusCode int, responseBody string) error {
+	if statusCode == http.StatusNotFound {
+		return engine.NewError(engine.ErrorKindNotFound, "job not found")
 	}
+	return formatJenkinsError(statusCode, responseBody)
 }
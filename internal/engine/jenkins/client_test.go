@@ -54,3 +54,55 @@ func TestDoRequest(t *testing.T) {
 		t.Errorf("Expected response containing 'created', got %s", string(resp))
 	}
 }
+
+// newBenchmarkServer returns a mock Jenkins that accepts builds and issues crumbs
+func newBenchmarkServer(b *testing.B) *httptest.Server {
+	b.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/crumbIssuer/api/json" {
+			w.Write([]byte(`{"crumb":"bench-crumb","crumbRequestField":"Jenkins-Crumb"}`))
+			return
+		}
+		w.Header().Set("Location", "/job/bench-job/1/")
+		w.WriteHeader(http.StatusCreated)
+	}))
+}
+
+// BenchmarkTriggerBuildSharedTransport triggers builds in parallel through clients sharing the pooled transport
+func BenchmarkTriggerBuildSharedTransport(b *testing.B) {
+	server := newBenchmarkServer(b)
+	defer server.Close()
+
+	cfg := config.JenkinsConfig{URL: server.URL, Username: "user", Token: "token", Timeout: 5}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		trigger := NewTrigger(NewClient(cfg))
+		for pb.Next() {
+			if _, err := trigger.TriggerBuild("bench-job", map[string]string{"param": "value"}); err != nil {
+				b.Fatalf("Failed to trigger build: %v", err)
+			}
+		}
+	})
+}
+
+// BenchmarkTriggerBuildTransportPerClient is the baseline where every client owns an untuned transport
+func BenchmarkTriggerBuildTransportPerClient(b *testing.B) {
+	server := newBenchmarkServer(b)
+	defer server.Close()
+
+	cfg := config.JenkinsConfig{URL: server.URL, Username: "user", Token: "token", Timeout: 5}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			client := NewClient(cfg)
+			transport := &http.Transport{}
+			client.client.Transport = transport
+			if _, err := NewTrigger(client).TriggerBuild("bench-job", map[string]string{"param": "value"}); err != nil {
+				b.Fatalf("Failed to trigger build: %v", err)
+			}
+			transport.CloseIdleConnections()
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package jenkins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"triggermesh/internal/engine"
+)
+
+// Clock reads the Jenkins server time from the Date header of a small API request
+// The header has second precision, and the local time is taken halfway through the request
+func (t *Trigger) Clock() (*engine.ClockSample, error) {
+	// Use context.Background() for now (can be improved to accept context from handler)
+	ctx := context.Background()
+	client := t.client.Load()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.url+"/api/json?tree=mode", nil)
+	if err != nil {
+		return nil, err
+	}
+	client.setCommonHeaders(req)
+
+	sent := time.Now()
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	received := time.Now()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, formatJenkinsError(resp.StatusCode, string(body))
+	}
+
+	header := resp.Header.Get("Date")
+	if header == "" {
+		return nil, errors.New("Jenkins response has no Date header")
+	}
+	remote, err := http.ParseTime(header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Date header %q: %v", header, err)
+	}
+	roundTrip := received.Sub(sent)
+	return &engine.ClockSample{Remote: remote, Local: sent.Add(roundTrip / 2), RoundTrip: roundTrip}, nil
+}
@@ -0,0 +1,156 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"triggermesh/internal/engine"
+)
+
+// jenkinsBuild represents a build in the build lists of a Jenkins job API response
+type jenkinsBuild struct {
+	Number    int64  `json:"number"`
+	URL       string `json:"url"`
+	Building  bool   `json:"building"`
+	Result    string `json:"result"`
+	Duration  int64  `json:"duration"`  // Milliseconds, 0 while building
+	Timestamp int64  `json:"timestamp"` // Start time in Unix milliseconds
+}
+
+// jenkinsBuildHistory represents the build lists of a Jenkins job API response
+type jenkinsBuildHistory struct {
+	Builds    []jenkinsBuild `json:"builds"`
+	AllBuilds []jenkinsBuild `json:"allBuilds"`
+}
+
+// jenkinsLastBuild represents the last build section of a Jenkins job API response
+type jenkinsLastBuild struct {
+	LastBuild *struct {
+		Number int64 `json:"number"`
+	} `json:"lastBuild"`
+}
+
+// buildHistoryTree selects the build fields of build history requests
+const buildHistoryTree = "[number,url,building,result,duration,timestamp]"
+
+// maxBuildPageWindows bounds the build history requests made for one page of older builds
+const maxBuildPageWindows = 5
+
+// ListBuilds returns up to limit recent builds of a job (folder/job for jobs in folders), newest first
+func (t *Trigger) ListBuilds(jobName string, limit int) ([]engine.BuildInfo, error) {
+	if jobName == "" {
+		return nil, fmt.Errorf("job name cannot be empty")
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+
+	// The {0,N} range makes Jenkins return only the newest N builds
+	return t.getBuilds(jobName, "builds", 0, limit)
+}
+
+// ListBuildsBefore returns up to limit builds of a job numbered below before, newest first
+// Jenkins selects builds by position rather than number, so the position of build before-1 is
+// estimated from the last build number, and the window moved back when newer builds were deleted
+func (t *Trigger) ListBuildsBefore(jobName string, before int64, limit int) ([]engine.BuildInfo, error) {
+	if jobName == "" {
+		return nil, fmt.Errorf("job name cannot be empty")
+	}
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+	if before <= 1 {
+		return []engine.BuildInfo{}, nil
+	}
+
+	ctx := context.Background()
+	respBody, err := t.client.Load().doRequest(ctx, "GET", jobListPath(engine.JobFilter{Folder: jobName})+"/api/json?tree="+url.QueryEscape("lastBuild[number]"), nil)
+	if err != nil {
+		return nil, err
+	}
+	var last jenkinsLastBuild
+	if err := json.Unmarshal(respBody, &last); err != nil {
+		return nil, fmt.Errorf("failed to parse last build: %v", err)
+	}
+	if last.LastBuild == nil {
+		return []engine.BuildInfo{}, nil
+	}
+
+	// Builds are usually numbered without gaps, putting build n at position last-n
+	from := int(last.LastBuild.Number-before+1) - limit
+	if from < 0 {
+		from = 0
+	}
+	builds := make([]engine.BuildInfo, 0, limit)
+	for window := 0; window < maxBuildPageWindows; window++ {
+		to := from + 2*limit
+		page, err := t.getBuilds(jobName, "allBuilds", from, to)
+		if err != nil {
+			return nil, err
+		}
+		// Builds just below before may sit before the window when newer builds were deleted
+		if from > 0 && len(builds) == 0 && len(page) > 0 && page[0].Number < before {
+			from -= 2 * limit
+			if from < 0 {
+				from = 0
+			}
+			continue
+		}
+		for _, build := range page {
+			if build.Number < before && len(builds) < limit {
+				builds = append(builds, build)
+			}
+		}
+		if len(builds) == limit || len(page) < to-from {
+			break
+		}
+		from = to
+	}
+	return builds, nil
+}
+
+// getBuilds returns the builds of a job at positions from <= position < to of a build list
+// of the job API (builds for the recent builds, allBuilds for all of them)
+func (t *Trigger) getBuilds(jobName, list string, from, to int) ([]engine.BuildInfo, error) {
+	tree := list + buildHistoryTree + "{" + strconv.Itoa(from) + "," + strconv.Itoa(to) + "}"
+	apiPath := jobListPath(engine.JobFilter{Folder: jobName}) + "/api/json?tree=" + url.QueryEscape(tree)
+
+	// Use context.Background() for now (can be improved to accept context from handler)
+	ctx := context.Background()
+	client := t.client.Load()
+	respBody, err := client.doRequest(ctx, "GET", apiPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var history jenkinsBuildHistory
+	if err := json.Unmarshal(respBody, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse build history: %v", err)
+	}
+	if list == "allBuilds" {
+		history.Builds = history.AllBuilds
+	}
+
+	builds := make([]engine.BuildInfo, 0, len(history.Builds))
+	for _, build := range history.Builds {
+		info := engine.BuildInfo{
+			Number:     build.Number,
+			BuildID:    jobName + "/" + strconv.FormatInt(build.Number, 10),
+			URL:        client.publicLink(build.URL),
+			Building:   build.Building,
+			Result:     build.Result,
+			DurationMS: build.Duration,
+			StartedAt:  time.UnixMilli(build.Timestamp).UTC(),
+		}
+		if !build.Building && build.Duration > 0 {
+			finished := info.StartedAt.Add(time.Duration(build.Duration) * time.Millisecond)
+			info.FinishedAt = &finished
+		}
+		builds = append(builds, info)
+	}
+	return builds, nil
+}
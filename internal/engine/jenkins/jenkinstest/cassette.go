@@ -0,0 +1,62 @@
+// Package jenkinstest records Jenkins HTTP interactions into fixtures ("cassettes") and replays
+// them, so tests can exercise the Jenkins engine against responses captured from real servers,
+// including odd ones such as redirects, HTML error pages, and missing Location headers
+package jenkinstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Interaction is one recorded request and the response Jenkins sent to it
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request identifies a recorded request
+// Request headers are not recorded, so credentials never end up in fixtures
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`           // Path and query, without the Jenkins base URL
+	Body   string `json:"body,omitempty"` // Informational; replay matches on method and path only
+}
+
+// Response is a recorded response
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// Cassette is an ordered list of interactions, stored as indented JSON
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette reads a cassette from a JSON file
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to a JSON file, creating its directory if needed
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,63 @@
+package jenkinstest
+
+import (
+	"os"
+	"testing"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine/jenkins"
+)
+
+// ReplayURL is the Jenkins base URL of clients replaying a cassette
+const ReplayURL = "http://jenkins.test"
+
+// Environment variables that switch NewClient to recording against a real Jenkins
+const (
+	RecordURLEnv   = "TRIGGERMESH_JENKINS_RECORD_URL"
+	RecordUserEnv  = "TRIGGERMESH_JENKINS_RECORD_USER"
+	RecordTokenEnv = "TRIGGERMESH_JENKINS_RECORD_TOKEN"
+)
+
+// NewClient returns a Jenkins client for a test that replays the cassette at path
+// With TRIGGERMESH_JENKINS_RECORD_URL set, the client talks to that Jenkins instead (with the
+// credentials from TRIGGERMESH_JENKINS_RECORD_USER and _TOKEN) and overwrites the cassette when
+// the test ends. Replaying tests fail if interactions of the cassette remain unused
+func NewClient(t testing.TB, path string) *jenkins.Client {
+	t.Helper()
+
+	if recordURL := os.Getenv(RecordURLEnv); recordURL != "" {
+		client := jenkins.NewClient(config.JenkinsConfig{
+			URL:      recordURL,
+			Username: os.Getenv(RecordUserEnv),
+			Token:    os.Getenv(RecordTokenEnv),
+			Timeout:  30,
+		})
+		recorder := NewRecorder(nil, recordURL)
+		client.SetTransport(recorder)
+		t.Cleanup(func() {
+			if err := recorder.Cassette().Save(path); err != nil {
+				t.Errorf("Failed to save cassette %s: %v", path, err)
+			}
+		})
+		return client
+	}
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("Failed to load cassette: %v", err)
+	}
+	client := jenkins.NewClient(config.JenkinsConfig{
+		URL:      ReplayURL,
+		Username: "test",
+		Token:    "test-token",
+		Timeout:  5,
+	})
+	replayer := NewReplayer(cassette)
+	client.SetTransport(replayer)
+	t.Cleanup(func() {
+		for _, interaction := range replayer.Unused() {
+			t.Errorf("Unused interaction in %s: %s %s", path, interaction.Request.Method, interaction.Request.Path)
+		}
+	})
+	return client
+}
@@ -0,0 +1,170 @@
+package jenkinstest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// skippedHeaders are response headers not worth recording; they vary between runs or carry session state
+var skippedHeaders = map[string]bool{
+	"Date":              true,
+	"Set-Cookie":        true,
+	"X-Jenkins-Session": true,
+}
+
+// Recorder is an http.RoundTripper that passes requests to another transport and records them
+// Occurrences of the Jenkins base URL in response headers and bodies are replaced with ReplayURL,
+// so cassettes do not depend on the recorded server's address
+type Recorder struct {
+	next    http.RoundTripper
+	baseURL string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder creates a recorder of requests to the Jenkins server at baseURL
+func NewRecorder(next http.RoundTripper, baseURL string) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{
+		next:    next,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// RoundTrip sends the request and records it with its response
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = data
+		req.Body = io.NopCloser(bytes.NewReader(data))
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	headers := make(map[string]string, len(resp.Header))
+	for name := range resp.Header {
+		if !skippedHeaders[name] {
+			headers[name] = r.rewrite(resp.Header.Get(name))
+		}
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: Request{
+			Method: req.Method,
+			Path:   req.URL.RequestURI(),
+			Body:   string(reqBody),
+		},
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: headers,
+			Body:    r.rewrite(string(respBody)),
+		},
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Cassette returns a copy of the interactions recorded so far
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+// rewrite replaces the recorded server's base URL with ReplayURL
+func (r *Recorder) rewrite(value string) string {
+	if r.baseURL == "" {
+		return value
+	}
+	return strings.ReplaceAll(value, r.baseURL, ReplayURL)
+}
+
+// Replayer is an http.RoundTripper that answers requests from a cassette without network access
+// Each interaction answers one request; requests are matched to the first unused interaction with
+// the same method and path, so repeated requests (e.g. status polls) replay in recorded order
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer creates a replayer of the cassette's interactions
+func NewReplayer(cassette *Cassette) *Replayer {
+	return &Replayer{
+		interactions: cassette.Interactions,
+		used:         make([]bool, len(cassette.Interactions)),
+	}
+}
+
+// RoundTrip returns the recorded response to the request, or an error when none is left
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	path := req.URL.RequestURI()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Request.Method != req.Method || interaction.Request.Path != path {
+			continue
+		}
+		r.used[i] = true
+
+		header := make(http.Header, len(interaction.Response.Headers))
+		for name, value := range interaction.Response.Headers {
+			header.Set(name, value)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("jenkinstest: no recorded interaction left for %s %s", req.Method, path)
+}
+
+// Unused returns the interactions that have not answered a request yet
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []Interaction
+	for i, interaction := range r.interactions {
+		if !r.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
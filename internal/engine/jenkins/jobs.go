@@ -0,0 +1,73 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"triggermesh/internal/engine"
+)
+
+// folderClassSuffix identifies Jenkins folder items (CloudBees Folder, Organization Folder, ...)
+const folderClassSuffix = "Folder"
+
+// jenkinsJobList represents the jobs section of a Jenkins item or view API response
+type jenkinsJobList struct {
+	Jobs []struct {
+		Class string `json:"_class"`
+		Name  string `json:"name"`
+		URL   string `json:"url"`
+		Color string `json:"color"`
+	} `json:"jobs"`
+}
+
+// ListJobs lists the jobs in the root, a folder, and/or a view
+func (t *Trigger) ListJobs(filter engine.JobFilter) ([]engine.JobInfo, error) {
+	apiPath := jobListPath(filter) + "/api/json?tree=" + url.QueryEscape("jobs[name,url,color]")
+
+	// Use context.Background() for now (can be improved to accept context from handler)
+	ctx := context.Background()
+	client := t.client.Load()
+	respBody, err := client.doRequest(ctx, "GET", apiPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var list jenkinsJobList
+	if err := json.Unmarshal(respBody, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse job list: %v", err)
+	}
+
+	jobs := make([]engine.JobInfo, 0, len(list.Jobs))
+	for _, job := range list.Jobs {
+		name := job.Name
+		if filter.Folder != "" {
+			name = filter.Folder + "/" + job.Name
+		}
+		jobs = append(jobs, engine.JobInfo{
+			Name:   name,
+			URL:    client.publicLink(job.URL),
+			Folder: strings.HasSuffix(job.Class, folderClassSuffix),
+			Status: job.Color,
+		})
+	}
+
+	return jobs, nil
+}
+
+// jobListPath builds the Jenkins path for a folder and/or view
+// Folder "a/b" maps to /job/a/job/b, view "v" appends /view/v
+func jobListPath(filter engine.JobFilter) string {
+	var b strings.Builder
+	if filter.Folder != "" {
+		for _, segment := range strings.Split(filter.Folder, "/") {
+			b.WriteString("/job/" + url.PathEscape(segment))
+		}
+	}
+	if filter.View != "" {
+		b.WriteString("/view/" + url.PathEscape(filter.View))
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package jenkins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"triggermesh/internal/engine"
+)
+
+// jenkinsQueue represents the Jenkins build queue API response
+type jenkinsQueue struct {
+	Items []struct {
+		Stuck bool `json:"stuck"`
+	} `json:"items"`
+}
+
+// jenkinsComputerSet represents the Jenkins node (computer) API response
+type jenkinsComputerSet struct {
+	BusyExecutors int `json:"busyExecutors"`
+	Computer      []struct {
+		DisplayName  string `json:"displayName"`
+		Offline      bool   `json:"offline"`
+		NumExecutors int    `json:"numExecutors"`
+		Executors    []struct {
+			Idle bool `json:"idle"`
+		} `json:"executors"`
+	} `json:"computer"`
+}
+
+// Overview reports the Jenkins build queue length and the executors of every node
+// Executor counts only include online nodes, as Jenkins cannot schedule builds on the others
+func (t *Trigger) Overview() (*engine.Overview, error) {
+	// Use context.Background() for now (can be improved to accept context from handler)
+	ctx := context.Background()
+	client := t.client.Load()
+
+	respBody, err := client.doRequest(ctx, "GET", "/queue/api/json?tree="+url.QueryEscape("items[stuck]"), nil)
+	if err != nil {
+		return nil, err
+	}
+	var queue jenkinsQueue
+	if err := json.Unmarshal(respBody, &queue); err != nil {
+		return nil, fmt.Errorf("failed to parse build queue: %v", err)
+	}
+
+	respBody, err = client.doRequest(ctx, "GET", "/computer/api/json?tree="+url.QueryEscape("busyExecutors,computer[displayName,offline,numExecutors,executors[idle]]"), nil)
+	if err != nil {
+		return nil, err
+	}
+	var computers jenkinsComputerSet
+	if err := json.Unmarshal(respBody, &computers); err != nil {
+		return nil, fmt.Errorf("failed to parse node list: %v", err)
+	}
+
+	overview := &engine.Overview{
+		QueueLength:   len(queue.Items),
+		BusyExecutors: computers.BusyExecutors,
+		Nodes:         make([]engine.NodeInfo, 0, len(computers.Computer)),
+	}
+	for _, item := range queue.Items {
+		if item.Stuck {
+			overview.QueueStuck++
+		}
+	}
+	for _, computer := range computers.Computer {
+		node := engine.NodeInfo{
+			Name:      computer.DisplayName,
+			Online:    !computer.Offline,
+			Executors: computer.NumExecutors,
+		}
+		for _, executor := range computer.Executors {
+			if !executor.Idle {
+				node.BusyExecutors++
+			}
+		}
+		if node.Online {
+			overview.TotalExecutors += node.Executors
+		}
+		overview.Nodes = append(overview.Nodes, node)
+	}
+	overview.IdleExecutors = max(overview.TotalExecutors-overview.BusyExecutors, 0)
+
+	return overview, nil
+}
@@ -0,0 +1,37 @@
+package jenkins
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxIdleConnsPerHost keeps enough warm connections for bursts of triggers against one Jenkins
+	maxIdleConnsPerHost = 32
+	// idleConnTimeout closes idle connections before typical proxy/load balancer idle timeouts
+	idleConnTimeout = 90 * time.Second
+)
+
+// sharedTransport is reused by all Jenkins clients so connections (and crumb/build
+// request pairs) are pooled instead of re-dialing and re-handshaking TLS per client
+var sharedTransport = newTransport()
+
+// newTransport creates an http.Transport tuned for frequent requests to a small set of hosts
+func newTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
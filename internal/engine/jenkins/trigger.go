@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"sync/atomic"
 
 	"triggermesh/internal/engine"
 	"triggermesh/internal/logger"
@@ -13,24 +14,64 @@ import (
 
 // jenkinsBuildResult represents the result of a Jenkins build
 type jenkinsBuildResult struct {
-	Number int    `json:"number"`
-	URL    string `json:"url"`
+	Number   int    `json:"number"`
+	URL      string `json:"url"`
+	Building bool   `json:"building"`
+	Result   string `json:"result"`   // Empty (null) while the build is running
+	Duration int64  `json:"duration"` // Milliseconds, 0 while the build is running
+}
+
+// jenkinsQueueItem represents a Jenkins queue item; executable is set once the build started
+type jenkinsQueueItem struct {
+	Cancelled  bool `json:"cancelled"`
+	Executable *struct {
+		Number int64  `json:"number"`
+		URL    string `json:"url"`
+	} `json:"executable"`
+	Task struct {
+		Name string `json:"name"`
+	} `json:"task"`
 }
 
 // Trigger implements the CIEngine interface for Jenkins
 type Trigger struct {
-	client *Client
+	client atomic.Pointer[Client]
 }
 
 // NewTrigger creates a new Jenkins trigger instance
 func NewTrigger(client *Client) *Trigger {
-	return &Trigger{
-		client: client,
-	}
+	t := &Trigger{}
+	t.client.Store(client)
+	return t
+}
+
+// SetClient replaces the Jenkins client, e.g. after credentials are reloaded
+// Requests already in flight finish with the previous client
+func (t *Trigger) SetClient(client *Client) {
+	t.client.Store(client)
+}
+
+// Ping checks that Jenkins is reachable and accepts the configured credentials
+func (t *Trigger) Ping() error {
+	// Use context.Background() for now (can be improved to accept context from handler)
+	_, err := t.client.Load().doRequest(context.Background(), "GET", "/api/json?tree=mode", nil)
+	return err
 }
 
 // TriggerBuild triggers a Jenkins build for the given job with the provided parameters
 func (t *Trigger) TriggerBuild(jobName string, params map[string]string) (*engine.BuildResult, error) {
+	return t.triggerBuild(jobName, params, nil)
+}
+
+// TriggerBuildWithCause triggers a build and passes the cause in the cause query parameter
+// Jenkins shows it on the build page ("Started by remote host ... with note: ...") when the
+// request also carries the job's build token (jenkins.build_token)
+func (t *Trigger) TriggerBuildWithCause(jobName string, params map[string]string, cause engine.Cause) (*engine.BuildResult, error) {
+	return t.triggerBuild(jobName, params, &cause)
+}
+
+// triggerBuild triggers a build, with the cause if it is not nil
+func (t *Trigger) triggerBuild(jobName string, params map[string]string, cause *engine.Cause) (*engine.BuildResult, error) {
 	// Validate job name
 	if jobName == "" {
 		return &engine.BuildResult{
@@ -59,14 +100,28 @@ func (t *Trigger) TriggerBuild(jobName string, params map[string]string) (*engin
 	// We'll use a custom method for parameterized builds
 	var buildID string
 	var buildURL string
+	var queueID string
 	var err error
 
 	// Use context.Background() for now (can be improved to accept context from handler)
 	ctx := context.Background()
+	client := t.client.Load()
+
+	// The build token and cause go in the query string so they cannot clash with job parameters
+	query := url.Values{}
+	if client.buildToken != "" {
+		query.Set("token", client.buildToken)
+	}
+	if cause != nil {
+		query.Set("cause", cause.String())
+	}
+	if len(query) > 0 {
+		buildPath += "?" + query.Encode()
+	}
 	if len(params) > 0 {
-		buildID, buildURL, err = t.client.doParameterizedRequest(ctx, buildPath, params)
+		buildID, buildURL, queueID, err = client.doParameterizedRequest(ctx, buildPath, resolveCredentialRefs(params))
 	} else {
-		buildID, buildURL, err = t.client.doBuildRequest(ctx, buildPath)
+		buildID, buildURL, queueID, err = client.doBuildRequest(ctx, buildPath)
 	}
 
 	if err != nil {
@@ -80,7 +135,8 @@ func (t *Trigger) TriggerBuild(jobName string, params map[string]string) (*engin
 		Success:  true,
 		Message:  fmt.Sprintf("Successfully triggered Jenkins build for job %s", jobName),
 		BuildID:  buildID,
-		BuildURL: buildURL,
+		BuildURL: client.publicLink(buildURL),
+		QueueID:  queueID,
 	}, nil
 }
 
@@ -121,7 +177,8 @@ func (t *Trigger) GetBuildStatus(buildID string) (*engine.BuildResult, error) {
 	// Send the request to Jenkins
 	// Use context.Background() for now (can be improved to accept context from handler)
 	ctx := context.Background()
-	respBody, err := t.client.doRequest(ctx, "GET", buildPath, nil)
+	client := t.client.Load()
+	respBody, err := client.doRequest(ctx, "GET", buildPath, nil)
 	if err != nil {
 		return &engine.BuildResult{
 			Success: false,
@@ -138,19 +195,76 @@ func (t *Trigger) GetBuildStatus(buildID string) (*engine.BuildResult, error) {
 			Success:  true,
 			Message:  fmt.Sprintf("Retrieved build status for %s", buildID),
 			BuildID:  buildID,
-			BuildURL: fmt.Sprintf("%s/job/%s/%s/", t.client.url, jobName, buildNumber),
+			BuildURL: client.publicLink(fmt.Sprintf("%s/job/%s/%s/", client.url, jobName, buildNumber)),
 		}, nil
 	}
 
 	buildURL := buildInfo.URL
 	if buildURL == "" {
-		buildURL = fmt.Sprintf("%s/job/%s/%s/", t.client.url, jobName, buildNumber)
+		buildURL = fmt.Sprintf("%s/job/%s/%s/", client.url, jobName, buildNumber)
 	}
 
 	return &engine.BuildResult{
-		Success:  true,
-		Message:  fmt.Sprintf("Retrieved build status for %s", buildID),
-		BuildID:  buildID,
-		BuildURL: buildURL,
+		Success:         true,
+		Message:         fmt.Sprintf("Retrieved build status for %s", buildID),
+		BuildID:         buildID,
+		BuildURL:        client.publicLink(buildURL),
+		Building:        buildInfo.Building,
+		Result:          buildInfo.Result,
+		BuildDurationMS: buildInfo.Duration,
 	}, nil
 }
+
+// ResolveQueuedBuild returns the build ID (jobName/buildNumber) started for a Jenkins queue item,
+// or "" while the item waits in the queue
+func (t *Trigger) ResolveQueuedBuild(queueID string) (string, error) {
+	if queueID == "" || strings.Contains(queueID, "/") {
+		return "", fmt.Errorf("invalid queue item ID: %s", queueID)
+	}
+
+	// Use context.Background() for now (can be improved to accept context from handler)
+	ctx := context.Background()
+	client := t.client.Load()
+	respBody, err := client.doRequest(ctx, "GET", "/queue/item/"+url.PathEscape(queueID)+"/api/json", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var item jenkinsQueueItem
+	if err := json.Unmarshal(respBody, &item); err != nil {
+		return "", fmt.Errorf("failed to parse queue item: %v", err)
+	}
+	if item.Cancelled {
+		return "", fmt.Errorf("queue item %s was cancelled", queueID)
+	}
+	if item.Executable == nil {
+		return "", nil
+	}
+	if buildID, _, _ := client.extractBuildInfo(item.Executable.URL, ""); buildID != "" {
+		return buildID, nil
+	}
+	return fmt.Sprintf("%s/%d", item.Task.Name, item.Executable.Number), nil
+}
+
+// resolveCredentialRefs replaces "@cred:<id>" values with the bare credential ID, the value
+// Jenkins credentials parameters expect; Jenkins resolves the secret itself
+func resolveCredentialRefs(params map[string]string) map[string]string {
+	var resolved map[string]string
+	for key, value := range params {
+		id, ok := engine.ParseCredentialRef(value)
+		if !ok {
+			continue
+		}
+		if resolved == nil {
+			resolved = make(map[string]string, len(params))
+			for k, v := range params {
+				resolved[k] = v
+			}
+		}
+		resolved[key] = id
+	}
+	if resolved == nil {
+		return params
+	}
+	return resolved
+}
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Registry holds the CI engines available to the API, keyed by name (e.g. "jenkins")
+type Registry struct {
+	mu      sync.RWMutex
+	engines map[string]CIEngine
+	names   []string // Registration order
+}
+
+// NewRegistry creates an empty engine registry
+func NewRegistry() *Registry {
+	return &Registry{
+		engines: make(map[string]CIEngine),
+	}
+}
+
+// Register adds an engine under the given name
+func (r *Registry) Register(name string, e CIEngine) error {
+	if name == "" {
+		return fmt.Errorf("engine name cannot be empty")
+	}
+	if e == nil {
+		return fmt.Errorf("engine %q cannot be nil", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.engines[name]; exists {
+		return fmt.Errorf("engine %q is already registered", name)
+	}
+	r.engines[name] = e
+	r.names = append(r.names, name)
+	return nil
+}
+
+// Get returns the engine registered under the given name
+func (r *Registry) Get(name string) (CIEngine, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.engines[name]
+	return e, ok
+}
+
+// Names returns the registered engine names in registration order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]string(nil), r.names...)
+}
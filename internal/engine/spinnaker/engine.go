@@ -0,0 +1,238 @@
+// Package spinnaker implements a CI engine that starts Spinnaker pipeline executions through the Gate API
+package spinnaker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/version"
+)
+
+// maxResponseSize bounds the Gate response read into memory
+const maxResponseSize = 1024 * 1024
+
+// Engine starts Spinnaker pipelines; job names are application/pipeline and parameters become
+// pipeline parameters. Build IDs are execution IDs
+type Engine struct {
+	url      string
+	uiURL    string
+	token    string
+	username string
+	password string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// execution is the part of a pipeline execution that TriggerMesh reads
+type execution struct {
+	ID          string `json:"id"`
+	Application string `json:"application"`
+	Status      string `json:"status"`    // NOT_STARTED, RUNNING, SUCCEEDED, TERMINAL, CANCELED, ...
+	StartTime   int64  `json:"startTime"` // Epoch milliseconds
+	EndTime     int64  `json:"endTime"`
+}
+
+// New creates a Spinnaker engine from its configuration
+func New(cfg config.SpinnakerEngineConfig) *Engine {
+	return &Engine{
+		url:      strings.TrimSuffix(cfg.URL, "/"),
+		uiURL:    strings.TrimSuffix(cfg.UIURL, "/"),
+		token:    cfg.Token,
+		username: cfg.Username,
+		password: cfg.Password,
+		headers:  cfg.Headers,
+		client:   &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+	}
+}
+
+// TriggerBuild starts a manual execution of the pipeline named application/pipeline
+// Credential references ("@cred:<id>") pass the bare ID for the pipeline to resolve
+func (e *Engine) TriggerBuild(jobName string, params map[string]string) (*engine.BuildResult, error) {
+	application, pipeline, ok := strings.Cut(jobName, "/")
+	if !ok || application == "" || pipeline == "" {
+		return &engine.BuildResult{
+			Success: false,
+			Message: "Invalid job name format, expected application/pipeline",
+		}, engine.NewError(engine.ErrorKindNotFound, "job not found: expected application/pipeline")
+	}
+
+	parameters := make(map[string]string, len(params))
+	for name, value := range params {
+		if id, ok := engine.ParseCredentialRef(value); ok {
+			value = id
+		}
+		parameters[name] = value
+	}
+	trigger := map[string]interface{}{
+		"type":       "manual",
+		"user":       "triggermesh",
+		"parameters": parameters,
+	}
+
+	var output struct {
+		Ref string `json:"ref"` // /pipelines/{executionId}
+	}
+	path := fmt.Sprintf("/pipelines/%s/%s", url.PathEscape(application), url.PathEscape(pipeline))
+	if err := e.do(context.Background(), http.MethodPost, path, trigger, &output, true); err != nil {
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to start Spinnaker pipeline: %v", err),
+		}, err
+	}
+
+	executionID := output.Ref[strings.LastIndex(output.Ref, "/")+1:]
+	if executionID == "" {
+		logger.Error("Spinnaker response has no execution reference", "job", jobName)
+		return &engine.BuildResult{
+			Success: false,
+			Message: "Failed to start Spinnaker pipeline: no execution in the response",
+		}, engine.NewError(engine.ErrorKindUnknown, "spinnaker returned no execution")
+	}
+
+	return &engine.BuildResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Successfully started Spinnaker pipeline %s", jobName),
+		BuildID:  executionID,
+		BuildURL: e.executionURL(application, executionID),
+	}, nil
+}
+
+// GetBuildStatus returns the status of a pipeline execution
+func (e *Engine) GetBuildStatus(buildID string) (*engine.BuildResult, error) {
+	if buildID == "" {
+		return &engine.BuildResult{
+			Success: false,
+			Message: "Build ID cannot be empty",
+		}, fmt.Errorf("build ID cannot be empty")
+	}
+
+	var exec execution
+	if err := e.do(context.Background(), http.MethodGet, "/pipelines/"+url.PathEscape(buildID), nil, &exec, false); err != nil {
+		return &engine.BuildResult{
+			Success: false,
+			Message: fmt.Sprintf("Failed to get Spinnaker execution status: %v", err),
+		}, err
+	}
+
+	result := &engine.BuildResult{
+		Success:  true,
+		Message:  fmt.Sprintf("Retrieved build status for %s", buildID),
+		BuildID:  buildID,
+		BuildURL: e.executionURL(exec.Application, buildID),
+	}
+	switch exec.Status {
+	case "SUCCEEDED":
+		result.Result = engine.ResultSuccess
+	case "FAILED_CONTINUE":
+		// Finished, but stages marked "continue on failure" failed
+		result.Result = engine.ResultUnstable
+	case "TERMINAL":
+		result.Result = engine.ResultFailure
+	case "CANCELED", "STOPPED", "SKIPPED":
+		result.Result = engine.ResultAborted
+	default:
+		result.Building = true
+	}
+	if !result.Building && exec.StartTime > 0 && exec.EndTime > 0 {
+		result.BuildDurationMS = exec.EndTime - exec.StartTime
+	}
+	return result, nil
+}
+
+// Ping checks that Gate is reachable
+func (e *Engine) Ping() error {
+	var health map[string]interface{}
+	return e.do(context.Background(), http.MethodGet, "/health", nil, &health, false)
+}
+
+// executionURL returns the Deck page of an execution, or "" without ui_url
+func (e *Engine) executionURL(application, executionID string) string {
+	if e.uiURL == "" || application == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/#/applications/%s/executions/details/%s", e.uiURL, url.PathEscape(application), url.PathEscape(executionID))
+}
+
+// do sends a request to Gate and decodes the JSON response into output
+// On trigger requests a 404 means the application or pipeline does not exist
+func (e *Engine) do(ctx context.Context, method, path string, input, output interface{}, trigger bool) error {
+	var body io.Reader
+	if input != nil {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, e.url+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range e.headers {
+		req.Header.Set(name, value)
+	}
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	} else if e.username != "" {
+		req.SetBasicAuth(e.username, e.password)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logger.Error("Spinnaker API request failed", "path", path, "status", resp.Status, "body", string(respBody))
+		if trigger && resp.StatusCode == http.StatusNotFound {
+			return engine.NewError(engine.ErrorKindNotFound, "job not found")
+		}
+		return formatError(resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(respBody, output); err != nil {
+		return fmt.Errorf("failed to decode Spinnaker response: %w", err)
+	}
+	return nil
+}
+
+// formatError turns a Gate error status into a sanitized engine error
+func formatError(statusCode int) error {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return engine.NewError(engine.ErrorKindAuth, "authentication failed: invalid credentials")
+	case http.StatusForbidden:
+		return engine.NewError(engine.ErrorKindAuth, "access denied: insufficient permissions")
+	case http.StatusNotFound:
+		return engine.NewError(engine.ErrorKindNotFound, "resource not found")
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return engine.NewError(engine.ErrorKindUnknown, "invalid request")
+	case http.StatusGatewayTimeout:
+		return engine.NewError(engine.ErrorKindTimeout, "spinnaker server timed out: please try again later")
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return engine.NewError(engine.ErrorKindServer, "spinnaker server error: please try again later")
+	default:
+		return engine.NewError(engine.ErrorKindUnknown, "spinnaker api request failed")
+	}
+}
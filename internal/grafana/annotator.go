@@ -0,0 +1,145 @@
+// Package grafana posts deploy markers to Grafana as annotations when jobs are triggered or complete
+package grafana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/jobmatch"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/outbound"
+	"triggermesh/internal/storage/models"
+	"triggermesh/internal/version"
+)
+
+// defaultTimeout is the time to wait for Grafana when none is configured
+const defaultTimeout = 5 * time.Second
+
+// Annotation is the body of a Grafana annotation request (POST /api/annotations)
+// Times are Unix milliseconds; an annotation with TimeEnd is a region
+type Annotation struct {
+	DashboardUID string   `json:"dashboardUID,omitempty"`
+	Time         int64    `json:"time"`
+	TimeEnd      int64    `json:"timeEnd,omitempty"`
+	Tags         []string `json:"tags"`
+	Text         string   `json:"text"`
+}
+
+// Annotator posts trigger and completion annotations for the configured jobs
+// Annotations are sent in the background; failures are logged and never affect triggers
+type Annotator struct {
+	endpoint     string
+	token        string
+	jobs         []string
+	dashboardUID string
+	tags         []string
+	timeout      time.Duration
+	client       *http.Client
+}
+
+// NewAnnotator creates an annotator from the Grafana configuration
+// Requests go through the outbound URL policy, if any
+func NewAnnotator(cfg config.GrafanaConfig, policy *outbound.Policy) *Annotator {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Annotator{
+		endpoint:     strings.TrimRight(cfg.URL, "/") + "/api/annotations",
+		token:        cfg.Token,
+		jobs:         cfg.Jobs,
+		dashboardUID: cfg.DashboardUID,
+		tags:         cfg.Tags,
+		timeout:      timeout,
+		client:       policy.Client(timeout),
+	}
+}
+
+// Matches reports whether annotations are posted for the job
+func (a *Annotator) Matches(job string) bool {
+	return len(a.jobs) == 0 || jobmatch.MatchAny(a.jobs, job)
+}
+
+// Triggered marks a build triggered at the given time
+func (a *Annotator) Triggered(engineName, job, buildID string, at time.Time) {
+	if !a.Matches(job) {
+		return
+	}
+	text := fmt.Sprintf("Triggered %s on %s", job, engineName)
+	if buildID != "" {
+		text += fmt.Sprintf(" (build %s)", buildID)
+	}
+	go a.send(a.annotation(engineName, job, "triggered", text, at, time.Time{}))
+}
+
+// Completed marks a finished build as a region from its trigger to its completion
+func (a *Annotator) Completed(build models.TrackedBuild, outcome models.BuildOutcome) {
+	if !a.Matches(build.JobName) {
+		return
+	}
+	text := fmt.Sprintf("%s on %s finished: %s (build %s)", build.JobName, build.Engine, outcome.Result, build.BuildID)
+	annotation := a.annotation(build.Engine, build.JobName, "completed", text, build.TriggeredAt, outcome.FinishedAt)
+	annotation.Tags = append(annotation.Tags, "result:"+strings.ToLower(outcome.Result))
+	go a.send(annotation)
+}
+
+// annotation builds an annotation tagged with the engine, job, and event; a zero end is a point in time
+func (a *Annotator) annotation(engineName, job, event, text string, start, end time.Time) Annotation {
+	tags := []string{"triggermesh", event, "engine:" + engineName, "job:" + job}
+	annotation := Annotation{
+		DashboardUID: a.dashboardUID,
+		Time:         start.UnixMilli(),
+		Tags:         append(tags, a.tags...),
+		Text:         text,
+	}
+	if !end.IsZero() {
+		annotation.TimeEnd = end.UnixMilli()
+	}
+	return annotation
+}
+
+// send posts the annotation and logs failures
+func (a *Annotator) send(annotation Annotation) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	if err := a.Post(ctx, annotation); err != nil {
+		logger.Error("Failed to post Grafana annotation", "error", err, "text", annotation.Text)
+	}
+}
+
+// Post sends one annotation to Grafana
+func (a *Annotator) Post(ctx context.Context, annotation Annotation) error {
+	body, err := json.Marshal(annotation)
+	if err != nil {
+		return fmt.Errorf("failed to marshal annotation: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create annotation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent())
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send annotation: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("grafana returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// Package i18n translates client-facing error messages
+// Messages are written in English, the source language; catalogs map an English message, or a
+// template with fmt verbs (%s, %d, %v) for formatted messages, to its translation
+// Error codes are never translated
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is the language messages are written in
+const DefaultLanguage = "en"
+
+//go:embed catalogs/*.json
+var catalogFS embed.FS
+
+// verbRegex matches the fmt verbs allowed in catalog templates
+var verbRegex = regexp.MustCompile(`%(\[[0-9]+\])?[sdv]`)
+
+// catalog holds the translations of one language
+type catalog struct {
+	exact     map[string]string // Messages without verbs
+	templates []template        // Messages with verbs, most specific first
+}
+
+// template translates formatted messages by capturing the formatted values
+type template struct {
+	pattern     *regexp.Regexp
+	translation string // Translation with every verb rewritten to %s, since captured values are strings
+}
+
+// catalogs maps a language to its translations
+var catalogs = mustLoadCatalogs()
+
+// mustLoadCatalogs parses the embedded catalogs; they are part of the binary, so errors are bugs
+func mustLoadCatalogs() map[string]*catalog {
+	entries, err := catalogFS.ReadDir("catalogs")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: failed to read catalogs: %v", err))
+	}
+	loaded := make(map[string]*catalog)
+	for _, entry := range entries {
+		data, err := catalogFS.ReadFile(path.Join("catalogs", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: failed to read catalog %s: %v", entry.Name(), err))
+		}
+		c, err := parseCatalog(data)
+		if err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", entry.Name(), err))
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = c
+	}
+	return loaded
+}
+
+// parseCatalog parses a JSON object mapping English messages to translations
+func parseCatalog(data []byte) (*catalog, error) {
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, err
+	}
+
+	c := &catalog{exact: make(map[string]string)}
+	for message, translation := range messages {
+		verbs := verbRegex.FindAllString(message, -1)
+		if len(verbs) == 0 {
+			c.exact[message] = translation
+			continue
+		}
+		if len(verbRegex.FindAllString(translation, -1)) != len(verbs) {
+			return nil, fmt.Errorf("translation of %q must keep its %d verbs", message, len(verbs))
+		}
+
+		pattern := regexp.QuoteMeta(message)
+		pattern = verbRegex.ReplaceAllStringFunc(pattern, func(verb string) string {
+			if strings.HasSuffix(verb, "d") {
+				return `(-?[0-9]+)`
+			}
+			return `(.*)`
+		})
+		c.templates = append(c.templates, template{
+			pattern:     regexp.MustCompile("^" + pattern + "$"),
+			translation: verbRegex.ReplaceAllString(translation, "%${1}s"),
+		})
+	}
+
+	// Longer templates have more literal text and are tried first, so
+	// "Failed to trigger build: engine request timed out" wins over "Failed to trigger build: %s"
+	sort.Slice(c.templates, func(i, j int) bool {
+		return len(c.templates[i].pattern.String()) > len(c.templates[j].pattern.String())
+	})
+	return c, nil
+}
+
+// Languages returns the supported languages, the default first
+func Languages() []string {
+	languages := []string{DefaultLanguage}
+	for language := range catalogs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages[1:])
+	return languages
+}
+
+// Negotiate picks the supported language the client prefers most in an Accept-Language header
+// Regional variants fall back to their base language (zh-CN uses zh); without a match the
+// default language is returned
+func Negotiate(acceptLanguage string) string {
+	best, bestQ := DefaultLanguage, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, q := parseLanguageRange(part)
+		if q <= bestQ {
+			continue
+		}
+		if language, ok := supported(tag); ok {
+			best, bestQ = language, q
+		}
+	}
+	return best
+}
+
+// parseLanguageRange parses one Accept-Language entry such as "zh-CN;q=0.8"
+func parseLanguageRange(part string) (string, float64) {
+	tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+	q := 1.0
+	for _, param := range strings.Split(params, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || name != "q" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", 0
+		}
+		q = parsed
+	}
+	return strings.ToLower(strings.TrimSpace(tag)), q
+}
+
+// supported returns the supported language matching a language tag
+func supported(tag string) (string, bool) {
+	if tag == "*" {
+		return DefaultLanguage, true
+	}
+	base, _, _ := strings.Cut(tag, "-")
+	if base == DefaultLanguage {
+		return DefaultLanguage, true
+	}
+	if _, ok := catalogs[base]; ok {
+		return base, true
+	}
+	return "", false
+}
+
+// Translate returns the message in the language, or the message itself when the
+// language or message has no translation
+func Translate(language, message string) string {
+	c, ok := catalogs[language]
+	if !ok {
+		return message
+	}
+	if translation, ok := c.exact[message]; ok {
+		return translation
+	}
+	for _, t := range c.templates {
+		match := t.pattern.FindStringSubmatch(message)
+		if match == nil {
+			continue
+		}
+		args := make([]interface{}, len(match)-1)
+		for i, value := range match[1:] {
+			args[i] = value
+		}
+		return fmt.Sprintf(t.translation, args...)
+	}
+	return message
+}
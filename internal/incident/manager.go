@@ -0,0 +1,211 @@
+// Package incident opens and resolves PagerDuty or Opsgenie incidents when critical jobs fail repeatedly
+package incident
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/jobmatch"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/outbound"
+)
+
+const (
+	// sendTimeout bounds a single request to the incident provider
+	sendTimeout = 10 * time.Second
+	// queueSize is the number of incident events waiting for delivery before new ones are dropped
+	queueSize = 100
+)
+
+// Incident describes repeated trigger failures of a critical job
+type Incident struct {
+	Key       string // Stable identifier, used to deduplicate and resolve the incident
+	Engine    string
+	Job       string
+	Severity  string // critical, error, warning, or info
+	Failures  int    // Consecutive failed triggers
+	LastError string
+	OpenedAt  time.Time
+}
+
+// Summary returns a one-line human readable description of the incident
+func (i Incident) Summary() string {
+	return fmt.Sprintf("TriggerMesh: %d consecutive trigger failures for job %s on %s", i.Failures, i.Job, i.Engine)
+}
+
+// Provider opens and resolves incidents in an incident management system
+type Provider interface {
+	Open(ctx context.Context, incident Incident) error
+	Resolve(ctx context.Context, incident Incident) error
+}
+
+// NewProvider creates the provider described by the configuration
+// Requests go through the outbound URL policy, if any
+func NewProvider(cfg config.IncidentsConfig, policy *outbound.Policy) (Provider, error) {
+	client := policy.Client(sendTimeout)
+	switch cfg.Provider {
+	case config.IncidentProviderPagerDuty:
+		return newPagerDuty(cfg, client), nil
+	case config.IncidentProviderOpsgenie:
+		return newOpsgenie(cfg, client), nil
+	default:
+		return nil, fmt.Errorf("unknown incident provider: %s", cfg.Provider)
+	}
+}
+
+// event is an incident to open or resolve
+type event struct {
+	incident Incident
+	resolve  bool
+}
+
+// jobState is the failure streak of a job
+type jobState struct {
+	failures int
+	open     *Incident // Incident opened for the streak; nil if none
+}
+
+// Manager counts consecutive trigger failures of critical jobs, opens an incident when a job
+// reaches the threshold, and resolves it on the job's next successful trigger
+// Events are delivered in order by a background goroutine; state is not persisted, so incidents
+// open at a restart are not resolved automatically
+type Manager struct {
+	provider  Provider
+	jobs      []config.IncidentJobConfig
+	threshold int
+
+	mu     sync.Mutex
+	states map[string]*jobState
+	closed bool
+
+	events chan event
+	done   chan struct{} // Closed when the delivery goroutine exits
+	now    func() time.Time
+}
+
+// NewManager creates a manager that reports to the provider and starts its delivery goroutine
+func NewManager(cfg config.IncidentsConfig, provider Provider) *Manager {
+	m := &Manager{
+		provider:  provider,
+		jobs:      cfg.Jobs,
+		threshold: cfg.FailureThreshold,
+		states:    make(map[string]*jobState),
+		events:    make(chan event, queueSize),
+		done:      make(chan struct{}),
+		now:       time.Now,
+	}
+	go m.deliver()
+	return m
+}
+
+// severity returns the severity of the first job entry matching the job, or false if the job is not critical
+func (m *Manager) severity(job string) (string, bool) {
+	for _, jobCfg := range m.jobs {
+		if jobmatch.Match(jobCfg.Pattern, job) {
+			return jobCfg.Severity, true
+		}
+	}
+	return "", false
+}
+
+// Record adds a trigger outcome for the job; err is nil for successful triggers
+func (m *Manager) Record(engineName, job string, err error) {
+	severity, ok := m.severity(job)
+	if !ok {
+		return
+	}
+
+	key := "triggermesh/" + engineName + "/" + job
+	m.mu.Lock()
+	state, ok := m.states[key]
+	if !ok {
+		state = &jobState{}
+		m.states[key] = state
+	}
+
+	var ev *event
+	if err == nil {
+		if state.open != nil {
+			ev = &event{incident: *state.open, resolve: true}
+		}
+		delete(m.states, key)
+	} else {
+		state.failures++
+		if state.open == nil && state.failures >= m.threshold {
+			state.open = &Incident{
+				Key:       key,
+				Engine:    engineName,
+				Job:       job,
+				Severity:  severity,
+				Failures:  state.failures,
+				LastError: err.Error(),
+				OpenedAt:  m.now(),
+			}
+			ev = &event{incident: *state.open}
+		}
+	}
+	m.mu.Unlock()
+
+	if ev == nil {
+		return
+	}
+	if ev.resolve {
+		logger.Info("Resolving incident", "engine", engineName, "job", job)
+	} else {
+		logger.Warn("Opening incident for repeated trigger failures", "engine", engineName, "job", job,
+			"severity", severity, "failures", ev.incident.Failures)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		logger.Error("Incident manager is closed, dropping incident event", "engine", engineName, "job", job, "resolve", ev.resolve)
+		return
+	}
+	select {
+	case m.events <- *ev:
+	default:
+		logger.Error("Incident queue is full, dropping incident event", "engine", engineName, "job", job, "resolve", ev.resolve)
+	}
+}
+
+// Close delivers the queued events and stops the delivery goroutine; later events are dropped
+func (m *Manager) Close() {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.events)
+	}
+	m.mu.Unlock()
+	<-m.done
+}
+
+// deliver sends queued events to the provider, logging failures
+func (m *Manager) deliver() {
+	defer close(m.done)
+	for ev := range m.events {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		var err error
+		if ev.resolve {
+			err = m.provider.Resolve(ctx, ev.incident)
+		} else {
+			err = m.provider.Open(ctx, ev.incident)
+		}
+		cancel()
+		if err != nil {
+			logger.Error("Failed to deliver incident event", "error", err, "engine", ev.incident.Engine,
+				"job", ev.incident.Job, "resolve", ev.resolve)
+		}
+	}
+}
+
+// checkStatus fails on non-2xx provider responses
+func checkStatus(resp *http.Response, provider string) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned status %d", provider, resp.StatusCode)
+	}
+	return nil
+}
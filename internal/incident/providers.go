@@ -0,0 +1,166 @@
+package incident
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/version"
+)
+
+// Default API base URLs of the providers
+const (
+	defaultPagerDutyURL = "https://events.pagerduty.com"
+	defaultOpsgenieURL  = "https://api.opsgenie.com"
+)
+
+// opsgenieMessageLength is the longest alert message Opsgenie accepts
+const opsgenieMessageLength = 130
+
+// opsgeniePriorities maps severities to Opsgenie alert priorities
+var opsgeniePriorities = map[string]string{
+	config.SeverityCritical: "P1",
+	config.SeverityError:    "P2",
+	config.SeverityWarning:  "P3",
+	config.SeverityInfo:     "P5",
+}
+
+// PagerDuty sends incidents to the PagerDuty Events API v2
+type PagerDuty struct {
+	url        string
+	routingKey string
+	client     *http.Client
+}
+
+func newPagerDuty(cfg config.IncidentsConfig, client *http.Client) *PagerDuty {
+	base := cfg.URL
+	if base == "" {
+		base = defaultPagerDutyURL
+	}
+	return &PagerDuty{url: strings.TrimRight(base, "/") + "/v2/enqueue", routingKey: cfg.RoutingKey, client: client}
+}
+
+// Open implements Provider by sending a trigger event deduplicated by the incident key
+func (p *PagerDuty) Open(ctx context.Context, incident Incident) error {
+	return p.send(ctx, map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    incident.Key,
+		"payload": map[string]interface{}{
+			"summary":   incident.Summary(),
+			"source":    "triggermesh",
+			"severity":  incident.Severity,
+			"component": incident.Job,
+			"group":     incident.Engine,
+			"timestamp": incident.OpenedAt.UTC().Format(time.RFC3339),
+			"custom_details": map[string]interface{}{
+				"failures":   incident.Failures,
+				"last_error": incident.LastError,
+			},
+		},
+	})
+}
+
+// Resolve implements Provider by sending a resolve event for the incident key
+func (p *PagerDuty) Resolve(ctx context.Context, incident Incident) error {
+	return p.send(ctx, map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": "resolve",
+		"dedup_key":    incident.Key,
+	})
+}
+
+func (p *PagerDuty) send(ctx context.Context, body interface{}) error {
+	resp, err := postJSON(ctx, p.client, p.url, nil, body)
+	if err != nil {
+		return err
+	}
+	return checkStatus(resp, "pagerduty")
+}
+
+// Opsgenie sends incidents to the Opsgenie Alert API
+type Opsgenie struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func newOpsgenie(cfg config.IncidentsConfig, client *http.Client) *Opsgenie {
+	base := cfg.URL
+	if base == "" {
+		base = defaultOpsgenieURL
+	}
+	return &Opsgenie{url: strings.TrimRight(base, "/") + "/v2/alerts", apiKey: cfg.APIKey, client: client}
+}
+
+// Open implements Provider by creating an alert whose alias is the incident key
+func (o *Opsgenie) Open(ctx context.Context, incident Incident) error {
+	message := incident.Summary()
+	if len(message) > opsgenieMessageLength {
+		message = message[:opsgenieMessageLength]
+	}
+	return o.send(ctx, o.url, map[string]interface{}{
+		"message":     message,
+		"alias":       incident.Key,
+		"description": "Last error: " + incident.LastError,
+		"priority":    opsgeniePriorities[incident.Severity],
+		"source":      "TriggerMesh",
+		"tags":        []string{"triggermesh", "engine:" + incident.Engine, "job:" + incident.Job},
+		"details": map[string]string{
+			"engine":   incident.Engine,
+			"job":      incident.Job,
+			"failures": strconv.Itoa(incident.Failures),
+		},
+	})
+}
+
+// Resolve implements Provider by closing the alert with the incident key as alias
+func (o *Opsgenie) Resolve(ctx context.Context, incident Incident) error {
+	endpoint := o.url + "/" + url.PathEscape(incident.Key) + "/close?identifierType=alias"
+	return o.send(ctx, endpoint, map[string]string{
+		"source": "TriggerMesh",
+		"note":   "Job " + incident.Job + " triggered successfully",
+	})
+}
+
+func (o *Opsgenie) send(ctx context.Context, endpoint string, body interface{}) error {
+	resp, err := postJSON(ctx, o.client, endpoint, map[string]string{"Authorization": "GenieKey " + o.apiKey}, body)
+	if err != nil {
+		return err
+	}
+	return checkStatus(resp, "opsgenie")
+}
+
+// postJSON posts the body as JSON with the extra headers; the response body is drained and closed
+func postJSON(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal incident event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create incident request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent())
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send incident event: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp, nil
+}
@@ -0,0 +1,40 @@
+package jobmatch
+
+import "strings"
+
+// Match reports whether a job name matches a pattern
+// "*" matches any sequence of characters including folder separators, so
+// "team-a/*" matches every job below the team-a folder; matching is case-sensitive
+func Match(pattern, job string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == job
+	}
+
+	// The first and last parts are anchored to the start and end of the name
+	if !strings.HasPrefix(job, parts[0]) {
+		return false
+	}
+	job = job[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(job, part)
+		if idx < 0 {
+			return false
+		}
+		job = job[idx+len(part):]
+	}
+
+	return len(job) >= len(last) && strings.HasSuffix(job, last)
+}
+
+// MatchAny reports whether a job name matches any of the patterns
+func MatchAny(patterns []string, job string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, job) {
+			return true
+		}
+	}
+	return false
+}
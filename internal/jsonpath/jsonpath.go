@@ -0,0 +1,122 @@
+// Package jsonpath evaluates the subset of JSONPath used to pick values out of CI engine responses:
+// a root ($), member access (.name or ['name']), and array indexes ([0])
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// step is one member name or array index of a path
+type step struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+// Path is a compiled JSONPath expression
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// Parse compiles an expression such as $.build.id, $.items[0].state, or $['build-id']
+func Parse(expr string) (*Path, error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("JSONPath %q must start with $", expr)
+	}
+	p := &Path{expr: expr}
+	rest := expr[1:]
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" {
+				return nil, fmt.Errorf("JSONPath %q has an empty member name", expr)
+			}
+			p.steps = append(p.steps, step{name: name})
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("JSONPath %q has an unterminated [", expr)
+			}
+			inner := rest[1:end]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				p.steps = append(p.steps, step{name: inner[1 : len(inner)-1]})
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("JSONPath %q has an invalid index %q", expr, inner)
+				}
+				p.steps = append(p.steps, step{index: index, isIndex: true})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("JSONPath %q has unexpected %q", expr, rest[:1])
+		}
+	}
+	return p, nil
+}
+
+// String returns the expression the path was parsed from
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Lookup returns the value at the path in a decoded JSON document
+func (p *Path) Lookup(doc interface{}) (interface{}, bool) {
+	for _, s := range p.steps {
+		if s.isIndex {
+			items, ok := doc.([]interface{})
+			if !ok || s.index >= len(items) {
+				return nil, false
+			}
+			doc = items[s.index]
+			continue
+		}
+		fields, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if doc, ok = fields[s.name]; !ok {
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// LookupString returns the scalar at the path as text; numbers keep their JSON form
+func (p *Path) LookupString(doc interface{}) (string, error) {
+	value, ok := p.Lookup(doc)
+	if !ok {
+		return "", fmt.Errorf("%s not found in response", p.expr)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case nil:
+		return "", fmt.Errorf("%s is null in response", p.expr)
+	default:
+		return "", fmt.Errorf("%s is not a string, number, or boolean", p.expr)
+	}
+}
+
+// Decode parses a JSON response body for Lookup
+func Decode(body []byte) (interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	return doc, nil
+}
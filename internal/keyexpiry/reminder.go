@@ -0,0 +1,158 @@
+// Package keyexpiry notifies API key owners before their keys expire
+package keyexpiry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"triggermesh/internal/alert"
+	"triggermesh/internal/config"
+	"triggermesh/internal/logger"
+)
+
+// defaultInterval is the time between expiry checks when none is configured
+const defaultInterval = time.Hour
+
+// Key is an API key with an expiry date
+type Key struct {
+	Client    string    // Client name of the key
+	Owner     string    // Contact to remind; may be empty
+	ExpiresAt time.Time // Never zero
+}
+
+// Reminder is the notification sent before a key expires
+type Reminder struct {
+	Client    string    `json:"client"`
+	Owner     string    `json:"owner,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	DaysLeft  int       `json:"days_left"`
+}
+
+// Summary returns a one-line human readable description of the reminder
+func (r Reminder) Summary() string {
+	owner := ""
+	if r.Owner != "" {
+		owner = fmt.Sprintf(" (owner %s)", r.Owner)
+	}
+	return fmt.Sprintf("TriggerMesh: API key of client %s%s expires in %d day(s), at %s; request or configure a new key before then",
+		r.Client, owner, r.DaysLeft, r.ExpiresAt.UTC().Format(time.RFC3339))
+}
+
+// KeysFunc returns the API keys that expire before the given time
+type KeysFunc func(before time.Time) ([]Key, error)
+
+// Watcher periodically reminds the owners of keys that expire within the configured number of days
+// Each key is reminded once per expiry date; reminders are not persisted, so a restart may repeat one
+type Watcher struct {
+	interval  time.Duration
+	notice    time.Duration
+	keys      KeysFunc
+	notifiers []alert.Notifier
+
+	mu       sync.Mutex
+	reminded map[string]bool // Client and expiry of keys already reminded
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewWatcher creates a watcher from the reminder configuration
+func NewWatcher(cfg config.KeyExpiryReminderConfig, keys KeysFunc) (*Watcher, error) {
+	notifiers := make([]alert.Notifier, 0, len(cfg.Notifiers))
+	for _, notifierCfg := range cfg.Notifiers {
+		notifier, err := alert.NewNotifier(notifierCfg)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, notifier)
+	}
+
+	interval := time.Duration(cfg.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return &Watcher{
+		interval:  interval,
+		notice:    time.Duration(cfg.Days) * 24 * time.Hour,
+		keys:      keys,
+		notifiers: notifiers,
+		reminded:  make(map[string]bool),
+	}, nil
+}
+
+// Start checks keys in the background, once immediately and then every interval, until Stop is called
+func (w *Watcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := w.Check(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				logger.Error("API key expiry check failed", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the watcher and waits for an in-flight check to finish
+func (w *Watcher) Stop() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+}
+
+// Check sends reminders for keys that expire within the notice period after now and returns how many were sent
+// Keys that have already expired are not reminded; requests with them fail with a KEY_EXPIRED error
+func (w *Watcher) Check(ctx context.Context, now time.Time) (int, error) {
+	keys, err := w.keys(now.Add(w.notice))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, key := range keys {
+		if !key.ExpiresAt.After(now) || key.ExpiresAt.Sub(now) > w.notice {
+			continue
+		}
+		id := key.Client + "@" + key.ExpiresAt.UTC().Format(time.RFC3339Nano)
+		w.mu.Lock()
+		done := w.reminded[id]
+		w.reminded[id] = true
+		w.mu.Unlock()
+		if done {
+			continue
+		}
+
+		reminder := Reminder{
+			Client:    key.Client,
+			Owner:     key.Owner,
+			ExpiresAt: key.ExpiresAt,
+			DaysLeft:  int(math.Ceil(key.ExpiresAt.Sub(now).Hours() / 24)),
+		}
+		logger.Warn("API key expires soon", "client", key.Client, "owner", key.Owner, "expires_at", key.ExpiresAt)
+		for _, notifier := range w.notifiers {
+			if err := notifier.Notify(ctx, reminder); err != nil {
+				logger.Error("Failed to send API key expiry reminder", "error", err, "client", key.Client)
+			}
+		}
+		sent++
+	}
+	return sent, nil
+}
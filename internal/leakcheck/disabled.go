@@ -0,0 +1,6 @@
+//go:build !leakcheck
+
+package leakcheck
+
+// Enabled reports whether the test binary was built with -tags leakcheck
+const Enabled = false
@@ -0,0 +1,178 @@
+// Package leakcheck finds goroutines that outlive the test (or test binary) that started them,
+// e.g. a poller whose stop hook does not wait for it, or a server that is never shut down
+package leakcheck
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// defaultTimeout is how long leaked goroutines are given to exit before they are reported
+const defaultTimeout = 5 * time.Second
+
+// defaultIgnored are functions of goroutines owned by the standard library rather than the code
+// under test, e.g. idle keep-alive connections of HTTP clients
+var defaultIgnored = []string{
+	"net/http.(*persistConn).readLoop",
+	"net/http.(*persistConn).writeLoop",
+}
+
+// Option configures a check
+type Option func(*options)
+
+type options struct {
+	timeout time.Duration
+	ignored []string
+}
+
+// Timeout sets how long goroutines are given to exit before they are reported (default: 5s)
+func Timeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// IgnoreFunction ignores goroutines with the function (e.g. "database/sql.(*DB).connectionOpener")
+// anywhere in their stack
+func IgnoreFunction(name string) Option {
+	return func(o *options) {
+		o.ignored = append(o.ignored, name)
+	}
+}
+
+// Goroutine is a running goroutine
+type Goroutine struct {
+	ID    uint64
+	Stack string // Stack trace in runtime.Stack format, starting with the "goroutine N [state]:" line
+}
+
+// Snapshot is the set of goroutines running at a point in time
+type Snapshot struct {
+	ids map[uint64]bool
+}
+
+// Take records the running goroutines
+func Take() Snapshot {
+	goroutines := running()
+	ids := make(map[uint64]bool, len(goroutines))
+	for _, g := range goroutines {
+		ids[g.ID] = true
+	}
+	return Snapshot{ids: ids}
+}
+
+// Leaked waits until every goroutine started since the snapshot has exited and returns those still
+// running after the timeout. The calling goroutine and ignored goroutines are never reported
+func (s Snapshot) Leaked(opts ...Option) []Goroutine {
+	o := options{timeout: defaultTimeout, ignored: defaultIgnored}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	deadline := time.Now().Add(o.timeout)
+	delay := time.Millisecond
+	for {
+		var leaked []Goroutine
+		for i, g := range running() {
+			// runtime.Stack lists the calling goroutine first
+			if i == 0 || s.ids[g.ID] || g.ignored(o.ignored) {
+				continue
+			}
+			leaked = append(leaked, g)
+		}
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(delay)
+		if delay < 100*time.Millisecond {
+			delay *= 2
+		}
+	}
+}
+
+// Verify fails the test if goroutines it started are still running when it ends (after its
+// other cleanup functions). Call it first in the test; parallel tests cannot be told apart
+func Verify(t testing.TB, opts ...Option) {
+	t.Helper()
+
+	snapshot := Take()
+	t.Cleanup(func() {
+		if leaked := snapshot.Leaked(opts...); len(leaked) > 0 {
+			t.Errorf("%s", report(leaked))
+		}
+	})
+}
+
+// VerifyTestMain runs the tests of m and, in builds with the leakcheck tag, fails the test binary if
+// goroutines started by the tests are still running afterwards
+func VerifyTestMain(m *testing.M, opts ...Option) {
+	if !Enabled {
+		os.Exit(m.Run())
+	}
+
+	snapshot := Take()
+	code := m.Run()
+	if code == 0 {
+		if leaked := snapshot.Leaked(opts...); len(leaked) > 0 {
+			fmt.Fprintln(os.Stderr, report(leaked))
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+// report describes leaked goroutines with their stacks
+func report(leaked []Goroutine) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "found %d leaked goroutine(s):", len(leaked))
+	for _, g := range leaked {
+		b.WriteString("\n\n")
+		b.WriteString(g.Stack)
+	}
+	return b.String()
+}
+
+// ignored reports whether one of the functions is in the goroutine's stack
+func (g Goroutine) ignored(functions []string) bool {
+	for _, function := range functions {
+		if strings.Contains(g.Stack, "\n"+function+"(") {
+			return true
+		}
+	}
+	return false
+}
+
+// running returns the running goroutines, the calling goroutine first
+func running() []Goroutine {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var goroutines []Goroutine
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		// Each stack starts with "goroutine 12 [running]:"
+		header, _, _ := strings.Cut(string(stack), "\n")
+		fields := strings.Fields(header)
+		if len(fields) < 2 || fields[0] != "goroutine" {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		goroutines = append(goroutines, Goroutine{ID: id, Stack: strings.TrimSpace(string(stack))})
+	}
+	return goroutines
+}
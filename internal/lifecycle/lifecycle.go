@@ -0,0 +1,113 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"triggermesh/internal/logger"
+)
+
+// Hook is a component's start and stop functions
+// Either function may be nil
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Manager starts hooks in registration order and stops them in reverse order
+// Each hook call is bounded by the manager's timeout
+type Manager struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	hooks   []Hook
+	started int // Number of hooks (from the start of hooks) that have been started
+}
+
+// NewManager creates a lifecycle manager that allows each hook up to timeout to start or stop
+func NewManager(timeout time.Duration) *Manager {
+	return &Manager{
+		timeout: timeout,
+	}
+}
+
+// Append registers a hook; hooks appended after Start are not started
+func (m *Manager) Append(hook Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks = append(m.hooks, hook)
+}
+
+// Start runs the OnStart hooks in order
+// If a hook fails, the hooks already started are stopped and the error is returned
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for m.started < len(m.hooks) {
+		hook := m.hooks[m.started]
+		if hook.OnStart != nil {
+			logger.Debug("Starting component", "component", hook.Name)
+			if err := m.run(ctx, hook.Name, hook.OnStart); err != nil {
+				if stopErr := m.stopStarted(ctx); stopErr != nil {
+					logger.Error("Failed to stop components after start failure", "error", stopErr)
+				}
+				return fmt.Errorf("failed to start %s: %w", hook.Name, err)
+			}
+		}
+		m.started++
+	}
+	return nil
+}
+
+// Stop runs the OnStop hooks of started components in reverse order
+// Every hook is given a chance to stop; the errors are joined
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.stopStarted(ctx)
+}
+
+// stopStarted stops the started hooks in reverse order; the caller holds m.mu
+func (m *Manager) stopStarted(ctx context.Context) error {
+	var errs []error
+	for ; m.started > 0; m.started-- {
+		hook := m.hooks[m.started-1]
+		if hook.OnStop == nil {
+			continue
+		}
+		logger.Debug("Stopping component", "component", hook.Name)
+		if err := m.run(ctx, hook.Name, hook.OnStop); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", hook.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// run calls fn with a context bounded by the manager's timeout
+// A hook that ignores its context is abandoned when the timeout expires
+func (m *Manager) run(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		logger.Warn("Component did not finish in time", "component", name, "timeout", m.timeout.String())
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,244 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"triggermesh/internal/api"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine/jenkins"
+	"triggermesh/internal/storage"
+)
+
+// Options configures a load test run
+type Options struct {
+	// TargetURL is the TriggerMesh base URL; empty runs an in-process server against a mock Jenkins
+	TargetURL string
+	// APIKey is the key used for trigger requests against TargetURL
+	APIKey string
+	// Job is the job name sent in trigger requests
+	Job string
+	// Requests is the total number of trigger requests to send
+	Requests int
+	// Concurrency is the number of concurrent workers
+	Concurrency int
+	// JenkinsLatency is the artificial latency of the mock Jenkins (in-process mode only)
+	JenkinsLatency time.Duration
+}
+
+// Report summarizes a load test run
+type Report struct {
+	Requests   int
+	Succeeded  int
+	Failed     int
+	Duration   time.Duration
+	Throughput float64 // Requests per second
+	Min        time.Duration
+	Mean       time.Duration
+	P50        time.Duration
+	P95        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+}
+
+// String formats the report for terminal output
+func (r *Report) String() string {
+	return fmt.Sprintf(
+		"requests=%d succeeded=%d failed=%d duration=%s throughput=%.1f req/s\nlatency min=%s mean=%s p50=%s p95=%s p99=%s max=%s",
+		r.Requests, r.Succeeded, r.Failed, r.Duration.Round(time.Millisecond), r.Throughput,
+		r.Min, r.Mean, r.P50, r.P95, r.P99, r.Max,
+	)
+}
+
+// Run drives concurrent trigger requests and reports throughput and latency
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if opts.Requests <= 0 {
+		return nil, fmt.Errorf("requests must be positive")
+	}
+	if opts.Concurrency <= 0 {
+		return nil, fmt.Errorf("concurrency must be positive")
+	}
+	if opts.Job == "" {
+		opts.Job = "loadtest-job"
+	}
+
+	targetURL := opts.TargetURL
+	apiKey := opts.APIKey
+	if targetURL == "" {
+		url, key, cleanup, err := startInProcessServer(opts.JenkinsLatency)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		targetURL, apiKey = url, key
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"job":        opts.Job,
+		"parameters": map[string]string{"source": "loadtest"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Timeout: 60 * time.Second,
+		Transport: &http.Transport{
+			MaxIdleConnsPerHost: opts.Concurrency,
+		},
+	}
+
+	latencies := make([]time.Duration, opts.Requests)
+	failures := make([]bool, opts.Requests)
+	work := make(chan int)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				latencies[i], failures[i] = sendTrigger(ctx, client, targetURL, apiKey, body)
+			}
+		}()
+	}
+
+	for i := 0; i < opts.Requests; i++ {
+		select {
+		case work <- i:
+		case <-ctx.Done():
+			close(work)
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	return buildReport(latencies, failures, time.Since(start)), nil
+}
+
+// sendTrigger sends a single trigger request and returns its latency and whether it failed
+func sendTrigger(ctx context.Context, client *http.Client, targetURL, apiKey string, body []byte) (time.Duration, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL+"/api/v1/trigger/jenkins", bytes.NewReader(body))
+	if err != nil {
+		return 0, true
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return time.Since(start), true
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return time.Since(start), resp.StatusCode != http.StatusOK
+}
+
+// buildReport computes summary statistics from per-request latencies
+func buildReport(latencies []time.Duration, failures []bool, elapsed time.Duration) *Report {
+	report := &Report{
+		Requests: len(latencies),
+		Duration: elapsed,
+	}
+	for _, failed := range failures {
+		if failed {
+			report.Failed++
+		}
+	}
+	report.Succeeded = report.Requests - report.Failed
+	if elapsed > 0 {
+		report.Throughput = float64(report.Requests) / elapsed.Seconds()
+	}
+
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	report.Min = sorted[0]
+	report.Max = sorted[len(sorted)-1]
+	report.Mean = total / time.Duration(len(sorted))
+	report.P50 = percentile(sorted, 50)
+	report.P95 = percentile(sorted, 95)
+	report.P99 = percentile(sorted, 99)
+
+	return report
+}
+
+// percentile returns the p-th percentile of sorted latencies (nearest-rank method)
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// startInProcessServer starts a mock Jenkins and a TriggerMesh router backed by a temporary database
+func startInProcessServer(jenkinsLatency time.Duration) (string, string, func(), error) {
+	mockJenkins := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if jenkinsLatency > 0 {
+			time.Sleep(jenkinsLatency)
+		}
+		if r.URL.Path == "/crumbIssuer/api/json" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"crumb":"loadtest-crumb","crumbRequestField":"Jenkins-Crumb"}`))
+			return
+		}
+		w.Header().Set("Location", "/queue/item/1/")
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	dbFile, err := os.CreateTemp("", "triggermesh-loadtest-*.db")
+	if err != nil {
+		mockJenkins.Close()
+		return "", "", nil, err
+	}
+	dbFile.Close()
+
+	if err := storage.Init(dbFile.Name()); err != nil {
+		mockJenkins.Close()
+		os.Remove(dbFile.Name())
+		return "", "", nil, err
+	}
+
+	const apiKey = "loadtest-key"
+	cfg := config.Config{
+		Server: config.ServerConfig{MaxBodySize: 1 << 20},
+		Jenkins: config.JenkinsConfig{
+			URL:      mockJenkins.URL,
+			Username: "loadtest",
+			Token:    "loadtest",
+			Timeout:  30,
+		},
+		API: config.APIConfig{Keys: []string{apiKey}},
+	}
+	router := api.NewRouter(cfg, jenkins.NewTrigger(jenkins.NewClient(cfg.Jenkins)))
+	server := httptest.NewServer(router)
+
+	cleanup := func() {
+		server.Close()
+		mockJenkins.Close()
+		storage.Close()
+		os.Remove(dbFile.Name())
+	}
+
+	return server.URL, apiKey, cleanup, nil
+}
@@ -0,0 +1,234 @@
+// Package metrics keeps counters and gauges and exposes them in the Prometheus text format,
+// without depending on the Prometheus client library
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"triggermesh/internal/logger"
+)
+
+// Metric types, as written in the # TYPE lines
+const (
+	typeCounter = "counter"
+	typeGauge   = "gauge"
+)
+
+// labelSeparator joins label values into the key of a series; it cannot occur in UTF-8 text
+const labelSeparator = "\xff"
+
+// family is a metric with its series, one per combination of label values
+type family struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+	fn     func() float64 // Reads the value of a gauge without labels at collection time
+
+	mu     sync.Mutex
+	series map[string]float64 // Keyed by the label values joined with labelSeparator
+}
+
+// add adds delta to the series of the label values
+func (f *family) add(delta float64, values []string) {
+	key := f.key(values)
+	f.mu.Lock()
+	f.series[key] += delta
+	f.mu.Unlock()
+}
+
+// set sets the series of the label values
+func (f *family) set(value float64, values []string) {
+	key := f.key(values)
+	f.mu.Lock()
+	f.series[key] = value
+	f.mu.Unlock()
+}
+
+// key returns the series key of the label values, which must match the family's labels
+func (f *family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s has %d labels, got %d values", f.name, len(f.labels), len(values)))
+	}
+	return strings.Join(values, labelSeparator)
+}
+
+// write writes the family in the Prometheus text format, series sorted by label values
+func (f *family) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+	if f.fn != nil {
+		fmt.Fprintf(w, "%s %s\n", f.name, formatValue(f.fn()))
+		return
+	}
+
+	f.mu.Lock()
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]float64, len(keys))
+	for i, key := range keys {
+		values[i] = f.series[key]
+	}
+	f.mu.Unlock()
+
+	for i, key := range keys {
+		w.WriteString(f.name)
+		if len(f.labels) > 0 {
+			w.WriteByte('{')
+			for j, value := range strings.Split(key, labelSeparator) {
+				if j > 0 {
+					w.WriteByte(',')
+				}
+				fmt.Fprintf(w, "%s=\"%s\"", f.labels[j], escapeLabel(value))
+			}
+			w.WriteByte('}')
+		}
+		fmt.Fprintf(w, " %s\n", formatValue(values[i]))
+	}
+}
+
+// Counter is a monotonically increasing metric, with one series per combination of label values
+type Counter struct {
+	family *family
+}
+
+// Inc adds one to the series of the label values
+func (c *Counter) Inc(values ...string) {
+	c.family.add(1, values)
+}
+
+// Add adds a non-negative delta to the series of the label values
+func (c *Counter) Add(delta float64, values ...string) {
+	if delta < 0 {
+		return
+	}
+	c.family.add(delta, values)
+}
+
+// Gauge is a metric that can go up and down, with one series per combination of label values
+type Gauge struct {
+	family *family
+}
+
+// Set sets the series of the label values
+func (g *Gauge) Set(value float64, values ...string) {
+	g.family.set(value, values)
+}
+
+// Registry holds the metrics exposed by a server
+// Metric names must be unique; registering a name twice panics, as it is a programming error
+type Registry struct {
+	mu       sync.Mutex
+	families []*family // Registration order
+	names    map[string]bool
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{family: r.register(&family{name: name, help: help, typ: typeCounter, labels: labels})}
+}
+
+// NewGauge registers a gauge with the given label names
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{family: r.register(&family{name: name, help: help, typ: typeGauge, labels: labels})}
+}
+
+// NewGaugeFunc registers a gauge without labels whose value is read from fn when metrics are
+// collected, for values kept elsewhere such as the size of a cache
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&family{name: name, help: help, typ: typeGauge, fn: fn})
+}
+
+// register adds a family to the registry
+func (r *Registry) register(f *family) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[f.name] {
+		panic(fmt.Sprintf("metric %s is already registered", f.name))
+	}
+	f.series = make(map[string]float64)
+	r.names[f.name] = true
+	r.families = append(r.families, f)
+	return f
+}
+
+// WriteTo writes every metric in the Prometheus text format, in registration order
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := append([]*family(nil), r.families...)
+	r.mu.Unlock()
+
+	counter := &countingWriter{w: w}
+	buffered := bufio.NewWriter(counter)
+	for _, f := range families {
+		f.write(buffered)
+	}
+	err := buffered.Flush()
+	return counter.n, err
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := r.WriteTo(w); err != nil {
+		logger.Debug("Failed to write metrics", "error", err)
+	}
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements io.Writer
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// formatValue formats a sample value as Prometheus expects
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// escapeHelp escapes backslashes and line feeds in help text
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// escapeLabel escapes backslashes, double quotes, and line feeds in label values
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
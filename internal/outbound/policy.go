@@ -0,0 +1,185 @@
+// Package outbound enforces the policy for outbound requests whose URLs are influenced by API
+// clients: allowed schemes, an optional host allowlist, and denied address ranges
+//
+// Addresses are checked when connecting, after DNS resolution, so a host name that resolves to
+// a permitted address during validation and to a denied one afterwards (DNS rebinding) is refused
+// For the same reason, policy clients connect directly and ignore the proxy environment variables
+package outbound
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/jobmatch"
+)
+
+// maxRedirects matches the limit of the default HTTP client
+const maxRedirects = 10
+
+// DeniedError is returned when a request violates the outbound policy
+type DeniedError struct {
+	URL    string // Requested URL, or the address connected to for address checks
+	Reason string
+}
+
+// Error implements the error interface
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("outbound request to %s denied: %s", e.URL, e.Reason)
+}
+
+// Policy validates outbound URLs and the addresses they connect to
+type Policy struct {
+	schemes      []string
+	allowedHosts []string
+	deniedCIDRs  []netip.Prefix
+}
+
+// NewPolicy creates a policy from the outbound configuration
+func NewPolicy(cfg config.OutboundConfig) (*Policy, error) {
+	p := &Policy{allowedHosts: cfg.AllowedHosts}
+	for _, scheme := range cfg.AllowedSchemes {
+		p.schemes = append(p.schemes, strings.ToLower(scheme))
+	}
+	for _, cidr := range cfg.DeniedCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid outbound.denied_cidrs entry %q: %w", cidr, err)
+		}
+		p.deniedCIDRs = append(p.deniedCIDRs, prefix.Masked())
+	}
+	return p, nil
+}
+
+// CheckURL validates the scheme and host of a URL; literal IP hosts are also checked against the denied ranges
+func (p *Policy) CheckURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return &DeniedError{URL: rawURL, Reason: "invalid URL"}
+	}
+	if !p.schemeAllowed(u.Scheme) {
+		return &DeniedError{URL: redact(u), Reason: fmt.Sprintf("scheme %q is not allowed", u.Scheme)}
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return &DeniedError{URL: redact(u), Reason: "missing host"}
+	}
+	if len(p.allowedHosts) > 0 && !jobmatch.MatchAny(p.allowedHosts, host) {
+		return &DeniedError{URL: redact(u), Reason: fmt.Sprintf("host %q is not in outbound.allowed_hosts", host)}
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if err := p.checkAddr(addr); err != nil {
+			return &DeniedError{URL: redact(u), Reason: err.Error()}
+		}
+	}
+	return nil
+}
+
+// CheckAddr validates an address connected to, e.g. "10.0.0.1:443"
+func (p *Policy) CheckAddr(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return &DeniedError{URL: address, Reason: "unresolved address"}
+	}
+	if err := p.checkAddr(addr); err != nil {
+		return &DeniedError{URL: address, Reason: err.Error()}
+	}
+	return nil
+}
+
+// Client returns an HTTP client that enforces the policy on the request URL, every redirect,
+// and every address it connects to; a nil policy returns an unrestricted client
+func (p *Policy) Client(timeout time.Duration) *http.Client {
+	if p == nil {
+		return &http.Client{Timeout: timeout}
+	}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return p.CheckAddr(address)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// Requests are never sent through HTTP_PROXY or HTTPS_PROXY: the dialer would only check the
+	// proxy's address, while the proxy connects to wherever the host name resolves
+	transport.Proxy = nil
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &checkingTransport{policy: p, next: transport},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("stopped after 10 redirects")
+			}
+			return p.CheckURL(req.URL.String())
+		},
+	}
+}
+
+// schemeAllowed reports whether the URL scheme is allowed
+func (p *Policy) schemeAllowed(scheme string) bool {
+	scheme = strings.ToLower(scheme)
+	for _, allowed := range p.schemes {
+		if scheme == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAddr fails for addresses in a denied range
+// IPv4-mapped IPv6 addresses are checked as IPv4 so ::ffff:169.254.169.254 cannot bypass the ranges
+func (p *Policy) checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, prefix := range p.deniedCIDRs {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("address %s is in denied range %s", addr, prefix)
+		}
+	}
+	return nil
+}
+
+// checkingTransport validates request URLs before sending them
+type checkingTransport struct {
+	policy *Policy
+	next   http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *checkingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.CheckURL(req.URL.String()); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+// redact drops credentials and the query from a URL for error messages
+func redact(u *url.URL) string {
+	clean := *u
+	clean.User = nil
+	clean.RawQuery = ""
+	clean.Fragment = ""
+	return clean.String()
+}
+
+// IsDenied reports whether err, possibly wrapped, was caused by the outbound policy
+func IsDenied(err error) bool {
+	var denied *DeniedError
+	return errors.As(err, &denied)
+}
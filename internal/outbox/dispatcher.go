@@ -0,0 +1,259 @@
+// Package outbox forwards audit entries to webhooks, such as a SIEM, at least once
+//
+// Events are written to the outbox table in the same transaction as the audit entry they derive
+// from, so an entry is never recorded without its events. A background dispatcher delivers due
+// events and retries failed deliveries with exponential backoff; events still pending at a restart
+// are delivered after it. Receivers may see an event more than once and should deduplicate on the
+// X-TriggerMesh-Event-ID header
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"triggermesh/internal/authz"
+	"triggermesh/internal/config"
+	"triggermesh/internal/jobmatch"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/outbound"
+	"triggermesh/internal/signature"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+	"triggermesh/internal/version"
+)
+
+// Delivery headers
+const (
+	EventIDHeader   = "X-TriggerMesh-Event-ID"   // Outbox event ID, the same for every delivery attempt of an event
+	EventTypeHeader = "X-TriggerMesh-Event-Type" // Type of the event, e.g. audit
+)
+
+// EventTypeAudit is the type of events forwarding an audit entry
+const EventTypeAudit = "audit"
+
+const (
+	// batchSize is the number of due events delivered per run
+	batchSize = 100
+	// minBackoff and maxBackoff bound the wait before retrying a failed delivery
+	minBackoff = 5 * time.Second
+	maxBackoff = time.Hour
+	// pruneInterval is the time between deletions of delivered and dead events past their retention
+	pruneInterval = time.Hour
+)
+
+// AuditEvent is the payload of an audit event
+type AuditEvent struct {
+	Type  string          `json:"type"`             // Always audit
+	KeyID string          `json:"key_id,omitempty"` // Non-reversible identifier of the API key, as in policy inputs
+	Audit models.AuditLog `json:"audit"`            // The audit entry, without the API key
+}
+
+// destination is a webhook receiving events
+type destination struct {
+	url    string
+	secret string
+	jobs   []string
+	client *http.Client
+}
+
+// Dispatcher derives outbox events from audit entries and delivers them to the destinations
+type Dispatcher struct {
+	destinations map[string]*destination
+	names        []string // Destination names in configuration order
+	interval     time.Duration
+	maxAttempts  int
+	retention    time.Duration
+	lastPrune    time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewDispatcher creates a dispatcher for the outbox configuration
+// Deliveries go through the outbound URL policy, if any
+func NewDispatcher(cfg config.OutboxConfig, policy *outbound.Policy) *Dispatcher {
+	d := &Dispatcher{
+		destinations: make(map[string]*destination, len(cfg.Destinations)),
+		interval:     time.Duration(cfg.PollInterval) * time.Second,
+		maxAttempts:  cfg.MaxAttempts,
+		retention:    time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+	}
+	for _, destCfg := range cfg.Destinations {
+		d.destinations[destCfg.Name] = &destination{
+			url:    destCfg.URL,
+			secret: destCfg.Secret,
+			jobs:   destCfg.Jobs,
+			client: policy.Client(time.Duration(destCfg.Timeout) * time.Second),
+		}
+		d.names = append(d.names, destCfg.Name)
+	}
+	return d
+}
+
+// Events returns an event for each destination forwarding the audit entry's job
+// It is the outbox event builder installed with storage.SetOutboxEventBuilder
+func (d *Dispatcher) Events(log models.AuditLog) []models.OutboxEvent {
+	var events []models.OutboxEvent
+	var payload string
+	for _, name := range d.names {
+		if jobs := d.destinations[name].jobs; len(jobs) > 0 && !jobmatch.MatchAny(jobs, log.JobName) {
+			continue
+		}
+		if payload == "" {
+			event := AuditEvent{Type: EventTypeAudit, Audit: log}
+			if log.APIKey != "" {
+				event.KeyID = authz.KeyID(log.APIKey)
+			}
+			event.Audit.APIKey = ""
+			body, err := json.Marshal(event)
+			if err != nil {
+				// The entry is still recorded, without events
+				logger.Error("Failed to encode outbox event", "error", err, "audit_log_id", log.ID)
+				return nil
+			}
+			payload = string(body)
+		}
+		events = append(events, models.OutboxEvent{Destination: name, Payload: payload})
+	}
+	return events
+}
+
+// Start runs deliveries in the background until Stop is called; events left pending by a previous
+// run of the process are delivered right away
+func (d *Dispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.done = make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := d.Dispatch(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("Outbox delivery run failed", "error", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the dispatcher and waits for an in-flight run to finish
+// Events not delivered yet stay pending for the next start
+func (d *Dispatcher) Stop() {
+	if d.cancel == nil {
+		return
+	}
+	d.cancel()
+	<-d.done
+}
+
+// Dispatch delivers the due events once and returns how many were delivered
+// After a failed delivery, later events of the same destination wait for the next run, so a
+// destination that is down does not hold up the others
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	now := time.Now()
+	if now.Sub(d.lastPrune) >= pruneInterval {
+		d.lastPrune = now
+		if deleted, err := storage.DeleteFinishedOutboxEvents(now.Add(-d.retention)); err != nil {
+			logger.Warn("Failed to delete old outbox events", "error", err)
+		} else if deleted > 0 {
+			logger.Info("Deleted old outbox events", "count", deleted)
+		}
+	}
+
+	events, err := storage.GetDueOutboxEvents(now, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	delivered := 0
+	failed := make(map[string]bool)
+	for _, event := range events {
+		if ctx.Err() != nil || failed[event.Destination] {
+			continue
+		}
+		dest, ok := d.destinations[event.Destination]
+		if !ok {
+			event.Status = models.OutboxDead
+			event.LastError = "destination is no longer configured"
+			if err := storage.UpdateOutboxEvent(event); err != nil {
+				return delivered, err
+			}
+			continue
+		}
+
+		event.Attempts++
+		if err := dest.deliver(ctx, event); err != nil {
+			if ctx.Err() != nil {
+				// Interrupted by shutdown; the attempt is not counted
+				continue
+			}
+			failed[event.Destination] = true
+			event.LastError = err.Error()
+			if event.Attempts >= d.maxAttempts {
+				event.Status = models.OutboxDead
+				logger.Error("Giving up outbox event", "error", err, "id", event.ID, "destination", event.Destination, "attempts", event.Attempts)
+			} else {
+				event.NextAttemptAt = now.Add(backoff(event.Attempts))
+				logger.Warn("Outbox delivery failed", "error", err, "id", event.ID, "destination", event.Destination, "attempts", event.Attempts, "next_attempt_at", event.NextAttemptAt)
+			}
+		} else {
+			event.Status = models.OutboxDelivered
+			event.LastError = ""
+			delivered++
+		}
+		if err := storage.UpdateOutboxEvent(event); err != nil {
+			// A delivered event still pending is delivered again
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+// backoff returns the wait after the given number of failed attempts: minBackoff, doubled for each
+// further attempt, up to maxBackoff
+func backoff(attempts int) time.Duration {
+	wait := minBackoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+// deliver posts the event payload, signed when a secret is set, and fails on non-2xx responses
+func (dest *destination) deliver(ctx context.Context, event models.OutboxEvent) error {
+	body := []byte(event.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dest.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create delivery request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent())
+	req.Header.Set(EventIDHeader, strconv.FormatInt(event.ID, 10))
+	req.Header.Set(EventTypeHeader, EventTypeAudit)
+	signature.SetHeader(req, dest.secret, body)
+
+	resp, err := dest.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver event: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("destination returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package quota
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/jobmatch"
+	"triggermesh/internal/logger"
+)
+
+// Limits of a category, as named in the configuration
+const (
+	LimitConcurrent = "max_concurrent"
+	LimitTriggers   = "max_triggers"
+)
+
+// ExceededError is returned for triggers refused because their category reached a limit
+type ExceededError struct {
+	Category   string
+	Limit      string        // LimitConcurrent or LimitTriggers
+	Max        int           // Configured value of the limit
+	Window     time.Duration // Window of LimitTriggers
+	Running    int           // Builds of the category running, for LimitConcurrent
+	RetryAfter time.Duration // When a trigger is let through again; 0 when unknown
+}
+
+// Error implements error
+func (e *ExceededError) Error() string {
+	if e.Limit == LimitConcurrent {
+		return fmt.Sprintf("category %q has %d builds running, the limit is %d", e.Category, e.Running, e.Max)
+	}
+	return fmt.Sprintf("category %q reached its limit of %d triggers per %d seconds", e.Category, e.Max, int(e.Window.Seconds()))
+}
+
+// RunningCounter returns the number of running builds of each job, as
+// storage.CountTrackedBuildsByJob does
+type RunningCounter func() (map[string]int, error)
+
+// category is a configured job category with its admitted triggers
+type category struct {
+	name          string
+	jobs          []string
+	maxConcurrent int
+	maxTriggers   int
+	window        time.Duration
+
+	triggers []time.Time // Triggers admitted within the window, oldest first
+	pending  int         // Admitted triggers not released yet, so not counted by the running counter
+}
+
+// Limiter enforces the concurrency and trigger rate limits of job categories
+// Running builds are counted by the running counter, plus triggers admitted but not released yet,
+// so concurrent triggers cannot overshoot the limit before their builds are tracked
+// A failed count lets triggers through, as the build tracking failure is logged where it happens
+type Limiter struct {
+	running RunningCounter
+
+	mu         sync.Mutex
+	categories []*category
+}
+
+// NewLimiter creates a limiter from the quota configuration
+func NewLimiter(cfg config.QuotasConfig, running RunningCounter) *Limiter {
+	l := &Limiter{running: running}
+	for _, cc := range cfg.Categories {
+		l.categories = append(l.categories, &category{
+			name:          cc.Name,
+			jobs:          cc.Jobs,
+			maxConcurrent: cc.MaxConcurrent,
+			maxTriggers:   cc.MaxTriggers,
+			window:        time.Duration(cc.Window) * time.Second,
+		})
+	}
+	return l
+}
+
+// category returns the first category matching the job, or nil
+func (l *Limiter) category(job string) *category {
+	for _, c := range l.categories {
+		if jobmatch.MatchAny(c.jobs, job) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Admit returns nil when a trigger of the job may proceed, or an ExceededError when its category
+// reached a limit. An admitted trigger counts as running until release is called, which the caller
+// does once the build is tracked or the trigger failed; release may be called more than once
+func (l *Limiter) Admit(job string) (release func(), err error) {
+	c := l.category(job)
+	if c == nil {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if c.maxTriggers > 0 {
+		cutoff := now.Add(-c.window)
+		expired := 0
+		for expired < len(c.triggers) && !c.triggers[expired].After(cutoff) {
+			expired++
+		}
+		c.triggers = c.triggers[expired:]
+		if len(c.triggers) >= c.maxTriggers {
+			retryAfter := c.triggers[0].Add(c.window).Sub(now).Truncate(time.Second) + time.Second
+			return nil, &ExceededError{Category: c.name, Limit: LimitTriggers, Max: c.maxTriggers, Window: c.window, RetryAfter: retryAfter}
+		}
+	}
+	if c.maxConcurrent > 0 {
+		running, err := l.countRunning(c)
+		if err != nil {
+			logger.Warn("Failed to count running builds, concurrency quota not enforced", "error", err, "category", c.name)
+		} else if running+c.pending >= c.maxConcurrent {
+			return nil, &ExceededError{Category: c.name, Limit: LimitConcurrent, Max: c.maxConcurrent, Running: running + c.pending}
+		}
+	}
+
+	if c.maxTriggers > 0 {
+		c.triggers = append(c.triggers, now)
+	}
+	c.pending++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			c.pending--
+			l.mu.Unlock()
+		})
+	}, nil
+}
+
+// countRunning returns the running builds of the category's jobs
+func (l *Limiter) countRunning(c *category) (int, error) {
+	counts, err := l.running()
+	if err != nil {
+		return 0, err
+	}
+	running := 0
+	for job, count := range counts {
+		if l.category(job) == c {
+			running += count
+		}
+	}
+	return running, nil
+}
+
+// CategoryUsage is the current usage of a category against its limits
+type CategoryUsage struct {
+	Name          string
+	Jobs          []string
+	MaxConcurrent int           // 0 is unlimited
+	Running       int           // Running builds plus admitted triggers not released yet; -1 when they cannot be counted
+	MaxTriggers   int           // 0 is unlimited
+	Window        time.Duration // Window of MaxTriggers
+	Triggers      int           // Triggers admitted within the window
+}
+
+// Usage returns the usage of the categories, in configuration order, that include a job matching
+// one of the patterns, or of every category when no pattern is given
+// A pattern and a category overlap when either matches the other, e.g. "deploy-*" and "deploy-web"
+func (l *Limiter) Usage(patterns []string) []CategoryUsage {
+	counts, countErr := l.running()
+	if countErr != nil {
+		logger.Warn("Failed to count running builds for quota usage", "error", countErr)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	var usage []CategoryUsage
+	for _, c := range l.categories {
+		if len(patterns) > 0 && !overlaps(patterns, c.jobs) {
+			continue
+		}
+		u := CategoryUsage{
+			Name:          c.name,
+			Jobs:          c.jobs,
+			MaxConcurrent: c.maxConcurrent,
+			MaxTriggers:   c.maxTriggers,
+			Window:        c.window,
+		}
+		if countErr != nil {
+			u.Running = -1
+		} else {
+			u.Running = c.pending
+			for job, count := range counts {
+				if l.category(job) == c {
+					u.Running += count
+				}
+			}
+		}
+		cutoff := now.Add(-c.window)
+		for _, at := range c.triggers {
+			if at.After(cutoff) {
+				u.Triggers++
+			}
+		}
+		usage = append(usage, u)
+	}
+	return usage
+}
+
+// overlaps reports whether a job pattern and a category pattern may match the same job
+func overlaps(patterns, jobs []string) bool {
+	for _, pattern := range patterns {
+		for _, job := range jobs {
+			if jobmatch.Match(pattern, job) || jobmatch.Match(job, pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+// Package resilience retries failed CI engine calls and stops calling an engine that keeps
+// failing, with retry and circuit breaker settings per engine
+package resilience
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/metrics"
+)
+
+// Metrics are the retry and circuit breaker metrics, shared by the guards of every engine
+type Metrics struct {
+	retries *metrics.Counter
+	open    *metrics.Gauge
+}
+
+// NewMetrics registers the retry and circuit breaker metrics
+func NewMetrics(registry *metrics.Registry) *Metrics {
+	return &Metrics{
+		retries: registry.NewCounter("triggermesh_engine_retries_total",
+			"Engine calls retried after a transient failure", "engine"),
+		open: registry.NewGauge("triggermesh_engine_circuit_open",
+			"Whether the circuit breaker of the engine is open (1) or closed (0)", "engine"),
+	}
+}
+
+// Guard applies an engine's retry and circuit breaker settings to its calls
+// Triggers are only retried after server errors: a trigger that timed out may have started a build
+// Status calls are also retried after timeouts. Consecutive timeouts, server errors, and network
+// errors open the breaker, failing calls at once for the open duration; then one trial call is let
+// through, which closes the breaker on success and reopens it on failure
+type Guard struct {
+	engine    string
+	attempts  int
+	backoff   time.Duration
+	threshold int // 0 disables the breaker
+	openFor   time.Duration
+	metrics   *Metrics
+	observe   func(engine string, healthy bool)
+
+	mu        sync.Mutex
+	failures  int       // Consecutive failed calls
+	openUntil time.Time // Zero while the breaker is closed
+	trial     bool      // A trial call is in flight after the open period
+}
+
+// NewGuard creates the guard of the named engine
+func NewGuard(engineName string, retry config.RetryConfig, breaker config.BreakerConfig) *Guard {
+	attempts := retry.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &Guard{
+		engine:    engineName,
+		attempts:  attempts,
+		backoff:   time.Duration(retry.Backoff) * time.Millisecond,
+		threshold: breaker.FailureThreshold,
+		openFor:   time.Duration(breaker.OpenDuration) * time.Second,
+	}
+}
+
+// SetMetrics records the guard's retries and breaker state in m
+func (g *Guard) SetMetrics(m *Metrics) {
+	g.metrics = m
+	if m != nil && g.threshold > 0 {
+		m.open.Set(0, g.engine)
+	}
+}
+
+// SetObserver reports the outcome of every call that reached the engine to observe; healthy is
+// false after the failures that count toward opening the breaker and after rejected credentials
+func (g *Guard) SetObserver(observe func(engine string, healthy bool)) {
+	g.observe = observe
+}
+
+// Trigger runs a trigger call, retrying it after server errors
+// A nil guard runs the call as is
+func (g *Guard) Trigger(call func() (*engine.BuildResult, error)) (*engine.BuildResult, error) {
+	return g.do(call, func(kind engine.ErrorKind) bool {
+		return kind == engine.ErrorKindServer
+	})
+}
+
+// Status runs a build status call, retrying it after server errors and timeouts
+// A nil guard runs the call as is
+func (g *Guard) Status(call func() (*engine.BuildResult, error)) (*engine.BuildResult, error) {
+	return g.do(call, func(kind engine.ErrorKind) bool {
+		return kind == engine.ErrorKindServer || kind == engine.ErrorKindTimeout
+	})
+}
+
+// Open reports whether the breaker is open, failing calls at once
+func (g *Guard) Open() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return !g.openUntil.IsZero()
+}
+
+// do runs the call through the breaker, retrying failures of the retryable kinds with a backoff
+// doubled after each attempt
+func (g *Guard) do(call func() (*engine.BuildResult, error), retryable func(engine.ErrorKind) bool) (*engine.BuildResult, error) {
+	if g == nil {
+		return call()
+	}
+
+	backoff := g.backoff
+	for attempt := 1; ; attempt++ {
+		trial, err := g.allow()
+		if err != nil {
+			return &engine.BuildResult{Success: false, Message: err.Error()}, err
+		}
+		result, err := call()
+		failed := err != nil && countsAsFailure(err)
+		g.record(trial, failed)
+		if g.observe != nil {
+			g.observe(g.engine, !failed && !errors.Is(err, engine.ErrAuth))
+		}
+		if err == nil || attempt >= g.attempts || !retryable(engine.Classify(err)) {
+			return result, err
+		}
+
+		logger.Warn("Engine call failed, retrying",
+			"engine", g.engine,
+			"attempt", attempt,
+			"error", err,
+			"retry_in_ms", backoff.Milliseconds())
+		if g.metrics != nil {
+			g.metrics.retries.Inc(g.engine)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// allow returns an error while the breaker is open; once the open period is over, it lets one trial
+// call through and reports it
+func (g *Guard) allow() (trial bool, err error) {
+	if g.threshold == 0 {
+		return false, nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.openUntil.IsZero() {
+		return false, nil
+	}
+	if g.trial || time.Now().Before(g.openUntil) {
+		return false, engine.NewError(engine.ErrorKindCircuitOpen,
+			fmt.Sprintf("%s is unavailable after repeated failures: please try again later", g.engine))
+	}
+	g.trial = true
+	return true, nil
+}
+
+// record updates the breaker with the outcome of a call
+func (g *Guard) record(trial, failed bool) {
+	if g.threshold == 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if trial {
+		g.trial = false
+	}
+	if !failed {
+		if !g.openUntil.IsZero() {
+			logger.Info("Engine circuit breaker closed", "engine", g.engine)
+			g.setOpen(0)
+		}
+		g.failures = 0
+		g.openUntil = time.Time{}
+		return
+	}
+
+	g.failures++
+	if trial || (g.openUntil.IsZero() && g.failures >= g.threshold) {
+		logger.Warn("Engine circuit breaker opened",
+			"engine", g.engine,
+			"consecutive_failures", g.failures,
+			"open_for", g.openFor.String())
+		g.openUntil = time.Now().Add(g.openFor)
+		g.setOpen(1)
+	}
+}
+
+// setOpen records the breaker state; the caller holds mu
+func (g *Guard) setOpen(value float64) {
+	if g.metrics != nil {
+		g.metrics.open.Set(value, g.engine)
+	}
+}
+
+// countsAsFailure reports whether err means the engine is unhealthy: timeouts, server errors, and
+// network errors count, while rejections such as unknown jobs or bad credentials do not
+func countsAsFailure(err error) bool {
+	switch engine.Classify(err) {
+	case engine.ErrorKindTimeout, engine.ErrorKindServer:
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/cron"
+)
+
+// Recurring is a job triggered on a cron schedule, with a misfire policy for the fire times
+// missed while the service was down
+type Recurring struct {
+	Name       string
+	Job        string
+	Parameters map[string]string
+
+	schedule   *cron.Schedule
+	location   *time.Location
+	misfire    string
+	grace      time.Duration
+	maxCatchUp int
+}
+
+// NewRecurring creates a recurring schedule from its configuration
+func NewRecurring(cfg config.ScheduleConfig) (*Recurring, error) {
+	schedule, err := cron.Parse(cfg.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule of %q: %w", cfg.Name, err)
+	}
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone of %q: %w", cfg.Name, err)
+	}
+	return &Recurring{
+		Name:       cfg.Name,
+		Job:        cfg.Job,
+		Parameters: cfg.Parameters,
+		schedule:   schedule,
+		location:   location,
+		misfire:    cfg.Misfire,
+		grace:      time.Duration(cfg.MisfireGrace) * time.Second,
+		maxCatchUp: cfg.MaxCatchUp,
+	}, nil
+}
+
+// Misfire returns the misfire policy of the schedule
+func (s *Recurring) Misfire() string {
+	return s.misfire
+}
+
+// Due returns the fire times after last, up to now, that should run, oldest first, and the latest
+// fire time reached (zero when none is due); missed reports fire times that did not run on time
+// A fire time within the grace period always runs. Older ones were missed: skip drops them, run_once
+// runs the latest once, and catch_up runs the most recent max_catch_up of them
+func (s *Recurring) Due(last, now time.Time) (fires []time.Time, latest time.Time, missed bool) {
+	limit := 1
+	if s.misfire == config.MisfireCatchUp && s.maxCatchUp > 1 {
+		limit = s.maxCatchUp
+	}
+
+	// Fire times are whole minutes, so the first one after last is in the next minute
+	since := last.Truncate(time.Minute).Add(time.Minute)
+	t := now.In(s.location)
+	var due []time.Time // Newest first
+	for len(due) < limit {
+		fire, ok := s.schedule.Prev(t, since)
+		if !ok {
+			break
+		}
+		due = append(due, fire)
+		t = fire.Add(-time.Minute)
+	}
+	if len(due) == 0 {
+		return nil, time.Time{}, false
+	}
+
+	latest = due[0]
+	oldest := due[len(due)-1]
+	_, earlier := s.schedule.Prev(oldest.Add(-time.Minute), since)
+	missed = earlier || now.Sub(oldest) > s.grace
+	if s.misfire == config.MisfireSkip && now.Sub(latest) > s.grace {
+		return nil, latest, missed
+	}
+	for i := len(due) - 1; i >= 0; i-- {
+		fires = append(fires, due[i])
+	}
+	return fires, latest, missed
+}
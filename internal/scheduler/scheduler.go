@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"triggermesh/internal/logger"
+)
+
+// FireFunc fires the triggers that are due and returns how many were fired
+type FireFunc func(ctx context.Context) (int, error)
+
+// Scheduler periodically fires triggers held until their not_before time
+type Scheduler struct {
+	interval time.Duration
+	fire     FireFunc
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewScheduler creates a scheduler that calls fire every interval
+func NewScheduler(interval time.Duration, fire FireFunc) *Scheduler {
+	return &Scheduler{
+		interval: interval,
+		fire:     fire,
+	}
+}
+
+// Start runs the scheduler in the background until Stop is called
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			fired, err := s.fire(ctx)
+			if err != nil && ctx.Err() == nil {
+				logger.Error("Scheduled trigger run failed", "error", err)
+			}
+			if fired > 0 {
+				logger.Info("Fired scheduled triggers", "count", fired)
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler and waits for an in-flight run to finish
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
@@ -0,0 +1,54 @@
+package sdnotify
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket activation
+const listenFDsStart = 3
+
+// ActivationListener is a socket passed by systemd socket activation
+type ActivationListener struct {
+	Name     string // FileDescriptorName= of the socket unit, or "unknown"
+	Listener net.Listener
+}
+
+// ActivationListeners returns the listening sockets passed in LISTEN_FDS by systemd socket
+// activation, or none when the process was not socket-activated
+// The LISTEN_* variables are unset so child processes do not inherit the sockets
+func ActivationListeners() ([]ActivationListener, error) {
+	if pid := os.Getenv("LISTEN_PID"); pid == "" || pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		_ = os.Unsetenv(name)
+	}
+
+	listeners := make([]ActivationListener, 0, count)
+	for i := 0; i < count; i++ {
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(listenFDsStart+i), name)
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Listener.Close()
+			}
+			return nil, fmt.Errorf("inherited socket %d (%s) is not a listening stream socket: %w", listenFDsStart+i, name, err)
+		}
+		listeners = append(listeners, ActivationListener{Name: name, Listener: listener})
+	}
+	return listeners, nil
+}
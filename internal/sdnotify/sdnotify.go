@@ -0,0 +1,79 @@
+// Package sdnotify implements the systemd service notification protocol (sd_notify)
+// for Type=notify units and socket activation (LISTEN_FDS); outside systemd every call is a no-op
+package sdnotify
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Notification states understood by systemd
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+	Watchdog = "WATCHDOG=1"
+)
+
+// Notify sends a state to the socket in NOTIFY_SOCKET
+// It reports false without error when the process is not run by systemd with notification enabled
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	// Abstract namespace sockets are given with a leading @
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the watchdog timeout configured with WatchdogSec=, or 0 when the
+// watchdog is disabled or meant for another process
+func WatchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// RunWatchdog pings the systemd watchdog at half its timeout until ctx is done
+// check is called before each ping; a failing check skips the ping so systemd restarts the service
+// It returns immediately when the watchdog is disabled
+func RunWatchdog(ctx context.Context, check func() error) {
+	timeout := WatchdogInterval()
+	if timeout == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if check != nil && check() != nil {
+			continue
+		}
+		_, _ = Notify(Watchdog)
+	}
+}
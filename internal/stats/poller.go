@@ -0,0 +1,197 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+	"triggermesh/internal/workpool"
+)
+
+// pollBatchSize caps the number of builds checked per poll
+const pollBatchSize = 100
+
+// defaultPollConcurrency is the number of build statuses checked at once unless SetConcurrency is called
+const defaultPollConcurrency = 4
+
+// Poller polls the status of tracked builds and records their outcomes in the job statistics
+type Poller struct {
+	engines     *engine.Registry
+	interval    time.Duration
+	maxTrackAge time.Duration
+	onComplete  []func(models.TrackedBuild, models.BuildOutcome)
+	concurrency int
+	completeMu  sync.Mutex // Serializes onComplete calls of concurrent polls
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPoller creates a new Poller for builds triggered through the given engines
+func NewPoller(cfg config.StatsConfig, engines *engine.Registry) *Poller {
+	return &Poller{
+		engines:     engines,
+		interval:    time.Duration(cfg.PollInterval) * time.Second,
+		maxTrackAge: time.Duration(cfg.MaxTrackAge) * time.Second,
+		concurrency: defaultPollConcurrency,
+	}
+}
+
+// SetConcurrency sets the number of build statuses checked at once; call it before Start
+func (p *Poller) SetConcurrency(limit int) {
+	p.concurrency = limit
+}
+
+// OnComplete registers a function called with every build outcome recorded by the poller
+// Functions are called in registration order, one outcome at a time, and must be registered before Start
+func (p *Poller) OnComplete(fn func(models.TrackedBuild, models.BuildOutcome)) {
+	p.onComplete = append(p.onComplete, fn)
+}
+
+// Start runs the poller in the background until Stop is called
+func (p *Poller) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if _, err := p.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("Build status poll failed", "error", err)
+			}
+		}
+	}()
+}
+
+// Stop stops the background poller and waits for an in-flight poll to finish
+func (p *Poller) Stop() {
+	if p.cancel == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+}
+
+// RunOnce checks every tracked build once and returns the number of outcomes recorded
+func (p *Poller) RunOnce(ctx context.Context) (int, error) {
+	// Builds that never finish (or whose status can no longer be read) are eventually dropped
+	if p.maxTrackAge > 0 {
+		dropped, err := storage.DeleteTrackedBuildsBefore(time.Now().Add(-p.maxTrackAge))
+		if err != nil {
+			return 0, err
+		}
+		if dropped > 0 {
+			logger.Warn("Stopped tracking builds that did not finish in time", "count", dropped, "max_track_age", p.maxTrackAge.String())
+		}
+	}
+
+	builds, err := storage.GetTrackedBuilds(pollBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var mu sync.Mutex
+	recorded := 0
+	pool := workpool.New(ctx, p.concurrency, 0)
+	for _, build := range builds {
+		if ctx.Err() != nil {
+			break
+		}
+
+		build := build
+		pool.Go(func(ctx context.Context) error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			done, err := p.poll(build)
+			if err != nil {
+				logger.Warn("Failed to poll build status", "error", err, "build_id", build.BuildID, "engine", build.Engine)
+				return nil
+			}
+			if done {
+				mu.Lock()
+				recorded++
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	_ = pool.Wait()
+
+	return recorded, ctx.Err()
+}
+
+// poll checks one build and records its outcome if it has finished
+func (p *Poller) poll(build models.TrackedBuild) (bool, error) {
+	e, ok := p.engines.Get(build.Engine)
+	if !ok {
+		logger.Warn("Dropping tracked build for unknown engine", "build_id", build.BuildID, "engine", build.Engine)
+		return false, storage.UntrackBuild(build.BuildID)
+	}
+
+	result, err := e.GetBuildStatus(build.BuildID)
+	if err != nil {
+		// A build that no longer exists will never finish
+		if errors.Is(err, engine.ErrJobNotFound) {
+			return false, storage.UntrackBuild(build.BuildID)
+		}
+		return false, err
+	}
+	if result.Building || result.Result == "" {
+		return false, nil
+	}
+	return p.record(build, result)
+}
+
+// Report records the outcome of a finished build pushed by its engine, e.g. by a Jenkins
+// notification, instead of waiting for the next poll. It returns false if the build is not
+// tracked, because TriggerMesh did not start it or its outcome was already recorded
+func (p *Poller) Report(engineName, buildID string, result *engine.BuildResult) (bool, error) {
+	build, err := storage.GetTrackedBuild(engineName, buildID)
+	if err != nil || build == nil {
+		return false, err
+	}
+	return p.record(*build, result)
+}
+
+// record stores the outcome of a finished build and calls the OnComplete functions
+// An outcome already recorded through another path is skipped
+func (p *Poller) record(build models.TrackedBuild, result *engine.BuildResult) (bool, error) {
+	outcome := models.BuildOutcome{
+		JobName:    build.JobName,
+		BuildID:    build.BuildID,
+		Result:     result.Result,
+		Succeeded:  result.Result == engine.ResultSuccess,
+		DurationMS: result.BuildDurationMS,
+		FinishedAt: time.Now(),
+	}
+	if err := storage.RecordBuildOutcome(outcome); err != nil {
+		if errors.Is(err, storage.ErrBuildNotTracked) {
+			return false, nil
+		}
+		return true, err
+	}
+	p.completeMu.Lock()
+	defer p.completeMu.Unlock()
+	for _, fn := range p.onComplete {
+		fn(build, outcome)
+	}
+	return true, nil
+}
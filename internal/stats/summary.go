@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"context"
+	"time"
+
+	"triggermesh/internal/logger"
+	"triggermesh/internal/storage"
+)
+
+// Summarizer periodically refreshes the daily per-job trigger summary from the audit log,
+// so the daily stats endpoint reads a few summary rows instead of scanning audit entries
+type Summarizer struct {
+	interval time.Duration
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewSummarizer creates a summarizer that refreshes the summary every interval
+func NewSummarizer(interval time.Duration) *Summarizer {
+	return &Summarizer{interval: interval}
+}
+
+// Start refreshes the summary immediately, then in the background until Stop is called
+func (s *Summarizer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := storage.RefreshAuditSummary(time.Now()); err != nil {
+				logger.Error("Audit summary refresh failed", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the background summarizer and waits for an in-flight refresh to finish
+func (s *Summarizer) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
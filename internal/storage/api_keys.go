@@ -0,0 +1,265 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"triggermesh/internal/storage/models"
+)
+
+// keyRequestColumns lists the columns read by scanKeyRequests, in order
+const keyRequestColumns = "id, name, tenant, jobs, scopes, reason, requested_by, status, decided_by, decision_note, key_hash, key_id, created_at, decided_at, issued_at, expires_at"
+
+// ErrKeyNameTaken is returned by InsertKeyRequest for a name already used by a key request that was
+// not denied
+var ErrKeyNameTaken = errors.New("key name is already taken")
+
+// InsertKeyRequest stores a pending API key request and returns its ID
+// The name is checked and stored together, so two requests never get the same name
+func InsertKeyRequest(request models.KeyRequest) (int64, error) {
+	if !sqliteActive() {
+		return 0, errNoDatabase
+	}
+
+	jobs, err := encodeStrings(request.Jobs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode jobs: %w", err)
+	}
+	scopes, err := encodeStrings(request.Scopes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode scopes: %w", err)
+	}
+
+	var expiresAt sql.NullString
+	if request.ExpiresAt != nil {
+		expiresAt = sql.NullString{String: formatTimestamp(*request.ExpiresAt), Valid: true}
+	}
+
+	result, err := db.Exec(
+		`INSERT INTO api_key_requests (name, tenant, jobs, scopes, reason, requested_by, status, created_at, expires_at)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM api_key_requests WHERE name = ? AND status != ?)`,
+		request.Name,
+		request.Tenant,
+		jobs,
+		scopes,
+		request.Reason,
+		request.RequestedBy,
+		models.KeyRequestPending,
+		formatTimestamp(request.CreatedAt),
+		expiresAt,
+		request.Name,
+		models.KeyRequestDenied,
+	)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected == 0 {
+		return 0, ErrKeyNameTaken
+	}
+	return result.LastInsertId()
+}
+
+// GetKeyRequest returns the API key request with the given ID, or nil if there is none
+func GetKeyRequest(id int64) (*models.KeyRequest, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(`SELECT `+keyRequestColumns+` FROM api_key_requests WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests, err := scanKeyRequests(rows)
+	if err != nil || len(requests) == 0 {
+		return nil, err
+	}
+	return &requests[0], nil
+}
+
+// GetKeyRequests retrieves API key requests with pagination, newest first
+// A non-empty status or requestedBy restricts the result to matching requests
+func GetKeyRequests(status, requestedBy string, limit, offset int) ([]models.KeyRequest, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(
+		`SELECT `+keyRequestColumns+` FROM api_key_requests
+		WHERE (? = '' OR status = ?) AND (? = '' OR requested_by = ?) ORDER BY id DESC LIMIT ? OFFSET ?`,
+		status,
+		status,
+		requestedBy,
+		requestedBy,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanKeyRequests(rows)
+}
+
+// DecideKeyRequest approves or denies a pending API key request
+// It returns false if the request does not exist or was already decided
+func DecideKeyRequest(id int64, status, decidedBy, note string, decidedAt time.Time) (bool, error) {
+	if !sqliteActive() {
+		return false, errNoDatabase
+	}
+
+	result, err := db.Exec(
+		`UPDATE api_key_requests SET status = ?, decided_by = ?, decision_note = ?, decided_at = ? WHERE id = ? AND status = ?`,
+		status,
+		decidedBy,
+		note,
+		formatTimestamp(decidedAt),
+		id,
+		models.KeyRequestPending,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// IssueKeyRequest records the key issued for an approved request
+// It returns false if the request is not approved, e.g. because the key was already issued
+func IssueKeyRequest(id int64, keyHash, keyID string, issuedAt time.Time) (bool, error) {
+	if !sqliteActive() {
+		return false, errNoDatabase
+	}
+
+	result, err := db.Exec(
+		`UPDATE api_key_requests SET status = ?, key_hash = ?, key_id = ?, issued_at = ? WHERE id = ? AND status = ?`,
+		models.KeyRequestIssued,
+		keyHash,
+		keyID,
+		formatTimestamp(issuedAt),
+		id,
+		models.KeyRequestApproved,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// GetIssuedKey returns the request of the issued API key with the given hash, or nil if there is none
+func GetIssuedKey(keyHash string) (*models.KeyRequest, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(`SELECT `+keyRequestColumns+` FROM api_key_requests WHERE key_hash = ? AND status = ?`, keyHash, models.KeyRequestIssued)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests, err := scanKeyRequests(rows)
+	if err != nil || len(requests) == 0 {
+		return nil, err
+	}
+	return &requests[0], nil
+}
+
+// GetExpiringIssuedKeys returns the requests of issued API keys that expire before the given time,
+// including keys that have already expired
+// Keys are only issued with the SQLite database, so lookups against custom stores return nil
+func GetExpiringIssuedKeys(before time.Time) ([]models.KeyRequest, error) {
+	if store != nil {
+		return nil, nil
+	}
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(
+		`SELECT `+keyRequestColumns+` FROM api_key_requests WHERE status = ? AND expires_at IS NOT NULL AND expires_at < ? ORDER BY expires_at ASC`,
+		models.KeyRequestIssued,
+		formatTimestamp(before),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanKeyRequests(rows)
+}
+
+// scanKeyRequests reads API key request rows selected with keyRequestColumns
+func scanKeyRequests(rows *sql.Rows) ([]models.KeyRequest, error) {
+	var requests []models.KeyRequest
+	for rows.Next() {
+		var request models.KeyRequest
+		var jobs, scopes, createdAt string
+		var decidedAt, issuedAt, expiresAt sql.NullString
+		if err := rows.Scan(
+			&request.ID,
+			&request.Name,
+			&request.Tenant,
+			&jobs,
+			&scopes,
+			&request.Reason,
+			&request.RequestedBy,
+			&request.Status,
+			&request.DecidedBy,
+			&request.DecisionNote,
+			&request.KeyHash,
+			&request.KeyID,
+			&createdAt,
+			&decidedAt,
+			&issuedAt,
+			&expiresAt,
+		); err != nil {
+			return nil, err
+		}
+		if jobs != "" {
+			if err := json.Unmarshal([]byte(jobs), &request.Jobs); err != nil {
+				return nil, fmt.Errorf("failed to decode jobs of key request %d: %w", request.ID, err)
+			}
+		}
+		if scopes != "" {
+			if err := json.Unmarshal([]byte(scopes), &request.Scopes); err != nil {
+				return nil, fmt.Errorf("failed to decode scopes of key request %d: %w", request.ID, err)
+			}
+		}
+		request.CreatedAt = parseTimestamp(createdAt)
+		if decidedAt.Valid {
+			t := parseTimestamp(decidedAt.String)
+			request.DecidedAt = &t
+		}
+		if issuedAt.Valid {
+			t := parseTimestamp(issuedAt.String)
+			request.IssuedAt = &t
+		}
+		if expiresAt.Valid {
+			t := parseTimestamp(expiresAt.String)
+			request.ExpiresAt = &t
+		}
+		requests = append(requests, request)
+	}
+	return requests, rows.Err()
+}
+
+// encodeStrings encodes a string list as JSON, or "" when it is empty
+func encodeStrings(values []string) (string, error) {
+	if len(values) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"triggermesh/internal/storage/models"
+)
+
+// auditLogColumns is the column list selected for audit log rows
+const auditLogColumns = "id, timestamp, api_key, method, path, status, job_name, params, result, error, source, tenant, engine, trigger_id, duration_ms, engine_duration_ms, replay_of, labels, build_id, change_ref, global_build_id, params_truncated, git_commit, git_ref, params_hash"
+
+// GetAuditLogsInRange retrieves audit logs with start <= timestamp < end in insertion order
+func GetAuditLogsInRange(start, end time.Time) ([]models.AuditLog, error) {
+	rows, err := db.Query(
+		`SELECT `+auditLogColumns+` FROM audit_logs WHERE timestamp >= ? AND timestamp < ? ORDER BY id ASC`,
+		formatTimestamp(start),
+		formatTimestamp(end),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAuditLogs(rows)
+}
+
+// DeleteAuditLogsInRange deletes audit logs with start <= timestamp < end, and their full parameters,
+// and returns the number of audit rows removed
+func DeleteAuditLogsInRange(start, end time.Time) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.Exec(
+		`DELETE FROM audit_log_params WHERE audit_log_id IN (SELECT id FROM audit_logs WHERE timestamp >= ? AND timestamp < ?)`,
+		formatTimestamp(start),
+		formatTimestamp(end),
+	); err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec(
+		`DELETE FROM audit_logs WHERE timestamp >= ? AND timestamp < ?`,
+		formatTimestamp(start),
+		formatTimestamp(end),
+	)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
+
+// GetOldestAuditTimestamp returns the oldest audit timestamp at or after the given time
+// The boolean is false when there is no such row
+func GetOldestAuditTimestamp(after time.Time) (time.Time, bool, error) {
+	var timestampStr sql.NullString
+	err := db.QueryRow(
+		`SELECT MIN(timestamp) FROM audit_logs WHERE timestamp >= ?`,
+		formatTimestamp(after),
+	).Scan(&timestampStr)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if !timestampStr.Valid {
+		return time.Time{}, false, nil
+	}
+	return parseTimestamp(timestampStr.String), true, nil
+}
+
+// GetAuditLiveRange returns the time range and row count of the live audit table
+func GetAuditLiveRange() (models.AuditLiveRange, error) {
+	var liveRange models.AuditLiveRange
+	if !sqliteActive() {
+		return liveRange, errNoDatabase
+	}
+
+	var oldest, newest sql.NullString
+	err := db.QueryRow(`SELECT MIN(timestamp), MAX(timestamp), COUNT(*) FROM audit_logs`).Scan(&oldest, &newest, &liveRange.RowCount)
+	if err != nil {
+		return liveRange, err
+	}
+	if oldest.Valid {
+		t := parseTimestamp(oldest.String)
+		liveRange.Oldest = &t
+	}
+	if newest.Valid {
+		t := parseTimestamp(newest.String)
+		liveRange.Newest = &t
+	}
+	return liveRange, nil
+}
+
+// InsertAuditArchive records a manifest entry for an archived audit partition
+func InsertAuditArchive(archive models.AuditArchive) error {
+	_, err := db.Exec(
+		`INSERT INTO audit_archives (partition_start, partition_end, object_key, row_count, size_bytes, live_deleted, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		formatTimestamp(archive.PartitionStart),
+		formatTimestamp(archive.PartitionEnd),
+		archive.ObjectKey,
+		archive.RowCount,
+		archive.SizeBytes,
+		archive.LiveDeleted,
+		formatTimestamp(archive.CreatedAt),
+	)
+	return err
+}
+
+// GetAuditArchives retrieves all archive manifest entries ordered by partition
+func GetAuditArchives() ([]models.AuditArchive, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(
+		`SELECT id, partition_start, partition_end, object_key, row_count, size_bytes, live_deleted, created_at FROM audit_archives ORDER BY partition_start ASC`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	archives := []models.AuditArchive{}
+	for rows.Next() {
+		var archive models.AuditArchive
+		var start, end, createdAt string
+		if err := rows.Scan(&archive.ID, &start, &end, &archive.ObjectKey, &archive.RowCount, &archive.SizeBytes, &archive.LiveDeleted, &createdAt); err != nil {
+			return nil, err
+		}
+		archive.PartitionStart = parseTimestamp(start)
+		archive.PartitionEnd = parseTimestamp(end)
+		archive.CreatedAt = parseTimestamp(createdAt)
+		archives = append(archives, archive)
+	}
+
+	return archives, rows.Err()
+}
+
+// GetLatestArchivedPartitionEnd returns the end of the most recent archived partition
+// The boolean is false when nothing has been archived yet
+func GetLatestArchivedPartitionEnd() (time.Time, bool, error) {
+	var end sql.NullString
+	if err := db.QueryRow(`SELECT MAX(partition_end) FROM audit_archives`).Scan(&end); err != nil {
+		return time.Time{}, false, err
+	}
+	if !end.Valid {
+		return time.Time{}, false, nil
+	}
+	return parseTimestamp(end.String), true, nil
+}
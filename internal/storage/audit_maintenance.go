@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"time"
+
+	"triggermesh/internal/storage/models"
+)
+
+// CountAuditLogsInRange returns the number of audit logs with start <= timestamp < end
+func CountAuditLogsInRange(start, end time.Time) (int64, error) {
+	if !sqliteActive() {
+		return 0, errNoDatabase
+	}
+
+	var count int64
+	err := db.QueryRow(
+		`SELECT COUNT(*) FROM audit_logs WHERE timestamp >= ? AND timestamp < ?`,
+		formatTimestamp(start),
+		formatTimestamp(end),
+	).Scan(&count)
+	return count, err
+}
+
+// CountAPIKeyAuditLogsSince returns the number of audit logs of the API key with timestamp >= since,
+// and how many of them failed (status 400 or above)
+func CountAPIKeyAuditLogsSince(apiKey string, since time.Time) (total, failed int64, err error) {
+	if !sqliteActive() {
+		return 0, 0, errNoDatabase
+	}
+
+	err = db.QueryRow(
+		`SELECT COUNT(*), COALESCE(SUM(status >= 400), 0) FROM audit_logs WHERE api_key = ? AND timestamp >= ?`,
+		apiKey,
+		formatTimestamp(since),
+	).Scan(&total, &failed)
+	return total, failed, err
+}
+
+// EachAuditLog calls fn for every audit log with start <= timestamp < end in insertion order,
+// streaming rows so exports of large tables do not load them all into memory
+// It stops at the first error returned by fn
+func EachAuditLog(start, end time.Time, fn func(models.AuditLog) error) error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+
+	rows, err := db.Query(
+		`SELECT `+auditLogColumns+` FROM audit_logs WHERE timestamp >= ? AND timestamp < ? ORDER BY id ASC`,
+		formatTimestamp(start),
+		formatTimestamp(end),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		log, err := scanAuditLog(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetAuditStats summarizes the live audit table by result, source, and job
+func GetAuditStats(topJobs int) (*models.AuditStats, error) {
+	liveRange, err := GetAuditLiveRange()
+	if err != nil {
+		return nil, err
+	}
+	stats := &models.AuditStats{AuditLiveRange: liveRange}
+
+	if stats.ByResult, err = countAuditLogsBy("result"); err != nil {
+		return nil, err
+	}
+	if stats.BySource, err = countAuditLogsBy("source"); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT job_name, COUNT(*) AS n FROM audit_logs GROUP BY job_name ORDER BY n DESC, job_name ASC LIMIT ?`, topJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var job models.JobCount
+		if err := rows.Scan(&job.JobName, &job.Count); err != nil {
+			return nil, err
+		}
+		stats.TopJobs = append(stats.TopJobs, job)
+	}
+	return stats, rows.Err()
+}
+
+// countAuditLogsBy counts audit logs grouped by a column; column is never user input
+func countAuditLogsBy(column string) (map[string]int64, error) {
+	rows, err := db.Query(`SELECT ` + column + `, COUNT(*) FROM audit_logs GROUP BY ` + column)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var value string
+		var count int64
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, err
+		}
+		counts[value] = count
+	}
+	return counts, rows.Err()
+}
+
+// Vacuum rebuilds the database file to reclaim the space left by deleted rows
+func Vacuum() error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+	_, err := db.Exec(`VACUUM`)
+	return err
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Backup writes a consistent snapshot of the open database to path with VACUUM INTO and
+// verifies it; the database stays online, and path must not exist yet
+func Backup(path string) error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup target %s already exists", path)
+	}
+
+	if _, err := db.Exec(`VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	if err := VerifyDatabase(path); err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("backup failed verification: %w", err)
+	}
+	return nil
+}
+
+// VerifyDatabase checks that the file at path is an intact TriggerMesh database whose schema
+// this version can open: SQLite's integrity check passes and no migration is newer than known ones
+func VerifyDatabase(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+
+	conn, err := sql.Open(DriverName, path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var result string
+	if err := conn.QueryRow(`PRAGMA integrity_check`).Scan(&result); err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+
+	var version int
+	if err := conn.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return fmt.Errorf("not a TriggerMesh database: %w", err)
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than this version supports (%d)", version, len(migrations))
+	}
+	return nil
+}
+
+// Restore replaces the database file at dbPath with a verified copy of the backup
+// It must run before Init, while no process has the database open; migrations are
+// applied to the restored data by the next Init
+func Restore(backupPath, dbPath string) error {
+	if db != nil && db.Ping() == nil {
+		return errors.New("cannot restore while the database is open")
+	}
+	if err := VerifyDatabase(backupPath); err != nil {
+		return fmt.Errorf("backup failed verification: %w", err)
+	}
+
+	// Copy next to the target and rename, so a failed copy never leaves a partial database
+	tmpPath := dbPath + ".restore"
+	if err := copyFile(backupPath, tmpPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+	// Stale WAL files belong to the replaced database and must not be replayed into the restored one
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			_ = os.Remove(tmpPath)
+			return err
+		}
+	}
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace database: %w", err)
+	}
+	return nil
+}
+
+// copyFile copies src to dst and syncs it to disk
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
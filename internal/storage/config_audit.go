@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"triggermesh/internal/storage/models"
+)
+
+// InsertConfigAudit records a configuration change or administrative action
+func InsertConfigAudit(entry models.ConfigAudit) error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+
+	changes := ""
+	if len(entry.Changes) > 0 {
+		data, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return fmt.Errorf("failed to encode config changes: %w", err)
+		}
+		changes = string(data)
+	}
+
+	_, err := db.Exec(
+		`INSERT INTO config_audit (timestamp, action, actor, details, changes) VALUES (?, ?, ?, ?, ?)`,
+		formatTimestamp(entry.Timestamp),
+		entry.Action,
+		entry.Actor,
+		entry.Details,
+		changes,
+	)
+	return err
+}
+
+// GetConfigAudit retrieves configuration audit entries with pagination, newest first
+// A non-empty action restricts the result to that action
+func GetConfigAudit(action string, limit, offset int) ([]models.ConfigAudit, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(
+		`SELECT id, timestamp, action, actor, details, changes FROM config_audit
+		WHERE ? = '' OR action = ? ORDER BY id DESC LIMIT ? OFFSET ?`,
+		action,
+		action,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.ConfigAudit
+	for rows.Next() {
+		var entry models.ConfigAudit
+		var timestamp, changes string
+		if err := rows.Scan(&entry.ID, &timestamp, &entry.Action, &entry.Actor, &entry.Details, &changes); err != nil {
+			return nil, err
+		}
+		entry.Timestamp = parseTimestamp(timestamp)
+		if changes != "" {
+			if err := json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+				return nil, fmt.Errorf("failed to decode config changes of entry %d: %w", entry.ID, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,17 @@
+//go:build cgo && !purego
+
+package storage
+
+import (
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// DriverName is the database/sql driver backing the SQLite database
+// cgo builds use mattn/go-sqlite3; build with CGO_ENABLED=0 or -tags purego for the pure-Go driver
+const DriverName = "sqlite3"
+
+// dataSourceName returns the connection string for the database file with WAL journaling,
+// normal synchronous mode, and foreign keys enabled
+func dataSourceName(path string) string {
+	return path + "?_journal_mode=WAL&_synchronous=NORMAL&_foreign_keys=ON"
+}
@@ -0,0 +1,17 @@
+//go:build !cgo || purego
+
+package storage
+
+import (
+	_ "modernc.org/sqlite"
+)
+
+// DriverName is the database/sql driver backing the SQLite database
+// Builds without cgo (or with -tags purego) use modernc.org/sqlite, which needs no C toolchain
+const DriverName = "sqlite"
+
+// dataSourceName returns the connection string for the database file with WAL journaling,
+// normal synchronous mode, and foreign keys enabled
+func dataSourceName(path string) string {
+	return path + "?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=foreign_keys(ON)"
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"triggermesh/internal/storage/models"
+)
+
+// killSwitchColumns lists the columns read by scanKillSwitches, in order
+const killSwitchColumns = "id, pattern, reason, created_by, created_at, expires_at, lifted_by, lifted_at"
+
+// InsertKillSwitch stores a kill switch and returns its ID
+func InsertKillSwitch(killSwitch models.KillSwitch) (int64, error) {
+	if !sqliteActive() {
+		return 0, errNoDatabase
+	}
+
+	result, err := db.Exec(
+		`INSERT INTO kill_switches (pattern, reason, created_by, created_at, expires_at) VALUES (?, ?, ?, ?, ?)`,
+		killSwitch.Pattern,
+		killSwitch.Reason,
+		killSwitch.CreatedBy,
+		formatTimestamp(killSwitch.CreatedAt),
+		formatTimestamp(killSwitch.ExpiresAt),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetKillSwitch returns the kill switch with the given ID, or nil if there is none
+func GetKillSwitch(id int64) (*models.KillSwitch, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(`SELECT `+killSwitchColumns+` FROM kill_switches WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	killSwitches, err := scanKillSwitches(rows)
+	if err != nil || len(killSwitches) == 0 {
+		return nil, err
+	}
+	return &killSwitches[0], nil
+}
+
+// GetActiveKillSwitches returns the kill switches neither lifted nor expired at the given time,
+// soonest expiry first
+// Without the SQLite database no kill switch can be stored, so none is returned; triggers check
+// kill switches with every backend
+func GetActiveKillSwitches(now time.Time) ([]models.KillSwitch, error) {
+	if !sqliteActive() {
+		return nil, nil
+	}
+
+	rows, err := db.Query(
+		`SELECT `+killSwitchColumns+` FROM kill_switches WHERE lifted_at IS NULL AND expires_at > ? ORDER BY expires_at ASC, id ASC`,
+		formatTimestamp(now),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanKillSwitches(rows)
+}
+
+// LiftKillSwitch ends an active kill switch before it expires
+// It returns false if the kill switch does not exist, was already lifted, or has expired
+func LiftKillSwitch(id int64, liftedBy string, liftedAt time.Time) (bool, error) {
+	if !sqliteActive() {
+		return false, errNoDatabase
+	}
+
+	result, err := db.Exec(
+		`UPDATE kill_switches SET lifted_by = ?, lifted_at = ? WHERE id = ? AND lifted_at IS NULL AND expires_at > ?`,
+		liftedBy,
+		formatTimestamp(liftedAt),
+		id,
+		formatTimestamp(liftedAt),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// scanKillSwitches reads kill switches selected with killSwitchColumns
+func scanKillSwitches(rows *sql.Rows) ([]models.KillSwitch, error) {
+	var killSwitches []models.KillSwitch
+	for rows.Next() {
+		var killSwitch models.KillSwitch
+		var createdAt, expiresAt string
+		var liftedAt sql.NullString
+		if err := rows.Scan(
+			&killSwitch.ID,
+			&killSwitch.Pattern,
+			&killSwitch.Reason,
+			&killSwitch.CreatedBy,
+			&createdAt,
+			&expiresAt,
+			&killSwitch.LiftedBy,
+			&liftedAt,
+		); err != nil {
+			return nil, err
+		}
+		killSwitch.CreatedAt = parseTimestamp(createdAt)
+		killSwitch.ExpiresAt = parseTimestamp(expiresAt)
+		if liftedAt.Valid {
+			t := parseTimestamp(liftedAt.String)
+			killSwitch.LiftedAt = &t
+		}
+		killSwitches = append(killSwitches, killSwitch)
+	}
+	return killSwitches, rows.Err()
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// encodeLabels serializes trigger labels for the labels column; no labels are stored as an empty string
+func encodeLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// decodeLabels parses the labels column, ignoring empty or malformed values
+func decodeLabels(value string) map[string]string {
+	if value == "" {
+		return nil
+	}
+	var labels map[string]string
+	if err := json.Unmarshal([]byte(value), &labels); err != nil {
+		return nil
+	}
+	return labels
+}
+
+// GetBuildLabels retrieves the labels of the trigger that started a build, or nil if it has none
+// Custom stores do not index builds, so lookups against them return nil
+func GetBuildLabels(buildID string) (map[string]string, error) {
+	if store != nil {
+		return nil, nil
+	}
+	if db == nil {
+		return nil, errNoDatabase
+	}
+
+	var labels string
+	err := db.QueryRow(
+		`SELECT labels FROM audit_logs WHERE build_id = ? ORDER BY id DESC LIMIT 1`,
+		buildID,
+	).Scan(&labels)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeLabels(labels), nil
+}
+
+// GetBuildJob retrieves the job of the trigger that started a build on the engine, or "" if unknown
+// Custom stores do not index builds, so lookups against them return ""
+func GetBuildJob(engine, buildID string) (string, error) {
+	if store != nil {
+		return "", nil
+	}
+	if db == nil {
+		return "", errNoDatabase
+	}
+
+	var job string
+	err := db.QueryRow(
+		`SELECT job_name FROM audit_logs WHERE engine = ? AND build_id = ? ORDER BY id DESC LIMIT 1`,
+		engine, buildID,
+	).Scan(&job)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return job, err
+}
+
+// labelPath builds the JSON path selecting a label key, quoted so keys containing dots match literally
+func labelPath(key string) string {
+	return `$."` + key + `"`
+}
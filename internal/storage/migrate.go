@@ -0,0 +1,233 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"triggermesh/internal/logger"
+)
+
+// migrations upgrade the schema created by createTables
+// Each entry is applied once, in order, and recorded in schema_migrations by its 1-based position;
+// only ever append to this list
+var migrations = []string{
+	// 1: trigger source attribution
+	`ALTER TABLE audit_logs ADD COLUMN source TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE audit_logs ADD COLUMN tenant TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE audit_logs ADD COLUMN engine TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE audit_logs ADD COLUMN trigger_id TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE audit_logs ADD COLUMN duration_ms INTEGER NOT NULL DEFAULT 0`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_logs_trigger_id ON audit_logs(trigger_id)`,
+	// 7: engine round-trip latency
+	`ALTER TABLE audit_logs ADD COLUMN engine_duration_ms INTEGER NOT NULL DEFAULT 0`,
+	// 8: per-job build statistics
+	`CREATE TABLE IF NOT EXISTS job_stats (
+		job_name TEXT PRIMARY KEY,
+		builds_total INTEGER NOT NULL DEFAULT 0,
+		builds_succeeded INTEGER NOT NULL DEFAULT 0,
+		builds_failed INTEGER NOT NULL DEFAULT 0,
+		total_duration_ms INTEGER NOT NULL DEFAULT 0,
+		last_result TEXT NOT NULL DEFAULT '',
+		last_build_id TEXT NOT NULL DEFAULT '',
+		last_failure_at DATETIME,
+		last_failure_build_id TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS tracked_builds (
+		build_id TEXT PRIMARY KEY,
+		job_name TEXT NOT NULL,
+		engine TEXT NOT NULL,
+		triggered_at DATETIME NOT NULL
+	)`,
+	// 10: replay linkage
+	`ALTER TABLE audit_logs ADD COLUMN replay_of INTEGER NOT NULL DEFAULT 0`,
+	// 11: trigger labels and the build they started
+	`ALTER TABLE audit_logs ADD COLUMN labels TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE audit_logs ADD COLUMN build_id TEXT NOT NULL DEFAULT ''`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_logs_build_id ON audit_logs(build_id)`,
+	// 14: change ticket correlation
+	`ALTER TABLE audit_logs ADD COLUMN change_ref TEXT NOT NULL DEFAULT ''`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_logs_change_ref ON audit_logs(change_ref)`,
+	// 16: configuration change and admin action audit
+	`CREATE TABLE IF NOT EXISTS config_audit (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		action TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		details TEXT NOT NULL DEFAULT '',
+		changes TEXT NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_config_audit_action ON config_audit(action)`,
+	// 18: triggers held until their not_before time
+	`CREATE TABLE IF NOT EXISTS scheduled_triggers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trigger_id TEXT NOT NULL UNIQUE,
+		job_name TEXT NOT NULL,
+		params TEXT NOT NULL DEFAULT '',
+		labels TEXT NOT NULL DEFAULT '',
+		change_ref TEXT NOT NULL DEFAULT '',
+		api_key TEXT NOT NULL,
+		principal TEXT NOT NULL DEFAULT '',
+		not_before DATETIME NOT NULL,
+		deadline DATETIME,
+		status TEXT NOT NULL,
+		build_id TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_scheduled_triggers_due ON scheduled_triggers(status, not_before)`,
+	// 20: TriggerMesh-wide build IDs mapped to the engine's build ID
+	`ALTER TABLE audit_logs ADD COLUMN global_build_id TEXT NOT NULL DEFAULT ''`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_logs_global_build_id ON audit_logs(global_build_id)`,
+	// 22: self-service API key requests and the keys issued for them
+	`CREATE TABLE IF NOT EXISTS api_key_requests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		tenant TEXT NOT NULL DEFAULT '',
+		jobs TEXT NOT NULL DEFAULT '',
+		scopes TEXT NOT NULL DEFAULT '',
+		reason TEXT NOT NULL DEFAULT '',
+		requested_by TEXT NOT NULL,
+		status TEXT NOT NULL,
+		decided_by TEXT NOT NULL DEFAULT '',
+		decision_note TEXT NOT NULL DEFAULT '',
+		key_hash TEXT NOT NULL DEFAULT '',
+		key_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		decided_at DATETIME,
+		issued_at DATETIME
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_api_key_requests_key_hash ON api_key_requests(key_hash)`,
+	// 24: expiry of issued API keys
+	`ALTER TABLE api_key_requests ADD COLUMN expires_at DATETIME`,
+	// 25: truncated trigger parameters, with the full parameters kept out of the hot table
+	`ALTER TABLE audit_logs ADD COLUMN params_truncated INTEGER NOT NULL DEFAULT 0`,
+	`CREATE TABLE IF NOT EXISTS audit_log_params (
+		audit_log_id INTEGER PRIMARY KEY,
+		params TEXT NOT NULL
+	)`,
+	// 27: daily per-job trigger counts, refreshed from audit_logs in the background
+	`CREATE TABLE IF NOT EXISTS audit_daily_summary (
+		day TEXT NOT NULL,
+		job_name TEXT NOT NULL,
+		triggers INTEGER NOT NULL DEFAULT 0,
+		succeeded INTEGER NOT NULL DEFAULT 0,
+		failed INTEGER NOT NULL DEFAULT 0,
+		denied INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (day, job_name)
+	)`,
+	// 28: hourly buckets, so days can be summed up in any time zone; rebuilt from audit_logs
+	`DROP TABLE IF EXISTS audit_daily_summary`,
+	`CREATE TABLE IF NOT EXISTS audit_hourly_summary (
+		hour TEXT NOT NULL,
+		job_name TEXT NOT NULL,
+		triggers INTEGER NOT NULL DEFAULT 0,
+		succeeded INTEGER NOT NULL DEFAULT 0,
+		failed INTEGER NOT NULL DEFAULT 0,
+		denied INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (hour, job_name)
+	)`,
+	// 30: parameter fingerprints of triggers, to reuse recent successful builds
+	`ALTER TABLE audit_logs ADD COLUMN params_hash TEXT NOT NULL DEFAULT ''`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_logs_params_hash ON audit_logs(job_name, params_hash)`,
+	// 32: Git commit and ref of triggers, to find what was deployed for a commit
+	`ALTER TABLE audit_logs ADD COLUMN git_commit TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE audit_logs ADD COLUMN git_ref TEXT NOT NULL DEFAULT ''`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_logs_git_commit ON audit_logs(git_commit)`,
+	`ALTER TABLE scheduled_triggers ADD COLUMN git_commit TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE scheduled_triggers ADD COLUMN git_ref TEXT NOT NULL DEFAULT ''`,
+	// 37: outbound events written with their audit entries, delivered at least once by the outbox dispatcher
+	`CREATE TABLE IF NOT EXISTS outbox_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		audit_log_id INTEGER NOT NULL,
+		destination TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		next_attempt_at DATETIME NOT NULL,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_outbox_events_due ON outbox_events(status, next_attempt_at)`,
+	// 39: emergency kill switches disabling triggers of jobs
+	`CREATE TABLE IF NOT EXISTS kill_switches (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		pattern TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL,
+		lifted_by TEXT NOT NULL DEFAULT '',
+		lifted_at DATETIME
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_kill_switches_expires_at ON kill_switches(expires_at)`,
+	// 41: triggers whose builds may no longer be reused, purged by admins
+	`CREATE TABLE IF NOT EXISTS reuse_purges (
+		audit_id INTEGER PRIMARY KEY,
+		purged_by TEXT NOT NULL,
+		purged_at DATETIME NOT NULL
+	)`,
+	// 42: last fire time of each recurring schedule
+	`CREATE TABLE IF NOT EXISTS schedule_fires (
+		name TEXT PRIMARY KEY,
+		last_fire_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	)`,
+	// 43: claimed fire times of recurring schedules whose trigger has not succeeded yet
+	`CREATE TABLE IF NOT EXISTS schedule_pending_fires (
+		name TEXT NOT NULL,
+		fire_at DATETIME NOT NULL,
+		trigger_id TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		retry_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (name, fire_at)
+	)`,
+	// 44: when the attempt of a pending fire time called the engine without recording its outcome
+	`ALTER TABLE schedule_pending_fires ADD COLUMN dispatched_at DATETIME`,
+}
+
+// migrate applies the migrations that have not been applied yet
+func migrate() error {
+	if _, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at DATETIME NOT NULL
+	)
+	`); err != nil {
+		return err
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+
+	for version := current + 1; version <= len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[version-1]); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d failed: %w", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, formatTimestamp(time.Now())); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	if current < len(migrations) {
+		logger.Info("Applied database migrations", "from_version", current, "to_version", len(migrations))
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// API key request states recorded in KeyRequest.Status
+const (
+	KeyRequestPending  = "pending"
+	KeyRequestApproved = "approved" // Approved by an admin; the requester has not claimed the key yet
+	KeyRequestDenied   = "denied"
+	KeyRequestIssued   = "issued" // The requester claimed the key, which is now accepted
+)
+
+// KeyRequest is a self-service request for an API key and, once claimed, the issued key
+// Only a hash of the key is stored; the key itself is returned once to the requester
+type KeyRequest struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"` // Client name of the requested key
+	Tenant       string     `json:"tenant,omitempty"`
+	Jobs         []string   `json:"jobs,omitempty"`   // Job name patterns; empty means all jobs
+	Scopes       []string   `json:"scopes,omitempty"` // Extra permissions such as admin
+	Reason       string     `json:"reason,omitempty"`
+	RequestedBy  string     `json:"requested_by"` // Actor that made the request
+	Status       string     `json:"status"`
+	DecidedBy    string     `json:"decided_by,omitempty"`
+	DecisionNote string     `json:"decision_note,omitempty"`
+	KeyHash      string     `json:"-"`                    // SHA-256 of the issued key, hex encoded
+	KeyID        string     `json:"key_id,omitempty"`     // Fingerprint of the issued key as in audit actors
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"` // The issued key is refused from this time; nil never expires
+	CreatedAt    time.Time  `json:"created_at"`
+	DecidedAt    *time.Time `json:"decided_at,omitempty"`
+	IssuedAt     *time.Time `json:"issued_at,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditArchive represents a manifest entry for an audit partition exported to object storage
+type AuditArchive struct {
+	ID             int64     `json:"id"`
+	PartitionStart time.Time `json:"partition_start"`
+	PartitionEnd   time.Time `json:"partition_end"`
+	ObjectKey      string    `json:"object_key"`
+	RowCount       int64     `json:"row_count"`
+	SizeBytes      int64     `json:"size_bytes"`
+	LiveDeleted    bool      `json:"live_deleted"` // Whether the rows were removed from the live table
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// AuditLiveRange describes the time range covered by the live audit table
+type AuditLiveRange struct {
+	Oldest   *time.Time `json:"oldest,omitempty"`
+	Newest   *time.Time `json:"newest,omitempty"`
+	RowCount int64      `json:"row_count"`
+}
@@ -4,16 +4,63 @@ import (
 	"time"
 )
 
+// Trigger sources recorded in AuditLog.Source
+const (
+	SourceHTTP     = "http"
+	SourceWebhook  = "webhook"
+	SourceSchedule = "schedule"
+	SourceQueue    = "queue"
+	SourceChain    = "chain"
+	SourceReplay   = "replay"
+)
+
+// AuditFilter selects audit log entries; all set fields must match
+type AuditFilter struct {
+	Labels    map[string]string // Labels the trigger must carry
+	ChangeRef string            // Change ticket of the trigger
+	Commit    string            // Git commit of the trigger; shorter SHAs match every commit they abbreviate
+	Ref       string            // Git ref of the trigger
+	Job       string            // Job name pattern; "*" matches any characters including folder separators
+	Since     time.Time         // Only entries at or after this time; zero for all
+	Until     time.Time         // Only entries before this time; zero for all
+	Terms     []string          // Values each matching the job, change ticket, ref, or a label value of the entry
+	APIKey    string            // API key that made the request
+	BeforeID  int64             // Only entries with a lower ID, for cursor pagination; 0 for all
+	AfterID   int64             // Only entries with a higher ID, oldest first, for tailing; 0 for all
+}
+
+// IsEmpty reports whether the filter matches every entry
+func (f AuditFilter) IsEmpty() bool {
+	return len(f.Labels) == 0 && f.ChangeRef == "" && f.Commit == "" && f.Ref == "" && f.Job == "" &&
+		f.Since.IsZero() && f.Until.IsZero() && len(f.Terms) == 0 && f.APIKey == "" && f.BeforeID == 0 && f.AfterID == 0
+}
+
 // AuditLog represents an audit log entry
 type AuditLog struct {
-	ID        int64     `json:"id"`
-	Timestamp time.Time `json:"timestamp"`
-	APIKey    string    `json:"api_key"`
-	Method    string    `json:"method"`
-	Path      string    `json:"path"`
-	Status    int       `json:"status"`
-	JobName   string    `json:"job_name"`
-	Params    string    `json:"params"`
-	Result    string    `json:"result"`
-	Error     string    `json:"error,omitempty"`
+	ID               int64             `json:"id"`
+	Timestamp        time.Time         `json:"timestamp"`
+	APIKey           string            `json:"api_key"`
+	Method           string            `json:"method"`
+	Path             string            `json:"path"`
+	Status           int               `json:"status"`
+	JobName          string            `json:"job_name"`
+	Params           string            `json:"params"`
+	Result           string            `json:"result"`
+	Error            string            `json:"error,omitempty"`
+	Source           string            `json:"source,omitempty"`           // What started the trigger (http, webhook, schedule, queue, chain, replay)
+	Tenant           string            `json:"tenant,omitempty"`           // Tenant of the API client, if configured
+	Engine           string            `json:"engine,omitempty"`           // CI engine that received the trigger
+	TriggerID        string            `json:"trigger_id,omitempty"`       // Unique ID of the trigger attempt
+	DurationMS       int64             `json:"duration_ms"`                // End-to-end handler duration
+	EngineDurationMS int64             `json:"engine_duration_ms"`         // CI engine round-trip duration, included in DurationMS
+	ReplayOf         int64             `json:"replay_of,omitempty"`        // ID of the audit entry this trigger replays
+	Labels           map[string]string `json:"labels,omitempty"`           // Client-supplied key/value metadata
+	BuildID          string            `json:"build_id,omitempty"`         // Build started by a successful trigger
+	ChangeRef        string            `json:"change_ref,omitempty"`       // Change ticket (Jira, ServiceNow) authorizing the trigger
+	Commit           string            `json:"commit,omitempty"`           // Git commit SHA the trigger builds, lowercase
+	Ref              string            `json:"ref,omitempty"`              // Git branch, tag, or full ref the trigger builds
+	GlobalBuildID    string            `json:"global_build_id,omitempty"`  // TriggerMesh-wide ID (ULID) of the build, mapped to Engine and BuildID
+	ParamsTruncated  bool              `json:"params_truncated,omitempty"` // Params holds only the beginning of the parameters (audit.max_params_size)
+	FullParams       string            `json:"-"`                          // Untruncated parameters of a truncated entry, stored in the side table when set
+	ParamsHash       string            `json:"-"`                          // Fingerprint of the requested parameters, matched for result reuse
 }
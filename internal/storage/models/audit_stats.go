@@ -0,0 +1,15 @@
+package models
+
+// AuditStats summarizes the live audit table for maintenance
+type AuditStats struct {
+	AuditLiveRange
+	ByResult map[string]int64 `json:"by_result"`
+	BySource map[string]int64 `json:"by_source"`
+	TopJobs  []JobCount       `json:"top_jobs"` // Most triggered jobs, most first
+}
+
+// JobCount is the number of audit entries of a job
+type JobCount struct {
+	JobName string `json:"job_name"`
+	Count   int64  `json:"count"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// Operational actions recorded in ConfigAudit.Action
+const (
+	ConfigActionReload      = "config_reload"     // Configuration file reloaded
+	ConfigActionKeyRotation = "key_rotation"      // API keys added, removed, or replaced by a reload
+	ConfigActionReplay      = "audit_replay"      // Admin replayed a recorded trigger
+	ConfigActionBulkReplay  = "audit_bulk_replay" // Admin re-triggered failed triggers
+	ConfigActionBackup      = "database_backup"   // Admin backed up the database
+	ConfigActionKeyRequest  = "key_request"       // API client requested a new API key
+	ConfigActionKeyApprove  = "key_approve"       // Admin approved a key request
+	ConfigActionKeyDeny     = "key_deny"          // Admin denied a key request
+	ConfigActionKeyIssue    = "key_issue"         // Requester claimed the key of an approved request
+	ConfigActionAuditRead   = "audit_read"        // API client read the audit log (audit.log_reads)
+	ConfigActionClockSkew   = "clock_skew"        // The local clock differs from the Jenkins clock by more than clock.max_skew
+	ConfigActionKillSwitch  = "kill_switch"       // Admin disabled triggering of jobs
+	ConfigActionKillLift    = "kill_switch_lift"  // Admin lifted a kill switch before it expired
+	ConfigActionReusePurge  = "reuse_purge"       // Admin stopped recent builds from answering identical triggers
+)
+
+// ConfigChange is a setting changed by an operational action; secrets are masked
+type ConfigChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ConfigAudit records a configuration change or administrative action
+type ConfigAudit struct {
+	ID        int64          `json:"id"`
+	Timestamp time.Time      `json:"timestamp"`
+	Action    string         `json:"action"`
+	Actor     string         `json:"actor"`             // API client name, API key fingerprint, "config_watcher", or "clock_check"
+	Details   string         `json:"details,omitempty"` // Free-form context, e.g. the replayed audit ID
+	Changes   []ConfigChange `json:"changes,omitempty"`
+}
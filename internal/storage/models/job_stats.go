@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// JobStats are aggregated build outcomes for a job
+type JobStats struct {
+	JobName            string     `json:"job_name"`
+	BuildsTotal        int64      `json:"builds_total"`
+	BuildsSucceeded    int64      `json:"builds_succeeded"`
+	BuildsFailed       int64      `json:"builds_failed"` // Any finished build whose result is not SUCCESS
+	SuccessRate        float64    `json:"success_rate"`  // BuildsSucceeded / BuildsTotal
+	AverageDurationMS  int64      `json:"average_duration_ms"`
+	LastResult         string     `json:"last_result"`
+	LastBuildID        string     `json:"last_build_id"`
+	LastFailureAt      *time.Time `json:"last_failure_at,omitempty"`
+	LastFailureBuildID string     `json:"last_failure_build_id,omitempty"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// TrackedBuild is a triggered build whose outcome has not been recorded yet
+type TrackedBuild struct {
+	BuildID     string    `json:"build_id"`
+	JobName     string    `json:"job_name"`
+	Engine      string    `json:"engine"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+// BuildOutcome is the final result of a tracked build
+type BuildOutcome struct {
+	JobName    string
+	BuildID    string
+	Result     string // Engine result, e.g. SUCCESS or FAILURE
+	Succeeded  bool
+	DurationMS int64
+	FinishedAt time.Time
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// KillSwitch disables triggering of the jobs matching a pattern until it expires or is lifted
+type KillSwitch struct {
+	ID        int64      `json:"id"`
+	Pattern   string     `json:"pattern"` // Job name pattern ("*" wildcard)
+	Reason    string     `json:"reason"`  // Returned to callers whose triggers are refused
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	LiftedBy  string     `json:"lifted_by,omitempty"`
+	LiftedAt  *time.Time `json:"lifted_at,omitempty"` // Set when an admin lifted the switch before it expired
+}
+
+// Active reports whether the kill switch disables triggers at the given time
+func (k KillSwitch) Active(now time.Time) bool {
+	return k.LiftedAt == nil && now.Before(k.ExpiresAt)
+}
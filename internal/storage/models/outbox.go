@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// Outbox event states recorded in OutboxEvent.Status
+const (
+	OutboxPending   = "pending"   // Waiting for its next delivery attempt
+	OutboxDelivered = "delivered" // Accepted by the destination
+	OutboxDead      = "dead"      // Given up after the maximum attempts, or its destination is gone
+)
+
+// OutboxEvent is an outbound event stored with the audit entry it derives from and delivered by
+// the outbox dispatcher
+type OutboxEvent struct {
+	ID            int64     `json:"id"`
+	AuditLogID    int64     `json:"audit_log_id"`
+	Destination   string    `json:"destination"` // Name of the destination in outbox.destinations
+	Payload       string    `json:"payload"`     // Request body delivered to the destination
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// OutboxEventBuilder derives the outbound events of an audit entry, whose ID is set; it fills in the
+// destination and payload of each event
+type OutboxEventBuilder func(log AuditLog) []OutboxEvent
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// Scheduled trigger states recorded in ScheduledTrigger.Status
+const (
+	ScheduledPending   = "scheduled" // Waiting for not_before
+	ScheduledRunning   = "running"   // Claimed by the scheduler
+	ScheduledTriggered = "triggered"
+	ScheduledFailed    = "failed"  // Refused by a policy or the engine
+	ScheduledExpired   = "expired" // The deadline passed before the trigger could run
+)
+
+// ScheduledTrigger is a trigger request held until its not_before time
+type ScheduledTrigger struct {
+	ID         int64             `json:"-"`
+	TriggerID  string            `json:"trigger_id"`
+	JobName    string            `json:"job"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	ChangeRef  string            `json:"change_ref,omitempty"`
+	Commit     string            `json:"commit,omitempty"`
+	Ref        string            `json:"ref,omitempty"`
+	APIKey     string            `json:"-"`
+	Principal  TriggerPrincipal  `json:"-"` // Identity of the API key when the trigger was scheduled
+	NotBefore  time.Time         `json:"not_before"`
+	Deadline   *time.Time        `json:"deadline,omitempty"`
+	Status     string            `json:"status"`
+	BuildID    string            `json:"build_id,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// TriggerPrincipal is the stored identity of the API key behind a scheduled trigger
+type TriggerPrincipal struct {
+	Name   string   `json:"name,omitempty"`
+	Tenant string   `json:"tenant,omitempty"`
+	Jobs   []string `json:"jobs,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// PendingScheduleFire is a claimed fire time of a recurring schedule whose trigger has not
+// succeeded yet; it is retried from RetryAt on
+type PendingScheduleFire struct {
+	Name      string
+	FireAt    time.Time
+	TriggerID string // Kept across attempts, so the audit log shows whether an attempt succeeded
+	Attempts  int
+	Error     string // Error of the last failed attempt
+	RetryAt   time.Time
+	// DispatchedAt is when an attempt called the engine, until its failure is recorded; an attempt
+	// interrupted after that may have started a build, so it is not repeated
+	DispatchedAt time.Time
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// DailyJobSummary counts the triggers of a job on one day
+type DailyJobSummary struct {
+	Day         string    `json:"day"` // YYYY-MM-DD in the requested time zone
+	JobName     string    `json:"job_name"`
+	Triggers    int64     `json:"triggers"`
+	Succeeded   int64     `json:"succeeded"`
+	Failed      int64     `json:"failed"`
+	Denied      int64     `json:"denied"`       // Refused by a policy before reaching the engine
+	FailureRate float64   `json:"failure_rate"` // Failed / Triggers
+	UpdatedAt   time.Time `json:"updated_at"`   // When the day was last refreshed from the audit log
+}
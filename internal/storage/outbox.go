@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"triggermesh/internal/storage/models"
+)
+
+// outboxEventColumns lists the columns read by scanOutboxEvents, in order
+const outboxEventColumns = "id, audit_log_id, destination, payload, status, attempts, last_error, next_attempt_at, created_at, updated_at"
+
+// outboxBuilder derives the outbox events written with each audit entry; nil writes none
+var outboxBuilder atomic.Pointer[models.OutboxEventBuilder]
+
+// SetOutboxEventBuilder sets how outbox events are derived from audit entries; they are written in
+// the same transaction as the entry. nil stops writing events
+// Custom stores do not support transactions, so their audit entries never have outbox events
+func SetOutboxEventBuilder(builder models.OutboxEventBuilder) {
+	if builder == nil {
+		outboxBuilder.Store(nil)
+		return
+	}
+	outboxBuilder.Store(&builder)
+}
+
+// insertOutboxEvents writes the outbox events of an audit entry, due immediately
+func insertOutboxEvents(e execer, log models.AuditLog) error {
+	builder := outboxBuilder.Load()
+	if builder == nil {
+		return nil
+	}
+	now := formatTimestamp(time.Now())
+	for _, event := range (*builder)(log) {
+		if _, err := e.Exec(
+			`INSERT INTO outbox_events (audit_log_id, destination, payload, status, next_attempt_at, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			log.ID,
+			event.Destination,
+			event.Payload,
+			models.OutboxPending,
+			now,
+			now,
+			now,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDueOutboxEvents returns up to limit pending events whose next attempt is at or before now, oldest first
+func GetDueOutboxEvents(now time.Time, limit int) ([]models.OutboxEvent, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(
+		`SELECT `+outboxEventColumns+` FROM outbox_events WHERE status = ? AND next_attempt_at <= ? ORDER BY id ASC LIMIT ?`,
+		models.OutboxPending,
+		formatTimestamp(now),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanOutboxEvents(rows)
+}
+
+// UpdateOutboxEvent records the outcome of a delivery attempt: the new status and attempt count,
+// the error of a failed attempt, and when a pending event is tried next
+func UpdateOutboxEvent(event models.OutboxEvent) error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+
+	_, err := db.Exec(
+		`UPDATE outbox_events SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, updated_at = ? WHERE id = ?`,
+		event.Status,
+		event.Attempts,
+		event.LastError,
+		formatTimestamp(event.NextAttemptAt),
+		formatTimestamp(time.Now()),
+		event.ID,
+	)
+	return err
+}
+
+// DeleteFinishedOutboxEvents deletes delivered and dead events last updated before the given time
+// and returns how many were deleted
+func DeleteFinishedOutboxEvents(before time.Time) (int64, error) {
+	if !sqliteActive() {
+		return 0, errNoDatabase
+	}
+
+	result, err := db.Exec(
+		`DELETE FROM outbox_events WHERE status IN (?, ?) AND updated_at < ?`,
+		models.OutboxDelivered,
+		models.OutboxDead,
+		formatTimestamp(before),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// CountOutboxEvents returns the number of stored events by status
+func CountOutboxEvents() (map[string]int64, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(`SELECT status, COUNT(*) FROM outbox_events GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var status string
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
+
+// scanOutboxEvents reads outbox event rows selected with outboxEventColumns
+func scanOutboxEvents(rows *sql.Rows) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	for rows.Next() {
+		var event models.OutboxEvent
+		var nextAttemptAt, createdAt, updatedAt string
+		if err := rows.Scan(
+			&event.ID,
+			&event.AuditLogID,
+			&event.Destination,
+			&event.Payload,
+			&event.Status,
+			&event.Attempts,
+			&event.LastError,
+			&nextAttemptAt,
+			&createdAt,
+			&updatedAt,
+		); err != nil {
+			return nil, err
+		}
+		event.NextAttemptAt = parseTimestamp(nextAttemptAt)
+		event.CreatedAt = parseTimestamp(createdAt)
+		event.UpdatedAt = parseTimestamp(updatedAt)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
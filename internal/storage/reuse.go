@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"time"
+
+	"triggermesh/internal/storage/models"
+)
+
+// GetReuseEntries retrieves up to limit successful triggers with a build, timestamp >= since, and
+// id < beforeID (0 for no bound), newest first; these are the triggers whose builds may be reused
+// A non-empty job pattern ("*" wildcard) filters by job name; purged triggers are excluded
+func GetReuseEntries(jobPattern string, since time.Time, beforeID int64, limit int) ([]models.AuditLog, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	query := `SELECT ` + auditLogColumns + ` FROM audit_logs WHERE result = 'success' AND build_id != '' AND timestamp >= ? AND id NOT IN (SELECT audit_id FROM reuse_purges)`
+	args := []interface{}{formatTimestamp(since)}
+	if jobPattern != "" {
+		// Job names cannot contain the other GLOB wildcards (? and [)
+		query += ` AND job_name GLOB ?`
+		args = append(args, jobPattern)
+	}
+	if beforeID > 0 {
+		query += ` AND id < ?`
+		args = append(args, beforeID)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanAuditLogs(rows)
+}
+
+// PurgeReuseEntries stops the builds of the given triggers from being reused and returns the
+// number of triggers newly purged
+func PurgeReuseEntries(auditIDs []int64, purgedBy string, purgedAt time.Time) (int64, error) {
+	if !sqliteActive() {
+		return 0, errNoDatabase
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	var purged int64
+	for _, id := range auditIDs {
+		result, err := tx.Exec(
+			`INSERT OR IGNORE INTO reuse_purges (audit_id, purged_by, purged_at) VALUES (?, ?, ?)`,
+			id,
+			purgedBy,
+			formatTimestamp(purgedAt),
+		)
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+		purged += affected
+	}
+	return purged, tx.Commit()
+}
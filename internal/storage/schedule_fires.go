@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"triggermesh/internal/storage/models"
+)
+
+// GetScheduleLastFire returns the last fire time of a recurring schedule; ok is false for a
+// schedule that has not been registered
+func GetScheduleLastFire(name string) (lastFire time.Time, ok bool, err error) {
+	if !sqliteActive() {
+		return time.Time{}, false, errNoDatabase
+	}
+
+	var value string
+	err = db.QueryRow(`SELECT last_fire_at FROM schedule_fires WHERE name = ?`, name).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return parseTimestamp(value), true, nil
+}
+
+// RegisterSchedule stores the starting point of a recurring schedule, whose first fire time is
+// after it; a schedule already registered keeps its last fire time
+func RegisterSchedule(name string, at time.Time) error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+
+	_, err := db.Exec(
+		`INSERT OR IGNORE INTO schedule_fires (name, last_fire_at, updated_at) VALUES (?, ?, ?)`,
+		name,
+		formatTimestamp(at),
+		formatTimestamp(time.Now()),
+	)
+	return err
+}
+
+// SkipScheduleFires moves the last fire time of a recurring schedule past fire times that are not
+// fired, such as those skipped by the misfire policy
+// It returns false when the last fire time is no longer from, because another instance moved it first
+func SkipScheduleFires(name string, from, to time.Time) (bool, error) {
+	if !sqliteActive() {
+		return false, errNoDatabase
+	}
+
+	result, err := db.Exec(
+		`UPDATE schedule_fires SET last_fire_at = ?, updated_at = ? WHERE name = ? AND last_fire_at = ?`,
+		formatTimestamp(to),
+		formatTimestamp(time.Now()),
+		name,
+		formatTimestamp(from),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ClaimScheduleFire moves the last fire time of a recurring schedule from one fire time to the
+// next and records the next fire time as pending, together
+// It returns false when the last fire time is no longer from, because another instance claimed
+// the fire time first; a claimed fire time is never claimed again, and stays pending until
+// FinishScheduleFire, so a trigger that failed or was interrupted is retried
+func ClaimScheduleFire(name string, from time.Time, fire models.PendingScheduleFire) (bool, error) {
+	if !sqliteActive() {
+		return false, errNoDatabase
+	}
+
+	claimed := false
+	err := WithTx(func(tx *Tx) error {
+		now := formatTimestamp(time.Now())
+		result, err := tx.tx.Exec(
+			`UPDATE schedule_fires SET last_fire_at = ?, updated_at = ? WHERE name = ? AND last_fire_at = ?`,
+			formatTimestamp(fire.FireAt),
+			now,
+			name,
+			formatTimestamp(from),
+		)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil || affected == 0 {
+			return err
+		}
+
+		if _, err := tx.tx.Exec(
+			`INSERT INTO schedule_pending_fires (name, fire_at, trigger_id, attempts, error, retry_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			name,
+			formatTimestamp(fire.FireAt),
+			fire.TriggerID,
+			fire.Attempts,
+			fire.Error,
+			formatTimestamp(fire.RetryAt),
+			now,
+		); err != nil {
+			return err
+		}
+		claimed = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return claimed, nil
+}
+
+// ClaimScheduleRetries returns the pending fire times of a recurring schedule whose retry_at is at
+// or before now, oldest first, counting an attempt for each and holding them until leaseUntil
+// A fire time held by another instance is not returned; one whose trigger was interrupted by a
+// crash is returned again once its lease ran out, with the DispatchedAt of the interrupted attempt
+func ClaimScheduleRetries(name string, now, leaseUntil time.Time) ([]models.PendingScheduleFire, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	var claimed []models.PendingScheduleFire
+	err := WithTx(func(tx *Tx) error {
+		rows, err := tx.tx.Query(
+			`SELECT fire_at, trigger_id, attempts, error, retry_at, dispatched_at FROM schedule_pending_fires WHERE name = ? AND retry_at <= ? ORDER BY fire_at ASC`,
+			name,
+			formatTimestamp(now),
+		)
+		if err != nil {
+			return err
+		}
+		var due []models.PendingScheduleFire
+		for rows.Next() {
+			fire := models.PendingScheduleFire{Name: name}
+			var fireAt, at string
+			var dispatchedAt sql.NullString
+			if err := rows.Scan(&fireAt, &fire.TriggerID, &fire.Attempts, &fire.Error, &at, &dispatchedAt); err != nil {
+				rows.Close()
+				return err
+			}
+			fire.FireAt = parseTimestamp(fireAt)
+			fire.RetryAt = parseTimestamp(at)
+			if dispatchedAt.Valid {
+				fire.DispatchedAt = parseTimestamp(dispatchedAt.String)
+			}
+			due = append(due, fire)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, fire := range due {
+			result, err := tx.tx.Exec(
+				`UPDATE schedule_pending_fires SET attempts = attempts + 1, retry_at = ?, updated_at = ? WHERE name = ? AND fire_at = ? AND retry_at = ?`,
+				formatTimestamp(leaseUntil),
+				formatTimestamp(now),
+				name,
+				formatTimestamp(fire.FireAt),
+				formatTimestamp(fire.RetryAt),
+			)
+			if err != nil {
+				return err
+			}
+			if affected, err := result.RowsAffected(); err != nil {
+				return err
+			} else if affected == 0 {
+				continue
+			}
+			fire.Attempts++
+			fire.RetryAt = leaseUntil
+			claimed = append(claimed, fire)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// DispatchScheduleFire records that the attempt of a pending fire time is calling the engine,
+// right before it does
+func DispatchScheduleFire(name string, fireAt time.Time) error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+
+	now := formatTimestamp(time.Now())
+	_, err := db.Exec(
+		`UPDATE schedule_pending_fires SET dispatched_at = ?, updated_at = ? WHERE name = ? AND fire_at = ?`,
+		now,
+		now,
+		name,
+		formatTimestamp(fireAt),
+	)
+	return err
+}
+
+// FailScheduleFire records the error of a failed attempt of a pending fire time, which is retried
+// from retryAt on; the attempt is no longer dispatched, as the engine did not start a build
+func FailScheduleFire(name string, fireAt time.Time, errMsg string, retryAt time.Time) error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+
+	_, err := db.Exec(
+		`UPDATE schedule_pending_fires SET error = ?, retry_at = ?, dispatched_at = NULL, updated_at = ? WHERE name = ? AND fire_at = ?`,
+		errMsg,
+		formatTimestamp(retryAt),
+		formatTimestamp(time.Now()),
+		name,
+		formatTimestamp(fireAt),
+	)
+	return err
+}
+
+// FinishScheduleFire removes a pending fire time once its trigger succeeded or was given up
+func FinishScheduleFire(name string, fireAt time.Time) error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+
+	_, err := db.Exec(`DELETE FROM schedule_pending_fires WHERE name = ? AND fire_at = ?`, name, formatTimestamp(fireAt))
+	return err
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"triggermesh/internal/storage/models"
+)
+
+// scheduledTriggerColumns lists the columns read by scanScheduledTriggers, in order
+const scheduledTriggerColumns = "id, trigger_id, job_name, params, labels, change_ref, api_key, principal, not_before, deadline, status, build_id, error, created_at, updated_at, git_commit, git_ref"
+
+// InsertScheduledTrigger stores a trigger to be fired by the scheduler once its not_before time is reached
+func InsertScheduledTrigger(trigger models.ScheduledTrigger) error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+
+	params, err := json.Marshal(trigger.Parameters)
+	if err != nil {
+		return fmt.Errorf("failed to encode parameters: %w", err)
+	}
+	principal, err := json.Marshal(trigger.Principal)
+	if err != nil {
+		return fmt.Errorf("failed to encode principal: %w", err)
+	}
+	var deadline sql.NullString
+	if trigger.Deadline != nil {
+		deadline = sql.NullString{String: formatTimestamp(*trigger.Deadline), Valid: true}
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO scheduled_triggers (trigger_id, job_name, params, labels, change_ref, api_key, principal, not_before, deadline, status, created_at, updated_at, git_commit, git_ref) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		trigger.TriggerID,
+		trigger.JobName,
+		string(params),
+		encodeLabels(trigger.Labels),
+		trigger.ChangeRef,
+		trigger.APIKey,
+		string(principal),
+		formatTimestamp(trigger.NotBefore),
+		deadline,
+		trigger.Status,
+		formatTimestamp(trigger.CreatedAt),
+		formatTimestamp(trigger.CreatedAt),
+		trigger.Commit,
+		trigger.Ref,
+	)
+	return err
+}
+
+// GetScheduledTrigger returns the scheduled trigger with the given trigger ID, or nil if there is none
+func GetScheduledTrigger(triggerID string) (*models.ScheduledTrigger, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(`SELECT `+scheduledTriggerColumns+` FROM scheduled_triggers WHERE trigger_id = ?`, triggerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	triggers, err := scanScheduledTriggers(rows)
+	if err != nil || len(triggers) == 0 {
+		return nil, err
+	}
+	return &triggers[0], nil
+}
+
+// ClaimDueScheduledTriggers marks up to limit pending triggers whose not_before is at or before now
+// as running and returns them, oldest first
+// A claimed trigger is never returned again, so a trigger interrupted by a crash is not fired twice
+func ClaimDueScheduledTriggers(now time.Time, limit int) ([]models.ScheduledTrigger, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	var claimed []models.ScheduledTrigger
+	err := WithTx(func(tx *Tx) error {
+		rows, err := tx.tx.Query(
+			`SELECT `+scheduledTriggerColumns+` FROM scheduled_triggers WHERE status = ? AND not_before <= ? ORDER BY not_before ASC, id ASC LIMIT ?`,
+			models.ScheduledPending,
+			formatTimestamp(now),
+			limit,
+		)
+		if err != nil {
+			return err
+		}
+		claimed, err = scanScheduledTriggers(rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		for i := range claimed {
+			if _, err := tx.tx.Exec(
+				`UPDATE scheduled_triggers SET status = ?, updated_at = ? WHERE id = ?`,
+				models.ScheduledRunning,
+				formatTimestamp(now),
+				claimed[i].ID,
+			); err != nil {
+				return err
+			}
+			claimed[i].Status = models.ScheduledRunning
+			claimed[i].UpdatedAt = now
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// FinishScheduledTrigger records the final state of a claimed trigger
+func FinishScheduledTrigger(id int64, status, buildID, errMsg string) error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+
+	_, err := db.Exec(
+		`UPDATE scheduled_triggers SET status = ?, build_id = ?, error = ?, updated_at = ? WHERE id = ?`,
+		status,
+		buildID,
+		errMsg,
+		formatTimestamp(time.Now()),
+		id,
+	)
+	return err
+}
+
+// scanScheduledTriggers reads scheduled trigger rows selected with scheduledTriggerColumns
+func scanScheduledTriggers(rows *sql.Rows) ([]models.ScheduledTrigger, error) {
+	var triggers []models.ScheduledTrigger
+	for rows.Next() {
+		var trigger models.ScheduledTrigger
+		var params, labels, principal, notBefore, createdAt, updatedAt string
+		var deadline sql.NullString
+		if err := rows.Scan(
+			&trigger.ID,
+			&trigger.TriggerID,
+			&trigger.JobName,
+			&params,
+			&labels,
+			&trigger.ChangeRef,
+			&trigger.APIKey,
+			&principal,
+			&notBefore,
+			&deadline,
+			&trigger.Status,
+			&trigger.BuildID,
+			&trigger.Error,
+			&createdAt,
+			&updatedAt,
+			&trigger.Commit,
+			&trigger.Ref,
+		); err != nil {
+			return nil, err
+		}
+		if params != "" {
+			if err := json.Unmarshal([]byte(params), &trigger.Parameters); err != nil {
+				return nil, fmt.Errorf("failed to decode parameters of scheduled trigger %d: %w", trigger.ID, err)
+			}
+		}
+		if principal != "" {
+			if err := json.Unmarshal([]byte(principal), &trigger.Principal); err != nil {
+				return nil, fmt.Errorf("failed to decode principal of scheduled trigger %d: %w", trigger.ID, err)
+			}
+		}
+		trigger.Labels = decodeLabels(labels)
+		trigger.NotBefore = parseTimestamp(notBefore)
+		if deadline.Valid {
+			t := parseTimestamp(deadline.String)
+			trigger.Deadline = &t
+		}
+		trigger.CreatedAt = parseTimestamp(createdAt)
+		trigger.UpdatedAt = parseTimestamp(updatedAt)
+		triggers = append(triggers, trigger)
+	}
+	return triggers, rows.Err()
+}
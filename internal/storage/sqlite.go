@@ -2,23 +2,29 @@ package storage
 
 import (
 	"database/sql"
-	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"triggermesh/internal/logger"
 	"triggermesh/internal/storage/models"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 var db *sql.DB
 
+// timestampFormat is the layout used to store timestamps (UTC, microsecond precision)
+// It sorts lexicographically, so range queries can compare the stored strings directly
+const timestampFormat = "2006-01-02 15:04:05.000000"
+
 // Init initializes the SQLite database
 func Init(dbPath string) error {
 	var err error
 
+	// Opening the database replaces any custom store
+	store = nil
+
 	// Open the database connection with connection pool settings
-	db, err = sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_synchronous=NORMAL&_foreign_keys=ON")
+	db, err = sql.Open(DriverName, dataSourceName(dbPath))
 	if err != nil {
 		return err
 	}
@@ -32,11 +38,13 @@ func Init(dbPath string) error {
 
 	// Test the connection
 	if err = db.Ping(); err != nil {
+		closeAfterInitFailure()
 		return err
 	}
 
 	// Create the audit log table if it doesn't exist
 	if err = createTables(); err != nil {
+		closeAfterInitFailure()
 		return err
 	}
 
@@ -44,6 +52,12 @@ func Init(dbPath string) error {
 	return nil
 }
 
+// closeAfterInitFailure closes the database of a failed Init, so its connection goroutines do not leak
+func closeAfterInitFailure() {
+	db.Close()
+	db = nil
+}
+
 // createTables creates the necessary database tables
 func createTables() error {
 	// Create audit log table
@@ -65,11 +79,29 @@ func createTables() error {
 		return err
 	}
 
+	// Create audit archive manifest table
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS audit_archives (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		partition_start DATETIME NOT NULL,
+		partition_end DATETIME NOT NULL,
+		object_key TEXT NOT NULL,
+		row_count INTEGER NOT NULL,
+		size_bytes INTEGER NOT NULL,
+		live_deleted INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL
+	)
+	`)
+	if err != nil {
+		return err
+	}
+
 	// Create indexes for better query performance
 	indexes := []string{
 		"CREATE INDEX IF NOT EXISTS idx_audit_logs_timestamp ON audit_logs(timestamp)",
 		"CREATE INDEX IF NOT EXISTS idx_audit_logs_api_key ON audit_logs(api_key)",
 		"CREATE INDEX IF NOT EXISTS idx_audit_logs_job_name ON audit_logs(job_name)",
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_archives_partition ON audit_archives(partition_start)",
 	}
 
 	for _, indexSQL := range indexes {
@@ -78,15 +110,31 @@ func createTables() error {
 		}
 	}
 
-	return nil
+	return migrate()
 }
 
 // InsertAuditLog inserts a new audit log entry
 func InsertAuditLog(log models.AuditLog) error {
-	// Format timestamp as RFC3339 for better precision
-	timestampStr := log.Timestamp.Format("2006-01-02 15:04:05.000000")
-	_, err := db.Exec(
-		`INSERT INTO audit_logs (timestamp, api_key, method, path, status, job_name, params, result, error) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	if store != nil {
+		return store.InsertAuditLog(log)
+	}
+
+	// The entry, its full parameters, and its outbox events are written together
+	if err := WithTx(func(tx *Tx) error { return tx.InsertAuditLog(log) }); err != nil {
+		logger.Error("Failed to insert audit log", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// insertAuditLog inserts an audit log entry, with its full parameters and outbox events, through the
+// database or a transaction
+func insertAuditLog(e execer, log models.AuditLog) error {
+	// Format timestamp in UTC with microsecond precision
+	timestampStr := formatTimestamp(log.Timestamp)
+	result, err := e.Exec(
+		`INSERT INTO audit_logs (timestamp, api_key, method, path, status, job_name, params, result, error, source, tenant, engine, trigger_id, duration_ms, engine_duration_ms, replay_of, labels, build_id, change_ref, global_build_id, params_truncated, params_hash, git_commit, git_ref) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		timestampStr,
 		log.APIKey,
 		log.Method,
@@ -96,20 +144,65 @@ func InsertAuditLog(log models.AuditLog) error {
 		log.Params,
 		log.Result,
 		log.Error,
+		log.Source,
+		log.Tenant,
+		log.Engine,
+		log.TriggerID,
+		log.DurationMS,
+		log.EngineDurationMS,
+		log.ReplayOf,
+		encodeLabels(log.Labels),
+		log.BuildID,
+		log.ChangeRef,
+		log.GlobalBuildID,
+		log.ParamsTruncated,
+		log.ParamsHash,
+		log.Commit,
+		log.Ref,
 	)
+	if err != nil {
+		return err
+	}
 
+	id, err := result.LastInsertId()
 	if err != nil {
-		logger.Error("Failed to insert audit log", "error", err)
 		return err
 	}
+	if log.FullParams != "" {
+		if _, err := e.Exec(`INSERT INTO audit_log_params (audit_log_id, params) VALUES (?, ?)`, id, log.FullParams); err != nil {
+			return err
+		}
+	}
+	log.ID = id
+	return insertOutboxEvents(e, log)
+}
 
-	return nil
+// GetAuditParams returns the full parameters stored for a truncated audit log entry
+// The boolean is false when none were stored
+func GetAuditParams(id int64) (string, bool, error) {
+	if !sqliteActive() {
+		return "", false, errNoDatabase
+	}
+
+	var params string
+	err := db.QueryRow(`SELECT params FROM audit_log_params WHERE audit_log_id = ?`, id).Scan(&params)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return params, true, nil
 }
 
 // GetAuditLogs retrieves audit logs with pagination
 func GetAuditLogs(limit, offset int) ([]models.AuditLog, error) {
+	if store != nil {
+		return store.GetAuditLogs(limit, offset)
+	}
+
 	rows, err := db.Query(
-		`SELECT id, timestamp, api_key, method, path, status, job_name, params, result, error FROM audit_logs ORDER BY id DESC LIMIT ? OFFSET ?`,
+		`SELECT `+auditLogColumns+` FROM audit_logs ORDER BY id DESC LIMIT ? OFFSET ?`,
 		limit,
 		offset,
 	)
@@ -118,64 +211,301 @@ func GetAuditLogs(limit, offset int) ([]models.AuditLog, error) {
 	}
 	defer rows.Close()
 
+	return scanAuditLogs(rows)
+}
+
+// QueryAuditLogs retrieves audit logs matching the filter with pagination, newest first, or oldest
+// first with filter.AfterID
+func QueryAuditLogs(filter models.AuditFilter, limit, offset int) ([]models.AuditLog, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	var conditions []string
+	var args []any
+	if filter.BeforeID > 0 {
+		conditions = append(conditions, `id < ?`)
+		args = append(args, filter.BeforeID)
+	}
+	if filter.AfterID > 0 {
+		conditions = append(conditions, `id > ?`)
+		args = append(args, filter.AfterID)
+	}
+	if filter.ChangeRef != "" {
+		conditions = append(conditions, `change_ref = ?`)
+		args = append(args, filter.ChangeRef)
+	}
+	if filter.Commit != "" {
+		// A range over the index: commits starting with the SHA sort between it and the SHA followed by "g",
+		// the first character after the hex digits
+		conditions = append(conditions, `git_commit >= ? AND git_commit < ?`)
+		args = append(args, filter.Commit, filter.Commit+"g")
+	}
+	if filter.Ref != "" {
+		conditions = append(conditions, `git_ref = ?`)
+		args = append(args, filter.Ref)
+	}
+	if filter.Job != "" {
+		// Job names cannot contain the other GLOB wildcards (? and [)
+		conditions = append(conditions, `job_name GLOB ?`)
+		args = append(args, filter.Job)
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, `timestamp >= ?`)
+		args = append(args, formatTimestamp(filter.Since))
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, `timestamp < ?`)
+		args = append(args, formatTimestamp(filter.Until))
+	}
+	for _, term := range filter.Terms {
+		conditions = append(conditions, `(job_name = ? OR change_ref = ? OR git_ref = ? OR EXISTS (SELECT 1 FROM json_each(NULLIF(labels, '')) WHERE value = ?))`)
+		args = append(args, term, term, term, term)
+	}
+	if filter.APIKey != "" {
+		conditions = append(conditions, `api_key = ?`)
+		args = append(args, filter.APIKey)
+	}
+	// Sort label keys so the generated statement is stable
+	keys := make([]string, 0, len(filter.Labels))
+	for key := range filter.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		conditions = append(conditions, `json_extract(NULLIF(labels, ''), ?) = ?`)
+		args = append(args, labelPath(key), filter.Labels[key])
+	}
+
+	query := `SELECT ` + auditLogColumns + ` FROM audit_logs`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	if filter.AfterID > 0 {
+		query += ` ORDER BY id ASC LIMIT ? OFFSET ?`
+	} else {
+		query += ` ORDER BY id DESC LIMIT ? OFFSET ?`
+	}
+	args = append(args, limit, offset)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAuditLogs(rows)
+}
+
+// GetAuditLog retrieves an audit log entry by ID, or nil if it does not exist
+func GetAuditLog(id int64) (*models.AuditLog, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(`SELECT `+auditLogColumns+` FROM audit_logs WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs, err := scanAuditLogs(rows)
+	if err != nil || len(logs) == 0 {
+		return nil, err
+	}
+	return &logs[0], nil
+}
+
+// GetAuditLogByGlobalBuildID retrieves the trigger that started a build by its TriggerMesh-wide build ID,
+// or nil if no trigger has that ID
+func GetAuditLogByGlobalBuildID(globalBuildID string) (*models.AuditLog, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(`SELECT `+auditLogColumns+` FROM audit_logs WHERE global_build_id = ? ORDER BY id DESC LIMIT 1`, globalBuildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs, err := scanAuditLogs(rows)
+	if err != nil || len(logs) == 0 {
+		return nil, err
+	}
+	return &logs[0], nil
+}
+
+// GetAuditLogByTriggerID retrieves the latest audit log entry of a trigger attempt by its trigger
+// ID, or nil if there is none
+func GetAuditLogByTriggerID(triggerID string) (*models.AuditLog, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(`SELECT `+auditLogColumns+` FROM audit_logs WHERE trigger_id = ? ORDER BY id DESC LIMIT 1`, triggerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs, err := scanAuditLogs(rows)
+	if err != nil || len(logs) == 0 {
+		return nil, err
+	}
+	return &logs[0], nil
+}
+
+// GetReusableTriggers retrieves up to limit successful triggers of a job on an engine with the
+// given parameter fingerprint and timestamp >= since, newest first; purged triggers are excluded
+func GetReusableTriggers(engineName, jobName, paramsHash string, since time.Time, limit int) ([]models.AuditLog, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(
+		`SELECT `+auditLogColumns+` FROM audit_logs WHERE job_name = ? AND params_hash = ? AND engine = ? AND result = 'success' AND build_id != '' AND timestamp >= ? AND id NOT IN (SELECT audit_id FROM reuse_purges) ORDER BY id DESC LIMIT ?`,
+		jobName,
+		paramsHash,
+		engineName,
+		formatTimestamp(since),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAuditLogs(rows)
+}
+
+// GetFailedTriggers retrieves failed trigger entries with start <= timestamp < end in insertion order
+func GetFailedTriggers(start, end time.Time) ([]models.AuditLog, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(
+		`SELECT `+auditLogColumns+` FROM audit_logs WHERE result = 'failed' AND job_name != '' AND timestamp >= ? AND timestamp < ? ORDER BY id ASC`,
+		formatTimestamp(start),
+		formatTimestamp(end),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAuditLogs(rows)
+}
+
+// scanAuditLogs scans audit log rows selected with auditLogColumns
+func scanAuditLogs(rows *sql.Rows) ([]models.AuditLog, error) {
 	var logs []models.AuditLog
 	for rows.Next() {
-		var log models.AuditLog
-		var timestampStr string
-
-		// Scan the row into the log struct
-		if scanErr := rows.Scan(
-			&log.ID,
-			&timestampStr,
-			&log.APIKey,
-			&log.Method,
-			&log.Path,
-			&log.Status,
-			&log.JobName,
-			&log.Params,
-			&log.Result,
-			&log.Error,
-		); scanErr != nil {
-			return nil, scanErr
-		}
-
-		// Parse the timestamp string into time.Time
-		// Try multiple formats for compatibility
-		var timestamp time.Time
-		var parseErr error
-
-		// Try with microseconds first
-		timestamp, parseErr = time.Parse("2006-01-02 15:04:05.000000", timestampStr)
-		if parseErr != nil {
-			// Try without microseconds
-			timestamp, parseErr = time.Parse("2006-01-02 15:04:05", timestampStr)
-			if parseErr != nil {
-				// If parsing fails, use current time as fallback
-				timestamp = time.Now()
-			}
+		log, err := scanAuditLog(rows)
+		if err != nil {
+			return nil, err
 		}
-		log.Timestamp = timestamp
-
 		logs = append(logs, log)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
 	return logs, nil
 }
 
+// scanAuditLog scans the current row selected with auditLogColumns
+func scanAuditLog(rows *sql.Rows) (models.AuditLog, error) {
+	var log models.AuditLog
+	var timestampStr, labels string
+
+	// Scan the row into the log struct
+	if err := rows.Scan(
+		&log.ID,
+		&timestampStr,
+		&log.APIKey,
+		&log.Method,
+		&log.Path,
+		&log.Status,
+		&log.JobName,
+		&log.Params,
+		&log.Result,
+		&log.Error,
+		&log.Source,
+		&log.Tenant,
+		&log.Engine,
+		&log.TriggerID,
+		&log.DurationMS,
+		&log.EngineDurationMS,
+		&log.ReplayOf,
+		&labels,
+		&log.BuildID,
+		&log.ChangeRef,
+		&log.GlobalBuildID,
+		&log.ParamsTruncated,
+		&log.Commit,
+		&log.Ref,
+		&log.ParamsHash,
+	); err != nil {
+		return log, err
+	}
+
+	log.Timestamp = parseTimestamp(timestampStr)
+	log.Labels = decodeLabels(labels)
+	return log, nil
+}
+
+// formatTimestamp formats a time for storage
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(timestampFormat)
+}
+
+// parseTimestamp parses a stored timestamp string into time.Time
+// Try multiple formats for compatibility
+func parseTimestamp(timestampStr string) time.Time {
+	// Try with microseconds first
+	timestamp, err := time.Parse(timestampFormat, timestampStr)
+	if err == nil {
+		return timestamp
+	}
+
+	// Try without microseconds
+	timestamp, err = time.Parse("2006-01-02 15:04:05", timestampStr)
+	if err == nil {
+		return timestamp
+	}
+
+	// The SQLite drivers return DATETIME columns as time.Time, which database/sql
+	// converts to RFC 3339 when scanning into a string
+	timestamp, err = time.Parse(time.RFC3339Nano, timestampStr)
+	if err == nil {
+		return timestamp
+	}
+
+	// If parsing fails, use current time as fallback
+	return time.Now()
+}
+
 // Ping checks the database connection
 func Ping() error {
+	if store != nil {
+		return store.Ping()
+	}
 	if db == nil {
-		return fmt.Errorf("database not initialized")
+		return errNoDatabase
 	}
 	return db.Ping()
 }
 
-// Close closes the database connection
+// Close closes the database connection or custom store
 func Close() error {
+	if store != nil {
+		s := store
+		store = nil
+		return s.Close()
+	}
 	if db != nil {
 		return db.Close()
 	}
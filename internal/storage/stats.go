@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"triggermesh/internal/storage/models"
+)
+
+// ErrBuildNotTracked is returned when recording the outcome of a build that is not tracked, e.g.
+// because the poller and a build notification both reported it
+var ErrBuildNotTracked = errors.New("build is not tracked")
+
+// TrackBuild records a triggered build so the status poller can collect its outcome
+// Tracking the same build twice is a no-op
+func TrackBuild(build models.TrackedBuild) error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+
+	return trackBuild(db, build)
+}
+
+// trackBuild records a triggered build through the database or a transaction
+func trackBuild(e execer, build models.TrackedBuild) error {
+	_, err := e.Exec(
+		`INSERT OR IGNORE INTO tracked_builds (build_id, job_name, engine, triggered_at) VALUES (?, ?, ?, ?)`,
+		build.BuildID,
+		build.JobName,
+		build.Engine,
+		formatTimestamp(build.TriggeredAt),
+	)
+	return err
+}
+
+// GetTrackedBuilds returns up to limit tracked builds, oldest first
+func GetTrackedBuilds(limit int) ([]models.TrackedBuild, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(
+		`SELECT build_id, job_name, engine, triggered_at FROM tracked_builds ORDER BY triggered_at ASC LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var builds []models.TrackedBuild
+	for rows.Next() {
+		var build models.TrackedBuild
+		var triggeredAt string
+		if err := rows.Scan(&build.BuildID, &build.JobName, &build.Engine, &triggeredAt); err != nil {
+			return nil, err
+		}
+		build.TriggeredAt = parseTimestamp(triggeredAt)
+		builds = append(builds, build)
+	}
+	return builds, rows.Err()
+}
+
+// GetTrackedBuild returns a tracked build of the engine, or nil if it is not tracked
+func GetTrackedBuild(engine, buildID string) (*models.TrackedBuild, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	var build models.TrackedBuild
+	var triggeredAt string
+	err := db.QueryRow(
+		`SELECT build_id, job_name, engine, triggered_at FROM tracked_builds WHERE build_id = ? AND engine = ?`,
+		buildID,
+		engine,
+	).Scan(&build.BuildID, &build.JobName, &build.Engine, &triggeredAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	build.TriggeredAt = parseTimestamp(triggeredAt)
+	return &build, nil
+}
+
+// CountTrackedBuildsByJob returns the number of tracked builds of each job, across engines
+func CountTrackedBuildsByJob() (map[string]int, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	rows, err := db.Query(`SELECT job_name, COUNT(*) FROM tracked_builds GROUP BY job_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var job string
+		var count int
+		if err := rows.Scan(&job, &count); err != nil {
+			return nil, err
+		}
+		counts[job] = count
+	}
+	return counts, rows.Err()
+}
+
+// UntrackBuild stops tracking a build without recording an outcome
+func UntrackBuild(buildID string) error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+
+	_, err := db.Exec(`DELETE FROM tracked_builds WHERE build_id = ?`, buildID)
+	return err
+}
+
+// DeleteTrackedBuildsBefore stops tracking builds triggered before the given time
+// and returns the number of builds dropped
+func DeleteTrackedBuildsBefore(before time.Time) (int64, error) {
+	if !sqliteActive() {
+		return 0, errNoDatabase
+	}
+
+	result, err := db.Exec(`DELETE FROM tracked_builds WHERE triggered_at < ?`, formatTimestamp(before))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// RecordBuildOutcome adds a finished build to its job's statistics and stops tracking it
+// It returns ErrBuildNotTracked, recording nothing, if the build is not tracked, so an outcome
+// reported twice is counted once
+func RecordBuildOutcome(outcome models.BuildOutcome) error {
+	if !sqliteActive() {
+		return errNoDatabase
+	}
+
+	succeeded, failed := 1, 0
+	var failureAt sql.NullString
+	var failureBuildID string
+	if !outcome.Succeeded {
+		succeeded, failed = 0, 1
+		failureAt = sql.NullString{String: formatTimestamp(outcome.FinishedAt), Valid: true}
+		failureBuildID = outcome.BuildID
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	result, err := tx.Exec(`DELETE FROM tracked_builds WHERE build_id = ?`, outcome.BuildID)
+	if err != nil {
+		return err
+	}
+	untracked, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if untracked == 0 {
+		return ErrBuildNotTracked
+	}
+
+	// Failure columns only move forward on failures; COALESCE/NULLIF keep the previous failure otherwise
+	_, err = tx.Exec(`
+	INSERT INTO job_stats (job_name, builds_total, builds_succeeded, builds_failed, total_duration_ms, last_result, last_build_id, last_failure_at, last_failure_build_id, updated_at)
+	VALUES (?, 1, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(job_name) DO UPDATE SET
+		builds_total = builds_total + 1,
+		builds_succeeded = builds_succeeded + excluded.builds_succeeded,
+		builds_failed = builds_failed + excluded.builds_failed,
+		total_duration_ms = total_duration_ms + excluded.total_duration_ms,
+		last_result = excluded.last_result,
+		last_build_id = excluded.last_build_id,
+		last_failure_at = COALESCE(excluded.last_failure_at, last_failure_at),
+		last_failure_build_id = COALESCE(NULLIF(excluded.last_failure_build_id, ''), last_failure_build_id),
+		updated_at = excluded.updated_at
+	`,
+		outcome.JobName,
+		succeeded,
+		failed,
+		outcome.DurationMS,
+		outcome.Result,
+		outcome.BuildID,
+		failureAt,
+		failureBuildID,
+		formatTimestamp(outcome.FinishedAt),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetJobStats returns the statistics for a job, or nil if no outcome has been recorded
+func GetJobStats(jobName string) (*models.JobStats, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	var stats models.JobStats
+	var totalDurationMS int64
+	var lastFailureAt sql.NullString
+	var updatedAt string
+	err := db.QueryRow(
+		`SELECT job_name, builds_total, builds_succeeded, builds_failed, total_duration_ms, last_result, last_build_id, last_failure_at, last_failure_build_id, updated_at FROM job_stats WHERE job_name = ?`,
+		jobName,
+	).Scan(
+		&stats.JobName,
+		&stats.BuildsTotal,
+		&stats.BuildsSucceeded,
+		&stats.BuildsFailed,
+		&totalDurationMS,
+		&stats.LastResult,
+		&stats.LastBuildID,
+		&lastFailureAt,
+		&stats.LastFailureBuildID,
+		&updatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.BuildsTotal > 0 {
+		stats.SuccessRate = float64(stats.BuildsSucceeded) / float64(stats.BuildsTotal)
+		stats.AverageDurationMS = totalDurationMS / stats.BuildsTotal
+	}
+	if lastFailureAt.Valid {
+		t := parseTimestamp(lastFailureAt.String)
+		stats.LastFailureAt = &t
+	}
+	stats.UpdatedAt = parseTimestamp(updatedAt)
+	return &stats, nil
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"errors"
+
+	"triggermesh/internal/storage/models"
+)
+
+// Store is an audit log backend that replaces the built-in SQLite database
+// Audit archiving works directly on SQLite and is unavailable with a custom store
+type Store interface {
+	InsertAuditLog(log models.AuditLog) error
+	GetAuditLogs(limit, offset int) ([]models.AuditLog, error)
+	Ping() error
+	Close() error
+}
+
+// store is the custom backend installed with Use; nil means the SQLite database is used
+var store Store
+
+// errNoDatabase is returned by SQLite-only functions when no database is open
+var errNoDatabase = errors.New("database not initialized")
+
+// Use installs a custom audit log store instead of the SQLite database
+// The package-level audit functions delegate to it until Init or Close is called
+func Use(s Store) {
+	store = s
+}
+
+// sqliteActive reports whether the SQLite database is open and not replaced by a custom store
+func sqliteActive() bool {
+	return db != nil && store == nil
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"triggermesh/internal/storage/models"
+)
+
+// Formats of the summary keys: UTC hours are the date-and-hour prefix of stored timestamps,
+// days are formatted in the requested time zone
+const (
+	summaryHourFormat = "2006-01-02 15"
+	summaryDayFormat  = "2006-01-02"
+)
+
+// RefreshAuditSummary recomputes the hourly per-job summary from the audit log and returns the
+// number of summary rows written
+// Only the latest summarized hour and the hours after it are recomputed, so hours whose audit rows
+// were archived and deleted keep their counts
+func RefreshAuditSummary(now time.Time) (int64, error) {
+	if !sqliteActive() {
+		return 0, errNoDatabase
+	}
+
+	var latest sql.NullString
+	if err := db.QueryRow(`SELECT MAX(hour) FROM audit_hourly_summary`).Scan(&latest); err != nil {
+		return 0, err
+	}
+	from := ""
+	if latest.Valid {
+		from = latest.String
+	}
+
+	result, err := db.Exec(`
+	INSERT INTO audit_hourly_summary (hour, job_name, triggers, succeeded, failed, denied, updated_at)
+	SELECT substr(timestamp, 1, 13), job_name, COUNT(*),
+		SUM(CASE WHEN result = 'success' THEN 1 ELSE 0 END),
+		SUM(CASE WHEN result = 'failed' THEN 1 ELSE 0 END),
+		SUM(CASE WHEN result = 'denied' THEN 1 ELSE 0 END),
+		?
+	FROM audit_logs
+	WHERE timestamp >= ? AND job_name != ''
+	GROUP BY substr(timestamp, 1, 13), job_name
+	ON CONFLICT(hour, job_name) DO UPDATE SET
+		triggers = excluded.triggers,
+		succeeded = excluded.succeeded,
+		failed = excluded.failed,
+		denied = excluded.denied,
+		updated_at = excluded.updated_at
+	`,
+		formatTimestamp(now),
+		from,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetAuditSummary returns the daily summary of a job for the days in loc from since to until,
+// oldest first
+// Hours are assigned to the day they start in, so in zones whose offset is not a whole number of
+// hours, days begin at the start of the UTC hour containing local midnight
+func GetAuditSummary(jobName string, since, until time.Time, loc *time.Location) ([]models.DailyJobSummary, error) {
+	if !sqliteActive() {
+		return nil, errNoDatabase
+	}
+
+	since, until = since.In(loc), until.In(loc)
+	start := time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, loc)
+	end := time.Date(until.Year(), until.Month(), until.Day()+1, 0, 0, 0, 0, loc)
+	rows, err := db.Query(
+		`SELECT hour, job_name, triggers, succeeded, failed, denied, updated_at FROM audit_hourly_summary WHERE job_name = ? AND hour >= ? AND hour < ? ORDER BY hour ASC`,
+		jobName,
+		start.UTC().Format(summaryHourFormat),
+		end.UTC().Format(summaryHourFormat),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	firstDay := start.Format(summaryDayFormat)
+	var days []models.DailyJobSummary
+	for rows.Next() {
+		var bucket models.DailyJobSummary
+		var hourKey, updatedAt string
+		if err := rows.Scan(&hourKey, &bucket.JobName, &bucket.Triggers, &bucket.Succeeded, &bucket.Failed, &bucket.Denied, &updatedAt); err != nil {
+			return nil, err
+		}
+		hourStart, err := time.Parse(summaryHourFormat, hourKey)
+		if err != nil {
+			return nil, err
+		}
+		day := hourStart.In(loc).Format(summaryDayFormat)
+		if day < firstDay {
+			continue
+		}
+
+		bucket.UpdatedAt = parseTimestamp(updatedAt).In(loc)
+		if n := len(days); n > 0 && days[n-1].Day == day {
+			days[n-1].Triggers += bucket.Triggers
+			days[n-1].Succeeded += bucket.Succeeded
+			days[n-1].Failed += bucket.Failed
+			days[n-1].Denied += bucket.Denied
+			if bucket.UpdatedAt.After(days[n-1].UpdatedAt) {
+				days[n-1].UpdatedAt = bucket.UpdatedAt
+			}
+			continue
+		}
+		bucket.Day = day
+		days = append(days, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range days {
+		if days[i].Triggers > 0 {
+			days[i].FailureRate = float64(days[i].Failed) / float64(days[i].Triggers)
+		}
+	}
+	return days, nil
+}
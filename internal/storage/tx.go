@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"database/sql"
+
+	"triggermesh/internal/storage/models"
+)
+
+// execer runs statements; it is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Tx is a unit of work: the writes made through it are committed together or not at all
+type Tx struct {
+	tx *sql.Tx // nil when a custom store is installed
+}
+
+// WithTx runs fn in a transaction, committing when fn returns nil and rolling back when it
+// returns an error or panics
+// With a custom store, audit logs are written to the store directly and are not atomic
+func WithTx(fn func(tx *Tx) error) error {
+	if store != nil {
+		return fn(&Tx{})
+	}
+	if db == nil {
+		return errNoDatabase
+	}
+
+	sqlTx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = sqlTx.Rollback()
+	}()
+
+	if err := fn(&Tx{tx: sqlTx}); err != nil {
+		return err
+	}
+	return sqlTx.Commit()
+}
+
+// InsertAuditLog inserts an audit log entry as part of the transaction
+func (t *Tx) InsertAuditLog(log models.AuditLog) error {
+	if t.tx == nil {
+		return store.InsertAuditLog(log)
+	}
+	return insertAuditLog(t.tx, log)
+}
+
+// TrackBuild records a triggered build as part of the transaction
+// Build tracking requires the SQLite database
+func (t *Tx) TrackBuild(build models.TrackedBuild) error {
+	if t.tx == nil {
+		return errNoDatabase
+	}
+	return trackBuild(t.tx, build)
+}
@@ -0,0 +1,112 @@
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+)
+
+// ThrottledError is returned for triggers refused while the engine's build queue is too long
+type ThrottledError struct {
+	QueueLength int           // Queue length of the last sample
+	Threshold   int           // Queue length above which triggers are throttled
+	RetryAfter  time.Duration // When the queue length is sampled again
+}
+
+// Error implements error
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("build queue length %d is above the throttle threshold %d", e.QueueLength, e.Threshold)
+}
+
+// Controller throttles triggers on the build queue length reported by the engine
+// Throttling starts when the queue grows above the queue threshold and stops once it has drained to
+// the resume threshold, so triggers are not let through at every sample near the threshold
+// The queue length is sampled on demand, at most once per sample interval; a failed sample lets
+// triggers through, as Jenkins being unreachable is reported by the trigger itself
+type Controller struct {
+	reporter        engine.OverviewReporter
+	queueThreshold  int
+	resumeThreshold int
+	delay           bool
+	maxDelay        time.Duration
+	interval        time.Duration
+
+	mu          sync.Mutex
+	sampledAt   time.Time
+	queueLength int
+	throttled   bool
+}
+
+// NewController creates a controller from the throttle configuration
+func NewController(cfg config.ThrottleConfig, reporter engine.OverviewReporter) *Controller {
+	return &Controller{
+		reporter:        reporter,
+		queueThreshold:  cfg.QueueThreshold,
+		resumeThreshold: cfg.ResumeThreshold,
+		delay:           cfg.Policy == config.ThrottlePolicyDelay,
+		maxDelay:        time.Duration(cfg.MaxDelay) * time.Second,
+		interval:        time.Duration(cfg.SampleInterval) * time.Second,
+	}
+}
+
+// Admit returns nil when a trigger may proceed, or a ThrottledError while the queue is too long
+// With the delay policy, it waits up to the maximum delay, or until ctx is cancelled, for the queue to drain
+func (c *Controller) Admit(ctx context.Context) error {
+	throttled, queueLength := c.sample()
+	if !throttled {
+		return nil
+	}
+	if c.delay {
+		deadline := time.Now().Add(c.maxDelay)
+		for throttled && time.Now().Before(deadline) {
+			timer := time.NewTimer(min(c.interval, time.Until(deadline)))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return &ThrottledError{QueueLength: queueLength, Threshold: c.queueThreshold, RetryAfter: c.interval}
+			case <-timer.C:
+			}
+			throttled, queueLength = c.sample()
+		}
+		if !throttled {
+			return nil
+		}
+	}
+	return &ThrottledError{QueueLength: queueLength, Threshold: c.queueThreshold, RetryAfter: c.interval}
+}
+
+// sample returns whether triggers are throttled and the queue length, asking the engine once the
+// last sample is older than the interval; concurrent callers share one request
+func (c *Controller) sample() (bool, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.sampledAt.IsZero() && time.Since(c.sampledAt) < c.interval {
+		return c.throttled, c.queueLength
+	}
+
+	overview, err := c.reporter.Overview()
+	c.sampledAt = time.Now()
+	if err != nil {
+		if c.throttled {
+			logger.Warn("Failed to sample the build queue, trigger throttling stopped", "error", err)
+		}
+		c.throttled = false
+		return false, c.queueLength
+	}
+
+	c.queueLength = overview.QueueLength
+	switch {
+	case !c.throttled && c.queueLength > c.queueThreshold:
+		c.throttled = true
+		logger.Warn("Build queue is too long, throttling triggers", "queue_length", c.queueLength, "threshold", c.queueThreshold, "delay", c.delay)
+	case c.throttled && c.queueLength <= c.resumeThreshold:
+		c.throttled = false
+		logger.Info("Build queue drained, trigger throttling stopped", "queue_length", c.queueLength, "resume_threshold", c.resumeThreshold)
+	}
+	return c.throttled, c.queueLength
+}
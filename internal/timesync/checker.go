@@ -0,0 +1,158 @@
+// Package timesync compares the local clock with the clock of the Jenkins server, at startup and
+// periodically, and warns when they drift apart: Jenkins rejects crumbs and the audit log orders
+// entries by time, so both depend on synchronized clocks
+package timesync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// actor records clock skew in the configuration audit
+const actor = "clock_check"
+
+// Status is the outcome of the latest clock check
+type Status struct {
+	Source      string    `json:"source"`          // Engine whose clock is compared, e.g. jenkins
+	SkewMS      int64     `json:"skew_ms"`         // Engine clock minus local clock; positive when the local clock is behind
+	RoundTripMS int64     `json:"round_trip_ms"`   // Duration of the request the engine clock was read from
+	MaxSkewMS   int64     `json:"max_skew_ms"`     // Skew tolerated before warning
+	Exceeded    bool      `json:"exceeded"`        // The skew is beyond max_skew_ms
+	CheckedAt   time.Time `json:"checked_at"`      // When the check ran
+	Error       string    `json:"error,omitempty"` // Why the engine clock could not be read; the skew is then from the last successful check
+	LastSuccess time.Time `json:"last_success"`    // When the engine clock was last read
+}
+
+// Checker compares the local clock with an engine's clock
+type Checker struct {
+	source   string
+	reporter engine.ClockReporter
+	maxSkew  time.Duration
+	interval time.Duration
+
+	mu     sync.Mutex
+	status *Status // nil before the first check
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewChecker creates a checker comparing the local clock with the clock of the named engine
+func NewChecker(cfg config.ClockConfig, source string, reporter engine.ClockReporter) *Checker {
+	return &Checker{
+		source:   source,
+		reporter: reporter,
+		maxSkew:  time.Duration(cfg.MaxSkew) * time.Second,
+		interval: time.Duration(cfg.CheckInterval) * time.Second,
+	}
+}
+
+// Status returns the outcome of the latest check, or nil before the first check
+func (c *Checker) Status() *Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.status == nil {
+		return nil
+	}
+	status := *c.status
+	return &status
+}
+
+// Check reads the engine clock once and returns the updated status
+// A skew beyond the maximum is logged as a warning on every check, and recorded in the
+// configuration audit when it starts; a failure to read the clock keeps the previous skew
+func (c *Checker) Check() Status {
+	sample, err := c.reporter.Clock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := Status{Source: c.source, MaxSkewMS: c.maxSkew.Milliseconds(), CheckedAt: time.Now()}
+	if c.status != nil {
+		status.SkewMS, status.RoundTripMS, status.Exceeded, status.LastSuccess = c.status.SkewMS, c.status.RoundTripMS, c.status.Exceeded, c.status.LastSuccess
+	}
+	if err != nil {
+		status.Error = err.Error()
+		c.status = &status
+		logger.Warn("Failed to read engine clock", "error", err, "source", c.source)
+		return status
+	}
+
+	// Date headers are truncated to the second; comparing with the middle of that second halves the error
+	remote := sample.Remote
+	if remote.Nanosecond() == 0 {
+		remote = remote.Add(500 * time.Millisecond)
+	}
+	skew := remote.Sub(sample.Local)
+	wasExceeded := status.Exceeded
+	status.SkewMS = skew.Milliseconds()
+	status.RoundTripMS = sample.RoundTrip.Milliseconds()
+	status.Exceeded = skew > c.maxSkew || skew < -c.maxSkew
+	status.LastSuccess = status.CheckedAt
+	c.status = &status
+
+	switch {
+	case status.Exceeded:
+		logger.Warn("Local clock differs from the engine clock; check time synchronization (NTP)", "source", c.source, "skew_ms", status.SkewMS, "max_skew_ms", status.MaxSkewMS)
+		if !wasExceeded {
+			c.record(status)
+		}
+	case wasExceeded:
+		logger.Info("Local clock is back in sync with the engine clock", "source", c.source, "skew_ms", status.SkewMS)
+	default:
+		logger.Debug("Checked engine clock", "source", c.source, "skew_ms", status.SkewMS, "round_trip_ms", status.RoundTripMS)
+	}
+	return status
+}
+
+// record adds the start of a skew to the configuration audit
+func (c *Checker) record(status Status) {
+	entry := models.ConfigAudit{
+		Timestamp: status.CheckedAt,
+		Action:    models.ConfigActionClockSkew,
+		Actor:     actor,
+		Details:   fmt.Sprintf("source=%s skew_ms=%d max_skew_ms=%d", status.Source, status.SkewMS, status.MaxSkewMS),
+	}
+	if err := storage.InsertConfigAudit(entry); err != nil {
+		logger.Warn("Failed to record clock skew", "error", err)
+	}
+}
+
+// Start checks the clock right away and then every interval, in the background until Stop is called
+func (c *Checker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			c.Check()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic checks and waits for an in-flight check to finish
+func (c *Checker) Stop() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
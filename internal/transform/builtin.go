@@ -0,0 +1,206 @@
+package transform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/outbound"
+	"triggermesh/internal/version"
+)
+
+// maxLookupResponseSize bounds the lookup response read into memory
+const maxLookupResponseSize = 64 * 1024
+
+// placeholderRegex finds {job} and {param:NAME} placeholders
+var placeholderRegex = regexp.MustCompile(`\{(job|param:[a-zA-Z0-9_.-]+)\}`)
+
+// expand replaces {job} with the job name and {param:NAME} with the parameter's current value,
+// passing each substituted value through escape
+func expand(template, job string, params map[string]string, escape func(string) string) string {
+	return placeholderRegex.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		if name == "job" {
+			return escape(job)
+		}
+		return escape(params[strings.TrimPrefix(name, "param:")])
+	})
+}
+
+// keep reports whether a step must leave a value given in the request as is
+func keep(params map[string]string, param string, overwrite bool) bool {
+	_, given := params[param]
+	return given && !overwrite
+}
+
+// Set writes a fixed value, with {job} and {param:NAME} placeholders expanded
+type Set struct {
+	Param     string
+	Value     string
+	Overwrite bool // Replace a value already present
+}
+
+// Transform implements Transformer
+func (s *Set) Transform(ctx context.Context, job string, params map[string]string) error {
+	if keep(params, s.Param, s.Overwrite) {
+		return nil
+	}
+	params[s.Param] = expand(s.Value, job, params, func(v string) string { return v })
+	return nil
+}
+
+// Replace rewrites a parameter with a regular expression, e.g. turning refs/heads/main into main
+// Absent parameters are left absent
+type Replace struct {
+	Param       string
+	Pattern     *regexp.Regexp
+	Replacement string // May reference groups ($1)
+}
+
+// Transform implements Transformer
+func (r *Replace) Transform(ctx context.Context, job string, params map[string]string) error {
+	if value, ok := params[r.Param]; ok {
+		params[r.Param] = r.Pattern.ReplaceAllString(value, r.Replacement)
+	}
+	return nil
+}
+
+// Timestamp writes the dispatch time
+type Timestamp struct {
+	Param     string
+	Layout    string         // Go time layout (default: RFC 3339)
+	Location  *time.Location // Time zone of the value (default: UTC)
+	Overwrite bool           // Replace a value already present
+}
+
+// Transform implements Transformer
+func (t *Timestamp) Transform(ctx context.Context, job string, params map[string]string) error {
+	if keep(params, t.Param, t.Overwrite) {
+		return nil
+	}
+	layout := t.Layout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	location := t.Location
+	if location == nil {
+		location = time.UTC
+	}
+	params[t.Param] = time.Now().In(location).Format(layout)
+	return nil
+}
+
+// HTTPLookup writes a value fetched from an HTTP endpoint, e.g. the latest artifact version
+type HTTPLookup struct {
+	Param     string
+	URL       string // {job} and {param:NAME} placeholders are URL-escaped
+	Field     string // Dot-separated path of the value in the JSON response; empty uses the whole body as text
+	Overwrite bool   // Replace a value already present
+	Client    *http.Client
+}
+
+// NewHTTPLookup creates an HTTP lookup transformer from a step configuration
+// Lookups are subject to the outbound policy, since their URLs are rendered from parameters
+func NewHTTPLookup(step config.TransformStepConfig, policy *outbound.Policy) *HTTPLookup {
+	return &HTTPLookup{
+		Param:     step.Param,
+		URL:       step.URL,
+		Field:     step.Field,
+		Overwrite: step.Overwrite,
+		Client:    policy.Client(time.Duration(step.Timeout) * time.Second),
+	}
+}
+
+// Transform implements Transformer
+func (l *HTTPLookup) Transform(ctx context.Context, job string, params map[string]string) error {
+	if keep(params, l.Param, l.Overwrite) {
+		return nil
+	}
+	value, err := l.lookup(ctx, expand(l.URL, job, params, url.QueryEscape))
+	if err != nil {
+		return &Error{Param: l.Param, Err: err}
+	}
+	params[l.Param] = value
+	return nil
+}
+
+// lookup fetches the endpoint and extracts the configured field
+func (l *HTTPLookup) lookup(ctx context.Context, endpoint string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create lookup request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", version.UserAgent())
+
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send lookup request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxLookupResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read lookup response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("lookup returned status %d", resp.StatusCode)
+	}
+
+	if l.Field == "" {
+		return strings.TrimSpace(string(body)), nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return "", fmt.Errorf("failed to decode lookup response: %w", err)
+	}
+	return extractField(doc, l.Field)
+}
+
+// extractField follows a dot-separated path through JSON objects (and array indexes)
+// and returns the scalar found there as text
+func extractField(doc interface{}, path string) (string, error) {
+	for _, name := range strings.Split(path, ".") {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[name]
+			if !ok {
+				return "", fmt.Errorf("field %q not found in lookup response", path)
+			}
+			doc = value
+		case []interface{}:
+			index, err := strconv.Atoi(name)
+			if err != nil || index < 0 || index >= len(node) {
+				return "", fmt.Errorf("field %q not found in lookup response", path)
+			}
+			doc = node[index]
+		default:
+			return "", fmt.Errorf("field %q not found in lookup response", path)
+		}
+	}
+
+	switch value := doc.(type) {
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case nil:
+		return "", fmt.Errorf("field %q is null in lookup response", path)
+	default:
+		return "", fmt.Errorf("field %q is not a string, number, or boolean", path)
+	}
+}
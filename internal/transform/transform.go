@@ -0,0 +1,134 @@
+// Package transform rewrites and enriches trigger parameters before they are dispatched to the engine
+package transform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/jobmatch"
+	"triggermesh/internal/outbound"
+)
+
+// Transformer rewrites or enriches the parameters of a trigger for the job
+// params is owned by the pipeline and may be modified in place
+type Transformer interface {
+	Transform(ctx context.Context, job string, params map[string]string) error
+}
+
+// Func adapts a function to the Transformer interface
+type Func func(ctx context.Context, job string, params map[string]string) error
+
+// Transform implements Transformer
+func (f Func) Transform(ctx context.Context, job string, params map[string]string) error {
+	return f(ctx, job, params)
+}
+
+// Chain runs transformers in order, stopping at the first error
+type Chain []Transformer
+
+// Transform implements Transformer
+func (c Chain) Transform(ctx context.Context, job string, params map[string]string) error {
+	for _, t := range c {
+		if err := t.Transform(ctx, job, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Error is returned when a transformer fails; the trigger is not dispatched
+type Error struct {
+	Param string // Parameter the failing step writes, if known
+	Err   error
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	if e.Param == "" {
+		return "failed to transform parameters: " + e.Err.Error()
+	}
+	return fmt.Sprintf("failed to transform parameter %q: %v", e.Param, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// rule is a chain of transformers applied to jobs matching its patterns
+type rule struct {
+	jobs  []string
+	chain Chain
+}
+
+// Pipeline applies the configured transformer rules to trigger parameters
+type Pipeline struct {
+	rules []rule
+}
+
+// NewPipeline creates a pipeline from the transform configuration; http_lookup steps use the outbound policy
+func NewPipeline(cfg config.TransformConfig, policy *outbound.Policy) (*Pipeline, error) {
+	p := &Pipeline{}
+	for i, rc := range cfg.Rules {
+		r := rule{jobs: rc.Jobs}
+		for j, step := range rc.Steps {
+			t, err := New(step, policy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid transform rule %d step %d: %w", i, j, err)
+			}
+			r.chain = append(r.chain, t)
+		}
+		p.rules = append(p.rules, r)
+	}
+	return p, nil
+}
+
+// Apply returns the parameters to dispatch for the job; params itself is left untouched
+// Errors are always *Error
+func (p *Pipeline) Apply(ctx context.Context, job string, params map[string]string) (map[string]string, error) {
+	out := make(map[string]string, len(params))
+	for key, value := range params {
+		out[key] = value
+	}
+	for _, r := range p.rules {
+		if len(r.jobs) > 0 && !jobmatch.MatchAny(r.jobs, job) {
+			continue
+		}
+		if err := r.chain.Transform(ctx, job, out); err != nil {
+			var transformErr *Error
+			if !errors.As(err, &transformErr) {
+				err = &Error{Err: err}
+			}
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// New creates the built-in transformer configured by the step; a nil policy leaves lookups unrestricted
+func New(step config.TransformStepConfig, policy *outbound.Policy) (Transformer, error) {
+	switch step.Type {
+	case config.TransformTypeSet:
+		return &Set{Param: step.Param, Value: step.Value, Overwrite: step.Overwrite}, nil
+	case config.TransformTypeReplace:
+		pattern, err := regexp.Compile(step.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		return &Replace{Param: step.Param, Pattern: pattern, Replacement: step.Replacement}, nil
+	case config.TransformTypeTimestamp:
+		location, err := time.LoadLocation(step.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+		return &Timestamp{Param: step.Param, Layout: step.Format, Location: location, Overwrite: step.Overwrite}, nil
+	case config.TransformTypeHTTPLookup:
+		return NewHTTPLookup(step, policy), nil
+	default:
+		return nil, fmt.Errorf("unknown transformer type %q", step.Type)
+	}
+}
@@ -0,0 +1,87 @@
+// Package ulid generates ULIDs: 26-character, lexicographically sortable identifiers made of a
+// 48-bit millisecond timestamp and 80 random bits, in Crockford base32
+package ulid
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Length is the number of characters of a ULID
+const Length = 26
+
+// encoding is the Crockford base32 alphabet, which omits I, L, O, and U
+const encoding = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// New returns a ULID for the current time
+func New() string {
+	return NewAt(time.Now())
+}
+
+// NewAt returns a ULID for the given time
+func NewAt(t time.Time) string {
+	var id [16]byte
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		id[i] = byte(ms)
+		ms >>= 8
+	}
+	// crypto/rand does not fail on supported platforms
+	_, _ = rand.Read(id[6:])
+	return encode(id)
+}
+
+// encode renders 128 bits as 26 base32 characters, the first carrying the top 3 bits
+func encode(id [16]byte) string {
+	var out [Length]byte
+	// Work on the 128-bit value as two halves, taking 5 bits at a time from the end
+	hi := uint64(id[0])<<56 | uint64(id[1])<<48 | uint64(id[2])<<40 | uint64(id[3])<<32 |
+		uint64(id[4])<<24 | uint64(id[5])<<16 | uint64(id[6])<<8 | uint64(id[7])
+	lo := uint64(id[8])<<56 | uint64(id[9])<<48 | uint64(id[10])<<40 | uint64(id[11])<<32 |
+		uint64(id[12])<<24 | uint64(id[13])<<16 | uint64(id[14])<<8 | uint64(id[15])
+	for i := Length - 1; i >= 0; i-- {
+		out[i] = encoding[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// Valid reports whether s is a well-formed ULID; lowercase letters are accepted
+func Valid(s string) bool {
+	if len(s) != Length {
+		return false
+	}
+	// The first character carries only 3 bits, so values above 7 overflow 128 bits
+	if s[0] > '7' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !strings.ContainsRune(encoding, rune(upper(s[i]))) {
+			return false
+		}
+	}
+	return true
+}
+
+// Time returns the timestamp encoded in a ULID
+func Time(s string) (time.Time, error) {
+	if !Valid(s) {
+		return time.Time{}, fmt.Errorf("invalid ULID %q", s)
+	}
+	var ms uint64
+	for i := 0; i < 10; i++ {
+		ms = ms<<5 | uint64(strings.IndexByte(encoding, upper(s[i])))
+	}
+	return time.UnixMilli(int64(ms)), nil
+}
+
+// upper returns the uppercase form of an ASCII letter
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
@@ -0,0 +1,10 @@
+package version
+
+// Version is the TriggerMesh release version
+// It can be overridden at build time with -ldflags "-X triggermesh/internal/version.Version=..."
+var Version = "1.0.0"
+
+// UserAgent returns the User-Agent sent on outgoing requests
+func UserAgent() string {
+	return "triggermesh/" + Version
+}
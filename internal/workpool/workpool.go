@@ -0,0 +1,60 @@
+// Package workpool runs tasks with bounded concurrency and per-task timeouts
+// It replaces goroutines spawned per item in batch triggers, notification fan-out, and pollers,
+// so their concurrency limits are set in one place (the concurrency configuration)
+package workpool
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Task is a unit of work; ctx is cancelled when the task's timeout expires or the pool's context is done
+type Task func(ctx context.Context) error
+
+// Pool runs tasks in goroutines, at most limit at a time
+// A task error does not cancel the other tasks; Wait returns the first one
+type Pool struct {
+	ctx     context.Context
+	group   errgroup.Group
+	timeout time.Duration
+}
+
+// New creates a pool running up to limit tasks at once (no limit if limit <= 0), each bounded by
+// timeout (none if 0) and by ctx
+func New(ctx context.Context, limit int, timeout time.Duration) *Pool {
+	p := &Pool{ctx: ctx, timeout: timeout}
+	if limit > 0 {
+		p.group.SetLimit(limit)
+	}
+	return p
+}
+
+// Go runs the task, blocking while limit tasks are running
+func (p *Pool) Go(task Task) {
+	p.group.Go(p.wrap(task))
+}
+
+// TryGo runs the task unless limit tasks are running, and reports whether it was started
+func (p *Pool) TryGo(task Task) bool {
+	return p.group.TryGo(p.wrap(task))
+}
+
+// Wait waits for the started tasks to finish and returns the first error of a task, if any
+func (p *Pool) Wait() error {
+	return p.group.Wait()
+}
+
+// wrap bounds the task by the pool's context and timeout
+func (p *Pool) wrap(task Task) func() error {
+	return func() error {
+		ctx := p.ctx
+		if p.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, p.timeout)
+			defer cancel()
+		}
+		return task(ctx)
+	}
+}
@@ -0,0 +1,264 @@
+// Package client is a minimal Go client for the TriggerMesh HTTP API
+// It has no dependencies outside the standard library so it can be imported by any Go service
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTimeout is used when no custom http.Client is configured
+const defaultTimeout = 30 * time.Second
+
+// BuildResult is the result of a trigger or build status request
+type BuildResult struct {
+	Success          bool              `json:"success"`
+	BuildID          string            `json:"build_id,omitempty"`
+	BuildURL         string            `json:"build_url,omitempty"`
+	Message          string            `json:"message"`
+	TriggerID        string            `json:"trigger_id,omitempty"`         // Trigger responses only
+	DurationMS       int64             `json:"duration_ms,omitempty"`        // Trigger responses only
+	EngineDurationMS int64             `json:"engine_duration_ms,omitempty"` // Trigger responses only
+	Labels           map[string]string `json:"labels,omitempty"`
+	ChangeRef        string            `json:"change_ref,omitempty"` // Trigger responses only
+	Commit           string            `json:"commit,omitempty"`     // Trigger responses only
+	Ref              string            `json:"ref,omitempty"`        // Trigger responses only
+}
+
+// AuditLog is an audit log entry returned by the audit API
+type AuditLog struct {
+	ID               int64             `json:"id"`
+	Timestamp        time.Time         `json:"timestamp"`
+	APIKey           string            `json:"api_key"`
+	Method           string            `json:"method"`
+	Path             string            `json:"path"`
+	Status           int               `json:"status"`
+	JobName          string            `json:"job_name"`
+	Params           string            `json:"params"`
+	Result           string            `json:"result"`
+	Error            string            `json:"error,omitempty"`
+	Source           string            `json:"source,omitempty"`
+	Tenant           string            `json:"tenant,omitempty"`
+	Engine           string            `json:"engine,omitempty"`
+	TriggerID        string            `json:"trigger_id,omitempty"`
+	DurationMS       int64             `json:"duration_ms"`
+	EngineDurationMS int64             `json:"engine_duration_ms"`
+	ReplayOf         int64             `json:"replay_of,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	BuildID          string            `json:"build_id,omitempty"`
+	ChangeRef        string            `json:"change_ref,omitempty"`
+	Commit           string            `json:"commit,omitempty"`
+	Ref              string            `json:"ref,omitempty"`
+}
+
+// Error is returned when the API answers with a non-2xx status
+type Error struct {
+	StatusCode int
+	Message    string // The "error" message from the response body, or the raw body
+	Code       string // Stable error code for engine failures (e.g. JOB_NOT_FOUND)
+	Kind       string // Engine failure classification (e.g. not_found)
+	RequestID  string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("triggermesh: %d %s", e.StatusCode, e.Message)
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	return msg
+}
+
+// Client calls the TriggerMesh API
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	userAgent  string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client used for requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with requests
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// New creates a client for the TriggerMesh instance at baseURL authenticating with apiKey
+func New(baseURL, apiKey string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		userAgent:  "triggermesh-go-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// TriggerOptions carries optional trigger metadata recorded in the audit log
+type TriggerOptions struct {
+	Labels    map[string]string // Key/value metadata, e.g. team
+	ChangeRef string            // Change ticket (Jira, ServiceNow) authorizing the trigger
+	Commit    string            // Git commit SHA the build is for
+	Ref       string            // Git branch, tag, or full ref the build is for
+}
+
+// TriggerBuild triggers a Jenkins job with optional parameters
+func (c *Client) TriggerBuild(ctx context.Context, job string, params map[string]string) (*BuildResult, error) {
+	return c.TriggerBuildWithOptions(ctx, job, params, TriggerOptions{})
+}
+
+// TriggerBuildWithOptions triggers a Jenkins job with optional parameters and trigger metadata
+func (c *Client) TriggerBuildWithOptions(ctx context.Context, job string, params map[string]string, opts TriggerOptions) (*BuildResult, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"job":        job,
+		"parameters": params,
+		"labels":     opts.Labels,
+		"change_ref": opts.ChangeRef,
+		"commit":     opts.Commit,
+		"ref":        opts.Ref,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal trigger request: %w", err)
+	}
+
+	var result BuildResult
+	if err := c.do(ctx, http.MethodPost, "/api/v1/trigger/jenkins", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetStatus returns the status of a build by the build ID returned from TriggerBuild (jobName/buildNumber)
+func (c *Client) GetStatus(ctx context.Context, buildID string) (*BuildResult, error) {
+	jobName, buildNumber, ok := strings.Cut(buildID, "/")
+	if !ok || jobName == "" || buildNumber == "" {
+		return nil, fmt.Errorf("invalid build ID %q: expected jobName/buildNumber", buildID)
+	}
+
+	path := "/api/v1/jenkins/builds/" + url.PathEscape(jobName) + "/" + url.PathEscape(buildNumber)
+	var result BuildResult
+	if err := c.do(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListAudit returns audit log entries, newest first
+// Zero limit or offset use the server defaults
+func (c *Client) ListAudit(ctx context.Context, limit, offset int) ([]AuditLog, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	path := "/api/v1/audit"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var logs []AuditLog
+	if err := c.do(ctx, http.MethodGet, path, nil, &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// do sends a request and decodes a successful JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return parseError(resp.StatusCode, respBody)
+	}
+
+	// Servers with server.response_envelope wrap the body in {data, request_id, meta}
+	if resp.Header.Get("X-Response-Envelope") == "true" {
+		var envelope struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(respBody, &envelope); err != nil {
+			return fmt.Errorf("failed to decode response envelope: %w", err)
+		}
+		respBody = envelope.Data
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// parseError builds an *Error from an error response body
+// Falls back to the raw body for non-JSON errors (e.g. 401 from the auth middleware)
+func parseError(status int, body []byte) error {
+	apiErr := &Error{StatusCode: status}
+
+	var payload struct {
+		Error     string `json:"error"`
+		Code      string `json:"code"`
+		Kind      string `json:"kind"`
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Error != "" {
+		apiErr.Message = payload.Error
+		apiErr.Code = payload.Code
+		apiErr.Kind = payload.Kind
+		apiErr.RequestID = payload.RequestID
+		return apiErr
+	}
+
+	apiErr.Message = strings.TrimSpace(string(body))
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(status)
+	}
+	return apiErr
+}
@@ -0,0 +1,149 @@
+package triggermesh
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/sdnotify"
+)
+
+// listen binds the listener groups (server.listen, each of server.listeners, server.management),
+// returning the listeners of each group in order. Addresses are tcp (host:port), tcp:<address>,
+// unix:<path>, and sockets passed by systemd socket activation. Either all listeners are bound or none
+func (s *Server) listen(groups [][]string) ([][]net.Listener, error) {
+	for i := range groups {
+		if len(groups[i]) == 0 {
+			groups[i] = []string{config.ListenTCP}
+		}
+	}
+
+	var activated []sdnotify.ActivationListener
+	needsSystemd := false
+	for _, addresses := range groups {
+		for _, address := range addresses {
+			needsSystemd = needsSystemd || strings.HasPrefix(address, config.ListenSystemd)
+		}
+	}
+	if needsSystemd {
+		var err error
+		if activated, err = sdnotify.ActivationListeners(); err != nil {
+			return nil, err
+		}
+	}
+	claimed := make([]bool, len(activated))
+
+	bound := make([][]net.Listener, len(groups))
+	fail := func(err error) ([][]net.Listener, error) {
+		for _, listeners := range bound {
+			for _, listener := range listeners {
+				listener.Close()
+			}
+		}
+		// Unclaimed sockets are closed too; systemd keeps its own copies
+		for i, a := range activated {
+			if !claimed[i] {
+				a.Listener.Close()
+			}
+		}
+		return nil, err
+	}
+
+	// Named systemd sockets are claimed first, so "systemd" takes only the remaining ones
+	for g, addresses := range groups {
+		for _, address := range addresses {
+			scheme, name, _ := strings.Cut(address, ":")
+			if scheme != config.ListenSystemd || name == "" {
+				continue
+			}
+			found := false
+			for i, a := range activated {
+				if !claimed[i] && a.Name == name {
+					claimed[i], found = true, true
+					bound[g] = append(bound[g], a.Listener)
+					logger.Info("Server listening", "addr", a.Listener.Addr().String(), "systemd_socket", name)
+				}
+			}
+			if !found {
+				return fail(fmt.Errorf("listen address %q: no socket named %q passed by systemd (LISTEN_FDS)", address, name))
+			}
+		}
+	}
+
+	for g, addresses := range groups {
+		for _, address := range addresses {
+			scheme, value, _ := strings.Cut(address, ":")
+			switch {
+			case scheme == config.ListenTCP:
+				addr := value
+				if addr == "" {
+					addr = fmt.Sprintf("%s:%d", s.cfg.Server.Host, s.cfg.Server.Port)
+				}
+				listener, err := net.Listen("tcp", addr)
+				if err != nil {
+					return fail(err)
+				}
+				bound[g] = append(bound[g], listener)
+				logger.Info("Server listening", "addr", listener.Addr().String())
+			case scheme == config.ListenUnix:
+				listener, err := listenUnix(value, s.cfg.Server.SocketMode)
+				if err != nil {
+					return fail(err)
+				}
+				bound[g] = append(bound[g], listener)
+				logger.Info("Server listening", "addr", "unix:"+value)
+			case scheme == config.ListenSystemd && value == "":
+				found := false
+				for i, a := range activated {
+					if !claimed[i] {
+						claimed[i], found = true, true
+						bound[g] = append(bound[g], a.Listener)
+						logger.Info("Server listening", "addr", a.Listener.Addr().String(), "systemd_socket", a.Name)
+					}
+				}
+				if !found {
+					return fail(errors.New("listen address \"systemd\": no sockets passed by systemd (LISTEN_FDS)"))
+				}
+			}
+		}
+	}
+	return bound, nil
+}
+
+// listenUnix listens on a Unix socket with the given octal file mode
+// A socket left behind by a previous run is replaced; any other file at the path is an error
+func listenUnix(path, socketMode string) (net.Listener, error) {
+	mode := fs.FileMode(0o660)
+	if socketMode != "" {
+		parsed, err := strconv.ParseUint(socketMode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid socket mode %q: %w", socketMode, err)
+		}
+		mode = fs.FileMode(parsed)
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("cannot listen on %s: file exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
@@ -0,0 +1,775 @@
+package triggermesh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"triggermesh/internal/api"
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/archive"
+	"triggermesh/internal/canary"
+	"triggermesh/internal/config"
+	"triggermesh/internal/email"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/engine/awsengine"
+	"triggermesh/internal/engine/awx"
+	"triggermesh/internal/engine/fake"
+	"triggermesh/internal/engine/httpengine"
+	"triggermesh/internal/engine/jenkins"
+	"triggermesh/internal/engine/spinnaker"
+	"triggermesh/internal/grafana"
+	"triggermesh/internal/keyexpiry"
+	"triggermesh/internal/lifecycle"
+	"triggermesh/internal/logger"
+	"triggermesh/internal/outbound"
+	"triggermesh/internal/outbox"
+	"triggermesh/internal/scheduler"
+	"triggermesh/internal/sdnotify"
+	"triggermesh/internal/stats"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+	"triggermesh/internal/timesync"
+)
+
+// startupRetryInterval is the delay between failed startup checks during gated startup
+const startupRetryInterval = 5 * time.Second
+
+// shutdownTimeout bounds how long each component may take to start or stop, including
+// how long Run waits for in-flight requests after its context is cancelled
+const shutdownTimeout = 30 * time.Second
+
+// Server is an embeddable TriggerMesh API server
+type Server struct {
+	cfg         *Config
+	engines     *Registry
+	store       Storage
+	hooks       []Hook
+	router      *api.Router
+	jenkins     *jenkins.Trigger // Jenkins engine built from the configuration; nil if replaced by WithEngine or jenkins.fake
+	canary      *canary.Prober   // nil unless monitoring.canary is enabled
+	httpServers []*http.Server   // One per listener group: server.listen, server.listeners, then server.management
+}
+
+// Option configures a Server
+type Option func(*Server) error
+
+// WithEngine registers a CI engine under the given name
+// Registering JenkinsEngine replaces the Jenkins engine built from the configuration; other engines
+// are served at /api/v1/trigger/{name} and replace configured engines of the same name
+func WithEngine(name string, e Engine) Option {
+	return func(s *Server) error {
+		return s.engines.Register(name, e)
+	}
+}
+
+// WithStorage stores audit logs in a custom backend instead of the configured SQLite database
+// Audit archiving requires SQLite and cannot be enabled together with a custom store
+func WithStorage(store Storage) Option {
+	return func(s *Server) error {
+		s.store = store
+		return nil
+	}
+}
+
+// WithHook registers a component started by Run after the built-in background jobs and
+// before the HTTP listener, and stopped in reverse order on shutdown
+func WithHook(hook Hook) Option {
+	return func(s *Server) error {
+		if hook.Name == "" {
+			return errors.New("hook name cannot be empty")
+		}
+		s.hooks = append(s.hooks, hook)
+		return nil
+	}
+}
+
+// NewServer creates a server from the configuration, opening its storage
+// Storage is process-wide, so only one Server should be active at a time
+func NewServer(cfg *Config, opts ...Option) (*Server, error) {
+	if cfg == nil {
+		return nil, errors.New("config cannot be nil")
+	}
+
+	s := &Server{
+		cfg:     cfg,
+		engines: NewRegistry(),
+	}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fall back to the Jenkins engine from the configuration
+	jenkinsEngine, ok := s.engines.Get(JenkinsEngine)
+	if !ok {
+		if cfg.Jenkins.Fake != nil {
+			logger.Warn("Jenkins is replaced by the fake engine; builds are simulated")
+			jenkinsEngine = fake.New(*cfg.Jenkins.Fake)
+		} else {
+			s.jenkins = jenkins.NewTrigger(jenkins.NewClient(cfg.Jenkins))
+			jenkinsEngine = s.jenkins
+		}
+		if err := s.engines.Register(JenkinsEngine, jenkinsEngine); err != nil {
+			return nil, err
+		}
+	}
+
+	// Register the engines from the configuration unless WithEngine registered the name
+	// Engines registered with WithEngine are reported as type "custom"
+	policy, err := outbound.NewPolicy(cfg.Outbound)
+	if err != nil {
+		return nil, err
+	}
+	engineTypes := make(map[string]string, len(cfg.Engines))
+	engineFields := make(map[string][]engine.RequestField, len(cfg.Engines))
+	for _, engineCfg := range cfg.Engines {
+		if _, ok := s.engines.Get(engineCfg.Name); ok {
+			continue
+		}
+		e, err := newEngine(engineCfg, policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create engine %q: %w", engineCfg.Name, err)
+		}
+		if err := s.engines.Register(engineCfg.Name, e); err != nil {
+			return nil, err
+		}
+		engineTypes[engineCfg.Name] = engineCfg.Type
+		engineFields[engineCfg.Name] = requestFields(engineCfg.Fields)
+	}
+
+	s.router = api.NewRouter(*cfg, jenkinsEngine)
+	for _, name := range s.engines.Names() {
+		if name == JenkinsEngine {
+			continue
+		}
+		e, _ := s.engines.Get(name)
+		engineType, ok := engineTypes[name]
+		if !ok {
+			engineType = "custom"
+		}
+		s.router.AddEngine(name, engineType, e, engineFields[name]...)
+	}
+
+	// The canary engine is registered like the others so the build status poller finds its builds
+	if cfg.Monitoring.Canary.Enabled {
+		canaryEngine := canary.NewEngine()
+		if err := s.engines.Register(config.CanaryEngine, canaryEngine); err != nil {
+			return nil, fmt.Errorf("failed to register the canary engine: %w", err)
+		}
+		prober, err := s.router.EnableCanary(cfg.Monitoring.Canary, canaryEngine)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the canary: %w", err)
+		}
+		s.canary = prober
+	}
+
+	if s.store != nil {
+		if cfg.Archive.Enabled {
+			return nil, errors.New("audit archiving requires the SQLite database and cannot be used with custom storage")
+		}
+		if cfg.Stats.Enabled {
+			return nil, errors.New("build statistics require the SQLite database and cannot be used with custom storage")
+		}
+		if cfg.Outbox.Enabled() {
+			return nil, errors.New("audit forwarding through the outbox requires the SQLite database and cannot be used with custom storage")
+		}
+		storage.Use(s.store)
+	} else if !cfg.Server.ReadinessGating {
+		// With readiness gating, Run opens the database after the listener is bound
+		if err := storage.Init(cfg.Database.Path); err != nil {
+			return nil, fmt.Errorf("failed to initialize database: %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+// requestFields converts the configured request fields of an engine
+func requestFields(fields []config.EngineFieldConfig) []engine.RequestField {
+	var converted []engine.RequestField
+	for _, field := range fields {
+		converted = append(converted, engine.RequestField{
+			Name:        field.Name,
+			Type:        field.Type,
+			Description: field.Description,
+			Required:    field.Required,
+			Enum:        field.Enum,
+			Pattern:     field.Pattern,
+			Parameter:   field.Parameter,
+		})
+	}
+	return converted
+}
+
+// newEngine creates a CI engine from its configuration
+// The outbound policy applies to the generic HTTP engine, whose URLs are rendered from trigger requests
+func newEngine(cfg config.EngineConfig, policy *outbound.Policy) (engine.CIEngine, error) {
+	switch cfg.Type {
+	case config.EngineTypeHTTP:
+		return httpengine.New(cfg.Name, cfg.HTTP, policy)
+	case config.EngineTypeCodeBuild:
+		return awsengine.NewCodeBuild(cfg.AWS)
+	case config.EngineTypeCodePipeline:
+		return awsengine.NewCodePipeline(cfg.AWS)
+	case config.EngineTypeSpinnaker:
+		return spinnaker.New(cfg.Spinnaker), nil
+	case config.EngineTypeAWX:
+		return awx.New(cfg.AWX), nil
+	case config.EngineTypeFake:
+		return fake.New(cfg.Fake), nil
+	default:
+		return nil, fmt.Errorf("unknown engine type %q", cfg.Type)
+	}
+}
+
+// Handler returns the HTTP handler serving the TriggerMesh API, for mounting into another server
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// Engines returns the server's engine registry
+func (s *Server) Engines() *Registry {
+	return s.engines
+}
+
+// Run listens on the addresses of server.listen (by default the configured host and port) and serves the API until ctx is cancelled
+// Components start in order (storage, background jobs, hooks from WithHook, HTTP listener)
+// and stop in reverse order on shutdown, each bounded by the shutdown timeout
+// With server.readiness_gating the listener is bound first and /readyz reports not ready
+// until migrations and engine connectivity checks pass; the other components start after that
+// With server.engine_check fail_fast, Run fails when an engine supporting checks cannot be reached
+func (s *Server) Run(ctx context.Context) error {
+	// Engines report their connectivity at /readyz once checked: at startup with server.engine_check
+	// fail_fast or readiness gating, otherwise when they are first called
+	for _, name := range s.engines.Names() {
+		s.router.Readiness().SetEngine(name, handlers.EngineUnchecked)
+	}
+	if s.cfg.Server.EngineCheck == config.EngineCheckFailFast && !s.cfg.Server.ReadinessGating {
+		if err := s.checkEngines(); err != nil {
+			_ = s.Close()
+			return err
+		}
+	}
+
+	manager := lifecycle.NewManager(shutdownTimeout)
+
+	// Storage is already open; it only needs closing, after everything that uses it has stopped
+	manager.Append(lifecycle.Hook{
+		Name:   "storage",
+		OnStop: func(context.Context) error { return s.Close() },
+	})
+
+	if s.cfg.Archive.Enabled {
+		archiver := archive.NewArchiver(s.cfg.Archive, archive.NewS3Uploader(s.cfg.Archive.S3))
+		manager.Append(lifecycle.Hook{
+			Name: "audit-archiver",
+			OnStart: func(context.Context) error {
+				archiver.Start()
+				logger.Info("Audit archiver started", "bucket", s.cfg.Archive.S3.Bucket, "interval_seconds", s.cfg.Archive.Interval)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				archiver.Stop()
+				return nil
+			},
+		})
+	}
+
+	if s.cfg.Stats.Enabled {
+		poller := stats.NewPoller(s.cfg.Stats, s.engines)
+		if s.cfg.Concurrency.StatusPolls > 0 {
+			poller.SetConcurrency(s.cfg.Concurrency.StatusPolls)
+		}
+		// Completion annotations and emails need the outcomes collected by the poller
+		policy, err := outbound.NewPolicy(s.cfg.Outbound)
+		if err != nil {
+			return err
+		}
+		if s.cfg.Grafana.URL != "" {
+			poller.OnComplete(grafana.NewAnnotator(s.cfg.Grafana, policy).Completed)
+		}
+		if s.cfg.Notifications.Email.Host != "" {
+			mailer, err := email.NewMailer(s.cfg.Notifications.Email, policy)
+			if err != nil {
+				return err
+			}
+			poller.OnComplete(mailer.BuildCompleted)
+		}
+		// Jenkins may push finished builds instead of waiting for the next poll
+		s.router.SetBuildReporter(poller.Report)
+		manager.Append(lifecycle.Hook{
+			Name: "build-status-poller",
+			OnStart: func(context.Context) error {
+				poller.Start()
+				logger.Info("Build status poller started", "poll_interval_seconds", s.cfg.Stats.PollInterval)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				poller.Stop()
+				return nil
+			},
+		})
+	}
+
+	// Audit entries are written with their outbox events from the start; the HTTP server stops
+	// before the dispatcher, so entries written while shutting down are delivered after the restart
+	if s.cfg.Outbox.Enabled() {
+		policy, err := outbound.NewPolicy(s.cfg.Outbound)
+		if err != nil {
+			return err
+		}
+		dispatcher := outbox.NewDispatcher(s.cfg.Outbox, policy)
+		manager.Append(lifecycle.Hook{
+			Name: "outbox-dispatcher",
+			OnStart: func(context.Context) error {
+				storage.SetOutboxEventBuilder(dispatcher.Events)
+				dispatcher.Start()
+				logger.Info("Outbox dispatcher started", "destinations", len(s.cfg.Outbox.Destinations), "poll_interval_seconds", s.cfg.Outbox.PollInterval)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				dispatcher.Stop()
+				storage.SetOutboxEventBuilder(nil)
+				return nil
+			},
+		})
+	}
+
+	// Crumbs and the ordering of audit entries depend on the local clock agreeing with Jenkins; a zero
+	// check interval disables the check
+	if jenkinsEngine, ok := s.engines.Get(JenkinsEngine); ok && s.cfg.Clock.CheckInterval > 0 {
+		if reporter, ok := jenkinsEngine.(engine.ClockReporter); ok {
+			checker := timesync.NewChecker(s.cfg.Clock, JenkinsEngine, reporter)
+			s.router.SetClockStatus(checker.Status)
+			manager.Append(lifecycle.Hook{
+				Name: "clock-check",
+				OnStart: func(context.Context) error {
+					checker.Start()
+					logger.Info("Clock check started", "max_skew_seconds", s.cfg.Clock.MaxSkew, "interval_seconds", s.cfg.Clock.CheckInterval)
+					return nil
+				},
+				OnStop: func(context.Context) error {
+					checker.Stop()
+					return nil
+				},
+			})
+		}
+	}
+
+	if s.canary != nil {
+		manager.Append(lifecycle.Hook{
+			Name: "canary",
+			OnStart: func(context.Context) error {
+				s.canary.Start()
+				logger.Info("Canary started", "interval_seconds", s.cfg.Monitoring.Canary.Interval)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				s.canary.Stop()
+				return nil
+			},
+		})
+	}
+
+	// The daily trigger summary is aggregated from the SQLite audit table; a zero interval disables it
+	if s.store == nil && s.cfg.Stats.SummaryInterval > 0 {
+		summarizer := stats.NewSummarizer(time.Duration(s.cfg.Stats.SummaryInterval) * time.Second)
+		manager.Append(lifecycle.Hook{
+			Name: "audit-summary",
+			OnStart: func(context.Context) error {
+				summarizer.Start()
+				logger.Info("Audit summary refresh started", "interval_seconds", s.cfg.Stats.SummaryInterval)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				summarizer.Stop()
+				return nil
+			},
+		})
+	}
+
+	// Scheduled triggers and the last fire times of recurring schedules are stored in SQLite;
+	// a zero poll interval disables the scheduler
+	if len(s.cfg.Scheduler.Schedules) > 0 && (s.store != nil || s.cfg.Scheduler.PollInterval == 0) {
+		logger.Warn("Recurring schedules need the SQLite database and the scheduler, schedules disabled", "schedules", len(s.cfg.Scheduler.Schedules))
+	}
+	if s.store == nil && s.cfg.Scheduler.PollInterval > 0 {
+		sched := scheduler.NewScheduler(time.Duration(s.cfg.Scheduler.PollInterval)*time.Second, s.router.FireDueTriggers)
+		manager.Append(lifecycle.Hook{
+			Name: "trigger-scheduler",
+			OnStart: func(context.Context) error {
+				sched.Start()
+				logger.Info("Trigger scheduler started", "poll_interval_seconds", s.cfg.Scheduler.PollInterval)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				sched.Stop()
+				return nil
+			},
+		})
+	}
+
+	// Reminders use the notifiers configured at startup; reloads only change which keys expire
+	if s.cfg.API.ExpiryReminder.Days > 0 {
+		reminders, err := keyexpiry.NewWatcher(s.cfg.API.ExpiryReminder, s.router.ExpiringKeys)
+		if err != nil {
+			return fmt.Errorf("failed to create API key expiry reminders: %w", err)
+		}
+		manager.Append(lifecycle.Hook{
+			Name: "key-expiry-reminders",
+			OnStart: func(context.Context) error {
+				reminders.Start()
+				logger.Info("API key expiry reminders started", "days", s.cfg.API.ExpiryReminder.Days)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				reminders.Stop()
+				return nil
+			},
+		})
+	}
+
+	if s.cfg.Reload.Watch && s.cfg.Path != "" {
+		watcher, err := config.NewWatcher(s.cfg.Path, time.Duration(s.cfg.Reload.WatchInterval)*time.Second, s.applyConfig)
+		if err != nil {
+			return fmt.Errorf("failed to watch configuration: %w", err)
+		}
+		manager.Append(lifecycle.Hook{
+			Name: "config-watcher",
+			OnStart: func(context.Context) error {
+				watcher.Start()
+				logger.Info("Configuration watcher started", "path", s.cfg.Path, "interval_seconds", s.cfg.Reload.WatchInterval)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				watcher.Stop()
+				return nil
+			},
+		})
+	}
+
+	for _, hook := range s.hooks {
+		manager.Append(hook)
+	}
+
+	s.httpServers = []*http.Server{{
+		Addr:    fmt.Sprintf("%s:%d", s.cfg.Server.Host, s.cfg.Server.Port),
+		Handler: s.router,
+	}}
+	for _, listener := range s.cfg.Server.Listeners {
+		s.httpServers = append(s.httpServers, &http.Server{Handler: s.router.ForListener(listener)})
+	}
+	if s.cfg.Server.Management.Enabled() {
+		s.httpServers = append(s.httpServers, &http.Server{Handler: s.router.ForManagement()})
+	}
+	serveErr := make(chan error, 1)
+	gated := s.cfg.Server.ReadinessGating
+	manager.Append(lifecycle.Hook{
+		Name: "http-server",
+		OnStart: func(context.Context) error {
+			if gated {
+				// Already serving /readyz since before the startup checks
+				return nil
+			}
+			return s.serve(serveErr)
+		},
+		OnStop: func(ctx context.Context) error {
+			logger.Info("Initiating graceful shutdown", "timeout", shutdownTimeout.String())
+			return s.shutdown(ctx)
+		},
+	})
+
+	if gated {
+		if err := s.serve(serveErr); err != nil {
+			return err
+		}
+		if err := s.runStartupChecks(ctx); err != nil {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			if shutdownErr := s.shutdown(shutdownCtx); shutdownErr != nil {
+				logger.Error("Failed to shut down server", "error", shutdownErr)
+			}
+			_ = s.Close()
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	if err := manager.Start(context.Background()); err != nil {
+		return err
+	}
+
+	// Under systemd (Type=notify) report readiness and feed the watchdog while the database answers
+	notifySystemd(sdnotify.Ready)
+	watchdogCtx, stopWatchdog := context.WithCancel(ctx)
+	go sdnotify.RunWatchdog(watchdogCtx, storage.Ping)
+
+	var runErr error
+	select {
+	case err := <-serveErr:
+		runErr = fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	stopWatchdog()
+	notifySystemd(sdnotify.Stopping)
+	if err := manager.Stop(context.Background()); err != nil {
+		logger.Error("Shutdown completed with errors", "error", err)
+	} else {
+		logger.Info("Server shutdown gracefully")
+	}
+
+	return runErr
+}
+
+// notifySystemd sends a service state to systemd when it supervises the process
+func notifySystemd(state string) {
+	sent, err := sdnotify.Notify(state)
+	if err != nil {
+		logger.Warn("Failed to notify systemd", "state", state, "error", err)
+		return
+	}
+	if sent {
+		logger.Debug("Notified systemd", "state", state)
+	}
+}
+
+// serve binds the listeners synchronously, so address errors fail startup, and serves in the background
+// Each listener group has its own HTTP server, so it can route and filter requests differently
+func (s *Server) serve(serveErr chan<- error) error {
+	groups := [][]string{s.cfg.Server.Listen}
+	for _, listener := range s.cfg.Server.Listeners {
+		groups = append(groups, listener.Listen)
+	}
+	if s.cfg.Server.Management.Enabled() {
+		groups = append(groups, s.cfg.Server.Management.Listen)
+	}
+	bound, err := s.listen(groups)
+	if err != nil {
+		return err
+	}
+	for i, listeners := range bound {
+		for _, listener := range listeners {
+			go func(server *http.Server, listener net.Listener) {
+				if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+					select {
+					case serveErr <- err:
+					default:
+					}
+				}
+			}(s.httpServers[i], listener)
+		}
+	}
+	return nil
+}
+
+// shutdown gracefully shuts down the HTTP servers of all listener groups, ending streamed responses first
+func (s *Server) shutdown(ctx context.Context) error {
+	s.router.CloseStreams()
+	var errs []error
+	for _, server := range s.httpServers {
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runStartupChecks opens the database (running migrations) and checks every engine that
+// supports it, retrying failed checks until all pass or ctx is cancelled, then marks the server ready
+// With server.engine_check fail_fast, engines are checked once and a failure is returned
+func (s *Server) runStartupChecks(ctx context.Context) error {
+	readiness := s.router.Readiness()
+
+	checks := map[string]func() error{}
+	if s.store == nil {
+		checks["storage"] = func() error {
+			if err := storage.Init(s.cfg.Database.Path); err != nil {
+				_ = storage.Close()
+				return err
+			}
+			return nil
+		}
+	}
+	if s.cfg.Server.EngineCheck == config.EngineCheckFailFast {
+		// Failed engines are not retried: startup fails instead
+		if err := s.checkEngines(); err != nil {
+			return err
+		}
+	} else {
+		for _, name := range s.engines.Names() {
+			e, _ := s.engines.Get(name)
+			if pinger, ok := e.(engine.Pinger); ok {
+				name := name
+				checks["engine:"+name] = func() error {
+					err := pinger.Ping()
+					readiness.RecordEngine(name, err == nil)
+					return err
+				}
+			}
+		}
+	}
+	for name := range checks {
+		readiness.SetCheck(name, handlers.CheckPending)
+	}
+
+	for {
+		for name, check := range checks {
+			if err := check(); err != nil {
+				logger.Warn("Startup check failed, retrying", "check", name, "error", err, "retry_in", startupRetryInterval.String())
+				readiness.SetCheck(name, handlers.CheckFailed)
+				continue
+			}
+			readiness.SetCheck(name, handlers.CheckOK)
+			delete(checks, name)
+		}
+		if len(checks) == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(startupRetryInterval):
+		}
+	}
+
+	readiness.SetReady(true)
+	logger.Info("Startup checks completed, server is ready")
+	return nil
+}
+
+// checkEngines checks the connectivity of every engine that supports it once, for server.engine_check
+// fail_fast, and returns an error naming the engines that failed
+func (s *Server) checkEngines() error {
+	readiness := s.router.Readiness()
+	var errs []error
+	for _, name := range s.engines.Names() {
+		e, _ := s.engines.Get(name)
+		pinger, ok := e.(engine.Pinger)
+		if !ok {
+			continue
+		}
+		if err := pinger.Ping(); err != nil {
+			logger.Error("Engine connectivity check failed", "engine", name, "error", err)
+			readiness.SetEngine(name, handlers.CheckFailed)
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		logger.Info("Engine connectivity check passed", "engine", name)
+		readiness.SetEngine(name, handlers.CheckOK)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("engine connectivity check failed (server.engine_check is fail_fast): %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// applyConfig hot-reloads API keys and Jenkins credentials from a changed configuration
+// Other settings are only applied on restart
+func (s *Server) applyConfig(cfg *Config) {
+	s.recordConfigChanges(cfg)
+
+	s.router.UpdateAPIConfig(cfg.API)
+	if s.jenkins != nil {
+		s.jenkins.SetClient(jenkins.NewClient(cfg.Jenkins))
+	}
+
+	// Compare everything except the reloaded sections to warn about changes that need a restart
+	current, next := *s.cfg, *cfg
+	current.API, next.API = config.APIConfig{}, config.APIConfig{}
+	current.Jenkins, next.Jenkins = config.JenkinsConfig{}, config.JenkinsConfig{}
+	if !reflect.DeepEqual(current, next) {
+		logger.Warn("Configuration changes outside api and jenkins require a restart to take effect", "path", cfg.Path)
+	}
+
+	s.cfg.API = cfg.API
+	s.cfg.Jenkins = cfg.Jenkins
+	logger.Info("Configuration reloaded", "path", cfg.Path, "api_keys", len(cfg.API.Keys)+len(cfg.API.Clients))
+}
+
+// configWatcherActor is the config audit actor for changes picked up from the configuration file
+const configWatcherActor = "config_watcher"
+
+// recordConfigChanges adds the differences between the current and the reloaded configuration
+// to the config audit, recording API key changes separately as a key rotation
+func (s *Server) recordConfigChanges(cfg *Config) {
+	changes, err := config.Diff(s.cfg, cfg)
+	if err != nil {
+		logger.Warn("Failed to compare reloaded configuration", "error", err)
+		return
+	}
+
+	var keyChanges, otherChanges []models.ConfigChange
+	for _, change := range changes {
+		entry := models.ConfigChange{Field: change.Field, Before: change.Before, After: change.After}
+		if isAPIKeyField(change.Field) {
+			keyChanges = append(keyChanges, entry)
+		} else {
+			otherChanges = append(otherChanges, entry)
+		}
+	}
+
+	now := time.Now()
+	record := func(action string, changes []models.ConfigChange) {
+		if len(changes) == 0 {
+			return
+		}
+		entry := models.ConfigAudit{Timestamp: now, Action: action, Actor: configWatcherActor, Details: cfg.Path, Changes: changes}
+		if err := storage.InsertConfigAudit(entry); err != nil {
+			logger.Warn("Failed to record configuration change", "error", err, "action", action)
+		}
+	}
+	record(models.ConfigActionReload, otherChanges)
+	record(models.ConfigActionKeyRotation, keyChanges)
+}
+
+// apiClientKeyField matches the key of an API client, e.g. api.clients[0].key
+var apiClientKeyField = regexp.MustCompile(`^api\.clients\[[0-9]+\]\.key$`)
+
+// isAPIKeyField reports whether a configuration field holds an API key
+func isAPIKeyField(field string) bool {
+	return strings.HasPrefix(field, "api.keys[") || apiClientKeyField.MatchString(field)
+}
+
+// Migrate opens the configured database, applies pending schema migrations, and closes it
+// It backs the --migrate-only flag used to run migrations as a separate job (e.g. a Helm init container)
+func Migrate(cfg *Config) error {
+	if err := storage.Init(cfg.Database.Path); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return storage.Close()
+}
+
+// Backup writes a verified snapshot of the configured database to path, which must not exist
+// It is safe while a server is running against the same database
+func Backup(cfg *Config, path string) error {
+	if err := storage.Init(cfg.Database.Path); err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer storage.Close()
+	return storage.Backup(path)
+}
+
+// Restore replaces the configured database with a verified backup
+// No server may have the database open; run it before NewServer (e.g. with -restore-from)
+func Restore(cfg *Config, backupPath string) error {
+	return storage.Restore(backupPath, cfg.Database.Path)
+}
+
+// Close stops the router's background deliveries and closes the server's storage
+// Run calls it on shutdown; call it directly when only Handler is used
+func (s *Server) Close() error {
+	s.router.Close()
+	return storage.Close()
+}
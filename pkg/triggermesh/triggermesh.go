@@ -0,0 +1,65 @@
+// Package triggermesh embeds the TriggerMesh trigger API in other Go services
+//
+// A Server wires configuration, CI engines, and audit storage together and can either
+// listen on its own address (Run) or be mounted into an existing mux (Handler):
+//
+//	cfg, err := triggermesh.LoadConfig("config.yaml")
+//	srv, err := triggermesh.NewServer(cfg, triggermesh.WithStorage(myStore))
+//	portalMux.Handle("/ci/", http.StripPrefix("/ci", srv.Handler()))
+package triggermesh
+
+import (
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/lifecycle"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// Config is the TriggerMesh configuration
+type Config = config.Config
+
+// Engine is a CI engine that can trigger builds and report their status
+type Engine = engine.CIEngine
+
+// BuildResult is the result of a trigger or build status call
+type BuildResult = engine.BuildResult
+
+// Cause describes on whose behalf a build is triggered
+type Cause = engine.Cause
+
+// CauseTriggerer is implemented by engines that can record the cause of a build; the server
+// calls TriggerBuildWithCause instead of TriggerBuild for them
+type CauseTriggerer = engine.CauseTriggerer
+
+// RequestField describes a field engines accept in the fields object of trigger requests
+type RequestField = engine.RequestField
+
+// FieldProvider is implemented by engines that accept request fields; the server validates the
+// fields and passes them to the engine as parameters
+type FieldProvider = engine.FieldProvider
+
+// Registry holds the CI engines available to the API, keyed by name
+type Registry = engine.Registry
+
+// Storage is an audit log backend that replaces the built-in SQLite database
+type Storage = storage.Store
+
+// Hook is a component's start and stop functions, run by Server.Run
+type Hook = lifecycle.Hook
+
+// AuditLog is an audit log entry
+type AuditLog = models.AuditLog
+
+// JenkinsEngine is the registry name of the Jenkins engine
+const JenkinsEngine = "jenkins"
+
+// LoadConfig loads the configuration from a YAML file, applying environment overrides and defaults
+func LoadConfig(path string) (*Config, error) {
+	return config.Load(path)
+}
+
+// NewRegistry creates an empty engine registry
+func NewRegistry() *Registry {
+	return engine.NewRegistry()
+}
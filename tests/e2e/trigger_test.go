@@ -67,9 +67,9 @@ func TestE2ETriggerJenkinsBuild(t *testing.T) {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusInternalServerError {
-		// InternalServerError is acceptable if Jenkins is not available
-		t.Errorf("Expected status 200 or 500, got %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode < http.StatusInternalServerError {
+		// 5xx is acceptable if Jenkins is not available
+		t.Errorf("Expected status 200 or 5xx, got %d", resp.StatusCode)
 	}
 
 	var result map[string]interface{}
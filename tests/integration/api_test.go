@@ -2,7 +2,9 @@ package integration
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -12,10 +14,16 @@ import (
 	"triggermesh/internal/config"
 	"triggermesh/internal/engine/jenkins"
 	"triggermesh/internal/storage"
+	"triggermesh/pkg/client"
 )
 
 // setupTestServer creates a test server with a temporary database
 func setupTestServer(t *testing.T) (*httptest.Server, func()) {
+	return setupTestServerWith(t, nil)
+}
+
+// setupTestServerWith creates a test server whose configuration is adjusted by configure, if set
+func setupTestServerWith(t *testing.T, configure func(*config.Config)) (*httptest.Server, func()) {
 	// Create a temporary database file
 	tmpFile, err := os.CreateTemp("", "test-integration-*.db")
 	if err != nil {
@@ -66,6 +74,10 @@ func setupTestServer(t *testing.T) (*httptest.Server, func()) {
 		},
 	}
 
+	if configure != nil {
+		configure(&cfg)
+	}
+
 	// Create Jenkins client and engine
 	jenkinsClient := jenkins.NewClient(cfg.Jenkins)
 	jenkinsEngine := jenkins.NewTrigger(jenkinsClient)
@@ -289,3 +301,79 @@ func TestRequestIDHeader(t *testing.T) {
 		t.Error("Expected X-Request-ID header in response")
 	}
 }
+
+func TestGoClient(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	c := client.New(server.URL, "test-api-key")
+
+	result, err := c.TriggerBuild(ctx, "test-job", map[string]string{"BRANCH": "main"})
+	if err != nil {
+		t.Fatalf("TriggerBuild failed: %v", err)
+	}
+	if !result.Success {
+		t.Errorf("Expected successful trigger, got %+v", result)
+	}
+
+	status, err := c.GetStatus(ctx, "test-job/1")
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if status.BuildID != "test-job/1" {
+		t.Errorf("Expected build ID test-job/1, got %q", status.BuildID)
+	}
+
+	logs, err := c.ListAudit(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("ListAudit failed: %v", err)
+	}
+	if len(logs) != 1 || logs[0].JobName != "test-job" {
+		t.Errorf("Expected one audit log for test-job, got %+v", logs)
+	}
+
+	// Errors carry the status code and message
+	_, err = client.New(server.URL, "wrong-key").ListAudit(ctx, 0, 0)
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 client error, got %v", err)
+	}
+
+	_, err = c.TriggerBuild(ctx, "", nil)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Message != "Job name is required" {
+		t.Errorf("Expected 400 'Job name is required', got %v", err)
+	}
+}
+
+func TestGoClientWithResponseEnvelope(t *testing.T) {
+	server, cleanup := setupTestServerWith(t, func(cfg *config.Config) {
+		cfg.Server.ResponseEnvelope = true
+	})
+	defer cleanup()
+
+	ctx := context.Background()
+	c := client.New(server.URL, "test-api-key")
+
+	result, err := c.TriggerBuild(ctx, "test-job", nil)
+	if err != nil {
+		t.Fatalf("TriggerBuild failed: %v", err)
+	}
+	if !result.Success {
+		t.Errorf("Expected successful trigger, got %+v", result)
+	}
+	logs, err := c.ListAudit(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("ListAudit failed: %v", err)
+	}
+	if len(logs) != 1 || logs[0].JobName != "test-job" {
+		t.Errorf("Expected one audit log for test-job, got %+v", logs)
+	}
+
+	// Error responses are not enveloped
+	_, err = c.TriggerBuild(ctx, "", nil)
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Message != "Job name is required" {
+		t.Errorf("Expected 400 'Job name is required', got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"triggermesh/internal/alert"
+	"triggermesh/internal/config"
+)
+
+// newAlertWebhook starts a webhook server that forwards received alerts to the returned channel
+func newAlertWebhook(t *testing.T) (*httptest.Server, <-chan alert.Alert) {
+	t.Helper()
+	received := make(chan alert.Alert, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a alert.Alert
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			t.Errorf("Failed to decode alert: %v", err)
+		}
+		received <- a
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func newTestEvaluator(t *testing.T, url string) *alert.Evaluator {
+	t.Helper()
+	evaluator, err := alert.NewEvaluator(config.AlertsConfig{
+		Enabled:     true,
+		Window:      300,
+		MinTriggers: 4,
+		FailureRate: 0.5,
+		Cooldown:    900,
+		Notifiers:   []config.AlertNotifierConfig{{Type: "webhook", URL: url}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create evaluator: %v", err)
+	}
+	return evaluator
+}
+
+func TestAlertEvaluatorFiresOnFailureRate(t *testing.T) {
+	server, received := newAlertWebhook(t)
+	evaluator := newTestEvaluator(t, server.URL)
+
+	// Below min_triggers: no alert even at a 100% failure rate
+	for i := 0; i < 3; i++ {
+		evaluator.Record("jenkins", "deploy", true)
+	}
+	select {
+	case a := <-received:
+		t.Fatalf("Unexpected alert before min_triggers: %+v", a)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The fourth failure reaches min_triggers for both the job and the engine
+	evaluator.Record("jenkins", "deploy", true)
+	scopes := map[string]alert.Alert{}
+	for i := 0; i < 2; i++ {
+		select {
+		case a := <-received:
+			scopes[a.Scope] = a
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for alert")
+		}
+	}
+
+	jobAlert, ok := scopes[alert.ScopeJob]
+	if !ok {
+		t.Fatalf("Expected a job alert, got %+v", scopes)
+	}
+	if jobAlert.Job != "deploy" || jobAlert.Engine != "jenkins" || jobAlert.Failures != 4 || jobAlert.Triggers != 4 {
+		t.Errorf("Unexpected job alert: %+v", jobAlert)
+	}
+	if _, ok := scopes[alert.ScopeEngine]; !ok {
+		t.Errorf("Expected an engine alert, got %+v", scopes)
+	}
+
+	// Cooldown suppresses further alerts for the same job and engine
+	evaluator.Record("jenkins", "deploy", true)
+	select {
+	case a := <-received:
+		t.Fatalf("Unexpected alert during cooldown: %+v", a)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestAlertEvaluatorIgnoresHealthyJobs(t *testing.T) {
+	server, received := newAlertWebhook(t)
+	evaluator := newTestEvaluator(t, server.URL)
+
+	// 1 failure in 6 triggers stays below the 50% threshold
+	evaluator.Record("jenkins", "build", true)
+	for i := 0; i < 5; i++ {
+		evaluator.Record("jenkins", "build", false)
+	}
+
+	select {
+	case a := <-received:
+		t.Fatalf("Unexpected alert for healthy job: %+v", a)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,209 @@
+package unit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/archive"
+	"triggermesh/internal/config"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// fakeS3 records objects uploaded with PUT requests
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut || !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	f.objects[r.URL.Path] = body
+	f.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+// setupArchiveTest initializes storage with audit rows from two past days and today
+func setupArchiveTest(t *testing.T) (*fakeS3, config.ArchiveConfig, func()) {
+	tmpFile, err := os.CreateTemp("", "test-archive-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+
+	now := time.Now().UTC()
+	for _, ts := range []time.Time{
+		now.AddDate(0, 0, -3),
+		now.AddDate(0, 0, -3),
+		now.AddDate(0, 0, -2),
+		now,
+	} {
+		if err := storage.InsertAuditLog(models.AuditLog{
+			Timestamp: ts,
+			APIKey:    "test-api-key",
+			Method:    "POST",
+			Path:      "/api/v1/trigger/jenkins",
+			Status:    200,
+			JobName:   "test-job",
+			Result:    "success",
+		}); err != nil {
+			t.Fatalf("Failed to insert audit log: %v", err)
+		}
+	}
+
+	s3 := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(s3)
+
+	cfg := config.ArchiveConfig{
+		Enabled:  true,
+		Interval: 3600,
+		S3: config.S3Config{
+			Endpoint:        server.URL,
+			Region:          "us-east-1",
+			Bucket:          "audit-bucket",
+			Prefix:          "audit/",
+			AccessKeyID:     "test-id",
+			SecretAccessKey: "test-secret",
+		},
+	}
+
+	cleanup := func() {
+		server.Close()
+		storage.Close()
+		os.Remove(tmpFile.Name())
+	}
+	return s3, cfg, cleanup
+}
+
+func TestArchiverExportsCompletedPartitions(t *testing.T) {
+	s3, cfg, cleanup := setupArchiveTest(t)
+	defer cleanup()
+
+	archiver := archive.NewArchiver(cfg, archive.NewS3Uploader(cfg.S3))
+	created, err := archiver.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("Archive run failed: %v", err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("Expected 2 archived partitions, got %d", len(created))
+	}
+	if created[0].RowCount != 2 || created[1].RowCount != 1 {
+		t.Errorf("Unexpected row counts: %d, %d", created[0].RowCount, created[1].RowCount)
+	}
+
+	// The uploaded object is gzipped NDJSON with one line per row
+	object, ok := s3.objects["/audit-bucket/"+created[0].ObjectKey]
+	if !ok {
+		t.Fatalf("Expected object %s to be uploaded, got %v", created[0].ObjectKey, s3.objects)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(object))
+	if err != nil {
+		t.Fatalf("Failed to open gzip: %v", err)
+	}
+	lines := 0
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var log models.AuditLog
+		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
+			t.Errorf("Invalid NDJSON line: %v", err)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("Expected 2 NDJSON lines, got %d", lines)
+	}
+
+	// A second run finds nothing new
+	created, err = archiver.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("Second archive run failed: %v", err)
+	}
+	if len(created) != 0 {
+		t.Errorf("Expected no new partitions, got %d", len(created))
+	}
+
+	// Rows stay live unless delete_archived is set
+	liveRange, err := storage.GetAuditLiveRange()
+	if err != nil {
+		t.Fatalf("Failed to get live range: %v", err)
+	}
+	if liveRange.RowCount != 4 {
+		t.Errorf("Expected 4 live rows, got %d", liveRange.RowCount)
+	}
+}
+
+func TestArchiverDeletesArchivedRows(t *testing.T) {
+	_, cfg, cleanup := setupArchiveTest(t)
+	defer cleanup()
+
+	cfg.DeleteArchived = true
+	archiver := archive.NewArchiver(cfg, archive.NewS3Uploader(cfg.S3))
+	if _, err := archiver.RunOnce(context.Background()); err != nil {
+		t.Fatalf("Archive run failed: %v", err)
+	}
+
+	liveRange, err := storage.GetAuditLiveRange()
+	if err != nil {
+		t.Fatalf("Failed to get live range: %v", err)
+	}
+	if liveRange.RowCount != 1 {
+		t.Errorf("Expected only today's row to stay live, got %d", liveRange.RowCount)
+	}
+
+	// The archives endpoint reports archived and live ranges
+	req := httptest.NewRequest("GET", "/api/v1/audit/archives", nil)
+	rr := httptest.NewRecorder()
+	handlers.NewAuditHandler().GetAuditArchives(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	var resp struct {
+		Archives []models.AuditArchive `json:"archives"`
+		Live     models.AuditLiveRange `json:"live"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Archives) != 2 || !resp.Archives[0].LiveDeleted {
+		t.Errorf("Expected 2 archives with live rows deleted, got %+v", resp.Archives)
+	}
+	if resp.Live.RowCount != 1 || resp.Live.Oldest == nil {
+		t.Errorf("Expected live range with 1 row, got %+v", resp.Live)
+	}
+}
+
+func TestS3UploaderReportsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("<Error><Code>AccessDenied</Code></Error>"))
+	}))
+	defer server.Close()
+
+	uploader := archive.NewS3Uploader(config.S3Config{Endpoint: server.URL, Region: "us-east-1", Bucket: "b"})
+	err := uploader.Put(context.Background(), "key", []byte("data"), "text/plain")
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Expected AccessDenied error, got %v", err)
+	}
+}
@@ -7,11 +7,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"triggermesh/internal/api/handlers"
 	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/config"
 	"triggermesh/internal/storage"
 	"triggermesh/internal/storage/models"
 )
@@ -380,3 +382,67 @@ func seedLogs(t *testing.T, count int) {
 		}
 	}
 }
+
+func TestGetAuditLogsJobRestrictedKey(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.API.Clients = []config.APIClientConfig{{Name: "only-bot", Key: "only-key", Jobs: []string{"only-this"}}}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	for _, entry := range []models.AuditLog{
+		{Timestamp: time.Now(), APIKey: "admin-secret-key", JobName: "prod-deploy", Params: `{"ENV":"prod"}`, Status: http.StatusOK, Result: "success"},
+		{Timestamp: time.Now(), APIKey: "only-key", JobName: "only-this", Params: `{"ENV":"dev"}`, Status: http.StatusOK, Result: "success"},
+	} {
+		if err := storage.InsertAuditLog(entry); err != nil {
+			t.Fatalf("Failed to insert audit log: %v", err)
+		}
+	}
+	stored, err := storage.GetAuditLogs(10, 0)
+	if err != nil || len(stored) != 2 {
+		t.Fatalf("Expected 2 audit logs, got %d (%v)", len(stored), err)
+	}
+	ids := map[string]int64{}
+	for _, log := range stored {
+		ids[log.JobName] = log.ID
+	}
+
+	get := func(key, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// A key restricted to a job sees neither the triggers of other jobs nor any API key
+	rr := get("only-key", "/api/v1/audit")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var logs []models.AuditLog
+	if err := json.Unmarshal(rr.Body.Bytes(), &logs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(logs) != 1 || logs[0].JobName != "only-this" || logs[0].APIKey != "" {
+		t.Errorf("Expected only the only-this entry without its key, got %+v", logs)
+	}
+	if strings.Contains(rr.Body.String(), "admin-secret-key") || strings.Contains(rr.Body.String(), "prod-deploy") {
+		t.Errorf("Expected no foreign key or job in the response, got %s", rr.Body.String())
+	}
+
+	if rr := get("only-key", fmt.Sprintf("/api/v1/audit/%d/params", ids["prod-deploy"])); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for the parameters of another job, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := get("only-key", fmt.Sprintf("/api/v1/audit/%d/params", ids["only-this"])); rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for the parameters of the key's job, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Unrestricted keys see every job, still without API keys
+	rr = get("test-key", "/api/v1/audit")
+	if err := json.Unmarshal(rr.Body.Bytes(), &logs); err != nil || len(logs) != 2 {
+		t.Fatalf("Expected 2 entries for an unrestricted key, got %s", rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "admin-secret-key") {
+		t.Errorf("Expected no API key in the response, got %s", rr.Body.String())
+	}
+}
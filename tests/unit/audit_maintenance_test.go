@@ -0,0 +1,75 @@
+package unit
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func TestAuditMaintenance(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-audit-maintenance-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, entry := range []models.AuditLog{
+		{Timestamp: day.Add(-48 * time.Hour), JobName: "deploy", Result: "success", Source: models.SourceHTTP},
+		{Timestamp: day.Add(-time.Hour), JobName: "deploy", Result: "failed", Source: models.SourceHTTP},
+		{Timestamp: day.Add(time.Hour), JobName: "test", Result: "success", Source: models.SourceReplay},
+	} {
+		if err := storage.InsertAuditLog(entry); err != nil {
+			t.Fatalf("Failed to insert audit log: %v", err)
+		}
+	}
+
+	count, err := storage.CountAuditLogsInRange(time.Time{}, day)
+	if err != nil || count != 2 {
+		t.Errorf("Expected 2 entries before the cutoff, got %d (err %v)", count, err)
+	}
+
+	var jobs []string
+	if err := storage.EachAuditLog(day.Add(-24*time.Hour), day.Add(24*time.Hour), func(log models.AuditLog) error {
+		jobs = append(jobs, log.JobName)
+		return nil
+	}); err != nil || len(jobs) != 2 || jobs[0] != "deploy" || jobs[1] != "test" {
+		t.Errorf("Expected the two entries in range in order, got %v (err %v)", jobs, err)
+	}
+
+	stop := errors.New("stop")
+	if err := storage.EachAuditLog(time.Time{}, day.Add(24*time.Hour), func(models.AuditLog) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("Expected the callback error, got %v", err)
+	}
+
+	stats, err := storage.GetAuditStats(1)
+	if err != nil {
+		t.Fatalf("Failed to get audit stats: %v", err)
+	}
+	if stats.RowCount != 3 || stats.ByResult["success"] != 2 || stats.BySource[models.SourceReplay] != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if len(stats.TopJobs) != 1 || stats.TopJobs[0].JobName != "deploy" || stats.TopJobs[0].Count != 2 {
+		t.Errorf("Expected deploy as the top job, got %+v", stats.TopJobs)
+	}
+
+	deleted, err := storage.DeleteAuditLogsInRange(time.Time{}, day)
+	if err != nil || deleted != 2 {
+		t.Fatalf("Expected 2 pruned entries, got %d (err %v)", deleted, err)
+	}
+	if err := storage.Vacuum(); err != nil {
+		t.Errorf("Failed to vacuum: %v", err)
+	}
+	if stats, err := storage.GetAuditStats(10); err != nil || stats.RowCount != 1 {
+		t.Errorf("Expected 1 remaining entry, got %+v (err %v)", stats, err)
+	}
+}
@@ -0,0 +1,149 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// triggerWithParams triggers the job through the handler and returns the recorded audit entry
+func triggerWithParams(t *testing.T, handler *handlers.JenkinsHandler, params map[string]string) models.AuditLog {
+	t.Helper()
+	body, _ := json.Marshal(handlers.TriggerJenkinsBuildRequest{Job: "deploy", Parameters: params})
+	rr := httptest.NewRecorder()
+	handler.TriggerJenkinsBuild(rr, httptest.NewRequest("POST", "/api/v1/trigger/jenkins", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	logs, err := storage.GetAuditLogs(1, 0)
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("Expected audit log, got %v (err %v)", logs, err)
+	}
+	return logs[0]
+}
+
+// getAuditParams requests the full parameters of an audit entry
+func getAuditParams(id int64) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	handlers.NewAuditHandler().GetAuditParams(rr, httptest.NewRequest("GET", fmt.Sprintf("/api/v1/audit/%d/params", id), nil))
+	return rr
+}
+
+func TestAuditParamsTruncation(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-audit-params-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	var triggeredParams map[string]string
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			triggeredParams = params
+			return &engine.BuildResult{Success: true, BuildID: jobName + "/1"}, nil
+		},
+	})
+	large := map[string]string{"notes": strings.Repeat("部署", 50), "version": "1.2.3"}
+	admin := &middleware.Principal{Name: "ops", Scopes: []string{"admin"}}
+
+	t.Run("Small parameters are recorded in full", func(t *testing.T) {
+		handler.SetAuditParamsLimit(64, true)
+		entry := triggerWithParams(t, handler, map[string]string{"version": "1.2.3"})
+		if entry.ParamsTruncated || entry.Params != `{"version":"1.2.3"}` {
+			t.Errorf("Expected untruncated parameters, got %q (truncated %v)", entry.Params, entry.ParamsTruncated)
+		}
+	})
+
+	t.Run("Keeps the full parameters of truncated entries", func(t *testing.T) {
+		handler.SetAuditParamsLimit(64, true)
+		entry := triggerWithParams(t, handler, large)
+		if !entry.ParamsTruncated || len(entry.Params) > 64 || !utf8.ValidString(entry.Params) {
+			t.Fatalf("Expected parameters truncated to valid UTF-8 within 64 bytes, got %q (truncated %v)", entry.Params, entry.ParamsTruncated)
+		}
+
+		rr := getAuditParams(entry.ID)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			ID     int64             `json:"id"`
+			Params map[string]string `json:"params"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.ID != entry.ID || resp.Params["notes"] != large["notes"] {
+			t.Errorf("Expected the full parameters, got %+v", resp)
+		}
+
+		// Replays use the full parameters
+		triggeredParams = nil
+		rr = httptest.NewRecorder()
+		handler.ReplayAuditEntry(rr, newReplayRequest(entry.ID, "", admin))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if triggeredParams["notes"] != large["notes"] || triggeredParams["version"] != "1.2.3" {
+			t.Errorf("Expected the replay to use the full parameters, got %v", triggeredParams)
+		}
+	})
+
+	t.Run("Truncated entries without full parameters", func(t *testing.T) {
+		handler.SetAuditParamsLimit(64, false)
+		entry := triggerWithParams(t, handler, large)
+		if !entry.ParamsTruncated {
+			t.Fatalf("Expected truncated parameters, got %q", entry.Params)
+		}
+
+		if rr := getAuditParams(entry.ID); rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rr.Code)
+		}
+		rr := httptest.NewRecorder()
+		handler.ReplayAuditEntry(rr, newReplayRequest(entry.ID, "", admin))
+		if rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), "truncated") {
+			t.Errorf("Expected status 422 for truncated parameters, got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("Archiving removes the full parameters", func(t *testing.T) {
+		logs, err := storage.GetAuditLogs(10, 0)
+		if err != nil {
+			t.Fatalf("Failed to get audit logs: %v", err)
+		}
+		var kept int64
+		for _, entry := range logs {
+			if _, ok, _ := storage.GetAuditParams(entry.ID); ok {
+				kept = entry.ID
+			}
+		}
+		if kept == 0 {
+			t.Fatal("Expected full parameters to be stored")
+		}
+
+		entry, _ := storage.GetAuditLog(kept)
+		if _, err := storage.DeleteAuditLogsInRange(entry.Timestamp, entry.Timestamp.Add(time.Second)); err != nil {
+			t.Fatalf("Failed to delete audit logs: %v", err)
+		}
+		if _, ok, err := storage.GetAuditParams(kept); ok || err != nil {
+			t.Errorf("Expected the full parameters to be deleted with the entry (err %v)", err)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func insertSummaryAuditLogs(t *testing.T, at time.Time, job string, results ...string) {
+	t.Helper()
+	for _, result := range results {
+		if err := storage.InsertAuditLog(models.AuditLog{Timestamp: at, Method: "POST", Path: "/api/v1/trigger/jenkins", JobName: job, Result: result}); err != nil {
+			t.Fatalf("Failed to insert audit log: %v", err)
+		}
+	}
+}
+
+func TestAuditSummaryRefresh(t *testing.T) {
+	cleanup := setupStatsStorage(t)
+	defer cleanup()
+
+	today := time.Now().UTC()
+	yesterday := today.AddDate(0, 0, -1)
+	insertSummaryAuditLogs(t, yesterday, "deploy", "success", "failed", "denied", "success")
+	insertSummaryAuditLogs(t, today, "deploy", "success")
+	insertSummaryAuditLogs(t, today, "build", "failed")
+	// Entries without a job, e.g. audit reads, are not counted
+	insertSummaryAuditLogs(t, today, "", "success")
+
+	if _, err := storage.RefreshAuditSummary(today); err != nil {
+		t.Fatalf("Failed to refresh summary: %v", err)
+	}
+	days, err := storage.GetAuditSummary("deploy", yesterday, today, time.UTC)
+	if err != nil || len(days) != 2 {
+		t.Fatalf("Expected two days, got %+v (err %v)", days, err)
+	}
+	first := days[0]
+	if first.Day != yesterday.Format("2006-01-02") || first.Triggers != 4 || first.Succeeded != 2 || first.Failed != 1 || first.Denied != 1 || first.FailureRate != 0.25 {
+		t.Errorf("Unexpected summary of yesterday: %+v", first)
+	}
+
+	// Archived days keep their counts; the latest day picks up new triggers
+	if _, err := storage.DeleteAuditLogsInRange(yesterday.Add(-time.Hour), yesterday.Add(time.Second)); err != nil {
+		t.Fatalf("Failed to delete audit logs: %v", err)
+	}
+	insertSummaryAuditLogs(t, today, "deploy", "failed")
+	if _, err := storage.RefreshAuditSummary(today); err != nil {
+		t.Fatalf("Failed to refresh summary: %v", err)
+	}
+	days, _ = storage.GetAuditSummary("deploy", yesterday, today, time.UTC)
+	if len(days) != 2 || days[0].Triggers != 4 || days[1].Triggers != 2 || days[1].Failed != 1 {
+		t.Errorf("Unexpected summary after archiving: %+v", days)
+	}
+
+	t.Run("Daily stats endpoint", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handlers.NewStatsHandler().GetJobStats(rr, httptest.NewRequest("GET", "/api/v1/jobs/deploy/daily?days=1", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp handlers.JobDailyStatsResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.JobName != "deploy" || len(resp.Days) != 1 || resp.Days[0].FailureRate != 0.5 {
+			t.Errorf("Expected today's summary only, got %+v", resp)
+		}
+
+		rr = httptest.NewRecorder()
+		handlers.NewStatsHandler().GetJobStats(rr, httptest.NewRequest("GET", "/api/v1/jobs/deploy/daily?days=0", nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for days=0, got %d", rr.Code)
+		}
+	})
+}
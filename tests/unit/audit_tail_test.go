@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// readAuditEvents reads the audit entries of an event stream until count arrived
+func readAuditEvents(t *testing.T, reader *bufio.Reader, count int) []models.AuditLog {
+	t.Helper()
+	var logs []models.AuditLog
+	for len(logs) < count {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Stream ended after %d events: %v", len(logs), err)
+		}
+		data, ok := strings.CutPrefix(strings.TrimRight(line, "\n"), "data: ")
+		if !ok {
+			continue
+		}
+		var log models.AuditLog
+		if err := json.Unmarshal([]byte(data), &log); err != nil {
+			t.Fatalf("Failed to decode event %q: %v", data, err)
+		}
+		logs = append(logs, log)
+	}
+	return logs
+}
+
+func TestTailAuditLogs(t *testing.T) {
+	router, cleanup := setupTestRouter(t, defaultTestConfig())
+	defer cleanup()
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	insert := func(job string, labels map[string]string) {
+		if err := storage.InsertAuditLog(models.AuditLog{Timestamp: time.Now(), APIKey: "test-key", Status: http.StatusOK, JobName: job, Labels: labels}); err != nil {
+			t.Fatalf("Failed to insert audit log: %v", err)
+		}
+	}
+	insert("deploy-old", nil)
+
+	tail := func(query, lastEventID string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/audit/tail?"+query, nil)
+		req.Header.Set("Authorization", "Bearer test-key")
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to tail: %v", err)
+		}
+		return resp
+	}
+
+	// Only entries written after connecting that match the filter are streamed
+	resp := tail("q=job:deploy-*+label:env:prod", "")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	insert("deploy-web", map[string]string{"env": "staging"})
+	insert("build-web", map[string]string{"env": "prod"})
+	insert("deploy-web", map[string]string{"env": "prod"})
+	insert("deploy-api", map[string]string{"env": "prod"})
+	logs := readAuditEvents(t, bufio.NewReader(resp.Body), 2)
+	if logs[0].JobName != "deploy-web" || logs[1].JobName != "deploy-api" || logs[0].ID >= logs[1].ID {
+		t.Fatalf("Expected deploy-web then deploy-api, got %+v", logs)
+	}
+	if logs[0].APIKey != "" {
+		t.Errorf("Streamed entry exposes the API key")
+	}
+
+	// A reconnecting client resumes after the last entry it received
+	resumed := tail("", "1")
+	defer resumed.Body.Close()
+	logs = readAuditEvents(t, bufio.NewReader(resumed.Body), 4)
+	if logs[0].ID != 2 || logs[3].JobName != "deploy-api" {
+		t.Fatalf("Expected entries 2 to 5, got %+v", logs)
+	}
+
+	// Shutdown ends open streams and refuses new ones
+	router.CloseStreams()
+	if _, err := bufio.NewReader(resp.Body).ReadString(0); err == nil {
+		t.Errorf("Expected the stream to end on shutdown")
+	}
+	refused := tail("", "")
+	refused.Body.Close()
+	if refused.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 after shutdown, got %d", refused.StatusCode)
+	}
+
+	for _, query := range []string{"q=since:1h", "q=commit:xyz"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/audit/tail?"+query, nil)
+		req.Header.Set("Authorization", "Bearer test-key")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", query, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/config"
+	"triggermesh/internal/storage"
+)
+
+func TestAuditSummaryTimezoneDays(t *testing.T) {
+	cleanup := setupStatsStorage(t)
+	defer cleanup()
+
+	// Half an hour before and after midnight UTC
+	insertSummaryAuditLogs(t, time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC), "deploy", "success")
+	insertSummaryAuditLogs(t, time.Date(2026, 3, 2, 0, 30, 0, 0, time.UTC), "deploy", "failed")
+	if _, err := storage.RefreshAuditSummary(time.Now()); err != nil {
+		t.Fatalf("Failed to refresh summary: %v", err)
+	}
+
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	newYork, _ := time.LoadLocation("America/New_York")
+	since := time.Date(2026, 2, 28, 12, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		location *time.Location
+		expected map[string]int64
+	}{
+		{"UTC splits at midnight", time.UTC, map[string]int64{"2026-03-01": 1, "2026-03-02": 1}},
+		{"Tokyo is on the next day", tokyo, map[string]int64{"2026-03-02": 2}},
+		{"New York is on the previous day", newYork, map[string]int64{"2026-03-01": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days, err := storage.GetAuditSummary("deploy", since, until, tt.location)
+			if err != nil {
+				t.Fatalf("Failed to get summary: %v", err)
+			}
+			got := make(map[string]int64)
+			for _, day := range days {
+				got[day.Day] = day.Triggers
+				if day.UpdatedAt.Location() != tt.location {
+					t.Errorf("Expected updated_at in %s, got %s", tt.location, day.UpdatedAt.Location())
+				}
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected days %v, got %v", tt.expected, got)
+			}
+			for day, triggers := range tt.expected {
+				if got[day] != triggers {
+					t.Errorf("Expected days %v, got %v", tt.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditLogsTimezone(t *testing.T) {
+	cleanup := setupStatsStorage(t)
+	defer cleanup()
+
+	insertSummaryAuditLogs(t, time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC), "deploy", "success")
+	handler := handlers.NewAuditHandler()
+
+	getTimestamp := func(t *testing.T, query string, principal *middleware.Principal) (int, string) {
+		req := httptest.NewRequest("GET", "/api/v1/audit"+query, nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.PrincipalContextKey, principal))
+		rr := httptest.NewRecorder()
+		handler.GetAuditLogs(rr, req)
+		if rr.Code != http.StatusOK {
+			return rr.Code, ""
+		}
+		// Decoded as a map to compare the timestamps as written, with their offsets
+		var logs []map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &logs); err != nil || len(logs) != 1 {
+			t.Fatalf("Expected one audit log, got %s (err %v)", rr.Body.String(), err)
+		}
+		timestamp, _ := logs[0]["timestamp"].(string)
+		return rr.Code, timestamp
+	}
+
+	if _, timestamp := getTimestamp(t, "", nil); timestamp != "2026-03-01T23:30:00Z" {
+		t.Errorf("Expected a UTC timestamp by default, got %s", timestamp)
+	}
+	if _, timestamp := getTimestamp(t, "?tz=Asia/Tokyo", nil); timestamp != "2026-03-02T08:30:00+09:00" {
+		t.Errorf("Expected a Tokyo timestamp, got %s", timestamp)
+	}
+	berlin := &middleware.Principal{Name: "ops", Timezone: "Europe/Berlin"}
+	if _, timestamp := getTimestamp(t, "", berlin); timestamp != "2026-03-02T00:30:00+01:00" {
+		t.Errorf("Expected the client's time zone, got %s", timestamp)
+	}
+	if _, timestamp := getTimestamp(t, "?tz=UTC", berlin); timestamp != "2026-03-01T23:30:00Z" {
+		t.Errorf("Expected tz to override the client's time zone, got %s", timestamp)
+	}
+	if code, _ := getTimestamp(t, "?tz=Mars/Olympus_Mons", nil); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown time zone, got %d", code)
+	}
+}
+
+func TestAPIClientTimezoneValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "jenkins:\n  url: https://test-jenkins.example.com\n  token: test-token\napi:\n  clients:\n    - name: ops\n      key: ops-key\n      timezone: Mars/Olympus_Mons\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	_, err := config.Load(path)
+	if err == nil || !strings.Contains(err.Error(), "invalid api.clients[0].timezone") {
+		t.Errorf("Expected a timezone validation error, got %v", err)
+	}
+}
@@ -131,6 +131,24 @@ func TestValidateAPIKey(t *testing.T) {
 	}
 }
 
+func TestAuthMiddlewareUpdate(t *testing.T) {
+	authMiddleware := middleware.NewAuthMiddleware(config.APIConfig{
+		Keys: []string{"old-key"},
+	})
+
+	authMiddleware.Update(config.APIConfig{
+		Keys:    []string{"new-key"},
+		Clients: []config.APIClientConfig{{Name: "ci", Key: "client-key"}},
+	})
+
+	if authMiddleware.ValidateAPIKey("old-key") {
+		t.Error("Expected removed key to be rejected after update")
+	}
+	if !authMiddleware.ValidateAPIKey("new-key") || !authMiddleware.ValidateAPIKey("client-key") {
+		t.Error("Expected new keys to be accepted after update")
+	}
+}
+
 func TestGetAPIKey(t *testing.T) {
 	tests := []struct {
 		name           string
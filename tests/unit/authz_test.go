@@ -0,0 +1,166 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/authz"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+)
+
+// newOPAServer returns an OPA-style policy server that allows only the given job
+// It counts queries so tests can check decision caching
+func newOPAServer(t *testing.T, allowedJob string, queries *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(queries, 1)
+		var req struct {
+			Input authz.Input `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.Input.KeyID == "" || req.Input.SourceIP == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		result := map[string]interface{}{"allow": req.Input.Job == allowedJob}
+		if req.Input.Job != allowedJob {
+			result["reason"] = "job is frozen"
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAuthorizer(t *testing.T) {
+	t.Run("OPA decision with reason and caching", func(t *testing.T) {
+		var queries int32
+		server := newOPAServer(t, "deploy", &queries)
+		authorizer := authz.NewAuthorizer(config.AuthzConfig{Type: config.AuthzTypeOPA, URL: server.URL, Timeout: 2, CacheTTL: 10})
+
+		input := authz.Input{KeyID: authz.KeyID("key"), Job: "deploy", SourceIP: "10.0.0.1"}
+		for i := 0; i < 3; i++ {
+			if err := authorizer.Authorize(context.Background(), input); err != nil {
+				t.Fatalf("Expected trigger to be allowed, got %v", err)
+			}
+		}
+		if atomic.LoadInt32(&queries) != 1 {
+			t.Errorf("Expected a single policy query with caching, got %d", atomic.LoadInt32(&queries))
+		}
+
+		input.Job = "release"
+		var denied *authz.DeniedError
+		if err := authorizer.Authorize(context.Background(), input); !errors.As(err, &denied) || denied.Reason != "job is frozen" {
+			t.Errorf("Expected denial with reason, got %v", err)
+		}
+	})
+
+	t.Run("OPA boolean result", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"result": false}`))
+		}))
+		defer server.Close()
+		authorizer := authz.NewAuthorizer(config.AuthzConfig{Type: config.AuthzTypeOPA, URL: server.URL, Timeout: 2, CacheTTL: -1})
+
+		var denied *authz.DeniedError
+		if err := authorizer.Authorize(context.Background(), authz.Input{Job: "deploy"}); !errors.As(err, &denied) {
+			t.Errorf("Expected denial, got %v", err)
+		}
+	})
+
+	t.Run("Webhook decision", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var input authz.Input
+			_ = json.NewDecoder(r.Body).Decode(&input)
+			_ = json.NewEncoder(w).Encode(authz.Decision{Allow: input.Tenant == "team-a"})
+		}))
+		defer server.Close()
+		authorizer := authz.NewAuthorizer(config.AuthzConfig{Type: config.AuthzTypeWebhook, URL: server.URL, Timeout: 2, CacheTTL: -1})
+
+		if err := authorizer.Authorize(context.Background(), authz.Input{Job: "deploy", Tenant: "team-a"}); err != nil {
+			t.Errorf("Expected trigger to be allowed, got %v", err)
+		}
+		if err := authorizer.Authorize(context.Background(), authz.Input{Job: "deploy", Tenant: "team-b"}); err == nil {
+			t.Error("Expected trigger to be denied")
+		}
+	})
+
+	t.Run("Unavailable policy service", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		closed := authz.NewAuthorizer(config.AuthzConfig{Type: config.AuthzTypeWebhook, URL: server.URL, Timeout: 2, CacheTTL: 10})
+		var unavailable *authz.UnavailableError
+		if err := closed.Authorize(context.Background(), authz.Input{Job: "deploy"}); !errors.As(err, &unavailable) {
+			t.Errorf("Expected unavailable error when failing closed, got %v", err)
+		}
+
+		open := authz.NewAuthorizer(config.AuthzConfig{Type: config.AuthzTypeWebhook, URL: server.URL, Timeout: 2, CacheTTL: 10, FailOpen: true})
+		if err := open.Authorize(context.Background(), authz.Input{Job: "deploy"}); err != nil {
+			t.Errorf("Expected trigger to be allowed when failing open, got %v", err)
+		}
+	})
+}
+
+func TestTriggerAuthorizationHook(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-authz-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	var queries int32
+	server := newOPAServer(t, "deploy", &queries)
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			return &engine.BuildResult{Success: true, BuildID: jobName + "/1"}, nil
+		},
+	})
+	handler.SetAuthorizer(authz.NewAuthorizer(config.AuthzConfig{Type: config.AuthzTypeOPA, URL: server.URL, Timeout: 2, CacheTTL: 10}))
+
+	rr := httptest.NewRecorder()
+	handler.TriggerJenkinsBuild(rr, newLabelTriggerRequest(`{"job":"deploy"}`))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.TriggerJenkinsBuild(rr, newLabelTriggerRequest(`{"job":"release"}`))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp["code"] != "POLICY_DENIED" {
+		t.Errorf("Expected code POLICY_DENIED, got %v", resp["code"])
+	}
+
+	logs, err := storage.GetAuditLogs(1, 0)
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("Expected audit log, got %v (err %v)", logs, err)
+	}
+	if logs[0].Result != "denied" || logs[0].Status != http.StatusForbidden || logs[0].Error == "" {
+		t.Errorf("Expected denied audit entry with reason, got %+v", logs[0])
+	}
+}
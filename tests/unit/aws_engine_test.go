@@ -0,0 +1,230 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"triggermesh/internal/aws"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/engine/awsengine"
+)
+
+// newFakeAWSAPI returns an AWS JSON API answering operations by X-Amz-Target with the handler's result
+// Requests must be signed for the service; received collects the decoded request bodies by operation
+func newFakeAWSAPI(t *testing.T, service string, received map[string]map[string]interface{}, handle func(operation string) (int, string)) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "/us-west-2/"+service+"/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"__type": "MissingAuthenticationToken"}`))
+			return
+		}
+		_, operation, _ := strings.Cut(r.Header.Get("X-Amz-Target"), ".")
+		var body map[string]interface{}
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &body)
+		received[operation] = body
+
+		status, response := handle(operation)
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// fakeAWSConfig points an AWS engine at a fake API with static credentials
+func fakeAWSConfig(endpoint string) config.AWSEngineConfig {
+	return config.AWSEngineConfig{
+		Region:          "us-west-2",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		Endpoint:        endpoint,
+		Timeout:         5,
+	}
+}
+
+func TestCodeBuildEngine(t *testing.T) {
+	received := map[string]map[string]interface{}{}
+	server := newFakeAWSAPI(t, "codebuild", received, func(operation string) (int, string) {
+		switch operation {
+		case "StartBuild":
+			if received[operation]["projectName"] == "missing" {
+				return http.StatusBadRequest, `{"__type": "ResourceNotFoundException", "message": "Project cannot be found"}`
+			}
+			return http.StatusOK, `{"build": {"id": "web:1234", "projectName": "web", "buildStatus": "IN_PROGRESS"}}`
+		case "BatchGetBuilds":
+			if received[operation]["ids"].([]interface{})[0] == "web:gone" {
+				return http.StatusOK, `{"builds": [], "buildsNotFound": ["web:gone"]}`
+			}
+			return http.StatusOK, `{"builds": [{"id": "web:1234", "projectName": "web", "buildStatus": "FAILED", "startTime": 1700000000.5, "endTime": 1700000060.5}]}`
+		}
+		return http.StatusBadRequest, `{"__type": "UnknownOperationException"}`
+	})
+
+	e, err := awsengine.NewCodeBuild(fakeAWSConfig(server.URL))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	result, err := e.TriggerBuild("web", map[string]string{"BRANCH": "main", "TOKEN": "@cred:deploy-token"})
+	if err != nil {
+		t.Fatalf("Failed to start build: %v", err)
+	}
+	if result.BuildID != "web:1234" || !strings.Contains(result.BuildURL, "region=us-west-2") {
+		t.Errorf("Unexpected trigger result: %+v", result)
+	}
+	overrides, _ := json.Marshal(received["StartBuild"]["environmentVariablesOverride"])
+	expected := `[{"name":"BRANCH","type":"PLAINTEXT","value":"main"},{"name":"TOKEN","type":"SECRETS_MANAGER","value":"deploy-token"}]`
+	if string(overrides) != expected {
+		t.Errorf("Unexpected environment overrides: %s", overrides)
+	}
+
+	status, err := e.GetBuildStatus("web:1234")
+	if err != nil {
+		t.Fatalf("Failed to get build status: %v", err)
+	}
+	if status.Building || status.Result != engine.ResultFailure || status.BuildDurationMS != 60000 {
+		t.Errorf("Unexpected build status: %+v", status)
+	}
+
+	if _, err := e.GetBuildStatus("web:gone"); !errors.Is(err, engine.ErrJobNotFound) {
+		t.Errorf("Expected not found for an unknown build, got %v", err)
+	}
+	if _, err := e.TriggerBuild("missing", nil); !errors.Is(err, engine.ErrJobNotFound) {
+		t.Errorf("Expected job not found for an unknown project, got %v", err)
+	}
+}
+
+func TestCodePipelineEngine(t *testing.T) {
+	received := map[string]map[string]interface{}{}
+	server := newFakeAWSAPI(t, "codepipeline", received, func(operation string) (int, string) {
+		switch operation {
+		case "StartPipelineExecution":
+			return http.StatusOK, `{"pipelineExecutionId": "exec-1"}`
+		case "GetPipelineExecution":
+			return http.StatusOK, `{"pipelineExecution": {"status": "InProgress"}}`
+		}
+		return http.StatusBadRequest, `{"__type": "UnknownOperationException"}`
+	})
+
+	e, err := awsengine.NewCodePipeline(fakeAWSConfig(server.URL))
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	result, err := e.TriggerBuild("release", map[string]string{"VERSION": "1.2.3"})
+	if err != nil {
+		t.Fatalf("Failed to start execution: %v", err)
+	}
+	if result.BuildID != "release:exec-1" {
+		t.Errorf("Expected build ID release:exec-1, got %s", result.BuildID)
+	}
+	variables, _ := json.Marshal(received["StartPipelineExecution"]["variables"])
+	if string(variables) != `[{"name":"VERSION","value":"1.2.3"}]` {
+		t.Errorf("Unexpected pipeline variables: %s", variables)
+	}
+
+	status, err := e.GetBuildStatus("release:exec-1")
+	if err != nil {
+		t.Fatalf("Failed to get execution status: %v", err)
+	}
+	if !status.Building {
+		t.Errorf("Expected a running execution, got %+v", status)
+	}
+	if received["GetPipelineExecution"]["pipelineName"] != "release" || received["GetPipelineExecution"]["pipelineExecutionId"] != "exec-1" {
+		t.Errorf("Unexpected status request: %v", received["GetPipelineExecution"])
+	}
+
+	if _, err := e.GetBuildStatus("exec-1"); err == nil {
+		t.Error("Expected an error for a build ID without the pipeline")
+	}
+}
+
+func TestAWSEngineRequiresRegion(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	if _, err := awsengine.NewCodeBuild(config.AWSEngineConfig{Timeout: 5}); err == nil {
+		t.Error("Expected an error without a region")
+	}
+}
+
+func TestAWSCredentialChain(t *testing.T) {
+	dir := t.TempDir()
+	credentials := filepath.Join(dir, "credentials")
+	if err := os.WriteFile(credentials, []byte("[default]\naws_access_key_id = AKIDDEFAULT\naws_secret_access_key = s1\n\n[ci]\naws_access_key_id = AKIDCI\naws_secret_access_key = s2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configFile := filepath.Join(dir, "config")
+	if err := os.WriteFile(configFile, []byte("[profile ci]\nregion = eu-central-1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentials)
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	ctx := context.Background()
+
+	// Shared files, with the profile's region from the config file
+	creds, err := aws.NewDefaultProvider("ci", "").Retrieve(ctx)
+	if err != nil || creds.AccessKeyID != "AKIDCI" {
+		t.Errorf("Expected profile credentials, got %+v, %v", creds, err)
+	}
+	if region := aws.ResolveRegion("", "ci"); region != "eu-central-1" {
+		t.Errorf("Expected the profile's region, got %q", region)
+	}
+
+	// Environment variables take precedence over the shared files
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "s3")
+	creds, err = aws.NewDefaultProvider("", "").Retrieve(ctx)
+	if err != nil || creds.AccessKeyID != "AKIDENV" {
+		t.Errorf("Expected environment credentials, got %+v, %v", creds, err)
+	}
+}
+
+func TestAWSContainerCredentials(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "task-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"AccessKeyId": "ASIATASK", "SecretAccessKey": "s", "Token": "session", "Expiration": "2099-01-01T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL+"/creds")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "task-token")
+
+	provider := &aws.CachedProvider{Provider: aws.ContainerProvider{}}
+	for i := 0; i < 2; i++ {
+		creds, err := provider.Retrieve(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to get container credentials: %v", err)
+		}
+		if creds.AccessKeyID != "ASIATASK" || creds.SessionToken != "session" || creds.Expires.IsZero() {
+			t.Errorf("Unexpected credentials: %+v", creds)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Expected cached credentials to be reused, got %d requests", requests)
+	}
+}
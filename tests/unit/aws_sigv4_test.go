@@ -0,0 +1,56 @@
+package unit
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/aws"
+)
+
+func TestSignRequestVanillaVector(t *testing.T) {
+	// "get-vanilla" from the AWS Signature Version 4 test suite
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	creds := aws.Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	aws.SignRequest(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Errorf("Unexpected Authorization header:\n got: %s\nwant: %s", got, expected)
+	}
+}
+
+func TestSignRequestS3SetsContentHash(t *testing.T) {
+	req, err := http.NewRequest("PUT", "https://s3.example.com/bucket/audit/key.gz", strings.NewReader("data"))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	aws.SignRequest(req, []byte("data"), aws.Credentials{AccessKeyID: "id", SecretAccessKey: "secret"}, "us-east-1", "s3", time.Now())
+
+	if req.Header.Get("X-Amz-Content-Sha256") == "" {
+		t.Error("Expected X-Amz-Content-Sha256 header for S3")
+	}
+	if !strings.Contains(req.Header.Get("Authorization"), "x-amz-content-sha256") {
+		t.Errorf("Expected content hash to be signed, got %s", req.Header.Get("Authorization"))
+	}
+}
+
+func TestURIEncode(t *testing.T) {
+	if got := aws.URIEncode("a b/c~d", false); got != "a%20b/c~d" {
+		t.Errorf("Unexpected path encoding: %s", got)
+	}
+	if got := aws.URIEncode("a/b", true); got != "a%2Fb" {
+		t.Errorf("Unexpected query encoding: %s", got)
+	}
+}
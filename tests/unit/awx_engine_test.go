@@ -0,0 +1,82 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/engine/awx"
+)
+
+func TestAWXEngine(t *testing.T) {
+	var launch map[string]map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/job_templates/":
+			if r.URL.Query().Get("name") == "Deploy web" {
+				_, _ = w.Write([]byte(`{"count": 1, "results": [{"id": 7}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"count": 0, "results": []}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/job_templates/7/launch/":
+			_ = json.NewDecoder(r.Body).Decode(&launch)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"job": 42, "id": 42}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/jobs/42/":
+			_, _ = w.Write([]byte(`{"id": 42, "status": "successful", "elapsed": 12.5}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/jobs/43/":
+			_, _ = w.Write([]byte(`{"id": 43, "status": "running", "elapsed": 0}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	e := awx.New(config.AWXEngineConfig{URL: server.URL + "/", Username: "admin", Password: "secret", Timeout: 5})
+
+	result, err := e.TriggerBuild("Deploy web", map[string]string{"version": "1.2.3"})
+	if err != nil {
+		t.Fatalf("Failed to launch job template: %v", err)
+	}
+	if result.BuildID != "42" || result.BuildURL != server.URL+"/#/jobs/playbook/42/output" {
+		t.Errorf("Unexpected trigger result: %+v", result)
+	}
+	if launch["extra_vars"]["version"] != "1.2.3" {
+		t.Errorf("Unexpected launch body: %v", launch)
+	}
+
+	// Numeric job names are template IDs
+	if _, err := e.TriggerBuild("7", nil); err != nil {
+		t.Errorf("Failed to launch job template by ID: %v", err)
+	}
+
+	status, err := e.GetBuildStatus("42")
+	if err != nil {
+		t.Fatalf("Failed to get job status: %v", err)
+	}
+	if status.Building || status.Result != engine.ResultSuccess || status.BuildDurationMS != 12500 {
+		t.Errorf("Unexpected job status: %+v", status)
+	}
+	status, err = e.GetBuildStatus("43")
+	if err != nil || !status.Building {
+		t.Errorf("Expected a running job, got %+v, %v", status, err)
+	}
+
+	if _, err := e.TriggerBuild("Unknown", nil); !errors.Is(err, engine.ErrJobNotFound) {
+		t.Errorf("Expected job not found for an unknown template name, got %v", err)
+	}
+	if _, err := e.TriggerBuild("99", nil); !errors.Is(err, engine.ErrJobNotFound) {
+		t.Errorf("Expected job not found for an unknown template ID, got %v", err)
+	}
+	if _, err := e.GetBuildStatus("../me"); err == nil {
+		t.Error("Expected an error for a non-numeric build ID")
+	}
+}
@@ -0,0 +1,127 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/config"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "triggermesh.db")
+	backupPath := filepath.Join(dir, "backup.db")
+
+	if err := storage.Init(dbPath); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	if err := storage.InsertAuditLog(models.AuditLog{Timestamp: time.Now(), JobName: "before-backup", Result: "success"}); err != nil {
+		t.Fatalf("Failed to insert audit log: %v", err)
+	}
+	if err := storage.Backup(backupPath); err != nil {
+		t.Fatalf("Failed to back up: %v", err)
+	}
+	if err := storage.Backup(backupPath); err == nil {
+		t.Error("Expected backup over an existing file to fail")
+	}
+	if err := storage.InsertAuditLog(models.AuditLog{Timestamp: time.Now(), JobName: "after-backup", Result: "success"}); err != nil {
+		t.Fatalf("Failed to insert audit log: %v", err)
+	}
+
+	if err := storage.Restore(backupPath, dbPath); err == nil {
+		t.Error("Expected restore to fail while the database is open")
+	}
+	storage.Close()
+
+	if err := storage.Restore(backupPath, dbPath); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if err := storage.Init(dbPath); err != nil {
+		t.Fatalf("Failed to open restored database: %v", err)
+	}
+	defer storage.Close()
+
+	logs, err := storage.GetAuditLogs(10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit logs: %v", err)
+	}
+	if len(logs) != 1 || logs[0].JobName != "before-backup" {
+		t.Errorf("Expected only the entry from before the backup, got %+v", logs)
+	}
+}
+
+func TestVerifyDatabaseRejectsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	corrupt := filepath.Join(dir, "corrupt.db")
+	if err := os.WriteFile(corrupt, []byte("not a database"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := storage.VerifyDatabase(corrupt); err == nil {
+		t.Error("Expected a corrupt file to fail verification")
+	}
+	if err := storage.VerifyDatabase(filepath.Join(dir, "missing.db")); err == nil {
+		t.Error("Expected a missing file to fail verification")
+	}
+	if err := storage.Restore(corrupt, filepath.Join(dir, "triggermesh.db")); err == nil {
+		t.Error("Expected restoring a corrupt backup to fail")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "triggermesh.db")); !os.IsNotExist(err) {
+		t.Error("A failed restore must not create the database")
+	}
+}
+
+func TestCreateBackupHandler(t *testing.T) {
+	dir := t.TempDir()
+	if err := storage.Init(filepath.Join(dir, "triggermesh.db")); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	admin := &middleware.Principal{Name: "ops", Scopes: []string{config.ScopeAdmin}}
+	post := func(handler *handlers.BackupHandler, principal *middleware.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/admin/backup", nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.PrincipalContextKey, principal))
+		rr := httptest.NewRecorder()
+		handler.CreateBackup(rr, req)
+		return rr
+	}
+
+	backups := filepath.Join(dir, "backups")
+	if rr := post(handlers.NewBackupHandler(backups, nil, ""), &middleware.Principal{Name: "ci"}); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 without admin scope, got %d", rr.Code)
+	}
+	if rr := post(handlers.NewBackupHandler("", nil, ""), admin); rr.Code != http.StatusNotImplemented {
+		t.Errorf("Expected status 501 without a backup directory, got %d", rr.Code)
+	}
+
+	rr := post(handlers.NewBackupHandler(backups, nil, ""), admin)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp handlers.BackupResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if filepath.Dir(resp.Path) != backups || resp.SizeBytes == 0 {
+		t.Errorf("Unexpected backup response: %+v", resp)
+	}
+	if err := storage.VerifyDatabase(resp.Path); err != nil {
+		t.Errorf("Backup failed verification: %v", err)
+	}
+
+	entries, err := storage.GetConfigAudit(models.ConfigActionBackup, 10, 0)
+	if err != nil || len(entries) != 1 || entries[0].Actor != "ops" {
+		t.Errorf("Expected the backup in the config audit, got %+v (err %v)", entries, err)
+	}
+}
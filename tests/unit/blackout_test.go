@@ -0,0 +1,221 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/blackout"
+	"triggermesh/internal/config"
+	"triggermesh/internal/cron"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+)
+
+func TestCronSchedule(t *testing.T) {
+	t.Run("Invalid expressions", func(t *testing.T) {
+		for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+			if _, err := cron.Parse(expr); err == nil {
+				t.Errorf("Expected %q to be rejected", expr)
+			}
+		}
+	})
+
+	t.Run("Matches", func(t *testing.T) {
+		schedule, err := cron.Parse("*/15 9-17 * * 1-5")
+		if err != nil {
+			t.Fatalf("Failed to parse: %v", err)
+		}
+		tests := []struct {
+			at    time.Time
+			match bool
+		}{
+			{time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC), true},  // Friday
+			{time.Date(2026, 10, 16, 9, 31, 0, 0, time.UTC), false}, // Not on the quarter hour
+			{time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC), false}, // After hours
+			{time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC), false}, // Saturday
+		}
+		for _, tt := range tests {
+			if got := schedule.Matches(tt.at); got != tt.match {
+				t.Errorf("Matches(%s) = %v, expected %v", tt.at, got, tt.match)
+			}
+		}
+	})
+
+	t.Run("Day of month or day of week", func(t *testing.T) {
+		schedule, err := cron.Parse("0 0 1 * 7")
+		if err != nil {
+			t.Fatalf("Failed to parse: %v", err)
+		}
+		if !schedule.Matches(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) || !schedule.Matches(time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)) {
+			t.Error("Expected the first of the month and Sundays to match")
+		}
+	})
+
+	t.Run("Prev", func(t *testing.T) {
+		schedule, err := cron.Parse("0 18 * * 5")
+		if err != nil {
+			t.Fatalf("Failed to parse: %v", err)
+		}
+		now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) // Sunday
+		prev, ok := schedule.Prev(now, now.Add(-7*24*time.Hour))
+		if !ok || !prev.Equal(time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected Friday 18:00, got %s (ok %v)", prev, ok)
+		}
+		if _, ok := schedule.Prev(now, now.Add(-24*time.Hour)); ok {
+			t.Error("Expected no match within a day")
+		}
+	})
+}
+
+func TestBlackoutCalendar(t *testing.T) {
+	calendar, err := blackout.NewCalendar(config.BlackoutConfig{Windows: []config.BlackoutWindowConfig{
+		{Name: "year-end", Jobs: []string{"prod/*"}, Start: "2026-12-20T00:00:00Z", End: "2027-01-05T00:00:00Z"},
+		{Name: "weekend", Jobs: []string{"prod/*"}, Schedule: "0 18 * * 5", Duration: 62 * 3600, Timezone: "Europe/Berlin", AllowOverride: true},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to create calendar: %v", err)
+	}
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		job    string
+		at     time.Time
+		window string
+	}{
+		{"Inside one-off window", "prod/deploy", time.Date(2026, 12, 24, 12, 0, 0, 0, time.UTC), "year-end"},
+		{"Job not covered", "staging/deploy", time.Date(2026, 12, 24, 12, 0, 0, 0, time.UTC), ""},
+		{"After one-off window", "prod/deploy", time.Date(2027, 1, 5, 0, 0, 0, 0, time.UTC), ""},
+		{"Recurring window open", "prod/deploy", time.Date(2026, 10, 17, 9, 0, 0, 0, berlin), "weekend"},
+		{"Recurring window opens in its time zone", "prod/deploy", time.Date(2026, 10, 16, 17, 30, 0, 0, berlin), ""},
+		{"Recurring window closed", "prod/deploy", time.Date(2026, 10, 19, 8, 0, 0, 0, berlin), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active := calendar.Active(tt.job, tt.at)
+			if tt.window == "" {
+				if len(active) != 0 {
+					t.Errorf("Expected no active window, got %+v", active)
+				}
+				return
+			}
+			if len(active) != 1 || active[0].Name != tt.window {
+				t.Fatalf("Expected window %s, got %+v", tt.window, active)
+			}
+		})
+	}
+
+	weekend := time.Date(2026, 10, 17, 9, 0, 0, 0, berlin)
+	var activeErr *blackout.ActiveError
+	if err := calendar.Check("prod/deploy", false, weekend); !errors.As(err, &activeErr) || !activeErr.Window.End.Equal(time.Date(2026, 10, 19, 8, 0, 0, 0, berlin)) {
+		t.Errorf("Expected the weekend window ending Monday 08:00, got %v", err)
+	}
+	if err := calendar.Check("prod/deploy", true, weekend); err != nil {
+		t.Errorf("Expected the override to pass the weekend window, got %v", err)
+	}
+	if err := calendar.Check("prod/deploy", true, time.Date(2026, 12, 24, 12, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Expected the year-end window to ignore the override")
+	}
+}
+
+func TestTriggerBlackout(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-blackout-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	triggered := 0
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			triggered++
+			return &engine.BuildResult{Success: true, BuildID: jobName + "/1"}, nil
+		},
+	})
+	now := time.Now().UTC()
+	calendar, err := blackout.NewCalendar(config.BlackoutConfig{Windows: []config.BlackoutWindowConfig{{
+		Name:          "freeze",
+		Jobs:          []string{"prod/*"},
+		Start:         now.Add(-time.Hour).Format(time.RFC3339),
+		End:           now.Add(time.Hour).Format(time.RFC3339),
+		AllowOverride: true,
+	}}})
+	if err != nil {
+		t.Fatalf("Failed to create calendar: %v", err)
+	}
+	handler.SetBlackoutCalendar(calendar)
+
+	withScopes := func(r *http.Request, scopes ...string) *http.Request {
+		principal := &middleware.Principal{Name: "deployer", Scopes: scopes}
+		return r.WithContext(context.WithValue(r.Context(), middleware.PrincipalContextKey, principal))
+	}
+
+	rr := httptest.NewRecorder()
+	handler.TriggerJenkinsBuild(rr, withScopes(newLabelTriggerRequest(`{"job":"prod/deploy"}`)))
+	if rr.Code != http.StatusLocked {
+		t.Fatalf("Expected status 423, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Code     string          `json:"code"`
+		Blackout blackout.Window `json:"blackout"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Code != "BLACKOUT_ACTIVE" || resp.Blackout.Name != "freeze" || resp.Blackout.End.IsZero() {
+		t.Errorf("Expected the active window in the response, got %+v", resp)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+
+	for _, tt := range []struct {
+		name string
+		req  *http.Request
+	}{
+		{"Job not covered", withScopes(newLabelTriggerRequest(`{"job":"staging/deploy"}`))},
+		{"Override scope", withScopes(newLabelTriggerRequest(`{"job":"prod/deploy"}`), config.ScopeBlackoutOverride)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.TriggerJenkinsBuild(rr, tt.req)
+			if rr.Code != http.StatusOK {
+				t.Errorf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+	if triggered != 2 {
+		t.Errorf("Expected 2 engine calls, got %d", triggered)
+	}
+
+	logs, err := storage.GetAuditLogs(10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit logs: %v", err)
+	}
+	denied := 0
+	for _, log := range logs {
+		if log.Result == "denied" && log.Status == http.StatusLocked {
+			denied++
+		}
+	}
+	if denied != 1 {
+		t.Errorf("Expected the blackout refusal in the audit log, got %d", denied)
+	}
+}
@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/config"
+)
+
+func TestBranding(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.API.Clients = []config.APIClientConfig{
+		{Name: "payments-ci", Key: "payments-key", Tenant: "payments"},
+		{Name: "web-ci", Key: "web-key", Tenant: "web"},
+	}
+	cfg.Branding = config.BrandingConfig{
+		Brand: config.Brand{
+			ServiceName: "Acme Build Gateway",
+			Contact:     "#ci-help",
+			DocsURL:     "https://docs.acme.example/ci",
+		},
+		Tenants: map[string]config.Brand{
+			"payments": {ServiceName: "Payments CI", Message: "Deploys freeze Friday 18:00"},
+		},
+	}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	root := func(key string) (string, *handlers.Branding) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var body struct {
+			Message  string             `json:"message"`
+			Branding *handlers.Branding `json:"branding"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return body.Message, body.Branding
+	}
+
+	// Anonymous callers, unknown keys, and tenants without overrides see the defaults
+	for _, key := range []string{"", "wrong-key", "web-key"} {
+		message, branding := root(key)
+		if message != "Acme Build Gateway API" || branding == nil || branding.Contact != "#ci-help" || branding.Message != "" {
+			t.Errorf("Key %q: expected the default branding, got %q %+v", key, message, branding)
+		}
+	}
+
+	// The tenant's settings override the defaults; the others are kept
+	message, branding := root("payments-key")
+	if message != "Payments CI API" || branding == nil {
+		t.Fatalf("Expected the payments branding, got %q %+v", message, branding)
+	}
+	if branding.Message != "Deploys freeze Friday 18:00" || branding.DocsURL != "https://docs.acme.example/ci" {
+		t.Errorf("Expected the tenant message with the default docs URL, got %+v", branding)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+	req.Header.Set("Authorization", "Bearer payments-key")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var me handlers.MeResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &me); err != nil {
+		t.Fatalf("Failed to decode me response: %v", err)
+	}
+	if me.Branding == nil || me.Branding.ServiceName != "Payments CI" {
+		t.Errorf("Expected the payments branding in /api/v1/me, got %+v", me.Branding)
+	}
+}
+
+func TestRootWithoutBranding(t *testing.T) {
+	router, cleanup := setupTestRouter(t, defaultTestConfig())
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var body map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body["message"] != "TriggerMesh API" {
+		t.Errorf("Expected message 'TriggerMesh API', got %v", body["message"])
+	}
+	if _, ok := body["branding"]; ok {
+		t.Errorf("Expected no branding, got %v", body["branding"])
+	}
+}
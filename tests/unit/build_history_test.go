@@ -0,0 +1,117 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine/jenkins"
+)
+
+// newBuildHistoryJenkins returns a mock Jenkins serving the build history of team-a/deploy
+// It counts history requests so tests can check caching
+func newBuildHistoryJenkins(t *testing.T, requests *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job/team-a/job/deploy/api/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(requests, 1)
+		if tree := r.URL.Query().Get("tree"); tree != "builds[number,url,building,result,duration,timestamp]{0,2}" {
+			t.Errorf("Unexpected tree query: %s", tree)
+		}
+		w.Write([]byte(`{"builds":[
+			{"number":12,"url":"http://jenkins/job/team-a/job/deploy/12/","building":true,"duration":0,"timestamp":1767261600000},
+			{"number":11,"url":"http://jenkins/job/team-a/job/deploy/11/","building":false,"result":"FAILURE","duration":90000,"timestamp":1767258000000}
+		]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJenkinsListBuilds(t *testing.T) {
+	var requests int32
+	server := newBuildHistoryJenkins(t, &requests)
+	trigger := jenkins.NewTrigger(jenkins.NewClient(config.JenkinsConfig{URL: server.URL, Username: "u", Token: "t", Timeout: 5}))
+
+	builds, err := trigger.ListBuilds("team-a/deploy", 2)
+	if err != nil {
+		t.Fatalf("Failed to list builds: %v", err)
+	}
+	if len(builds) != 2 {
+		t.Fatalf("Expected 2 builds, got %+v", builds)
+	}
+	running, failed := builds[0], builds[1]
+	if !running.Building || running.FinishedAt != nil || running.BuildID != "team-a/deploy/12" {
+		t.Errorf("Unexpected running build: %+v", running)
+	}
+	if failed.Result != "FAILURE" || failed.DurationMS != 90000 || failed.FinishedAt == nil {
+		t.Errorf("Unexpected finished build: %+v", failed)
+	}
+	if got := failed.FinishedAt.Sub(failed.StartedAt).Milliseconds(); got != 90000 {
+		t.Errorf("Expected finished_at 90s after started_at, got %dms", got)
+	}
+}
+
+func TestListJenkinsJobBuilds(t *testing.T) {
+	var requests int32
+	server := newBuildHistoryJenkins(t, &requests)
+
+	cfg := defaultTestConfig()
+	cfg.Jenkins.URL = server.URL
+	cfg.API.Clients = []config.APIClientConfig{
+		{Name: "team-b", Key: "team-b-key", Jobs: []string{"team-b/*"}},
+	}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	get := func(key, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		rr := get("test-key", "/api/v1/jenkins/jobs/team-a/deploy/builds?limit=2")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp handlers.BuildHistoryResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Job != "team-a/deploy" || len(resp.Builds) != 2 || resp.Builds[0].Number != 12 {
+			t.Errorf("Unexpected build history: %+v", resp)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("Expected history to be served from cache, got %d Jenkins requests", got)
+	}
+
+	tests := []struct {
+		name           string
+		key            string
+		path           string
+		expectedStatus int
+	}{
+		{"Job not visible to key", "team-b-key", "/api/v1/jenkins/jobs/team-a/deploy/builds", http.StatusForbidden},
+		{"Limit too large", "test-key", "/api/v1/jenkins/jobs/team-a/deploy/builds?limit=1000", http.StatusBadRequest},
+		{"Invalid job name", "test-key", "/api/v1/jenkins/jobs/team-a/de%3Bploy/builds", http.StatusBadRequest},
+		{"Unknown sub-resource", "test-key", "/api/v1/jenkins/jobs/team-a/deploy/config", http.StatusNotFound},
+		{"Unknown job", "test-key", "/api/v1/jenkins/jobs/missing/builds", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := get(tt.key, tt.path); rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+)
+
+func TestBuildURLs(t *testing.T) {
+	run := func(t *testing.T, mode string) (trigger, status func(key string) map[string]interface{}) {
+		cfg := defaultTestConfig()
+		cfg.Server.MaxBodySize = 1024 * 1024
+		cfg.Server.BuildURLs = mode
+		cfg.API.Clients = []config.APIClientConfig{
+			{Name: "ci", Key: "ci-key"},
+			{Name: "ops", Key: "ops-key", Scopes: []string{config.ScopeInternalURLs}},
+		}
+		router, cleanup := setupTestRouter(t, cfg)
+		t.Cleanup(cleanup)
+		router.AddEngine("tekton", "custom", &MockCIEngine{
+			TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+				return &engine.BuildResult{Success: true, BuildID: "run-1", BuildURL: "https://tekton.internal/runs/run-1"}, nil
+			},
+			GetBuildStatusFunc: func(buildID string) (*engine.BuildResult, error) {
+				return &engine.BuildResult{Success: true, BuildID: buildID, BuildURL: "https://tekton.internal/runs/" + buildID, Building: true}, nil
+			},
+		})
+
+		do := func(method, path, key, body string) map[string]interface{} {
+			t.Helper()
+			req := httptest.NewRequest(method, path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+key)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			var resp map[string]interface{}
+			if rr.Code != http.StatusOK || json.Unmarshal(rr.Body.Bytes(), &resp) != nil {
+				t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			return resp
+		}
+		trigger = func(key string) map[string]interface{} {
+			return do(http.MethodPost, "/api/v1/trigger/tekton", key, `{"job":"build-web"}`)
+		}
+		status = func(key string) map[string]interface{} {
+			return do(http.MethodGet, "/api/v1/engines/tekton/builds/run-1", key, "")
+		}
+		return trigger, status
+	}
+
+	t.Run("Rewrite", func(t *testing.T) {
+		trigger, status := run(t, config.BuildURLsRewrite)
+		resp := trigger("ci-key")
+		if resp["build_url"] != "/api/v1/builds/"+resp["global_build_id"].(string) {
+			t.Errorf("Expected the global build status URL, got %v", resp["build_url"])
+		}
+		if resp := status("ci-key"); resp["build_url"] != "/api/v1/engines/tekton/builds/run-1" {
+			t.Errorf("Expected the engine build status URL, got %v", resp["build_url"])
+		}
+		// Keys with the internal_urls scope see the engine's URL
+		if resp := trigger("ops-key"); resp["build_url"] != "https://tekton.internal/runs/run-1" {
+			t.Errorf("Expected the engine URL with the internal_urls scope, got %v", resp["build_url"])
+		}
+	})
+
+	t.Run("Omit", func(t *testing.T) {
+		trigger, status := run(t, config.BuildURLsOmit)
+		if resp := trigger("ci-key"); resp["build_url"] != nil || resp["build_id"] != "run-1" {
+			t.Errorf("Expected the build URL to be omitted, got %v", resp)
+		}
+		if resp := status("ci-key"); resp["build_url"] != nil {
+			t.Errorf("Expected the build URL to be omitted, got %v", resp["build_url"])
+		}
+	})
+}
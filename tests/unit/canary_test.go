@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/canary"
+	"triggermesh/internal/config"
+	"triggermesh/internal/metrics"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func TestMetricsRegistry(t *testing.T) {
+	registry := metrics.NewRegistry()
+	requests := registry.NewCounter("test_requests_total", "Requests by route", "route")
+	registry.NewGaugeFunc("test_cache_entries", "Entries in the cache", func() float64 { return 3 })
+	requests.Inc("/b")
+	requests.Add(2, `/a"\`)
+
+	var out strings.Builder
+	if _, err := registry.WriteTo(&out); err != nil {
+		t.Fatalf("Failed to write metrics: %v", err)
+	}
+	expected := `# HELP test_requests_total Requests by route
+# TYPE test_requests_total counter
+test_requests_total{route="/a\"\\"} 2
+test_requests_total{route="/b"} 1
+# HELP test_cache_entries Entries in the cache
+# TYPE test_cache_entries gauge
+test_cache_entries 3
+`
+	if out.String() != expected {
+		t.Errorf("Unexpected metrics:\n%s", out.String())
+	}
+}
+
+func TestCanary(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 1024 * 1024
+	cfg.Monitoring.Canary = config.CanaryConfig{Enabled: true, Interval: 60, Timeout: 5}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	prober, err := router.EnableCanary(cfg.Monitoring.Canary, canary.NewEngine())
+	if err != nil {
+		t.Fatalf("Failed to enable the canary: %v", err)
+	}
+	if !prober.Probe() {
+		t.Fatal("Expected the canary probes to succeed")
+	}
+
+	// A kill switch covering the canary job fails the trigger probe only
+	if _, err := storage.InsertKillSwitch(models.KillSwitch{
+		Pattern:   canary.Job,
+		Reason:    "maintenance",
+		CreatedBy: "ops",
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: time.Now().Add(time.Hour).UTC(),
+	}); err != nil {
+		t.Fatalf("Failed to insert kill switch: %v", err)
+	}
+	if prober.Probe() {
+		t.Fatal("Expected the trigger probe to fail with the canary job disabled")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without an API key, got %d", rr.Code)
+	}
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	for _, line := range []string{
+		`triggermesh_canary_probes_total{probe="api",result="success"} 2`,
+		`triggermesh_canary_probes_total{probe="trigger",result="success"} 1`,
+		`triggermesh_canary_probes_total{probe="trigger",result="failure"} 1`,
+		`triggermesh_canary_up{probe="api"} 1`,
+		`triggermesh_canary_up{probe="trigger"} 0`,
+		`triggermesh_canary_last_success_timestamp_seconds{probe="trigger"} `,
+	} {
+		if !strings.Contains(rr.Body.String(), line) {
+			t.Errorf("Expected %q in the metrics, got:\n%s", line, rr.Body.String())
+		}
+	}
+
+	// Canary triggers are audited under the canary client
+	logs, err := storage.GetAuditLogs(10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit logs: %v", err)
+	}
+	if len(logs) != 2 || logs[0].Engine != "canary" || logs[0].JobName != canary.Job {
+		t.Errorf("Expected 2 canary triggers in the audit log, got %+v", logs)
+	}
+}
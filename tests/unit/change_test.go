@@ -0,0 +1,170 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/change"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+)
+
+// newChangeLookupServer returns a lookup webhook that accepts only the given change_ref
+func newChangeLookupServer(t *testing.T, validRef string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req change.LookupRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.ChangeRef == "CHG-503" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		resp := change.LookupResponse{Valid: req.ChangeRef == validRef}
+		if !resp.Valid {
+			resp.Reason = "change is not approved"
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChangeChecker(t *testing.T) {
+	lookup := newChangeLookupServer(t, "CHG-100")
+	checker, err := change.NewChecker(config.ChangeConfig{
+		Pattern:       `^CHG-[0-9]+$`,
+		RequiredJobs:  []string{"prod/*"},
+		LookupURL:     lookup.URL,
+		LookupTimeout: 5,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+
+	var rejected *change.RejectedError
+	var lookupErr *change.LookupError
+	tests := []struct {
+		name  string
+		job   string
+		ref   string
+		check func(error) bool
+	}{
+		{"Optional and missing", "staging/deploy", "", func(err error) bool { return err == nil }},
+		{"Required and missing", "prod/deploy", "", func(err error) bool { return errors.Is(err, change.ErrRequired) }},
+		{"Pattern mismatch", "prod/deploy", "OPS-1", func(err error) bool { return errors.As(err, &rejected) }},
+		{"Refused by lookup", "prod/deploy", "CHG-200", func(err error) bool { return errors.As(err, &rejected) }},
+		{"Lookup unavailable", "prod/deploy", "CHG-503", func(err error) bool { return errors.As(err, &lookupErr) }},
+		{"Approved", "prod/deploy", "CHG-100", func(err error) bool { return err == nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checker.Check(context.Background(), tt.job, tt.ref)
+			if !tt.check(err) {
+				t.Errorf("Unexpected result for %s %q: %v", tt.job, tt.ref, err)
+			}
+		})
+	}
+}
+
+func TestTriggerChangePolicy(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-change-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	triggered := 0
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			triggered++
+			return &engine.BuildResult{Success: true, BuildID: jobName + "/1"}, nil
+		},
+	})
+	checker, err := change.NewChecker(config.ChangeConfig{
+		Pattern:      `^CHG-[0-9]+$`,
+		RequiredJobs: []string{"prod/*"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create checker: %v", err)
+	}
+	handler.SetChangeChecker(checker)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"Missing change_ref", `{"job":"prod/deploy"}`, http.StatusUnprocessableEntity, "CHANGE_REF_REQUIRED"},
+		{"Rejected change_ref", `{"job":"prod/deploy","change_ref":"OPS-1"}`, http.StatusUnprocessableEntity, "CHANGE_REF_REJECTED"},
+		{"Malformed change_ref", `{"job":"prod/deploy","change_ref":"CHG 1"}`, http.StatusBadRequest, ""},
+		{"Valid change_ref", `{"job":"prod/deploy","change_ref":"CHG-1234"}`, http.StatusOK, ""},
+		{"Job without policy", `{"job":"staging/deploy"}`, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.TriggerJenkinsBuild(rr, newLabelTriggerRequest(tt.body))
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedCode != "" {
+				var resp map[string]interface{}
+				if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if resp["code"] != tt.expectedCode {
+					t.Errorf("Expected code %s, got %v", tt.expectedCode, resp["code"])
+				}
+			}
+		})
+	}
+
+	if triggered != 2 {
+		t.Errorf("Expected only policy-compliant triggers to reach the engine, got %d", triggered)
+	}
+
+	t.Run("Audit correlation", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handlers.NewAuditHandler().GetAuditLogs(rr, httptest.NewRequest("GET", "/api/v1/audit?change_ref=CHG-1234", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		var logs []map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &logs); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(logs) != 1 || logs[0]["change_ref"] != "CHG-1234" || logs[0]["result"] != "success" {
+			t.Errorf("Expected the approved trigger, got %v", logs)
+		}
+
+		all, err := storage.GetAuditLogs(10, 0)
+		if err != nil {
+			t.Fatalf("Failed to get audit logs: %v", err)
+		}
+		denied := 0
+		for _, log := range all {
+			if log.Result == "denied" {
+				denied++
+			}
+		}
+		if denied != 2 {
+			t.Errorf("Expected 2 denied triggers in the audit log, got %d", denied)
+		}
+	})
+}
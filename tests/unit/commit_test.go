@@ -0,0 +1,144 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func TestTriggerCommitAndRef(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-commit-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	var lastParams map[string]string
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			lastParams = params
+			return &engine.BuildResult{Success: true, BuildID: jobName + "/1"}, nil
+		},
+	})
+	handler.SetCommitParameters("GIT_COMMIT", "GIT_REF")
+
+	const sha = "3f2a9c1e4b5d6a7f8e9d0c1b2a3f4e5d6c7b8a90"
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Full SHA and branch", `{"job":"deploy","commit":"3F2A9C1E4B5D6A7F8E9D0C1B2A3F4E5D6C7B8A90","ref":"release/1.4"}`, http.StatusOK},
+		{"Abbreviated SHA and full ref", `{"job":"deploy","commit":"3f2a9c1","ref":"refs/tags/v1.4.0"}`, http.StatusOK},
+		{"SHA too short", `{"job":"deploy","commit":"3f2a9c"}`, http.StatusBadRequest},
+		{"SHA not hexadecimal", `{"job":"deploy","commit":"3f2a9c1z"}`, http.StatusBadRequest},
+		{"Ref with double dots", `{"job":"deploy","ref":"main..dev"}`, http.StatusBadRequest},
+		{"Ref with a space", `{"job":"deploy","ref":"feature one"}`, http.StatusBadRequest},
+		{"Ref ending in .lock", `{"job":"deploy","ref":"heads/main.lock"}`, http.StatusBadRequest},
+		{"Ref with a leading slash", `{"job":"deploy","ref":"/main"}`, http.StatusBadRequest},
+		{"Conflicting parameter", `{"job":"deploy","commit":"3f2a9c1","parameters":{"GIT_COMMIT":"abcdef0"}}`, http.StatusBadRequest},
+		{"Matching parameter", `{"job":"deploy","commit":"3f2a9c1","parameters":{"GIT_COMMIT":"3F2A9C1"}}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.TriggerJenkinsBuild(rr, newLabelTriggerRequest(tt.body))
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	// The first trigger passed the commit, lowercased, and the ref as Jenkins parameters
+	logs, err := storage.QueryAuditLogs(models.AuditFilter{Commit: sha}, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to query audit logs: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Commit != sha || logs[0].Ref != "release/1.4" {
+		t.Fatalf("Expected one audit entry for the full SHA, got %+v", logs)
+	}
+
+	// An abbreviated SHA finds every trigger of the commits it abbreviates
+	logs, err = storage.QueryAuditLogs(models.AuditFilter{Commit: "3f2a9c1"}, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to query audit logs: %v", err)
+	}
+	if len(logs) != 3 {
+		t.Errorf("Expected 3 audit entries for the abbreviated SHA, got %d", len(logs))
+	}
+	if lastParams["GIT_COMMIT"] != "3F2A9C1" || lastParams["GIT_REF"] != "" {
+		t.Errorf("Expected the explicit parameter to be passed unchanged, got %v", lastParams)
+	}
+
+	// The audit endpoint filters by commit
+	auditHandler := handlers.NewAuditHandler()
+	rr := httptest.NewRecorder()
+	auditHandler.GetAuditLogs(rr, httptest.NewRequest(http.MethodGet, "/api/v1/audit?commit="+sha[:12], nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var entries []models.AuditLog
+	if err := json.Unmarshal(rr.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("Failed to decode audit logs: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Ref != "release/1.4" {
+		t.Errorf("Expected the trigger of the full SHA, got %+v", entries)
+	}
+
+	rr = httptest.NewRecorder()
+	auditHandler.GetAuditLogs(rr, httptest.NewRequest(http.MethodGet, "/api/v1/audit?commit=main", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid commit, got %d", rr.Code)
+	}
+}
+
+func TestTriggerCommitParameters(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-commit-params-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	var params map[string]string
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, p map[string]string) (*engine.BuildResult, error) {
+			params = p
+			return &engine.BuildResult{Success: true, BuildID: jobName + "/1"}, nil
+		},
+	})
+	handler.SetCommitParameters("GIT_COMMIT", "-")
+
+	rr := httptest.NewRecorder()
+	handler.TriggerJenkinsBuild(rr, newLabelTriggerRequest(`{"job":"deploy","commit":"ABCDEF0","ref":"main","parameters":{"ENV":"prod"}}`))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(params) != 2 || params["GIT_COMMIT"] != "abcdef0" || params["ENV"] != "prod" {
+		t.Errorf("Expected the commit next to the requested parameters and no ref, got %v", params)
+	}
+
+	var resp handlers.TriggerJenkinsBuildResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Commit != "abcdef0" || resp.Ref != "main" {
+		t.Errorf("Expected the commit and ref in the response, got %q and %q", resp.Commit, resp.Ref)
+	}
+}
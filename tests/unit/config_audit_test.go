@@ -0,0 +1,179 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func TestConfigDiff(t *testing.T) {
+	before := &config.Config{
+		Jenkins: config.JenkinsConfig{URL: "https://jenkins.example.com", Token: "old-token"},
+		API: config.APIConfig{
+			Keys:    []string{"key-a"},
+			Clients: []config.APIClientConfig{{Name: "ci", Key: "client-old"}},
+		},
+	}
+	after := &config.Config{
+		Jenkins: config.JenkinsConfig{URL: "https://jenkins2.example.com", Token: "new-token"},
+		API: config.APIConfig{
+			Keys:    []string{"key-a", "key-b"},
+			Clients: []config.APIClientConfig{{Name: "ci", Key: "client-new"}},
+		},
+	}
+
+	changes, err := config.Diff(before, after)
+	if err != nil {
+		t.Fatalf("Failed to diff configs: %v", err)
+	}
+
+	got := make(map[string]config.FieldChange, len(changes))
+	for _, change := range changes {
+		got[change.Field] = change
+	}
+	if len(got) != 4 {
+		t.Errorf("Expected 4 changed fields, got %+v", changes)
+	}
+	if c := got["jenkins.url"]; c.Before != "https://jenkins.example.com" || c.After != "https://jenkins2.example.com" {
+		t.Errorf("Unexpected jenkins.url change: %+v", c)
+	}
+	if c := got["api.keys[1]"]; c.Before != "" || c.After != "********" {
+		t.Errorf("Expected the added key to be masked, got %+v", c)
+	}
+	for _, field := range []string{"jenkins.token", "api.clients[0].key"} {
+		if c, ok := got[field]; !ok || c.Before != "********" || c.After != "********" {
+			t.Errorf("Expected rotated secret %s to be reported masked, got %+v", field, c)
+		}
+	}
+
+	encoded, _ := json.Marshal(changes)
+	for _, secret := range []string{"old-token", "new-token", "key-b", "client-old", "client-new"} {
+		if strings.Contains(string(encoded), secret) {
+			t.Errorf("Diff leaks secret %q: %s", secret, encoded)
+		}
+	}
+}
+
+func TestConfigAuditEndpoint(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-config-audit-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	if err := storage.InsertConfigAudit(models.ConfigAudit{
+		Action:  models.ConfigActionKeyRotation,
+		Actor:   "config_watcher",
+		Changes: []models.ConfigChange{{Field: "api.keys[0]", Before: "********", After: "********"}},
+	}); err != nil {
+		t.Fatalf("Failed to insert config audit: %v", err)
+	}
+
+	// Replaying a trigger is an admin action and is recorded too
+	if err := storage.InsertAuditLog(models.AuditLog{JobName: "deploy", Params: `{}`, Result: "failed", Engine: "jenkins"}); err != nil {
+		t.Fatalf("Failed to insert audit log: %v", err)
+	}
+	logs, _ := storage.GetAuditLogs(1, 0)
+	jenkinsHandler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			return &engine.BuildResult{Success: true, BuildID: jobName + "/1"}, nil
+		},
+	})
+	admin := &middleware.Principal{Name: "ops", Scopes: []string{config.ScopeAdmin}}
+	rr := httptest.NewRecorder()
+	jenkinsHandler.ReplayAuditEntry(rr, newReplayRequest(logs[0].ID, "", admin))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected replay to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	getConfigAudit := func(query string, principal *middleware.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/audit/config"+query, nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.PrincipalContextKey, principal))
+		rr := httptest.NewRecorder()
+		handlers.NewAuditHandler().GetConfigAudit(rr, req)
+		return rr
+	}
+
+	t.Run("Requires admin scope", func(t *testing.T) {
+		if rr := getConfigAudit("", &middleware.Principal{Name: "ci"}); rr.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Lists entries newest first", func(t *testing.T) {
+		rr := getConfigAudit("", admin)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		var entries []models.ConfigAudit
+		if err := json.Unmarshal(rr.Body.Bytes(), &entries); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 entries, got %+v", entries)
+		}
+		if entries[0].Action != models.ConfigActionReplay || entries[0].Actor != "ops" || !strings.Contains(entries[0].Details, "job=deploy") {
+			t.Errorf("Unexpected replay entry: %+v", entries[0])
+		}
+		if entries[1].Action != models.ConfigActionKeyRotation || len(entries[1].Changes) != 1 {
+			t.Errorf("Unexpected key rotation entry: %+v", entries[1])
+		}
+	})
+
+	t.Run("Filters by action", func(t *testing.T) {
+		rr := getConfigAudit("?action="+models.ConfigActionKeyRotation, admin)
+		var entries []models.ConfigAudit
+		if err := json.Unmarshal(rr.Body.Bytes(), &entries); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(entries) != 1 || entries[0].Action != models.ConfigActionKeyRotation {
+			t.Errorf("Expected only the key rotation, got %+v", entries)
+		}
+	})
+}
+
+func TestAuditReadsAreRecorded(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.API.Clients = []config.APIClientConfig{{Name: "auditor", Key: "auditor-key"}}
+	cfg.Audit.LogReads = true
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	for _, job := range []string{"deploy", "build"} {
+		if err := storage.InsertAuditLog(models.AuditLog{JobName: job, Params: `{}`, Result: "success", Labels: map[string]string{"team": "web"}}); err != nil {
+			t.Fatalf("Failed to insert audit log: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/audit?label=team:web&limit=10", nil)
+	req.Header.Set("Authorization", "Bearer auditor-key")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	entries, err := storage.GetConfigAudit(models.ConfigActionAuditRead, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get config audit: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Actor != "auditor" || entries[0].Details != "limit=10 offset=0 rows=2 label=team:web" {
+		t.Errorf("Unexpected audit read entries: %+v", entries)
+	}
+}
@@ -3,8 +3,10 @@ package unit
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"triggermesh/internal/config"
 )
@@ -131,6 +133,11 @@ func TestConfigDefaults(t *testing.T) {
 	if cfg.Jenkins.Username != cfg.Jenkins.Token {
 		t.Errorf("Expected Jenkins username to default to token, got %s", cfg.Jenkins.Username)
 	}
+	// Commits and refs are only passed to Jenkins when asked, since Jenkins refuses parameters for
+	// jobs that are not parameterized
+	if cfg.Jenkins.CommitParameter != "" || cfg.Jenkins.RefParameter != "" {
+		t.Errorf("Expected no commit and ref parameters by default, got %q and %q", cfg.Jenkins.CommitParameter, cfg.Jenkins.RefParameter)
+	}
 }
 
 func TestConfigEnvVars(t *testing.T) {
@@ -201,71 +208,989 @@ func TestConfigValidation(t *testing.T) {
 		errorContains string
 	}{
 		{
-			name: "Valid config",
+			name: "Valid config",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError: false,
+		},
+		{
+			name: "Missing Jenkins URL",
+			configContent: `
+jenkins:
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "jenkins.url is required",
+		},
+		{
+			name: "Missing Jenkins Token",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "jenkins.token is required",
+		},
+		{
+			name: "Invalid Jenkins URL",
+			configContent: `
+jenkins:
+  url: "://invalid-url"
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid jenkins.url",
+		},
+		{
+			name: "Missing API Keys",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys: []
+`,
+			expectError:   true,
+			errorContains: "at least one api.key is required",
+		},
+		{
+			name: "Invalid Port",
+			configContent: `
+server:
+  port: 70000
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid server.port",
+		},
+		{
+			name: "Invalid Jenkins Header Name",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+  headers:
+    "X Bad Header": value
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid jenkins.headers name",
+		},
+		{
+			name: "Jenkins Header Overrides Authorization",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+  headers:
+    authorization: Bearer other
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "cannot override Authorization",
+		},
+		{
+			name: "Invalid Label Parameter Prefix",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+  label_parameter_prefix: "label "
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid jenkins.label_parameter_prefix",
+		},
+		{
+			name: "Backup Upload Without Directory",
+			configContent: `
+database:
+  backup_s3:
+    endpoint: http://minio:9000
+    bucket: backups
+    access_key_id: id
+    secret_access_key: secret
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "database.backup_dir is required",
+		},
+		{
+			name: "Invalid Blackout Schedule",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+blackout:
+  windows:
+    - name: weekend
+      schedule: "0 18 * * 8"
+      duration: 3600
+`,
+			expectError:   true,
+			errorContains: "invalid blackout.windows[0]",
+		},
+		{
+			name: "Blackout Window Without End",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+blackout:
+  windows:
+    - name: year-end
+      start: "2026-12-20T00:00:00Z"
+`,
+			expectError:   true,
+			errorContains: "end must be an RFC 3339 time",
+		},
+		{
+			name: "Unknown Transform Type",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+transform:
+  rules:
+    - jobs: ["deploy/*"]
+      steps:
+        - type: uppercase
+          param: BRANCH
+`,
+			expectError:   true,
+			errorContains: "invalid transform.rules[0].steps[0]",
+		},
+		{
+			name: "Transform Lookup Without URL",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+transform:
+  rules:
+    - steps:
+        - type: http_lookup
+          param: ARTIFACT_VERSION
+`,
+			expectError:   true,
+			errorContains: "invalid url",
+		},
+		{
+			name: "HTTP Engine Without Build ID Path",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+engines:
+  - name: buildkite
+    type: http
+    http:
+      trigger:
+        url: https://api.buildkite.com/v2/organizations/acme/pipelines/{{.Job}}/builds
+`,
+			expectError:   true,
+			errorContains: "trigger.build_id_path cannot be empty",
+		},
+		{
+			name: "Reserved Engine Name",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+engines:
+  - name: scheduled
+    type: http
+    http:
+      trigger:
+        url: https://ci.example.com/builds
+        build_id_path: $.id
+`,
+			expectError:   true,
+			errorContains: "invalid engines[0].name",
+		},
+		{
+			name: "AWS Engine Without Secret Key",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+engines:
+  - name: codebuild
+    type: codebuild
+    aws:
+      region: us-east-1
+      access_key_id: AKIDEXAMPLE
+`,
+			expectError:   true,
+			errorContains: "aws.access_key_id and aws.secret_access_key must be set together",
+		},
+		{
+			name: "AWS Engine Invalid Region",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+engines:
+  - name: pipelines
+    type: codepipeline
+    aws:
+      region: US East
+`,
+			expectError:   true,
+			errorContains: "invalid aws.region",
+		},
+		{
+			name: "Spinnaker Engine Without URL",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+engines:
+  - name: spinnaker
+    type: spinnaker
+    spinnaker:
+      token: gate-token
+`,
+			expectError:   true,
+			errorContains: "invalid spinnaker.url",
+		},
+		{
+			name: "AWX Engine Invalid URL",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+engines:
+  - name: awx
+    type: awx
+    awx:
+      url: awx.example.com
+`,
+			expectError:   true,
+			errorContains: "invalid awx.url",
+		},
+		{
+			name: "Invalid Listen Address",
+			configContent: `
+server:
+  listen: [tcp, "unix:"]
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid server.listen[1]",
+		},
+		{
+			name: "Invalid Base Path",
+			configContent: `
+server:
+  base_path: triggermesh
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid server.base_path",
+		},
+		{
+			name: "Additional Listeners",
+			configContent: `
+server:
+  listen: ["tcp:0.0.0.0:8080", "tcp:[::]:8080"]
+  listeners:
+    - name: health
+      listen: ["tcp:127.0.0.1:9090"]
+      routes: [/health, /readyz]
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError: false,
+		},
+		{
+			name: "Listener Without Listen Address",
+			configContent: `
+server:
+  listeners:
+    - name: health
+      routes: [/health]
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid server.listeners[0]: health: listen is required",
+		},
+		{
+			name: "Duplicate Listener Name",
+			configContent: `
+server:
+  listeners:
+    - name: health
+      listen: ["tcp:127.0.0.1:9090"]
+    - name: health
+      listen: ["tcp:127.0.0.1:9091"]
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "duplicate name",
+		},
+		{
+			name: "Pprof Without Management Listener",
+			configContent: `
+server:
+  management:
+    pprof: true
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid server.management.pprof",
+		},
+		{
+			name: "Invalid Concurrency Limit",
+			configContent: `
+concurrency:
+  status_polls: -1
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid concurrency.status_polls",
+		},
+		{
+			name: "Monitoring Token Reusing an API Key",
+			configContent: `
+monitoring:
+  token: test-api-key-0123456789
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key-0123456789
+`,
+			expectError:   true,
+			errorContains: "invalid monitoring.token: must differ from every API key",
+		},
+		{
+			name: "Deprecation Sunset Before Since",
+			configContent: `
+server:
+  deprecations:
+    - route: /api/v1/jenkins
+      since: 2027-01-01T00:00:00Z
+      sunset: 2026-01-01T00:00:00Z
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid server.deprecations[0]: /api/v1/jenkins: sunset must be after since",
+		},
+		{
+			name: "Duplicate Deprecated Route",
+			configContent: `
+server:
+  deprecations:
+    - route: /api/v1/jenkins
+      link: https://docs.example.com/migrate-to-v2
+    - route: /api/v1/jenkins
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid server.deprecations[1]: duplicate route",
+		},
+		{
+			name: "Notification Token Without Build Statistics",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+  notification_token: notify-token-0123456789
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid jenkins.notification_token: requires stats.enabled",
+		},
+		{
+			name: "Throttle Resume Threshold Above Queue Threshold",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+throttle:
+  enabled: true
+  queue_threshold: 20
+  resume_threshold: 30
+`,
+			expectError:   true,
+			errorContains: "invalid throttle.resume_threshold: 30",
+		},
+		{
+			name: "Invalid Throttle Policy",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+throttle:
+  enabled: true
+  policy: queue
+`,
+			expectError:   true,
+			errorContains: "invalid throttle.policy",
+		},
+		{
+			name: "Same Commit And Ref Parameter",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+  commit_parameter: REVISION
+  ref_parameter: REVISION
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid jenkins.ref_parameter: must differ from jenkins.commit_parameter",
+		},
+		{
+			name: "Duplicate Outbox Destination",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+outbox:
+  destinations:
+    - name: siem
+      url: https://siem.example.com/ingest
+    - name: siem
+      url: https://backup.example.com/ingest
+`,
+			expectError:   true,
+			errorContains: "invalid outbox.destinations[1].name: duplicate name",
+		},
+		{
+			name: "Invalid Outbox Destination URL",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+outbox:
+  destinations:
+    - name: siem
+      url: siem.example.com/ingest
+`,
+			expectError:   true,
+			errorContains: "invalid outbox.destinations[0].url",
+		},
+		{
+			name: "Negative Clock Max Skew",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+clock:
+  max_skew: -1
+`,
+			expectError:   true,
+			errorContains: "invalid clock.max_skew",
+		},
+		{
+			name: "Invalid Engine Field Type",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+engines:
+  - name: tekton
+    type: fake
+    fields:
+      - name: namespace
+        type: list
+`,
+			expectError:   true,
+			errorContains: "invalid engines[0] (tekton): invalid fields[0].type",
+		},
+		{
+			name: "Invalid Branding Docs URL",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+branding:
+  service_name: Acme CI
+  tenants:
+    payments:
+      docs_url: docs.acme.example
+`,
+			expectError:   true,
+			errorContains: "invalid branding.tenants[payments].docs_url",
+		},
+		{
+			name: "Quota Concurrency Without Stats",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+quotas:
+  categories:
+    - name: deploy
+      jobs: ["deploy-*"]
+      max_concurrent: 2
+`,
+			expectError:   true,
+			errorContains: "invalid quotas.categories[0].max_concurrent",
+		},
+		{
+			name: "Engine Named Like The Canary",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+monitoring:
+  canary:
+    enabled: true
+engines:
+  - name: canary
+    type: fake
+`,
+			expectError:   true,
+			errorContains: "invalid engines[0].name",
+		},
+		{
+			name: "Invalid Build URLs Mode",
+			configContent: `
+server:
+  build_urls: hide
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid server.build_urls",
+		},
+		{
+			name: "Invalid Jenkins Public URL",
+			configContent: `
+jenkins:
+  url: http://jenkins.ci.svc:8080
+  public_url: ci.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid jenkins.public_url",
+		},
+		{
+			name: "Invalid Schedule Misfire Policy",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+scheduler:
+  schedules:
+    - name: nightly
+      job: nightly-report
+      schedule: "0 2 * * *"
+      misfire: retry
+`,
+			expectError:   true,
+			errorContains: "invalid scheduler.schedules[0]: invalid misfire",
+		},
+		{
+			name: "Invalid Engine Retry Attempts",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+engines:
+  - name: staging
+    type: fake
+    timeout: 120
+    retry:
+      attempts: 20
+`,
+			expectError:   true,
+			errorContains: "invalid engines[0].retry.attempts",
+		},
+		{
+			name: "Invalid Engine Check",
+			configContent: `
+server:
+  engine_check: eager
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid server.engine_check",
+		},
+		{
+			name: "Invalid TCP Listen Address",
 			configContent: `
+server:
+  listen: ["tcp:8080"]
 jenkins:
   url: https://test-jenkins.example.com
   token: test-token
 api:
   keys:
     - test-api-key
+`,
+			expectError:   true,
+			errorContains: "invalid server.listen[0]",
+		},
+		{
+			name: "Fake Jenkins Without URL and Token",
+			configContent: `
+jenkins:
+  fake:
+    build_duration: 5
+api:
+  keys:
+    - test-api-key
 `,
 			expectError: false,
 		},
 		{
-			name: "Missing Jenkins URL",
+			name: "Fake Engine Invalid Failure Rate",
 			configContent: `
 jenkins:
+  url: https://test-jenkins.example.com
   token: test-token
 api:
   keys:
     - test-api-key
+engines:
+  - name: staging
+    type: fake
+    fake:
+      failure_rate: 1.5
 `,
 			expectError:   true,
-			errorContains: "jenkins.url is required",
+			errorContains: "invalid failure_rate",
 		},
 		{
-			name: "Missing Jenkins Token",
+			name: "Invalid Change Pattern",
 			configContent: `
 jenkins:
   url: https://test-jenkins.example.com
+  token: test-token
 api:
   keys:
     - test-api-key
+change:
+  pattern: "CHG-[0-9"
 `,
 			expectError:   true,
-			errorContains: "jenkins.token is required",
+			errorContains: "invalid change.pattern",
 		},
 		{
-			name: "Invalid Jenkins URL",
+			name: "Archive Enabled Without Bucket",
 			configContent: `
 jenkins:
-  url: "://invalid-url"
+  url: https://test-jenkins.example.com
   token: test-token
 api:
   keys:
     - test-api-key
+archive:
+  enabled: true
+  s3:
+    endpoint: http://minio:9000
+    access_key_id: id
+    secret_access_key: secret
 `,
 			expectError:   true,
-			errorContains: "invalid jenkins.url",
+			errorContains: "archive.s3.bucket is required",
 		},
 		{
-			name: "Missing API Keys",
+			name: "Alerts without notifiers",
 			configContent: `
 jenkins:
   url: https://test-jenkins.example.com
   token: test-token
 api:
-  keys: []
+  keys:
+    - test-api-key
+alerts:
+  enabled: true
 `,
 			expectError:   true,
-			errorContains: "at least one api.key is required",
+			errorContains: "alerts.notifiers is required",
 		},
 		{
-			name: "Invalid Port",
+			name: "Key expiry reminders without notifiers",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  clients:
+    - name: ci
+      key: ci-key
+      expires_at: 2030-01-01T00:00:00Z
+      owner: ci-team@example.com
+  expiry_reminder:
+    days: 14
+`,
+			expectError:   true,
+			errorContains: "api.expiry_reminder.notifiers is required",
+		},
+		{
+			name: "Key expiry reminders with notifiers",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  clients:
+    - name: ci
+      key: ci-key
+      expires_at: 2030-01-01T00:00:00Z
+  expiry_reminder:
+    days: 14
+    notifiers:
+      - type: slack
+        url: https://hooks.slack.com/services/T000/B000/XXX
+`,
+			expectError: false,
+		},
+		{
+			name: "Grafana annotations with invalid URL",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+grafana:
+  url: grafana.example.com
+  token: glsa_token
+`,
+			expectError:   true,
+			errorContains: "invalid grafana.url",
+		},
+		{
+			name: "Incidents without a routing key",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+incidents:
+  provider: pagerduty
+  jobs:
+    - pattern: deploy-*
+`,
+			expectError:   true,
+			errorContains: "incidents.routing_key is required",
+		},
+		{
+			name: "Incidents with invalid severity",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+incidents:
+  provider: opsgenie
+  api_key: genie-key
+  jobs:
+    - pattern: deploy-*
+      severity: urgent
+`,
+			expectError:   true,
+			errorContains: "invalid incidents.jobs[0].severity",
+		},
+		{
+			name: "Alerts with invalid failure rate",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+alerts:
+  enabled: true
+  failure_rate: 1.5
+  notifiers:
+    - type: webhook
+      url: https://alerts.example.com/hook
+`,
+			expectError:   true,
+			errorContains: "invalid alerts.failure_rate",
+		},
+		{
+			name: "Alerts with unknown notifier type",
+			configContent: `
+jenkins:
+  url: https://test-jenkins.example.com
+  token: test-token
+api:
+  keys:
+    - test-api-key
+alerts:
+  enabled: true
+  notifiers:
+    - type: pager
+      url: https://alerts.example.com/hook
+`,
+			expectError:   true,
+			errorContains: "invalid alerts.notifiers[0].type",
+		},
+		{
+			name: "Unknown key",
 			configContent: `
 server:
-  port: 70000
+  alowed_origins:
+    - https://example.com
 jenkins:
   url: https://test-jenkins.example.com
   token: test-token
@@ -274,7 +1199,7 @@ api:
     - test-api-key
 `,
 			expectError:   true,
-			errorContains: "invalid server.port",
+			errorContains: "field alowed_origins not found",
 		},
 	}
 
@@ -611,3 +1536,219 @@ func TestConfigLoadInvalidYAML(t *testing.T) {
 		t.Error("Expected error loading invalid YAML, got nil")
 	}
 }
+
+func TestConfigMasked(t *testing.T) {
+	cfg := &config.Config{
+		Jenkins: config.JenkinsConfig{
+			URL:               "https://jenkins.example.com",
+			Username:          "admin",
+			Token:             "jenkins-secret",
+			Headers:           map[string]string{"X-Proxy-Auth": "proxy-secret"},
+			NotificationToken: "notification-secret",
+		},
+		API: config.APIConfig{
+			Keys:    []string{"key-secret"},
+			Clients: []config.APIClientConfig{{Name: "ci", Key: "client-secret"}},
+		},
+		Monitoring: config.MonitoringConfig{Token: "monitoring-secret"},
+	}
+
+	masked := cfg.Masked()
+	encoded := fmt.Sprintf("%+v", *masked)
+	for _, secret := range []string{"jenkins-secret", "proxy-secret", "key-secret", "client-secret", "monitoring-secret", "notification-secret"} {
+		if strings.Contains(encoded, secret) {
+			t.Errorf("Masked config still contains %q: %s", secret, encoded)
+		}
+	}
+	if masked.Jenkins.Username != "admin" || masked.API.Clients[0].Name != "ci" {
+		t.Errorf("Masked config changed non-secret fields: %+v", masked)
+	}
+	if masked.Archive.S3.SecretAccessKey != "" {
+		t.Errorf("Unset secrets should stay empty, got %q", masked.Archive.S3.SecretAccessKey)
+	}
+
+	// The original config must be left untouched
+	if cfg.API.Keys[0] != "key-secret" || cfg.Jenkins.Headers["X-Proxy-Auth"] != "proxy-secret" {
+		t.Error("Masked modified the original config")
+	}
+}
+
+// writeConfigFiles writes name -> content files into a temp directory and returns the directory
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestConfigIncludesAndOverlays(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"jenkins.yaml": `
+jenkins:
+  url: https://included-jenkins.example.com
+  token: included-token
+  timeout: 10
+`,
+		"keys.yaml": `
+api:
+  keys:
+    - included-key
+`,
+		"config.yaml": `
+include: [jenkins.yaml, keys.yaml]
+server:
+  port: 8081
+jenkins:
+  timeout: 20
+overrides:
+  production:
+    server:
+      port: 9443
+    jenkins:
+      url: https://prod-jenkins.example.com
+`,
+	})
+
+	// Without an environment the overlay is ignored and the main file wins over includes
+	t.Setenv("TRIGGERMESH_ENV", "")
+	cfg, err := config.Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Server.Port != 8081 {
+		t.Errorf("Expected port 8081, got %d", cfg.Server.Port)
+	}
+	if cfg.Jenkins.URL != "https://included-jenkins.example.com" || cfg.Jenkins.Token != "included-token" {
+		t.Errorf("Expected Jenkins settings from include, got %+v", cfg.Jenkins)
+	}
+	if cfg.Jenkins.Timeout != 20 {
+		t.Errorf("Expected main file timeout 20 over included 10, got %d", cfg.Jenkins.Timeout)
+	}
+	if len(cfg.API.Keys) != 1 || cfg.API.Keys[0] != "included-key" {
+		t.Errorf("Expected included API key, got %v", cfg.API.Keys)
+	}
+
+	// The production overlay is applied on top
+	t.Setenv("TRIGGERMESH_ENV", "production")
+	cfg, err = config.Load(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Server.Port != 9443 {
+		t.Errorf("Expected overlay port 9443, got %d", cfg.Server.Port)
+	}
+	if cfg.Jenkins.URL != "https://prod-jenkins.example.com" || cfg.Jenkins.Token != "included-token" {
+		t.Errorf("Expected overlay URL with included token, got %+v", cfg.Jenkins)
+	}
+}
+
+func TestConfigIncludeErrors(t *testing.T) {
+	t.Setenv("TRIGGERMESH_ENV", "")
+
+	tests := []struct {
+		name          string
+		files         map[string]string
+		errorContains string
+	}{
+		{
+			name: "Include cycle",
+			files: map[string]string{
+				"config.yaml": "include: [a.yaml]\n",
+				"a.yaml":      "include: [config.yaml]\n",
+			},
+			errorContains: "include cycle",
+		},
+		{
+			name: "Missing include",
+			files: map[string]string{
+				"config.yaml": "include: [missing.yaml]\n",
+			},
+			errorContains: "failed to read included config",
+		},
+		{
+			name: "Unknown key in include",
+			files: map[string]string{
+				"config.yaml": "include: [a.yaml]\n" + testMinimalConfigContent,
+				"a.yaml":      "jenkins:\n  tokn: typo\n",
+			},
+			errorContains: "field tokn not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, tt.files)
+			_, err := config.Load(filepath.Join(dir, "config.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+				t.Errorf("Expected error containing %q, got %v", tt.errorContains, err)
+			}
+		})
+	}
+}
+
+// swapConfigData atomically points dir/..data at a new directory holding config.yaml,
+// the way the kubelet updates mounted ConfigMap and Secret volumes
+func swapConfigData(t *testing.T, dir, version, content string) {
+	t.Helper()
+	dataDir := filepath.Join(dir, "..data_"+version)
+	if err := os.Mkdir(dataDir, 0o700); err != nil {
+		t.Fatalf("Failed to create data dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "config.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	tmpLink := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(filepath.Base(dataDir), tmpLink); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	if err := os.Rename(tmpLink, filepath.Join(dir, "..data")); err != nil {
+		t.Fatalf("Failed to swap symlink: %v", err)
+	}
+}
+
+func TestConfigWatcherReloadsOnSymlinkSwap(t *testing.T) {
+	t.Setenv("TRIGGERMESH_ENV", "")
+	dir := t.TempDir()
+	swapConfigData(t, dir, "1", testMinimalConfigContent)
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.Symlink(filepath.Join("..data", "config.yaml"), configPath); err != nil {
+		t.Fatalf("Failed to link config: %v", err)
+	}
+
+	var reloaded *config.Config
+	watcher, err := config.NewWatcher(configPath, time.Second, func(cfg *config.Config) {
+		reloaded = cfg
+	})
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+
+	// No change since the baseline
+	if changed, err := watcher.RunOnce(); err != nil || changed {
+		t.Fatalf("Expected no change, got changed=%v err=%v", changed, err)
+	}
+
+	// A rotated key arrives through an atomic symlink swap
+	swapConfigData(t, dir, "2", strings.Replace(testMinimalConfigContent, "test-api-key", "rotated-key", 1))
+	changed, err := watcher.RunOnce()
+	if err != nil || !changed {
+		t.Fatalf("Expected a reload, got changed=%v err=%v", changed, err)
+	}
+	if reloaded == nil || len(reloaded.API.Keys) != 1 || reloaded.API.Keys[0] != "rotated-key" {
+		t.Fatalf("Expected reloaded config with rotated key, got %+v", reloaded)
+	}
+
+	// An invalid update is reported and the previous config is kept
+	reloaded = nil
+	swapConfigData(t, dir, "3", "jenkins:\n  tokn: typo\n")
+	if _, err := watcher.RunOnce(); err == nil {
+		t.Error("Expected an error for an invalid config")
+	}
+	if reloaded != nil {
+		t.Error("Invalid config should not be applied")
+	}
+}
@@ -0,0 +1,175 @@
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine/jenkins"
+	"triggermesh/internal/storage/models"
+)
+
+// getAuditPage requests a page of audit logs and returns the IDs and the next cursor
+func getAuditPage(t *testing.T, handler *handlers.AuditHandler, query string) ([]int64, string) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	handler.GetAuditLogs(rr, httptest.NewRequest("GET", "/api/v1/audit?"+query, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var logs []models.AuditLog
+	if err := json.Unmarshal(rr.Body.Bytes(), &logs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	ids := make([]int64, len(logs))
+	for i, log := range logs {
+		ids[i] = log.ID
+	}
+	return ids, rr.Header().Get("X-Next-Cursor")
+}
+
+func TestAuditLogsCursorPagination(t *testing.T) {
+	cleanup := setupStatsStorage(t)
+	defer cleanup()
+
+	insertSummaryAuditLogs(t, time.Now(), "deploy", "success", "success", "failed", "success", "denied")
+	handler := handlers.NewAuditHandler()
+
+	first, cursor := getAuditPage(t, handler, "limit=2")
+	if fmt.Sprint(first) != "[5 4]" || cursor == "" {
+		t.Fatalf("Unexpected first page %v (cursor %q)", first, cursor)
+	}
+
+	// New entries do not shift the following pages
+	insertSummaryAuditLogs(t, time.Now(), "deploy", "success")
+	second, cursor := getAuditPage(t, handler, "limit=2&after="+cursor)
+	if fmt.Sprint(second) != "[3 2]" || cursor == "" {
+		t.Fatalf("Unexpected second page %v (cursor %q)", second, cursor)
+	}
+	last, cursor := getAuditPage(t, handler, "limit=2&after="+cursor)
+	if fmt.Sprint(last) != "[1]" || cursor != "" {
+		t.Errorf("Expected a last page without cursor, got %v (cursor %q)", last, cursor)
+	}
+
+	// Cursors combine with filters
+	insertSummaryAuditLogs(t, time.Now(), "build", "success")
+	if _, cursor = getAuditPage(t, handler, "limit=1"); cursor == "" {
+		t.Fatal("Expected a cursor")
+	}
+	if ids, _ := getAuditPage(t, handler, "limit=1&label=team:web&after="+cursor); len(ids) != 0 {
+		t.Errorf("Expected no entries with the label, got %v", ids)
+	}
+
+	for _, query := range []string{"after=not-a-cursor", "after=djE6MA", "offset=10&after=" + cursor} {
+		rr := httptest.NewRecorder()
+		handler.GetAuditLogs(rr, httptest.NewRequest("GET", "/api/v1/audit?"+query, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", query, rr.Code)
+		}
+	}
+}
+
+// newPagedBuildsJenkins serves a job whose builds have the given numbers, newest first,
+// answering lastBuild and build list range requests
+func newPagedBuildsJenkins(t *testing.T, numbers []int64, requests *[]string) *httptest.Server {
+	t.Helper()
+	rangePattern := regexp.MustCompile(`^(builds|allBuilds)\[[a-z,]+\]\{(\d+),(\d+)\}$`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tree := r.URL.Query().Get("tree")
+		*requests = append(*requests, tree)
+		if tree == "lastBuild[number]" {
+			fmt.Fprintf(w, `{"lastBuild":{"number":%d}}`, numbers[0])
+			return
+		}
+		match := rangePattern.FindStringSubmatch(tree)
+		if match == nil {
+			t.Errorf("Unexpected tree query: %s", tree)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		from, _ := strconv.Atoi(match[2])
+		to, _ := strconv.Atoi(match[3])
+		var builds []string
+		for i := from; i < to && i < len(numbers); i++ {
+			builds = append(builds, fmt.Sprintf(`{"number":%d,"result":"SUCCESS","duration":1000,"timestamp":1767258000000}`, numbers[i]))
+		}
+		fmt.Fprintf(w, `{"%s":[%s]}`, match[1], strings.Join(builds, ","))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestJenkinsListBuildsBefore(t *testing.T) {
+	// Builds 18 and 19 were deleted, moving older builds two positions up
+	numbers := []int64{20, 17, 16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1}
+	var requests []string
+	server := newPagedBuildsJenkins(t, numbers, &requests)
+	trigger := jenkins.NewTrigger(jenkins.NewClient(config.JenkinsConfig{URL: server.URL, Username: "u", Token: "t", Timeout: 5}))
+
+	tests := []struct {
+		before   int64
+		limit    int
+		expected string
+	}{
+		{17, 3, "[16 15 14]"},
+		{10, 4, "[9 8 7 6]"},
+		{10, 1, "[9]"}, // Past the deleted builds, found by moving the window back
+		{3, 5, "[2 1]"},
+		{1, 5, "[]"},
+		{25, 2, "[20 17]"},
+	}
+	for _, tt := range tests {
+		builds, err := trigger.ListBuildsBefore("deploy", tt.before, tt.limit)
+		if err != nil {
+			t.Fatalf("Failed to list builds before %d: %v", tt.before, err)
+		}
+		got := make([]int64, len(builds))
+		for i, build := range builds {
+			got[i] = build.Number
+		}
+		if fmt.Sprint(got) != tt.expected {
+			t.Errorf("Expected builds %s before %d, got %v (requests %v)", tt.expected, tt.before, got, requests)
+		}
+		requests = nil
+	}
+
+	t.Run("Build history endpoint", func(t *testing.T) {
+		handler := handlers.NewJenkinsHandler(trigger)
+		var cursor string
+		var pages []string
+		for i := 0; i < 10; i++ {
+			path := "/api/v1/jenkins/jobs/deploy/builds?limit=7"
+			if cursor != "" {
+				path += "&after=" + cursor
+			}
+			rr := httptest.NewRecorder()
+			handler.ListJenkinsJobBuilds(rr, httptest.NewRequest("GET", path, nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			var resp handlers.BuildHistoryResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			got := make([]int64, len(resp.Builds))
+			for j, build := range resp.Builds {
+				got[j] = build.Number
+			}
+			pages = append(pages, fmt.Sprint(got))
+			if cursor = resp.NextCursor; cursor == "" {
+				break
+			}
+		}
+		if strings.Join(pages, " ") != "[20 17 16 15 14 13 12] [11 10 9 8 7 6 5] [4 3 2 1]" {
+			t.Errorf("Unexpected pages: %v", pages)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStrictRequestBodyDecoding(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 4096
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	deep := strings.Repeat(`{"a":`, 40) + "1" + strings.Repeat("}", 40)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+		reason string
+		line   int
+		column int
+	}{
+		{"unknown field", `{"job":"deploy","paramters":{}}`, http.StatusBadRequest, "INVALID_REQUEST_BODY", `unknown field "paramters"`, 1, 17},
+		{"syntax error", "{\n  \"job\": \"deploy\",\n  \"parameters\": {,}\n}", http.StatusBadRequest, "INVALID_REQUEST_BODY", "invalid character ','", 3, 18},
+		{"wrong type", `{"job":"deploy","parameters":{"N":1}}`, http.StatusBadRequest, "INVALID_REQUEST_BODY", `field "parameters.N": cannot use number as string`, 1, 35},
+		{"trailing data", `{"job":"deploy"} {"job":"other"}`, http.StatusBadRequest, "INVALID_REQUEST_BODY", "unexpected data after the JSON value", 1, 0},
+		{"too deep", `{"job":"deploy","labels":` + deep + `}`, http.StatusBadRequest, "INVALID_REQUEST_BODY", "nesting exceeds 32 levels", 1, 0},
+		{"empty", ``, http.StatusBadRequest, "INVALID_REQUEST_BODY", "request body is empty", 0, 0},
+		{"too large", `{"job":"` + strings.Repeat("x", 5000) + `"}`, http.StatusRequestEntityTooLarge, "REQUEST_BODY_TOO_LARGE", "", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/trigger/jenkins", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer test-key")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+			var resp struct {
+				Code   string `json:"code"`
+				Reason string `json:"reason"`
+				Line   int    `json:"line"`
+				Column int    `json:"column"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if resp.Code != tt.code || !strings.HasPrefix(resp.Reason, tt.reason) {
+				t.Errorf("Unexpected error: %s", rr.Body.String())
+			}
+			if tt.line > 0 && resp.Line != tt.line {
+				t.Errorf("Expected line %d, got %d", tt.line, resp.Line)
+			}
+			if tt.column > 0 && resp.Column != tt.column {
+				t.Errorf("Expected column %d, got %d", tt.column, resp.Column)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package unit
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/email"
+	"triggermesh/internal/storage/models"
+)
+
+// smtpMessage is a message received by the fake SMTP server
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// startSMTPServer runs a minimal plaintext SMTP server that passes received messages to the channel
+func startSMTPServer(t *testing.T, messages chan<- smtpMessage) (string, int) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, messages)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func serveSMTP(conn net.Conn, messages chan<- smtpMessage) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	var message smtpMessage
+	reply("220 localhost ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			message.from = strings.Trim(strings.TrimPrefix(command, "MAIL FROM:"), "<>")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			message.to = append(message.to, strings.Trim(strings.TrimPrefix(command, "RCPT TO:"), "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			message.data = data.String()
+			reply("250 OK")
+			messages <- message
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// receiveEmail waits for the next message and returns it with its subject and decoded parts by content type
+func receiveEmail(t *testing.T, messages <-chan smtpMessage) (smtpMessage, string, map[string]string) {
+	t.Helper()
+	var message smtpMessage
+	select {
+	case message = <-messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the email")
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(message.data))
+	if err != nil {
+		t.Fatalf("Failed to parse email: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("Failed to decode subject: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("Failed to parse content type: %v", err)
+	}
+	parts := make(map[string]string)
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read email part: %v", err)
+		}
+		content, _ := io.ReadAll(part)
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[mediaType] = string(content)
+	}
+	return message, subject, parts
+}
+
+func newTestMailer(t *testing.T, templatesDir string, jobs []string) (*email.Mailer, chan smtpMessage) {
+	messages := make(chan smtpMessage, 4)
+	host, port := startSMTPServer(t, messages)
+	mailer, err := email.NewMailer(config.EmailConfig{
+		Host:         host,
+		Port:         port,
+		TLS:          config.EmailTLSNone,
+		From:         "TriggerMesh <triggermesh@example.com>",
+		To:           []string{"ops@example.com", "Release Team <release@example.com>"},
+		Events:       []string{config.EmailEventBuildCompleted, config.EmailEventKeyRequested},
+		Jobs:         jobs,
+		TemplatesDir: templatesDir,
+		Timeout:      5,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create mailer: %v", err)
+	}
+	return mailer, messages
+}
+
+func TestEmailBuildCompleted(t *testing.T) {
+	mailer, messages := newTestMailer(t, "", []string{"deploy-*"})
+
+	finished := time.Date(2026, 3, 1, 12, 5, 0, 0, time.UTC)
+	outcome := models.BuildOutcome{Result: "FAILURE", DurationMS: 300000, FinishedAt: finished}
+	mailer.BuildCompleted(models.TrackedBuild{BuildID: "build-web/1", JobName: "build-web", Engine: "jenkins"}, outcome)
+	mailer.BuildCompleted(models.TrackedBuild{BuildID: "deploy-web/9", JobName: "deploy-web", Engine: "jenkins", TriggeredAt: finished.Add(-5 * time.Minute)}, outcome)
+
+	message, subject, parts := receiveEmail(t, messages)
+	if message.from != "triggermesh@example.com" || strings.Join(message.to, ",") != "ops@example.com,release@example.com" {
+		t.Errorf("Unexpected envelope: from %s to %v", message.from, message.to)
+	}
+	if subject != "[TriggerMesh] deploy-web finished: FAILURE" {
+		t.Errorf("Unexpected subject: %q", subject)
+	}
+	if !strings.Contains(parts["text/plain"], "Build deploy-web/9 of deploy-web on jenkins finished with result FAILURE.") ||
+		!strings.Contains(parts["text/plain"], "Duration:  5m0s") {
+		t.Errorf("Unexpected text body: %s", parts["text/plain"])
+	}
+	if !strings.Contains(parts["text/html"], "<b>deploy-web/9</b>") {
+		t.Errorf("Unexpected HTML body: %s", parts["text/html"])
+	}
+	select {
+	case extra := <-messages:
+		t.Errorf("Unexpected email for a job outside the patterns: %s", extra.data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEmailKeyRequestedWithTemplateOverride(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "key_requested.subject.tmpl"), []byte("Approve {{.Name}} for {{.RequestedBy}}"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	mailer, messages := newTestMailer(t, dir, nil)
+
+	mailer.KeyRequested(models.KeyRequest{ID: 7, Name: "deploy-bot", Jobs: []string{"deploy-*"}, Reason: "<CD> pipeline", RequestedBy: "dev"})
+
+	_, subject, parts := receiveEmail(t, messages)
+	if subject != "Approve deploy-bot for dev" {
+		t.Errorf("Expected the subject template override, got %q", subject)
+	}
+	if !strings.Contains(parts["text/plain"], "Jobs:   deploy-*") || !strings.Contains(parts["text/plain"], "/api/v1/keys/requests/7/approve") {
+		t.Errorf("Unexpected text body: %s", parts["text/plain"])
+	}
+	if !strings.Contains(parts["text/html"], "&lt;CD&gt; pipeline") {
+		t.Errorf("Expected HTML escaping in the HTML body: %s", parts["text/html"])
+	}
+}
+
+func TestEmailConfigValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		email         string
+		errorContains string
+	}{
+		{"missing recipients", "host: smtp.example.com\n    from: triggermesh@example.com", "notifications.email.to is required"},
+		{"invalid sender", "host: smtp.example.com\n    from: not-an-address\n    to: [ops@example.com]", "invalid notifications.email.from"},
+		{"auth without TLS", "host: smtp.example.com\n    tls: none\n    username: bot\n    from: triggermesh@example.com\n    to: [ops@example.com]", "requires tls or starttls"},
+		{"unknown event", "host: smtp.example.com\n    from: triggermesh@example.com\n    to: [ops@example.com]\n    events: [build_started]", "invalid notifications.email.events[0]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			content := "jenkins:\n  url: https://test-jenkins.example.com\n  token: test-token\napi:\n  keys: [test-api-key]\nnotifications:\n  email:\n    " + tt.email + "\n"
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+			_, err := config.Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.errorContains) {
+				t.Errorf("Expected error containing %q, got %v", tt.errorContains, err)
+			}
+		})
+	}
+
+	// The default port follows the TLS mode
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "jenkins:\n  url: https://test-jenkins.example.com\n  token: test-token\napi:\n  keys: [test-api-key]\nnotifications:\n  email:\n    host: smtp.example.com\n    tls: tls\n    from: triggermesh@example.com\n    to: [ops@example.com]\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Notifications.Email.Port != 465 || len(cfg.Notifications.Email.Events) != 2 {
+		t.Errorf("Unexpected email defaults: %+v", cfg.Notifications.Email)
+	}
+}
@@ -0,0 +1,187 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/resilience"
+)
+
+func TestGuardRetries(t *testing.T) {
+	guard := resilience.NewGuard("tekton", config.RetryConfig{Attempts: 3, Backoff: 1}, config.BreakerConfig{})
+
+	calls := 0
+	failing := func(kind engine.ErrorKind) func() (*engine.BuildResult, error) {
+		calls = 0
+		return func() (*engine.BuildResult, error) {
+			calls++
+			return nil, engine.NewError(kind, "failed")
+		}
+	}
+
+	// Server errors are retried for triggers and status requests alike
+	if _, err := guard.Trigger(failing(engine.ErrorKindServer)); err == nil || calls != 3 {
+		t.Errorf("Expected 3 trigger attempts after server errors, got %d (%v)", calls, err)
+	}
+	if _, err := guard.Status(failing(engine.ErrorKindServer)); err == nil || calls != 3 {
+		t.Errorf("Expected 3 status attempts after server errors, got %d (%v)", calls, err)
+	}
+
+	// A trigger that timed out may have started a build, so only status requests are retried
+	if _, err := guard.Trigger(failing(engine.ErrorKindTimeout)); err == nil || calls != 1 {
+		t.Errorf("Expected 1 trigger attempt after a timeout, got %d", calls)
+	}
+	if _, err := guard.Status(failing(engine.ErrorKindTimeout)); err == nil || calls != 3 {
+		t.Errorf("Expected 3 status attempts after timeouts, got %d", calls)
+	}
+
+	// Rejections are not retried
+	if _, err := guard.Status(failing(engine.ErrorKindNotFound)); !errors.Is(err, engine.ErrJobNotFound) || calls != 1 {
+		t.Errorf("Expected 1 attempt for an unknown build, got %d (%v)", calls, err)
+	}
+
+	// A retry that succeeds returns its result
+	calls = 0
+	result, err := guard.Trigger(func() (*engine.BuildResult, error) {
+		calls++
+		if calls == 1 {
+			return nil, engine.NewError(engine.ErrorKindServer, "failed")
+		}
+		return &engine.BuildResult{Success: true, BuildID: "run-1"}, nil
+	})
+	if err != nil || result.BuildID != "run-1" || calls != 2 {
+		t.Errorf("Expected the second attempt to succeed, got %+v after %d calls (%v)", result, calls, err)
+	}
+
+	// A nil guard calls the engine once
+	var none *resilience.Guard
+	if _, err := none.Trigger(failing(engine.ErrorKindServer)); err == nil || calls != 1 {
+		t.Errorf("Expected 1 attempt without a guard, got %d", calls)
+	}
+}
+
+func TestGuardCircuitBreaker(t *testing.T) {
+	guard := resilience.NewGuard("tekton", config.RetryConfig{Attempts: 1}, config.BreakerConfig{FailureThreshold: 2, OpenDuration: 1})
+
+	calls := 0
+	var failure error
+	call := func() (*engine.BuildResult, error) {
+		calls++
+		if failure != nil {
+			return nil, failure
+		}
+		return &engine.BuildResult{Success: true}, nil
+	}
+
+	// Rejections do not count as failures
+	failure = engine.NewError(engine.ErrorKindAuth, "denied")
+	for i := 0; i < 3; i++ {
+		guard.Trigger(call)
+	}
+	if guard.Open() {
+		t.Fatal("Expected authentication failures not to open the breaker")
+	}
+
+	failure = engine.NewError(engine.ErrorKindServer, "failed")
+	guard.Trigger(call)
+	if guard.Open() {
+		t.Fatal("Expected the breaker to stay closed below the threshold")
+	}
+	guard.Trigger(call)
+	if !guard.Open() {
+		t.Fatal("Expected the breaker to open at the threshold")
+	}
+
+	// Calls fail at once while the breaker is open
+	calls = 0
+	failure = nil
+	if _, err := guard.Status(call); !errors.Is(err, engine.ErrCircuitOpen) || calls != 0 {
+		t.Fatalf("Expected the call to fail without reaching the engine, got %d calls (%v)", calls, err)
+	}
+
+	// A failed trial call reopens the breaker, a successful one closes it
+	time.Sleep(1100 * time.Millisecond)
+	failure = engine.NewError(engine.ErrorKindTimeout, "timed out")
+	guard.Trigger(call)
+	if calls != 1 || !guard.Open() {
+		t.Fatalf("Expected a failed trial call to reopen the breaker, got %d calls", calls)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	failure = nil
+	if _, err := guard.Trigger(call); err != nil || guard.Open() {
+		t.Fatalf("Expected a successful trial call to close the breaker: %v", err)
+	}
+}
+
+func TestEngineCircuitBreaker(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 1024 * 1024
+	cfg.Engines = []config.EngineConfig{{
+		Name:    "tekton",
+		Type:    config.EngineTypeHTTP,
+		Retry:   config.RetryConfig{Attempts: 2},
+		Breaker: config.BreakerConfig{FailureThreshold: 2, OpenDuration: 60},
+	}}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	calls := 0
+	router.AddEngine("tekton", config.EngineTypeHTTP, &MockCIEngine{TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+		calls++
+		return nil, engine.NewError(engine.ErrorKindServer, "tekton server error")
+	}})
+	// Engines without configuration are called directly
+	otherCalls := 0
+	router.AddEngine("gitlab", "custom", &MockCIEngine{TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+		otherCalls++
+		return nil, engine.NewError(engine.ErrorKindServer, "gitlab server error")
+	}})
+
+	trigger := func(name string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/trigger/"+name, bytes.NewReader([]byte(`{"job":"build-web"}`)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer test-key")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// The retried trigger fails twice, which opens the breaker
+	if rr := trigger("tekton"); rr.Code != http.StatusBadGateway || calls != 2 {
+		t.Fatalf("Expected status 502 after 2 attempts, got %d after %d: %s", rr.Code, calls, rr.Body.String())
+	}
+	rr := trigger("tekton")
+	if rr.Code != http.StatusServiceUnavailable || calls != 2 {
+		t.Fatalf("Expected status 503 without calling the engine, got %d after %d calls", rr.Code, calls)
+	}
+	if !strings.Contains(rr.Body.String(), `"code":"ENGINE_CIRCUIT_OPEN"`) {
+		t.Errorf("Expected ENGINE_CIRCUIT_OPEN, got %s", rr.Body.String())
+	}
+
+	// The breakers of engines are independent
+	for i := 0; i < 3; i++ {
+		trigger("gitlab")
+	}
+	if otherCalls != 3 {
+		t.Errorf("Expected every gitlab trigger to reach the engine once, got %d calls", otherCalls)
+	}
+
+	var metrics bytes.Buffer
+	if _, err := router.Metrics().WriteTo(&metrics); err != nil {
+		t.Fatalf("Failed to write metrics: %v", err)
+	}
+	body := metrics.String()
+	if !strings.Contains(body, `triggermesh_engine_circuit_open{engine="tekton"} 1`) {
+		t.Errorf("Expected the tekton breaker to be reported open, got:\n%s", body)
+	}
+	if !strings.Contains(body, `triggermesh_engine_retries_total{engine="tekton"} 1`) {
+		t.Errorf("Expected one tekton retry, got:\n%s", body)
+	}
+}
@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/engine/fake"
+)
+
+func TestFakeEngine(t *testing.T) {
+	e := fake.New(config.FakeEngineConfig{})
+
+	for i, want := range []string{"deploy/1", "deploy/2"} {
+		result, err := e.TriggerBuild("deploy", map[string]string{"run": "x"})
+		if err != nil || !result.Success || result.BuildID != want {
+			t.Fatalf("Trigger %d: expected build %s, got %+v, %v", i, want, result, err)
+		}
+	}
+	if result, _ := e.TriggerBuild("folder/test", nil); result.BuildID != "folder/test/1" {
+		t.Errorf("Expected builds numbered per job, got %s", result.BuildID)
+	}
+
+	status, err := e.GetBuildStatus("deploy/2")
+	if err != nil || status.Building || status.Result != engine.ResultSuccess {
+		t.Errorf("Expected a finished successful build, got %+v, %v", status, err)
+	}
+	if _, err := e.GetBuildStatus("deploy/3"); !errors.Is(err, engine.ErrJobNotFound) {
+		t.Errorf("Expected not found for an unknown build, got %v", err)
+	}
+	if _, err := e.GetBuildStatus("deploy"); err == nil {
+		t.Error("Expected an error for a build ID without a number")
+	}
+	if err := e.Ping(); err != nil {
+		t.Errorf("Expected ping to succeed, got %v", err)
+	}
+}
+
+func TestFakeEngineSimulation(t *testing.T) {
+	t.Run("Trigger failures", func(t *testing.T) {
+		e := fake.New(config.FakeEngineConfig{FailureRate: 1})
+		result, err := e.TriggerBuild("deploy", nil)
+		if !errors.Is(err, engine.ErrServer) || result.Success {
+			t.Errorf("Expected a server error, got %+v, %v", result, err)
+		}
+	})
+
+	t.Run("Build failures and duration", func(t *testing.T) {
+		e := fake.New(config.FakeEngineConfig{BuildDuration: 60, BuildFailureRate: 1})
+		result, _ := e.TriggerBuild("deploy", nil)
+		status, err := e.GetBuildStatus(result.BuildID)
+		if err != nil || !status.Building || status.Result != "" {
+			t.Errorf("Expected a running build, got %+v, %v", status, err)
+		}
+
+		e = fake.New(config.FakeEngineConfig{BuildFailureRate: 1})
+		result, _ = e.TriggerBuild("deploy", nil)
+		if status, _ := e.GetBuildStatus(result.BuildID); status.Result != engine.ResultFailure {
+			t.Errorf("Expected a failed build, got %+v", status)
+		}
+	})
+
+	t.Run("Latency", func(t *testing.T) {
+		e := fake.New(config.FakeEngineConfig{Latency: 20, Jitter: 10})
+		started := time.Now()
+		if _, err := e.TriggerBuild("deploy", nil); err != nil {
+			t.Fatalf("Failed to trigger: %v", err)
+		}
+		if elapsed := time.Since(started); elapsed < 20*time.Millisecond {
+			t.Errorf("Expected at least 20ms latency, took %v", elapsed)
+		}
+	})
+
+	t.Run("Equal seeds give equal outcomes", func(t *testing.T) {
+		cfg := config.FakeEngineConfig{FailureRate: 0.5, BuildFailureRate: 0.5, Seed: 7}
+		outcomes := func() []string {
+			e := fake.New(cfg)
+			var out []string
+			for i := 0; i < 20; i++ {
+				result, err := e.TriggerBuild("deploy", nil)
+				if err != nil {
+					out = append(out, "error")
+					continue
+				}
+				status, _ := e.GetBuildStatus(result.BuildID)
+				out = append(out, result.BuildID+":"+status.Result)
+			}
+			return out
+		}
+		first, second := outcomes(), outcomes()
+		for i := range first {
+			if first[i] != second[i] {
+				t.Fatalf("Outcome %d differs between runs: %s vs %s", i, first[i], second[i])
+			}
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine/jenkins"
+)
+
+// sortedKeys returns the keys of a decoded JSON object in order
+func sortedKeys(object map[string]interface{}) string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func TestAuditLogsFieldSelection(t *testing.T) {
+	cleanup := setupStatsStorage(t)
+	defer cleanup()
+
+	insertSummaryAuditLogs(t, time.Now(), "deploy", "success", "failed")
+	handler := handlers.NewAuditHandler()
+
+	rr := httptest.NewRecorder()
+	handler.GetAuditLogs(rr, httptest.NewRequest("GET", "/api/v1/audit?fields=id,job_name,result,id", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var logs []map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &logs); err != nil || len(logs) != 2 {
+		t.Fatalf("Expected two audit logs, got %s (err %v)", rr.Body.String(), err)
+	}
+	for _, log := range logs {
+		if keys := sortedKeys(log); keys != "id,job_name,result" {
+			t.Errorf("Expected only the selected fields, got %s", keys)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	handler.GetAuditLogs(rr, httptest.NewRequest("GET", "/api/v1/audit?fields=id,api_secret", nil))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "Unknown field 'api_secret'") {
+		t.Errorf("Expected status 400 for an unknown field, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Fields that are never encoded cannot be selected
+	rr = httptest.NewRecorder()
+	handler.GetAuditLogs(rr, httptest.NewRequest("GET", "/api/v1/audit?fields=FullParams", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a field that is not encoded, got %d", rr.Code)
+	}
+}
+
+func TestBuildHistoryFieldSelection(t *testing.T) {
+	var requests int32
+	server := newBuildHistoryJenkins(t, &requests)
+	trigger := jenkins.NewTrigger(jenkins.NewClient(config.JenkinsConfig{URL: server.URL, Username: "u", Token: "t", Timeout: 5}))
+	handler := handlers.NewJenkinsHandler(trigger)
+
+	rr := httptest.NewRecorder()
+	handler.ListJenkinsJobBuilds(rr, httptest.NewRequest("GET", "/api/v1/jenkins/jobs/team-a/deploy/builds?limit=2&fields=number,result", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Job    string                   `json:"job"`
+		Builds []map[string]interface{} `json:"builds"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || len(resp.Builds) != 2 {
+		t.Fatalf("Expected two builds, got %s (err %v)", rr.Body.String(), err)
+	}
+	// The running build has no result, which stays omitted
+	if keys := sortedKeys(resp.Builds[0]); resp.Job != "team-a/deploy" || keys != "number" {
+		t.Errorf("Unexpected running build: %s %v", resp.Job, resp.Builds[0])
+	}
+	if keys := sortedKeys(resp.Builds[1]); keys != "number,result" || resp.Builds[1]["result"] != "FAILURE" {
+		t.Errorf("Unexpected finished build: %v", resp.Builds[1])
+	}
+}
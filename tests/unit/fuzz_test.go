@@ -0,0 +1,236 @@
+package unit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/engine/jenkins"
+	"triggermesh/internal/signature"
+	"triggermesh/internal/storage"
+)
+
+// The fuzz targets run their seed corpus with the other tests; `make fuzz` explores beyond it
+
+// Job names and parameter keys that may reach an engine, as documented for the trigger API
+var (
+	fuzzJobNameRegex      = regexp.MustCompile(`^[a-zA-Z0-9_/\- ]{1,255}$`)
+	fuzzParameterKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`)
+)
+
+func FuzzTriggerRequestValidation(f *testing.F) {
+	tmpFile, err := os.CreateTemp("", "test-fuzz-trigger-*.db")
+	if err != nil {
+		f.Fatalf("Failed to create temp file: %v", err)
+	}
+	tmpFile.Close()
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		f.Fatalf("Failed to init storage: %v", err)
+	}
+	f.Cleanup(func() {
+		storage.Close()
+		os.Remove(tmpFile.Name())
+	})
+
+	for _, seed := range []string{
+		`{"job":"deploy","parameters":{"BRANCH":"main"}}`,
+		`{"job":"team/deploy","parameters":{"a.b":"@cred:deploy-key"},"labels":{"team":"web"}}`,
+		`{"job":"deploy","parameters":{"..":"x",".a":"y"}}`,
+		`{"job":"../../script","parameters":{}}`,
+		`{"job":"deploy\u0000","parameters":{"K":"v"}}`,
+		`{"job":"deploy","not_before":"2030-01-01T00:00:00Z","deadline":"2029-01-01T00:00:00Z"}`,
+		`{"job":"deploy","change_ref":"CHG-1","parameters":{"K":"@cred:"}}`,
+		`{"job":"","parameters":null}`,
+		`{"job":"deploy"} {"job":"other"}`,
+		`{"job":`,
+		``,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		var triggeredJob string
+		var triggeredParams map[string]string
+		handler := handlers.NewJenkinsHandler(&MockCIEngine{
+			TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+				triggeredJob, triggeredParams = jobName, params
+				return &engine.BuildResult{Success: true, BuildID: "deploy/1"}, nil
+			},
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/trigger/jenkins", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		ctx := context.WithValue(req.Context(), middleware.APIKeyContextKey, "test-api-key")
+		ctx = context.WithValue(ctx, middleware.RequestIDContextKey, "fuzz")
+		rr := httptest.NewRecorder()
+		handler.TriggerJenkinsBuild(rr, req.WithContext(ctx))
+
+		if rr.Code >= 500 {
+			t.Fatalf("Body %q: unexpected status %d: %s", body, rr.Code, rr.Body.String())
+		}
+		if triggeredJob == "" {
+			return
+		}
+		// Whatever reaches the engine must have passed validation
+		if !fuzzJobNameRegex.MatchString(triggeredJob) {
+			t.Fatalf("Body %q: invalid job name %q reached the engine", body, triggeredJob)
+		}
+		if len(triggeredParams) > 100 {
+			t.Fatalf("Body %q: %d parameters reached the engine", body, len(triggeredParams))
+		}
+		for key, value := range triggeredParams {
+			if engine.IsCredentialRef(value) {
+				continue // Resolved by the engine
+			}
+			if len(key) > 255 || !fuzzParameterKeyRegex.MatchString(key) || len(value) > 10240 {
+				t.Fatalf("Body %q: invalid parameter %q=%q reached the engine", body, key, value)
+			}
+		}
+	})
+}
+
+// locationTransport answers Jenkins build requests with a 201 and the given Location header
+type locationTransport struct {
+	location string
+}
+
+func (lt locationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp := &http.Response{
+		StatusCode: http.StatusNotFound,
+		Header:     make(http.Header),
+		Body:       http.NoBody,
+		Request:    req,
+	}
+	if strings.HasSuffix(req.URL.Path, "/build") {
+		resp.StatusCode = http.StatusCreated
+		resp.Header["Location"] = []string{lt.location}
+	}
+	return resp, nil
+}
+
+func FuzzJenkinsBuildLocation(f *testing.F) {
+	for _, seed := range []string{
+		"/job/deploy/5/",
+		"http://jenkins.example.com/job/deploy/5/",
+		"/job/deploy/5/console",
+		"https://ci.example.com/jenkins/job/deploy/5/",
+		"/job/team/job/deploy/5/",
+		"/job/my%20job/5/",
+		"/job/%zz/5/",
+		"/queue/item/123/",
+		"http://jenkins.example.com/queue/item/123",
+		"/queue/item/-1/",
+		"/job/deploy/99999999999999999999999/",
+		"/job/deploy/",
+		"/job//",
+		"job/",
+		"http://[::1",
+		"",
+	} {
+		f.Add(seed)
+	}
+
+	const baseURL = "http://jenkins.test"
+	f.Fuzz(func(t *testing.T, location string) {
+		client := jenkins.NewClient(config.JenkinsConfig{URL: baseURL, Username: "user", Token: "token", Timeout: 5})
+		client.SetTransport(locationTransport{location: location})
+		result, err := jenkins.NewTrigger(client).TriggerBuild("deploy", nil)
+		if err != nil {
+			t.Fatalf("Location %q: unexpected error: %v", location, err)
+		}
+
+		if result.BuildID != "" {
+			name, number, ok := strings.Cut(result.BuildID, "/")
+			if !ok || name == "" || !isDigits(number) {
+				t.Errorf("Location %q: build ID %q is not <job>/<number>", location, result.BuildID)
+			}
+		}
+		if result.QueueID != "" && !isDigits(result.QueueID) {
+			t.Errorf("Location %q: queue ID %q is not numeric", location, result.QueueID)
+		}
+		if result.BuildURL != "" && !strings.HasPrefix(result.BuildURL, baseURL+"/") {
+			t.Errorf("Location %q: build URL %q is outside the Jenkins URL", location, result.BuildURL)
+		}
+	})
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func FuzzSignatureVerify(f *testing.F) {
+	const secret = "fuzz-secret"
+	now := time.Unix(1700000000, 0)
+
+	body := []byte(`{"job":"deploy"}`)
+	f.Add(signature.Sign(secret, now, body), body)
+	f.Add(signature.Sign(secret, now.Add(-time.Hour), body), body)
+	f.Add(signature.Sign("other-secret", now, body), body)
+	f.Add("t=1700000000,v1=", body)
+	f.Add("t=+1700000000,v1=00,v1=", body)
+	f.Add("v1=abc", []byte{})
+	f.Add("t=,=,", []byte(nil))
+	f.Add("", body)
+
+	f.Fuzz(func(t *testing.T, header string, body []byte) {
+		err := signature.Verify(header, secret, body, now, 5*time.Minute)
+		if err != nil {
+			for _, known := range []error{signature.ErrMissing, signature.ErrMalformed, signature.ErrExpired, signature.ErrMismatch} {
+				if errors.Is(err, known) {
+					return
+				}
+			}
+			t.Fatalf("Header %q: unexpected error %v", header, err)
+		}
+
+		// Only a header carrying the HMAC of one of its timestamps and the body may pass
+		var timestamps, signatures []string
+		for _, part := range strings.Split(header, ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch key {
+			case "t":
+				timestamps = append(timestamps, value)
+			case "v1":
+				signatures = append(signatures, value)
+			}
+		}
+		for _, ts := range timestamps {
+			if seconds, err := strconv.ParseInt(ts, 10, 64); err != nil || now.Sub(time.Unix(seconds, 0)).Abs() > 5*time.Minute {
+				continue
+			}
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write([]byte(ts + "."))
+			mac.Write(body)
+			expected := hex.EncodeToString(mac.Sum(nil))
+			for _, sig := range signatures {
+				if sig == expected {
+					return
+				}
+			}
+		}
+		t.Fatalf("Header %q passed verification without the body's signature", header)
+	})
+}
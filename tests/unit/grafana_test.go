@@ -0,0 +1,150 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/grafana"
+	"triggermesh/internal/stats"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// newGrafanaServer returns a fake Grafana that passes received annotations to the channel
+func newGrafanaServer(t *testing.T, annotations chan<- grafana.Annotation) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/annotations" || r.Header.Get("Authorization") != "Bearer grafana-token" {
+			t.Errorf("Unexpected annotation request: %s %s (Authorization %q)", r.Method, r.URL.Path, r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var annotation grafana.Annotation
+		if err := json.NewDecoder(r.Body).Decode(&annotation); err != nil {
+			t.Errorf("Failed to decode annotation: %v", err)
+		}
+		w.Write([]byte(`{"id":1,"message":"Annotation added"}`))
+		annotations <- annotation
+	}))
+}
+
+// receiveAnnotation waits for the next annotation posted to the fake Grafana
+func receiveAnnotation(t *testing.T, annotations <-chan grafana.Annotation) grafana.Annotation {
+	t.Helper()
+	select {
+	case annotation := <-annotations:
+		return annotation
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the Grafana annotation")
+		return grafana.Annotation{}
+	}
+}
+
+func TestGrafanaAnnotatesTriggers(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-grafana-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	annotations := make(chan grafana.Annotation, 4)
+	server := newGrafanaServer(t, annotations)
+	defer server.Close()
+
+	annotator := grafana.NewAnnotator(config.GrafanaConfig{
+		URL:          server.URL + "/",
+		Token:        "grafana-token",
+		Jobs:         []string{"deploy-*"},
+		DashboardUID: "releases",
+		Tags:         []string{"prod"},
+	}, nil)
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			return &engine.BuildResult{Success: true, BuildID: jobName + "/7", Message: "Build triggered successfully"}, nil
+		},
+	})
+	handler.SetGrafanaAnnotator(annotator)
+
+	trigger := func(job string) {
+		body, _ := json.Marshal(handlers.TriggerJenkinsBuildRequest{Job: job})
+		rr := httptest.NewRecorder()
+		handler.TriggerJenkinsBuild(rr, httptest.NewRequest(http.MethodPost, "/api/v1/trigger/jenkins", bytes.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+	}
+
+	// Jobs outside the configured patterns are not annotated
+	trigger("build-web")
+	trigger("deploy-web")
+
+	annotation := receiveAnnotation(t, annotations)
+	if annotation.Text != "Triggered deploy-web on jenkins (build deploy-web/7)" {
+		t.Errorf("Unexpected annotation text: %q", annotation.Text)
+	}
+	if annotation.DashboardUID != "releases" || annotation.Time == 0 || annotation.TimeEnd != 0 {
+		t.Errorf("Unexpected annotation: %+v", annotation)
+	}
+	if tags := strings.Join(annotation.Tags, ","); tags != "triggermesh,triggered,engine:jenkins,job:deploy-web,prod" {
+		t.Errorf("Unexpected annotation tags: %s", tags)
+	}
+	select {
+	case extra := <-annotations:
+		t.Errorf("Unexpected annotation for a job outside the patterns: %+v", extra)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestGrafanaAnnotatesCompletedBuilds(t *testing.T) {
+	cleanup := setupStatsStorage(t)
+	defer cleanup()
+
+	annotations := make(chan grafana.Annotation, 4)
+	server := newGrafanaServer(t, annotations)
+	defer server.Close()
+
+	registry := engine.NewRegistry()
+	if err := registry.Register("jenkins", &MockCIEngine{
+		GetBuildStatusFunc: func(buildID string) (*engine.BuildResult, error) {
+			return &engine.BuildResult{Success: true, Result: engine.ResultFailure, BuildDurationMS: 60000}, nil
+		},
+	}); err != nil {
+		t.Fatalf("Failed to register engine: %v", err)
+	}
+
+	triggeredAt := time.Now().Add(-time.Minute)
+	if err := storage.TrackBuild(models.TrackedBuild{BuildID: "deploy-api/3", JobName: "deploy-api", Engine: "jenkins", TriggeredAt: triggeredAt}); err != nil {
+		t.Fatalf("Failed to track build: %v", err)
+	}
+
+	poller := stats.NewPoller(config.StatsConfig{PollInterval: 1}, registry)
+	poller.OnComplete(grafana.NewAnnotator(config.GrafanaConfig{URL: server.URL, Token: "grafana-token"}, nil).Completed)
+	if recorded, err := poller.RunOnce(context.Background()); err != nil || recorded != 1 {
+		t.Fatalf("Expected 1 outcome recorded, got %d (%v)", recorded, err)
+	}
+
+	annotation := receiveAnnotation(t, annotations)
+	if annotation.Text != "deploy-api on jenkins finished: FAILURE (build deploy-api/3)" {
+		t.Errorf("Unexpected annotation text: %q", annotation.Text)
+	}
+	if annotation.Time != triggeredAt.UnixMilli() || annotation.TimeEnd < annotation.Time {
+		t.Errorf("Expected a region from the trigger to the completion, got %d-%d", annotation.Time, annotation.TimeEnd)
+	}
+	if tags := strings.Join(annotation.Tags, ","); tags != "triggermesh,completed,engine:jenkins,job:deploy-api,result:failure" {
+		t.Errorf("Unexpected annotation tags: %s", tags)
+	}
+}
@@ -0,0 +1,211 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/engine/httpengine"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/ulid"
+)
+
+// newRESTCIServer returns a REST CI system that starts pipelines and reports their state
+func newRESTCIServer(t *testing.T, received *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "ci-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/projects/web/pipelines":
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, received); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"pipeline": {"id": 77, "web_url": "https://ci.example.com/p/77"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/pipelines/77":
+			_, _ = w.Write([]byte(`{"status": "passed"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/pipelines/78":
+			_, _ = w.Write([]byte(`{"status": "running"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// restCIEngineConfig describes newRESTCIServer as a generic HTTP engine
+func restCIEngineConfig(url string) config.HTTPEngineConfig {
+	return config.HTTPEngineConfig{
+		Timeout:    5,
+		AuthHeader: "X-Token",
+		Token:      "ci-secret",
+		Trigger: config.HTTPTriggerConfig{
+			Method:       http.MethodPost,
+			URL:          url + "/projects/{{urlquery .Job}}/pipelines",
+			Body:         `{"ref": {{json .Params.BRANCH}}, "variables": {{json .Params}}}`,
+			BuildIDPath:  "$.pipeline.id",
+			BuildURLPath: "$.pipeline.web_url",
+		},
+		Status: config.HTTPStatusConfig{
+			Method:    http.MethodGet,
+			URL:       url + "/pipelines/{{.BuildID}}",
+			StatePath: "$.status",
+			Results:   map[string]string{"passed": engine.ResultSuccess, "failed": engine.ResultFailure},
+		},
+	}
+}
+
+func TestHTTPEngine(t *testing.T) {
+	var received map[string]interface{}
+	server := newRESTCIServer(t, &received)
+	e, err := httpengine.New("restci", restCIEngineConfig(server.URL), nil)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	result, err := e.TriggerBuild("web", map[string]string{"BRANCH": "main"})
+	if err != nil {
+		t.Fatalf("Failed to trigger build: %v", err)
+	}
+	if result.BuildID != "77" || result.BuildURL != "https://ci.example.com/p/77" {
+		t.Errorf("Unexpected trigger result: %+v", result)
+	}
+	if received["ref"] != "main" {
+		t.Errorf("Expected rendered body with ref main, got %v", received)
+	}
+
+	status, err := e.GetBuildStatus("77")
+	if err != nil || status.Building || status.Result != engine.ResultSuccess {
+		t.Errorf("Expected finished successful build, got %+v (err %v)", status, err)
+	}
+	status, err = e.GetBuildStatus("78")
+	if err != nil || !status.Building || status.Result != "" {
+		t.Errorf("Expected running build, got %+v (err %v)", status, err)
+	}
+
+	if _, err := e.TriggerBuild("api", nil); !errors.Is(err, engine.ErrJobNotFound) {
+		t.Errorf("Expected job not found, got %v", err)
+	}
+
+	cfg := restCIEngineConfig(server.URL)
+	cfg.Token = "wrong"
+	unauthorized, err := httpengine.New("restci", cfg, nil)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if _, err := unauthorized.TriggerBuild("web", nil); !errors.Is(err, engine.ErrAuth) {
+		t.Errorf("Expected auth error, got %v", err)
+	}
+}
+
+func TestEngineRoutes(t *testing.T) {
+	var received map[string]interface{}
+	server := newRESTCIServer(t, &received)
+	e, err := httpengine.New("restci", restCIEngineConfig(server.URL), nil)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 1024 * 1024
+	cfg.API.Clients = []config.APIClientConfig{{Name: "team-b", Key: "team-b-key", Jobs: []string{"api"}}}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+	router.AddEngine("restci", config.EngineTypeHTTP, e)
+
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/api/v1/trigger/restci", "test-key", `{"job":"web","parameters":{"BRANCH":"main"}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || resp["build_id"] != "77" {
+		t.Fatalf("Expected build 77, got %s", rr.Body.String())
+	}
+	globalBuildID, _ := resp["global_build_id"].(string)
+	if !ulid.Valid(globalBuildID) {
+		t.Fatalf("Expected a global build ID, got %s", rr.Body.String())
+	}
+
+	logs, err := storage.GetAuditLogs(1, 0)
+	if err != nil || len(logs) != 1 || logs[0].Engine != "restci" || logs[0].BuildID != "77" || logs[0].GlobalBuildID != globalBuildID {
+		t.Fatalf("Expected audit entry for the restci engine, got %+v (err %v)", logs, err)
+	}
+
+	// The global build ID resolves to the engine build without naming the engine
+	rr = do(http.MethodGet, "/api/v1/builds/"+strings.ToLower(globalBuildID), "test-key", "")
+	var status handlers.BuildStatusResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil || rr.Code != http.StatusOK ||
+		status.Engine != "restci" || status.BuildID != "77" || status.Job != "web" || status.GlobalBuildID != globalBuildID {
+		t.Errorf("Expected build status by global build ID, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr = do(http.MethodGet, "/api/v1/builds/"+globalBuildID, "team-b-key", ""); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for a global build ID of another job, got %d", rr.Code)
+	}
+	if rr = do(http.MethodGet, "/api/v1/builds/"+ulid.New(), "test-key", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown global build ID, got %d", rr.Code)
+	}
+	if rr = do(http.MethodGet, "/api/v1/builds/77", "test-key", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a malformed global build ID, got %d", rr.Code)
+	}
+
+	rr = do(http.MethodGet, "/api/v1/engines/restci/builds/77", "test-key", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"result":"SUCCESS"`) {
+		t.Errorf("Expected build status, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// The restricted key may not see a build of a job outside its patterns
+	rr = do(http.MethodGet, "/api/v1/engines/restci/builds/77", "team-b-key", "")
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = do(http.MethodPost, "/api/v1/trigger/unknown", "test-key", `{"job":"web"}`)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown engine, got %d", rr.Code)
+	}
+
+	rr = do(http.MethodPost, "/api/v1/trigger/restci", "test-key", `{"job":"web","not_before":"2099-01-01T00:00:00Z"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for scheduling on another engine, got %d", rr.Code)
+	}
+
+	rr = do(http.MethodGet, "/api/v1/engines", "test-key", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 listing engines, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var engines handlers.EnginesResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &engines); err != nil || len(engines.Engines) != 2 {
+		t.Fatalf("Expected Jenkins and restci, got %s", rr.Body.String())
+	}
+	if engines.Engines[0].Name != "jenkins" || !slices.Contains(engines.Engines[0].Operations, handlers.OperationSchedule) {
+		t.Errorf("Expected Jenkins first with scheduling, got %+v", engines.Engines[0])
+	}
+	restci := engines.Engines[1]
+	if restci.Name != "restci" || restci.Type != config.EngineTypeHTTP || restci.TriggerPath != "/api/v1/trigger/restci" ||
+		strings.Join(restci.Operations, ",") != "trigger,status" {
+		t.Errorf("Unexpected restci engine: %+v", restci)
+	}
+}
@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/i18n"
+)
+
+func TestNegotiateLanguage(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", "en"},
+		{"zh", "zh"},
+		{"zh-CN,zh;q=0.9,en;q=0.8", "zh"},
+		{"en-US,en;q=0.9,zh;q=0.8", "en"},
+		{"fr-FR,zh;q=0.5", "zh"},
+		{"fr-FR", "en"},
+		{"zh;q=0", "en"},
+		{"de, *;q=0.1", "en"},
+	}
+	for _, tt := range tests {
+		if got := i18n.Negotiate(tt.header); got != tt.expected {
+			t.Errorf("Negotiate(%q) = %q, expected %q", tt.header, got, tt.expected)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		message  string
+		expected string
+	}{
+		{"Exact message", "zh", "Method not allowed", "不允许的请求方法"},
+		{"Formatted message", "zh", "API key is not allowed to trigger job 'prod/deploy'", "API 密钥无权触发任务“prod/deploy”"},
+		{"Numeric argument", "zh", "Maximum 20 labels allowed", "最多允许 20 个标签"},
+		{"Most specific template", "zh", "Failed to trigger build: engine request timed out", "触发构建失败：引擎请求超时"},
+		{"Engine message passed through", "zh", "Failed to trigger build: job not found", "触发构建失败：job not found"},
+		{"Unknown message", "zh", "Something new", "Something new"},
+		{"Default language", "en", "Method not allowed", "Method not allowed"},
+		{"Unsupported language", "fr", "Method not allowed", "Method not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := i18n.Translate(tt.language, tt.message); got != tt.expected {
+				t.Errorf("Translate(%q, %q) = %q, expected %q", tt.language, tt.message, got, tt.expected)
+			}
+		})
+	}
+
+	if languages := i18n.Languages(); len(languages) < 2 || languages[0] != i18n.DefaultLanguage {
+		t.Errorf("Expected the default language and at least one catalog, got %v", languages)
+	}
+}
+
+func TestLocalizedErrorResponse(t *testing.T) {
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{})
+
+	for _, accept := range []string{"application/json", "application/problem+json"} {
+		t.Run(accept, func(t *testing.T) {
+			req := newLabelTriggerRequest(`{"job":"deploy","labels":{"":"x"}}`)
+			req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9")
+			req.Header.Set("Accept", accept)
+			rr := httptest.NewRecorder()
+			handler.TriggerJenkinsBuild(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if rr.Header().Get("Content-Language") != "zh" {
+				t.Errorf("Expected Content-Language zh, got %q", rr.Header().Get("Content-Language"))
+			}
+			var resp map[string]interface{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			field := "error"
+			if accept == "application/problem+json" {
+				field = "detail"
+			}
+			if resp[field] != "标签名不能为空" {
+				t.Errorf("Expected translated message, got %v", resp[field])
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/incident"
+)
+
+// incidentRequest is a request received by a fake incident provider
+type incidentRequest struct {
+	path   string
+	auth   string
+	fields map[string]interface{}
+}
+
+// newIncidentServer returns a fake incident provider that passes received requests to the channel
+func newIncidentServer(t *testing.T, requests chan<- incidentRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var fields map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+			t.Errorf("Failed to decode incident request: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		requests <- incidentRequest{path: r.URL.RequestURI(), auth: r.Header.Get("Authorization"), fields: fields}
+	}))
+}
+
+// receiveIncidentRequest waits for the next request sent to the fake provider
+func receiveIncidentRequest(t *testing.T, requests <-chan incidentRequest) incidentRequest {
+	t.Helper()
+	select {
+	case request := <-requests:
+		return request
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the incident request")
+		return incidentRequest{}
+	}
+}
+
+func TestPagerDutyIncidentLifecycle(t *testing.T) {
+	requests := make(chan incidentRequest, 4)
+	server := newIncidentServer(t, requests)
+	defer server.Close()
+
+	cfg := config.IncidentsConfig{
+		Provider:         config.IncidentProviderPagerDuty,
+		RoutingKey:       "routing-key",
+		URL:              server.URL,
+		FailureThreshold: 2,
+		Jobs: []config.IncidentJobConfig{
+			{Pattern: "deploy-*", Severity: config.SeverityError},
+		},
+	}
+	provider, err := incident.NewProvider(cfg, nil)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	manager := incident.NewManager(cfg, provider)
+	defer manager.Close()
+
+	// Jobs outside the critical patterns never open incidents
+	for i := 0; i < 3; i++ {
+		manager.Record("jenkins", "build-web", errors.New("jenkins unreachable"))
+	}
+	manager.Record("jenkins", "deploy-web", errors.New("jenkins unreachable"))
+	manager.Record("jenkins", "deploy-web", errors.New("jenkins unreachable"))
+	manager.Record("jenkins", "deploy-web", errors.New("jenkins unreachable"))
+
+	request := receiveIncidentRequest(t, requests)
+	payload, _ := request.fields["payload"].(map[string]interface{})
+	if request.path != "/v2/enqueue" || request.fields["event_action"] != "trigger" || request.fields["routing_key"] != "routing-key" {
+		t.Fatalf("Unexpected trigger event: %s %v", request.path, request.fields)
+	}
+	if request.fields["dedup_key"] != "triggermesh/jenkins/deploy-web" || payload["severity"] != "error" || payload["component"] != "deploy-web" {
+		t.Errorf("Unexpected trigger event: %v", request.fields)
+	}
+
+	// The next success resolves the incident, once
+	manager.Record("jenkins", "deploy-web", nil)
+	manager.Record("jenkins", "deploy-web", nil)
+	request = receiveIncidentRequest(t, requests)
+	if request.fields["event_action"] != "resolve" || request.fields["dedup_key"] != "triggermesh/jenkins/deploy-web" {
+		t.Errorf("Unexpected resolve event: %v", request.fields)
+	}
+	select {
+	case extra := <-requests:
+		t.Errorf("Unexpected incident request: %v", extra.fields)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestOpsgenieIncidentLifecycle(t *testing.T) {
+	requests := make(chan incidentRequest, 4)
+	server := newIncidentServer(t, requests)
+	defer server.Close()
+
+	cfg := config.IncidentsConfig{
+		Provider:         config.IncidentProviderOpsgenie,
+		APIKey:           "genie-key",
+		URL:              server.URL,
+		FailureThreshold: 1,
+		Jobs:             []config.IncidentJobConfig{{Pattern: "*", Severity: config.SeverityCritical}},
+	}
+	provider, err := incident.NewProvider(cfg, nil)
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	manager := incident.NewManager(cfg, provider)
+	defer manager.Close()
+
+	manager.Record("jenkins", "deploy-api", errors.New("queue full"))
+	request := receiveIncidentRequest(t, requests)
+	if request.path != "/v2/alerts" || request.auth != "GenieKey genie-key" {
+		t.Fatalf("Unexpected create request: %s (Authorization %q)", request.path, request.auth)
+	}
+	if request.fields["alias"] != "triggermesh/jenkins/deploy-api" || request.fields["priority"] != "P1" || request.fields["description"] != "Last error: queue full" {
+		t.Errorf("Unexpected create request: %v", request.fields)
+	}
+
+	manager.Record("jenkins", "deploy-api", nil)
+	request = receiveIncidentRequest(t, requests)
+	if request.path != "/v2/alerts/triggermesh%2Fjenkins%2Fdeploy-api/close?identifierType=alias" {
+		t.Errorf("Unexpected close request: %s", request.path)
+	}
+}
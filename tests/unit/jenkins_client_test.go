@@ -2,13 +2,16 @@ package unit
 
 import (
 	"encoding/base64"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
 	"triggermesh/internal/engine/jenkins"
+	"triggermesh/internal/version"
 )
 
 const crumbIssuerPath = "/crumbIssuer/api/json"
@@ -125,6 +128,101 @@ func TestTriggerBuild_WithParams(t *testing.T) {
 	}
 }
 
+func TestTriggerBuild_WithCause(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == crumbIssuerPath {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"crumb":"test-crumb","crumbRequestField":"Jenkins-Crumb"}`))
+			return
+		}
+		queries = append(queries, r.URL.Path+"?"+r.URL.RawQuery)
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("Failed to parse form: %v", err)
+		}
+		if r.URL.Path == "/job/test-job/buildWithParameters" && r.PostForm.Get("param1") != "value1" {
+			t.Errorf("Expected param1=value1 in the form, got %v", r.PostForm)
+		}
+		w.Header().Set("Location", "http://jenkins.example.com/job/test-job/102/")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	trigger := jenkins.NewTrigger(jenkins.NewClient(config.JenkinsConfig{
+		URL:        server.URL,
+		Username:   "user",
+		Token:      "token",
+		Timeout:    5,
+		BuildToken: "job-token",
+	}))
+	cause := engine.Cause{Actor: "ci", RequestID: "req-1"}
+	if _, err := trigger.TriggerBuildWithCause("test-job", map[string]string{"param1": "value1"}, cause); err != nil {
+		t.Fatalf("Failed to trigger build: %v", err)
+	}
+	if _, err := trigger.TriggerBuildWithCause("test-job", nil, engine.Cause{Actor: "key:0123abcd"}); err != nil {
+		t.Fatalf("Failed to trigger build: %v", err)
+	}
+
+	expected := []string{
+		"/job/test-job/buildWithParameters?cause=Started+by+TriggerMesh+on+behalf+of+ci+%28request+req-1%29&token=job-token",
+		"/job/test-job/build?cause=Started+by+TriggerMesh+on+behalf+of+key%3A0123abcd&token=job-token",
+	}
+	if strings.Join(queries, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected build requests:\n%s", strings.Join(queries, "\n"))
+	}
+}
+
+func TestTriggerBuild_CredentialReference(t *testing.T) {
+	var form map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/job/deploy/buildWithParameters" {
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("Failed to parse form: %v", err)
+			}
+			form = map[string]string{"SSH_KEY": r.FormValue("SSH_KEY"), "ENV": r.FormValue("ENV")}
+			w.Header().Set("Location", "http://jenkins.example.com/queue/item/5/")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	trigger := jenkins.NewTrigger(jenkins.NewClient(config.JenkinsConfig{URL: server.URL, Username: "user", Token: "token", Timeout: 5}))
+
+	params := map[string]string{"SSH_KEY": "@cred:deploy-key", "ENV": "prod"}
+	if _, err := trigger.TriggerBuild("deploy", params); err != nil {
+		t.Fatalf("Failed to trigger build: %v", err)
+	}
+	if form["SSH_KEY"] != "deploy-key" || form["ENV"] != "prod" {
+		t.Errorf("Expected credential ID forwarded as the parameter value, got %v", form)
+	}
+	if params["SSH_KEY"] != "@cred:deploy-key" {
+		t.Errorf("Expected caller parameters to be left unchanged, got %v", params)
+	}
+}
+
+func TestParseCredentialRef(t *testing.T) {
+	tests := []struct {
+		value string
+		id    string
+		ok    bool
+	}{
+		{"@cred:deploy-key", "deploy-key", true},
+		{"@cred:1b2c3d4e-aaaa-bbbb-cccc-1234567890ab", "1b2c3d4e-aaaa-bbbb-cccc-1234567890ab", true},
+		{"@cred:", "", false},
+		{"@cred:bad key", "", false},
+		{"@cred:../secret", "", false},
+		{"plain-value", "", false},
+	}
+	for _, tt := range tests {
+		id, ok := engine.ParseCredentialRef(tt.value)
+		if id != tt.id || ok != tt.ok {
+			t.Errorf("ParseCredentialRef(%q) = %q, %v; expected %q, %v", tt.value, id, ok, tt.id, tt.ok)
+		}
+	}
+}
+
 func TestTriggerBuild_Failure(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == crumbIssuerPath {
@@ -232,3 +330,279 @@ func TestGetBuildStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestTriggerBuild_TypedErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		expectedErr  error
+		expectedKind engine.ErrorKind
+	}{
+		{"Job Not Found", http.StatusNotFound, engine.ErrJobNotFound, engine.ErrorKindNotFound},
+		{"Unauthorized", http.StatusUnauthorized, engine.ErrAuth, engine.ErrorKindAuth},
+		{"Forbidden", http.StatusForbidden, engine.ErrAuth, engine.ErrorKindAuth},
+		{"Server Error", http.StatusServiceUnavailable, engine.ErrServer, engine.ErrorKindServer},
+		{"Gateway Timeout", http.StatusGatewayTimeout, engine.ErrTimeout, engine.ErrorKindTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == crumbIssuerPath {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client := jenkins.NewClient(config.JenkinsConfig{
+				URL:      server.URL,
+				Username: "user",
+				Token:    "token",
+				Timeout:  5,
+			})
+			trigger := jenkins.NewTrigger(client)
+
+			_, err := trigger.TriggerBuild("test-job", map[string]string{"param": "value"})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if kind := engine.Classify(err); kind != tt.expectedKind {
+				t.Errorf("Expected kind %s, got %s", tt.expectedKind, kind)
+			}
+		})
+	}
+}
+
+func TestClientSendsUserAgentAndExtraHeaders(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if ua := r.Header.Get("User-Agent"); ua != "triggermesh/"+version.Version {
+			t.Errorf("Expected User-Agent triggermesh/%s on %s, got %q", version.Version, r.URL.Path, ua)
+		}
+		if proxy := r.Header.Get("X-Proxy-Token"); proxy != "secret" {
+			t.Errorf("Expected X-Proxy-Token header on %s, got %q", r.URL.Path, proxy)
+		}
+		if r.URL.Path == crumbIssuerPath {
+			w.Write([]byte(`{"crumb":"test-crumb","crumbRequestField":"Jenkins-Crumb"}`))
+			return
+		}
+		w.Header().Set("Location", "/job/test-job/7/")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := jenkins.NewClient(config.JenkinsConfig{
+		URL:      server.URL,
+		Username: "user",
+		Token:    "token",
+		Timeout:  5,
+		Headers:  map[string]string{"X-Proxy-Token": "secret"},
+	})
+	trigger := jenkins.NewTrigger(client)
+
+	if _, err := trigger.TriggerBuild("test-job", nil); err != nil {
+		t.Fatalf("Failed to trigger build: %v", err)
+	}
+	if _, err := trigger.TriggerBuild("test-job", map[string]string{"param": "value"}); err != nil {
+		t.Fatalf("Failed to trigger parameterized build: %v", err)
+	}
+	if requests != 4 {
+		t.Errorf("Expected 4 requests (2 crumbs, 2 builds), got %d", requests)
+	}
+}
+
+func TestTriggerBuild_LocationVariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string // Path below the test server that the client is configured with
+		location string
+		buildID  string
+		buildURL string // Relative to the configured URL
+		queueID  string
+	}{
+		{"Relative build", "", "/job/deploy/5/", "deploy/5", "/job/deploy/5/", ""},
+		{"Absolute build", "", "http://jenkins.example.com/job/deploy/5/", "deploy/5", "/job/deploy/5/", ""},
+		{"Build without trailing slash", "", "/job/deploy/5", "deploy/5", "/job/deploy/5/", ""},
+		{"Build with trailing segment", "", "/job/deploy/5/console", "deploy/5", "/job/deploy/5/", ""},
+		{"Build below context path", "/jenkins", "https://ci.example.com/jenkins/job/deploy/5/", "deploy/5", "/job/deploy/5/", ""},
+		{"Build below proxy prefix", "", "/ci/jenkins/job/deploy/5/", "deploy/5", "/job/deploy/5/", ""},
+		{"Folder build", "", "/job/team/job/deploy/5/", "deploy/5", "/job/deploy/5/", ""},
+		{"Escaped job name", "", "/job/my%20job/5/", "my job/5", "/job/my%20job/5/", ""},
+		{"Queue item", "", "/queue/item/123/", "", "", "123"},
+		{"Absolute queue item below context path", "/jenkins", "https://ci.example.com/jenkins/queue/item/123/", "", "", "123"},
+		{"Queue item without trailing slash", "", "http://jenkins.example.com/queue/item/123", "", "", "123"},
+		{"Job page", "", "/job/deploy/", "", "/job/deploy/", ""},
+		{"Missing", "", "", "", "/job/deploy/", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == tt.baseURL+"/job/deploy/build" {
+					if tt.location != "" {
+						w.Header().Set("Location", tt.location)
+					}
+					w.WriteHeader(http.StatusCreated)
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			base := server.URL + tt.baseURL
+			trigger := jenkins.NewTrigger(jenkins.NewClient(config.JenkinsConfig{URL: base, Username: "user", Token: "token", Timeout: 5}))
+			result, err := trigger.TriggerBuild("deploy", nil)
+			if err != nil {
+				t.Fatalf("Failed to trigger build: %v", err)
+			}
+			buildURL := ""
+			if tt.buildURL != "" {
+				buildURL = base + tt.buildURL
+			}
+			if result.BuildID != tt.buildID || result.BuildURL != buildURL || result.QueueID != tt.queueID {
+				t.Errorf("Expected build %q, URL %q, queue item %q, got %+v", tt.buildID, buildURL, tt.queueID, result)
+			}
+		})
+	}
+}
+
+func TestPublicURL(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/deploy/build":
+			w.Header().Set("Location", "/job/deploy/5/")
+			w.WriteHeader(http.StatusCreated)
+		case "/job/deploy/5/api/json":
+			w.Write([]byte(`{"number":5,"url":"` + server.URL + `/job/deploy/5/"}`))
+		case "/job/deploy/6/api/json":
+			w.Write([]byte(`{"number":6,"url":"https://jenkins.example.com/job/deploy/6/"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// Requests go to the internal URL; links below it are returned below the public URL
+	trigger := jenkins.NewTrigger(jenkins.NewClient(config.JenkinsConfig{
+		URL: server.URL, PublicURL: "https://ci.example.com/jenkins/", Username: "user", Token: "token", Timeout: 5,
+	}))
+	result, err := trigger.TriggerBuild("deploy", nil)
+	if err != nil {
+		t.Fatalf("Failed to trigger build: %v", err)
+	}
+	if result.BuildURL != "https://ci.example.com/jenkins/job/deploy/5/" {
+		t.Errorf("Expected the public build URL, got %q", result.BuildURL)
+	}
+	status, err := trigger.GetBuildStatus("deploy/5")
+	if err != nil {
+		t.Fatalf("Failed to get build status: %v", err)
+	}
+	if status.BuildURL != "https://ci.example.com/jenkins/job/deploy/5/" {
+		t.Errorf("Expected the public build URL in the status, got %q", status.BuildURL)
+	}
+
+	// Links Jenkins reports under another address are kept
+	status, err = trigger.GetBuildStatus("deploy/6")
+	if err != nil {
+		t.Fatalf("Failed to get build status: %v", err)
+	}
+	if status.BuildURL != "https://jenkins.example.com/job/deploy/6/" {
+		t.Errorf("Expected the reported build URL, got %q", status.BuildURL)
+	}
+}
+
+func TestTriggerBuild_Redirects(t *testing.T) {
+	tests := []struct {
+		name     string
+		redirect func(serverURL string) (int, string) // Response to the first build request
+		queueID  string
+		buildURL string
+		errKind  engine.ErrorKind
+		posts    int // Build requests received
+	}{
+		{
+			name:     "Found to queue item",
+			redirect: func(string) (int, string) { return http.StatusFound, "/queue/item/42/" },
+			queueID:  "42",
+			posts:    1,
+		},
+		{
+			name:     "See other to job page",
+			redirect: func(string) (int, string) { return http.StatusSeeOther, "/job/deploy/" },
+			buildURL: "/job/deploy/",
+			posts:    1,
+		},
+		{
+			name: "Endpoint moved below a prefix",
+			redirect: func(serverURL string) (int, string) {
+				return http.StatusMovedPermanently, serverURL + "/ci/job/deploy/build"
+			},
+			queueID: "43",
+			posts:   2,
+		},
+		{
+			name:     "Login page",
+			redirect: func(string) (int, string) { return http.StatusFound, "/login?from=%2Fjob%2Fdeploy%2Fbuild" },
+			errKind:  engine.ErrorKindAuth,
+			posts:    1,
+		},
+		{
+			name:     "Redirect loop",
+			redirect: func(string) (int, string) { return http.StatusTemporaryRedirect, "/ci/job/deploy/build" },
+			errKind:  engine.ErrorKindUnknown,
+			posts:    4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posts int
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/job/deploy/build") {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				if r.Method != http.MethodPost {
+					t.Errorf("Expected redirected build requests to stay POST, got %s", r.Method)
+				}
+				posts++
+				if r.URL.Path == "/job/deploy/build" || tt.name == "Redirect loop" {
+					status, location := tt.redirect(server.URL)
+					w.Header().Set("Location", location)
+					w.WriteHeader(status)
+					return
+				}
+				w.Header().Set("Location", "/ci/queue/item/43/")
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			trigger := jenkins.NewTrigger(jenkins.NewClient(config.JenkinsConfig{URL: server.URL, Username: "user", Token: "token", Timeout: 5}))
+			result, err := trigger.TriggerBuild("deploy", nil)
+			if posts != tt.posts {
+				t.Errorf("Expected %d build requests, got %d", tt.posts, posts)
+			}
+			if tt.errKind != "" {
+				if err == nil || engine.Classify(err) != tt.errKind {
+					t.Errorf("Expected a %s error, got %v", tt.errKind, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to trigger build: %v", err)
+			}
+			buildURL := ""
+			if tt.buildURL != "" {
+				buildURL = server.URL + tt.buildURL
+			}
+			if result.QueueID != tt.queueID || result.BuildURL != buildURL {
+				t.Errorf("Expected queue item %q and URL %q, got %+v", tt.queueID, buildURL, result)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"triggermesh/internal/api/handlers"
 	"triggermesh/internal/api/middleware"
@@ -198,6 +199,58 @@ func TestTriggerJenkinsBuild(t *testing.T) {
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   "", // Error response is JSON, check separately
 		},
+		{
+			name: "Engine Job Not Found",
+			requestBody: handlers.TriggerJenkinsBuildRequest{
+				Job: "missing-job",
+			},
+			mockEngine: &MockCIEngine{
+				TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+					return nil, engine.NewError(engine.ErrorKindNotFound, "resource not found")
+				},
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "resource not found",
+		},
+		{
+			name: "Engine Auth Failure",
+			requestBody: handlers.TriggerJenkinsBuildRequest{
+				Job: "test-job",
+			},
+			mockEngine: &MockCIEngine{
+				TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+					return nil, engine.NewError(engine.ErrorKindAuth, "authentication failed: invalid credentials")
+				},
+			},
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   "authentication failed",
+		},
+		{
+			name: "Engine Server Error",
+			requestBody: handlers.TriggerJenkinsBuildRequest{
+				Job: "test-job",
+			},
+			mockEngine: &MockCIEngine{
+				TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+					return nil, engine.NewError(engine.ErrorKindServer, "jenkins server error: please try again later")
+				},
+			},
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   "jenkins server error",
+		},
+		{
+			name: "Engine Timeout",
+			requestBody: handlers.TriggerJenkinsBuildRequest{
+				Job: "test-job",
+			},
+			mockEngine: &MockCIEngine{
+				TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+					return nil, context.DeadlineExceeded
+				},
+			},
+			expectedStatus: http.StatusGatewayTimeout,
+			expectedBody:   "timed out",
+		},
 	}
 
 	for _, tt := range tests {
@@ -760,3 +813,381 @@ func TestTriggerJenkinsBuildWithAPIKeyNotInContext(t *testing.T) {
 		t.Errorf("Expected API key 'unknown', got %q", logs[0].APIKey)
 	}
 }
+
+func TestTriggerJenkinsBuildEngineErrorIsSanitized(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-engine-error-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	// Transport errors can contain internal URLs and must not reach the client
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			return &engine.BuildResult{Success: false, Message: "internal details"},
+				errors.New(`Post "http://jenkins.internal:8080/job/test-job/build": connection refused`)
+		},
+	})
+
+	reqBodyBytes, _ := json.Marshal(handlers.TriggerJenkinsBuildRequest{Job: "test-job"})
+	req := httptest.NewRequest("POST", "/api/v1/trigger/jenkins", bytes.NewReader(reqBodyBytes))
+	ctx := context.WithValue(req.Context(), middleware.APIKeyContextKey, "test-api-key")
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	handler.TriggerJenkinsBuild(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rr.Code)
+	}
+	if strings.Contains(rr.Body.String(), "jenkins.internal") || strings.Contains(rr.Body.String(), "internal details") {
+		t.Errorf("Expected engine details to be suppressed, got %s", rr.Body.String())
+	}
+
+	var errorResp map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &errorResp); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	if errorResp["success"] != false {
+		t.Errorf("Expected success false, got %v", errorResp["success"])
+	}
+	if errorResp["kind"] != "unknown" {
+		t.Errorf("Expected kind 'unknown', got %v", errorResp["kind"])
+	}
+
+	// The audit log keeps the full error for operators
+	logs, err := storage.GetAuditLogs(1, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit logs: %v", err)
+	}
+	if len(logs) != 1 || !strings.Contains(logs[0].Error, "connection refused") {
+		t.Errorf("Expected audit log to record engine error, got %+v", logs)
+	}
+}
+
+func TestTriggerJenkinsBuildEngineErrorCodes(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-engine-codes-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	tests := []struct {
+		name           string
+		engineErr      error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"Job Not Found", engine.ErrJobNotFound, http.StatusNotFound, "JOB_NOT_FOUND"},
+		{"Auth", engine.NewError(engine.ErrorKindAuth, "access denied: insufficient permissions"), http.StatusBadGateway, "ENGINE_AUTH_FAILED"},
+		{"Timeout", engine.ErrTimeout, http.StatusGatewayTimeout, "ENGINE_TIMEOUT"},
+		{"Server", engine.ErrServer, http.StatusBadGateway, "ENGINE_UNAVAILABLE"},
+		{"Unknown", errors.New("boom"), http.StatusInternalServerError, "ENGINE_ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := handlers.NewJenkinsHandler(&MockCIEngine{
+				TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+					return nil, tt.engineErr
+				},
+			})
+
+			reqBodyBytes, _ := json.Marshal(handlers.TriggerJenkinsBuildRequest{Job: "test-job"})
+			req := httptest.NewRequest("POST", "/api/v1/trigger/jenkins", bytes.NewReader(reqBodyBytes))
+			rr := httptest.NewRecorder()
+			handler.TriggerJenkinsBuild(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+
+			var errorResp map[string]interface{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &errorResp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+			if errorResp["code"] != tt.expectedCode {
+				t.Errorf("Expected code %s, got %v", tt.expectedCode, errorResp["code"])
+			}
+		})
+	}
+}
+
+func TestTriggerJenkinsBuildProblemDetails(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-problem-details-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			return nil, engine.ErrJobNotFound
+		},
+	})
+
+	tests := []struct {
+		name           string
+		accept         string
+		job            string
+		expectedStatus int
+		expectedType   string
+		problem        bool
+	}{
+		{"Validation Error", "application/problem+json", "", http.StatusBadRequest, "about:blank", true},
+		{"Engine Error", "application/json, application/problem+json;q=0.9", "test-job", http.StatusNotFound, "urn:triggermesh:error:job_not_found", true},
+		{"Refused With q=0", "application/problem+json;q=0", "", http.StatusBadRequest, "", false},
+		{"Plain JSON", "application/json", "", http.StatusBadRequest, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqBodyBytes, _ := json.Marshal(handlers.TriggerJenkinsBuildRequest{Job: tt.job})
+			req := httptest.NewRequest("POST", "/api/v1/trigger/jenkins", bytes.NewReader(reqBodyBytes))
+			req.Header.Set("Accept", tt.accept)
+			ctx := context.WithValue(req.Context(), middleware.RequestIDContextKey, "req-123")
+			req = req.WithContext(ctx)
+
+			rr := httptest.NewRecorder()
+			handler.TriggerJenkinsBuild(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+
+			var resp map[string]interface{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to decode error response: %v", err)
+			}
+
+			if !tt.problem {
+				if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+					t.Errorf("Expected Content-Type application/json, got %q", ct)
+				}
+				if resp["error"] == nil || resp["request_id"] != "req-123" {
+					t.Errorf("Expected legacy error body, got %v", resp)
+				}
+				return
+			}
+
+			if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("Expected Content-Type application/problem+json, got %q", ct)
+			}
+			if resp["type"] != tt.expectedType {
+				t.Errorf("Expected type %q, got %v", tt.expectedType, resp["type"])
+			}
+			if resp["title"] != http.StatusText(tt.expectedStatus) {
+				t.Errorf("Expected title %q, got %v", http.StatusText(tt.expectedStatus), resp["title"])
+			}
+			if resp["status"] != float64(tt.expectedStatus) {
+				t.Errorf("Expected status member %d, got %v", tt.expectedStatus, resp["status"])
+			}
+			if resp["instance"] != "req-123" {
+				t.Errorf("Expected instance req-123, got %v", resp["instance"])
+			}
+			if detail, _ := resp["detail"].(string); detail == "" {
+				t.Error("Expected non-empty detail")
+			}
+			if _, ok := resp["error"]; ok {
+				t.Error("Expected no legacy 'error' member in problem details")
+			}
+		})
+	}
+}
+
+func TestGetJenkinsBuildStatus(t *testing.T) {
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		GetBuildStatusFunc: func(buildID string) (*engine.BuildResult, error) {
+			if buildID == "missing/1" {
+				return nil, engine.ErrJobNotFound
+			}
+			return &engine.BuildResult{Success: true, BuildID: buildID, Message: "Retrieved build status"}, nil
+		},
+	})
+	restricted := &middleware.Principal{Name: "team-a", Jobs: []string{"team-a-*"}}
+
+	tests := []struct {
+		name           string
+		path           string
+		principal      *middleware.Principal
+		expectedStatus int
+	}{
+		{"Success", "/api/v1/jenkins/builds/test-job/42", nil, http.StatusOK},
+		{"Missing Number", "/api/v1/jenkins/builds/test-job", nil, http.StatusBadRequest},
+		{"Non-numeric Number", "/api/v1/jenkins/builds/test-job/latest", nil, http.StatusBadRequest},
+		{"Job Not Allowed", "/api/v1/jenkins/builds/test-job/42", restricted, http.StatusForbidden},
+		{"Job Allowed", "/api/v1/jenkins/builds/team-a-deploy/42", restricted, http.StatusOK},
+		{"Engine Not Found", "/api/v1/jenkins/builds/missing/1", nil, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.principal != nil {
+				req = req.WithContext(context.WithValue(req.Context(), middleware.PrincipalContextKey, tt.principal))
+			}
+			rr := httptest.NewRecorder()
+			handler.GetJenkinsBuildStatus(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestTriggerJenkinsBuildAuditAttribution(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-attribution-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{})
+
+	reqBodyBytes, _ := json.Marshal(handlers.TriggerJenkinsBuildRequest{Job: "test-job"})
+	req := httptest.NewRequest("POST", "/api/v1/trigger/jenkins", bytes.NewReader(reqBodyBytes))
+	ctx := context.WithValue(req.Context(), middleware.PrincipalContextKey, &middleware.Principal{Name: "ci-bot", Tenant: "team-a"})
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	handler.TriggerJenkinsBuild(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	logs, err := storage.GetAuditLogs(1, 0)
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("Expected one audit log, got %v (err %v)", logs, err)
+	}
+	log := logs[0]
+	if log.Source != "http" || log.Engine != "jenkins" || log.Tenant != "team-a" {
+		t.Errorf("Expected source http, engine jenkins, tenant team-a, got %q, %q, %q", log.Source, log.Engine, log.Tenant)
+	}
+	if len(log.TriggerID) != 32 {
+		t.Errorf("Expected a generated trigger ID, got %q", log.TriggerID)
+	}
+	if log.DurationMS < 0 {
+		t.Errorf("Expected non-negative duration, got %d", log.DurationMS)
+	}
+}
+
+func TestTriggerJenkinsBuildRecordsLatency(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-latency-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			time.Sleep(20 * time.Millisecond)
+			return &engine.BuildResult{Success: true, BuildID: "test-job/1"}, nil
+		},
+	})
+
+	reqBodyBytes, _ := json.Marshal(handlers.TriggerJenkinsBuildRequest{Job: "test-job"})
+	req := httptest.NewRequest("POST", "/api/v1/trigger/jenkins", bytes.NewReader(reqBodyBytes))
+	rr := httptest.NewRecorder()
+	handler.TriggerJenkinsBuild(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if timing := rr.Header().Get("Server-Timing"); !strings.Contains(timing, "engine;dur=") || !strings.Contains(timing, "total;dur=") {
+		t.Errorf("Expected Server-Timing with engine and total durations, got %q", timing)
+	}
+
+	var resp handlers.TriggerJenkinsBuildResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.BuildResult == nil || resp.BuildID != "test-job/1" {
+		t.Errorf("Expected build result fields in response, got %s", rr.Body.String())
+	}
+	if resp.EngineDurationMS < 20 || resp.DurationMS < resp.EngineDurationMS {
+		t.Errorf("Expected engine_duration_ms >= 20 and duration_ms >= engine_duration_ms, got %d and %d", resp.EngineDurationMS, resp.DurationMS)
+	}
+	if resp.TriggerID == "" {
+		t.Error("Expected trigger_id in response")
+	}
+
+	logs, err := storage.GetAuditLogs(1, 0)
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("Expected one audit log, got %v (err %v)", logs, err)
+	}
+	if logs[0].EngineDurationMS < 20 || logs[0].DurationMS < logs[0].EngineDurationMS {
+		t.Errorf("Expected audit durations to be recorded, got engine %d, total %d", logs[0].EngineDurationMS, logs[0].DurationMS)
+	}
+	if logs[0].TriggerID != resp.TriggerID {
+		t.Errorf("Expected audit trigger_id %q to match response, got %q", resp.TriggerID, logs[0].TriggerID)
+	}
+}
+
+func TestTriggerJenkinsBuildCredentialReferences(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-cred-ref-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{})
+
+	testCases := []struct {
+		name           string
+		value          string
+		expectedStatus int
+	}{
+		{"Valid reference", "@cred:deploy-key", http.StatusOK},
+		{"Empty ID", "@cred:", http.StatusBadRequest},
+		{"Invalid ID", "@cred:deploy key", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reqBodyBytes, _ := json.Marshal(handlers.TriggerJenkinsBuildRequest{
+				Job:        "deploy",
+				Parameters: map[string]string{"SSH_KEY": tc.value},
+			})
+			req := httptest.NewRequest("POST", "/api/v1/trigger/jenkins", bytes.NewReader(reqBodyBytes))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, "test-api-key"))
+
+			rr := httptest.NewRecorder()
+			handler.TriggerJenkinsBuild(rr, req)
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"triggermesh/internal/api"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/engine/jenkins"
+	"triggermesh/internal/storage"
+)
+
+// newJobDiscoveryJenkins returns a mock Jenkins serving a root job list, a folder, and a view
+func newJobDiscoveryJenkins(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/json":
+			w.Write([]byte(`{"jobs":[
+				{"_class":"hudson.model.FreeStyleProject","name":"build-api","url":"http://jenkins/job/build-api/","color":"blue"},
+				{"_class":"com.cloudbees.hudson.plugins.folder.Folder","name":"team-a","url":"http://jenkins/job/team-a/"},
+				{"_class":"com.cloudbees.hudson.plugins.folder.Folder","name":"team-b","url":"http://jenkins/job/team-b/"}
+			]}`))
+		case "/job/team-a/api/json":
+			w.Write([]byte(`{"jobs":[
+				{"_class":"org.jenkinsci.plugins.workflow.job.WorkflowJob","name":"deploy","color":"red"},
+				{"_class":"org.jenkinsci.plugins.workflow.job.WorkflowJob","name":"test","color":"blue"}
+			]}`))
+		case "/view/release/api/json":
+			w.Write([]byte(`{"jobs":[{"_class":"hudson.model.FreeStyleProject","name":"release-api"}]}`))
+		case "/crumbIssuer/api/json":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestJenkinsListJobs(t *testing.T) {
+	server := newJobDiscoveryJenkins(t)
+	defer server.Close()
+
+	trigger := jenkins.NewTrigger(jenkins.NewClient(config.JenkinsConfig{URL: server.URL, Username: "u", Token: "t", Timeout: 5}))
+
+	jobs, err := trigger.ListJobs(engine.JobFilter{})
+	if err != nil {
+		t.Fatalf("Failed to list root jobs: %v", err)
+	}
+	if len(jobs) != 3 || jobs[0].Name != "build-api" || jobs[0].Folder || !jobs[1].Folder {
+		t.Errorf("Unexpected root jobs: %+v", jobs)
+	}
+
+	jobs, err = trigger.ListJobs(engine.JobFilter{Folder: "team-a"})
+	if err != nil {
+		t.Fatalf("Failed to list folder jobs: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].Name != "team-a/deploy" || jobs[0].Status != "red" {
+		t.Errorf("Unexpected folder jobs: %+v", jobs)
+	}
+
+	jobs, err = trigger.ListJobs(engine.JobFilter{View: "release"})
+	if err != nil {
+		t.Fatalf("Failed to list view jobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Name != "release-api" {
+		t.Errorf("Unexpected view jobs: %+v", jobs)
+	}
+
+	if _, err := trigger.ListJobs(engine.JobFilter{View: "missing"}); err == nil {
+		t.Error("Expected error for missing view")
+	}
+}
+
+func TestListJenkinsJobsVisibility(t *testing.T) {
+	server := newJobDiscoveryJenkins(t)
+	defer server.Close()
+
+	cfg := defaultTestConfig()
+	cfg.Jenkins.URL = server.URL
+	cfg.API.Clients = []config.APIClientConfig{
+		{Name: "team-a", Key: "team-a-key", Jobs: []string{"team-a/deploy"}},
+	}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	listJobs := func(key, query string) []engine.JobInfo {
+		req := httptest.NewRequest("GET", "/api/v1/jenkins/jobs"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp struct {
+			Jobs []engine.JobInfo `json:"jobs"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp.Jobs
+	}
+
+	// Unrestricted keys see everything
+	if jobs := listJobs("test-key", ""); len(jobs) != 3 {
+		t.Errorf("Expected 3 jobs for unrestricted key, got %+v", jobs)
+	}
+
+	// Restricted keys only see the folder leading to their job, and the job itself
+	jobs := listJobs("team-a-key", "")
+	if len(jobs) != 1 || jobs[0].Name != "team-a" {
+		t.Errorf("Expected only team-a folder, got %+v", jobs)
+	}
+	jobs = listJobs("team-a-key", "?folder=team-a")
+	if len(jobs) != 1 || jobs[0].Name != "team-a/deploy" {
+		t.Errorf("Expected only team-a/deploy, got %+v", jobs)
+	}
+
+	// Invalid filters are rejected
+	req := httptest.NewRequest("GET", "/api/v1/jenkins/jobs?folder=../etc", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid folder, got %d", rr.Code)
+	}
+}
+
+func TestTriggerJenkinsBuildJobNotAllowed(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-job-denied-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 1 << 20
+	cfg.API.Clients = []config.APIClientConfig{
+		{Name: "team-a", Key: "team-a-key", Jobs: []string{"team-a-*"}},
+	}
+	called := false
+	router := api.NewRouter(cfg, &MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			called = true
+			return &engine.BuildResult{Success: true}, nil
+		},
+	})
+
+	body, _ := json.Marshal(map[string]interface{}{"job": "team-b-deploy"})
+	req := httptest.NewRequest("POST", "/api/v1/trigger/jenkins", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer team-a-key")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", rr.Code)
+	}
+	if called {
+		t.Error("Expected engine not to be called for a denied job")
+	}
+
+	logs, err := storage.GetAuditLogs(1, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit logs: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Result != "denied" || logs[0].Status != http.StatusForbidden {
+		t.Errorf("Expected denied audit entry, got %+v", logs)
+	}
+}
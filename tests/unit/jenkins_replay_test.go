@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/engine/jenkins"
+	"triggermesh/internal/engine/jenkins/jenkinstest"
+)
+
+// Replayed Jenkins interactions from testdata/jenkins; see internal/engine/jenkins/jenkinstest
+// for re-recording them against a real server
+
+func TestJenkinsReplayAbsoluteLocation(t *testing.T) {
+	trigger := jenkins.NewTrigger(jenkinstest.NewClient(t, filepath.Join("testdata", "jenkins", "absolute_location.json")))
+
+	result, err := trigger.TriggerBuild("deploy", map[string]string{"version": "1.2.3"})
+	if err != nil {
+		t.Fatalf("Failed to trigger build: %v", err)
+	}
+	if result.QueueID != "17" || result.BuildID != "" {
+		t.Errorf("Expected queue item 17 from the absolute Location, got %+v", result)
+	}
+
+	buildID, err := trigger.ResolveQueuedBuild(result.QueueID)
+	if err != nil || buildID != "deploy/5" {
+		t.Errorf("Expected build deploy/5, got %q, %v", buildID, err)
+	}
+}
+
+func TestJenkinsReplayStatusRedirect(t *testing.T) {
+	trigger := jenkins.NewTrigger(jenkinstest.NewClient(t, filepath.Join("testdata", "jenkins", "status_redirect.json")))
+
+	status, err := trigger.GetBuildStatus("deploy/5")
+	if err != nil {
+		t.Fatalf("Failed to get build status: %v", err)
+	}
+	if status.Result != engine.ResultSuccess || status.BuildDurationMS != 48211 || status.BuildURL != jenkinstest.ReplayURL+"/job/deploy/5/" {
+		t.Errorf("Unexpected build status after the redirect: %+v", status)
+	}
+}
+
+func TestJenkinsReplayHTMLErrorPages(t *testing.T) {
+	trigger := jenkins.NewTrigger(jenkinstest.NewClient(t, filepath.Join("testdata", "jenkins", "html_error_pages.json")))
+
+	// Jenkins starting up answers with an HTML page; the missing crumb issuer is not fatal
+	result, err := trigger.TriggerBuild("deploy", nil)
+	if !errors.Is(err, engine.ErrServer) || result.Success {
+		t.Errorf("Expected a server error, got %+v, %v", result, err)
+	}
+	if strings.Contains(result.Message, "<html") {
+		t.Errorf("Expected the HTML page to stay out of the message, got %q", result.Message)
+	}
+
+	// A login page served with 200 (e.g. by an SSO proxy) yields basic build info only
+	status, err := trigger.GetBuildStatus("deploy/6")
+	if err != nil || status.Result != "" || status.Building {
+		t.Errorf("Expected basic build info, got %+v, %v", status, err)
+	}
+}
+
+func TestJenkinsReplayMissingLocation(t *testing.T) {
+	trigger := jenkins.NewTrigger(jenkinstest.NewClient(t, filepath.Join("testdata", "jenkins", "missing_location.json")))
+
+	result, err := trigger.TriggerBuild("deploy", nil)
+	if err != nil {
+		t.Fatalf("Failed to trigger build: %v", err)
+	}
+	if result.BuildID != "" || result.QueueID != "" || result.BuildURL != jenkinstest.ReplayURL+"/job/deploy/" {
+		t.Errorf("Expected only the job URL without a Location header, got %+v", result)
+	}
+}
+
+func TestJenkinsRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Set-Cookie", "JSESSIONID=secret")
+		_, _ = w.Write([]byte(`{"url":"http://` + r.Host + `/job/deploy/5/","result":"SUCCESS"}`))
+	}))
+	defer server.Close()
+
+	client := jenkins.NewClient(config.JenkinsConfig{URL: server.URL, Username: "user", Token: "token", Timeout: 5})
+	recorder := jenkinstest.NewRecorder(nil, server.URL)
+	client.SetTransport(recorder)
+	if _, err := jenkins.NewTrigger(client).GetBuildStatus("deploy/5"); err != nil {
+		t.Fatalf("Failed to get build status: %v", err)
+	}
+
+	// Save and reload the cassette, then replay it
+	path := filepath.Join(t.TempDir(), "recorded.json")
+	if err := recorder.Cassette().Save(path); err != nil {
+		t.Fatalf("Failed to save cassette: %v", err)
+	}
+	cassette, err := jenkinstest.LoadCassette(path)
+	if err != nil || len(cassette.Interactions) != 1 {
+		t.Fatalf("Expected one recorded interaction, got %+v, %v", cassette, err)
+	}
+	recorded := cassette.Interactions[0]
+	if recorded.Request.Path != "/job/deploy/5/api/json" || recorded.Response.Headers["Set-Cookie"] != "" {
+		t.Errorf("Unexpected recorded interaction: %+v", recorded)
+	}
+	if !strings.Contains(recorded.Response.Body, jenkinstest.ReplayURL+"/job/deploy/5/") {
+		t.Errorf("Expected the server URL to be rewritten, got %s", recorded.Response.Body)
+	}
+
+	status, err := jenkins.NewTrigger(jenkinstest.NewClient(t, path)).GetBuildStatus("deploy/5")
+	if err != nil || status.Result != engine.ResultSuccess {
+		t.Errorf("Expected the recorded status, got %+v, %v", status, err)
+	}
+}
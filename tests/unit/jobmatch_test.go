@@ -0,0 +1,42 @@
+package unit
+
+import (
+	"testing"
+
+	"triggermesh/internal/jobmatch"
+)
+
+func TestJobMatch(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		job      string
+		expected bool
+	}{
+		{"deploy-api", "deploy-api", true},
+		{"deploy-api", "deploy-web", false},
+		{"deploy-*", "deploy-api", true},
+		{"deploy-*", "build-api", false},
+		{"team-a/*", "team-a/deploy", true},
+		{"team-a/*", "team-a/sub/deploy", true},
+		{"team-a/*", "team-b/deploy", false},
+		{"*-prod", "api-prod", true},
+		{"*-prod", "api-prod-canary", false},
+		{"*api*", "team/api-deploy", true},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "acb", false},
+		{"*", "anything/at/all", true},
+	}
+
+	for _, tt := range tests {
+		if got := jobmatch.Match(tt.pattern, tt.job); got != tt.expected {
+			t.Errorf("Match(%q, %q) = %v, expected %v", tt.pattern, tt.job, got, tt.expected)
+		}
+	}
+
+	if !jobmatch.MatchAny([]string{"build-*", "deploy-*"}, "deploy-api") {
+		t.Error("Expected MatchAny to match second pattern")
+	}
+	if jobmatch.MatchAny(nil, "deploy-api") {
+		t.Error("Expected MatchAny with no patterns to be false")
+	}
+}
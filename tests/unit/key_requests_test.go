@@ -0,0 +1,129 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/authz"
+	"triggermesh/internal/config"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func TestKeyRequestWorkflow(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 1024 * 1024
+	cfg.API.Clients = []config.APIClientConfig{
+		{Name: "dev", Key: "dev-key"},
+		{Name: "ops", Key: "ops-key", Scopes: []string{config.ScopeAdmin}},
+		{Name: "lead", Key: "lead-key", Scopes: []string{config.ScopeAdmin}},
+	}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/api/v1/keys/requests", "dev-key", `{"name":"deploy-bot","jobs":["deploy-*"],"reason":"CD pipeline"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var request models.KeyRequest
+	if err := json.Unmarshal(rr.Body.Bytes(), &request); err != nil || request.Status != models.KeyRequestPending || request.RequestedBy != "key:"+authz.KeyID("dev-key") {
+		t.Fatalf("Unexpected key request: %s", rr.Body.String())
+	}
+	requestPath := fmt.Sprintf("/api/v1/keys/requests/%d", request.ID)
+
+	if rr := do(http.MethodPost, "/api/v1/keys/requests", "dev-key", `{"name":"bot","scopes":["root"]}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown scope, got %d", rr.Code)
+	}
+
+	// Names identify keys in audit logs, so a requested key cannot take the name of another key
+	for _, name := range []string{"ops", "deploy-bot"} {
+		if rr := do(http.MethodPost, "/api/v1/keys/requests", "lead-key", `{"name":"`+name+`"}`); rr.Code != http.StatusConflict {
+			t.Errorf("Expected status 409 requesting the taken name %s, got %d: %s", name, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := do(http.MethodPost, "/api/v1/keys/requests", "dev-key", `{"name":"key:7e9f8fd11180"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a name shaped like a key fingerprint, got %d", rr.Code)
+	}
+	if rr := do(http.MethodPost, requestPath+"/claim", "dev-key", ""); rr.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 claiming a pending request, got %d", rr.Code)
+	}
+	if rr := do(http.MethodPost, requestPath+"/approve", "dev-key", ""); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 approving without the admin scope, got %d", rr.Code)
+	}
+
+	rr = do(http.MethodPost, requestPath+"/approve", "ops-key", `{"note":"ok for CD"}`)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"decided_by":"ops"`) {
+		t.Fatalf("Expected the request to be approved, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodPost, requestPath+"/deny", "lead-key", ""); rr.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 deciding twice, got %d", rr.Code)
+	}
+
+	// Only the requester can claim, and only once
+	if rr := do(http.MethodPost, requestPath+"/claim", "ops-key", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 claiming another client's request, got %d", rr.Code)
+	}
+	rr = do(http.MethodPost, requestPath+"/claim", "dev-key", "")
+	if rr.Code != http.StatusOK || rr.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("Expected the key to be issued, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var claim handlers.KeyClaimResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &claim); err != nil || !strings.HasPrefix(claim.Key, "tmk_") || claim.Status != models.KeyRequestIssued {
+		t.Fatalf("Unexpected claim response: %s", rr.Body.String())
+	}
+	if rr := do(http.MethodPost, requestPath+"/claim", "dev-key", ""); rr.Code != http.StatusGone {
+		t.Errorf("Expected status 410 claiming twice, got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, requestPath, "dev-key", ""); strings.Contains(rr.Body.String(), claim.Key) {
+		t.Error("Key request exposes the issued key")
+	}
+
+	// The issued key authenticates with the requested rules
+	rr = do(http.MethodPost, "/api/v1/trigger/jenkins", claim.Key, `{"job":"build-web"}`)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected the issued key to be restricted to deploy-*, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/api/v1/keys/requests", claim.Key, ""); rr.Code != http.StatusOK || rr.Body.String() != "[]\n" {
+		t.Errorf("Expected the issued key to see no requests of its own, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodGet, "/api/v1/keys/requests", "tmk_unknown", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for an unknown key, got %d", rr.Code)
+	}
+
+	// Admins may not approve their own requests
+	rr = do(http.MethodPost, "/api/v1/keys/requests", "ops-key", `{"name":"ops-2","scopes":["admin"]}`)
+	if err := json.Unmarshal(rr.Body.Bytes(), &request); err != nil {
+		t.Fatalf("Failed to decode key request: %v", err)
+	}
+	if rr := do(http.MethodPost, fmt.Sprintf("/api/v1/keys/requests/%d/approve", request.ID), "ops-key", ""); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 approving an own request, got %d", rr.Code)
+	}
+
+	// Every step is recorded in the configuration audit
+	entries, err := storage.GetConfigAudit("", 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get config audit: %v", err)
+	}
+	var actions []string
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+	}
+	if strings.Join(actions, ",") != "key_request,key_issue,key_approve,key_request" {
+		t.Errorf("Unexpected audited actions: %v", actions)
+	}
+}
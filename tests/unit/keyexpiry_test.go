@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/config"
+	"triggermesh/internal/keyexpiry"
+)
+
+func TestKeyExpiryWatcherRemindsOnce(t *testing.T) {
+	received := make(chan keyexpiry.Reminder, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reminder keyexpiry.Reminder
+		if err := json.NewDecoder(r.Body).Decode(&reminder); err != nil {
+			t.Errorf("Failed to decode reminder: %v", err)
+		}
+		received <- reminder
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var horizon time.Time
+	keys := []keyexpiry.Key{
+		{Client: "ci", Owner: "ci-team@example.com", ExpiresAt: now.Add(36 * time.Hour)},
+		{Client: "old", ExpiresAt: now.Add(-time.Hour)},
+		{Client: "later", ExpiresAt: now.Add(30 * 24 * time.Hour)},
+	}
+	watcher, err := keyexpiry.NewWatcher(config.KeyExpiryReminderConfig{
+		Days:      7,
+		Notifiers: []config.AlertNotifierConfig{{Type: "webhook", URL: server.URL}},
+	}, func(before time.Time) ([]keyexpiry.Key, error) {
+		horizon = before
+		return keys, nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+
+	sent, err := watcher.Check(context.Background(), now)
+	if err != nil || sent != 1 {
+		t.Fatalf("Expected one reminder, got %d (%v)", sent, err)
+	}
+	if !horizon.Equal(now.Add(7 * 24 * time.Hour)) {
+		t.Errorf("Expected keys expiring within 7 days to be looked up, got %v", horizon)
+	}
+	reminder := <-received
+	if reminder.Client != "ci" || reminder.Owner != "ci-team@example.com" || reminder.DaysLeft != 2 {
+		t.Errorf("Unexpected reminder: %+v", reminder)
+	}
+	if !strings.Contains(reminder.Summary(), "expires in 2 day(s)") {
+		t.Errorf("Unexpected summary: %s", reminder.Summary())
+	}
+
+	// The same key is not reminded again, but a renewed expiry is
+	if sent, _ := watcher.Check(context.Background(), now.Add(time.Hour)); sent != 0 {
+		t.Errorf("Expected no repeated reminder, got %d", sent)
+	}
+	keys[0].ExpiresAt = now.Add(48 * time.Hour)
+	if sent, _ := watcher.Check(context.Background(), now.Add(time.Hour)); sent != 1 {
+		t.Errorf("Expected a reminder for the new expiry, got %d", sent)
+	}
+}
+
+func TestAuthMiddlewareRejectsExpiredKeys(t *testing.T) {
+	auth := middleware.NewAuthMiddleware(config.APIConfig{
+		Clients: []config.APIClientConfig{
+			{Name: "expired", Key: "expired-key", ExpiresAt: time.Now().Add(-time.Minute)},
+			{Name: "current", Key: "current-key", ExpiresAt: time.Now().Add(time.Hour), Owner: "ops@example.com"},
+		},
+	})
+	handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer expired-key")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized || !strings.Contains(rr.Body.String(), `"code":"KEY_EXPIRED"`) {
+		t.Errorf("Expected a KEY_EXPIRED error, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if auth.ValidateAPIKey("expired-key") {
+		t.Error("Expected expired key to be invalid")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer current-key")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200 before expiry, got %d", rr.Code)
+	}
+
+	owners := map[string]string{}
+	for _, principal := range auth.ExpiringKeys() {
+		owners[principal.Name] = principal.Owner
+	}
+	if len(owners) != 2 || owners["current"] != "ops@example.com" {
+		t.Errorf("Unexpected expiring keys: %v", owners)
+	}
+}
@@ -0,0 +1,128 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func TestKillSwitch(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 1024 * 1024
+	cfg.API.Clients = []config.APIClientConfig{
+		{Name: "ci", Key: "ci-key"},
+		{Name: "ops", Key: "ops-key", Scopes: []string{config.ScopeAdmin}},
+	}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	builds := 0
+	router.AddEngine("tekton", "custom", &MockCIEngine{TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+		builds++
+		return &engine.BuildResult{Success: true, BuildID: fmt.Sprintf("run-%d", builds)}, nil
+	}})
+
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	trigger := func(job string) *httptest.ResponseRecorder {
+		return do(http.MethodPost, "/api/v1/trigger/tekton", "ci-key", `{"job":"`+job+`"}`)
+	}
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	body := `{"pattern":"deploy-*","reason":"Payment outage INC-42","expires_at":"` + expiresAt + `"}`
+	if rr := do(http.MethodPost, "/api/v1/admin/kill-switches", "ci-key", body); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 without the admin scope, got %d", rr.Code)
+	}
+	for _, invalid := range []string{
+		`{"pattern":"deploy-*","expires_at":"` + expiresAt + `"}`,
+		`{"pattern":"deploy-*","reason":"outage"}`,
+		`{"pattern":"deploy-*","reason":"outage","expires_at":"2020-01-01T00:00:00Z"}`,
+		`{"pattern":"deploy-*","reason":"outage","expires_at":"` + time.Now().Add(40*24*time.Hour).UTC().Format(time.RFC3339) + `"}`,
+		`{"pattern":"deploy;*","reason":"outage","expires_at":"` + expiresAt + `"}`,
+	} {
+		if rr := do(http.MethodPost, "/api/v1/admin/kill-switches", "ops-key", invalid); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", invalid, rr.Code)
+		}
+	}
+
+	rr := do(http.MethodPost, "/api/v1/admin/kill-switches", "ops-key", body)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var killSwitch models.KillSwitch
+	if err := json.Unmarshal(rr.Body.Bytes(), &killSwitch); err != nil || killSwitch.ID == 0 || killSwitch.CreatedBy != "ops" {
+		t.Fatalf("Unexpected kill switch: %s", rr.Body.String())
+	}
+
+	// Covered jobs are refused with the reason; other jobs are not affected
+	rr = trigger("deploy-prod")
+	if rr.Code != http.StatusLocked {
+		t.Fatalf("Expected status 423, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"code":"JOB_DISABLED"`) || !strings.Contains(rr.Body.String(), "Payment outage INC-42") {
+		t.Errorf("Expected JOB_DISABLED with the reason, got %s", rr.Body.String())
+	}
+	if retryAfter := rr.Header().Get("Retry-After"); retryAfter == "" || retryAfter == "0" {
+		t.Errorf("Expected Retry-After until the expiry, got %q", retryAfter)
+	}
+	if rr := trigger("build-web"); rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for an uncovered job, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if builds != 1 {
+		t.Errorf("Expected 1 build, got %d", builds)
+	}
+
+	rr = do(http.MethodGet, "/api/v1/admin/kill-switches", "ops-key", "")
+	var active []models.KillSwitch
+	if err := json.Unmarshal(rr.Body.Bytes(), &active); err != nil || len(active) != 1 || active[0].Pattern != "deploy-*" {
+		t.Fatalf("Expected the active kill switch, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Lifting the kill switch allows triggers again; browsers may send it cross-origin
+	path := fmt.Sprintf("/api/v1/admin/kill-switches/%d", killSwitch.ID)
+	preflight := httptest.NewRequest(http.MethodOptions, path, nil)
+	preflight.Header.Set("Origin", "https://ops.example.com")
+	preflight.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, preflight)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Header().Get("Access-Control-Allow-Methods"), http.MethodDelete) {
+		t.Errorf("Expected the preflight to allow DELETE, got %d with methods %q", rr.Code, rr.Header().Get("Access-Control-Allow-Methods"))
+	}
+	rr = do(http.MethodDelete, path, "ops-key", "")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"lifted_by":"ops"`) {
+		t.Fatalf("Expected the kill switch to be lifted, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodDelete, path, "ops-key", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 lifting twice, got %d", rr.Code)
+	}
+	if rr := trigger("deploy-prod"); rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200 after the lift, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Engaging and lifting are recorded in the configuration audit
+	for _, action := range []string{models.ConfigActionKillSwitch, models.ConfigActionKillLift} {
+		entries, err := storage.GetConfigAudit(action, 10, 0)
+		if err != nil {
+			t.Fatalf("Failed to get config audit: %v", err)
+		}
+		if len(entries) != 1 || entries[0].Actor != "ops" || !strings.Contains(entries[0].Details, "pattern=deploy-*") {
+			t.Errorf("Expected one %s entry by ops, got %+v", action, entries)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+)
+
+// newLabelTriggerRequest builds an authenticated trigger request with the given JSON body
+func newLabelTriggerRequest(body string) *http.Request {
+	req := httptest.NewRequest("POST", "/api/v1/trigger/jenkins", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	return req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, "test-key"))
+}
+
+func TestTriggerLabels(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-labels-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	var triggeredParams map[string]string
+	buildNumber := 6
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			triggeredParams = params
+			buildNumber++
+			return &engine.BuildResult{Success: true, BuildID: fmt.Sprintf("%s/%d", jobName, buildNumber)}, nil
+		},
+		GetBuildStatusFunc: func(buildID string) (*engine.BuildResult, error) {
+			return &engine.BuildResult{Success: true, BuildID: buildID}, nil
+		},
+	})
+	handler.InjectLabelParameters("LABEL_")
+
+	rr := httptest.NewRecorder()
+	handler.TriggerJenkinsBuild(rr, newLabelTriggerRequest(`{"job":"deploy","parameters":{"LABEL_team":"explicit"},"labels":{"team":"payments","change":"CHG-1234"}}`))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	t.Run("Injects labels as parameters", func(t *testing.T) {
+		if triggeredParams["LABEL_change"] != "CHG-1234" {
+			t.Errorf("Expected LABEL_change parameter, got %v", triggeredParams)
+		}
+		if triggeredParams["LABEL_team"] != "explicit" {
+			t.Errorf("Expected explicit parameter to win over injected label, got %v", triggeredParams)
+		}
+	})
+
+	t.Run("Stores labels and build ID", func(t *testing.T) {
+		logs, err := storage.GetAuditLogs(1, 0)
+		if err != nil || len(logs) != 1 {
+			t.Fatalf("Expected audit log, got %v (err %v)", logs, err)
+		}
+		if logs[0].Labels["team"] != "payments" || logs[0].BuildID != "deploy/7" {
+			t.Errorf("Expected labels and build ID in audit log, got %+v", logs[0])
+		}
+		if logs[0].Params != `{"LABEL_team":"explicit"}` {
+			t.Errorf("Expected request parameters in audit log, got %s", logs[0].Params)
+		}
+	})
+
+	t.Run("Filters audit logs by label", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.TriggerJenkinsBuild(rr, newLabelTriggerRequest(`{"job":"deploy","labels":{"team":"search"}}`))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+
+		auditHandler := handlers.NewAuditHandler()
+		tests := []struct {
+			query    string
+			expected int
+		}{
+			{"label=team:payments", 1},
+			{"label=team:payments&label=change:CHG-1234", 1},
+			{"label=team:payments&label=change:CHG-9", 0},
+			{"label=team:search", 1},
+			{"", 2},
+		}
+		for _, tt := range tests {
+			rr := httptest.NewRecorder()
+			auditHandler.GetAuditLogs(rr, httptest.NewRequest("GET", "/api/v1/audit?"+tt.query, nil))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("%s: expected status 200, got %d", tt.query, rr.Code)
+			}
+			var logs []map[string]interface{}
+			if err := json.Unmarshal(rr.Body.Bytes(), &logs); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(logs) != tt.expected {
+				t.Errorf("%s: expected %d logs, got %d", tt.query, tt.expected, len(logs))
+			}
+		}
+
+		rr = httptest.NewRecorder()
+		auditHandler.GetAuditLogs(rr, httptest.NewRequest("GET", "/api/v1/audit?label=team", nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for malformed filter, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Returns labels in build status", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.GetJenkinsBuildStatus(rr, httptest.NewRequest("GET", "/api/v1/jenkins/builds/deploy/7", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		var resp handlers.BuildStatusResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Labels["change"] != "CHG-1234" || resp.BuildID != "deploy/7" {
+			t.Errorf("Expected build status with labels, got %+v", resp)
+		}
+	})
+
+	t.Run("Rejects invalid labels", func(t *testing.T) {
+		for _, body := range []string{
+			`{"job":"deploy","labels":{"bad key":"x"}}`,
+			`{"job":"deploy","labels":{"":"x"}}`,
+		} {
+			rr := httptest.NewRecorder()
+			handler.TriggerJenkinsBuild(rr, newLabelTriggerRequest(body))
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status 400, got %d", body, rr.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/leakcheck"
+	"triggermesh/internal/lifecycle"
+)
+
+// recordingHook returns a hook that appends its start/stop events to events
+func recordingHook(name string, events *[]string, startErr error) lifecycle.Hook {
+	return lifecycle.Hook{
+		Name: name,
+		OnStart: func(ctx context.Context) error {
+			*events = append(*events, "start "+name)
+			return startErr
+		},
+		OnStop: func(ctx context.Context) error {
+			*events = append(*events, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func TestLifecycleStartStopOrder(t *testing.T) {
+	var events []string
+	manager := lifecycle.NewManager(time.Second)
+	manager.Append(recordingHook("a", &events, nil))
+	manager.Append(lifecycle.Hook{Name: "no-op"})
+	manager.Append(recordingHook("b", &events, nil))
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := manager.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	// A second Stop has nothing left to stop
+	if err := manager.Stop(context.Background()); err != nil {
+		t.Fatalf("Second Stop failed: %v", err)
+	}
+
+	expected := []string{"start a", "start b", "stop b", "stop a"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected events %v, got %v", expected, events)
+	}
+}
+
+func TestLifecycleStartFailureStopsStartedHooks(t *testing.T) {
+	var events []string
+	startErr := errors.New("boom")
+	manager := lifecycle.NewManager(time.Second)
+	manager.Append(recordingHook("a", &events, nil))
+	manager.Append(recordingHook("b", &events, startErr))
+	manager.Append(recordingHook("c", &events, nil))
+
+	err := manager.Start(context.Background())
+	if !errors.Is(err, startErr) {
+		t.Fatalf("Expected start error wrapping %v, got %v", startErr, err)
+	}
+
+	expected := []string{"start a", "start b", "stop a"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected events %v, got %v", expected, events)
+	}
+}
+
+func TestLifecycleHookTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	var events []string
+	manager := lifecycle.NewManager(20 * time.Millisecond)
+	manager.Append(recordingHook("a", &events, nil))
+	manager.Append(lifecycle.Hook{
+		Name: "stuck",
+		OnStop: func(ctx context.Context) error {
+			// Ignores its context
+			<-release
+			return nil
+		},
+	})
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	err := manager.Stop(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded from stuck hook, got %v", err)
+	}
+	// Hooks after the stuck one are still stopped
+	if events[len(events)-1] != "stop a" {
+		t.Errorf("Expected remaining hooks to stop, got %v", events)
+	}
+}
+
+// pollerHook returns a hook running a ticker loop until stopped, as the built-in jobs do
+func pollerHook() lifecycle.Hook {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	return lifecycle.Hook{
+		Name: "poller",
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				defer close(done)
+				ticker := time.NewTicker(time.Millisecond)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ticker.C:
+					case <-stop:
+						return
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(stop)
+			<-done
+			return nil
+		},
+	}
+}
+
+// consumerHook returns a hook draining a channel until stopped, and the channel
+func consumerHook() (lifecycle.Hook, chan<- int) {
+	events := make(chan int)
+	done := make(chan struct{})
+	return lifecycle.Hook{
+		Name: "consumer",
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				defer close(done)
+				for range events {
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(events)
+			<-done
+			return nil
+		},
+	}, events
+}
+
+// serverHook returns a hook serving HTTP on a local port until stopped, and the server's address
+func serverHook(t *testing.T) (lifecycle.Hook, func() string) {
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})}
+	var addr string
+	served := make(chan error, 1)
+	return lifecycle.Hook{
+		Name: "server",
+		OnStart: func(ctx context.Context) error {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				return err
+			}
+			addr = listener.Addr().String()
+			go func() {
+				served <- server.Serve(listener)
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if err := server.Shutdown(ctx); err != nil {
+				return err
+			}
+			if err := <-served; err != http.ErrServerClosed {
+				t.Errorf("Expected ErrServerClosed from Serve, got %v", err)
+			}
+			return nil
+		},
+	}, func() string { return addr }
+}
+
+func TestLifecycleStartStopCyclesDoNotLeak(t *testing.T) {
+	leakcheck.Verify(t)
+
+	for cycle := 0; cycle < 3; cycle++ {
+		consumer, events := consumerHook()
+		server, addr := serverHook(t)
+		manager := lifecycle.NewManager(time.Second)
+		manager.Append(pollerHook())
+		manager.Append(consumer)
+		manager.Append(server)
+
+		if err := manager.Start(context.Background()); err != nil {
+			t.Fatalf("Cycle %d: Start failed: %v", cycle, err)
+		}
+		events <- cycle
+		client := &http.Client{Transport: &http.Transport{}}
+		resp, err := client.Get("http://" + addr() + "/")
+		if err != nil {
+			t.Fatalf("Cycle %d: request failed: %v", cycle, err)
+		}
+		resp.Body.Close()
+		client.CloseIdleConnections()
+
+		if err := manager.Stop(context.Background()); err != nil {
+			t.Fatalf("Cycle %d: Stop failed: %v", cycle, err)
+		}
+	}
+}
+
+func TestLeakcheckReportsLeakedGoroutines(t *testing.T) {
+	snapshot := leakcheck.Take()
+	release := make(chan struct{})
+	go func() {
+		<-release
+	}()
+
+	leaked := snapshot.Leaked(leakcheck.Timeout(50 * time.Millisecond))
+	if len(leaked) != 1 || !strings.Contains(leaked[0].Stack, "TestLeakcheckReportsLeakedGoroutines") {
+		t.Fatalf("Expected the blocked goroutine to be reported, got %+v", leaked)
+	}
+	if ignored := snapshot.Leaked(leakcheck.Timeout(0), leakcheck.IgnoreFunction("triggermesh/tests/unit.TestLeakcheckReportsLeakedGoroutines.func1")); len(ignored) != 0 {
+		t.Errorf("Expected the ignored goroutine not to be reported, got %+v", ignored)
+	}
+
+	close(release)
+	if leaked := snapshot.Leaked(); len(leaked) != 0 {
+		t.Errorf("Expected no leaks once the goroutine exits, got %+v", leaked)
+	}
+}
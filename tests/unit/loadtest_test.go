@@ -0,0 +1,41 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"triggermesh/internal/loadtest"
+)
+
+func TestLoadTestInProcess(t *testing.T) {
+	report, err := loadtest.Run(context.Background(), loadtest.Options{
+		Requests:    50,
+		Concurrency: 5,
+	})
+	if err != nil {
+		t.Fatalf("Load test failed: %v", err)
+	}
+
+	if report.Requests != 50 {
+		t.Errorf("Expected 50 requests, got %d", report.Requests)
+	}
+	if report.Failed != 0 {
+		t.Errorf("Expected no failures, got %d", report.Failed)
+	}
+	if report.Min > report.P50 || report.P50 > report.P95 || report.P95 > report.P99 || report.P99 > report.Max {
+		t.Errorf("Expected ordered latency percentiles, got %s", report)
+	}
+	if !strings.Contains(report.String(), "throughput=") {
+		t.Errorf("Expected report to include throughput, got %s", report)
+	}
+}
+
+func TestLoadTestInvalidOptions(t *testing.T) {
+	if _, err := loadtest.Run(context.Background(), loadtest.Options{Requests: 0, Concurrency: 1}); err == nil {
+		t.Error("Expected error for zero requests")
+	}
+	if _, err := loadtest.Run(context.Background(), loadtest.Options{Requests: 1, Concurrency: 0}); err == nil {
+		t.Error("Expected error for zero concurrency")
+	}
+}
@@ -0,0 +1,12 @@
+package unit
+
+import (
+	"testing"
+
+	"triggermesh/internal/leakcheck"
+)
+
+// TestMain fails the run if tests leave goroutines behind, in builds with -tags leakcheck
+func TestMain(m *testing.M) {
+	leakcheck.VerifyTestMain(m)
+}
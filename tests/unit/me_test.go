@@ -0,0 +1,172 @@
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func TestMeReturnsCallerIdentityAndTriggers(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.API.Clients = []config.APIClientConfig{
+		{Name: "deploy-bot", Key: "bot-key", Tenant: "web", Jobs: []string{"deploy-*"}, Owner: "team@example.com"},
+		{Name: "other", Key: "other-key"},
+	}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	now := time.Now()
+	if err := storage.InsertAuditLog(models.AuditLog{Timestamp: now.Add(-48 * time.Hour), APIKey: "bot-key", Status: http.StatusOK, JobName: "deploy-old"}); err != nil {
+		t.Fatalf("Failed to insert audit log: %v", err)
+	}
+	for i := 0; i < 12; i++ {
+		status := http.StatusOK
+		if i%4 == 0 {
+			status = http.StatusBadGateway
+		}
+		if err := storage.InsertAuditLog(models.AuditLog{
+			Timestamp: now.Add(time.Duration(i-12) * time.Minute),
+			APIKey:    "bot-key",
+			Method:    http.MethodPost,
+			Path:      "/api/v1/trigger/jenkins",
+			Status:    status,
+			JobName:   fmt.Sprintf("deploy-%d", i),
+		}); err != nil {
+			t.Fatalf("Failed to insert audit log: %v", err)
+		}
+	}
+	if err := storage.InsertAuditLog(models.AuditLog{Timestamp: now, APIKey: "other-key", Status: http.StatusOK, JobName: "other-job"}); err != nil {
+		t.Fatalf("Failed to insert audit log: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+	req.Header.Set("Authorization", "Bearer bot-key")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var me handlers.MeResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &me); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if me.Name != "deploy-bot" || me.Tenant != "web" || me.Owner != "team@example.com" {
+		t.Errorf("Unexpected identity: %+v", me)
+	}
+	if len(me.Jobs) != 1 || me.Jobs[0] != "deploy-*" {
+		t.Errorf("Expected jobs [deploy-*], got %v", me.Jobs)
+	}
+	if me.Usage.Triggers != 12 || me.Usage.Failed != 3 || me.Usage.WindowSeconds != 86400 {
+		t.Errorf("Expected 12 triggers with 3 failed in 24h, got %+v", me.Usage)
+	}
+	if me.Usage.QuotasConfigured || me.Usage.Quotas == nil || len(me.Usage.Quotas) != 0 {
+		t.Errorf("Expected an empty quota list without quotas configured, got %+v", me.Usage)
+	}
+	if len(me.RecentTriggers) != 10 {
+		t.Fatalf("Expected 10 recent triggers, got %d", len(me.RecentTriggers))
+	}
+	if me.RecentTriggers[0].JobName != "deploy-11" {
+		t.Errorf("Expected newest trigger first, got %s", me.RecentTriggers[0].JobName)
+	}
+	for _, log := range me.RecentTriggers {
+		if log.APIKey != "" {
+			t.Errorf("Recent trigger %d exposes the API key", log.ID)
+		}
+	}
+
+	// A key without triggers gets empty lists, not null
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+	req.Header.Set("Authorization", "Bearer other-key")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var other map[string]json.RawMessage
+	if err := json.Unmarshal(rr.Body.Bytes(), &other); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if string(other["scopes"]) != "[]" || string(other["jobs"]) != "[]" {
+		t.Errorf("Expected empty scopes and jobs, got %s", rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without an API key, got %d", rr.Code)
+	}
+}
+
+func TestMeReportsQuotaUsage(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 1024 * 1024
+	cfg.Stats.Enabled = true
+	cfg.API.Clients = []config.APIClientConfig{
+		{Name: "ops", Key: "ops-key"},
+		{Name: "deploy-bot", Key: "bot-key", Jobs: []string{"deploy-web"}},
+	}
+	cfg.Quotas = config.QuotasConfig{Categories: []config.QuotaCategoryConfig{
+		{Name: "deploy", Jobs: []string{"deploy-*"}, MaxConcurrent: 2, Window: 60},
+		{Name: "test", Jobs: []string{"test-*"}, MaxTriggers: 5, Window: 300},
+	}}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	router.AddEngine("tekton", "custom", &MockCIEngine{TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+		return &engine.BuildResult{Success: true, BuildID: "run-" + jobName}, nil
+	}})
+	for _, job := range []string{"deploy-web", "test-unit", "test-e2e"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/trigger/tekton", strings.NewReader(`{"job":"`+job+`"}`))
+		req.Header.Set("Authorization", "Bearer ops-key")
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d: %s", job, rr.Code, rr.Body.String())
+		}
+	}
+
+	getMe := func(key string) handlers.MeUsage {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var me handlers.MeResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &me); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return me.Usage
+	}
+
+	// An unrestricted key sees every category
+	usage := getMe("ops-key")
+	if !usage.QuotasConfigured || len(usage.Quotas) != 2 {
+		t.Fatalf("Expected both categories, got %+v", usage)
+	}
+	deploy, test := usage.Quotas[0], usage.Quotas[1]
+	if deploy.Category != "deploy" || deploy.MaxConcurrent != 2 || deploy.Running == nil || *deploy.Running != 1 {
+		t.Errorf("Expected one of two deploys running, got %+v", deploy)
+	}
+	if test.Category != "test" || test.MaxTriggers != 5 || test.WindowSeconds != 300 || test.Triggers != 2 {
+		t.Errorf("Expected two of five test triggers in 300s, got %+v", test)
+	}
+
+	// A key restricted to deploy jobs sees only the deploy category
+	usage = getMe("bot-key")
+	if !usage.QuotasConfigured || len(usage.Quotas) != 1 || usage.Quotas[0].Category != "deploy" {
+		t.Errorf("Expected only the deploy category, got %+v", usage.Quotas)
+	}
+}
@@ -3,12 +3,19 @@ package unit
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
 )
 
 func TestLimitBodySize(t *testing.T) {
@@ -251,3 +258,161 @@ func TestRequestIDMiddlewareContextPropagation(t *testing.T) {
 		t.Error("Request ID in header should match context")
 	}
 }
+
+func TestDeprecate(t *testing.T) {
+	since := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC)
+	deprecate := middleware.Deprecate([]config.DeprecationConfig{
+		{Route: "/api/v1/jenkins", Since: since, Sunset: sunset, Link: "https://docs.example.com/v2"},
+		{Route: "/api/v1/jenkins/jobs", Message: "Use /api/v2/jobs"},
+	})
+	handler := deprecate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/jenkins/builds/deploy/1":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"job":"deploy","number":1}` + "\n"))
+		case "/api/v1/jenkins/jobs":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`[{"name":"deploy"}]`))
+		case "/api/v1/jenkins/empty":
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{}`))
+		default:
+			w.Write([]byte("OK"))
+		}
+	}))
+
+	tests := []struct {
+		name            string
+		path            string
+		wantDeprecation string
+		wantSunset      string
+		wantBody        string
+	}{
+		{
+			name:            "JSON object gets the warning",
+			path:            "/api/v1/jenkins/builds/deploy/1",
+			wantDeprecation: "@1780272000",
+			wantSunset:      "Wed, 30 Jun 2027 00:00:00 GMT",
+			wantBody:        `{"job":"deploy","number":1,"warning":"/api/v1/jenkins is deprecated and may be removed after 2027-06-30; see https://docs.example.com/v2"}` + "\n",
+		},
+		{
+			name:            "Most specific route applies; arrays are unchanged",
+			path:            "/api/v1/jenkins/jobs",
+			wantDeprecation: "true",
+			wantBody:        `[{"name":"deploy"}]`,
+		},
+		{
+			name:            "Empty problem details object",
+			path:            "/api/v1/jenkins/empty",
+			wantDeprecation: "@1780272000",
+			wantSunset:      "Wed, 30 Jun 2027 00:00:00 GMT",
+			wantBody:        `{"warning":"/api/v1/jenkins is deprecated and may be removed after 2027-06-30; see https://docs.example.com/v2"}`,
+		},
+		{
+			name:     "Route prefix does not match longer names",
+			path:     "/api/v1/jenkinsx",
+			wantBody: "OK",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if got := rr.Header().Get("Deprecation"); got != tt.wantDeprecation {
+				t.Errorf("Expected Deprecation %q, got %q", tt.wantDeprecation, got)
+			}
+			if got := rr.Header().Get("Sunset"); got != tt.wantSunset {
+				t.Errorf("Expected Sunset %q, got %q", tt.wantSunset, got)
+			}
+			if got := rr.Body.String(); got != tt.wantBody {
+				t.Errorf("Expected body %s, got %s", tt.wantBody, got)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/jenkins/empty", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 to be kept, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Link"); got != `<https://docs.example.com/v2>; rel="deprecation"` {
+		t.Errorf("Unexpected Link header %q", got)
+	}
+}
+
+func TestResponseEnvelope(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.ResponseEnvelope = true
+	cfg.Server.MaxBodySize = 1024 * 1024
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	for _, job := range []string{"deploy", "build"} {
+		if err := storage.InsertAuditLog(models.AuditLog{Timestamp: time.Now(), APIKey: "test-key", Status: http.StatusOK, JobName: job}); err != nil {
+			t.Fatalf("Failed to insert audit log: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/audit?limit=1", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	req.Header.Set("X-Request-ID", "envelope-test")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get(middleware.EnvelopeHeader) != "true" {
+		t.Errorf("Expected the %s header", middleware.EnvelopeHeader)
+	}
+	var envelope middleware.ResponseEnvelope
+	if err := json.Unmarshal(rr.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("Failed to decode envelope: %v", err)
+	}
+	if envelope.RequestID != "envelope-test" || envelope.Meta.Status != http.StatusOK {
+		t.Errorf("Unexpected envelope %s", rr.Body.String())
+	}
+	if envelope.Meta.NextCursor == "" || envelope.Meta.NextCursor != rr.Header().Get("X-Next-Cursor") {
+		t.Errorf("Expected the next cursor in meta, got %q", envelope.Meta.NextCursor)
+	}
+	var logs []models.AuditLog
+	if err := json.Unmarshal(envelope.Data, &logs); err != nil || len(logs) != 1 || logs[0].JobName != "build" {
+		t.Errorf("Expected the audit page in data, got %s", envelope.Data)
+	}
+
+	// Trigger responses are wrapped too
+	router.AddEngine("tekton", "custom", &MockCIEngine{TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+		return &engine.BuildResult{Success: true, BuildID: "run-1"}, nil
+	}})
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/trigger/tekton", strings.NewReader(`{"job":"build-web"}`))
+	req.Header.Set("Authorization", "Bearer test-key")
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "application/json") {
+		t.Fatalf("Expected a JSON 200, got %d %q: %s", rr.Code, rr.Header().Get("Content-Type"), rr.Body.String())
+	}
+	if rr.Header().Get(middleware.EnvelopeHeader) != "true" {
+		t.Errorf("Expected the %s header on the trigger response", middleware.EnvelopeHeader)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(rr.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("Failed to decode trigger response: %v", err)
+	}
+	if _, ok := raw["build_id"]; ok || raw["meta"] == nil || !strings.Contains(string(raw["data"]), `"build_id":"run-1"`) {
+		t.Errorf("Expected the trigger result in data, got %s", rr.Body.String())
+	}
+
+	// Errors keep their shape
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/trigger/jenkins", strings.NewReader(`{}`))
+	req.Header.Set("Authorization", "Bearer test-key")
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest || rr.Header().Get(middleware.EnvelopeHeader) != "" || strings.Contains(rr.Body.String(), `"data"`) {
+		t.Errorf("Expected an unwrapped 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,137 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine/httpengine"
+	"triggermesh/internal/outbound"
+)
+
+func newTestPolicy(t *testing.T, cfg config.OutboundConfig) *outbound.Policy {
+	t.Helper()
+	if cfg.AllowedSchemes == nil {
+		cfg.AllowedSchemes = []string{"http", "https"}
+	}
+	if cfg.DeniedCIDRs == nil {
+		cfg.DeniedCIDRs = config.DefaultDeniedCIDRs
+	}
+	policy, err := outbound.NewPolicy(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create policy: %v", err)
+	}
+	return policy
+}
+
+func TestOutboundPolicyCheckURL(t *testing.T) {
+	policy := newTestPolicy(t, config.OutboundConfig{})
+	allowlisted := newTestPolicy(t, config.OutboundConfig{AllowedHosts: []string{"ci.example.com", "*.build.example.com"}})
+
+	tests := []struct {
+		name    string
+		policy  *outbound.Policy
+		url     string
+		allowed bool
+	}{
+		{"public https", policy, "https://ci.example.com/api", true},
+		{"private address", policy, "http://10.0.0.5:8080/", true},
+		{"file scheme", policy, "file:///etc/passwd", false},
+		{"gopher scheme", policy, "gopher://ci.example.com/", false},
+		{"metadata address", policy, "http://169.254.169.254/latest/meta-data/", false},
+		{"mapped metadata address", policy, "http://[::ffff:169.254.169.254]/", false},
+		{"IPv6 metadata address", policy, "http://[fd00:ec2::254]/", false},
+		{"unspecified address", policy, "http://0.0.0.0:8080/", false},
+		{"allowlisted host", allowlisted, "https://ci.example.com/api", true},
+		{"allowlisted wildcard", allowlisted, "https://eu.build.example.com/", true},
+		{"host outside allowlist", allowlisted, "https://evil.example.net/", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.CheckURL(tt.url)
+			if (err == nil) != tt.allowed {
+				t.Errorf("CheckURL(%q) = %v, allowed %v", tt.url, err, tt.allowed)
+			}
+			if err != nil && !outbound.IsDenied(err) {
+				t.Errorf("Expected a DeniedError, got %T", err)
+			}
+		})
+	}
+}
+
+func TestOutboundPolicyClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := newTestPolicy(t, config.OutboundConfig{}).Client(5 * time.Second)
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected request to be allowed, got %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := client.Get(server.URL + "/redirect"); !outbound.IsDenied(err) {
+		t.Errorf("Expected redirect to the metadata service to be denied, got %v", err)
+	}
+
+	// The host name passes the URL check; its resolved address is denied when connecting
+	denyLoopback := newTestPolicy(t, config.OutboundConfig{DeniedCIDRs: []string{"127.0.0.0/8", "::1/128"}})
+	localhostURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	if err := denyLoopback.CheckURL(localhostURL); err != nil {
+		t.Fatalf("Expected host name to pass the URL check, got %v", err)
+	}
+	if _, err := denyLoopback.Client(5 * time.Second).Get(localhostURL); !outbound.IsDenied(err) {
+		t.Errorf("Expected resolved loopback address to be denied, got %v", err)
+	}
+}
+
+func TestOutboundPolicyClientIgnoresProxy(t *testing.T) {
+	// A proxy would connect to the target itself, out of reach of the address checks
+	proxyHits := 0
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxyHits++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("http_proxy", proxy.URL)
+	t.Setenv("NO_PROXY", "")
+
+	// The host name passes the URL check and could resolve to a denied address
+	client := newTestPolicy(t, config.OutboundConfig{}).Client(5 * time.Second)
+	resp, err := client.Get("http://metadata.internal.invalid/latest/meta-data/")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("Expected the request to fail to resolve the host, got a response")
+	}
+	if proxyHits != 0 {
+		t.Errorf("Expected the request not to go through the proxy, got %d proxied requests", proxyHits)
+	}
+}
+
+func TestHTTPEngineOutboundPolicy(t *testing.T) {
+	var received map[string]interface{}
+	server := newRESTCIServer(t, &received)
+	policy := newTestPolicy(t, config.OutboundConfig{AllowedHosts: []string{"ci.example.com"}})
+
+	e, err := httpengine.New("restci", restCIEngineConfig(server.URL), policy)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	_, err = e.TriggerBuild("web", map[string]string{"BRANCH": "main"})
+	if err == nil || !strings.Contains(err.Error(), "outbound URL policy") {
+		t.Errorf("Expected the trigger to be blocked, got %v", err)
+	}
+	if received != nil {
+		t.Errorf("Expected no request to reach the engine, got %v", received)
+	}
+}
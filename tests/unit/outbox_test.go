@@ -0,0 +1,143 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/outbox"
+	"triggermesh/internal/signature"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func TestOutboxDeliversAuditEntries(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-outbox-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	var mu sync.Mutex
+	var received []outbox.AuditEvent
+	var eventIDs []string
+	up := true
+	siem := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if err := signature.Verify(r.Header.Get(signature.Header), "siem-secret", body, time.Now(), signature.DefaultTolerance); err != nil {
+			t.Errorf("Invalid signature: %v", err)
+		}
+		var event outbox.AuditEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("Failed to decode event: %v", err)
+		}
+		received = append(received, event)
+		eventIDs = append(eventIDs, r.Header.Get(outbox.EventIDHeader))
+	}))
+	defer siem.Close()
+
+	cfg := config.OutboxConfig{
+		Destinations: []config.OutboxDestinationConfig{
+			{Name: "siem", URL: siem.URL, Secret: "siem-secret", Timeout: 5},
+			{Name: "deploys", URL: siem.URL + "/deploys", Secret: "siem-secret", Jobs: []string{"deploy-*"}, Timeout: 5},
+		},
+		PollInterval:  1,
+		MaxAttempts:   2,
+		RetentionDays: 7,
+	}
+	dispatcher := outbox.NewDispatcher(cfg, nil)
+	storage.SetOutboxEventBuilder(dispatcher.Events)
+	defer storage.SetOutboxEventBuilder(nil)
+
+	// Events are written with the entry: one per destination forwarding the job
+	for _, job := range []string{"deploy-web", "build-web"} {
+		if err := storage.InsertAuditLog(models.AuditLog{Timestamp: time.Now(), APIKey: "secret-key", Status: http.StatusOK, JobName: job}); err != nil {
+			t.Fatalf("Failed to insert audit log: %v", err)
+		}
+	}
+	counts, err := storage.CountOutboxEvents()
+	if err != nil || counts[models.OutboxPending] != 3 {
+		t.Fatalf("Expected 3 pending events, got %v (%v)", counts, err)
+	}
+
+	delivered, err := dispatcher.Dispatch(context.Background())
+	if err != nil || delivered != 3 {
+		t.Fatalf("Expected 3 deliveries, got %d (%v)", delivered, err)
+	}
+	if received[0].Type != outbox.EventTypeAudit || received[0].Audit.JobName != "deploy-web" || received[0].Audit.ID == 0 {
+		t.Errorf("Unexpected first event: %+v", received[0])
+	}
+	if received[0].Audit.APIKey != "" || received[0].KeyID == "" {
+		t.Errorf("Expected the key ID instead of the API key, got %+v", received[0])
+	}
+	if eventIDs[0] == "" || eventIDs[0] == eventIDs[1] {
+		t.Errorf("Expected distinct event IDs, got %v", eventIDs)
+	}
+	if delivered, _ := dispatcher.Dispatch(context.Background()); delivered != 0 {
+		t.Errorf("Expected delivered events not to be sent again, got %d", delivered)
+	}
+
+	// A failed delivery stays pending for a later attempt, including after a restart, and is given
+	// up after the maximum attempts
+	mu.Lock()
+	up = false
+	mu.Unlock()
+	if err := storage.InsertAuditLog(models.AuditLog{Timestamp: time.Now(), Status: http.StatusOK, JobName: "build-api"}); err != nil {
+		t.Fatalf("Failed to insert audit log: %v", err)
+	}
+	if delivered, err := dispatcher.Dispatch(context.Background()); err != nil || delivered != 0 {
+		t.Fatalf("Expected no deliveries while the destination is down, got %d (%v)", delivered, err)
+	}
+	if counts, _ := storage.CountOutboxEvents(); counts[models.OutboxPending] != 1 {
+		t.Fatalf("Expected the failed event to stay pending, got %v", counts)
+	}
+	due, err := storage.GetDueOutboxEvents(time.Now().Add(time.Minute), 10)
+	if err != nil || len(due) != 1 || due[0].Attempts != 1 || due[0].LastError == "" {
+		t.Fatalf("Expected one retried event with its error, got %+v (%v)", due, err)
+	}
+	if !due[0].NextAttemptAt.After(time.Now()) {
+		t.Errorf("Expected the retry to be backed off, got %v", due[0].NextAttemptAt)
+	}
+
+	due[0].NextAttemptAt = time.Now()
+	if err := storage.UpdateOutboxEvent(due[0]); err != nil {
+		t.Fatalf("Failed to update event: %v", err)
+	}
+	restarted := outbox.NewDispatcher(cfg, nil)
+	if _, err := restarted.Dispatch(context.Background()); err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	if counts, _ := storage.CountOutboxEvents(); counts[models.OutboxDead] != 1 || counts[models.OutboxDelivered] != 3 {
+		t.Errorf("Expected the event to be dead after 2 attempts, got %v", counts)
+	}
+
+	// Events of a removed destination are given up
+	if err := storage.InsertAuditLog(models.AuditLog{Timestamp: time.Now(), Status: http.StatusOK, JobName: "build-api"}); err != nil {
+		t.Fatalf("Failed to insert audit log: %v", err)
+	}
+	cfg.Destinations = cfg.Destinations[1:]
+	if _, err := outbox.NewDispatcher(cfg, nil).Dispatch(context.Background()); err != nil {
+		t.Fatalf("Dispatch failed: %v", err)
+	}
+	if counts, _ := storage.CountOutboxEvents(); counts[models.OutboxDead] != 2 || counts[models.OutboxPending] != 0 {
+		t.Errorf("Expected the event of the removed destination to be dead, got %v", counts)
+	}
+}
@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"triggermesh/internal/api/handlers"
+)
+
+// newOverviewJenkins returns a mock Jenkins with three queued builds, one of them stuck, and
+// three nodes: the built-in node fully busy, an idle agent, and an offline agent
+// It counts node requests so tests can check caching
+func newOverviewJenkins(t *testing.T, requests *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/queue/api/json":
+			w.Write([]byte(`{"items":[{"stuck":false},{"stuck":true},{"stuck":false}]}`))
+		case "/computer/api/json":
+			atomic.AddInt32(requests, 1)
+			w.Write([]byte(`{"busyExecutors":2,"computer":[
+				{"displayName":"Built-In Node","offline":false,"numExecutors":2,"executors":[{"idle":false},{"idle":false}]},
+				{"displayName":"agent-1","offline":false,"numExecutors":4,"executors":[{"idle":true},{"idle":true},{"idle":true},{"idle":true}]},
+				{"displayName":"agent-2","offline":true,"numExecutors":4,"executors":[]}
+			]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetJenkinsOverview(t *testing.T) {
+	var requests int32
+	server := newOverviewJenkins(t, &requests)
+
+	cfg := defaultTestConfig()
+	cfg.Jenkins.URL = server.URL
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jenkins/overview", nil)
+		req.Header.Set("Authorization", "Bearer test-key")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var overview handlers.OverviewResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &overview); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if overview.QueueLength != 3 || overview.QueueStuck != 1 {
+		t.Errorf("Expected 3 queued builds with 1 stuck, got %d and %d", overview.QueueLength, overview.QueueStuck)
+	}
+	if overview.BusyExecutors != 2 || overview.IdleExecutors != 4 || overview.TotalExecutors != 6 {
+		t.Errorf("Expected 2 busy and 4 idle of 6 executors, got %+v", overview.Overview)
+	}
+	if len(overview.Nodes) != 3 {
+		t.Fatalf("Expected 3 nodes, got %+v", overview.Nodes)
+	}
+	if builtIn := overview.Nodes[0]; !builtIn.Online || builtIn.BusyExecutors != 2 {
+		t.Errorf("Unexpected built-in node: %+v", builtIn)
+	}
+	if offline := overview.Nodes[2]; offline.Online || offline.Name != "agent-2" {
+		t.Errorf("Expected agent-2 offline, got %+v", offline)
+	}
+	if overview.FetchedAt.IsZero() {
+		t.Error("Expected fetched_at to be set")
+	}
+
+	// The overview is served from cache
+	if rr := get(); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("Expected 1 Jenkins node request, got %d", got)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jenkins/overview", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/quota"
+	"triggermesh/internal/storage"
+)
+
+func TestQuotaLimiter(t *testing.T) {
+	running := map[string]int{"deploy-web": 1, "test-web": 5}
+	var countErr error
+	limiter := quota.NewLimiter(config.QuotasConfig{Categories: []config.QuotaCategoryConfig{
+		{Name: "deploy", Jobs: []string{"deploy-*"}, MaxConcurrent: 2, Window: 60},
+		{Name: "test", Jobs: []string{"test-*", "*-tests"}, MaxTriggers: 2, Window: 60},
+	}}, func() (map[string]int, error) { return running, countErr })
+
+	// One deploy is running; an admitted trigger counts as running until it is released
+	release, err := limiter.Admit("deploy-api")
+	if err != nil {
+		t.Fatalf("Expected the first deploy to be admitted, got %v", err)
+	}
+	_, err = limiter.Admit("deploy-db")
+	var exceeded *quota.ExceededError
+	if !errors.As(err, &exceeded) || exceeded.Limit != quota.LimitConcurrent || exceeded.Running != 2 {
+		t.Fatalf("Expected the concurrency limit, got %v", err)
+	}
+	release()
+	release()
+	if _, err := limiter.Admit("deploy-db"); err != nil {
+		t.Errorf("Expected a deploy to be admitted after the release, got %v", err)
+	}
+
+	// A failed count lets triggers through
+	countErr = errors.New("database is locked")
+	if _, err := limiter.Admit("deploy-db"); err != nil {
+		t.Errorf("Expected a failed count to admit the trigger, got %v", err)
+	}
+
+	// Test triggers are limited by rate, not by running builds
+	for _, job := range []string{"test-web", "api-tests"} {
+		if _, err := limiter.Admit(job); err != nil {
+			t.Fatalf("Expected %s to be admitted, got %v", job, err)
+		}
+	}
+	_, err = limiter.Admit("test-web")
+	if !errors.As(err, &exceeded) || exceeded.Category != "test" || exceeded.Limit != quota.LimitTriggers {
+		t.Fatalf("Expected the trigger limit, got %v", err)
+	}
+	if exceeded.RetryAfter <= 0 || exceeded.RetryAfter > 61*time.Second {
+		t.Errorf("Expected a retry within the window, got %v", exceeded.RetryAfter)
+	}
+
+	// Jobs of no category are not limited
+	for i := 0; i < 5; i++ {
+		if _, err := limiter.Admit("build-web"); err != nil {
+			t.Fatalf("Expected an uncategorized job to be admitted, got %v", err)
+		}
+	}
+}
+
+func TestTriggerQuotas(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 1024 * 1024
+	cfg.Stats.Enabled = true
+	cfg.Quotas = config.QuotasConfig{Categories: []config.QuotaCategoryConfig{
+		{Name: "deploy", Jobs: []string{"deploy-*"}, MaxConcurrent: 1, Window: 60},
+		{Name: "test", Jobs: []string{"test-*"}, MaxTriggers: 1, Window: 60},
+	}}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	builds := 0
+	router.AddEngine("tekton", "custom", &MockCIEngine{TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+		builds++
+		return &engine.BuildResult{Success: true, BuildID: fmt.Sprintf("run-%d", builds)}, nil
+	}})
+
+	trigger := func(job string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/trigger/tekton", strings.NewReader(`{"job":"`+job+`"}`))
+		req.Header.Set("Authorization", "Bearer test-key")
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	refused := func(rr *httptest.ResponseRecorder, limit string) {
+		t.Helper()
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected status 429, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if body["code"] != "QUOTA_EXCEEDED" || body["limit"] != limit {
+			t.Errorf("Expected QUOTA_EXCEEDED on %s, got %v", limit, body)
+		}
+	}
+
+	if rr := trigger("test-unit"); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr := trigger("test-unit")
+	refused(rr, quota.LimitTriggers)
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After on the trigger limit")
+	}
+
+	// The running deploy holds the only slot until its outcome is recorded
+	if rr := trigger("deploy-prod"); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	refused(trigger("deploy-prod"), quota.LimitConcurrent)
+	if err := storage.UntrackBuild("run-2"); err != nil {
+		t.Fatalf("Failed to untrack build: %v", err)
+	}
+	if rr := trigger("deploy-prod"); rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200 once the deploy finished, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if builds != 3 {
+		t.Errorf("Expected 3 builds, got %d", builds)
+	}
+
+	// Refused triggers are audited as denied
+	logs, err := storage.GetAuditLogs(10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit logs: %v", err)
+	}
+	denied := 0
+	for _, log := range logs {
+		if log.Result == "denied" && log.Status == http.StatusTooManyRequests {
+			denied++
+		}
+	}
+	if denied != 2 {
+		t.Errorf("Expected 2 denied triggers in the audit log, got %d", denied)
+	}
+}
@@ -0,0 +1,328 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/scheduler"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func TestRecurringDue(t *testing.T) {
+	newRecurring := func(misfire string) *scheduler.Recurring {
+		schedule, err := scheduler.NewRecurring(config.ScheduleConfig{
+			Name: "hourly", Job: "report", Schedule: "0 * * * *", Misfire: misfire, MisfireGrace: 60, MaxCatchUp: 2,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create schedule: %v", err)
+		}
+		return schedule
+	}
+	at := func(hour, minute, second int) time.Time {
+		return time.Date(2026, 3, 2, hour, minute, second, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		misfire string
+		last    time.Time
+		now     time.Time
+		fires   []time.Time
+		latest  time.Time
+		missed  bool
+	}{
+		{"Nothing due", config.MisfireRunOnce, at(10, 0, 0), at(10, 59, 0), nil, time.Time{}, false},
+		{"On time", config.MisfireSkip, at(10, 0, 0), at(11, 0, 5), []time.Time{at(11, 0, 0)}, at(11, 0, 0), false},
+		{"Skip drops late fire times", config.MisfireSkip, at(7, 0, 0), at(10, 30, 0), nil, at(10, 0, 0), true},
+		{"Skip runs an on-time fire after downtime", config.MisfireSkip, at(7, 0, 0), at(10, 0, 30), []time.Time{at(10, 0, 0)}, at(10, 0, 0), true},
+		{"Run once", config.MisfireRunOnce, at(7, 0, 0), at(10, 30, 0), []time.Time{at(10, 0, 0)}, at(10, 0, 0), true},
+		{"Catch up the most recent", config.MisfireCatchUp, at(7, 0, 0), at(10, 30, 0), []time.Time{at(9, 0, 0), at(10, 0, 0)}, at(10, 0, 0), true},
+		{"Registration time between fire times", config.MisfireCatchUp, at(8, 59, 30), at(9, 0, 10), []time.Time{at(9, 0, 0)}, at(9, 0, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fires, latest, missed := newRecurring(tt.misfire).Due(tt.last, tt.now)
+			if fmt.Sprint(fires) != fmt.Sprint(tt.fires) || !latest.Equal(tt.latest) || missed != tt.missed {
+				t.Errorf("Expected fires %v, latest %v, missed %v; got %v, %v, %v", tt.fires, tt.latest, tt.missed, fires, latest, missed)
+			}
+		})
+	}
+}
+
+func TestRecurringSchedules(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-recurring-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	builds := 0
+	newHandler := func() *handlers.JenkinsHandler {
+		return handlers.NewJenkinsHandler(&MockCIEngine{
+			TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+				builds++
+				return &engine.BuildResult{Success: true, BuildID: fmt.Sprintf("%s/%d", jobName, builds)}, nil
+			},
+		})
+	}
+
+	// A daily schedule that last fired two hours ago, so its next fire time is far ahead
+	fireAt := time.Now().UTC().Add(-2 * time.Hour)
+	expr := fmt.Sprintf("%d %d * * *", fireAt.Minute(), fireAt.Hour())
+	newSchedule := func(name, misfire string) *scheduler.Recurring {
+		schedule, err := scheduler.NewRecurring(config.ScheduleConfig{
+			Name: name, Job: "nightly-report", Schedule: expr, Misfire: misfire, MisfireGrace: 60, MaxCatchUp: 10,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create schedule: %v", err)
+		}
+		return schedule
+	}
+
+	tests := []struct {
+		misfire string
+		fired   int
+	}{
+		{config.MisfireSkip, 0},
+		{config.MisfireRunOnce, 1},
+		{config.MisfireCatchUp, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.misfire, func(t *testing.T) {
+			name := "report-" + tt.misfire
+			// The service was down for three days
+			if err := storage.RegisterSchedule(name, time.Now().Add(-72*time.Hour)); err != nil {
+				t.Fatalf("Failed to register schedule: %v", err)
+			}
+			handler := newHandler()
+			handler.SetSchedules([]*scheduler.Recurring{newSchedule(name, tt.misfire)})
+			builds = 0
+
+			fired, err := handler.FireRecurringSchedules(context.Background())
+			if err != nil || fired != tt.fired || builds != tt.fired {
+				t.Fatalf("Expected %d fired triggers, got %d (%d builds, error %v)", tt.fired, fired, builds, err)
+			}
+			last, ok, err := storage.GetScheduleLastFire(name)
+			if err != nil || !ok || !last.Equal(fireAt.Truncate(time.Minute)) {
+				t.Errorf("Expected the last fire time %v to be stored, got %v (%v)", fireAt.Truncate(time.Minute), last, err)
+			}
+
+			// A restart or another instance does not fire the claimed fire times again
+			restarted := newHandler()
+			restarted.SetSchedules([]*scheduler.Recurring{newSchedule(name, tt.misfire)})
+			if fired, err := restarted.FireRecurringSchedules(context.Background()); err != nil || fired != 0 {
+				t.Errorf("Expected no trigger after the restart, got %d (%v)", fired, err)
+			}
+		})
+	}
+
+	entries, err := storage.GetAuditLogs(100, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit logs: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Source != models.SourceSchedule || entry.APIKey != "schedule:report-"+config.MisfireCatchUp && entry.APIKey != "schedule:report-"+config.MisfireRunOnce {
+			t.Errorf("Unexpected audit entry: %+v", entry)
+		}
+	}
+	if len(entries) != 4 {
+		t.Errorf("Expected 4 audit entries, got %d", len(entries))
+	}
+
+	// A new schedule starts from its next fire time
+	handler := newHandler()
+	handler.SetSchedules([]*scheduler.Recurring{newSchedule("new-report", config.MisfireCatchUp)})
+	if fired, err := handler.FireRecurringSchedules(context.Background()); err != nil || fired != 0 {
+		t.Errorf("Expected a new schedule not to fire, got %d (%v)", fired, err)
+	}
+	if _, ok, _ := storage.GetScheduleLastFire("new-report"); !ok {
+		t.Error("Expected the new schedule to be registered")
+	}
+}
+
+func TestRecurringScheduleRetries(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-recurring-retry-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	attempts, builds := 0, 0
+	newHandler := func(failing bool) *handlers.JenkinsHandler {
+		return handlers.NewJenkinsHandler(&MockCIEngine{
+			TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+				attempts++
+				if failing {
+					return nil, engine.NewError(engine.ErrorKindServer, "jenkins unavailable")
+				}
+				builds++
+				return &engine.BuildResult{Success: true, BuildID: fmt.Sprintf("%s/%d", jobName, builds)}, nil
+			},
+		})
+	}
+
+	// A daily schedule due two hours ago, registered before that
+	fireAt := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Minute)
+	expr := fmt.Sprintf("%d %d * * *", fireAt.Minute(), fireAt.Hour())
+	newSchedules := func(name string) []*scheduler.Recurring {
+		schedule, err := scheduler.NewRecurring(config.ScheduleConfig{
+			Name: name, Job: "nightly-report", Schedule: expr, Misfire: config.MisfireRunOnce, MisfireGrace: 60, MaxCatchUp: 10,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create schedule: %v", err)
+		}
+		return []*scheduler.Recurring{schedule}
+	}
+	register := func(name string) {
+		if err := storage.RegisterSchedule(name, time.Now().Add(-3*time.Hour)); err != nil {
+			t.Fatalf("Failed to register schedule: %v", err)
+		}
+	}
+	fire := func(handler *handlers.JenkinsHandler, expected int) {
+		t.Helper()
+		if fired, err := handler.FireRecurringSchedules(context.Background()); err != nil || fired != expected {
+			t.Fatalf("Expected %d fired triggers, got %d (%v)", expected, fired, err)
+		}
+	}
+
+	t.Run("Failed trigger retried on the next tick and after a restart", func(t *testing.T) {
+		register("retried")
+		attempts, builds = 0, 0
+		failing := newHandler(true)
+		failing.SetSchedules(newSchedules("retried"))
+		fire(failing, 0)
+		fire(failing, 0)
+		if attempts != 2 {
+			t.Fatalf("Expected the failed fire time to be attempted again on the next tick, got %d attempts", attempts)
+		}
+
+		restarted := newHandler(false)
+		restarted.SetSchedules(newSchedules("retried"))
+		fire(restarted, 1)
+		fire(restarted, 0)
+		if builds != 1 {
+			t.Errorf("Expected one build once the engine recovered, got %d", builds)
+		}
+		if last, _, _ := storage.GetScheduleLastFire("retried"); !last.Equal(fireAt) {
+			t.Errorf("Expected the last fire time %v, got %v", fireAt, last)
+		}
+	})
+
+	t.Run("Fire time given up after the last attempt", func(t *testing.T) {
+		register("abandoned")
+		attempts, builds = 0, 0
+		failing := newHandler(true)
+		failing.SetSchedules(newSchedules("abandoned"))
+		for i := 0; i < 6; i++ {
+			fire(failing, 0)
+		}
+		if attempts != 5 {
+			t.Errorf("Expected 5 attempts, got %d", attempts)
+		}
+
+		recovered := newHandler(false)
+		recovered.SetSchedules(newSchedules("abandoned"))
+		fire(recovered, 0)
+		if builds != 0 {
+			t.Errorf("Expected the abandoned fire time not to be triggered, got %d builds", builds)
+		}
+	})
+
+	t.Run("Trigger interrupted before reaching the engine retried once its lease ran out", func(t *testing.T) {
+		register("interrupted")
+		last, _, _ := storage.GetScheduleLastFire("interrupted")
+		// The instance that claimed the fire time stopped before triggering it
+		claimed, err := storage.ClaimScheduleFire("interrupted", last, models.PendingScheduleFire{
+			Name: "interrupted", FireAt: fireAt, TriggerID: "trigger-interrupted", Attempts: 1, RetryAt: time.Now().Add(time.Minute),
+		})
+		if err != nil || !claimed {
+			t.Fatalf("Failed to claim the fire time: %v", err)
+		}
+
+		attempts, builds = 0, 0
+		handler := newHandler(false)
+		handler.SetSchedules(newSchedules("interrupted"))
+		fire(handler, 0)
+		if attempts != 0 {
+			t.Fatalf("Expected the leased fire time not to be retried, got %d attempts", attempts)
+		}
+
+		retries, err := storage.ClaimScheduleRetries("interrupted", time.Now().Add(2*time.Minute), time.Now().Add(-time.Second))
+		if err != nil || len(retries) != 1 || retries[0].TriggerID != "trigger-interrupted" || retries[0].Attempts != 2 {
+			t.Fatalf("Expected the fire time to be claimed once its lease ran out, got %+v (%v)", retries, err)
+		}
+		fire(handler, 1)
+		entry, err := storage.GetAuditLogByTriggerID("trigger-interrupted")
+		if err != nil || entry == nil || entry.Result != "success" || entry.Source != models.SourceSchedule {
+			t.Errorf("Expected a successful audit entry for the retried trigger, got %+v (%v)", entry, err)
+		}
+	})
+
+	t.Run("Trigger that succeeded before a crash not repeated", func(t *testing.T) {
+		register("crashed")
+		last, _, _ := storage.GetScheduleLastFire("crashed")
+		if _, err := storage.ClaimScheduleFire("crashed", last, models.PendingScheduleFire{
+			Name: "crashed", FireAt: fireAt, TriggerID: "trigger-crashed", Attempts: 1, RetryAt: time.Now().Add(-time.Second),
+		}); err != nil {
+			t.Fatalf("Failed to claim the fire time: %v", err)
+		}
+		if err := storage.InsertAuditLog(models.AuditLog{
+			Timestamp: time.Now(), APIKey: "schedule:crashed", Method: "POST", Path: "/api/v1/trigger", Status: 200,
+			JobName: "nightly-report", Result: "success", Source: models.SourceSchedule, TriggerID: "trigger-crashed",
+		}); err != nil {
+			t.Fatalf("Failed to insert audit log: %v", err)
+		}
+
+		attempts = 0
+		handler := newHandler(false)
+		handler.SetSchedules(newSchedules("crashed"))
+		fire(handler, 0)
+		fire(handler, 0)
+		if attempts != 0 {
+			t.Errorf("Expected the triggered fire time not to be triggered again, got %d attempts", attempts)
+		}
+	})
+
+	t.Run("Trigger interrupted after reaching the engine not repeated", func(t *testing.T) {
+		register("dispatched")
+		last, _, _ := storage.GetScheduleLastFire("dispatched")
+		// The instance stopped after calling the engine, before the build was audited
+		if _, err := storage.ClaimScheduleFire("dispatched", last, models.PendingScheduleFire{
+			Name: "dispatched", FireAt: fireAt, TriggerID: "trigger-dispatched", Attempts: 1, RetryAt: time.Now().Add(-time.Second),
+		}); err != nil {
+			t.Fatalf("Failed to claim the fire time: %v", err)
+		}
+		if err := storage.DispatchScheduleFire("dispatched", fireAt); err != nil {
+			t.Fatalf("Failed to mark the fire time dispatched: %v", err)
+		}
+
+		attempts = 0
+		handler := newHandler(false)
+		handler.SetSchedules(newSchedules("dispatched"))
+		fire(handler, 0)
+		if attempts != 0 {
+			t.Errorf("Expected the dispatched fire time not to be triggered again, got %d attempts", attempts)
+		}
+		retries, err := storage.ClaimScheduleRetries("dispatched", time.Now().Add(time.Hour), time.Now().Add(time.Hour))
+		if err != nil || len(retries) != 0 {
+			t.Errorf("Expected the dispatched fire time to be given up, got %+v (%v)", retries, err)
+		}
+	})
+}
@@ -0,0 +1,269 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/api/middleware"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+// newReplayRequest builds an authenticated replay request for the given audit entry
+func newReplayRequest(id int64, body string, principal *middleware.Principal) *http.Request {
+	req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/audit/%d/replay", id), bytes.NewReader([]byte(body)))
+	ctx := context.WithValue(req.Context(), middleware.PrincipalContextKey, principal)
+	return req.WithContext(ctx)
+}
+
+func TestReplayAuditEntry(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-replay-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	var triggeredParams map[string]string
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			triggeredParams = params
+			return &engine.BuildResult{Success: true, BuildID: jobName + "/2"}, nil
+		},
+	})
+
+	// A failed deploy recorded in the audit log
+	if err := storage.InsertAuditLog(models.AuditLog{
+		Method:  "POST",
+		Path:    "/api/v1/trigger/jenkins",
+		Status:  http.StatusBadGateway,
+		JobName: "deploy",
+		Params:  `{"env":"prod","version":"1.2.3"}`,
+		Result:  "failed",
+		Engine:  "jenkins",
+	}); err != nil {
+		t.Fatalf("Failed to insert audit log: %v", err)
+	}
+	logs, _ := storage.GetAuditLogs(1, 0)
+	originalID := logs[0].ID
+
+	admin := &middleware.Principal{Name: "ops", Scopes: []string{"admin"}}
+
+	t.Run("Requires admin scope", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ReplayAuditEntry(rr, newReplayRequest(originalID, "", &middleware.Principal{Name: "ci"}))
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Unknown entry", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ReplayAuditEntry(rr, newReplayRequest(999, "", admin))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Replays with edited parameters", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ReplayAuditEntry(rr, newReplayRequest(originalID, `{"parameters":{"version":"1.2.4"}}`, admin))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+
+		if triggeredParams["env"] != "prod" || triggeredParams["version"] != "1.2.4" {
+			t.Errorf("Expected original parameters with the edit applied, got %v", triggeredParams)
+		}
+
+		var resp handlers.TriggerJenkinsBuildResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.ReplayOf != originalID {
+			t.Errorf("Expected replay_of %d in response, got %d", originalID, resp.ReplayOf)
+		}
+
+		logs, err := storage.GetAuditLogs(1, 0)
+		if err != nil || len(logs) != 1 {
+			t.Fatalf("Expected replay audit log, got %v (err %v)", logs, err)
+		}
+		replay := logs[0]
+		if replay.ReplayOf != originalID || replay.Source != models.SourceReplay || replay.JobName != "deploy" {
+			t.Errorf("Expected replay of %d from source replay, got %+v", originalID, replay)
+		}
+	})
+
+	t.Run("Rejects non-trigger entries", func(t *testing.T) {
+		if err := storage.InsertAuditLog(models.AuditLog{Method: "GET", Path: "/api/v1/audit", Status: http.StatusOK}); err != nil {
+			t.Fatalf("Failed to insert audit log: %v", err)
+		}
+		logs, _ := storage.GetAuditLogs(1, 0)
+
+		rr := httptest.NewRecorder()
+		handler.ReplayAuditEntry(rr, newReplayRequest(logs[0].ID, "", admin))
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status 422, got %d", rr.Code)
+		}
+	})
+}
+
+func TestBulkReplay(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-bulk-replay-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	var triggered []string
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			triggered = append(triggered, jobName+":"+params["n"])
+			mu.Unlock()
+			return &engine.BuildResult{Success: true, BuildID: jobName + "/1"}, nil
+		},
+	})
+
+	outage := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	entries := []models.AuditLog{
+		{Timestamp: outage.Add(-time.Hour), JobName: "team/deploy", Params: `{"n":"0"}`, Result: "failed"}, // Before the window
+		{Timestamp: outage, JobName: "team/deploy", Params: `{"n":"1"}`, Result: "failed"},
+		{Timestamp: outage.Add(time.Minute), JobName: "team/deploy", Params: `{"n":"2"}`, Result: "success"},
+		{Timestamp: outage.Add(2 * time.Minute), JobName: "team/build", Params: `{"n":"3"}`, Result: "failed"},
+		{Timestamp: outage.Add(3 * time.Minute), JobName: "other/deploy", Params: `{"n":"4"}`, Result: "failed"},
+		{Timestamp: outage.Add(4 * time.Minute), JobName: "team/test", Params: `{"n":"5"}`, Result: "failed"},
+	}
+	for _, entry := range entries {
+		entry.Method, entry.Path, entry.Engine = "POST", "/api/v1/trigger/jenkins", "jenkins"
+		if err := storage.InsertAuditLog(entry); err != nil {
+			t.Fatalf("Failed to insert audit log: %v", err)
+		}
+	}
+
+	admin := &middleware.Principal{Name: "ops", Scopes: []string{"admin"}}
+	bulkReplay := func(body string) handlers.BulkReplayResponse {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/api/v1/audit/replay", bytes.NewReader([]byte(body)))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.PrincipalContextKey, admin))
+		rr := httptest.NewRecorder()
+		handler.BulkReplay(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp handlers.BulkReplayResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	window := `"since":"2026-03-01T10:00:00Z","until":"2026-03-01T11:00:00Z"`
+
+	// Dry run previews the failed team/* triggers in the window without triggering
+	preview := bulkReplay(`{"job":"team/*",` + window + `,"dry_run":true}`)
+	if !preview.DryRun || preview.Matched != 3 || len(preview.Results) != 3 {
+		t.Fatalf("Expected 3 previewed triggers, got %+v", preview)
+	}
+	for _, result := range preview.Results {
+		if result.Status != handlers.BulkReplayPending {
+			t.Errorf("Expected pending status in dry run, got %+v", result)
+		}
+	}
+	if len(triggered) != 0 {
+		t.Fatalf("Dry run must not trigger builds, got %v", triggered)
+	}
+
+	// Limit truncates the selection
+	limited := bulkReplay(`{"job":"team/*",` + window + `,"dry_run":true,"limit":2}`)
+	if limited.Matched != 3 || len(limited.Results) != 2 || !limited.Truncated {
+		t.Errorf("Expected 2 of 3 results with truncation, got %+v", limited)
+	}
+
+	// The real run re-triggers with bounded concurrency and links each replay
+	resp := bulkReplay(`{"job":"team/*",` + window + `,"concurrency":2}`)
+	if len(resp.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %+v", resp)
+	}
+	for _, result := range resp.Results {
+		if result.Status != handlers.BulkReplayTriggered || result.BuildID == "" {
+			t.Errorf("Expected triggered result, got %+v", result)
+		}
+	}
+	if len(triggered) != 3 {
+		t.Errorf("Expected 3 triggers, got %v", triggered)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 concurrent triggers, got %d", maxInFlight)
+	}
+
+	logs, err := storage.GetAuditLogs(3, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit logs: %v", err)
+	}
+	for _, log := range logs {
+		if log.Source != models.SourceReplay || log.ReplayOf == 0 {
+			t.Errorf("Expected linked replay audit entry, got %+v", log)
+		}
+	}
+}
+
+func TestBulkReplayValidation(t *testing.T) {
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{})
+	admin := &middleware.Principal{Name: "ops", Scopes: []string{"admin"}}
+
+	tests := []struct {
+		name      string
+		body      string
+		principal *middleware.Principal
+		status    int
+	}{
+		{"Requires admin scope", `{"since":"2026-03-01T10:00:00Z"}`, &middleware.Principal{Name: "ci"}, http.StatusForbidden},
+		{"Missing since", `{}`, admin, http.StatusBadRequest},
+		{"Inverted window", `{"since":"2026-03-02T00:00:00Z","until":"2026-03-01T00:00:00Z"}`, admin, http.StatusBadRequest},
+		{"Concurrency too high", `{"since":"2026-03-01T10:00:00Z","concurrency":100}`, admin, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/audit/replay", bytes.NewReader([]byte(tt.body)))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.PrincipalContextKey, tt.principal))
+			rr := httptest.NewRecorder()
+			handler.BulkReplay(rr, req)
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
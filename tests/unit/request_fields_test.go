@@ -0,0 +1,152 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/engine"
+)
+
+// fieldEngine is a mock engine declaring its own request fields
+type fieldEngine struct {
+	MockCIEngine
+}
+
+func (e *fieldEngine) RequestFields() []engine.RequestField {
+	return []engine.RequestField{{Name: "namespace", Required: true, Pattern: `[a-z0-9-]+`, Parameter: "NAMESPACE"}}
+}
+
+func TestEngineRequestFields(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 1024 * 1024
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	var lastParams map[string]string
+	tekton := &fieldEngine{MockCIEngine{TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+		lastParams = params
+		return &engine.BuildResult{Success: true, BuildID: "run-1"}, nil
+	}}}
+	router.AddEngine("tekton", "custom", tekton,
+		engine.RequestField{Name: "priority", Type: engine.FieldInteger},
+		engine.RequestField{Name: "dry_run", Type: engine.FieldBoolean, Parameter: "DRY_RUN"},
+		engine.RequestField{Name: "tier", Enum: []string{"gold", "silver"}},
+	)
+
+	trigger := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer test-key")
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := trigger("/api/v1/trigger/tekton", `{"job":"build","parameters":{"A":"1"},"fields":{"namespace":"ci","priority":5,"dry_run":true,"tier":"gold"}}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	want := map[string]string{"A": "1", "NAMESPACE": "ci", "priority": "5", "DRY_RUN": "true", "tier": "gold"}
+	if len(lastParams) != len(want) {
+		t.Fatalf("Expected parameters %v, got %v", want, lastParams)
+	}
+	for key, value := range want {
+		if lastParams[key] != value {
+			t.Errorf("Expected parameter %s=%s, got %q", key, value, lastParams[key])
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		path    string
+		body    string
+		message string
+	}{
+		{"missing required field", "/api/v1/trigger/tekton", `{"job":"build"}`, "Field 'namespace' is required"},
+		{"unknown field", "/api/v1/trigger/tekton", `{"job":"build","fields":{"namespace":"ci","branch":"main"}}`, "Unknown field 'branch'"},
+		{"pattern mismatch", "/api/v1/trigger/tekton", `{"job":"build","fields":{"namespace":"CI prod"}}`, "Invalid field 'namespace'"},
+		{"wrong type", "/api/v1/trigger/tekton", `{"job":"build","fields":{"namespace":"ci","priority":"high"}}`, "Field 'priority' must be an integer"},
+		{"not in enum", "/api/v1/trigger/tekton", `{"job":"build","fields":{"namespace":"ci","tier":"bronze"}}`, "must be one of gold, silver"},
+		{"conflicting parameter", "/api/v1/trigger/tekton", `{"job":"build","parameters":{"NAMESPACE":"prod"},"fields":{"namespace":"ci"}}`, "Parameter 'NAMESPACE' conflicts with field 'namespace'"},
+		{"fields on Jenkins", "/api/v1/trigger/jenkins", `{"job":"build","fields":{"namespace":"ci"}}`, "Engine 'jenkins' accepts no fields"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := trigger(tc.path, tc.body)
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if !strings.Contains(rr.Body.String(), tc.message) {
+				t.Errorf("Expected error containing %q, got %s", tc.message, rr.Body.String())
+			}
+		})
+	}
+
+	// Engines list their fields and the OpenAPI document describing them
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/engines", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var engines handlers.EnginesResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &engines); err != nil {
+		t.Fatalf("Failed to decode engines: %v", err)
+	}
+	if len(engines.Engines) != 2 || strings.Join(engines.Engines[1].Fields, ",") != "namespace,priority,dry_run,tier" {
+		t.Fatalf("Expected the tekton fields, got %+v", engines.Engines)
+	}
+	if engines.Engines[1].OpenAPIPath != "/api/v1/engines/tekton/openapi" {
+		t.Errorf("Unexpected OpenAPI path: %s", engines.Engines[1].OpenAPIPath)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/engines/tekton/openapi", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var document struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+		Comps   struct {
+			Schemas struct {
+				TriggerRequest struct {
+					Required   []string `json:"required"`
+					Properties struct {
+						Fields struct {
+							Required   []string                          `json:"required"`
+							Properties map[string]map[string]interface{} `json:"properties"`
+						} `json:"fields"`
+					} `json:"properties"`
+				} `json:"TriggerRequest"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &document); err != nil {
+		t.Fatalf("Failed to decode OpenAPI document: %v", err)
+	}
+	if document.OpenAPI == "" || document.Paths["/api/v1/trigger/tekton"]["post"] == nil {
+		t.Fatalf("Expected the tekton trigger path, got %s", rr.Body.String())
+	}
+	schema := document.Comps.Schemas.TriggerRequest
+	if strings.Join(schema.Required, ",") != "job,fields" || strings.Join(schema.Properties.Fields.Required, ",") != "namespace" {
+		t.Errorf("Expected job and fields.namespace required, got %v and %v", schema.Required, schema.Properties.Fields.Required)
+	}
+	if namespace := schema.Properties.Fields.Properties["namespace"]; namespace["type"] != "string" || namespace["pattern"] != "^(?:[a-z0-9-]+)$" {
+		t.Errorf("Unexpected namespace schema: %v", namespace)
+	}
+	if priority := schema.Properties.Fields.Properties["priority"]; priority["type"] != "integer" {
+		t.Errorf("Unexpected priority schema: %v", priority)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/engines/missing/openapi", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown engine, got %d", rr.Code)
+	}
+}
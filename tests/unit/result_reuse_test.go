@@ -0,0 +1,240 @@
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func TestResultReuse(t *testing.T) {
+	cleanup := setupStatsStorage(t)
+	defer cleanup()
+
+	// Builds of the flaky job fail; all others succeed
+	var triggers int
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			triggers++
+			return &engine.BuildResult{Success: true, BuildID: fmt.Sprintf("%s/%d", jobName, triggers)}, nil
+		},
+		GetBuildStatusFunc: func(buildID string) (*engine.BuildResult, error) {
+			result := engine.ResultSuccess
+			if strings.HasPrefix(buildID, "flaky/") {
+				result = engine.ResultFailure
+			}
+			return &engine.BuildResult{Success: true, BuildID: buildID, Result: result, BuildDurationMS: 1000}, nil
+		},
+	})
+	handler.SetResultReuse([]config.ReuseRuleConfig{
+		{Jobs: []string{"verify-*", "flaky"}, Window: 600},
+	})
+
+	trigger := func(t *testing.T, body string) handlers.TriggerJenkinsBuildResponse {
+		t.Helper()
+		rr := postTrigger(handler, body)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp handlers.TriggerJenkinsBuildResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	first := trigger(t, `{"job":"verify-api","parameters":{"sha":"abc","suite":"full"}}`)
+	if first.ReusedFrom != 0 || triggers != 1 {
+		t.Fatalf("Expected the first trigger to start a build, got %+v", first)
+	}
+
+	t.Run("Identical trigger reuses the build", func(t *testing.T) {
+		resp := trigger(t, `{"job":"verify-api","parameters":{"suite":"full","sha":"abc"},"wait":true}`)
+		if triggers != 1 || resp.ReusedFrom == 0 || resp.BuildID != first.BuildID || resp.GlobalBuildID != first.GlobalBuildID {
+			t.Errorf("Expected build %s to be reused, got %+v (triggers %d)", first.BuildID, resp, triggers)
+		}
+		if resp.Result != engine.ResultSuccess || resp.WaitStatus != handlers.WaitCompleted {
+			t.Errorf("Expected a completed successful result, got %+v", resp.BuildResult)
+		}
+		logs, err := storage.GetAuditLogs(1, 0)
+		if err != nil || len(logs) != 1 || logs[0].Result != "reused" || logs[0].BuildID != first.BuildID {
+			t.Errorf("Expected a reused audit entry, got %+v (err %v)", logs, err)
+		}
+	})
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"Different parameters", `{"job":"verify-api","parameters":{"sha":"def","suite":"full"}}`},
+		{"Opted out with no_reuse", `{"job":"verify-api","parameters":{"sha":"abc","suite":"full"},"no_reuse":true}`},
+		{"Job without a reuse rule", `{"job":"deploy","parameters":{"sha":"abc"}}`},
+		{"Job without a reuse rule again", `{"job":"deploy","parameters":{"sha":"abc"}}`},
+		{"Failed build", `{"job":"flaky"}`},
+		{"Failed build again", `{"job":"flaky"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := triggers
+			if resp := trigger(t, tt.body); resp.ReusedFrom != 0 || triggers != before+1 {
+				t.Errorf("Expected a new build, got %+v (triggers %d)", resp, triggers)
+			}
+		})
+	}
+
+	t.Run("Kill switch refuses reuse", func(t *testing.T) {
+		if _, err := storage.InsertKillSwitch(models.KillSwitch{
+			Pattern:   "verify-*",
+			Reason:    "maintenance",
+			CreatedBy: "ops",
+			CreatedAt: time.Now().UTC(),
+			ExpiresAt: time.Now().Add(time.Hour).UTC(),
+		}); err != nil {
+			t.Fatalf("Failed to insert kill switch: %v", err)
+		}
+		rr := postTrigger(handler, `{"job":"verify-api","parameters":{"sha":"abc","suite":"full"}}`)
+		if rr.Code != http.StatusLocked || !strings.Contains(rr.Body.String(), `"code":"JOB_DISABLED"`) {
+			t.Errorf("Expected status 423 with JOB_DISABLED, got %d: %s", rr.Code, rr.Body.String())
+		}
+		logs, err := storage.GetAuditLogs(1, 0)
+		if err != nil || len(logs) != 1 || logs[0].Result != "denied" || logs[0].BuildID != "" {
+			t.Errorf("Expected a denied audit entry, got %+v (err %v)", logs, err)
+		}
+	})
+}
+
+func TestResultReuseConfigValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "jenkins:\n  url: https://test-jenkins.example.com\n  token: test-token\napi:\n  keys: [test-key]\nreuse:\n  rules:\n    - jobs: [verify-*]\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	_, err := config.Load(path)
+	if err == nil || !strings.Contains(err.Error(), "invalid reuse.rules[0].window") {
+		t.Errorf("Expected a window validation error, got %v", err)
+	}
+}
+
+func TestReuseEntries(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 1024 * 1024
+	cfg.Reuse.Rules = []config.ReuseRuleConfig{{Jobs: []string{"verify-*"}, Window: 600}}
+	cfg.API.Clients = []config.APIClientConfig{
+		{Name: "ci", Key: "ci-key"},
+		{Name: "ops", Key: "ops-key", Scopes: []string{config.ScopeAdmin}},
+	}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	builds := 0
+	router.AddEngine("tekton", "custom", &MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			builds++
+			return &engine.BuildResult{Success: true, BuildID: fmt.Sprintf("run-%d", builds)}, nil
+		},
+		GetBuildStatusFunc: func(buildID string) (*engine.BuildResult, error) {
+			return &engine.BuildResult{Success: true, BuildID: buildID, Result: engine.ResultSuccess}, nil
+		},
+	})
+
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	trigger := func() handlers.TriggerJenkinsBuildResponse {
+		t.Helper()
+		rr := do(http.MethodPost, "/api/v1/trigger/tekton", "ci-key", `{"job":"verify-api","parameters":{"sha":"abc"}}`)
+		var resp handlers.TriggerJenkinsBuildResponse
+		if rr.Code != http.StatusOK || json.Unmarshal(rr.Body.Bytes(), &resp) != nil {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		return resp
+	}
+	list := func() []handlers.ReuseEntry {
+		t.Helper()
+		rr := do(http.MethodGet, "/api/v1/admin/reuse?job=verify-*", "ops-key", "")
+		var entries []handlers.ReuseEntry
+		if rr.Code != http.StatusOK || json.Unmarshal(rr.Body.Bytes(), &entries) != nil {
+			t.Fatalf("Expected the reuse entries, got %d: %s", rr.Code, rr.Body.String())
+		}
+		return entries
+	}
+
+	trigger()
+	if resp := trigger(); resp.ReusedFrom == 0 || builds != 1 {
+		t.Fatalf("Expected the second trigger to reuse the build, got %+v", resp)
+	}
+	if rr := do(http.MethodGet, "/api/v1/admin/reuse", "ci-key", ""); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 without the admin scope, got %d", rr.Code)
+	}
+	entries := list()
+	if len(entries) != 1 || entries[0].BuildID != "run-1" || entries[0].Engine != "tekton" || entries[0].Key == "" ||
+		!entries[0].ExpiresAt.Equal(entries[0].CreatedAt.Add(600*time.Second)) {
+		t.Fatalf("Expected the first trigger as the only entry, got %+v", entries)
+	}
+
+	rr := do(http.MethodGet, "/metrics", "ops-key", "")
+	for _, line := range []string{
+		`triggermesh_reuse_lookups_total{engine="tekton",result="hit"} 1`,
+		`triggermesh_reuse_lookups_total{engine="tekton",result="miss"} 1`,
+	} {
+		if !strings.Contains(rr.Body.String(), line) {
+			t.Errorf("Expected %q in the metrics, got:\n%s", line, rr.Body.String())
+		}
+	}
+
+	// Browsers may purge cross-origin, after a preflight for DELETE
+	path := fmt.Sprintf("/api/v1/admin/reuse/%d", entries[0].AuditID)
+	for _, target := range []string{path, "/api/v1/admin/reuse?job=verify-*"} {
+		preflight := httptest.NewRequest(http.MethodOptions, target, nil)
+		preflight.Header.Set("Origin", "https://ops.example.com")
+		preflight.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, preflight)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Header().Get("Access-Control-Allow-Methods"), http.MethodDelete) {
+			t.Errorf("Expected the preflight of %s to allow DELETE, got %d with methods %q", target, rr.Code, rr.Header().Get("Access-Control-Allow-Methods"))
+		}
+	}
+
+	// A purged entry no longer answers identical triggers
+	if rr := do(http.MethodDelete, path, "ops-key", ""); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"purged":1`) {
+		t.Fatalf("Expected the entry to be purged, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := do(http.MethodDelete, path, "ops-key", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 purging twice, got %d", rr.Code)
+	}
+	if resp := trigger(); resp.ReusedFrom != 0 || builds != 2 {
+		t.Errorf("Expected a new build after the purge, got %+v", resp)
+	}
+
+	// Purging by job requires an explicit pattern
+	if rr := do(http.MethodDelete, "/api/v1/admin/reuse", "ops-key", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a job pattern, got %d", rr.Code)
+	}
+	if rr := do(http.MethodDelete, "/api/v1/admin/reuse?job=verify-*", "ops-key", ""); !strings.Contains(rr.Body.String(), `"purged":1`) {
+		t.Errorf("Expected the new entry to be purged, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if entries := list(); len(entries) != 0 {
+		t.Errorf("Expected no entries after the purge, got %+v", entries)
+	}
+
+	audit, err := storage.GetConfigAudit(models.ConfigActionReusePurge, 10, 0)
+	if err != nil || len(audit) != 2 || audit[0].Actor != "ops" {
+		t.Errorf("Expected 2 purges by ops in the config audit, got %+v (%v)", audit, err)
+	}
+}
@@ -0,0 +1,157 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func TestScheduledTriggers(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-schedule-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	var triggered []string
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			triggered = append(triggered, jobName)
+			return &engine.BuildResult{Success: true, BuildID: jobName + "/1"}, nil
+		},
+	})
+	handler.SetMaxScheduleDelay(24 * time.Hour)
+
+	rfc := func(d time.Duration) string { return time.Now().Add(d).UTC().Format(time.RFC3339) }
+
+	t.Run("Validation", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			body           string
+			expectedStatus int
+			expectedCode   string
+		}{
+			{"Deadline passed", fmt.Sprintf(`{"job":"deploy","deadline":%q}`, rfc(-time.Minute)), http.StatusUnprocessableEntity, "DEADLINE_EXCEEDED"},
+			{"Deadline before not_before", fmt.Sprintf(`{"job":"deploy","not_before":%q,"deadline":%q}`, rfc(time.Hour), rfc(time.Minute)), http.StatusBadRequest, ""},
+			{"not_before beyond max delay", fmt.Sprintf(`{"job":"deploy","not_before":%q}`, rfc(48*time.Hour)), http.StatusBadRequest, ""},
+			{"not_before already reached", fmt.Sprintf(`{"job":"deploy","not_before":%q,"deadline":%q}`, rfc(-time.Minute), rfc(time.Minute)), http.StatusOK, ""},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := httptest.NewRecorder()
+				handler.TriggerJenkinsBuild(rr, newLabelTriggerRequest(tt.body))
+				if rr.Code != tt.expectedStatus {
+					t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+				}
+				if tt.expectedCode != "" {
+					var resp map[string]interface{}
+					if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+						t.Fatalf("Failed to decode response: %v", err)
+					}
+					if resp["code"] != tt.expectedCode {
+						t.Errorf("Expected code %s, got %v", tt.expectedCode, resp["code"])
+					}
+				}
+			})
+		}
+
+		logs, err := storage.GetAuditLogs(10, 0)
+		if err != nil {
+			t.Fatalf("Failed to get audit logs: %v", err)
+		}
+		expired := 0
+		for _, log := range logs {
+			if log.Result == "expired" {
+				expired++
+			}
+		}
+		if expired != 1 {
+			t.Errorf("Expected the expired trigger in the audit log, got %d", expired)
+		}
+	})
+
+	t.Run("Future not_before is held", func(t *testing.T) {
+		triggered = nil
+		rr := httptest.NewRecorder()
+		handler.TriggerJenkinsBuild(rr, newLabelTriggerRequest(fmt.Sprintf(`{"job":"nightly","not_before":%q}`, rfc(time.Hour))))
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp handlers.ScheduledTriggerResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.Status != models.ScheduledPending || rr.Header().Get("Location") != resp.StatusURL {
+			t.Errorf("Unexpected scheduled response %+v (Location %q)", resp, rr.Header().Get("Location"))
+		}
+		if len(triggered) != 0 {
+			t.Errorf("Expected the engine not to be called yet, got %v", triggered)
+		}
+
+		fired, err := handler.FireDueTriggers(context.Background())
+		if err != nil || fired != 0 {
+			t.Errorf("Expected no due triggers, got %d (err %v)", fired, err)
+		}
+
+		rr = httptest.NewRecorder()
+		handler.GetScheduledTrigger(rr, httptest.NewRequest("GET", resp.StatusURL, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+
+		rr = httptest.NewRecorder()
+		handler.GetScheduledTrigger(rr, httptest.NewRequest("GET", "/api/v1/trigger/scheduled/unknown", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rr.Code)
+		}
+	})
+
+	t.Run("Due triggers fire once and expired ones are dropped", func(t *testing.T) {
+		triggered = nil
+		past := time.Now().Add(-time.Minute)
+		deadline := time.Now().Add(-time.Second)
+		for _, trigger := range []models.ScheduledTrigger{
+			{TriggerID: "due", JobName: "release", NotBefore: past, Status: models.ScheduledPending, APIKey: "test-key", CreatedAt: past},
+			{TriggerID: "late", JobName: "release", NotBefore: past, Deadline: &deadline, Status: models.ScheduledPending, APIKey: "test-key", CreatedAt: past},
+		} {
+			if err := storage.InsertScheduledTrigger(trigger); err != nil {
+				t.Fatalf("Failed to insert scheduled trigger: %v", err)
+			}
+		}
+
+		fired, err := handler.FireDueTriggers(context.Background())
+		if err != nil || fired != 1 {
+			t.Fatalf("Expected one fired trigger, got %d (err %v)", fired, err)
+		}
+		if fired, _ := handler.FireDueTriggers(context.Background()); fired != 0 {
+			t.Errorf("Expected triggers to fire only once, got %d", fired)
+		}
+		if len(triggered) != 1 || triggered[0] != "release" {
+			t.Errorf("Expected a single engine call, got %v", triggered)
+		}
+
+		due, err := storage.GetScheduledTrigger("due")
+		if err != nil || due == nil || due.Status != models.ScheduledTriggered || due.BuildID != "release/1" {
+			t.Errorf("Expected the due trigger to be triggered, got %+v (err %v)", due, err)
+		}
+		late, err := storage.GetScheduledTrigger("late")
+		if err != nil || late == nil || late.Status != models.ScheduledExpired {
+			t.Errorf("Expected the late trigger to be expired, got %+v (err %v)", late, err)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"triggermesh/internal/sdnotify"
+)
+
+// listenNotifySocket creates a unixgram socket standing in for systemd and points NOTIFY_SOCKET at it
+func listenNotifySocket(t *testing.T) *net.UnixConn {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "sd")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets unavailable: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+// readNotification reads one state sent to the socket
+func readNotification(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	buf := make([]byte, 256)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Failed to read notification: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestSDNotify(t *testing.T) {
+	t.Run("Outside systemd", func(t *testing.T) {
+		t.Setenv("NOTIFY_SOCKET", "")
+		sent, err := sdnotify.Notify(sdnotify.Ready)
+		if sent || err != nil {
+			t.Errorf("Expected a no-op, got sent=%v err=%v", sent, err)
+		}
+	})
+
+	t.Run("Ready", func(t *testing.T) {
+		conn := listenNotifySocket(t)
+		sent, err := sdnotify.Notify(sdnotify.Ready)
+		if !sent || err != nil {
+			t.Fatalf("Expected notification to be sent, got sent=%v err=%v", sent, err)
+		}
+		if state := readNotification(t, conn); state != sdnotify.Ready {
+			t.Errorf("Expected %q, got %q", sdnotify.Ready, state)
+		}
+	})
+
+	t.Run("Watchdog interval", func(t *testing.T) {
+		t.Setenv("WATCHDOG_USEC", "30000000")
+		t.Setenv("WATCHDOG_PID", "")
+		if interval := sdnotify.WatchdogInterval(); interval != 30*time.Second {
+			t.Errorf("Expected 30s, got %v", interval)
+		}
+		t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+		if interval := sdnotify.WatchdogInterval(); interval != 0 {
+			t.Errorf("Expected watchdog meant for another process to be ignored, got %v", interval)
+		}
+	})
+
+	t.Run("Watchdog pings", func(t *testing.T) {
+		conn := listenNotifySocket(t)
+		t.Setenv("WATCHDOG_USEC", "20000")
+		t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			sdnotify.RunWatchdog(ctx, func() error { return nil })
+		}()
+		if state := readNotification(t, conn); state != sdnotify.Watchdog {
+			t.Errorf("Expected %q, got %q", sdnotify.Watchdog, state)
+		}
+		cancel()
+		<-done
+	})
+}
@@ -0,0 +1,117 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/config"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func TestSearchTriggers(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.API.Clients = []config.APIClientConfig{{Name: "web-bot", Key: "web-key", Jobs: []string{"web-*"}}}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	now := time.Now()
+	entries := []models.AuditLog{
+		{Timestamp: now.Add(-10 * 24 * time.Hour), JobName: "web-deploy", Commit: "3f2a9c1e4b5d", Ref: "main", Labels: map[string]string{"env": "prod"}},
+		{Timestamp: now.Add(-3 * time.Hour), JobName: "web-deploy", Commit: "3f2a9c1e4b5d", Ref: "main", ChangeRef: "CHG-42", Labels: map[string]string{"env": "prod"}},
+		{Timestamp: now.Add(-2 * time.Hour), JobName: "api-deploy", Commit: "3f2a9c1e4b5d", Ref: "release/1.4", ChangeRef: "CHG-42"},
+		{Timestamp: now.Add(-1 * time.Hour), JobName: "web-build", Commit: "aa11bb22cc33", Ref: "main", Labels: map[string]string{"env": "staging"}},
+	}
+	for _, entry := range entries {
+		entry.APIKey = "test-key"
+		entry.Status = http.StatusOK
+		if err := storage.InsertAuditLog(entry); err != nil {
+			t.Fatalf("Failed to insert audit log: %v", err)
+		}
+	}
+
+	search := func(key, query string) (*httptest.ResponseRecorder, handlers.SearchResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/search?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var response handlers.SearchResponse
+		if rr.Code == http.StatusOK {
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return rr, response
+	}
+	jobs := func(response handlers.SearchResponse) []string {
+		names := make([]string, 0, len(response.Results))
+		for _, log := range response.Results {
+			names = append(names, log.JobName)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		jobs  []string
+	}{
+		{"Bare SHA prefix, newest first", "3F2A9C1", []string{"api-deploy", "web-deploy", "web-deploy"}},
+		{"Commit and ticket", "commit:3f2a9c1 ticket:CHG-42", []string{"api-deploy", "web-deploy"}},
+		{"Label", "label:env:prod", []string{"web-deploy", "web-deploy"}},
+		{"Bare label value", "staging", []string{"web-build"}},
+		{"Bare ticket and job pattern", "CHG-42 job:web-*", []string{"web-deploy"}},
+		{"Ref and relative time", "ref:main since:7d", []string{"web-build", "web-deploy"}},
+		{"Until", "ref:main until:" + now.Add(-5*time.Hour).Format(time.RFC3339), []string{"web-deploy"}},
+		{"No match", "job:missing", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, response := search("test-key", "q="+url.QueryEscape(tt.query))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+			got := jobs(response)
+			if len(got) != len(tt.jobs) {
+				t.Fatalf("Expected %v, got %v", tt.jobs, got)
+			}
+			for i := range got {
+				if got[i] != tt.jobs[i] {
+					t.Fatalf("Expected %v, got %v", tt.jobs, got)
+				}
+			}
+			for _, log := range response.Results {
+				if log.APIKey != "" {
+					t.Errorf("Result %d exposes the API key", log.ID)
+				}
+			}
+		})
+	}
+
+	// Pages continue from the cursor of a full page
+	_, first := search("test-key", "q=3f2a9c1&limit=2")
+	if len(first.Results) != 2 || first.NextCursor == "" {
+		t.Fatalf("Expected a full page with a cursor, got %+v", first)
+	}
+	_, second := search("test-key", "q=3f2a9c1&limit=2&after="+first.NextCursor)
+	if len(second.Results) != 1 || second.Results[0].ID >= first.Results[1].ID {
+		t.Fatalf("Expected the oldest match on the second page, got %+v", second.Results)
+	}
+
+	// Keys restricted to jobs only find the triggers of their jobs
+	_, restricted := search("web-key", "q=ticket:CHG-42")
+	if got := jobs(restricted); len(got) != 1 || got[0] != "web-deploy" {
+		t.Errorf("Expected only web-deploy for a restricted key, got %v", got)
+	}
+
+	for _, query := range []string{"", "q=", "q=commit:xyz", "q=ref:a..b", "q=label:env", "q=since:yesterday", "q=since:1h+until:2h", "q=job:a%3Bb", "q=main&limit=500"} {
+		if rr, _ := search("test-key", query); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", query, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"triggermesh/internal/alert"
+	"triggermesh/internal/config"
+	"triggermesh/internal/signature"
+)
+
+func TestSignatureVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"job":"deploy"}`)
+	header := signature.Sign("s3cret", now, body)
+
+	if err := signature.Verify(header, "s3cret", body, now.Add(time.Minute), signature.DefaultTolerance); err != nil {
+		t.Errorf("Expected valid signature, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		secret string
+		body   []byte
+		now    time.Time
+		want   error
+	}{
+		{"missing header", "", "s3cret", body, now, signature.ErrMissing},
+		{"malformed header", "v1", "s3cret", body, now, signature.ErrMalformed},
+		{"no timestamp", "v1=abc", "s3cret", body, now, signature.ErrMalformed},
+		{"wrong secret", header, "other", body, now, signature.ErrMismatch},
+		{"tampered body", header, "s3cret", []byte(`{"job":"drop"}`), now, signature.ErrMismatch},
+		{"replayed", header, "s3cret", body, now.Add(time.Hour), signature.ErrExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := signature.Verify(tt.header, tt.secret, tt.body, tt.now, signature.DefaultTolerance)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierSignsPayload(t *testing.T) {
+	headers := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := signature.Verify(r.Header.Get(signature.Header), "hook-secret", body, time.Now(), signature.DefaultTolerance); err != nil {
+			headers <- err.Error()
+		} else {
+			headers <- "ok"
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	a := alert.Alert{Scope: "job", Engine: "jenkins", Job: "deploy"}
+	for _, secret := range []string{"hook-secret", ""} {
+		notifier, err := alert.NewNotifier(config.AlertNotifierConfig{Type: "webhook", URL: server.URL, Secret: secret})
+		if err != nil {
+			t.Fatalf("Failed to create notifier: %v", err)
+		}
+		if err := notifier.Notify(context.Background(), a); err != nil {
+			t.Fatalf("Failed to notify: %v", err)
+		}
+	}
+	if got := <-headers; got != "ok" {
+		t.Errorf("Expected a valid signature, got %s", got)
+	}
+	if got := <-headers; got != signature.ErrMissing.Error() {
+		t.Errorf("Expected an unsigned delivery without a secret, got %s", got)
+	}
+}
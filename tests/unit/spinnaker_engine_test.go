@@ -0,0 +1,73 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/engine/spinnaker"
+)
+
+func TestSpinnakerEngine(t *testing.T) {
+	var trigger map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gate-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/pipelines/shop/Deploy to prod":
+			_ = json.NewDecoder(r.Body).Decode(&trigger)
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"ref": "/pipelines/01HEXEC"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/pipelines/01HEXEC":
+			_, _ = w.Write([]byte(`{"id": "01HEXEC", "application": "shop", "status": "TERMINAL", "startTime": 1000, "endTime": 61000}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/pipelines/01HRUN":
+			_, _ = w.Write([]byte(`{"id": "01HRUN", "application": "shop", "status": "RUNNING", "startTime": 1000}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	e := spinnaker.New(config.SpinnakerEngineConfig{
+		URL:     server.URL,
+		UIURL:   "https://spinnaker.example.com/",
+		Token:   "gate-token",
+		Timeout: 5,
+	})
+
+	result, err := e.TriggerBuild("shop/Deploy to prod", map[string]string{"VERSION": "1.4.0"})
+	if err != nil {
+		t.Fatalf("Failed to start pipeline: %v", err)
+	}
+	if result.BuildID != "01HEXEC" || result.BuildURL != "https://spinnaker.example.com/#/applications/shop/executions/details/01HEXEC" {
+		t.Errorf("Unexpected trigger result: %+v", result)
+	}
+	if trigger["type"] != "manual" || trigger["parameters"].(map[string]interface{})["VERSION"] != "1.4.0" {
+		t.Errorf("Unexpected trigger body: %v", trigger)
+	}
+
+	status, err := e.GetBuildStatus("01HEXEC")
+	if err != nil {
+		t.Fatalf("Failed to get execution status: %v", err)
+	}
+	if status.Building || status.Result != engine.ResultFailure || status.BuildDurationMS != 60000 {
+		t.Errorf("Unexpected execution status: %+v", status)
+	}
+	status, err = e.GetBuildStatus("01HRUN")
+	if err != nil || !status.Building {
+		t.Errorf("Expected a running execution, got %+v, %v", status, err)
+	}
+
+	if _, err := e.TriggerBuild("shop/missing", nil); !errors.Is(err, engine.ErrJobNotFound) {
+		t.Errorf("Expected job not found for an unknown pipeline, got %v", err)
+	}
+	if _, err := e.TriggerBuild("no-application", nil); !errors.Is(err, engine.ErrJobNotFound) {
+		t.Errorf("Expected job not found for a job name without an application, got %v", err)
+	}
+}
@@ -0,0 +1,313 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/engine/jenkins"
+	"triggermesh/internal/stats"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+)
+
+func setupStatsStorage(t *testing.T) func() {
+	tmpFile, err := os.CreateTemp("", "test-stats-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	tmpFile.Close()
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+
+	return func() {
+		storage.Close()
+		os.Remove(tmpFile.Name())
+	}
+}
+
+func TestPollerRecordsBuildOutcomes(t *testing.T) {
+	cleanup := setupStatsStorage(t)
+	defer cleanup()
+
+	statuses := map[string]*engine.BuildResult{
+		"test-job/1": {Success: true, Result: engine.ResultSuccess, BuildDurationMS: 1000},
+		"test-job/2": {Success: true, Result: engine.ResultFailure, BuildDurationMS: 3000},
+		"test-job/3": {Success: true, Building: true},
+	}
+	registry := engine.NewRegistry()
+	if err := registry.Register("jenkins", &MockCIEngine{
+		GetBuildStatusFunc: func(buildID string) (*engine.BuildResult, error) {
+			if status, ok := statuses[buildID]; ok {
+				return status, nil
+			}
+			return nil, engine.ErrJobNotFound
+		},
+	}); err != nil {
+		t.Fatalf("Failed to register engine: %v", err)
+	}
+
+	now := time.Now()
+	for _, build := range []models.TrackedBuild{
+		{BuildID: "test-job/1", JobName: "test-job", Engine: "jenkins", TriggeredAt: now},
+		{BuildID: "test-job/2", JobName: "test-job", Engine: "jenkins", TriggeredAt: now},
+		{BuildID: "test-job/3", JobName: "test-job", Engine: "jenkins", TriggeredAt: now},
+		{BuildID: "gone-job/1", JobName: "gone-job", Engine: "jenkins", TriggeredAt: now},
+		{BuildID: "old-job/1", JobName: "old-job", Engine: "jenkins", TriggeredAt: now.Add(-48 * time.Hour)},
+	} {
+		if err := storage.TrackBuild(build); err != nil {
+			t.Fatalf("Failed to track build: %v", err)
+		}
+	}
+
+	poller := stats.NewPoller(config.StatsConfig{PollInterval: 1, MaxTrackAge: 86400}, registry)
+	recorded, err := poller.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+	if recorded != 2 {
+		t.Errorf("Expected 2 outcomes recorded, got %d", recorded)
+	}
+
+	// Only the running build is still tracked
+	tracked, err := storage.GetTrackedBuilds(10)
+	if err != nil {
+		t.Fatalf("Failed to get tracked builds: %v", err)
+	}
+	if len(tracked) != 1 || tracked[0].BuildID != "test-job/3" {
+		t.Errorf("Expected only test-job/3 to remain tracked, got %+v", tracked)
+	}
+
+	jobStats, err := storage.GetJobStats("test-job")
+	if err != nil || jobStats == nil {
+		t.Fatalf("Expected job stats, got %v (err %v)", jobStats, err)
+	}
+	if jobStats.BuildsTotal != 2 || jobStats.BuildsSucceeded != 1 || jobStats.BuildsFailed != 1 {
+		t.Errorf("Expected 2 builds (1 succeeded, 1 failed), got %+v", jobStats)
+	}
+	if jobStats.SuccessRate != 0.5 || jobStats.AverageDurationMS != 2000 {
+		t.Errorf("Expected success rate 0.5 and average 2000ms, got %v and %d", jobStats.SuccessRate, jobStats.AverageDurationMS)
+	}
+	if jobStats.LastFailureBuildID != "test-job/2" || jobStats.LastFailureAt == nil {
+		t.Errorf("Expected last failure test-job/2, got %q at %v", jobStats.LastFailureBuildID, jobStats.LastFailureAt)
+	}
+
+	// A later success keeps the last failure
+	statuses["test-job/3"] = &engine.BuildResult{Success: true, Result: engine.ResultSuccess, BuildDurationMS: 2000}
+	if _, err := poller.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+	jobStats, _ = storage.GetJobStats("test-job")
+	if jobStats.LastResult != engine.ResultSuccess || jobStats.LastBuildID != "test-job/3" || jobStats.LastFailureBuildID != "test-job/2" {
+		t.Errorf("Expected last result SUCCESS for test-job/3 with last failure test-job/2, got %+v", jobStats)
+	}
+
+	if missing, err := storage.GetJobStats("gone-job"); err != nil || missing != nil {
+		t.Errorf("Expected no stats for a job whose build disappeared, got %v (err %v)", missing, err)
+	}
+}
+
+func TestJobStatsEndpoint(t *testing.T) {
+	// Mock Jenkins that starts build 5 and reports it finished
+	jenkinsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/stats-job/build":
+			w.Header().Set("Location", "/job/stats-job/5/")
+			w.WriteHeader(http.StatusCreated)
+		case "/job/stats-job/5/api/json":
+			w.Write([]byte(`{"number":5,"building":false,"result":"SUCCESS","duration":1500}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer jenkinsServer.Close()
+
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 1 << 20
+	cfg.Jenkins.URL = jenkinsServer.URL
+	cfg.Jenkins.Timeout = 5
+	cfg.Stats.Enabled = true
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer test-key")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := get("/api/v1/jobs/stats-job/stats"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 before any outcome, got %d", rr.Code)
+	}
+
+	body, _ := json.Marshal(map[string]string{"job": "stats-job"})
+	req := httptest.NewRequest("POST", "/api/v1/trigger/jenkins", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected trigger to succeed, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	registry := engine.NewRegistry()
+	if err := registry.Register("jenkins", jenkinsEngineFor(cfg)); err != nil {
+		t.Fatalf("Failed to register engine: %v", err)
+	}
+	if recorded, err := stats.NewPoller(cfg.Stats, registry).RunOnce(context.Background()); err != nil || recorded != 1 {
+		t.Fatalf("Expected 1 outcome recorded, got %d (err %v)", recorded, err)
+	}
+
+	rr = get("/api/v1/jobs/stats-job/stats")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var jobStats models.JobStats
+	if err := json.Unmarshal(rr.Body.Bytes(), &jobStats); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if jobStats.BuildsTotal != 1 || jobStats.SuccessRate != 1 || jobStats.AverageDurationMS != 1500 {
+		t.Errorf("Expected one successful 1500ms build, got %+v", jobStats)
+	}
+
+	if rr := get("/api/v1/jobs/bad$job/stats"); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid job name, got %d", rr.Code)
+	}
+	if rr := get("/api/v1/jobs/stats-job"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without /stats suffix, got %d", rr.Code)
+	}
+}
+
+// jenkinsEngineFor creates a Jenkins engine from the test configuration
+func jenkinsEngineFor(cfg config.Config) engine.CIEngine {
+	return jenkins.NewTrigger(jenkins.NewClient(cfg.Jenkins))
+}
+
+func TestPollerChecksBuildsConcurrently(t *testing.T) {
+	cleanup := setupStatsStorage(t)
+	defer cleanup()
+
+	// Each status check waits until the other one is in flight
+	var inFlight sync.WaitGroup
+	inFlight.Add(2)
+	registry := engine.NewRegistry()
+	if err := registry.Register("jenkins", &MockCIEngine{
+		GetBuildStatusFunc: func(buildID string) (*engine.BuildResult, error) {
+			inFlight.Done()
+			inFlight.Wait()
+			return &engine.BuildResult{Success: true, Result: engine.ResultSuccess}, nil
+		},
+	}); err != nil {
+		t.Fatalf("Failed to register engine: %v", err)
+	}
+	for _, id := range []string{"test-job/1", "test-job/2"} {
+		if err := storage.TrackBuild(models.TrackedBuild{BuildID: id, JobName: "test-job", Engine: "jenkins", TriggeredAt: time.Now()}); err != nil {
+			t.Fatalf("Failed to track build: %v", err)
+		}
+	}
+
+	poller := stats.NewPoller(config.StatsConfig{PollInterval: 1}, registry)
+	poller.SetConcurrency(2)
+	var completed []string
+	poller.OnComplete(func(build models.TrackedBuild, _ models.BuildOutcome) {
+		completed = append(completed, build.BuildID)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	recorded, err := poller.RunOnce(ctx)
+	if err != nil || recorded != 2 {
+		t.Fatalf("Expected 2 outcomes, got %d (err %v)", recorded, err)
+	}
+	if len(completed) != 2 {
+		t.Errorf("Expected OnComplete for both builds, got %v", completed)
+	}
+}
+
+func TestJenkinsNotificationsRecordTrackedBuilds(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 1024 * 1024
+	cfg.Stats.Enabled = true
+	cfg.Jenkins.NotificationToken = "notify-token-0123456789"
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	// Polling never sees the build finish, so only the notification can record it
+	registry := engine.NewRegistry()
+	if err := registry.Register("jenkins", &MockCIEngine{
+		GetBuildStatusFunc: func(buildID string) (*engine.BuildResult, error) {
+			return &engine.BuildResult{Success: true, Building: true}, nil
+		},
+	}); err != nil {
+		t.Fatalf("Failed to register engine: %v", err)
+	}
+	poller := stats.NewPoller(cfg.Stats, registry)
+	var completed []models.BuildOutcome
+	poller.OnComplete(func(_ models.TrackedBuild, outcome models.BuildOutcome) {
+		completed = append(completed, outcome)
+	})
+
+	if err := storage.TrackBuild(models.TrackedBuild{BuildID: "deploy/18", JobName: "deploy", Engine: "jenkins", TriggeredAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to track build: %v", err)
+	}
+
+	notify := func(token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/notifications/jenkins?token="+token, bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	// A Notification plugin payload, with fields TriggerMesh does not use
+	completedEvent := `{"name":"deploy","url":"job/team/job/deploy/","build":{"full_url":"http://jenkins/job/team/job/deploy/18/","number":18,"queue_id":7,"phase":"COMPLETED","status":"FAILURE","duration":4200,"url":"job/team/job/deploy/18/","scm":{},"log":""}}`
+
+	if rr := notify("wrong-token", completedEvent); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a wrong token, got %d", rr.Code)
+	}
+	if rr := notify(cfg.Jenkins.NotificationToken, completedEvent); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 before build tracking runs, got %d", rr.Code)
+	}
+	router.SetBuildReporter(poller.Report)
+
+	if rr := notify(cfg.Jenkins.NotificationToken, `{"name":"deploy","build":{"number":18,"phase":"STARTED"}}`); rr.Code != http.StatusAccepted || !strings.Contains(rr.Body.String(), `"ignored"`) {
+		t.Errorf("Expected the STARTED phase to be ignored, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := notify(cfg.Jenkins.NotificationToken, completedEvent); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"recorded"`) {
+		t.Fatalf("Expected the build to be recorded, got %d: %s", rr.Code, rr.Body.String())
+	}
+	// FINALIZED follows COMPLETED for the same build; it must not count twice
+	finalized := strings.Replace(completedEvent, "COMPLETED", "FINALIZED", 1)
+	if rr := notify(cfg.Jenkins.NotificationToken, finalized); rr.Code != http.StatusAccepted || !strings.Contains(rr.Body.String(), `"unmatched"`) {
+		t.Errorf("Expected the repeated outcome to be unmatched, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if recorded, err := poller.RunOnce(context.Background()); err != nil || recorded != 0 {
+		t.Errorf("Expected nothing left to poll, got %d (err %v)", recorded, err)
+	}
+
+	jobStats, err := storage.GetJobStats("deploy")
+	if err != nil || jobStats == nil {
+		t.Fatalf("Failed to get job stats: %v", err)
+	}
+	if jobStats.BuildsTotal != 1 || jobStats.BuildsFailed != 1 || jobStats.AverageDurationMS != 4200 || jobStats.LastBuildID != "deploy/18" {
+		t.Errorf("Unexpected job stats %+v", jobStats)
+	}
+	if len(completed) != 1 || completed[0].Result != engine.ResultFailure {
+		t.Errorf("Expected one FAILURE completion, got %+v", completed)
+	}
+
+	if rr := notify(cfg.Jenkins.NotificationToken, `{"name":"team/deploy","build":{"number":1,"phase":"COMPLETED","status":"SUCCESS"}}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a job name with a folder, got %d", rr.Code)
+	}
+}
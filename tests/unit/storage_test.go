@@ -1,6 +1,8 @@
 package unit
 
 import (
+	"database/sql"
+	"errors"
 	"os"
 	"testing"
 	"time"
@@ -199,6 +201,55 @@ func TestAuditLogWithError(t *testing.T) {
 	}
 }
 
+func TestWithTx(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to initialize storage: %v", err)
+	}
+	defer storage.Close()
+
+	record := func(buildID string, fail error) error {
+		return storage.WithTx(func(tx *storage.Tx) error {
+			if err := tx.InsertAuditLog(models.AuditLog{Timestamp: time.Now(), JobName: "deploy", Result: "success", BuildID: buildID}); err != nil {
+				return err
+			}
+			if err := tx.TrackBuild(models.TrackedBuild{BuildID: buildID, JobName: "deploy", Engine: "jenkins", TriggeredAt: time.Now()}); err != nil {
+				return err
+			}
+			return fail
+		})
+	}
+
+	if err := record("deploy/1", nil); err != nil {
+		t.Fatalf("Failed to commit transaction: %v", err)
+	}
+	failure := errors.New("handler failed")
+	if err := record("deploy/2", failure); !errors.Is(err, failure) {
+		t.Fatalf("Expected the handler error, got %v", err)
+	}
+
+	logs, err := storage.GetAuditLogs(10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit logs: %v", err)
+	}
+	tracked, err := storage.GetTrackedBuilds(10)
+	if err != nil {
+		t.Fatalf("Failed to get tracked builds: %v", err)
+	}
+	if len(logs) != 1 || logs[0].BuildID != "deploy/1" {
+		t.Errorf("Expected only the committed audit log, got %+v", logs)
+	}
+	if len(tracked) != 1 || tracked[0].BuildID != "deploy/1" {
+		t.Errorf("Expected only the committed tracked build, got %+v", tracked)
+	}
+}
+
 func TestInsertAuditLog_Error(t *testing.T) {
 	// Setup then close completely
 	tmpFile, err := os.CreateTemp("", "test-*.db")
@@ -465,3 +516,83 @@ func TestGetAuditLogsTimestampFallback(t *testing.T) {
 		t.Error("Expected non-zero timestamp, got zero")
 	}
 }
+
+func TestMigrateLegacyAuditTable(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-migrate-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	// Create a database with the original audit_logs schema and one row
+	legacy, err := sql.Open(storage.DriverName, tmpFile.Name())
+	if err != nil {
+		t.Fatalf("Failed to open legacy database: %v", err)
+	}
+	_, err = legacy.Exec(`
+	CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		timestamp DATETIME NOT NULL,
+		api_key TEXT NOT NULL,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		status INTEGER NOT NULL,
+		job_name TEXT,
+		params TEXT,
+		result TEXT,
+		error TEXT
+	);
+	INSERT INTO audit_logs (timestamp, api_key, method, path, status, job_name, params, result, error)
+	VALUES ('2026-01-01 00:00:00.000000', 'old-key', 'POST', '/api/v1/trigger/jenkins', 200, 'old-job', '{}', 'success', '');
+	`)
+	legacy.Close()
+	if err != nil {
+		t.Fatalf("Failed to create legacy schema: %v", err)
+	}
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to migrate storage: %v", err)
+	}
+	defer storage.Close()
+
+	// Re-opening an up-to-date database must not re-apply migrations
+	storage.Close()
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to re-open migrated storage: %v", err)
+	}
+
+	if err := storage.InsertAuditLog(models.AuditLog{
+		Timestamp:  time.Now(),
+		APIKey:     "new-key",
+		Method:     "POST",
+		Path:       "/api/v1/trigger/jenkins",
+		Status:     200,
+		JobName:    "new-job",
+		Result:     "success",
+		Source:     models.SourceSchedule,
+		Tenant:     "team-a",
+		Engine:     "jenkins",
+		TriggerID:  "trigger-1",
+		DurationMS: 42,
+	}); err != nil {
+		t.Fatalf("Failed to insert audit log: %v", err)
+	}
+
+	logs, err := storage.GetAuditLogs(10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit logs: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("Expected 2 logs, got %d", len(logs))
+	}
+
+	newLog, oldLog := logs[0], logs[1]
+	if newLog.Source != models.SourceSchedule || newLog.Tenant != "team-a" || newLog.Engine != "jenkins" ||
+		newLog.TriggerID != "trigger-1" || newLog.DurationMS != 42 {
+		t.Errorf("Expected attribution fields to round-trip, got %+v", newLog)
+	}
+	if oldLog.JobName != "old-job" || oldLog.Source != "" || oldLog.DurationMS != 0 {
+		t.Errorf("Expected legacy row with empty attribution, got %+v", oldLog)
+	}
+}
@@ -0,0 +1,166 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+	"triggermesh/internal/throttle"
+)
+
+// queueReporter reports the queue lengths it is given, one per sample, repeating the last one
+type queueReporter struct {
+	mu      sync.Mutex
+	lengths []int
+	err     error
+	samples int
+}
+
+func (q *queueReporter) Overview() (*engine.Overview, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.samples++
+	if q.err != nil {
+		return nil, q.err
+	}
+	length := q.lengths[0]
+	if len(q.lengths) > 1 {
+		q.lengths = q.lengths[1:]
+	}
+	return &engine.Overview{QueueLength: length}, nil
+}
+
+func TestThrottleControllerHysteresis(t *testing.T) {
+	reporter := &queueReporter{lengths: []int{5, 12, 9, 7}}
+	controller := throttle.NewController(config.ThrottleConfig{
+		QueueThreshold:  10,
+		ResumeThreshold: 8,
+		Policy:          config.ThrottlePolicyReject,
+		SampleInterval:  1,
+	}, reporter)
+
+	// Samples are reused within the interval, so the controller is driven sample by sample
+	expectations := []bool{false, true, true, false}
+	for i, throttled := range expectations {
+		if i > 0 {
+			time.Sleep(1010 * time.Millisecond)
+		}
+		err := controller.Admit(context.Background())
+		var throttledErr *throttle.ThrottledError
+		if got := errors.As(err, &throttledErr); got != throttled {
+			t.Fatalf("Sample %d: expected throttled %v, got %v", i, throttled, err)
+		}
+	}
+	if reporter.samples != 4 {
+		t.Errorf("Expected 4 samples, got %d", reporter.samples)
+	}
+}
+
+func TestThrottleControllerDelayAndFailOpen(t *testing.T) {
+	reporter := &queueReporter{lengths: []int{50, 3}}
+	controller := throttle.NewController(config.ThrottleConfig{
+		QueueThreshold:  10,
+		ResumeThreshold: 8,
+		Policy:          config.ThrottlePolicyDelay,
+		MaxDelay:        5,
+		SampleInterval:  1,
+	}, reporter)
+
+	// The trigger is held until the next sample finds the queue drained
+	started := time.Now()
+	if err := controller.Admit(context.Background()); err != nil {
+		t.Fatalf("Expected the delayed trigger to be admitted, got %v", err)
+	}
+	if waited := time.Since(started); waited < time.Second {
+		t.Errorf("Expected the trigger to wait for the next sample, waited %v", waited)
+	}
+
+	// A cancelled request stops waiting and is refused
+	reporter = &queueReporter{lengths: []int{50}}
+	controller = throttle.NewController(config.ThrottleConfig{
+		QueueThreshold:  10,
+		ResumeThreshold: 8,
+		Policy:          config.ThrottlePolicyDelay,
+		MaxDelay:        30,
+		SampleInterval:  10,
+	}, reporter)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var throttledErr *throttle.ThrottledError
+	if err := controller.Admit(ctx); !errors.As(err, &throttledErr) || throttledErr.QueueLength != 50 {
+		t.Fatalf("Expected a throttled error once the request is cancelled, got %v", err)
+	}
+
+	// Triggers pass when the queue cannot be sampled
+	controller = throttle.NewController(config.ThrottleConfig{QueueThreshold: 10, SampleInterval: 10}, &queueReporter{err: errors.New("jenkins unreachable")})
+	if err := controller.Admit(context.Background()); err != nil {
+		t.Errorf("Expected triggers to pass when sampling fails, got %v", err)
+	}
+}
+
+func TestTriggerThrottledOnQueueLength(t *testing.T) {
+	var triggers int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/queue/api/json":
+			w.Write([]byte(`{"items":[{},{},{}]}`))
+		case r.URL.Path == "/computer/api/json":
+			w.Write([]byte(`{"busyExecutors":2,"computer":[]}`))
+		case strings.HasSuffix(r.URL.Path, "/build") || strings.HasSuffix(r.URL.Path, "/buildWithParameters"):
+			triggers++
+			w.Header().Set("Location", "http://jenkins/queue/item/1/")
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := defaultTestConfig()
+	cfg.Jenkins.URL = server.URL
+	cfg.Server.MaxBodySize = 1024 * 1024
+	cfg.Throttle = config.ThrottleConfig{Enabled: true, QueueThreshold: 2, ResumeThreshold: 1, Policy: config.ThrottlePolicyReject, SampleInterval: 10}
+	router, cleanup := setupTestRouter(t, cfg)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/trigger/jenkins", strings.NewReader(`{"job":"deploy"}`))
+	req.Header.Set("Authorization", "Bearer test-key")
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Retry-After") != "10" {
+		t.Errorf("Expected Retry-After 10, got %q", rr.Header().Get("Retry-After"))
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body["code"] != "THROTTLED" || body["queue_length"] != float64(3) {
+		t.Errorf("Unexpected response: %v", body)
+	}
+	if triggers != 0 {
+		t.Errorf("Expected no build to reach Jenkins, got %d", triggers)
+	}
+
+	logs, err := storage.QueryAuditLogs(models.AuditFilter{APIKey: "test-key"}, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to query audit logs: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Result != "denied" || logs[0].Status != http.StatusTooManyRequests {
+		t.Errorf("Expected one denied audit entry with status 429, got %+v", logs)
+	}
+}
@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine/jenkins"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/storage/models"
+	"triggermesh/internal/timesync"
+	"triggermesh/internal/version"
+)
+
+// newSkewedJenkins returns a mock Jenkins whose Date header is ahead of the local clock by the
+// offset; a negative offset makes it answer with an error
+func newSkewedJenkins(t *testing.T, offset *atomic.Int64) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if offset.Load() < 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Date", time.Now().Add(time.Duration(offset.Load())).UTC().Format(http.TimeFormat))
+		w.Write([]byte(`{"mode":"NORMAL"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClockChecker(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-timesync-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	var offset atomic.Int64
+	offset.Store(int64(time.Minute))
+	server := newSkewedJenkins(t, &offset)
+	trigger := jenkins.NewTrigger(jenkins.NewClient(config.JenkinsConfig{URL: server.URL, Timeout: 5}))
+	checker := timesync.NewChecker(config.ClockConfig{MaxSkew: 5, CheckInterval: 600}, "jenkins", trigger)
+
+	if status := checker.Status(); status != nil {
+		t.Fatalf("Expected no status before the first check, got %+v", status)
+	}
+
+	// Jenkins is a minute ahead; the Date header has second precision
+	status := checker.Check()
+	if !status.Exceeded || status.Error != "" {
+		t.Fatalf("Expected the skew to be exceeded, got %+v", status)
+	}
+	if status.SkewMS < 59000 || status.SkewMS > 61000 {
+		t.Errorf("Expected a skew of about 60000ms, got %d", status.SkewMS)
+	}
+	if status.Source != "jenkins" || status.MaxSkewMS != 5000 {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+	checker.Check()
+
+	// The start of the skew is recorded once
+	entries, err := storage.GetConfigAudit(models.ConfigActionClockSkew, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get config audit: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Actor != "clock_check" {
+		t.Fatalf("Expected one clock_skew entry by clock_check, got %+v", entries)
+	}
+
+	// A failed check keeps the last skew and reports the error
+	offset.Store(-1)
+	status = checker.Check()
+	if status.Error == "" || !status.Exceeded || status.SkewMS < 59000 {
+		t.Errorf("Expected the error with the last skew, got %+v", status)
+	}
+
+	// Back in sync
+	offset.Store(0)
+	status = checker.Check()
+	if status.Exceeded || status.Error != "" {
+		t.Errorf("Expected the clocks in sync, got %+v", status)
+	}
+	if status.SkewMS < -2000 || status.SkewMS > 2000 {
+		t.Errorf("Expected a skew of about 0ms, got %d", status.SkewMS)
+	}
+	if got := checker.Status(); got == nil || *got != status {
+		t.Errorf("Expected Status to return the latest check, got %+v", got)
+	}
+}
+
+func TestSystemInfoEndpoint(t *testing.T) {
+	router, cleanup := setupTestRouter(t, defaultTestConfig())
+	defer cleanup()
+
+	get := func() handlers.SystemInfo {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/system/info", nil)
+		req.Header.Set("Authorization", "Bearer test-key")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var info handlers.SystemInfo
+		if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return info
+	}
+
+	info := get()
+	if info.Version != version.Version || info.GoVersion == "" || info.StartedAt.IsZero() {
+		t.Errorf("Unexpected system info: %+v", info)
+	}
+	if info.Clock != nil {
+		t.Errorf("Expected no clock status without a clock check, got %+v", info.Clock)
+	}
+
+	router.SetClockStatus(func() *timesync.Status {
+		return &timesync.Status{Source: "jenkins", SkewMS: -7200, MaxSkewMS: 5000, Exceeded: true}
+	})
+	info = get()
+	if info.Clock == nil || info.Clock.SkewMS != -7200 || !info.Clock.Exceeded {
+		t.Errorf("Expected the clock status, got %+v", info.Clock)
+	}
+
+	// Requires an API key
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/system/info", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without an API key, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,173 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/storage"
+	"triggermesh/internal/transform"
+)
+
+// newArtifactServer returns an artifact repository answering the latest version of each job
+func newArtifactServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("job") != "deploy/web" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"latest": {"version": "1.4.2", "build": 42}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTransformPipeline(t *testing.T) {
+	artifacts := newArtifactServer(t)
+	pipeline, err := transform.NewPipeline(config.TransformConfig{Rules: []config.TransformRuleConfig{
+		{
+			Jobs: []string{"deploy/*"},
+			Steps: []config.TransformStepConfig{
+				{Type: config.TransformTypeReplace, Param: "BRANCH", Pattern: "^refs/heads/", Replacement: ""},
+				{Type: config.TransformTypeSet, Param: "TARGET", Value: "{job}@{param:BRANCH}"},
+				{Type: config.TransformTypeTimestamp, Param: "TRIGGERED_AT", Format: time.RFC3339},
+				{Type: config.TransformTypeHTTPLookup, Param: "ARTIFACT_VERSION", URL: artifacts.URL + "/latest?job={job}", Field: "latest.version", Timeout: 5},
+			},
+		},
+		{
+			Steps: []config.TransformStepConfig{
+				{Type: config.TransformTypeSet, Param: "SOURCE", Value: "triggermesh"},
+			},
+		},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create pipeline: %v", err)
+	}
+
+	t.Run("Matching job", func(t *testing.T) {
+		params := map[string]string{"BRANCH": "refs/heads/main", "SOURCE": "ci"}
+		out, err := pipeline.Apply(context.Background(), "deploy/web", params)
+		if err != nil {
+			t.Fatalf("Failed to apply pipeline: %v", err)
+		}
+		if out["BRANCH"] != "main" || out["TARGET"] != "deploy/web@main" || out["ARTIFACT_VERSION"] != "1.4.2" {
+			t.Errorf("Unexpected parameters: %v", out)
+		}
+		if _, err := time.Parse(time.RFC3339, out["TRIGGERED_AT"]); err != nil {
+			t.Errorf("Expected RFC 3339 timestamp, got %q", out["TRIGGERED_AT"])
+		}
+		if out["SOURCE"] != "ci" {
+			t.Errorf("Expected request value to be kept without overwrite, got %q", out["SOURCE"])
+		}
+		if params["BRANCH"] != "refs/heads/main" || len(params) != 2 {
+			t.Errorf("Expected input parameters to be left untouched, got %v", params)
+		}
+	})
+
+	t.Run("Non-matching job", func(t *testing.T) {
+		out, err := pipeline.Apply(context.Background(), "build/web", map[string]string{"BRANCH": "refs/heads/main"})
+		if err != nil {
+			t.Fatalf("Failed to apply pipeline: %v", err)
+		}
+		if out["BRANCH"] != "refs/heads/main" || out["SOURCE"] != "triggermesh" || len(out) != 2 {
+			t.Errorf("Expected only the catch-all rule to apply, got %v", out)
+		}
+	})
+
+	t.Run("Lookup failure", func(t *testing.T) {
+		var transformErr *transform.Error
+		_, err := pipeline.Apply(context.Background(), "deploy/api", nil)
+		if !errors.As(err, &transformErr) || transformErr.Param != "ARTIFACT_VERSION" {
+			t.Errorf("Expected transform error for ARTIFACT_VERSION, got %v", err)
+		}
+	})
+
+	t.Run("Custom transformers compose", func(t *testing.T) {
+		chain := transform.Chain{
+			&transform.Set{Param: "ENV", Value: "prod"},
+			transform.Func(func(ctx context.Context, job string, params map[string]string) error {
+				params["ENV_UPPER"] = params["ENV"] + "!"
+				return nil
+			}),
+		}
+		params := map[string]string{}
+		if err := chain.Transform(context.Background(), "deploy/web", params); err != nil || params["ENV_UPPER"] != "prod!" {
+			t.Errorf("Unexpected chain result %v (err %v)", params, err)
+		}
+	})
+}
+
+func TestTriggerParameterTransforms(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "test-transform-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if err := storage.Init(tmpFile.Name()); err != nil {
+		t.Fatalf("Failed to init storage: %v", err)
+	}
+	defer storage.Close()
+
+	artifacts := newArtifactServer(t)
+	var dispatched map[string]string
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			dispatched = params
+			return &engine.BuildResult{Success: true, BuildID: jobName + "/1"}, nil
+		},
+	})
+	pipeline, err := transform.NewPipeline(config.TransformConfig{Rules: []config.TransformRuleConfig{{
+		Steps: []config.TransformStepConfig{
+			{Type: config.TransformTypeHTTPLookup, Param: "ARTIFACT_VERSION", URL: artifacts.URL + "/latest?job={job}", Field: "latest.version", Timeout: 5},
+		},
+	}}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create pipeline: %v", err)
+	}
+	handler.SetTransformPipeline(pipeline)
+
+	rr := httptest.NewRecorder()
+	handler.TriggerJenkinsBuild(rr, newLabelTriggerRequest(`{"job":"deploy/web","parameters":{"BRANCH":"main"}}`))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if dispatched["ARTIFACT_VERSION"] != "1.4.2" || dispatched["BRANCH"] != "main" {
+		t.Errorf("Expected enriched parameters to be dispatched, got %v", dispatched)
+	}
+
+	logs, err := storage.GetAuditLogs(1, 0)
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("Expected audit log, got %v (err %v)", logs, err)
+	}
+	var recorded map[string]string
+	if err := json.Unmarshal([]byte(logs[0].Params), &recorded); err != nil || recorded["ARTIFACT_VERSION"] != "1.4.2" {
+		t.Errorf("Expected the audit log to record dispatched parameters, got %q", logs[0].Params)
+	}
+
+	dispatched = nil
+	rr = httptest.NewRecorder()
+	handler.TriggerJenkinsBuild(rr, newLabelTriggerRequest(`{"job":"deploy/api"}`))
+	if rr.Code != http.StatusBadGateway {
+		t.Fatalf("Expected status 502, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp["code"] != "PARAMETER_TRANSFORM_FAILED" {
+		t.Errorf("Expected code PARAMETER_TRANSFORM_FAILED, got %v", resp["code"])
+	}
+	if dispatched != nil {
+		t.Error("Expected the trigger not to reach the engine")
+	}
+}
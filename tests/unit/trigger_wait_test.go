@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"triggermesh/internal/api/handlers"
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/engine/jenkins"
+)
+
+// postTrigger sends a trigger request body to the handler
+func postTrigger(handler *handlers.JenkinsHandler, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	handler.TriggerJenkinsBuild(rr, httptest.NewRequest("POST", "/api/v1/trigger/jenkins", bytes.NewReader([]byte(body))))
+	return rr
+}
+
+func TestTriggerAndWait(t *testing.T) {
+	cleanup := setupStatsStorage(t)
+	defer cleanup()
+
+	// The trigger waits in the queue for one poll, then the build runs for one more
+	var queuePolls, buildPolls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/deploy/build":
+			w.Header().Set("Location", "/queue/item/7/")
+			w.WriteHeader(http.StatusCreated)
+		case "/queue/item/7/api/json":
+			if atomic.AddInt32(&queuePolls, 1) == 1 {
+				w.Write([]byte(`{"cancelled":false,"task":{"name":"deploy"}}`))
+				return
+			}
+			w.Write([]byte(`{"cancelled":false,"task":{"name":"deploy"},"executable":{"number":12,"url":"http://jenkins/job/deploy/12/"}}`))
+		case "/job/deploy/12/api/json":
+			if atomic.AddInt32(&buildPolls, 1) == 1 {
+				w.Write([]byte(`{"number":12,"building":true,"result":null}`))
+				return
+			}
+			w.Write([]byte(`{"number":12,"building":false,"result":"FAILURE","duration":4000}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	trigger := jenkins.NewTrigger(jenkins.NewClient(config.JenkinsConfig{URL: server.URL, Username: "u", Token: "t", Timeout: 5}))
+	handler := handlers.NewJenkinsHandler(trigger)
+	handler.SetWaitLimits(5*time.Second, 10*time.Millisecond)
+
+	t.Run("Without wait", func(t *testing.T) {
+		rr := postTrigger(handler, `{"job":"deploy"}`)
+		var resp handlers.TriggerJenkinsBuildResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		if resp.QueueID != "7" || resp.BuildID != "" || resp.WaitStatus != "" {
+			t.Errorf("Expected the queue item only, got %+v", resp.BuildResult)
+		}
+	})
+
+	t.Run("Waits for the build to finish", func(t *testing.T) {
+		rr := postTrigger(handler, `{"job":"deploy","wait":true}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var resp handlers.TriggerJenkinsBuildResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if resp.WaitStatus != handlers.WaitCompleted || resp.BuildID != "deploy/12" || resp.Result != engine.ResultFailure || resp.BuildDurationMS != 4000 {
+			t.Errorf("Unexpected result: %+v (wait status %s)", resp.BuildResult, resp.WaitStatus)
+		}
+	})
+}
+
+func TestTriggerAndWaitInProgress(t *testing.T) {
+	cleanup := setupStatsStorage(t)
+	defer cleanup()
+
+	handler := handlers.NewJenkinsHandler(&MockCIEngine{
+		TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			return &engine.BuildResult{Success: true, BuildID: jobName + "/3"}, nil
+		},
+		GetBuildStatusFunc: func(buildID string) (*engine.BuildResult, error) {
+			return &engine.BuildResult{Success: true, BuildID: buildID, Building: true}, nil
+		},
+	})
+	handler.SetWaitLimits(time.Minute, 10*time.Millisecond)
+
+	started := time.Now()
+	rr := postTrigger(handler, `{"job":"deploy","wait":true,"max_wait":1}`)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if elapsed := time.Since(started); elapsed < time.Second || elapsed > 5*time.Second {
+		t.Errorf("Expected to wait about max_wait, waited %s", elapsed)
+	}
+	var resp handlers.TriggerJenkinsBuildResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.WaitStatus != handlers.WaitInProgress || !resp.Building || resp.BuildID != "deploy/3" {
+		t.Errorf("Expected a running build, got %+v (wait status %s)", resp.BuildResult, resp.WaitStatus)
+	}
+
+	for _, body := range []string{
+		`{"job":"deploy","max_wait":10}`,
+		`{"job":"deploy","wait":true,"max_wait":61}`,
+		`{"job":"deploy","wait":true,"max_wait":-1}`,
+		`{"job":"deploy","wait":true,"not_before":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`,
+	} {
+		if rr := postTrigger(handler, body); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "wait") {
+			t.Errorf("Expected status 400 for %s, got %d: %s", body, rr.Code, rr.Body.String())
+		}
+	}
+}
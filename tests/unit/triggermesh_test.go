@@ -0,0 +1,566 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"triggermesh/internal/config"
+	"triggermesh/internal/engine"
+	"triggermesh/internal/leakcheck"
+	"triggermesh/pkg/triggermesh"
+)
+
+// memoryStore is an in-memory triggermesh.Storage
+type memoryStore struct {
+	mu     sync.Mutex
+	logs   []triggermesh.AuditLog
+	closed bool
+}
+
+func (m *memoryStore) InsertAuditLog(log triggermesh.AuditLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	log.ID = int64(len(m.logs) + 1)
+	m.logs = append(m.logs, log)
+	return nil
+}
+
+func (m *memoryStore) GetAuditLogs(limit, offset int) ([]triggermesh.AuditLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var logs []triggermesh.AuditLog
+	for i := len(m.logs) - 1 - offset; i >= 0 && len(logs) < limit; i-- {
+		logs = append(logs, m.logs[i])
+	}
+	return logs, nil
+}
+
+func (m *memoryStore) Ping() error { return nil }
+
+func (m *memoryStore) Close() error {
+	m.closed = true
+	return nil
+}
+
+func TestEngineRegistry(t *testing.T) {
+	registry := engine.NewRegistry()
+
+	if err := registry.Register("jenkins", &MockCIEngine{}); err != nil {
+		t.Fatalf("Failed to register engine: %v", err)
+	}
+	if err := registry.Register("other", &MockCIEngine{}); err != nil {
+		t.Fatalf("Failed to register engine: %v", err)
+	}
+	if err := registry.Register("jenkins", &MockCIEngine{}); err == nil {
+		t.Error("Expected error registering a duplicate engine name")
+	}
+	if err := registry.Register("", &MockCIEngine{}); err == nil {
+		t.Error("Expected error registering an empty engine name")
+	}
+	if err := registry.Register("nil", nil); err == nil {
+		t.Error("Expected error registering a nil engine")
+	}
+
+	if _, ok := registry.Get("other"); !ok {
+		t.Error("Expected registered engine to be found")
+	}
+	if _, ok := registry.Get("missing"); ok {
+		t.Error("Expected unknown engine not to be found")
+	}
+
+	names := registry.Names()
+	if len(names) != 2 || names[0] != "jenkins" || names[1] != "other" {
+		t.Errorf("Expected names [jenkins other], got %v", names)
+	}
+}
+
+func TestEmbeddedServerWithCustomEngineAndStorage(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.MaxBodySize = 1 << 20
+	store := &memoryStore{}
+
+	triggered := ""
+	srv, err := triggermesh.NewServer(&cfg,
+		triggermesh.WithStorage(store),
+		triggermesh.WithEngine(triggermesh.JenkinsEngine, &MockCIEngine{
+			TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+				triggered = jobName
+				return &engine.BuildResult{Success: true, BuildID: jobName + "/1"}, nil
+			},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]string{"job": "embedded-job"})
+	req := httptest.NewRequest("POST", "/api/v1/trigger/jenkins", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if triggered != "embedded-job" {
+		t.Errorf("Expected custom engine to be used, got job %q", triggered)
+	}
+	if len(store.logs) != 1 || store.logs[0].JobName != "embedded-job" {
+		t.Errorf("Expected audit log in custom storage, got %+v", store.logs)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/audit", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected audit query to be served from custom storage, got %d", rr.Code)
+	}
+
+	if err := srv.Close(); err != nil {
+		t.Errorf("Failed to close server: %v", err)
+	}
+	if !store.closed {
+		t.Error("Expected custom storage to be closed")
+	}
+}
+
+func TestEmbeddedServerRejectsArchiveWithCustomStorage(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Archive.Enabled = true
+
+	if _, err := triggermesh.NewServer(&cfg, triggermesh.WithStorage(&memoryStore{})); err == nil {
+		t.Error("Expected error enabling audit archiving with custom storage")
+	}
+}
+
+func TestEmbeddedServerRunsHooks(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Server.Port = 0
+
+	var events []string
+	srv, err := triggermesh.NewServer(&cfg,
+		triggermesh.WithStorage(&memoryStore{}),
+		triggermesh.WithHook(recordingHook("poller", &events, nil)),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run(ctx)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+
+	expected := []string{"start poller", "stop poller"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected events %v, got %v", expected, events)
+	}
+
+	if _, err := triggermesh.NewServer(&cfg, triggermesh.WithHook(triggermesh.Hook{})); err == nil {
+		t.Error("Expected error for unnamed hook")
+	}
+}
+
+// pingableEngine is a MockCIEngine that also implements engine.Pinger
+type pingableEngine struct {
+	MockCIEngine
+	pingErr error
+}
+
+func (p *pingableEngine) Ping() error { return p.pingErr }
+
+// serveStatus sends an authenticated GET through the handler and returns the status code and body
+func serveStatus(handler http.Handler, path string) (int, map[string]interface{}) {
+	req := httptest.NewRequest("GET", path, nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	var body map[string]interface{}
+	_ = json.Unmarshal(rr.Body.Bytes(), &body)
+	return rr.Code, body
+}
+
+func TestEmbeddedServerReadinessGating(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Server.Port = 0
+	cfg.Server.ReadinessGating = true
+	cfg.Database.Path = t.TempDir() + "/gated.db"
+
+	srv, err := triggermesh.NewServer(&cfg, triggermesh.WithEngine(triggermesh.JenkinsEngine, &pingableEngine{}))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	handler := srv.Handler()
+
+	// Before startup checks run: not ready, API held back, but alive
+	if code, _ := serveStatus(handler, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected /readyz 503 before startup, got %d", code)
+	}
+	if code, _ := serveStatus(handler, "/api/v1/audit"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected API 503 before startup, got %d", code)
+	}
+	if code, body := serveStatus(handler, "/health"); code != http.StatusOK || body["status"] != "starting" {
+		t.Errorf("Expected /health 200 starting, got %d %v", code, body)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run(ctx)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		code, body := serveStatus(handler, "/readyz")
+		if code == http.StatusOK {
+			checks, _ := body["checks"].(map[string]interface{})
+			if checks["storage"] != "ok" || checks["engine:jenkins"] != "ok" {
+				t.Errorf("Expected passed startup checks, got %v", body)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Server did not become ready: %d %v", code, body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if code, _ := serveStatus(handler, "/api/v1/audit"); code != http.StatusOK {
+		t.Errorf("Expected API 200 once ready, got %d", code)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+}
+
+func TestEmbeddedServerReadinessGatingEngineUnreachable(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Server.Port = 0
+	cfg.Server.ReadinessGating = true
+
+	srv, err := triggermesh.NewServer(&cfg,
+		triggermesh.WithStorage(&memoryStore{}),
+		triggermesh.WithEngine(triggermesh.JenkinsEngine, &pingableEngine{pingErr: engine.ErrServer}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run(ctx)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, body := serveStatus(srv.Handler(), "/readyz")
+		checks, _ := body["checks"].(map[string]interface{})
+		if checks["engine:jenkins"] == "failed" {
+			if body["status"] != "not_ready" {
+				t.Errorf("Expected not_ready while the engine is unreachable, got %v", body)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Engine check was not reported as failed: %v", body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Cancelling during startup checks shuts down cleanly
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
+
+func TestEmbeddedServerEngineCheckFailFast(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Server.Port = 0
+	cfg.Server.EngineCheck = config.EngineCheckFailFast
+
+	srv, err := triggermesh.NewServer(&cfg,
+		triggermesh.WithStorage(&memoryStore{}),
+		triggermesh.WithEngine(triggermesh.JenkinsEngine, &pingableEngine{}),
+		triggermesh.WithEngine("tekton", &pingableEngine{pingErr: engine.ErrAuth}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	// An unreachable engine fails startup before the listener is bound
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run(context.Background())
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "tekton") || strings.Contains(err.Error(), "jenkins:") {
+			t.Fatalf("Expected startup to fail naming tekton only, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not fail on the unreachable engine")
+	}
+
+	_, body := serveStatus(srv.Handler(), "/readyz")
+	engines, _ := body["engines"].(map[string]interface{})
+	if engines["jenkins"] != "ok" || engines["tekton"] != "failed" {
+		t.Errorf("Expected the engine states in /readyz, got %v", body)
+	}
+}
+
+func TestEmbeddedServerEngineCheckLazy(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Server.Port = 0
+	cfg.Server.MaxBodySize = 1 << 20
+
+	srv, err := triggermesh.NewServer(&cfg,
+		triggermesh.WithStorage(&memoryStore{}),
+		triggermesh.WithEngine(triggermesh.JenkinsEngine, &pingableEngine{pingErr: engine.ErrServer}),
+		triggermesh.WithEngine("tekton", &MockCIEngine{TriggerBuildFunc: func(jobName string, params map[string]string) (*engine.BuildResult, error) {
+			return nil, engine.NewError(engine.ErrorKindAuth, "authentication failed: invalid credentials")
+		}}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	handler := srv.Handler()
+
+	// Engines are not checked at startup, even when a check would fail
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run(ctx)
+	}()
+
+	engineStates := func() (int, map[string]interface{}) {
+		code, body := serveStatus(handler, "/readyz")
+		engines, _ := body["engines"].(map[string]interface{})
+		return code, engines
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if code, engines := engineStates(); engines["jenkins"] == "unchecked" && engines["tekton"] == "unchecked" {
+			if code != http.StatusOK {
+				t.Errorf("Expected /readyz 200 with unchecked engines, got %d", code)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Engines were not reported as unchecked")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The first call reports each engine's state; failures do not affect readiness
+	trigger := func(name string) {
+		req := httptest.NewRequest("POST", "/api/v1/trigger/"+name, strings.NewReader(`{"job":"build-web"}`))
+		req.Header.Set("Authorization", "Bearer test-key")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	trigger("jenkins")
+	trigger("tekton")
+	code, engines := engineStates()
+	if code != http.StatusOK || engines["jenkins"] != "ok" || engines["tekton"] != "failed" {
+		t.Errorf("Expected jenkins ok and tekton failed with /readyz 200, got %d %v", code, engines)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+}
+
+func TestEmbeddedServerListenUnixSocket(t *testing.T) {
+	// Unix socket paths are limited to about 100 bytes, too short for some t.TempDir paths
+	dir, err := os.MkdirTemp("", "tm")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "api.sock")
+
+	cfg := defaultTestConfig()
+	cfg.Server.Listen = []string{"unix:" + socket}
+	cfg.Server.SocketMode = "0600"
+	srv, err := triggermesh.NewServer(&cfg, triggermesh.WithStorage(&memoryStore{}))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run(ctx)
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := client.Get("http://triggermesh/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Expected /health 200 over the Unix socket, got %d", resp.StatusCode)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Server did not listen on the Unix socket: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if info, err := os.Stat(socket); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected socket mode 0600, got %v, %v", info, err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("Expected the socket to be removed on shutdown, got %v", err)
+	}
+}
+
+func TestEmbeddedServerStartStopDoesNotLeak(t *testing.T) {
+	leakcheck.Verify(t)
+
+	for cycle := 0; cycle < 2; cycle++ {
+		cfg := defaultTestConfig()
+		cfg.Server.Listen = []string{"tcp:127.0.0.1:0"}
+		srv, err := triggermesh.NewServer(&cfg, triggermesh.WithStorage(&memoryStore{}))
+		if err != nil {
+			t.Fatalf("Cycle %d: failed to create server: %v", cycle, err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- srv.Run(ctx)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+		if err := <-done; err != nil {
+			t.Fatalf("Cycle %d: Run returned error: %v", cycle, err)
+		}
+	}
+}
+
+func TestEmbeddedServerAdditionalListeners(t *testing.T) {
+	dir, err := os.MkdirTemp("", "tm")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	apiSocket := filepath.Join(dir, "api.sock")
+	healthSocket := filepath.Join(dir, "health.sock")
+
+	cfg := defaultTestConfig()
+	cfg.Server.Listen = []string{"unix:" + apiSocket}
+	cfg.Server.Listeners = []config.ListenerConfig{
+		{Name: "health", Listen: []string{"unix:" + healthSocket}, Routes: []string{"/health"}},
+	}
+	srv, err := triggermesh.NewServer(&cfg, triggermesh.WithStorage(&memoryStore{}))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run(ctx)
+	}()
+
+	unixClient := func(socket string) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		}}
+	}
+	get := func(socket, path string) int {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			resp, err := unixClient(socket).Get("http://triggermesh" + path)
+			if err == nil {
+				resp.Body.Close()
+				return resp.StatusCode
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Server did not listen on %s: %v", socket, err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	if code := get(healthSocket, "/health"); code != http.StatusOK {
+		t.Errorf("Expected /health 200 on the health listener, got %d", code)
+	}
+	if code := get(healthSocket, "/"); code != http.StatusNotFound {
+		t.Errorf("Expected / 404 on the health listener, got %d", code)
+	}
+	if code := get(apiSocket, "/"); code != http.StatusOK {
+		t.Errorf("Expected / 200 on the API listener, got %d", code)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+}
+
+func TestEmbeddedServerListenSystemdWithoutSockets(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	cfg := defaultTestConfig()
+	cfg.Server.Listen = []string{"systemd"}
+	srv, err := triggermesh.NewServer(&cfg, triggermesh.WithStorage(&memoryStore{}))
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if err := srv.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "LISTEN_FDS") {
+		t.Errorf("Expected an error without systemd sockets, got %v", err)
+	}
+}
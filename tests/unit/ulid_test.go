@@ -0,0 +1,35 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"triggermesh/internal/ulid"
+)
+
+func TestULID(t *testing.T) {
+	at := time.UnixMilli(1700000000123)
+	id := ulid.NewAt(at)
+	if len(id) != ulid.Length || !ulid.Valid(id) {
+		t.Fatalf("Expected a valid ULID, got %q", id)
+	}
+	got, err := ulid.Time(strings.ToLower(id))
+	if err != nil || !got.Equal(at) {
+		t.Errorf("Expected timestamp %v, got %v (err %v)", at, got, err)
+	}
+
+	// IDs sort by creation time
+	if later := ulid.NewAt(at.Add(time.Millisecond)); later <= id {
+		t.Errorf("Expected %q to sort after %q", later, id)
+	}
+	if ulid.New() == ulid.New() {
+		t.Error("Expected distinct ULIDs")
+	}
+
+	for _, invalid := range []string{"", "01HEXEC", "8ZZZZZZZZZZZZZZZZZZZZZZZZZ", "01ARZ3NDEKTSV4RRFFQ69G5FAU", "01ARZ3NDEKTSV4RRFFQ69G5FA/"} {
+		if ulid.Valid(invalid) {
+			t.Errorf("Expected %q to be invalid", invalid)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"triggermesh/internal/workpool"
+)
+
+func TestWorkpoolBoundsConcurrency(t *testing.T) {
+	pool := workpool.New(context.Background(), 2, 0)
+
+	var running, peak, done int32
+	for i := 0; i < 8; i++ {
+		pool.Go(func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			atomic.AddInt32(&done, 1)
+			return nil
+		})
+	}
+	if err := pool.Wait(); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+	if done != 8 || peak != 2 {
+		t.Errorf("Expected 8 tasks with at most 2 at once, got %d tasks with %d at once", done, peak)
+	}
+}
+
+func TestWorkpoolTaskTimeoutAndErrors(t *testing.T) {
+	pool := workpool.New(context.Background(), 0, 20*time.Millisecond)
+	boom := errors.New("boom")
+
+	pool.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	var others int32
+	for i := 0; i < 3; i++ {
+		pool.Go(func(ctx context.Context) error {
+			time.Sleep(40 * time.Millisecond)
+			atomic.AddInt32(&others, 1)
+			return boom
+		})
+	}
+
+	// A failing task does not cancel the others; Wait returns the first error
+	err := pool.Wait()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the timed out task's error first, got %v", err)
+	}
+	if others != 3 {
+		t.Errorf("Expected the other tasks to finish, got %d", others)
+	}
+}
+
+func TestWorkpoolTryGo(t *testing.T) {
+	pool := workpool.New(context.Background(), 1, 0)
+	release := make(chan struct{})
+	if !pool.TryGo(func(ctx context.Context) error {
+		<-release
+		return nil
+	}) {
+		t.Fatal("Expected the first task to start")
+	}
+	if pool.TryGo(func(ctx context.Context) error { return nil }) {
+		t.Error("Expected TryGo to refuse a task while the pool is full")
+	}
+	close(release)
+	if err := pool.Wait(); err != nil {
+		t.Fatalf("Wait returned error: %v", err)
+	}
+}